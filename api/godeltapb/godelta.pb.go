@@ -0,0 +1,1463 @@
+// api/godeltapb/godelta.proto
+//
+// Remote control API for go-delta, served by `godelta daemon`.
+// Every RPC streams the same progress events the library reports through its
+// ProgressCallback, followed by a single final result message.
+//
+// Regenerate the Go code with:
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//          --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//          api/godeltapb/godelta.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        v5.29.3
+// source: api/godeltapb/godelta.proto
+
+package godeltapb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// EventType mirrors the library event types. Compress and decompress share
+// START..ERROR; DICT_TRAINING is compress-only, FILE_VERIFY and CHUNK_VERIFY
+// come from verify and list.
+type EventType int32
+
+const (
+	EventType_EVENT_TYPE_UNSPECIFIED   EventType = 0
+	EventType_EVENT_TYPE_START         EventType = 1
+	EventType_EVENT_TYPE_FILE_START    EventType = 2
+	EventType_EVENT_TYPE_FILE_PROGRESS EventType = 3
+	EventType_EVENT_TYPE_FILE_COMPLETE EventType = 4
+	EventType_EVENT_TYPE_COMPLETE      EventType = 5
+	EventType_EVENT_TYPE_ERROR         EventType = 6
+	EventType_EVENT_TYPE_DICT_TRAINING EventType = 7
+	EventType_EVENT_TYPE_FILE_VERIFY   EventType = 8
+	EventType_EVENT_TYPE_CHUNK_VERIFY  EventType = 9
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0: "EVENT_TYPE_UNSPECIFIED",
+		1: "EVENT_TYPE_START",
+		2: "EVENT_TYPE_FILE_START",
+		3: "EVENT_TYPE_FILE_PROGRESS",
+		4: "EVENT_TYPE_FILE_COMPLETE",
+		5: "EVENT_TYPE_COMPLETE",
+		6: "EVENT_TYPE_ERROR",
+		7: "EVENT_TYPE_DICT_TRAINING",
+		8: "EVENT_TYPE_FILE_VERIFY",
+		9: "EVENT_TYPE_CHUNK_VERIFY",
+	}
+	EventType_value = map[string]int32{
+		"EVENT_TYPE_UNSPECIFIED":   0,
+		"EVENT_TYPE_START":         1,
+		"EVENT_TYPE_FILE_START":    2,
+		"EVENT_TYPE_FILE_PROGRESS": 3,
+		"EVENT_TYPE_FILE_COMPLETE": 4,
+		"EVENT_TYPE_COMPLETE":      5,
+		"EVENT_TYPE_ERROR":         6,
+		"EVENT_TYPE_DICT_TRAINING": 7,
+		"EVENT_TYPE_FILE_VERIFY":   8,
+		"EVENT_TYPE_CHUNK_VERIFY":  9,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_godeltapb_godelta_proto_enumTypes[0].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_api_godeltapb_godelta_proto_enumTypes[0]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_api_godeltapb_godelta_proto_rawDescGZIP(), []int{0}
+}
+
+type ProgressEvent struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Type             EventType              `protobuf:"varint,1,opt,name=type,proto3,enum=godelta.v1.EventType" json:"type,omitempty"`
+	FilePath         string                 `protobuf:"bytes,2,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`
+	Current          int64                  `protobuf:"varint,3,opt,name=current,proto3" json:"current,omitempty"`
+	Total            int64                  `protobuf:"varint,4,opt,name=total,proto3" json:"total,omitempty"`
+	CurrentBytes     uint64                 `protobuf:"varint,5,opt,name=current_bytes,json=currentBytes,proto3" json:"current_bytes,omitempty"`
+	TotalBytes       uint64                 `protobuf:"varint,6,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	CompressedSize   uint64                 `protobuf:"varint,7,opt,name=compressed_size,json=compressedSize,proto3" json:"compressed_size,omitempty"`       // compress only
+	DecompressedSize uint64                 `protobuf:"varint,8,opt,name=decompressed_size,json=decompressedSize,proto3" json:"decompressed_size,omitempty"` // decompress only
+	Message          string                 `protobuf:"bytes,9,opt,name=message,proto3" json:"message,omitempty"`                                            // verify only
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ProgressEvent) Reset() {
+	*x = ProgressEvent{}
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProgressEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProgressEvent) ProtoMessage() {}
+
+func (x *ProgressEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProgressEvent.ProtoReflect.Descriptor instead.
+func (*ProgressEvent) Descriptor() ([]byte, []int) {
+	return file_api_godeltapb_godelta_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ProgressEvent) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *ProgressEvent) GetFilePath() string {
+	if x != nil {
+		return x.FilePath
+	}
+	return ""
+}
+
+func (x *ProgressEvent) GetCurrent() int64 {
+	if x != nil {
+		return x.Current
+	}
+	return 0
+}
+
+func (x *ProgressEvent) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ProgressEvent) GetCurrentBytes() uint64 {
+	if x != nil {
+		return x.CurrentBytes
+	}
+	return 0
+}
+
+func (x *ProgressEvent) GetTotalBytes() uint64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+func (x *ProgressEvent) GetCompressedSize() uint64 {
+	if x != nil {
+		return x.CompressedSize
+	}
+	return 0
+}
+
+func (x *ProgressEvent) GetDecompressedSize() uint64 {
+	if x != nil {
+		return x.DecompressedSize
+	}
+	return 0
+}
+
+func (x *ProgressEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type CompressRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	InputPath       string                 `protobuf:"bytes,1,opt,name=input_path,json=inputPath,proto3" json:"input_path,omitempty"`
+	Files           []string               `protobuf:"bytes,2,rep,name=files,proto3" json:"files,omitempty"` // overrides input_path when set
+	OutputPath      string                 `protobuf:"bytes,3,opt,name=output_path,json=outputPath,proto3" json:"output_path,omitempty"`
+	MaxThreads      int32                  `protobuf:"varint,4,opt,name=max_threads,json=maxThreads,proto3" json:"max_threads,omitempty"`
+	Parallelism     string                 `protobuf:"bytes,5,opt,name=parallelism,proto3" json:"parallelism,omitempty"` // "auto", "folder" or "file"
+	MaxThreadMemory uint64                 `protobuf:"varint,6,opt,name=max_thread_memory,json=maxThreadMemory,proto3" json:"max_thread_memory,omitempty"`
+	ChunkSize       uint64                 `protobuf:"varint,7,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
+	ChunkStoreSize  uint64                 `protobuf:"varint,8,opt,name=chunk_store_size,json=chunkStoreSize,proto3" json:"chunk_store_size,omitempty"`
+	Level           int32                  `protobuf:"varint,9,opt,name=level,proto3" json:"level,omitempty"`
+	Zip             bool                   `protobuf:"varint,10,opt,name=zip,proto3" json:"zip,omitempty"`
+	Xz              bool                   `protobuf:"varint,11,opt,name=xz,proto3" json:"xz,omitempty"`
+	Dictionary      bool                   `protobuf:"varint,12,opt,name=dictionary,proto3" json:"dictionary,omitempty"`
+	DryRun          bool                   `protobuf:"varint,13,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	Gitignore       bool                   `protobuf:"varint,14,opt,name=gitignore,proto3" json:"gitignore,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CompressRequest) Reset() {
+	*x = CompressRequest{}
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompressRequest) ProtoMessage() {}
+
+func (x *CompressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompressRequest.ProtoReflect.Descriptor instead.
+func (*CompressRequest) Descriptor() ([]byte, []int) {
+	return file_api_godeltapb_godelta_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CompressRequest) GetInputPath() string {
+	if x != nil {
+		return x.InputPath
+	}
+	return ""
+}
+
+func (x *CompressRequest) GetFiles() []string {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *CompressRequest) GetOutputPath() string {
+	if x != nil {
+		return x.OutputPath
+	}
+	return ""
+}
+
+func (x *CompressRequest) GetMaxThreads() int32 {
+	if x != nil {
+		return x.MaxThreads
+	}
+	return 0
+}
+
+func (x *CompressRequest) GetParallelism() string {
+	if x != nil {
+		return x.Parallelism
+	}
+	return ""
+}
+
+func (x *CompressRequest) GetMaxThreadMemory() uint64 {
+	if x != nil {
+		return x.MaxThreadMemory
+	}
+	return 0
+}
+
+func (x *CompressRequest) GetChunkSize() uint64 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+func (x *CompressRequest) GetChunkStoreSize() uint64 {
+	if x != nil {
+		return x.ChunkStoreSize
+	}
+	return 0
+}
+
+func (x *CompressRequest) GetLevel() int32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *CompressRequest) GetZip() bool {
+	if x != nil {
+		return x.Zip
+	}
+	return false
+}
+
+func (x *CompressRequest) GetXz() bool {
+	if x != nil {
+		return x.Xz
+	}
+	return false
+}
+
+func (x *CompressRequest) GetDictionary() bool {
+	if x != nil {
+		return x.Dictionary
+	}
+	return false
+}
+
+func (x *CompressRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *CompressRequest) GetGitignore() bool {
+	if x != nil {
+		return x.Gitignore
+	}
+	return false
+}
+
+type CompressResult struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	FilesTotal     int64                  `protobuf:"varint,1,opt,name=files_total,json=filesTotal,proto3" json:"files_total,omitempty"`
+	FilesProcessed int64                  `protobuf:"varint,2,opt,name=files_processed,json=filesProcessed,proto3" json:"files_processed,omitempty"`
+	OriginalSize   uint64                 `protobuf:"varint,3,opt,name=original_size,json=originalSize,proto3" json:"original_size,omitempty"`
+	CompressedSize uint64                 `protobuf:"varint,4,opt,name=compressed_size,json=compressedSize,proto3" json:"compressed_size,omitempty"`
+	TotalChunks    uint64                 `protobuf:"varint,5,opt,name=total_chunks,json=totalChunks,proto3" json:"total_chunks,omitempty"`
+	UniqueChunks   uint64                 `protobuf:"varint,6,opt,name=unique_chunks,json=uniqueChunks,proto3" json:"unique_chunks,omitempty"`
+	DedupedChunks  uint64                 `protobuf:"varint,7,opt,name=deduped_chunks,json=dedupedChunks,proto3" json:"deduped_chunks,omitempty"`
+	BytesSaved     uint64                 `protobuf:"varint,8,opt,name=bytes_saved,json=bytesSaved,proto3" json:"bytes_saved,omitempty"`
+	Errors         []string               `protobuf:"bytes,9,rep,name=errors,proto3" json:"errors,omitempty"`
+	Summary        string                 `protobuf:"bytes,10,opt,name=summary,proto3" json:"summary,omitempty"` // same text the CLI prints
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CompressResult) Reset() {
+	*x = CompressResult{}
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompressResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompressResult) ProtoMessage() {}
+
+func (x *CompressResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompressResult.ProtoReflect.Descriptor instead.
+func (*CompressResult) Descriptor() ([]byte, []int) {
+	return file_api_godeltapb_godelta_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CompressResult) GetFilesTotal() int64 {
+	if x != nil {
+		return x.FilesTotal
+	}
+	return 0
+}
+
+func (x *CompressResult) GetFilesProcessed() int64 {
+	if x != nil {
+		return x.FilesProcessed
+	}
+	return 0
+}
+
+func (x *CompressResult) GetOriginalSize() uint64 {
+	if x != nil {
+		return x.OriginalSize
+	}
+	return 0
+}
+
+func (x *CompressResult) GetCompressedSize() uint64 {
+	if x != nil {
+		return x.CompressedSize
+	}
+	return 0
+}
+
+func (x *CompressResult) GetTotalChunks() uint64 {
+	if x != nil {
+		return x.TotalChunks
+	}
+	return 0
+}
+
+func (x *CompressResult) GetUniqueChunks() uint64 {
+	if x != nil {
+		return x.UniqueChunks
+	}
+	return 0
+}
+
+func (x *CompressResult) GetDedupedChunks() uint64 {
+	if x != nil {
+		return x.DedupedChunks
+	}
+	return 0
+}
+
+func (x *CompressResult) GetBytesSaved() uint64 {
+	if x != nil {
+		return x.BytesSaved
+	}
+	return 0
+}
+
+func (x *CompressResult) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *CompressResult) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+type CompressResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*CompressResponse_Progress
+	//	*CompressResponse_Result
+	Payload       isCompressResponse_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompressResponse) Reset() {
+	*x = CompressResponse{}
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompressResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompressResponse) ProtoMessage() {}
+
+func (x *CompressResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompressResponse.ProtoReflect.Descriptor instead.
+func (*CompressResponse) Descriptor() ([]byte, []int) {
+	return file_api_godeltapb_godelta_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CompressResponse) GetPayload() isCompressResponse_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *CompressResponse) GetProgress() *ProgressEvent {
+	if x != nil {
+		if x, ok := x.Payload.(*CompressResponse_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *CompressResponse) GetResult() *CompressResult {
+	if x != nil {
+		if x, ok := x.Payload.(*CompressResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isCompressResponse_Payload interface {
+	isCompressResponse_Payload()
+}
+
+type CompressResponse_Progress struct {
+	Progress *ProgressEvent `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type CompressResponse_Result struct {
+	Result *CompressResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*CompressResponse_Progress) isCompressResponse_Payload() {}
+
+func (*CompressResponse_Result) isCompressResponse_Payload() {}
+
+type DecompressRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InputPath     string                 `protobuf:"bytes,1,opt,name=input_path,json=inputPath,proto3" json:"input_path,omitempty"`
+	OutputPath    string                 `protobuf:"bytes,2,opt,name=output_path,json=outputPath,proto3" json:"output_path,omitempty"`
+	MaxThreads    int32                  `protobuf:"varint,3,opt,name=max_threads,json=maxThreads,proto3" json:"max_threads,omitempty"`
+	Overwrite     bool                   `protobuf:"varint,4,opt,name=overwrite,proto3" json:"overwrite,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DecompressRequest) Reset() {
+	*x = DecompressRequest{}
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DecompressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecompressRequest) ProtoMessage() {}
+
+func (x *DecompressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecompressRequest.ProtoReflect.Descriptor instead.
+func (*DecompressRequest) Descriptor() ([]byte, []int) {
+	return file_api_godeltapb_godelta_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DecompressRequest) GetInputPath() string {
+	if x != nil {
+		return x.InputPath
+	}
+	return ""
+}
+
+func (x *DecompressRequest) GetOutputPath() string {
+	if x != nil {
+		return x.OutputPath
+	}
+	return ""
+}
+
+func (x *DecompressRequest) GetMaxThreads() int32 {
+	if x != nil {
+		return x.MaxThreads
+	}
+	return 0
+}
+
+func (x *DecompressRequest) GetOverwrite() bool {
+	if x != nil {
+		return x.Overwrite
+	}
+	return false
+}
+
+type DecompressResult struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	FilesTotal       int64                  `protobuf:"varint,1,opt,name=files_total,json=filesTotal,proto3" json:"files_total,omitempty"`
+	FilesProcessed   int64                  `protobuf:"varint,2,opt,name=files_processed,json=filesProcessed,proto3" json:"files_processed,omitempty"`
+	CompressedSize   uint64                 `protobuf:"varint,3,opt,name=compressed_size,json=compressedSize,proto3" json:"compressed_size,omitempty"`
+	DecompressedSize uint64                 `protobuf:"varint,4,opt,name=decompressed_size,json=decompressedSize,proto3" json:"decompressed_size,omitempty"`
+	Errors           []string               `protobuf:"bytes,5,rep,name=errors,proto3" json:"errors,omitempty"`
+	Degradations     []string               `protobuf:"bytes,6,rep,name=degradations,proto3" json:"degradations,omitempty"`
+	Summary          string                 `protobuf:"bytes,7,opt,name=summary,proto3" json:"summary,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *DecompressResult) Reset() {
+	*x = DecompressResult{}
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DecompressResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecompressResult) ProtoMessage() {}
+
+func (x *DecompressResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecompressResult.ProtoReflect.Descriptor instead.
+func (*DecompressResult) Descriptor() ([]byte, []int) {
+	return file_api_godeltapb_godelta_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DecompressResult) GetFilesTotal() int64 {
+	if x != nil {
+		return x.FilesTotal
+	}
+	return 0
+}
+
+func (x *DecompressResult) GetFilesProcessed() int64 {
+	if x != nil {
+		return x.FilesProcessed
+	}
+	return 0
+}
+
+func (x *DecompressResult) GetCompressedSize() uint64 {
+	if x != nil {
+		return x.CompressedSize
+	}
+	return 0
+}
+
+func (x *DecompressResult) GetDecompressedSize() uint64 {
+	if x != nil {
+		return x.DecompressedSize
+	}
+	return 0
+}
+
+func (x *DecompressResult) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *DecompressResult) GetDegradations() []string {
+	if x != nil {
+		return x.Degradations
+	}
+	return nil
+}
+
+func (x *DecompressResult) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+type DecompressResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*DecompressResponse_Progress
+	//	*DecompressResponse_Result
+	Payload       isDecompressResponse_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DecompressResponse) Reset() {
+	*x = DecompressResponse{}
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DecompressResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecompressResponse) ProtoMessage() {}
+
+func (x *DecompressResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecompressResponse.ProtoReflect.Descriptor instead.
+func (*DecompressResponse) Descriptor() ([]byte, []int) {
+	return file_api_godeltapb_godelta_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DecompressResponse) GetPayload() isDecompressResponse_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *DecompressResponse) GetProgress() *ProgressEvent {
+	if x != nil {
+		if x, ok := x.Payload.(*DecompressResponse_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *DecompressResponse) GetResult() *DecompressResult {
+	if x != nil {
+		if x, ok := x.Payload.(*DecompressResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isDecompressResponse_Payload interface {
+	isDecompressResponse_Payload()
+}
+
+type DecompressResponse_Progress struct {
+	Progress *ProgressEvent `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type DecompressResponse_Result struct {
+	Result *DecompressResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*DecompressResponse_Progress) isDecompressResponse_Payload() {}
+
+func (*DecompressResponse_Result) isDecompressResponse_Payload() {}
+
+type VerifyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InputPath     string                 `protobuf:"bytes,1,opt,name=input_path,json=inputPath,proto3" json:"input_path,omitempty"`
+	VerifyData    bool                   `protobuf:"varint,2,opt,name=verify_data,json=verifyData,proto3" json:"verify_data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyRequest) Reset() {
+	*x = VerifyRequest{}
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyRequest) ProtoMessage() {}
+
+func (x *VerifyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyRequest.ProtoReflect.Descriptor instead.
+func (*VerifyRequest) Descriptor() ([]byte, []int) {
+	return file_api_godeltapb_godelta_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *VerifyRequest) GetInputPath() string {
+	if x != nil {
+		return x.InputPath
+	}
+	return ""
+}
+
+func (x *VerifyRequest) GetVerifyData() bool {
+	if x != nil {
+		return x.VerifyData
+	}
+	return false
+}
+
+type VerifyResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Format        string                 `protobuf:"bytes,1,opt,name=format,proto3" json:"format,omitempty"`
+	Valid         bool                   `protobuf:"varint,2,opt,name=valid,proto3" json:"valid,omitempty"`
+	FileCount     int64                  `protobuf:"varint,3,opt,name=file_count,json=fileCount,proto3" json:"file_count,omitempty"`
+	TotalOrigSize uint64                 `protobuf:"varint,4,opt,name=total_orig_size,json=totalOrigSize,proto3" json:"total_orig_size,omitempty"`
+	TotalCompSize uint64                 `protobuf:"varint,5,opt,name=total_comp_size,json=totalCompSize,proto3" json:"total_comp_size,omitempty"`
+	CorruptFiles  int64                  `protobuf:"varint,6,opt,name=corrupt_files,json=corruptFiles,proto3" json:"corrupt_files,omitempty"`
+	CorruptChunks int64                  `protobuf:"varint,7,opt,name=corrupt_chunks,json=corruptChunks,proto3" json:"corrupt_chunks,omitempty"`
+	Errors        []string               `protobuf:"bytes,8,rep,name=errors,proto3" json:"errors,omitempty"`
+	Summary       string                 `protobuf:"bytes,9,opt,name=summary,proto3" json:"summary,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyResult) Reset() {
+	*x = VerifyResult{}
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyResult) ProtoMessage() {}
+
+func (x *VerifyResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyResult.ProtoReflect.Descriptor instead.
+func (*VerifyResult) Descriptor() ([]byte, []int) {
+	return file_api_godeltapb_godelta_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *VerifyResult) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *VerifyResult) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+func (x *VerifyResult) GetFileCount() int64 {
+	if x != nil {
+		return x.FileCount
+	}
+	return 0
+}
+
+func (x *VerifyResult) GetTotalOrigSize() uint64 {
+	if x != nil {
+		return x.TotalOrigSize
+	}
+	return 0
+}
+
+func (x *VerifyResult) GetTotalCompSize() uint64 {
+	if x != nil {
+		return x.TotalCompSize
+	}
+	return 0
+}
+
+func (x *VerifyResult) GetCorruptFiles() int64 {
+	if x != nil {
+		return x.CorruptFiles
+	}
+	return 0
+}
+
+func (x *VerifyResult) GetCorruptChunks() int64 {
+	if x != nil {
+		return x.CorruptChunks
+	}
+	return 0
+}
+
+func (x *VerifyResult) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *VerifyResult) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+type VerifyResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*VerifyResponse_Progress
+	//	*VerifyResponse_Result
+	Payload       isVerifyResponse_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyResponse) Reset() {
+	*x = VerifyResponse{}
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyResponse) ProtoMessage() {}
+
+func (x *VerifyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyResponse.ProtoReflect.Descriptor instead.
+func (*VerifyResponse) Descriptor() ([]byte, []int) {
+	return file_api_godeltapb_godelta_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *VerifyResponse) GetPayload() isVerifyResponse_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *VerifyResponse) GetProgress() *ProgressEvent {
+	if x != nil {
+		if x, ok := x.Payload.(*VerifyResponse_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *VerifyResponse) GetResult() *VerifyResult {
+	if x != nil {
+		if x, ok := x.Payload.(*VerifyResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isVerifyResponse_Payload interface {
+	isVerifyResponse_Payload()
+}
+
+type VerifyResponse_Progress struct {
+	Progress *ProgressEvent `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type VerifyResponse_Result struct {
+	Result *VerifyResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*VerifyResponse_Progress) isVerifyResponse_Payload() {}
+
+func (*VerifyResponse_Result) isVerifyResponse_Payload() {}
+
+type ListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InputPath     string                 `protobuf:"bytes,1,opt,name=input_path,json=inputPath,proto3" json:"input_path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_api_godeltapb_godelta_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListRequest) GetInputPath() string {
+	if x != nil {
+		return x.InputPath
+	}
+	return ""
+}
+
+type FileEntry struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Path           string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	OriginalSize   uint64                 `protobuf:"varint,2,opt,name=original_size,json=originalSize,proto3" json:"original_size,omitempty"`
+	CompressedSize uint64                 `protobuf:"varint,3,opt,name=compressed_size,json=compressedSize,proto3" json:"compressed_size,omitempty"`
+	ChunkCount     int64                  `protobuf:"varint,4,opt,name=chunk_count,json=chunkCount,proto3" json:"chunk_count,omitempty"` // GDELTA02 only
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *FileEntry) Reset() {
+	*x = FileEntry{}
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileEntry) ProtoMessage() {}
+
+func (x *FileEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileEntry.ProtoReflect.Descriptor instead.
+func (*FileEntry) Descriptor() ([]byte, []int) {
+	return file_api_godeltapb_godelta_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *FileEntry) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileEntry) GetOriginalSize() uint64 {
+	if x != nil {
+		return x.OriginalSize
+	}
+	return 0
+}
+
+func (x *FileEntry) GetCompressedSize() uint64 {
+	if x != nil {
+		return x.CompressedSize
+	}
+	return 0
+}
+
+func (x *FileEntry) GetChunkCount() int64 {
+	if x != nil {
+		return x.ChunkCount
+	}
+	return 0
+}
+
+type ListResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ListResponse_Progress
+	//	*ListResponse_Entry
+	Payload       isListResponse_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResponse) Reset() {
+	*x = ListResponse{}
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResponse) ProtoMessage() {}
+
+func (x *ListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_godeltapb_godelta_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResponse.ProtoReflect.Descriptor instead.
+func (*ListResponse) Descriptor() ([]byte, []int) {
+	return file_api_godeltapb_godelta_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListResponse) GetPayload() isListResponse_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ListResponse) GetProgress() *ProgressEvent {
+	if x != nil {
+		if x, ok := x.Payload.(*ListResponse_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *ListResponse) GetEntry() *FileEntry {
+	if x != nil {
+		if x, ok := x.Payload.(*ListResponse_Entry); ok {
+			return x.Entry
+		}
+	}
+	return nil
+}
+
+type isListResponse_Payload interface {
+	isListResponse_Payload()
+}
+
+type ListResponse_Progress struct {
+	Progress *ProgressEvent `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type ListResponse_Entry struct {
+	Entry *FileEntry `protobuf:"bytes,2,opt,name=entry,proto3,oneof"`
+}
+
+func (*ListResponse_Progress) isListResponse_Payload() {}
+
+func (*ListResponse_Entry) isListResponse_Payload() {}
+
+var File_api_godeltapb_godelta_proto protoreflect.FileDescriptor
+
+const file_api_godeltapb_godelta_proto_rawDesc = "" +
+	"\n" +
+	"\x1bapi/godeltapb/godelta.proto\x12\n" +
+	"godelta.v1\"\xbd\x02\n" +
+	"\rProgressEvent\x12)\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x15.godelta.v1.EventTypeR\x04type\x12\x1b\n" +
+	"\tfile_path\x18\x02 \x01(\tR\bfilePath\x12\x18\n" +
+	"\acurrent\x18\x03 \x01(\x03R\acurrent\x12\x14\n" +
+	"\x05total\x18\x04 \x01(\x03R\x05total\x12#\n" +
+	"\rcurrent_bytes\x18\x05 \x01(\x04R\fcurrentBytes\x12\x1f\n" +
+	"\vtotal_bytes\x18\x06 \x01(\x04R\n" +
+	"totalBytes\x12'\n" +
+	"\x0fcompressed_size\x18\a \x01(\x04R\x0ecompressedSize\x12+\n" +
+	"\x11decompressed_size\x18\b \x01(\x04R\x10decompressedSize\x12\x18\n" +
+	"\amessage\x18\t \x01(\tR\amessage\"\xae\x03\n" +
+	"\x0fCompressRequest\x12\x1d\n" +
+	"\n" +
+	"input_path\x18\x01 \x01(\tR\tinputPath\x12\x14\n" +
+	"\x05files\x18\x02 \x03(\tR\x05files\x12\x1f\n" +
+	"\voutput_path\x18\x03 \x01(\tR\n" +
+	"outputPath\x12\x1f\n" +
+	"\vmax_threads\x18\x04 \x01(\x05R\n" +
+	"maxThreads\x12 \n" +
+	"\vparallelism\x18\x05 \x01(\tR\vparallelism\x12*\n" +
+	"\x11max_thread_memory\x18\x06 \x01(\x04R\x0fmaxThreadMemory\x12\x1d\n" +
+	"\n" +
+	"chunk_size\x18\a \x01(\x04R\tchunkSize\x12(\n" +
+	"\x10chunk_store_size\x18\b \x01(\x04R\x0echunkStoreSize\x12\x14\n" +
+	"\x05level\x18\t \x01(\x05R\x05level\x12\x10\n" +
+	"\x03zip\x18\n" +
+	" \x01(\bR\x03zip\x12\x0e\n" +
+	"\x02xz\x18\v \x01(\bR\x02xz\x12\x1e\n" +
+	"\n" +
+	"dictionary\x18\f \x01(\bR\n" +
+	"dictionary\x12\x17\n" +
+	"\adry_run\x18\r \x01(\bR\x06dryRun\x12\x1c\n" +
+	"\tgitignore\x18\x0e \x01(\bR\tgitignore\"\xea\x02\n" +
+	"\x0eCompressResult\x12\x1f\n" +
+	"\vfiles_total\x18\x01 \x01(\x03R\n" +
+	"filesTotal\x12'\n" +
+	"\x0ffiles_processed\x18\x02 \x01(\x03R\x0efilesProcessed\x12#\n" +
+	"\roriginal_size\x18\x03 \x01(\x04R\foriginalSize\x12'\n" +
+	"\x0fcompressed_size\x18\x04 \x01(\x04R\x0ecompressedSize\x12!\n" +
+	"\ftotal_chunks\x18\x05 \x01(\x04R\vtotalChunks\x12#\n" +
+	"\runique_chunks\x18\x06 \x01(\x04R\funiqueChunks\x12%\n" +
+	"\x0ededuped_chunks\x18\a \x01(\x04R\rdedupedChunks\x12\x1f\n" +
+	"\vbytes_saved\x18\b \x01(\x04R\n" +
+	"bytesSaved\x12\x16\n" +
+	"\x06errors\x18\t \x03(\tR\x06errors\x12\x18\n" +
+	"\asummary\x18\n" +
+	" \x01(\tR\asummary\"\x8c\x01\n" +
+	"\x10CompressResponse\x127\n" +
+	"\bprogress\x18\x01 \x01(\v2\x19.godelta.v1.ProgressEventH\x00R\bprogress\x124\n" +
+	"\x06result\x18\x02 \x01(\v2\x1a.godelta.v1.CompressResultH\x00R\x06resultB\t\n" +
+	"\apayload\"\x92\x01\n" +
+	"\x11DecompressRequest\x12\x1d\n" +
+	"\n" +
+	"input_path\x18\x01 \x01(\tR\tinputPath\x12\x1f\n" +
+	"\voutput_path\x18\x02 \x01(\tR\n" +
+	"outputPath\x12\x1f\n" +
+	"\vmax_threads\x18\x03 \x01(\x05R\n" +
+	"maxThreads\x12\x1c\n" +
+	"\toverwrite\x18\x04 \x01(\bR\toverwrite\"\x88\x02\n" +
+	"\x10DecompressResult\x12\x1f\n" +
+	"\vfiles_total\x18\x01 \x01(\x03R\n" +
+	"filesTotal\x12'\n" +
+	"\x0ffiles_processed\x18\x02 \x01(\x03R\x0efilesProcessed\x12'\n" +
+	"\x0fcompressed_size\x18\x03 \x01(\x04R\x0ecompressedSize\x12+\n" +
+	"\x11decompressed_size\x18\x04 \x01(\x04R\x10decompressedSize\x12\x16\n" +
+	"\x06errors\x18\x05 \x03(\tR\x06errors\x12\"\n" +
+	"\fdegradations\x18\x06 \x03(\tR\fdegradations\x12\x18\n" +
+	"\asummary\x18\a \x01(\tR\asummary\"\x90\x01\n" +
+	"\x12DecompressResponse\x127\n" +
+	"\bprogress\x18\x01 \x01(\v2\x19.godelta.v1.ProgressEventH\x00R\bprogress\x126\n" +
+	"\x06result\x18\x02 \x01(\v2\x1c.godelta.v1.DecompressResultH\x00R\x06resultB\t\n" +
+	"\apayload\"O\n" +
+	"\rVerifyRequest\x12\x1d\n" +
+	"\n" +
+	"input_path\x18\x01 \x01(\tR\tinputPath\x12\x1f\n" +
+	"\vverify_data\x18\x02 \x01(\bR\n" +
+	"verifyData\"\xa9\x02\n" +
+	"\fVerifyResult\x12\x16\n" +
+	"\x06format\x18\x01 \x01(\tR\x06format\x12\x14\n" +
+	"\x05valid\x18\x02 \x01(\bR\x05valid\x12\x1d\n" +
+	"\n" +
+	"file_count\x18\x03 \x01(\x03R\tfileCount\x12&\n" +
+	"\x0ftotal_orig_size\x18\x04 \x01(\x04R\rtotalOrigSize\x12&\n" +
+	"\x0ftotal_comp_size\x18\x05 \x01(\x04R\rtotalCompSize\x12#\n" +
+	"\rcorrupt_files\x18\x06 \x01(\x03R\fcorruptFiles\x12%\n" +
+	"\x0ecorrupt_chunks\x18\a \x01(\x03R\rcorruptChunks\x12\x16\n" +
+	"\x06errors\x18\b \x03(\tR\x06errors\x12\x18\n" +
+	"\asummary\x18\t \x01(\tR\asummary\"\x88\x01\n" +
+	"\x0eVerifyResponse\x127\n" +
+	"\bprogress\x18\x01 \x01(\v2\x19.godelta.v1.ProgressEventH\x00R\bprogress\x122\n" +
+	"\x06result\x18\x02 \x01(\v2\x18.godelta.v1.VerifyResultH\x00R\x06resultB\t\n" +
+	"\apayload\",\n" +
+	"\vListRequest\x12\x1d\n" +
+	"\n" +
+	"input_path\x18\x01 \x01(\tR\tinputPath\"\x8e\x01\n" +
+	"\tFileEntry\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12#\n" +
+	"\roriginal_size\x18\x02 \x01(\x04R\foriginalSize\x12'\n" +
+	"\x0fcompressed_size\x18\x03 \x01(\x04R\x0ecompressedSize\x12\x1f\n" +
+	"\vchunk_count\x18\x04 \x01(\x03R\n" +
+	"chunkCount\"\x81\x01\n" +
+	"\fListResponse\x127\n" +
+	"\bprogress\x18\x01 \x01(\v2\x19.godelta.v1.ProgressEventH\x00R\bprogress\x12-\n" +
+	"\x05entry\x18\x02 \x01(\v2\x15.godelta.v1.FileEntryH\x00R\x05entryB\t\n" +
+	"\apayload*\x9a\x02\n" +
+	"\tEventType\x12\x1a\n" +
+	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10EVENT_TYPE_START\x10\x01\x12\x19\n" +
+	"\x15EVENT_TYPE_FILE_START\x10\x02\x12\x1c\n" +
+	"\x18EVENT_TYPE_FILE_PROGRESS\x10\x03\x12\x1c\n" +
+	"\x18EVENT_TYPE_FILE_COMPLETE\x10\x04\x12\x17\n" +
+	"\x13EVENT_TYPE_COMPLETE\x10\x05\x12\x14\n" +
+	"\x10EVENT_TYPE_ERROR\x10\x06\x12\x1c\n" +
+	"\x18EVENT_TYPE_DICT_TRAINING\x10\a\x12\x1a\n" +
+	"\x16EVENT_TYPE_FILE_VERIFY\x10\b\x12\x1b\n" +
+	"\x17EVENT_TYPE_CHUNK_VERIFY\x10\t2\xa1\x02\n" +
+	"\aGoDelta\x12G\n" +
+	"\bCompress\x12\x1b.godelta.v1.CompressRequest\x1a\x1c.godelta.v1.CompressResponse0\x01\x12M\n" +
+	"\n" +
+	"Decompress\x12\x1d.godelta.v1.DecompressRequest\x1a\x1e.godelta.v1.DecompressResponse0\x01\x12A\n" +
+	"\x06Verify\x12\x19.godelta.v1.VerifyRequest\x1a\x1a.godelta.v1.VerifyResponse0\x01\x12;\n" +
+	"\x04List\x12\x17.godelta.v1.ListRequest\x1a\x18.godelta.v1.ListResponse0\x01B2Z0github.com/creativeyann17/go-delta/api/godeltapbb\x06proto3"
+
+var (
+	file_api_godeltapb_godelta_proto_rawDescOnce sync.Once
+	file_api_godeltapb_godelta_proto_rawDescData []byte
+)
+
+func file_api_godeltapb_godelta_proto_rawDescGZIP() []byte {
+	file_api_godeltapb_godelta_proto_rawDescOnce.Do(func() {
+		file_api_godeltapb_godelta_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_godeltapb_godelta_proto_rawDesc), len(file_api_godeltapb_godelta_proto_rawDesc)))
+	})
+	return file_api_godeltapb_godelta_proto_rawDescData
+}
+
+var file_api_godeltapb_godelta_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_api_godeltapb_godelta_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_api_godeltapb_godelta_proto_goTypes = []any{
+	(EventType)(0),             // 0: godelta.v1.EventType
+	(*ProgressEvent)(nil),      // 1: godelta.v1.ProgressEvent
+	(*CompressRequest)(nil),    // 2: godelta.v1.CompressRequest
+	(*CompressResult)(nil),     // 3: godelta.v1.CompressResult
+	(*CompressResponse)(nil),   // 4: godelta.v1.CompressResponse
+	(*DecompressRequest)(nil),  // 5: godelta.v1.DecompressRequest
+	(*DecompressResult)(nil),   // 6: godelta.v1.DecompressResult
+	(*DecompressResponse)(nil), // 7: godelta.v1.DecompressResponse
+	(*VerifyRequest)(nil),      // 8: godelta.v1.VerifyRequest
+	(*VerifyResult)(nil),       // 9: godelta.v1.VerifyResult
+	(*VerifyResponse)(nil),     // 10: godelta.v1.VerifyResponse
+	(*ListRequest)(nil),        // 11: godelta.v1.ListRequest
+	(*FileEntry)(nil),          // 12: godelta.v1.FileEntry
+	(*ListResponse)(nil),       // 13: godelta.v1.ListResponse
+}
+var file_api_godeltapb_godelta_proto_depIdxs = []int32{
+	0,  // 0: godelta.v1.ProgressEvent.type:type_name -> godelta.v1.EventType
+	1,  // 1: godelta.v1.CompressResponse.progress:type_name -> godelta.v1.ProgressEvent
+	3,  // 2: godelta.v1.CompressResponse.result:type_name -> godelta.v1.CompressResult
+	1,  // 3: godelta.v1.DecompressResponse.progress:type_name -> godelta.v1.ProgressEvent
+	6,  // 4: godelta.v1.DecompressResponse.result:type_name -> godelta.v1.DecompressResult
+	1,  // 5: godelta.v1.VerifyResponse.progress:type_name -> godelta.v1.ProgressEvent
+	9,  // 6: godelta.v1.VerifyResponse.result:type_name -> godelta.v1.VerifyResult
+	1,  // 7: godelta.v1.ListResponse.progress:type_name -> godelta.v1.ProgressEvent
+	12, // 8: godelta.v1.ListResponse.entry:type_name -> godelta.v1.FileEntry
+	2,  // 9: godelta.v1.GoDelta.Compress:input_type -> godelta.v1.CompressRequest
+	5,  // 10: godelta.v1.GoDelta.Decompress:input_type -> godelta.v1.DecompressRequest
+	8,  // 11: godelta.v1.GoDelta.Verify:input_type -> godelta.v1.VerifyRequest
+	11, // 12: godelta.v1.GoDelta.List:input_type -> godelta.v1.ListRequest
+	4,  // 13: godelta.v1.GoDelta.Compress:output_type -> godelta.v1.CompressResponse
+	7,  // 14: godelta.v1.GoDelta.Decompress:output_type -> godelta.v1.DecompressResponse
+	10, // 15: godelta.v1.GoDelta.Verify:output_type -> godelta.v1.VerifyResponse
+	13, // 16: godelta.v1.GoDelta.List:output_type -> godelta.v1.ListResponse
+	13, // [13:17] is the sub-list for method output_type
+	9,  // [9:13] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_api_godeltapb_godelta_proto_init() }
+func file_api_godeltapb_godelta_proto_init() {
+	if File_api_godeltapb_godelta_proto != nil {
+		return
+	}
+	file_api_godeltapb_godelta_proto_msgTypes[3].OneofWrappers = []any{
+		(*CompressResponse_Progress)(nil),
+		(*CompressResponse_Result)(nil),
+	}
+	file_api_godeltapb_godelta_proto_msgTypes[6].OneofWrappers = []any{
+		(*DecompressResponse_Progress)(nil),
+		(*DecompressResponse_Result)(nil),
+	}
+	file_api_godeltapb_godelta_proto_msgTypes[9].OneofWrappers = []any{
+		(*VerifyResponse_Progress)(nil),
+		(*VerifyResponse_Result)(nil),
+	}
+	file_api_godeltapb_godelta_proto_msgTypes[12].OneofWrappers = []any{
+		(*ListResponse_Progress)(nil),
+		(*ListResponse_Entry)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_godeltapb_godelta_proto_rawDesc), len(file_api_godeltapb_godelta_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_godeltapb_godelta_proto_goTypes,
+		DependencyIndexes: file_api_godeltapb_godelta_proto_depIdxs,
+		EnumInfos:         file_api_godeltapb_godelta_proto_enumTypes,
+		MessageInfos:      file_api_godeltapb_godelta_proto_msgTypes,
+	}.Build()
+	File_api_godeltapb_godelta_proto = out.File
+	file_api_godeltapb_godelta_proto_goTypes = nil
+	file_api_godeltapb_godelta_proto_depIdxs = nil
+}
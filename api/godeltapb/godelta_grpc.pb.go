@@ -0,0 +1,266 @@
+// api/godeltapb/godelta.proto
+//
+// Remote control API for go-delta, served by `godelta daemon`.
+// Every RPC streams the same progress events the library reports through its
+// ProgressCallback, followed by a single final result message.
+//
+// Regenerate the Go code with:
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//          --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//          api/godeltapb/godelta.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             v5.29.3
+// source: api/godeltapb/godelta.proto
+
+package godeltapb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	GoDelta_Compress_FullMethodName   = "/godelta.v1.GoDelta/Compress"
+	GoDelta_Decompress_FullMethodName = "/godelta.v1.GoDelta/Decompress"
+	GoDelta_Verify_FullMethodName     = "/godelta.v1.GoDelta/Verify"
+	GoDelta_List_FullMethodName       = "/godelta.v1.GoDelta/List"
+)
+
+// GoDeltaClient is the client API for GoDelta service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GoDeltaClient interface {
+	// Compress creates an archive from files on the daemon host
+	Compress(ctx context.Context, in *CompressRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CompressResponse], error)
+	// Decompress extracts an archive on the daemon host
+	Decompress(ctx context.Context, in *DecompressRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DecompressResponse], error)
+	// Verify checks archive structure and optionally data integrity
+	Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[VerifyResponse], error)
+	// List streams the file entries stored in an archive
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListResponse], error)
+}
+
+type goDeltaClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGoDeltaClient(cc grpc.ClientConnInterface) GoDeltaClient {
+	return &goDeltaClient{cc}
+}
+
+func (c *goDeltaClient) Compress(ctx context.Context, in *CompressRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CompressResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GoDelta_ServiceDesc.Streams[0], GoDelta_Compress_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CompressRequest, CompressResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GoDelta_CompressClient = grpc.ServerStreamingClient[CompressResponse]
+
+func (c *goDeltaClient) Decompress(ctx context.Context, in *DecompressRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DecompressResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GoDelta_ServiceDesc.Streams[1], GoDelta_Decompress_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DecompressRequest, DecompressResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GoDelta_DecompressClient = grpc.ServerStreamingClient[DecompressResponse]
+
+func (c *goDeltaClient) Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[VerifyResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GoDelta_ServiceDesc.Streams[2], GoDelta_Verify_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[VerifyRequest, VerifyResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GoDelta_VerifyClient = grpc.ServerStreamingClient[VerifyResponse]
+
+func (c *goDeltaClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GoDelta_ServiceDesc.Streams[3], GoDelta_List_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListRequest, ListResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GoDelta_ListClient = grpc.ServerStreamingClient[ListResponse]
+
+// GoDeltaServer is the server API for GoDelta service.
+// All implementations must embed UnimplementedGoDeltaServer
+// for forward compatibility.
+type GoDeltaServer interface {
+	// Compress creates an archive from files on the daemon host
+	Compress(*CompressRequest, grpc.ServerStreamingServer[CompressResponse]) error
+	// Decompress extracts an archive on the daemon host
+	Decompress(*DecompressRequest, grpc.ServerStreamingServer[DecompressResponse]) error
+	// Verify checks archive structure and optionally data integrity
+	Verify(*VerifyRequest, grpc.ServerStreamingServer[VerifyResponse]) error
+	// List streams the file entries stored in an archive
+	List(*ListRequest, grpc.ServerStreamingServer[ListResponse]) error
+	mustEmbedUnimplementedGoDeltaServer()
+}
+
+// UnimplementedGoDeltaServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGoDeltaServer struct{}
+
+func (UnimplementedGoDeltaServer) Compress(*CompressRequest, grpc.ServerStreamingServer[CompressResponse]) error {
+	return status.Error(codes.Unimplemented, "method Compress not implemented")
+}
+func (UnimplementedGoDeltaServer) Decompress(*DecompressRequest, grpc.ServerStreamingServer[DecompressResponse]) error {
+	return status.Error(codes.Unimplemented, "method Decompress not implemented")
+}
+func (UnimplementedGoDeltaServer) Verify(*VerifyRequest, grpc.ServerStreamingServer[VerifyResponse]) error {
+	return status.Error(codes.Unimplemented, "method Verify not implemented")
+}
+func (UnimplementedGoDeltaServer) List(*ListRequest, grpc.ServerStreamingServer[ListResponse]) error {
+	return status.Error(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedGoDeltaServer) mustEmbedUnimplementedGoDeltaServer() {}
+func (UnimplementedGoDeltaServer) testEmbeddedByValue()                 {}
+
+// UnsafeGoDeltaServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GoDeltaServer will
+// result in compilation errors.
+type UnsafeGoDeltaServer interface {
+	mustEmbedUnimplementedGoDeltaServer()
+}
+
+func RegisterGoDeltaServer(s grpc.ServiceRegistrar, srv GoDeltaServer) {
+	// If the following call panics, it indicates UnimplementedGoDeltaServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GoDelta_ServiceDesc, srv)
+}
+
+func _GoDelta_Compress_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CompressRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GoDeltaServer).Compress(m, &grpc.GenericServerStream[CompressRequest, CompressResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GoDelta_CompressServer = grpc.ServerStreamingServer[CompressResponse]
+
+func _GoDelta_Decompress_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DecompressRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GoDeltaServer).Decompress(m, &grpc.GenericServerStream[DecompressRequest, DecompressResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GoDelta_DecompressServer = grpc.ServerStreamingServer[DecompressResponse]
+
+func _GoDelta_Verify_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(VerifyRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GoDeltaServer).Verify(m, &grpc.GenericServerStream[VerifyRequest, VerifyResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GoDelta_VerifyServer = grpc.ServerStreamingServer[VerifyResponse]
+
+func _GoDelta_List_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GoDeltaServer).List(m, &grpc.GenericServerStream[ListRequest, ListResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GoDelta_ListServer = grpc.ServerStreamingServer[ListResponse]
+
+// GoDelta_ServiceDesc is the grpc.ServiceDesc for GoDelta service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GoDelta_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "godelta.v1.GoDelta",
+	HandlerType: (*GoDeltaServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Compress",
+			Handler:       _GoDelta_Compress_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Decompress",
+			Handler:       _GoDelta_Decompress_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Verify",
+			Handler:       _GoDelta_Verify_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "List",
+			Handler:       _GoDelta_List_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/godeltapb/godelta.proto",
+}
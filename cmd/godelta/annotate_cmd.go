@@ -0,0 +1,54 @@
+// cmd/godelta/annotate_cmd.go
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/annotate"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+func init() {
+	rootCmd.AddCommand(annotateCmd())
+}
+
+func annotateCmd() *cobra.Command {
+	var set []string
+	var unset []string
+
+	cmd := &cobra.Command{
+		Use:   "annotate <archive>",
+		Short: "Add, change or remove notes in an archive's metadata",
+		Long: `Set or remove key=value annotations in the metadata field of a GDELTA02
+or GDELTA03 archive made with 'compress --metadata' or '--label'. The field
+is rewritten in place, within the room reserved for it when the archive
+was made, without touching the archive body. Parity is computed again.
+
+Example:
+
+  godelta annotate backup.gdelta --set note="pre-migration snapshot"
+  godelta annotate backup.gdelta --unset note`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(set) == 0 && len(unset) == 0 {
+				return usageErrorf("nothing to do: give --set or --unset")
+			}
+			annotations, err := godelta.ParseLabels(set)
+			if err != nil {
+				return err
+			}
+			m, err := annotate.Update(args[0], annotations, unset)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Archive: %s\n%s", args[0], godelta.FormatMetadata(m, "  "))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&set, "set", nil, "Set an annotation, as key=value (repeatable)")
+	cmd.Flags().StringArrayVar(&unset, "unset", nil, "Remove the annotation with this key (repeatable)")
+	return cmd
+}
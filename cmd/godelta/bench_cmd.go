@@ -0,0 +1,116 @@
+// cmd/godelta/bench_cmd.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+func init() {
+	rootCmd.AddCommand(benchCmd())
+}
+
+func benchCmd() *cobra.Command {
+	var inputPath string
+	var levels []int
+	var chunkSizeStrs []string
+	var dictionary []bool
+	var threads []int
+	var sampleStr string
+	var codec string
+	var chunking string
+	var useGitignore bool
+	var excludes []string
+	var ignores ignoreFlags
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Compare compression settings on a sample of the input",
+		Long: `Compress a sample of the input with every combination of levels, chunk
+sizes, dictionary on/off and thread counts, and report the archive size,
+ratio and throughput of each. Archives are written to a temp directory and
+removed after each run.
+
+The sample is about --sample bytes of files spread over the whole input.
+A chunk size of 0 compresses whole files; dictionary runs don't chunk.
+
+Example:
+
+  godelta bench -i /srv/data --levels 1,5,9,19 --chunk-sizes 0,64KB`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			matrix := compress.BenchMatrix{Levels: levels, Dictionary: dictionary, Threads: threads}
+			for _, s := range chunkSizeStrs {
+				size, err := godelta.ParseSize(s)
+				if err != nil {
+					return usageErrorf("invalid --chunk-sizes: %w", err)
+				}
+				matrix.ChunkSizes = append(matrix.ChunkSizes, size)
+			}
+			if sampleStr != "" {
+				size, err := godelta.ParseSize(sampleStr)
+				if err != nil {
+					return usageErrorf("invalid --sample: %w", err)
+				}
+				matrix.SampleSize = size
+			}
+
+			opts := &compress.Options{
+				InputPath:    inputPath,
+				Codec:        codec,
+				ChunkingMode: compress.ChunkingMode(chunking),
+				UseGitignore: useGitignore,
+				IgnoreFiles:  ignores.paths(),
+				Excludes:     excludes,
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			var onRun func(compress.BenchRun)
+			if !asJSON {
+				onRun = func(run compress.BenchRun) {
+					fmt.Fprintf(os.Stderr, "  level %d, %s, %d threads: done\n", run.Level, run.Mode(), run.Threads)
+				}
+			}
+			result, err := compress.Bench(ctx, opts, matrix, onRun)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(result)
+			}
+			fmt.Print(result.Summary())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputPath, "input", "i", "", "Input file or directory (required)")
+	cmd.Flags().IntSliceVar(&levels, "levels", nil, "Compression levels to compare (default 1,5,9)")
+	cmd.Flags().StringSliceVar(&chunkSizeStrs, "chunk-sizes", nil,
+		"Chunk sizes to compare, 0 for whole files (default 0,64KB,1MB)")
+	cmd.Flags().BoolSliceVar(&dictionary, "dictionary", nil, "Dictionary modes to compare (default false,true)")
+	cmd.Flags().IntSliceVar(&threads, "threads", nil, "Thread counts to compare (default 1 and the number of CPUs)")
+	cmd.Flags().StringVar(&sampleStr, "sample", "", "Input to compress per run (default 32MB)")
+	cmd.Flags().StringVar(&codec, "codec", "zstd", "Codec of the runs: zstd, deflate, lz4, brotli or snappy")
+	cmd.Flags().StringVar(&chunking, "chunking", "cdc", "Chunking mode: cdc (content-defined) or fixed")
+	cmd.Flags().BoolVar(&useGitignore, "gitignore", false, "Respect .gitignore files to exclude matching paths")
+	cmd.Flags().StringArrayVar(&excludes, "exclude", nil,
+		"Exclude paths matching a gitignore-style pattern (repeatable, e.g. --exclude '*.log')")
+	ignores.register(cmd.Flags())
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the results as JSON")
+
+	_ = cmd.MarkFlagRequired("input")
+
+	return cmd
+}
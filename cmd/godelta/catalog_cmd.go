@@ -0,0 +1,125 @@
+// cmd/godelta/catalog_cmd.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/catalog"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// defaultCatalogPath is the catalog file used without --output/--catalog
+const defaultCatalogPath = "catalog.db"
+
+func init() {
+	rootCmd.AddCommand(catalogCmd())
+}
+
+func catalogCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "catalog",
+		Short: "Index the files of many archives and search them",
+	}
+	cmd.AddCommand(catalogBuildCmd(), catalogSearchCmd())
+	return cmd
+}
+
+func catalogBuildCmd() *cobra.Command {
+	var output string
+	var quiet bool
+
+	cmd := &cobra.Command{
+		Use:   "build <archive|dir>...",
+		Short: "Index every file path and hash across archives",
+		Long: `Read the entry list of every archive given, and of every .gdelta file
+below the directories given, into a catalog file. Only headers and entry
+lists are read. Hashes come from the archives' .sha256 manifests
+('compress --write-manifest').
+
+When the catalog already exists, archives whose size and modification
+time haven't changed are taken from it without being read again.
+
+Example:
+
+  godelta catalog build /backups -o catalog.db`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			previous, err := catalog.Load(output)
+			if errors.Is(err, os.ErrNotExist) {
+				previous, err = nil, nil
+			}
+			if err != nil {
+				return err
+			}
+
+			c, result, err := catalog.Build(args, previous)
+			if err != nil {
+				return err
+			}
+			if err := c.Save(output); err != nil {
+				return err
+			}
+			for _, err := range result.Errors {
+				fmt.Fprintf(os.Stderr, "Skipped: %v\n", err)
+			}
+			if !quiet {
+				fmt.Printf("Cataloged %d archives (%d read, %d unchanged), %d files, in %s\n",
+					len(c.Archives), result.Read, result.Reused, c.Files(), output)
+			}
+			if len(result.Errors) > 0 {
+				return partialError(result.Errors)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", defaultCatalogPath, "Catalog file")
+	cmd.Flags().BoolVar(&quiet, "quiet", false, "Minimal output")
+	return cmd
+}
+
+func catalogSearchCmd() *cobra.Command {
+	var catalogPath string
+	var byHash bool
+
+	cmd := &cobra.Command{
+		Use:   "search <pattern>",
+		Short: "Find the archives holding a file",
+		Long: `List the archives holding files that match a glob pattern. Patterns
+with a '/' match whole paths in the archive, others the file name alone.
+With --hash, the argument is a SHA-256 instead.
+
+Example:
+
+  godelta catalog search "invoice-2023*"
+  godelta catalog search "docs/*.pdf" -c /backups/catalog.db`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := catalog.Load(catalogPath)
+			if err != nil {
+				return err
+			}
+			var matches []catalog.Match
+			if byHash {
+				matches = c.SearchHash(args[0])
+			} else if matches, err = c.Search(args[0]); err != nil {
+				return err
+			}
+			for _, m := range matches {
+				fmt.Printf("%s\t%s\t%d\n", m.Archive.Path, m.File.Path, m.File.Size)
+			}
+			if len(matches) == 0 {
+				return godelta.WithCategory(godelta.ErrNotFound, fmt.Errorf("no file matches %q", args[0]))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&catalogPath, "catalog", "c", defaultCatalogPath, "Catalog file")
+	cmd.Flags().BoolVar(&byHash, "hash", false, "Search by SHA-256 (from the archives' manifests) instead of name")
+	return cmd
+}
@@ -3,15 +3,25 @@
 package main
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/vbauerster/mpb/v8"
 
 	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/creativeyann17/go-delta/pkg/notify"
+	"github.com/creativeyann17/go-delta/pkg/profile"
+	"github.com/creativeyann17/go-delta/pkg/timestamp"
 )
 
 func init() {
@@ -20,36 +30,153 @@ func init() {
 
 func compressCmd() *cobra.Command {
 	var inputPath, outputPath string
+	var fromTar string
+	var filesFrom string
+	var nulList bool
+	var pathMode string
+	var pathMaps []string
+	var streamName string
 	var maxThreads int
+	var adaptiveThreads bool
+	var minThreads int
+	var nice int
+	var ionice string
 	var parallelism string
+	var order string
+	var statsBy string
 	var threadMemoryStr string
 	var chunkSizeStr string
+	var chunkMinStr, chunkMaxStr string
+	var chunkNormalization int
+	var chunking string
+	var chunkDict bool
 	var chunkStoreSizeStr string
+	var chunkIndexDir string
 	var dryRun bool
 	var verbose bool
 	var quiet bool
-	var compressLevel int
+	var levelStr string
+	var codec string
+	var windowLog int
+	var segmentSizeStr string
+	var noSegments bool
+	var memoryStr string
+	var longMatching bool
 	var useZipFormat bool
 	var useXzFormat bool
+	var xzParts bool
+	var singleZip bool
+	var useTarGzFormat bool
+	var use7zFormat bool
 	var useDictionary bool
 	var useGitignore bool
 	var disableGC bool
+	var excludes []string
+	var referenceDirs []string
+	var ignores ignoreFlags
+	var normalizePaths string
+	var storeExts []string
+	var compressAll bool
+	var packSmallStr string
+	var dedupFiles bool
+	var splitByFolder bool
+	var verifyAfter bool
+	var noSync bool
+	var noPageCache bool
+	var sniffTypes bool
+	var typeCodecs []string
+	var entropyThreshold float64
+	var noEntropyCheck bool
+	var parityPercent int
+	var writeManifest bool
+	var changeRetries int
+	var labels []string
+	var withMetadata bool
+	var archival bool
+	var snapshotMode string
+	var lockedRetries int
+	var skipLocked bool
+	var strict bool
+	var maxErrors int
+	var timestampURL string
+	var configPath, profileName string
+	var progressOpts progressFlags
+	var notifyOpts notifyFlags
 
 	cmd := &cobra.Command{
 		Use:   "compress",
 		Short: "Compress file or directory into delta archive",
+		Long: `Compress a file or directory into an archive.
+
+Settings can come from a named profile in a YAML or TOML file (see 'godelta
+config init'); any flag given on the command line overrides the profile value:
+
+  godelta compress --config backup.yaml --profile nightly --level 12`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// Profile values fill in flags not given on the command line
+			if err := applyProfile(cmd.Flags(), configPath, profileName); err != nil {
+				return err
+			}
+			if inputPath == "" && fromTar == "" && filesFrom == "" {
+				return usageErrorf("--input is required (on the command line or in the profile)")
+			}
+			if inputPath != "" && fromTar != "" {
+				return usageErrorf("--from-tar replaces --input, give only one")
+			}
+			if filesFrom != "" && (inputPath != "" || fromTar != "") {
+				return usageErrorf("--files-from replaces --input and --from-tar, give only one")
+			}
+			if nulList && filesFrom == "" {
+				return usageErrorf("-0 applies to --files-from")
+			}
+			// stdin and block devices are stored as a single streamed entry
+			streamInput := inputPath == "-" || compress.IsBlockDevice(inputPath)
+			if timestampURL != "" {
+				if useZipFormat || useXzFormat || useTarGzFormat || use7zFormat {
+					return usageErrorf("--timestamp-url needs a GDELTA archive (not ZIP, XZ, tar.gz or 7z output)")
+				}
+				if err := timestamp.ValidateURL(timestampURL); err != nil {
+					return err
+				}
+			}
+
+			events, out, err := progressOpts.open(verbose && !quiet)
+			if err != nil {
+				return err
+			}
+
 			// Determine output extension based on format
 			if outputPath == "" {
 				outputPath = "archive"
 			}
-			if useXzFormat {
-				// For XZ, remove .tar.xz or .xz if present - compress_xz will add _01.tar.xz, etc.
+			if useXzFormat && xzParts {
+				// For XZ parts, remove .tar.xz or .xz if present - compress_xz will add _01.tar.xz, etc.
 				if strings.HasSuffix(outputPath, ".tar.xz") {
 					outputPath = outputPath[:len(outputPath)-7]
 				} else if strings.HasSuffix(outputPath, ".xz") {
 					outputPath = outputPath[:len(outputPath)-3]
 				}
+			} else if useXzFormat {
+				// A single XZ stream is written as is: add .tar.xz if missing
+				if !strings.HasSuffix(outputPath, ".tar.xz") && !strings.HasSuffix(outputPath, ".txz") {
+					outputPath += ".tar.xz"
+				}
+			} else if useTarGzFormat {
+				// Same for tar.gz: compress_targz will add _01.tar.gz, etc.
+				for _, ext := range []string{".tar.gz", ".tgz", ".gz"} {
+					if strings.HasSuffix(outputPath, ext) {
+						outputPath = strings.TrimSuffix(outputPath, ext)
+						break
+					}
+				}
+			} else if use7zFormat {
+				// And for 7z: compress_7z will add _01.7z, etc.
+				outputPath = strings.TrimSuffix(outputPath, ".7z")
+			} else if useZipFormat && singleZip {
+				// A single ZIP is written as is: add .zip if missing
+				if !strings.HasSuffix(outputPath, ".zip") {
+					outputPath += ".zip"
+				}
 			} else if useZipFormat {
 				// For ZIP, remove .zip if present - compress_zip will add _01.zip, _02.zip, etc.
 				if strings.HasSuffix(outputPath, ".zip") {
@@ -65,25 +192,68 @@ func compressCmd() *cobra.Command {
 			// Parse size strings
 			threadMemoryKB, err := parseSize(threadMemoryStr)
 			if err != nil {
-				return fmt.Errorf("invalid --thread-memory: %w", err)
+				return usageErrorf("invalid --thread-memory: %w", err)
 			}
 
-			chunkSizeKB, err := parseSize(chunkSizeStr)
-			if err != nil {
-				return fmt.Errorf("invalid --chunk-size: %w", err)
+			// "auto" picks the level or chunk size on a sample of the input
+			autoLevel := levelStr == "auto"
+			var compressLevel int
+			if !autoLevel {
+				if compressLevel, err = strconv.Atoi(levelStr); err != nil {
+					return usageErrorf("invalid --level %q: a number or auto", levelStr)
+				}
+			}
+			autoChunkSize := chunkSizeStr == "auto"
+			var chunkSizeKB uint64
+			if !autoChunkSize {
+				if chunkSizeKB, err = parseSize(chunkSizeStr); err != nil {
+					return usageErrorf("invalid --chunk-size: %w", err)
+				}
 			}
 
 			// Validate minimum chunk size to prevent metadata overhead exceeding savings
 			if chunkSizeKB > 0 && chunkSizeKB < minChunkSizeKB {
-				return fmt.Errorf("--chunk-size too small: %d KB (minimum: %d KB)\n"+
+				return usageErrorf("--chunk-size too small: %d KB (minimum: %d KB)\n"+
 					"Reason: Each chunk has 56 bytes of metadata overhead in the archive.\n"+
 					"Chunks smaller than %d KB would increase archive size instead of reducing it.",
 					chunkSizeKB, minChunkSizeKB, minChunkSizeKB)
 			}
 
+			chunkMin, err := godelta.ParseSize(chunkMinStr)
+			if err != nil {
+				return usageErrorf("invalid --chunk-min: %w", err)
+			}
+			chunkMax, err := godelta.ParseSize(chunkMaxStr)
+			if err != nil {
+				return usageErrorf("invalid --chunk-max: %w", err)
+			}
+			// 0 turns normalization off; unset keeps the default
+			var normalization int
+			if cmd.Flags().Changed("chunk-normalization") {
+				normalization = chunkNormalization
+				if normalization == 0 {
+					normalization = compress.ChunkNormalizationOff
+				}
+			}
+
+			segmentSize, err := godelta.ParseSize(segmentSizeStr)
+			if err != nil {
+				return usageErrorf("invalid --segment-size: %w", err)
+			}
+
+			memoryBudget, err := godelta.ParseSize(memoryStr)
+			if err != nil {
+				return usageErrorf("invalid --memory: %w", err)
+			}
+
+			packSmallFiles, err := godelta.ParseSize(packSmallStr)
+			if err != nil {
+				return usageErrorf("invalid --pack-small-files: %w", err)
+			}
+
 			chunkStoreSizeKB, err := parseSize(chunkStoreSizeStr)
 			if err != nil {
-				return fmt.Errorf("invalid --chunk-store-size: %w", err)
+				return usageErrorf("invalid --chunk-store-size: %w", err)
 			}
 
 			// Get total system memory (cross-platform)
@@ -93,15 +263,16 @@ func compressCmd() *cobra.Command {
 			// Logging helper
 			log := func(format string, args ...interface{}) {
 				if !quiet {
-					fmt.Printf(format+"\n", args...)
+					fmt.Fprintf(out, format+"\n", args...)
 				}
 			}
 
 			// Auto-calculate thread memory if not specified.
 			// The budget is per worker (files up to this size are compressed
 			// in RAM), so split the auto value across threads to keep the
-			// total at ~autoSizePercent of system memory.
-			if threadMemoryKB == 0 && maxThreads > 0 {
+			// total at ~autoSizePercent of system memory. A --memory budget
+			// sizes the workers itself.
+			if threadMemoryKB == 0 && maxThreads > 0 && memoryBudget == 0 {
 				threadMemoryKB = autoSizeFromSystemMemory(totalSystemMemoryKB) / uint64(maxThreads)
 				if threadMemoryKB > 0 {
 					log("Auto-calculated thread memory: %.0f MB per thread (%d%% of system memory across %d threads, capped at %.0f GB)",
@@ -109,8 +280,13 @@ func compressCmd() *cobra.Command {
 				}
 			}
 
+			// A tar stream, stdin or a block device is always deduplicated
+			if (fromTar != "" || streamInput) && chunkSizeKB == 0 {
+				chunkSizeKB = compress.DefaultTarChunkSize / 1024
+			}
+
 			// Auto-calculate chunk store size if chunking is enabled but store size not specified
-			if chunkSizeKB > 0 && chunkStoreSizeKB == 0 {
+			if (chunkSizeKB > 0 || autoChunkSize) && chunkStoreSizeKB == 0 {
 				chunkStoreSizeKB = autoSizeFromSystemMemory(totalSystemMemoryKB)
 				if chunkStoreSizeKB > 0 {
 					log("Auto-calculated chunk store size: %.0f MB (%d%% of system memory, capped at %.0f GB)",
@@ -118,39 +294,162 @@ func compressCmd() *cobra.Command {
 				}
 			}
 
+			fileOrder, err := fileOrderFor(order)
+			if err != nil {
+				return err
+			}
+			if statsBy != "" && statsBy != "folder" && statsBy != "ext" {
+				return usageErrorf("invalid --stats-by %q (use folder or ext)", statsBy)
+			}
+
 			// Prepare options
 			opts := &compress.Options{
-				InputPath:       inputPath,
-				OutputPath:      outputPath,
-				MaxThreads:      maxThreads,
-				Parallelism:     compress.Parallelism(parallelism),
-				MaxThreadMemory: threadMemoryKB * 1024,   // Convert KB to bytes
-				ChunkSize:       chunkSizeKB * 1024,      // Convert KB to bytes
-				ChunkStoreSize:  chunkStoreSizeKB / 1024, // Convert KB to MB (ChunkStoreSize is in MB)
-				Level:           compressLevel,
-				UseZipFormat:    useZipFormat,
-				UseXzFormat:     useXzFormat,
-				UseDictionary:   useDictionary,
-				DryRun:          dryRun,
-				Verbose:         verbose,
-				Quiet:           quiet,
-				UseGitignore:    useGitignore,
-				DisableGC:       disableGC,
+				InputPath:                  inputPath,
+				OutputPath:                 outputPath,
+				MaxThreads:                 maxThreads,
+				Parallelism:                compress.Parallelism(parallelism),
+				FileOrder:                  fileOrder,
+				MaxThreadMemory:            threadMemoryKB * 1024, // Convert KB to bytes
+				MemoryBudget:               memoryBudget,
+				ChunkSize:                  chunkSizeKB * 1024,      // Convert KB to bytes
+				ChunkStoreSize:             chunkStoreSizeKB / 1024, // Convert KB to MB (ChunkStoreSize is in MB)
+				ChunkMinSize:               chunkMin,
+				ChunkMaxSize:               chunkMax,
+				ChunkNormalization:         normalization,
+				ChunkingMode:               compress.ChunkingMode(chunking),
+				ChunkDictionary:            chunkDict,
+				ChunkIndexDir:              chunkIndexDir,
+				ReferenceDirs:              referenceDirs,
+				Level:                      compressLevel,
+				AutoLevel:                  autoLevel,
+				AutoChunkSize:              autoChunkSize,
+				Codec:                      codec,
+				WindowLog:                  windowLog,
+				EnableLongDistanceMatching: longMatching,
+				SegmentSize:                segmentSize,
+				DisableSegments:            noSegments,
+				UseZipFormat:               useZipFormat,
+				UseXzFormat:                useXzFormat,
+				XzParts:                    xzParts,
+				SingleZip:                  singleZip,
+				UseTarGzFormat:             useTarGzFormat,
+				Use7zFormat:                use7zFormat,
+				UseDictionary:              useDictionary,
+				DryRun:                     dryRun,
+				Verbose:                    verbose,
+				Quiet:                      quiet,
+				UseGitignore:               useGitignore,
+				IgnoreFiles:                ignores.paths(),
+				Excludes:                   excludes,
+				PathNormalization:          godelta.PathNormalization(normalizePaths),
+				StoreExtensions:            storeExts,
+				CompressAll:                compressAll,
+				PackSmallFiles:             packSmallFiles,
+				DedupFiles:                 dedupFiles,
+				SplitByFolder:              splitByFolder,
+				VerifyAfter:                verifyAfter,
+				Sync:                       !noSync,
+				NoPageCache:                noPageCache,
+				SniffTypes:                 sniffTypes,
+				DisableGC:                  disableGC,
+				ParityPercent:              parityPercent,
+				Archival:                   archival,
+				WriteManifest:              writeManifest,
+				SnapshotMode:               compress.SnapshotMode(snapshotMode),
+				AdaptiveThreads:            adaptiveThreads,
+				MinThreads:                 minThreads,
+				CPUPriority:                nice,
+				IOPriority:                 compress.IOPriority(ionice),
+				LockedFileRetries:          lockedRetries,
+				SkipLockedFiles:            skipLocked,
+				ChangeRetries:              changeRetries,
+				FailFast:                   strict,
+				MaxErrors:                  maxErrors,
+				ProgressInterval:           progressOpts.interval,
+			}
+			for _, pair := range typeCodecs {
+				pattern, name, ok := strings.Cut(pair, "=")
+				if !ok {
+					return usageErrorf("invalid --type-codec %q: expected type=codec", pair)
+				}
+				if opts.TypeCodecs == nil {
+					opts.TypeCodecs = make(map[string]string)
+				}
+				opts.TypeCodecs[strings.TrimSpace(pattern)] = strings.TrimSpace(name)
+			}
+			if withMetadata || len(labels) > 0 || archival {
+				labelMap, err := godelta.ParseLabels(labels)
+				if err != nil {
+					return err
+				}
+				opts.Metadata = &godelta.ArchiveMetadata{Version: version, Labels: labelMap}
+			}
+			if filesFrom != "" {
+				if opts.Files, err = openFileList(filesFrom, nulList); err != nil {
+					return err
+				}
+				if len(opts.Files) == 0 {
+					return usageErrorf("--files-from %s lists no paths", filesFrom)
+				}
+			}
+			opts.PathMode = compress.PathMode(pathMode)
+			for _, pair := range pathMaps {
+				from, to, ok := strings.Cut(pair, "=")
+				if !ok || from == "" {
+					return usageErrorf("invalid --path-map %q: expected source=archive", pair)
+				}
+				if opts.PathMapping == nil {
+					opts.PathMapping = make(map[string]string)
+				}
+				opts.PathMapping[from] = to
+			}
+			if len(pathMaps) > 0 && pathMode == "" {
+				opts.PathMode = compress.PathCustom
+			}
+			if inputPath == "-" {
+				opts.InputPath = ""
+				opts.FromStream = os.Stdin
+				if streamName == "" {
+					streamName = "stdin"
+				}
+			}
+			if streamInput {
+				opts.StreamName = streamName
+			}
+			if fromTar == "-" {
+				opts.FromTar = os.Stdin
+			} else if fromTar != "" {
+				f, err := os.Open(fromTar)
+				if err != nil {
+					return fmt.Errorf("open tar: %w", err)
+				}
+				defer f.Close()
+				opts.FromTar = f
 			}
 
 			// Validate and set defaults
 			if err := opts.Validate(); err != nil {
 				return err
 			}
+			notifyCfg, err := notifyOpts.options()
+			if err != nil {
+				return err
+			}
 
 			// Warn about very high compression levels
-			if !useZipFormat && compressLevel >= 15 && !quiet {
-				fmt.Println("Note: high compression level (>=15) — this will be slow but can give much better ratio")
+			if !useZipFormat && !useTarGzFormat && compressLevel >= 15 && !quiet {
+				fmt.Fprintln(out, "Note: high compression level (>=15) — this will be slow but can give much better ratio")
 			}
 
 			formatType := "GDELTA01"
-			if useXzFormat {
+			if useXzFormat && xzParts {
+				formatType = "XZ (one archive per thread)"
+			} else if useXzFormat {
 				formatType = "XZ"
+			} else if useTarGzFormat {
+				formatType = "tar.gz"
+			} else if use7zFormat {
+				formatType = "7z"
 			} else if useZipFormat {
 				formatType = "ZIP"
 			} else if useDictionary {
@@ -161,16 +460,65 @@ func compressCmd() *cobra.Command {
 
 			log("Starting compression...")
 			log("  Format:      %s", formatType)
-			log("  Input:       %s", opts.InputPath)
-			log("  Output:      %s", opts.OutputPath)
-			log("  Threads:     %d", opts.MaxThreads)
+			if fromTar != "" {
+				log("  Input:       %s (tar stream)", fromTar)
+			} else if filesFrom != "" {
+				log("  Input:       %d paths from %s (paths: %s)", len(opts.Files), filesFrom, cmp.Or(string(opts.PathMode), string(compress.PathBasename)))
+			} else if streamInput {
+				log("  Input:       %s (stream, stored as %s)", inputPath, opts.StreamName)
+			} else {
+				log("  Input:       %s", opts.InputPath)
+			}
+			if opts.SplitByFolder {
+				log("  Output:      %s (one per top-level folder)", compress.SplitPath(opts.OutputPath, "<folder>"))
+			} else {
+				log("  Output:      %s", opts.OutputPath)
+			}
+			if opts.AdaptiveThreads {
+				log("  Threads:     %d-%d (adaptive)", opts.MinThreads, opts.MaxThreads)
+			} else {
+				log("  Threads:     %d", opts.MaxThreads)
+			}
 			log("  Parallelism: %s", opts.Parallelism)
-			log("  Level:       %d", opts.Level)
+			if opts.CPUPriority > 0 || opts.IOPriority != compress.IOPriorityNormal {
+				log("  Priority:    nice %d, I/O %s", opts.CPUPriority, cmp.Or(string(opts.IOPriority), "normal"))
+			}
+			if opts.FileOrder != nil {
+				log("  Order:       %s", order)
+			}
+			if opts.AutoLevel {
+				log("  Level:       auto")
+			} else {
+				log("  Level:       %d", opts.Level)
+			}
+			if opts.Codec != "zstd" {
+				log("  Codec:       %s", opts.Codec)
+			}
+			if opts.WindowLog > 0 {
+				log("  Window:      %s", compress.FormatSize(1<<opts.WindowLog))
+			}
 			if opts.MaxThreadMemory > 0 {
 				log("  Thread Mem:  %.2f MB", float64(opts.MaxThreadMemory)/(1024*1024))
 			}
-			if opts.ChunkSize > 0 {
+			if opts.MemoryBudget > 0 {
+				log("  Memory:      %s budget (threads lowered to fit)", compress.FormatSize(opts.MemoryBudget))
+			}
+			if opts.AutoChunkSize {
+				log("  Chunk Size:  auto")
+			} else if opts.ChunkSize > 0 {
 				log("  Chunk Size:  %s", compress.FormatSize(opts.ChunkSize))
+				if opts.ChunkingMode == compress.ChunkingFixed {
+					log("  Chunking:    fixed-size blocks")
+				}
+				if opts.ChunkDictionary {
+					log("  Chunk Dict:  trained from chunk samples, stored in the header")
+				}
+				if len(opts.ReferenceDirs) > 0 {
+					log("  Reference:   %s (chunks found there aren't stored)", strings.Join(opts.ReferenceDirs, ", "))
+				}
+				if chunkMin > 0 || chunkMax > 0 || normalization != 0 {
+					log("  Bounds:      %s - %s (normalization %d)", compress.FormatSize(opts.ChunkMinSize), compress.FormatSize(opts.ChunkMaxSize), max(normalization, 0))
+				}
 				if opts.ChunkStoreSize > 0 {
 					// Calculate max chunks accounting for overhead (same formula as compress_chunked.go)
 					const overheadPerChunk = 120
@@ -180,6 +528,9 @@ func compressCmd() *cobra.Command {
 						compress.FormatSize(opts.ChunkStoreSize*1024*1024), maxChunks)
 					log("               Note: Archive size NOT limited by this - all unique chunks are saved")
 				}
+				if opts.ChunkIndexDir != "" {
+					log("  Index Dir:   %s (chunk index on disk)", opts.ChunkIndexDir)
+				}
 			}
 			if dryRun {
 				log("  Mode:        DRY-RUN (no data written)")
@@ -187,116 +538,267 @@ func compressCmd() *cobra.Command {
 			if useGitignore {
 				log("  Gitignore:   enabled")
 			}
+			if len(excludes) > 0 {
+				log("  Excludes:    %s", strings.Join(excludes, ", "))
+			}
+			if len(opts.IgnoreFiles) > 0 {
+				log("  Ignore files: %s", strings.Join(opts.IgnoreFiles, ", "))
+			}
+			if compressAll {
+				log("  Store:       disabled (compressing every file)")
+			} else if len(opts.StoreExtensions) > 0 {
+				log("  Store Exts:  defaults + %s", strings.Join(opts.StoreExtensions, ", "))
+			}
+			if opts.PackSmallFiles > 0 {
+				log("  Packing:     files up to %s", compress.FormatSize(opts.PackSmallFiles))
+			}
+			if opts.DedupFiles {
+				log("  Dedup:       identical files stored once, as links")
+			}
+			if len(typeCodecs) > 0 {
+				log("  Type codecs: %s", strings.Join(typeCodecs, ", "))
+			} else if opts.SniffTypes {
+				log("  Sniffing:    file types detected from content")
+			}
 			if disableGC {
 				log("  GC Mode:     disabled (pooled buffers)")
 			}
+			if opts.NoPageCache {
+				log("  Page cache:  bypassed for the files read")
+			}
+			if opts.ParityPercent > 0 {
+				log("  Parity:      %d%% (repairable with 'godelta repair')", opts.ParityPercent)
+			}
+			if opts.WriteManifest {
+				log("  Manifest:    %s", compress.ManifestPath(opts))
+			}
+			if opts.VerifyAfter {
+				log("  Verify:      archive read back before reporting success")
+			}
 			log("")
 
 			// Create progress callback and progress container
 			var progressCb compress.ProgressCallback
 			var progress *mpb.Progress
 
-			if !quiet && !verbose {
+			if events != nil {
+				progressCb = compress.JSONProgressCallback(events)
+			} else if progressOpts.bars(quiet, verbose) {
 				progressCb, progress = compress.ProgressBarCallback()
 			}
 
+			// Ctrl-C cancels the run (partial archive removed); a second
+			// Ctrl-C falls back to the default handler and exits at once
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				stop()
+			}()
+
 			// Perform compression
-			result, err := compress.Compress(opts, progressCb)
+			start := time.Now()
+			result, err := compress.CompressContext(ctx, opts, progressCb)
 
 			// Wait for progress bars to finish rendering
 			if progress != nil {
 				progress.Wait()
 			}
 
+			sendNotification(notifyCfg, notify.CompressSummary(opts, result, err, start))
+
 			if err != nil {
 				return err
 			}
 
 			// Final report
-			fmt.Println()
-			fmt.Print(compress.FormatSummary(result, opts))
+			fmt.Fprintln(out)
+			fmt.Fprint(out, compress.FormatSummary(result, opts))
+			switch statsBy {
+			case "folder":
+				fmt.Fprint(out, compress.FormatBreakdown("By folder", result.ByFolder))
+			case "ext":
+				fmt.Fprint(out, compress.FormatBreakdown("By extension", result.ByExtension))
+			}
+
+			// Trusted timestamp over each finished archive
+			if timestampURL != "" && !dryRun {
+				archives := []string{opts.OutputPath}
+				if opts.SplitByFolder {
+					archives = archives[:0]
+					for _, split := range result.Splits {
+						archives = append(archives, split.OutputPath)
+					}
+				}
+				for _, archive := range archives {
+					tctx, cancel := context.WithTimeout(cmd.Context(), timestamp.DefaultTimeout)
+					token, err := timestamp.Stamp(tctx, timestampURL, archive)
+					cancel()
+					if err != nil {
+						return fmt.Errorf("timestamp %s: %w", archive, err)
+					}
+					log("Timestamped at %s (token: %s)", token.Time.UTC().Format(time.RFC3339), timestamp.SidecarPath(archive))
+				}
+			}
 
 			if len(result.Errors) > 0 {
-				return fmt.Errorf("finished with %d errors", len(result.Errors))
+				return partialError(result.Errors)
 			}
 
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVarP(&inputPath, "input", "i", "", "Input file or directory (required)")
+	cmd.Flags().StringVarP(&inputPath, "input", "i", "",
+		"Input file or directory (required unless set by the profile); - reads stdin and a block device (/dev/sdb1) is read whole, each stored as a single deduplicated GDELTA02 entry")
+	cmd.Flags().StringVar(&filesFrom, "files-from", "",
+		"Compress the files and directories listed in this file, one per line (- for stdin), instead of --input")
+	cmd.Flags().BoolVarP(&nulList, "null", "0", false,
+		"Paths of --files-from are NUL-separated (find -print0, fd -0)")
+	cmd.Flags().StringVar(&pathMode, "path-mode", "",
+		"Archive paths of --files-from entries: basename (default), common-prefix, full-sans-root or custom (--path-map)")
+	cmd.Flags().StringArrayVar(&pathMaps, "path-map", nil,
+		"Store --files-from entries under a source prefix with this archive prefix, as source=archive (repeatable, implies --path-mode custom)")
+	cmd.Flags().StringVar(&streamName, "stream-name", "", "Entry name for --input - or a block device (default: stdin, or the device name)")
 	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output archive file")
+	cmd.Flags().StringVar(&fromTar, "from-tar", "",
+		"Convert this tar, tar.gz or tar.zst (- for stdin) into a deduplicated GDELTA02 archive instead of reading --input")
 	cmd.Flags().IntVarP(&maxThreads, "threads", "t", runtime.NumCPU(), "Max concurrent threads")
+	cmd.Flags().BoolVar(&adaptiveThreads, "adaptive-threads", false,
+		"Shrink the threads down to --min-threads while the machine is busy and grow them back when it is quiet (Linux, GDELTA only)")
+	cmd.Flags().IntVar(&minThreads, "min-threads", 1, "Fewest threads --adaptive-threads goes down to")
+	cmd.Flags().IntVar(&nice, "nice", 0, "Lower the CPU priority like nice: 1-19, higher is lower (0 = unchanged)")
+	cmd.Flags().StringVar(&ionice, "ionice", "", "Lower the disk priority like ionice: low or idle (Linux and Windows)")
 	cmd.Flags().StringVarP(&parallelism, "parallelism", "p", "auto", "Parallelism strategy: auto, folder, file (auto=detect based on input structure)")
+	cmd.Flags().StringVar(&order, "order", "walk",
+		"File order: walk (folder by folder) or type (grouped by extension, then by size, so similar files are chunked one after another)")
+	cmd.Flags().StringVar(&statsBy, "stats-by", "",
+		"Break the summary down by top-level folder or by extension: folder, ext (files, original and compressed size)")
 	cmd.Flags().StringVar(&threadMemoryStr, "thread-memory", "0", "Max memory per thread (e.g. 128MB, 1GB, 0=auto ~25% RAM capped at 4GB)")
-	cmd.Flags().StringVar(&chunkSizeStr, "chunk-size", "0", "Average chunk size for content-defined dedup (e.g. 64KB, 512KB, actual chunks vary 1/4x to 4x, 0=disabled)")
+	cmd.Flags().StringVar(&memoryStr, "memory", "0",
+		"Memory budget for the whole run (e.g. 2GB, 0=unlimited): encoders, buffers, queued entries, segments and dictionary samples wait for room, and fewer threads are used if needed (GDELTA formats only)")
+	cmd.Flags().StringVar(&chunkSizeStr, "chunk-size", "0", "Average chunk size for content-defined dedup (e.g. 64KB, 512KB, actual chunks vary 1/4x to 4x, 0=disabled, auto=picked on a sample of the input)")
+	cmd.Flags().StringVar(&chunkMinStr, "chunk-min", "0", "Minimum chunk size with --chunk-size (0 = chunk size / 4)")
+	cmd.Flags().StringVar(&chunkMaxStr, "chunk-max", "0", "Maximum chunk size with --chunk-size (0 = chunk size * 4)")
+	cmd.Flags().IntVar(&chunkNormalization, "chunk-normalization", 2, "FastCDC normalization level with --chunk-size: 1-3 pulls chunk sizes toward the average, 0 turns it off")
+	cmd.Flags().StringVar(&chunking, "chunking", "cdc",
+		"Chunking mode with --chunk-size: cdc (content-defined) or fixed (blocks of exactly --chunk-size, for disk images, block devices and database files)")
+	cmd.Flags().BoolVar(&chunkDict, "chunk-dict", false,
+		"With --chunk-size, train a small zstd dictionary from chunk samples and compress every chunk with it (better ratio for many small similar chunks)")
+	cmd.Flags().StringArrayVar(&referenceDirs, "reference-dir", nil,
+		"With --chunk-size, leave out the chunks found in this directory (e.g. the previous backup extracted on a NAS) and record where they lie, for a forward-delta archive that needs it to restore (repeatable)")
 	cmd.Flags().StringVar(&chunkStoreSizeStr, "chunk-store-size", "0", "Max in-memory dedup cache size (e.g. 1GB, 500MB, 0=auto ~25% RAM, does NOT limit archive size)")
+	cmd.Flags().StringVar(&chunkIndexDir, "chunk-index-dir", "",
+		"Keep the GDELTA02 chunk index in a temporary hash table file in this directory instead of RAM, for datasets with more chunks than memory holds")
 	cmd.Flags().BoolVar(&useZipFormat, "zip", false, "Create standard ZIP archive instead of GDELTA format (universally compatible)")
 	cmd.Flags().BoolVar(&useXzFormat, "xz", false, "Create standard .tar.xz archive (best compression ratio, slower than zstd)")
+	cmd.Flags().BoolVar(&singleZip, "single-zip", false, "With --zip, write one .zip instead of one per thread (files are still compressed in parallel)")
+	cmd.Flags().BoolVar(&xzParts, "xz-parts", false, "With --xz, write one .tar.xz per thread (archive_01.tar.xz, ...) instead of a single multi-block stream")
+	cmd.Flags().BoolVar(&useTarGzFormat, "tar-gz", false, "Create standard .tar.gz archive (gzip, readable everywhere)")
+	cmd.Flags().BoolVar(&use7zFormat, "7z", false, "Create standard .7z archive (solid LZMA2, like --xz)")
 	cmd.Flags().BoolVar(&useDictionary, "dictionary", false, "Use dictionary compression (GDELTA03 format, good for many small files with common patterns)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Simulate without writing anything")
 	cmd.Flags().BoolVar(&verbose, "verbose", false, "Show detailed output")
 	cmd.Flags().BoolVar(&quiet, "quiet", false, "Minimal output (overrides verbose)")
-	cmd.Flags().IntVarP(&compressLevel, "level", "l", 5,
-		"Compression level: 1-9 for ZIP deflate, 1-22 for zstd (1=fastest, 9=best default, 19=max ratio for zstd)")
+	cmd.Flags().StringVarP(&levelStr, "level", "l", "5",
+		"Compression level: 1-9 for ZIP, XZ, tar.gz and 7z, 1-22 for zstd (1=fastest, 9=best default, 19=max ratio for zstd); auto picks it on a sample of the input")
+	cmd.Flags().StringVar(&codec, "codec", "zstd",
+		"Codec for GDELTA archives: "+strings.Join(compress.Codecs(), ", ")+" (lz4/snappy trade ratio for speed, brotli the reverse)")
+	cmd.Flags().IntVar(&windowLog, "window-log", 0,
+		"zstd window as a power of two, 10-29 (e.g. 27 = 128MB; 0=level default, at most 8MB); larger windows find repeats further apart but need that much memory per worker and on extraction")
+	cmd.Flags().BoolVar(&longMatching, "long", false,
+		"Long-distance matching for big files with far-apart repeats: selects a 128MB zstd window unless --window-log is set")
+	cmd.Flags().StringVar(&segmentSizeStr, "segment-size", "64MB",
+		"Split GDELTA01 files larger than this into zstd frames compressed and extracted in parallel (e.g. 256MB)")
+	cmd.Flags().BoolVar(&noSegments, "no-segments", false,
+		"Compress every file as a single zstd stream")
 	cmd.Flags().BoolVar(&useGitignore, "gitignore", false,
 		"Respect .gitignore files to exclude matching paths")
 	cmd.Flags().BoolVar(&disableGC, "no-gc", false,
 		"Disable garbage collection during ZIP compression (reduces latency spikes, uses pooled buffers)")
-
-	_ = cmd.MarkFlagRequired("input")
+	cmd.Flags().StringArrayVar(&excludes, "exclude", nil,
+		"Exclude paths matching a gitignore-style pattern (repeatable, e.g. --exclude '*.log')")
+	ignores.register(cmd.Flags())
+	cmd.Flags().StringVar(&normalizePaths, "normalize-paths", "",
+		"Store paths in this Unicode form: preserve, nfc (Linux, Windows) or nfd (macOS HFS+); use nfc for archives made on macOS and restored elsewhere")
+	cmd.Flags().StringArrayVar(&storeExts, "store-ext", nil,
+		"Store files with this extension without compression, on top of the built-in list (repeatable, e.g. --store-ext .dat)")
+	cmd.Flags().BoolVar(&compressAll, "compress-all", false,
+		"Compress every file, including already-compressed formats (jpg, mp4, zip, gz...) stored as-is by default")
+	cmd.Flags().StringVar(&packSmallStr, "pack-small-files", "",
+		"Pack files up to this size (e.g. 16KB, at most 1MB) into shared compressed frames (GDELTA01 only)")
+	cmd.Flags().BoolVar(&dedupFiles, "dedup-files", false,
+		"Store files identical to an earlier one (hardlinks or copies) once, the others as links to it (GDELTA01 and GDELTA03)")
+	cmd.Flags().BoolVar(&noPageCache, "no-page-cache", false,
+		"Keep the files read out of the OS page cache so large backups don't evict other services' data (Linux and macOS)")
+	cmd.Flags().BoolVar(&noSync, "no-sync", false,
+		"Don't fsync the archive and its directory before reporting success (faster, but a crash right after may leave a torn archive)")
+	cmd.Flags().BoolVar(&verifyAfter, "verify", false,
+		"Read the archive back like 'verify --data' once written (and its --write-manifest manifest), failing the run if it doesn't check out (GDELTA only)")
+	cmd.Flags().BoolVar(&splitByFolder, "split-by-folder", false,
+		"Write one archive per top-level folder of the input from a single scan (backup_home.gdelta, backup_etc.gdelta...), files at the top going to --output (GDELTA only)")
+	cmd.Flags().BoolVar(&sniffTypes, "sniff-types", false,
+		"Detect each file's type from its first bytes: store compressed types as-is, report a type histogram and record types in GDELTA02/03 archives")
+	cmd.Flags().StringArrayVar(&typeCodecs, "type-codec", nil,
+		"Compress files of a sniffed type with this codec, as type=codec (repeatable, e.g. 'text/*=brotli'; GDELTA01 only, implies --sniff-types)")
+	cmd.Flags().Float64Var(&entropyThreshold, "entropy-threshold", compress.DefaultEntropyThreshold,
+		"Store files of 64KB or more whose sampled entropy reaches this many bits per byte (0-8) as-is")
+	cmd.Flags().BoolVar(&noEntropyCheck, "no-entropy-check", false,
+		"Don't sample file content; only extensions decide which files are stored as-is")
+	cmd.Flags().IntVar(&parityPercent, "parity", 0,
+		"Append Reed-Solomon parity worth this percentage of the archive (1-25, GDELTA only) so 'godelta repair' can rebuild damaged blocks")
+	cmd.Flags().BoolVar(&writeManifest, "write-manifest", false,
+		"Write the SHA-256 of every file to <archive>.sha256 (sha256sum format), checked by 'verify --data'")
+	cmd.Flags().BoolVar(&withMetadata, "metadata", false,
+		"Record the creation time, host, godelta version and source path in the archive header (GDELTA02/GDELTA03), shown by 'godelta info'")
+	cmd.Flags().BoolVar(&archival, "archival", false,
+		"Write an archive meant to be read a decade later: chunked GDELTA02 with deterministic output, the format spec and godelta version embedded, --parity 10, --write-manifest and --verify")
+	cmd.Flags().StringArrayVar(&labels, "label", nil,
+		"Record a key=value label in the archive header, implies --metadata (repeatable, e.g. --label env=prod)")
+	cmd.Flags().StringVar(&snapshotMode, "snapshot", "",
+		"Read the input from a snapshot of its volume taken for the run, as root: auto (btrfs or LVM on Linux, VSS on Windows) or vss")
+	cmd.Flags().IntVar(&lockedRetries, "locked-retries", 0,
+		"Open a file locked by another process again, every half second, up to this many times (Windows)")
+	cmd.Flags().BoolVar(&skipLocked, "skip-locked", false,
+		"Skip files still locked after --locked-retries, listing them in the summary instead of as errors")
+	cmd.Flags().IntVar(&changeRetries, "change-retries", 0,
+		"Read a file that changed while it was compressed again, up to this many times (GDELTA01 buffered entries); others are stored as read and reported as changed")
+	cmd.Flags().BoolVar(&strict, "strict", false,
+		"Treat any error as fatal: abort on the first file that fails and remove the partial archive")
+	cmd.Flags().IntVar(&maxErrors, "max-errors", 0,
+		"Abort once more than this many files failed, removing the partial archive (0 = no limit)")
+	cmd.Flags().StringVar(&timestampURL, "timestamp-url", "",
+		"Request an RFC 3161 trusted timestamp over the archive from this TSA (e.g. https://freetsa.org/tsr), saved as <archive>.tsr")
+	cmd.Flags().StringVarP(&configPath, "config", "c", "",
+		"Profile file (YAML, or TOML for a .toml file, see 'godelta config init'; default "+profile.DefaultPath+" when --profile is set)")
+	progressOpts.register(cmd.Flags())
+	progressOpts.registerInterval(cmd.Flags())
+	notifyOpts.register(cmd.Flags())
+	cmd.Flags().StringVar(&profileName, "profile", "",
+		"Profile to use from --config (default: \"default\"); flags override its values")
 
 	return cmd
 }
 
-// parseSize parses a size string (e.g., "64KB", "1MB", "2GB") and returns KB
-func parseSize(s string) (uint64, error) {
-	if s == "" || s == "0" {
-		return 0, nil
-	}
-
-	s = strings.ToUpper(strings.TrimSpace(s))
-
-	// Extract number and unit
-	var numStr string
-	var unit string
-
-	for i, r := range s {
-		if r >= '0' && r <= '9' || r == '.' {
-			numStr += string(r)
-		} else {
-			unit = s[i:]
-			break
-		}
-	}
-
-	if numStr == "" {
-		return 0, fmt.Errorf("no number found in size: %s", s)
+// fileOrderFor returns the compress.FileOrder of an --order value
+func fileOrderFor(order string) (compress.FileOrder, error) {
+	switch order {
+	case "", "walk":
+		return nil, nil
+	case "type":
+		return compress.OrderByType, nil
+	default:
+		return nil, fmt.Errorf("invalid --order %q (use walk or type)", order)
 	}
+}
 
-	num, err := strconv.ParseFloat(numStr, 64)
+// parseSize parses a size string (e.g., "64KB", "1MB", "2GB") and returns KB
+func parseSize(s string) (uint64, error) {
+	bytes, err := godelta.ParseSize(s)
 	if err != nil {
-		return 0, fmt.Errorf("invalid number: %s", numStr)
+		return 0, err
 	}
-
-	// Convert to KB based on unit
-	var kb uint64
-	switch unit {
-	case "B", "":
-		kb = uint64(num / 1024)
-	case "KB", "K":
-		kb = uint64(num)
-	case "MB", "M":
-		kb = uint64(num * 1024)
-	case "GB", "G":
-		kb = uint64(num * 1024 * 1024)
-	case "TB", "T":
-		kb = uint64(num * 1024 * 1024 * 1024)
-	default:
-		return 0, fmt.Errorf("unknown unit: %s (use B, KB, MB, GB, TB)", unit)
-	}
-
-	return kb, nil
+	return bytes / 1024, nil
 }
 
 const (
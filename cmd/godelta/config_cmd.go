@@ -0,0 +1,127 @@
+// cmd/godelta/config_cmd.go
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/creativeyann17/go-delta/pkg/profile"
+)
+
+func init() {
+	rootCmd.AddCommand(configCmd())
+}
+
+func configCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage compression profile files",
+	}
+	cmd.AddCommand(configInitCmd())
+	return cmd
+}
+
+func configInitCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "init [path]",
+		Short: "Write a sample profile file (default: " + profile.DefaultPath + "; TOML for a .toml path)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := profile.DefaultPath
+			if len(args) == 1 {
+				path = args[0]
+			}
+			if err := profile.WriteSample(path, force); err != nil {
+				return err
+			}
+			fmt.Printf("Wrote %s\n", path)
+			fmt.Printf("Use it with: godelta compress --config %s --profile <name>\n", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing file")
+
+	return cmd
+}
+
+// applyProfile loads the selected profile and fills in every flag the user
+// didn't set explicitly, so flags always override file values
+func applyProfile(flags *pflag.FlagSet, configPath, name string) error {
+	if configPath == "" && name == "" {
+		return nil
+	}
+	if configPath == "" {
+		configPath = profile.DefaultPath
+	}
+
+	cfg, err := profile.Load(configPath)
+	if err != nil {
+		return err
+	}
+	p, err := cfg.Get(name)
+	if err != nil {
+		return err
+	}
+
+	values := []struct {
+		flag  string
+		value string
+		set   bool
+	}{
+		{"input", p.Input, p.Input != ""},
+		{"output", p.Output, p.Output != ""},
+		{"threads", strconv.Itoa(p.Threads), p.Threads != 0},
+		{"parallelism", p.Parallelism, p.Parallelism != ""},
+		{"order", p.Order, p.Order != ""},
+		{"thread-memory", p.ThreadMemory, p.ThreadMemory != ""},
+		{"chunk-size", p.ChunkSize, p.ChunkSize != ""},
+		{"chunk-min", p.ChunkMin, p.ChunkMin != ""},
+		{"chunk-max", p.ChunkMax, p.ChunkMax != ""},
+		{"chunk-normalization", intValue(p.ChunkNormalization), p.ChunkNormalization != nil},
+		{"chunking", p.Chunking, p.Chunking != ""},
+		{"chunk-store-size", p.ChunkStoreSize, p.ChunkStoreSize != ""},
+		{"level", strconv.Itoa(p.Level), p.Level != 0},
+		{"zip", "true", p.Zip},
+		{"single-zip", "true", p.SingleZip},
+		{"xz", "true", p.Xz},
+		{"xz-parts", "true", p.XzParts},
+		{"tar-gz", "true", p.TarGz},
+		{"7z", "true", p.SevenZip},
+		{"dictionary", "true", p.Dictionary},
+		{"gitignore", "true", p.Gitignore},
+		{"parity", strconv.Itoa(p.Parity), p.Parity != 0},
+		{"archival", "true", p.Archival},
+		{"verify", "true", p.Verify},
+	}
+	for _, v := range values {
+		if !v.set || flags.Changed(v.flag) {
+			continue
+		}
+		if err := flags.Set(v.flag, v.value); err != nil {
+			return usageErrorf("profile %s: %w", v.flag, err)
+		}
+	}
+
+	if !flags.Changed("exclude") {
+		for _, pattern := range p.Excludes {
+			if err := flags.Set("exclude", pattern); err != nil {
+				return usageErrorf("profile excludes: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// intValue formats an optional profile value as a flag value
+func intValue(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
@@ -0,0 +1,70 @@
+// cmd/godelta/copy_cmd.go
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/replicate"
+)
+
+func init() {
+	rootCmd.AddCommand(copyCmd())
+}
+
+func copyCmd() *cobra.Command {
+	var from, to, output string
+	var overwrite, verifyCopy, verbose, quiet bool
+
+	cmd := &cobra.Command{
+		Use:   "copy --from <dir> --to <dir> <archive>",
+		Short: "Copy a GDELTA02 archive, transferring only chunks the destination lacks",
+		Long: `Copy a GDELTA02 archive from one directory of archives (a repository) to
+another, reading from the source only the chunks no archive at the
+destination already holds. The other chunks are copied from the
+destination's archives, so replicating each night's backup offsite
+transfers little more than what changed. Archives at the destination must
+use the same codec to share chunks.
+
+The archive name is relative to --from unless absolute.
+
+Example:
+
+  godelta copy --from /mnt/offsite-a --to /mnt/offsite-b nightly-0614.gdelta --verify`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			source := args[0]
+			if from != "" && !filepath.IsAbs(source) {
+				source = filepath.Join(from, source)
+			}
+			opts := &replicate.Options{
+				SourcePath: source,
+				DestDir:    to,
+				OutputPath: output,
+				Overwrite:  overwrite,
+				Verify:     verifyCopy,
+				Verbose:    verbose,
+				Quiet:      quiet,
+			}
+			result, err := replicate.Copy(opts)
+			if err != nil {
+				return err
+			}
+			if !quiet {
+				fmt.Print(result.Summary())
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "Source directory of archives")
+	cmd.Flags().StringVar(&to, "to", "", "Destination directory of archives (required)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Path of the copy (default: the archive's name in --to)")
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Replace an existing copy")
+	cmd.Flags().BoolVar(&verifyCopy, "verify", false, "Verify the data of the copy before putting it in place")
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "List the destination archives chunks are taken from")
+	cmd.Flags().BoolVar(&quiet, "quiet", false, "Minimal output")
+	return cmd
+}
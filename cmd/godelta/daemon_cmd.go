@@ -0,0 +1,74 @@
+// cmd/godelta/daemon_cmd.go
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/daemon"
+)
+
+func init() {
+	rootCmd.AddCommand(daemonCmd())
+}
+
+func daemonCmd() *cobra.Command {
+	var listenAddr string
+	var metricsAddr string
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the gRPC remote control service",
+		Long: `Run a gRPC server exposing Compress, Decompress, Verify and List.
+
+Each call streams progress events followed by a final result
+(see api/godeltapb/godelta.proto). Paths are resolved on this host,
+so only listen on addresses reachable by trusted callers.
+
+Use unix:/path/to/socket to listen on a Unix domain socket.
+Use --metrics-addr to expose Prometheus metrics over HTTP.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			network, address := "tcp", listenAddr
+			if path, ok := strings.CutPrefix(listenAddr, "unix:"); ok {
+				network, address = "unix", path
+				// A stale socket from an unclean shutdown blocks Listen
+				_ = os.Remove(address)
+			}
+
+			lis, err := net.Listen(network, address)
+			if err != nil {
+				return fmt.Errorf("listen on %s: %w", listenAddr, err)
+			}
+
+			server := daemon.Register()
+
+			if metricsAddr != "" {
+				stopMetrics := serveMetrics(metricsAddr)
+				defer stopMetrics()
+				fmt.Printf("Metrics endpoint: http://%s/metrics\n", metricsAddr)
+			}
+
+			sigCh := make(chan os.Signal, 1)
+			signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-sigCh
+				fmt.Println("Shutting down, waiting for running operations...")
+				server.GracefulStop()
+			}()
+
+			fmt.Printf("godelta daemon listening on %s\n", lis.Addr())
+			return server.Serve(lis)
+		},
+	}
+
+	cmd.Flags().StringVarP(&listenAddr, "listen", "l", "127.0.0.1:7420", "Address to listen on (host:port or unix:/path)")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this address at /metrics (e.g. 127.0.0.1:9420)")
+
+	return cmd
+}
@@ -5,12 +5,14 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/vbauerster/mpb/v8"
 
 	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
 )
 
 func init() {
@@ -23,11 +25,39 @@ func decompressCmd() *cobra.Command {
 	var verbose bool
 	var quiet bool
 	var overwrite bool
+	var onConflict string
+	var recoverMode bool
+	var repackPath string
+	var streamTar bool
+	var paths []string
+	var referenceDirs []string
+	var rewrites, rewriteRegexps []string
+	var normalizePaths string
+	var caseInsensitive bool
+	var checkSpace bool
+	var minFreeStr string
+	var useMmap bool
+	var hardlinks bool
+	var dryRun bool
+	var toTar string
+	var progressOpts progressFlags
 
 	cmd := &cobra.Command{
-		Use:   "decompress",
+		Use:   "decompress [archive]",
 		Short: "Decompress delta archive to files",
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				if inputPath != "" {
+					return usageErrorf("give the archive with -i or as the argument, not both")
+				}
+				inputPath = args[0]
+			}
+
+			if archive, ok := writeOnlyArchive(inputPath); ok {
+				return fmt.Errorf("%s: %w", archive, decompress.ErrWriteOnlyFormat)
+			}
+
 			// Add extension if missing
 			if inputPath != "" {
 				hasZip := strings.HasSuffix(inputPath, ".zip")
@@ -49,14 +79,50 @@ func decompressCmd() *cobra.Command {
 				}
 			}
 
+			pathRewrites, err := parseRewrites(rewrites, rewriteRegexps)
+			if err != nil {
+				return err
+			}
+			minFree, err := godelta.ParseSize(minFreeStr)
+			if err != nil {
+				return usageErrorf("invalid --min-free: %w", err)
+			}
+
 			// Prepare options
 			opts := &decompress.Options{
-				InputPath:  inputPath,
-				OutputPath: outputPath,
-				MaxThreads: maxThreads,
-				Verbose:    verbose,
-				Quiet:      quiet,
-				Overwrite:  overwrite,
+				InputPath:         inputPath,
+				OutputPath:        outputPath,
+				MaxThreads:        maxThreads,
+				Verbose:           verbose,
+				Quiet:             quiet,
+				Overwrite:         overwrite,
+				ConflictPolicy:    decompress.ConflictPolicy(onConflict),
+				Recover:           recoverMode,
+				RepackPath:        repackPath,
+				StreamTar:         streamTar,
+				Paths:             paths,
+				ReferenceDirs:     referenceDirs,
+				PathRewrites:      pathRewrites,
+				PathNormalization: godelta.PathNormalization(normalizePaths),
+				CaseInsensitive:   caseInsensitive,
+				CheckSpace:        checkSpace,
+				MinFreeSpace:      minFree,
+				Mmap:              useMmap,
+				Hardlinks:         hardlinks,
+				DryRun:            dryRun,
+				ProgressInterval:  progressOpts.interval,
+			}
+
+			// A tar of the restore goes to stdout, or to a file or named pipe
+			if toTar == decompress.StdoutPath {
+				opts.TarOutput = os.Stdout
+			} else if toTar != "" {
+				tarFile, err := os.OpenFile(toTar, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+				if err != nil {
+					return fmt.Errorf("open tar output: %w", err)
+				}
+				defer tarFile.Close()
+				opts.TarOutput = tarFile
 			}
 
 			// Validate and set defaults
@@ -64,18 +130,55 @@ func decompressCmd() *cobra.Command {
 				return err
 			}
 
+			// Streaming to stdout: the restored data owns stdout, so
+			// messages move to stderr and progress bars are off
+			toStdout := (outputPath == decompress.StdoutPath && repackPath == "") || toTar == decompress.StdoutPath
+			if toStdout && verbose && !quiet {
+				return usageErrorf("--verbose writes to stdout and can't be combined with -o - or --to-tar -")
+			}
+
+			events, out, err := progressOpts.open(verbose && !quiet)
+			if err != nil {
+				return err
+			}
+			if toStdout {
+				if events == os.Stdout {
+					return usageErrorf("--progress json on stdout can't be combined with -o - or --to-tar - (use --progress-fd)")
+				}
+				out = os.Stderr
+			}
+
 			// Logging helper
 			log := func(format string, args ...interface{}) {
 				if !quiet {
-					fmt.Printf(format+"\n", args...)
+					fmt.Fprintf(out, format+"\n", args...)
 				}
 			}
 
 			log("Starting decompression...")
 			log("  Input:       %s", opts.InputPath)
-			log("  Output:      %s", opts.OutputPath)
-			if overwrite {
+			if repackPath != "" {
+				log("  Repack:      %s", opts.RepackPath)
+			} else if toTar != "" {
+				log("  Tar output:  %s", toTar)
+			} else {
+				log("  Output:      %s", opts.OutputPath)
+			}
+			if dryRun {
+				log("  Mode:        DRY RUN (nothing is written)")
+			} else if opts.ConflictPolicy == decompress.ConflictOverwrite || (opts.ConflictPolicy == "" && overwrite) {
 				log("  Mode:        OVERWRITE (replacing existing files)")
+			} else if opts.ConflictPolicy != "" && opts.ConflictPolicy != decompress.ConflictError {
+				log("  On conflict: %s", opts.ConflictPolicy)
+			}
+			if recoverMode {
+				log("  Recovery:    extracting intact entries only")
+			}
+			if len(paths) > 0 {
+				log("  Paths:       %s", strings.Join(paths, ", "))
+			}
+			for _, rw := range pathRewrites {
+				log("  Rewrite:     %s -> %s", rw.From, rw.To)
 			}
 			log("")
 
@@ -83,7 +186,9 @@ func decompressCmd() *cobra.Command {
 			var progressCb decompress.ProgressCallback
 			var progress *mpb.Progress
 
-			if !quiet && !verbose {
+			if events != nil {
+				progressCb = decompress.JSONProgressCallback(events)
+			} else if progressOpts.bars(quiet, verbose) && !toStdout {
 				progressCb, progress = decompress.ProgressBarCallback()
 			}
 
@@ -100,25 +205,89 @@ func decompressCmd() *cobra.Command {
 			}
 
 			// Final report
-			fmt.Println()
-			fmt.Print(decompress.FormatSummary(result))
+			fmt.Fprintln(out)
+			fmt.Fprint(out, decompress.FormatSummary(result))
 
 			if len(result.Errors) > 0 {
-				return fmt.Errorf("finished with %d errors", len(result.Errors))
+				return partialError(result.Errors)
+			}
+			if result.Plan != nil && !result.Plan.EnoughSpace() {
+				return godelta.WithCategory(godelta.ErrNoSpace, fmt.Errorf("not enough free space in %s", opts.OutputPath))
 			}
 
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVarP(&inputPath, "input", "i", "", "Input archive file (required)")
-	cmd.Flags().StringVarP(&outputPath, "output", "o", ".", "Output directory")
+	cmd.Flags().StringVarP(&inputPath, "input", "i", "", "Input archive file (or give it as the argument)")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", ".", "Output directory, or - / a named pipe to stream the restore")
 	cmd.Flags().IntVarP(&maxThreads, "threads", "t", 0, "Max concurrent threads (0 = number of CPUs)")
 	cmd.Flags().BoolVar(&verbose, "verbose", false, "Show detailed output")
 	cmd.Flags().BoolVar(&quiet, "quiet", false, "Minimal output (overrides verbose)")
 	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite existing files")
+	cmd.Flags().StringVar(&onConflict, "on-conflict", "", "What to do with files that already exist: overwrite, skip, rename, keep-newer or error (default error, or overwrite with --overwrite)")
+	cmd.Flags().BoolVar(&recoverMode, "recover", false, "Extract the intact part of a GDELTA archive with a damaged tail or footer")
+	cmd.Flags().BoolVar(&streamTar, "stream-tar", false, "Stream a tar even for a single-entry archive (with -o - or a named pipe)")
+	cmd.Flags().StringArrayVar(&paths, "path", nil, "Only extract this file or directory from the archive (repeatable)")
+	cmd.Flags().StringArrayVar(&referenceDirs, "reference-dir", nil, "Read the chunks a GDELTA02 archive made with --reference-dir takes from this directory instead of the one it recorded (repeatable, in the same order)")
+	cmd.Flags().StringArrayVar(&rewrites, "rewrite", nil, "Restore the entries under a path prefix somewhere else, as FROM=TO (repeatable)")
+	cmd.Flags().StringArrayVar(&rewriteRegexps, "rewrite-regex", nil, "Rewrite entry paths matching a regular expression, as PATTERN=REPLACEMENT ($1 for groups, repeatable, tried after --rewrite)")
+	cmd.Flags().StringVar(&normalizePaths, "normalize-paths", "", "Restore entry paths in this Unicode form: preserve, nfc (Linux, Windows) or nfd (macOS HFS+)")
+	cmd.Flags().BoolVar(&caseInsensitive, "case-insensitive", false, "Check entries for case-only collisions (README.md, readme.md) even when the output is case-sensitive")
+	cmd.Flags().BoolVar(&checkSpace, "check-space", false, "Check that the archive's files fit in the free space before extracting anything")
+	cmd.Flags().StringVar(&minFreeStr, "min-free", "", "Stop the restore cleanly once the output has less free space than this (e.g. 2GB); run again with --on-conflict skip to resume")
+	cmd.Flags().BoolVar(&useMmap, "mmap", false, "Memory-map the archive instead of reading it (falls back to reads when it can't be mapped)")
+	cmd.Flags().BoolVar(&hardlinks, "hardlinks", false, "Restore files deduplicated with --dedup-files as hardlinks to their first copy instead of copies")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report the files a restore would create, overwrite or skip and the space it needs, without writing")
+	cmd.Flags().StringVar(&toTar, "to-tar", "", "Write an uncompressed tar of every entry to this file, named pipe or - (stdout) instead of extracting")
+	cmd.Flags().StringVar(&repackPath, "repack", "", "Convert the archive into this .zip or .tar.xz instead of extracting")
 
-	_ = cmd.MarkFlagRequired("input")
+	progressOpts.register(cmd.Flags())
+	progressOpts.registerInterval(cmd.Flags())
 
 	return cmd
 }
+
+// writeOnlyArchive returns the tar.gz or 7z archive path names, directly
+// or as the base of a single or multi-part one, before a .gdelta extension
+// is assumed for it
+func writeOnlyArchive(path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	for _, ext := range decompress.WriteOnlyExtensions {
+		if strings.HasSuffix(path, ext) {
+			return path, true
+		}
+	}
+	if filepath.Ext(path) != "" {
+		return "", false
+	}
+	for _, ext := range decompress.WriteOnlyExtensions {
+		for _, candidate := range []string{path + ext, path + "_01" + ext} {
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate, true
+			}
+		}
+	}
+	return "", false
+}
+
+// parseRewrites turns --rewrite and --rewrite-regex values into path
+// rewrites, prefixes first. Values split at their first '='.
+func parseRewrites(prefixes, patterns []string) ([]decompress.Rewrite, error) {
+	var rewrites []decompress.Rewrite
+	for _, flag := range []struct {
+		values []string
+		regexp bool
+	}{{prefixes, false}, {patterns, true}} {
+		for _, v := range flag.values {
+			from, to, ok := strings.Cut(v, "=")
+			if !ok {
+				return nil, fmt.Errorf("rewrite %q: expected FROM=TO", v)
+			}
+			rewrites = append(rewrites, decompress.Rewrite{From: from, To: to, Regexp: flag.regexp})
+		}
+	}
+	return rewrites, nil
+}
@@ -0,0 +1,41 @@
+// cmd/godelta/dedup_report_cmd.go
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+func init() {
+	rootCmd.AddCommand(dedupReportCmd())
+}
+
+func dedupReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dedup-report <archive> <archive>...",
+		Short: "Report the chunks several GDELTA02 archives share",
+		Long: `Read the chunk indexes of several GDELTA02 archives and report how much
+chunk data they hold in common: per archive, per pair of archives, and
+what storing every chunk once in a shared repository would save. Only the
+headers and chunk indexes are read.
+
+Archives chunked with different chunk sizes or bounds rarely share chunks.
+
+Example:
+
+  godelta dedup-report mon.gdelta tue.gdelta wed.gdelta`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, err := verify.CrossDedup(args)
+			if err != nil {
+				return err
+			}
+			fmt.Print(report.Summary())
+			return nil
+		},
+	}
+	return cmd
+}
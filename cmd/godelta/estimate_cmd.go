@@ -0,0 +1,111 @@
+// cmd/godelta/estimate_cmd.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+func init() {
+	rootCmd.AddCommand(estimateCmd())
+}
+
+func estimateCmd() *cobra.Command {
+	var inputPath string
+	var chunkSizeStr string
+	var candidateStrs []string
+	var chunking string
+	var chunkNormalization int
+	var maxThreads int
+	var useGitignore bool
+	var excludes []string
+	var ignores ignoreFlags
+
+	cmd := &cobra.Command{
+		Use:   "estimate",
+		Short: "Estimate chunk deduplication without creating an archive",
+		Long: `Chunk the input with several chunk sizes and report, for each, how much
+unique data a GDELTA02 archive would store. Nothing is compressed or
+written, and each file is read once for all sizes.
+
+The recommended size is the one with the smallest projected archive before
+compression (unique data plus chunk metadata). --chunk-size adds a size to
+the candidates; --candidates replaces the default list.
+
+Example:
+
+  godelta estimate -i /srv/data --chunk-size 64KB`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var sizes []uint64
+			if len(candidateStrs) == 0 {
+				sizes = append(sizes, compress.DefaultEstimateChunkSizes...)
+			}
+			for _, s := range candidateStrs {
+				size, err := godelta.ParseSize(s)
+				if err != nil {
+					return usageErrorf("invalid --candidates: %w", err)
+				}
+				sizes = append(sizes, size)
+			}
+			if chunkSizeStr != "" {
+				size, err := godelta.ParseSize(chunkSizeStr)
+				if err != nil {
+					return usageErrorf("invalid --chunk-size: %w", err)
+				}
+				sizes = append(sizes, size)
+			}
+			// 0 turns normalization off; unset keeps the default
+			var normalization int
+			if cmd.Flags().Changed("chunk-normalization") {
+				normalization = chunkNormalization
+				if normalization == 0 {
+					normalization = compress.ChunkNormalizationOff
+				}
+			}
+
+			opts := &compress.Options{
+				InputPath:          inputPath,
+				MaxThreads:         maxThreads,
+				ChunkingMode:       compress.ChunkingMode(chunking),
+				ChunkNormalization: normalization,
+				UseGitignore:       useGitignore,
+				IgnoreFiles:        ignores.paths(),
+				Excludes:           excludes,
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			result, err := compress.Estimate(ctx, opts, sizes, nil)
+			if err != nil {
+				return err
+			}
+			fmt.Print(result.Summary())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputPath, "input", "i", "", "Input file or directory (required)")
+	cmd.Flags().StringVar(&chunkSizeStr, "chunk-size", "", "Chunk size to add to the candidates (e.g. 64KB)")
+	cmd.Flags().StringSliceVar(&candidateStrs, "candidates", nil,
+		"Chunk sizes to compare, replacing the default 16KB to 1MB (e.g. 32KB,64KB,128KB)")
+	cmd.Flags().StringVar(&chunking, "chunking", "cdc", "Chunking mode: cdc (content-defined) or fixed")
+	cmd.Flags().IntVar(&chunkNormalization, "chunk-normalization", 2, "FastCDC normalization level: 1-3, 0 turns it off")
+	cmd.Flags().IntVarP(&maxThreads, "threads", "t", runtime.NumCPU(), "Files read at once")
+	cmd.Flags().BoolVar(&useGitignore, "gitignore", false, "Respect .gitignore files to exclude matching paths")
+	cmd.Flags().StringArrayVar(&excludes, "exclude", nil,
+		"Exclude paths matching a gitignore-style pattern (repeatable, e.g. --exclude '*.log')")
+	ignores.register(cmd.Flags())
+
+	_ = cmd.MarkFlagRequired("input")
+
+	return cmd
+}
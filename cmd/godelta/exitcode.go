@@ -0,0 +1,59 @@
+// cmd/godelta/exitcode.go
+package main
+
+import (
+	"fmt"
+
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// Exit codes by error category, documented in the README
+const (
+	exitFailure     = 1 // unclassified error
+	exitUsage       = 2
+	exitPartial     = 3
+	exitCorrupt     = 4
+	exitNotFound    = 5
+	exitExists      = 6
+	exitPermission  = 7
+	exitNoSpace     = 8
+	exitIO          = 9
+	exitUnsupported = 10
+	exitCanceled    = 130 // as a shell reports SIGINT
+)
+
+var exitCodes = map[error]int{
+	godelta.ErrUsage:       exitUsage,
+	godelta.ErrPartial:     exitPartial,
+	godelta.ErrCorrupt:     exitCorrupt,
+	godelta.ErrNotFound:    exitNotFound,
+	godelta.ErrExists:      exitExists,
+	godelta.ErrPermission:  exitPermission,
+	godelta.ErrNoSpace:     exitNoSpace,
+	godelta.ErrIO:          exitIO,
+	godelta.ErrUnsupported: exitUnsupported,
+	godelta.ErrCanceled:    exitCanceled,
+}
+
+// exitCode returns the process exit code for a command error
+func exitCode(err error) int {
+	if code, ok := exitCodes[godelta.Category(err)]; ok {
+		return code
+	}
+	return exitFailure
+}
+
+// usageErrorf formats an invalid flag error
+func usageErrorf(format string, args ...any) error {
+	return godelta.WithCategory(godelta.ErrUsage, fmt.Errorf(format, args...))
+}
+
+// partialError reports a run that finished with per-file errors
+func partialError(errs []error) error {
+	return godelta.WithCategory(godelta.ErrPartial, fmt.Errorf("finished with %d errors", len(errs)))
+}
+
+// corruptErrorf formats a failed integrity check
+func corruptErrorf(format string, args ...any) error {
+	return godelta.WithCategory(godelta.ErrCorrupt, fmt.Errorf(format, args...))
+}
@@ -0,0 +1,63 @@
+// cmd/godelta/files_from.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxListEntry bounds one path of a --files-from list
+const maxListEntry = 1 << 20
+
+// readFileList reads the paths of a --files-from list: one per line, or
+// NUL-separated with nul (find -print0, fd -0). Empty entries are skipped;
+// lines lose a trailing \r.
+func readFileList(r io.Reader, nul bool) ([]string, error) {
+	sep := byte('\n')
+	if nul {
+		sep = 0
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxListEntry)
+	sc.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, sep); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+
+	var paths []string
+	for sc.Scan() {
+		path := sc.Text()
+		if !nul {
+			path = strings.TrimSuffix(path, "\r")
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read file list: %w", err)
+	}
+	return paths, nil
+}
+
+// openFileList reads the list at path, - for stdin
+func openFileList(path string, nul bool) ([]string, error) {
+	if path == "-" {
+		return readFileList(os.Stdin, nul)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file list: %w", err)
+	}
+	defer f.Close()
+	return readFileList(f, nul)
+}
@@ -0,0 +1,118 @@
+// cmd/godelta/format_cmd.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// hexdumpLimit is how much of each field --hexdump prints
+const hexdumpLimit = 64
+
+func init() {
+	rootCmd.AddCommand(formatCmd())
+}
+
+func formatCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "format",
+		Short: "Inspect the on-disk format of archives",
+	}
+	cmd.AddCommand(formatDescribeCmd())
+	return cmd
+}
+
+func formatDescribeCmd() *cobra.Command {
+	var hexdump bool
+	var maxEntries int
+
+	cmd := &cobra.Command{
+		Use:   "describe <archive>",
+		Short: "Print the annotated layout of a GDELTA archive",
+		Long: `Walk a GDELTA archive field by field and print where each field lies,
+its size and its decoded value: header fields, extension areas, entry
+tables, data spans, footer, index and parity section. Only the first
+--max-entries entries of each table are printed; the others are walked
+and counted.
+
+A field that doesn't fit in the archive (a length or count past the end
+of the file) stops the walk: the layout up to it is printed, then the
+error.
+
+Example:
+
+  godelta format describe backup.gdelta
+  godelta format describe --hexdump --max-entries 3 backup.gdelta`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			layout, err := verify.Describe(args[0], maxEntries)
+			if layout == nil {
+				return err
+			}
+
+			var f *os.File
+			if hexdump {
+				if f, err = os.Open(args[0]); err != nil {
+					return err
+				}
+				defer f.Close()
+			}
+
+			fmt.Printf("Archive: %s (%s, %d bytes)\n\n", args[0], layout.Format, layout.Size)
+			fmt.Printf("%-10s  %10s  %-32s  %s\n", "Offset", "Size", "Field", "Value")
+			for _, field := range layout.Fields {
+				line := fmt.Sprintf("0x%08x  %10d  %-32s  %s", field.Offset, field.Size, field.Name, field.Value)
+				fmt.Println(strings.TrimRight(line, " "))
+				if f != nil {
+					printHexdump(f, field.Offset, field.Size)
+				}
+			}
+			if layout.Omitted > 0 {
+				fmt.Printf("\n%d table entries not shown (--max-entries %d)\n", layout.Omitted, maxEntries)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&hexdump, "hexdump", false, fmt.Sprintf("Print the bytes of every field under it (the first %d)", hexdumpLimit))
+	cmd.Flags().IntVar(&maxEntries, "max-entries", 10, "Entries of each table to print (0 = all)")
+	return cmd
+}
+
+// printHexdump prints the first hexdumpLimit bytes at offset, 16 per line,
+// with their offset in the archive
+func printHexdump(r io.ReaderAt, offset, size int64) {
+	buf := make([]byte, min(size, hexdumpLimit))
+	n, _ := r.ReadAt(buf, offset)
+	buf = buf[:n]
+	for line := 0; line < len(buf); line += 16 {
+		row := buf[line:min(line+16, len(buf))]
+		var hex, text strings.Builder
+		for i := range 16 {
+			if i < len(row) {
+				fmt.Fprintf(&hex, "%02x ", row[i])
+			} else {
+				hex.WriteString("   ")
+			}
+			if i == 7 {
+				hex.WriteByte(' ')
+			}
+		}
+		for _, b := range row {
+			if b < 0x20 || b > 0x7e {
+				b = '.'
+			}
+			text.WriteByte(b)
+		}
+		fmt.Printf("    %08x  %s |%s|\n", offset+int64(line), hex.String(), text.String())
+	}
+	if size > int64(n) {
+		fmt.Printf("    ... %d more bytes\n", size-int64(n))
+	}
+}
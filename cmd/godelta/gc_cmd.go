@@ -0,0 +1,48 @@
+// cmd/godelta/gc_cmd.go
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/gc"
+)
+
+func init() {
+	rootCmd.AddCommand(gcCmd())
+}
+
+func gcCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "gc <archive>",
+		Short: "Drop the chunks no file references from a GDELTA02 archive",
+		Long: `Rewrite a GDELTA02 archive without its orphaned chunks, the chunks no
+file references ('verify' counts them), and compact the offsets of the
+others. Files and chunks are copied as they are, so a .sha256 manifest
+stays valid; parity is computed again. The new archive is written next
+to the original and verified before it replaces it.
+
+With --dry-run, the orphaned chunks are counted and the space they take
+is reported without touching the archive.
+
+Example:
+
+  godelta gc --dry-run backup.gdelta
+  godelta gc backup.gdelta`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := gc.Collect(args[0], dryRun)
+			if err != nil {
+				return err
+			}
+			fmt.Print(result.Summary())
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report the orphaned chunks without rewriting the archive")
+	return cmd
+}
@@ -0,0 +1,36 @@
+// cmd/godelta/ignore_flags.go
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+)
+
+// ignoreFlags holds --ignore-file and --no-global-ignore, shared by the
+// commands that scan an input (compress, watch, estimate, bench)
+type ignoreFlags struct {
+	files    []string
+	noGlobal bool
+}
+
+func (f *ignoreFlags) register(flags *pflag.FlagSet) {
+	flags.StringArrayVar(&f.files, "ignore-file", nil,
+		"Exclude paths matching the gitignore-style patterns of this file, applied at the input root (repeatable, later files win)")
+	flags.BoolVar(&f.noGlobal, "no-global-ignore", false,
+		"Don't read the global ignore file ("+compress.GlobalIgnoreFile()+")")
+}
+
+// paths returns compress.Options.IgnoreFiles: the global ignore file when it
+// exists, then the --ignore-file ones
+func (f *ignoreFlags) paths() []string {
+	var paths []string
+	if global := compress.GlobalIgnoreFile(); global != "" && !f.noGlobal {
+		if _, err := os.Stat(global); err == nil {
+			paths = append(paths, global)
+		}
+	}
+	return append(paths, f.files...)
+}
@@ -0,0 +1,73 @@
+// cmd/godelta/info_cmd.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+func init() {
+	rootCmd.AddCommand(infoCmd())
+}
+
+func infoCmd() *cobra.Command {
+	var asJSON bool
+	var formatSpec bool
+
+	cmd := &cobra.Command{
+		Use:   "info <archive>",
+		Short: "Show the metadata recorded in an archive",
+		Long: `Show the metadata recorded in the header of a GDELTA02 or GDELTA03
+archive by 'compress --metadata' or '--label': when and where it was made,
+by which version, from which path, and its labels. Only the header is read.
+
+--format-spec prints the layout summary 'compress --archival' embeds
+instead.
+
+Example:
+
+  godelta info backup.gdelta
+  godelta info --json backup.gdelta
+  godelta info --format-spec backup.gdelta`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if formatSpec {
+				spec, err := verify.ReadFormatSpec(args[0])
+				if err != nil {
+					return err
+				}
+				if spec == "" {
+					fmt.Printf("%s: no format spec embedded\n", args[0])
+					return nil
+				}
+				fmt.Print(spec)
+				return nil
+			}
+			m, err := verify.ReadMetadata(args[0])
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(m)
+			}
+			if m == nil {
+				fmt.Printf("%s: no metadata recorded\n", args[0])
+				return nil
+			}
+			fmt.Printf("Archive: %s\n%s", args[0], godelta.FormatMetadata(m, "  "))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the metadata as JSON (null when there is none)")
+	cmd.Flags().BoolVar(&formatSpec, "format-spec", false, "Print the archive layout summary embedded by 'compress --archival'")
+	return cmd
+}
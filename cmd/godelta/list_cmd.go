@@ -0,0 +1,85 @@
+// cmd/godelta/list_cmd.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+func init() {
+	rootCmd.AddCommand(listCmd())
+}
+
+// listEntry is one file of 'list --json'
+type listEntry struct {
+	Path           string `json:"path"`
+	Size           uint64 `json:"size"`
+	CompressedSize uint64 `json:"compressed_size,omitempty"`
+	Chunks         int    `json:"chunks,omitempty"`
+	Type           string `json:"type,omitempty"`
+}
+
+func listCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "list <archive>",
+		Short: "List the files of an archive",
+		Long: `List the files of an archive with their size and, for GDELTA02 and
+GDELTA03 archives written with 'compress --sniff-types', the type detected
+from their content. Only the archive's structure is read; use 'verify
+--data' to check the data.
+
+Example:
+
+  godelta list backup.gdelta
+  godelta list --json backup.gdelta | jq -r '.[] | select(.type == "image/png") | .path'`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := verify.Verify(&verify.Options{InputPath: args[0]}, nil)
+			if err != nil {
+				return err
+			}
+			if len(result.Errors) > 0 {
+				return fmt.Errorf("%s: invalid archive: %w", args[0], result.Errors[0])
+			}
+
+			entries := make([]listEntry, 0, len(result.Files))
+			for _, f := range result.Files {
+				entries = append(entries, listEntry{
+					Path:           f.Path,
+					Size:           f.OriginalSize,
+					CompressedSize: f.CompressedSize,
+					Chunks:         f.ChunkCount,
+					Type:           f.Type,
+				})
+			}
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(entries)
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "SIZE\tTYPE\tPATH")
+			for _, e := range entries {
+				typ := e.Type
+				if typ == "" {
+					typ = "-"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", godelta.FormatSize(e.Size), typ, e.Path)
+			}
+			return tw.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the files as a JSON array of {path, size, compressed_size, chunks, type}")
+	return cmd
+}
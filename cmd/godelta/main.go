@@ -5,6 +5,8 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/godelta"
 )
 
 var (
@@ -20,9 +22,16 @@ var rootCmd = &cobra.Command{
 	Version: fmt.Sprintf("%s (commit %s, built %s)", version, commit, date),
 }
 
+func init() {
+	// Bad flags exit with the usage code like invalid flag values
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return godelta.WithCategory(godelta.ErrUsage, err)
+	})
+}
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
@@ -0,0 +1,68 @@
+// cmd/godelta/notify_flags.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/creativeyann17/go-delta/pkg/notify"
+)
+
+// Environment variables holding SMTP credentials, kept off the command line
+const (
+	smtpUsernameEnv = "GODELTA_SMTP_USERNAME"
+	smtpPasswordEnv = "GODELTA_SMTP_PASSWORD"
+)
+
+// notifyFlags holds the notification flags shared by compress and verify
+type notifyFlags struct {
+	url      string
+	on       string
+	emailTo  []string
+	smtpAddr string
+	smtpFrom string
+}
+
+func (n *notifyFlags) register(flags *pflag.FlagSet) {
+	flags.StringVar(&n.url, "notify-url", "",
+		"POST a JSON run summary to this webhook URL")
+	flags.StringVar(&n.on, "notify-on", notify.OnFailure,
+		"When to notify: failure (errors or failed run) or always")
+	flags.StringArrayVar(&n.emailTo, "notify-email", nil,
+		"Email the run summary to this address (repeatable; needs --smtp-addr and --smtp-from)")
+	flags.StringVar(&n.smtpAddr, "smtp-addr", "",
+		"SMTP server host:port for --notify-email (credentials from "+smtpUsernameEnv+" / "+smtpPasswordEnv+")")
+	flags.StringVar(&n.smtpFrom, "smtp-from", "",
+		"Sender address for --notify-email")
+}
+
+// options builds and validates the notification options. Call it before the
+// run so a bad flag fails fast instead of after hours of compression.
+func (n *notifyFlags) options() (*notify.Options, error) {
+	opts := &notify.Options{
+		URL: n.url,
+		On:  n.on,
+		Email: notify.EmailOptions{
+			SMTPAddr: n.smtpAddr,
+			From:     n.smtpFrom,
+			To:       n.emailTo,
+			Username: os.Getenv(smtpUsernameEnv),
+			Password: os.Getenv(smtpPasswordEnv),
+		},
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// sendNotification delivers the summary. A failed notification is reported on stderr but
+// never changes the command's outcome.
+func sendNotification(opts *notify.Options, s notify.Summary) {
+	if err := notify.Send(context.Background(), opts, s); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: notification failed: %v\n", err)
+	}
+}
@@ -0,0 +1,79 @@
+// cmd/godelta/progress_flags.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Progress output modes for --progress
+const (
+	progressBar  = "bar"
+	progressJSON = "json"
+	progressNone = "none"
+)
+
+// progressFlags holds --progress and --progress-fd, shared by compress,
+// decompress and verify
+type progressFlags struct {
+	mode     string
+	fd       int
+	interval time.Duration
+}
+
+func (p *progressFlags) register(flags *pflag.FlagSet) {
+	flags.StringVar(&p.mode, "progress", progressBar,
+		"Progress output: bar, json (newline-delimited events) or none")
+	flags.IntVar(&p.fd, "progress-fd", 1,
+		"File descriptor receiving --progress json events (1 = stdout)")
+}
+
+// registerInterval adds --progress-interval, for commands whose events
+// can be throttled
+func (p *progressFlags) registerInterval(flags *pflag.FlagSet) {
+	flags.DurationVar(&p.interval, "progress-interval", 0,
+		"Send at most one file progress event per interval (e.g. 250ms, 0 = every event)")
+}
+
+// open validates the flags and returns where JSON events go (nil unless mode
+// is json) and where human-readable output goes. When events go to stdout,
+// human output moves to stderr so stdout stays machine-readable.
+func (p *progressFlags) open(verbose bool) (events io.Writer, out io.Writer, err error) {
+	switch p.mode {
+	case progressBar, progressNone:
+		return nil, os.Stdout, nil
+	case progressJSON:
+	default:
+		return nil, nil, fmt.Errorf("invalid --progress %q (expected bar, json or none)", p.mode)
+	}
+
+	switch p.fd {
+	case 1:
+		if verbose {
+			return nil, nil, fmt.Errorf("--verbose writes to stdout and can't be combined with --progress json on stdout (use --progress-fd)")
+		}
+		return os.Stdout, os.Stderr, nil
+	case 2:
+		return os.Stderr, os.Stdout, nil
+	}
+	if p.fd < 0 {
+		return nil, nil, fmt.Errorf("invalid --progress-fd %d", p.fd)
+	}
+	f := os.NewFile(uintptr(p.fd), fmt.Sprintf("fd%d", p.fd))
+	if f == nil {
+		return nil, nil, fmt.Errorf("invalid --progress-fd %d", p.fd)
+	}
+	if _, err := f.Stat(); err != nil {
+		return nil, nil, fmt.Errorf("--progress-fd %d: %w", p.fd, err)
+	}
+	return f, os.Stdout, nil
+}
+
+// bars reports whether interactive progress bars should be shown
+func (p *progressFlags) bars(quiet, verbose bool) bool {
+	return p.mode == progressBar && !quiet && !verbose
+}
@@ -0,0 +1,83 @@
+// cmd/godelta/push_cmd.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/replicate"
+)
+
+func init() {
+	rootCmd.AddCommand(pushCmd(), receiveCmd())
+}
+
+func pushCmd() *cobra.Command {
+	var name, sshCommand, remoteCommand string
+	var overwrite, verifyCopy, quiet bool
+
+	cmd := &cobra.Command{
+		Use:   "push <archive> <[user@]host:dir>",
+		Short: "Send a GDELTA02 archive over SSH, transferring only chunks the remote lacks",
+		Long: `Copy a GDELTA02 archive into a directory of archives on another machine.
+godelta is started there over SSH ('godelta receive <dir>'); it reports
+which chunks none of its archives holds, and only those are sent. The
+remote then builds the copy from its own archives and the chunks received,
+like 'godelta copy'.
+
+godelta must be installed on the remote (see --remote-command).
+
+Example:
+
+  godelta push nightly-0614.gdelta backup@offsite:/backups/repo --verify
+  godelta push nightly.gdelta backup@offsite:/backups --ssh "ssh -p 2222"`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := &replicate.PushOptions{
+				SourcePath:    args[0],
+				Remote:        args[1],
+				Name:          name,
+				Overwrite:     overwrite,
+				Verify:        verifyCopy,
+				SSHCommand:    sshCommand,
+				RemoteCommand: remoteCommand,
+			}
+			result, err := replicate.Push(opts)
+			if err != nil {
+				return err
+			}
+			if !quiet {
+				fmt.Print(result.Summary())
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "File name of the copy on the remote (default: the archive's name)")
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Replace an existing copy")
+	cmd.Flags().BoolVar(&verifyCopy, "verify", false, "Verify the data of the copy on the remote before putting it in place")
+	cmd.Flags().StringVar(&sshCommand, "ssh", "ssh", "SSH command, with its options")
+	cmd.Flags().StringVar(&remoteCommand, "remote-command", "godelta", "godelta binary on the remote")
+	cmd.Flags().BoolVar(&quiet, "quiet", false, "Minimal output")
+	return cmd
+}
+
+// receiveCmd is the remote end of a push, speaking on stdin and stdout
+func receiveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "receive <dir>",
+		Short:        "Receive a pushed archive on stdin (started by 'godelta push')",
+		Hidden:       true,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true, // stdout carries the protocol
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return replicate.Receive(struct {
+				io.Reader
+				io.Writer
+			}{os.Stdin, os.Stdout}, args[0])
+		},
+	}
+}
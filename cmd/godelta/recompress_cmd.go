@@ -0,0 +1,58 @@
+// cmd/godelta/recompress_cmd.go
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/recompress"
+)
+
+func init() {
+	rootCmd.AddCommand(recompressCmd())
+}
+
+func recompressCmd() *cobra.Command {
+	var output, codec string
+	var level, threads int
+
+	cmd := &cobra.Command{
+		Use:   "recompress <archive> -o <output>",
+		Short: "Re-encode the chunks of a GDELTA02 archive at another level or codec",
+		Long: `Decode every chunk of a GDELTA02 archive and encode it again with
+--level or --codec, without the original input. Files, their metadata,
+chunk hashes and the dedup structure are kept, so a .sha256 manifest
+stays valid; parity is computed again. Each chunk is checked against its
+hash first, and the new archive is verified before the command returns.
+The original archive is left as it is.
+
+Compress fast at night, recompress at a higher ratio before archiving:
+
+  godelta compress -i data -o nightly.gdelta --chunk-size 1MB --level 1
+  godelta recompress nightly.gdelta -o smaller.gdelta --level 19
+  godelta recompress nightly.gdelta -o fast.gdelta --codec lz4`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := recompress.Recompress(&recompress.Options{
+				InputPath:  args[0],
+				OutputPath: output,
+				Level:      level,
+				Codec:      codec,
+				MaxThreads: threads,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Print(result.Summary())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Recompressed archive path (required)")
+	cmd.Flags().IntVarP(&level, "level", "l", 0, "New compression level (default: the archive's, or the codec's default when it changes)")
+	cmd.Flags().StringVar(&codec, "codec", "", "New chunk codec: zstd, deflate, lz4, brotli or snappy (default: the archive's)")
+	cmd.Flags().IntVarP(&threads, "threads", "t", 0, "Chunks recompressed at once (default: CPU count)")
+	_ = cmd.MarkFlagRequired("output")
+	return cmd
+}
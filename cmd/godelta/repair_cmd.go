@@ -0,0 +1,76 @@
+// cmd/godelta/repair_cmd.go
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/repair"
+)
+
+func init() {
+	rootCmd.AddCommand(repairCmd())
+}
+
+func repairCmd() *cobra.Command {
+	var sourcePath string
+	var maxThreads int
+	var dryRun bool
+	var verbose bool
+	var quiet bool
+
+	cmd := &cobra.Command{
+		Use:   "repair <archive>",
+		Short: "Rebuild damaged parts of an archive from its parity or source",
+		Long: `Repair a GDELTA archive in place from the Reed-Solomon parity appended
+by 'godelta compress --parity'.
+
+Every block of the archive and of the parity is checked against its
+checksum; damaged blocks are rebuilt and written back, then the archive is
+checked again. Use --dry-run to only report the damage.
+
+With --source, the archive's data is verified and the damaged files
+(GDELTA01, GDELTA03) or chunks (GDELTA02) are compressed again from the
+directory the archive was made from, then patched into the archive. The
+source files must still hold the archived content.
+
+Examples:
+
+  godelta compress -i data/ -o backup.gdelta --parity 5
+  godelta repair backup.gdelta
+
+  godelta repair --source data/ backup.gdelta`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := &repair.Options{
+				InputPath:  args[0],
+				SourcePath: sourcePath,
+				MaxThreads: maxThreads,
+				DryRun:     dryRun,
+				Verbose:    verbose,
+				Quiet:      quiet,
+			}
+
+			result, err := repair.Repair(opts)
+			if result != nil && !quiet {
+				fmt.Print(result.Summary())
+			}
+			if err != nil {
+				return err
+			}
+			if dryRun && result.Damaged() {
+				return corruptErrorf("archive is damaged")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&sourcePath, "source", "", "Compress damaged files again from this directory (the archive's input) instead of using parity")
+	cmd.Flags().IntVarP(&maxThreads, "threads", "t", 0, "Files or chunks verified at once with --source (0 = number of CPUs)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report the damage without rewriting anything")
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "Show detailed output")
+	cmd.Flags().BoolVar(&quiet, "quiet", false, "Minimal output (overrides verbose)")
+
+	return cmd
+}
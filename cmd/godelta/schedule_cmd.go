@@ -0,0 +1,130 @@
+// cmd/godelta/schedule_cmd.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/schedule"
+)
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd())
+}
+
+func scheduleCmd() *cobra.Command {
+	var configPath string
+	var showStatus bool
+	var runNow string
+	var quiet bool
+	var metricsAddr string
+
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run compression jobs on cron schedules",
+		Long: `Run the jobs defined in a YAML config file on their cron schedules.
+
+Example config:
+
+  state_dir: /var/lib/godelta        # per-job logs and status.json
+  jobs:
+    - name: nightly
+      schedule: "0 2 * * *"          # minute hour day month weekday
+      input: /data
+      output: /backups/nightly       # nightly-<time>.gdelta
+      level: 9
+      chunk_size: 64KB
+      retention:
+        keep_last: 7
+        max_age: 720h
+
+Each job appends to <state_dir>/<name>.log; last-run status is kept in
+<state_dir>/status.json (see --status).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := schedule.LoadConfig(configPath)
+			if err != nil {
+				return err
+			}
+
+			if showStatus {
+				statuses, err := schedule.ReadStatus(cfg.StateDir)
+				if err != nil {
+					return fmt.Errorf("read status: %w", err)
+				}
+				printScheduleStatus(statuses)
+				return nil
+			}
+
+			var logf func(format string, args ...interface{})
+			if !quiet {
+				logf = func(format string, args ...interface{}) {
+					fmt.Printf("%s "+format+"\n", append([]interface{}{time.Now().Format("2006-01-02 15:04:05")}, args...)...)
+				}
+			}
+
+			s, err := schedule.New(cfg, logf)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if metricsAddr != "" {
+				stopMetrics := serveMetrics(metricsAddr)
+				defer stopMetrics()
+				if !quiet {
+					fmt.Printf("Metrics endpoint: http://%s/metrics\n", metricsAddr)
+				}
+			}
+
+			if runNow != "" {
+				return s.RunJob(ctx, runNow)
+			}
+			return s.Run(ctx)
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "", "Scheduler config file (required)")
+	cmd.Flags().BoolVar(&showStatus, "status", false, "Print last-run status of every job and exit")
+	cmd.Flags().StringVar(&runNow, "run-now", "", "Run the named job once immediately and exit")
+	cmd.Flags().BoolVar(&quiet, "quiet", false, "Don't echo job logs to stdout")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this address at /metrics (e.g. 127.0.0.1:9420)")
+
+	_ = cmd.MarkFlagRequired("config")
+
+	return cmd
+}
+
+// printScheduleStatus prints one row per job
+func printScheduleStatus(statuses []schedule.JobStatus) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tSCHEDULE\tLAST RUN\tRESULT\tDURATION\tSIZE\tNEXT RUN")
+	for _, st := range statuses {
+		lastRun, result, size := "never", "-", "-"
+		if !st.LastRun.IsZero() {
+			lastRun = st.LastRun.Format("2006-01-02 15:04")
+			result = st.LastResult
+			size = compress.FormatSize(st.CompressedSize)
+		}
+		if st.Running {
+			result = "running"
+		}
+		nextRun := "-"
+		if !st.NextRun.IsZero() {
+			nextRun = st.NextRun.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", st.Name, st.Schedule, lastRun, result, st.LastDuration, size, nextRun)
+		if st.LastError != "" {
+			fmt.Fprintf(tw, "\t  error: %s\t\t\t\t\t\n", st.LastError)
+		}
+	}
+	tw.Flush()
+}
@@ -0,0 +1,46 @@
+// cmd/godelta/stats_cmd.go
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+func init() {
+	rootCmd.AddCommand(statsCmd())
+}
+
+func statsCmd() *cobra.Command {
+	var top, files int
+
+	cmd := &cobra.Command{
+		Use:   "stats <archive>",
+		Short: "Report the chunk references of a GDELTA02 archive",
+		Long: `Count the references of the chunks of a GDELTA02 archive and report where
+its dedup savings come from: the most referenced chunks, how many chunks
+are referenced once, twice, 3-4 times and so on, and per file the
+original (logical) size next to its effective physical size, every chunk
+counting for its compressed size divided by the files sharing it. Only
+the headers, chunk index and file metadata are read.
+
+Example:
+
+  godelta stats backup.gdelta --top 20 --files 50`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stats, err := verify.ArchiveChunkStats(args[0], top)
+			if err != nil {
+				return err
+			}
+			fmt.Print(stats.Summary(files))
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&top, "top", 10, "Most referenced chunks to list")
+	cmd.Flags().IntVar(&files, "files", 20, "Files to list, those saving the most first")
+	return cmd
+}
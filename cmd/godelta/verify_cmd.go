@@ -2,10 +2,14 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/creativeyann17/go-delta/pkg/notify"
 	"github.com/creativeyann17/go-delta/pkg/verify"
 )
 
@@ -16,8 +20,15 @@ func init() {
 func verifyCmd() *cobra.Command {
 	var inputPath string
 	var verifyData bool
+	var useMmap bool
+	var compareDir string
+	var manifestFile string
+	var maxThreads int
 	var verbose bool
 	var quiet bool
+	var asJSON bool
+	var progressOpts progressFlags
+	var notifyOpts notifyFlags
 
 	cmd := &cobra.Command{
 		Use:   "verify",
@@ -25,11 +36,22 @@ func verifyCmd() *cobra.Command {
 		Long: `Verify the integrity of a GDELTA or ZIP archive.
 
 By default, performs structural validation (header, metadata, footer).
-Use --data to also verify data integrity by decompressing all content.`,
+Use --data to also verify data integrity by decompressing all content.
+Use --compare to check the archive against a directory, such as the one it
+was made from: every entry is extracted in memory and compared with the file
+on disk, and files the archive doesn't hold are listed. Nothing is written.
+With --data, an <archive>.sha256 manifest written by 'compress
+--write-manifest' is checked too (or the one given with --manifest).
+With --json, the result is printed as a JSON object instead: errors and
+warnings are lists of {severity, code, message}.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts := &verify.Options{
 				InputPath:  inputPath,
 				VerifyData: verifyData,
+				Mmap:       useMmap,
+				CompareDir: compareDir,
+				Manifest:   manifestFile,
+				MaxThreads: maxThreads,
 				Verbose:    verbose,
 				Quiet:      quiet,
 			}
@@ -37,11 +59,23 @@ Use --data to also verify data integrity by decompressing all content.`,
 			if err := opts.Validate(); err != nil {
 				return err
 			}
+			notifyCfg, err := notifyOpts.options()
+			if err != nil {
+				return err
+			}
+
+			events, out, err := progressOpts.open(verbose && !quiet)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				quiet = true // stdout holds the JSON result only
+			}
 
 			// Logging helper
 			log := func(format string, args ...interface{}) {
 				if !quiet {
-					fmt.Printf(format+"\n", args...)
+					fmt.Fprintf(out, format+"\n", args...)
 				}
 			}
 
@@ -51,11 +85,19 @@ Use --data to also verify data integrity by decompressing all content.`,
 			} else {
 				log("Mode: Structural validation only")
 			}
+			if compareDir != "" {
+				log("Compare with: %s", compareDir)
+			}
+			if manifestFile != "" {
+				log("Manifest: %s", manifestFile)
+			}
 			log("")
 
 			// Create progress callback
 			var progressCb verify.ProgressCallback
-			if !quiet && !verbose {
+			if events != nil {
+				progressCb = verify.JSONProgressCallback(events)
+			} else if progressOpts.bars(quiet, verbose) {
 				lastFile := ""
 				progressCb = func(event verify.ProgressEvent) {
 					switch event.Type {
@@ -70,19 +112,37 @@ Use --data to also verify data integrity by decompressing all content.`,
 						if event.Current%500 == 0 {
 							fmt.Printf("\r  Chunks verified: %d/%d", event.Current, event.Total)
 						}
+					case verify.EventFileCompare:
+						if event.Current%100 == 0 || event.Current == event.Total {
+							fmt.Printf("\r  Compared: %d/%d files", event.Current, event.Total)
+						}
+						if event.Current == event.Total {
+							fmt.Println()
+						}
+					case verify.EventManifestCheck:
+						if event.Current%100 == 0 || event.Current == event.Total {
+							fmt.Printf("\r  Manifest: %d/%d files", event.Current, event.Total)
+						}
+						if event.Current == event.Total {
+							fmt.Println()
+						}
 					case verify.EventComplete:
 						fmt.Printf("\r  Progress: %d/%d files\n", event.Current, event.Total)
 					case verify.EventError:
 						fmt.Printf("\n  Error in: %s\n", lastFile)
 					}
 				}
-			} else if verbose {
+			} else if verbose && !asJSON {
 				progressCb = func(event verify.ProgressEvent) {
 					switch event.Type {
 					case verify.EventStart:
 						fmt.Printf("Starting verification: %s\n", event.Message)
 					case verify.EventFileVerify:
 						fmt.Printf("  [%d/%d] %s\n", event.Current, event.Total, event.FilePath)
+					case verify.EventFileCompare:
+						fmt.Printf("  [%d/%d] compared %s\n", event.Current, event.Total, event.FilePath)
+					case verify.EventManifestCheck:
+						fmt.Printf("  [%d/%d] manifest %s\n", event.Current, event.Total, event.FilePath)
 					case verify.EventChunkVerify:
 						if event.Current%100 == 0 {
 							fmt.Printf("  Chunks: %d/%d verified\n", event.Current, event.Total)
@@ -94,18 +154,34 @@ Use --data to also verify data integrity by decompressing all content.`,
 			}
 
 			// Perform verification
+			start := time.Now()
 			result, err := verify.Verify(opts, progressCb)
+			sendNotification(notifyCfg, notify.VerifySummary(opts, result, err, start))
 			if err != nil && result == nil {
 				return err
 			}
 
 			// Print summary
-			fmt.Println()
-			fmt.Print(result.Summary())
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(result); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintln(out)
+				fmt.Fprint(out, result.Summary())
+			}
 
 			// Return error if invalid
 			if !result.IsValid() {
-				return fmt.Errorf("archive verification failed")
+				return corruptErrorf("archive verification failed")
+			}
+			if result.Compare != nil && result.Compare.Differs() {
+				return corruptErrorf("archive doesn't match %s", compareDir)
+			}
+			if result.Manifest != nil && result.Manifest.Differs() {
+				return corruptErrorf("archive doesn't match manifest %s", result.Manifest.Path)
 			}
 
 			return nil
@@ -114,8 +190,16 @@ Use --data to also verify data integrity by decompressing all content.`,
 
 	cmd.Flags().StringVarP(&inputPath, "input", "i", "", "Input archive file (required)")
 	cmd.Flags().BoolVar(&verifyData, "data", false, "Verify data integrity by decompressing all content")
+	cmd.Flags().IntVarP(&maxThreads, "threads", "t", 0, "Files or chunks checked at once with --data (0 = number of CPUs)")
+	cmd.Flags().BoolVar(&useMmap, "mmap", false, "Memory-map the archive for --data (falls back to reads when it can't be mapped)")
+	cmd.Flags().StringVar(&compareDir, "compare", "", "Compare the archive's content with this directory")
+	cmd.Flags().StringVar(&manifestFile, "manifest", "", "Check the archive against this SHA-256 manifest (default: <archive>.sha256 with --data)")
 	cmd.Flags().BoolVar(&verbose, "verbose", false, "Show detailed output")
 	cmd.Flags().BoolVar(&quiet, "quiet", false, "Minimal output (overrides verbose)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the result as JSON, with errors and warnings as {severity, code, message}")
+
+	progressOpts.register(cmd.Flags())
+	notifyOpts.register(cmd.Flags())
 
 	_ = cmd.MarkFlagRequired("input")
 
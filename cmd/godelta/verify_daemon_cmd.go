@@ -0,0 +1,106 @@
+// cmd/godelta/verify_daemon_cmd.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/creativeyann17/go-delta/pkg/verifyd"
+)
+
+func init() {
+	rootCmd.AddCommand(verifyDaemonCmd())
+}
+
+func verifyDaemonCmd() *cobra.Command {
+	var root, statusPath string
+	var interval time.Duration
+	var ioLimit string
+	var maxThreads int
+	var nice int
+	var ionice string
+	var metricsAddr string
+	var quiet bool
+
+	cmd := &cobra.Command{
+		Use:   "verify-daemon",
+		Short: "Verify the archives under a directory continuously, at low priority",
+		Long: `Cycle through the .gdelta archives under --root, verifying the data of
+one at a time, so damage on cold storage is caught early.
+
+An archive is verified again once its last verification is older than
+--interval; new and changed archives come first, archives modified in the
+last minute wait until they are complete. Reads are capped by --io-limit
+and the process runs at a low disk priority (--ionice) and CPU priority
+(--nice). Each outcome is recorded in the --status JSON file, read again
+at start so a restart carries on, and exported as Prometheus metrics with
+--metrics-addr.
+
+Example:
+
+  godelta verify-daemon --root /backups --status /var/lib/godelta/verify.json \
+    --interval 24h --io-limit 50MB/s --metrics-addr 127.0.0.1:9421`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := &verifyd.Options{
+				Root:        root,
+				StatusPath:  statusPath,
+				Interval:    interval,
+				MaxThreads:  maxThreads,
+				CPUPriority: nice,
+				IOPriority:  compress.IOPriority(ionice),
+			}
+			if ioLimit != "" && ioLimit != "0" {
+				limit, err := godelta.ParseSize(strings.TrimSuffix(ioLimit, "/s"))
+				if err != nil {
+					return usageErrorf("invalid --io-limit: %w", err)
+				}
+				opts.ReadLimit = int64(limit)
+			}
+			if !quiet {
+				opts.Logf = func(format string, args ...interface{}) {
+					fmt.Printf("%s "+format+"\n", append([]interface{}{time.Now().Format("2006-01-02 15:04:05")}, args...)...)
+				}
+			}
+
+			d, err := verifyd.New(opts)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if metricsAddr != "" {
+				stopMetrics := serveMetrics(metricsAddr)
+				defer stopMetrics()
+				if !quiet {
+					fmt.Printf("Metrics endpoint: http://%s/metrics\n", metricsAddr)
+				}
+			}
+			return d.Run(ctx)
+		},
+	}
+
+	cmd.Flags().StringVar(&root, "root", "", "Directory whose .gdelta archives are verified, recursively (required)")
+	cmd.Flags().StringVar(&statusPath, "status", "", "JSON file recording the last verification of each archive (required)")
+	cmd.Flags().DurationVar(&interval, "interval", 24*time.Hour, "Verify each archive again once its last verification is this old")
+	cmd.Flags().StringVar(&ioLimit, "io-limit", "0", "Cap archive reads, e.g. 50MB/s (0 = no limit)")
+	cmd.Flags().IntVarP(&maxThreads, "threads", "t", 1, "Files or chunks checked at once")
+	cmd.Flags().IntVar(&nice, "nice", 10, "Lower the CPU priority like nice: 1-19, higher is lower (0 = unchanged)")
+	cmd.Flags().StringVar(&ionice, "ionice", "idle", "Lower the disk priority like ionice: low, idle, or \"\" for unchanged")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this address at /metrics (e.g. 127.0.0.1:9421)")
+	cmd.Flags().BoolVar(&quiet, "quiet", false, "Only print errors")
+
+	_ = cmd.MarkFlagRequired("root")
+	_ = cmd.MarkFlagRequired("status")
+
+	return cmd
+}
@@ -0,0 +1,128 @@
+// cmd/godelta/watch_cmd.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/metrics"
+	"github.com/creativeyann17/go-delta/pkg/watch"
+)
+
+func init() {
+	rootCmd.AddCommand(watchCmd())
+}
+
+func watchCmd() *cobra.Command {
+	var inputPath, outputDir string
+	var interval, debounce time.Duration
+	var statusAddr string
+	var maxThreads int
+	var compressLevel int
+	var chunkSizeStr string
+	var useDictionary bool
+	var useGitignore bool
+	var noSync bool
+	var ignores ignoreFlags
+	var quiet bool
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch a directory and snapshot changes continuously",
+		Long: `Watch a directory and write snapshot archives as it changes.
+
+The first snapshot holds every file; each later snapshot holds only the
+files modified since the previous one. Bursts of writes are debounced into
+a single snapshot, and snapshots are at least --interval apart.
+Deleted files are not recorded.
+
+Restore by extracting the full snapshot, then each incremental snapshot
+in order with --overwrite.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			chunkSizeKB, err := parseSize(chunkSizeStr)
+			if err != nil {
+				return usageErrorf("invalid --chunk-size: %w", err)
+			}
+
+			opts := &watch.Options{
+				InputPath: inputPath,
+				OutputDir: outputDir,
+				Interval:  interval,
+				Debounce:  debounce,
+				Compress: compress.Options{
+					MaxThreads:    maxThreads,
+					Level:         compressLevel,
+					ChunkSize:     chunkSizeKB * 1024,
+					UseDictionary: useDictionary,
+					UseGitignore:  useGitignore,
+					IgnoreFiles:   ignores.paths(),
+					Sync:          !noSync,
+				},
+			}
+			if !quiet {
+				opts.Logf = func(format string, args ...interface{}) {
+					fmt.Printf("%s "+format+"\n", append([]interface{}{time.Now().Format("15:04:05")}, args...)...)
+				}
+			}
+
+			w, err := watch.New(opts)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if statusAddr != "" {
+				mux := http.NewServeMux()
+				mux.Handle("/status", w.StatusHandler())
+				mux.Handle("/metrics", metrics.Handler())
+				srv := &http.Server{Addr: statusAddr, Handler: mux}
+				go func() {
+					if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+						fmt.Fprintf(os.Stderr, "status endpoint: %v\n", err)
+					}
+				}()
+				defer func() {
+					shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+					_ = srv.Shutdown(shutdownCtx)
+				}()
+				if !quiet {
+					fmt.Printf("Status endpoint: http://%s/status (Prometheus metrics at /metrics)\n", statusAddr)
+				}
+			}
+
+			return w.Run(ctx)
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputPath, "input", "i", "", "Directory to watch (required)")
+	cmd.Flags().StringVarP(&outputDir, "output", "o", "", "Directory receiving snapshot archives (required)")
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Minute, "Minimum time between snapshots")
+	cmd.Flags().DurationVar(&debounce, "debounce", 2*time.Second, "Quiet period after the last change before snapshotting")
+	cmd.Flags().StringVar(&statusAddr, "status-addr", "", "Serve JSON status at /status and Prometheus metrics at /metrics on this address (e.g. 127.0.0.1:7421)")
+	cmd.Flags().IntVarP(&maxThreads, "threads", "t", runtime.NumCPU(), "Max concurrent threads")
+	cmd.Flags().IntVarP(&compressLevel, "level", "l", 5, "Compression level (1-22, zstd)")
+	cmd.Flags().StringVar(&chunkSizeStr, "chunk-size", "0", "Average chunk size for dedup within each snapshot (e.g. 64KB, 0=disabled)")
+	cmd.Flags().BoolVar(&useDictionary, "dictionary", false, "Use dictionary compression (GDELTA03)")
+	cmd.Flags().BoolVar(&useGitignore, "gitignore", false, "Respect .gitignore files to exclude matching paths")
+	cmd.Flags().BoolVar(&noSync, "no-sync", false, "Don't fsync each archive and its directory once written")
+	ignores.register(cmd.Flags())
+	cmd.Flags().BoolVar(&quiet, "quiet", false, "Only print errors")
+
+	_ = cmd.MarkFlagRequired("input")
+	_ = cmd.MarkFlagRequired("output")
+
+	return cmd
+}
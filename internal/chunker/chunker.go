@@ -8,20 +8,58 @@ import (
 	"github.com/zeebo/blake3"
 )
 
-// Chunker splits data into content-defined chunks using FastCDC
+// DefaultNormalization is the FastCDC normalization level used by New
+const DefaultNormalization = 2
+
+// Chunker splits data into content-defined chunks using FastCDC, or into
+// fixed-size blocks (see NewFixed)
 type Chunker struct {
-	avgSize uint64
-	minSize uint64
-	maxSize uint64
+	avgSize       uint64
+	minSize       uint64
+	maxSize       uint64
+	normalization int
+	fixed         bool
 }
 
 // New creates a new chunker with the specified average chunk size.
 // Actual chunks will vary between avgSize/4 and avgSize*4.
 func New(avgSize uint64) *Chunker {
+	return NewBounded(avgSize, avgSize/4, avgSize*4, DefaultNormalization)
+}
+
+// NewBounded creates a chunker whose chunks vary between minSize and
+// maxSize around avgSize. normalization (0-3) is how strongly chunk sizes
+// are pulled toward avgSize; 0 turns normalization off.
+func NewBounded(avgSize, minSize, maxSize uint64, normalization int) *Chunker {
+	return &Chunker{
+		avgSize:       avgSize,
+		minSize:       minSize,
+		maxSize:       maxSize,
+		normalization: normalization,
+	}
+}
+
+// NewFixed creates a chunker cutting blockSize blocks at fixed offsets (the
+// last one of a stream may be shorter). Fixed blocks stay aligned with the
+// blocks of disk images and database files, where content-defined
+// boundaries bring nothing, and they cost no boundary search.
+func NewFixed(blockSize uint64) *Chunker {
 	return &Chunker{
-		avgSize: avgSize,
-		minSize: avgSize / 4,
-		maxSize: avgSize * 4,
+		avgSize: blockSize,
+		minSize: blockSize,
+		maxSize: blockSize,
+		fixed:   true,
+	}
+}
+
+// fastcdcOptions returns the FastCDC options of the chunker
+func (c *Chunker) fastcdcOptions() fastcdc.Options {
+	return fastcdc.Options{
+		AverageSize:          int(c.avgSize),
+		MinSize:              int(c.minSize),
+		MaxSize:              int(c.maxSize),
+		Normalization:        c.normalization,
+		DisableNormalization: c.normalization == 0,
 	}
 }
 
@@ -40,13 +78,17 @@ type Chunk struct {
 // WARNING: For large files, this loads all chunks into memory at once.
 // Consider using SplitWithCallback for streaming processing.
 func (c *Chunker) Split(reader io.Reader) ([]Chunk, error) {
-	opts := fastcdc.Options{
-		AverageSize: int(c.avgSize),
-		MinSize:     int(c.minSize),
-		MaxSize:     int(c.maxSize),
+	if c.fixed {
+		var chunks []Chunk
+		err := c.splitFixed(reader, func(chunk Chunk) error {
+			chunk.Data = append([]byte(nil), chunk.Data...)
+			chunks = append(chunks, chunk)
+			return nil
+		})
+		return chunks, err
 	}
 
-	chunker, err := fastcdc.NewChunker(reader, opts)
+	chunker, err := fastcdc.NewChunker(reader, c.fastcdcOptions())
 	if err != nil {
 		return nil, err
 	}
@@ -87,13 +129,11 @@ type ChunkCallback func(chunk Chunk) error
 // This enables streaming processing without loading entire file into memory.
 // The chunk.Data slice is only valid during the callback - copy if needed.
 func (c *Chunker) SplitWithCallback(reader io.Reader, callback ChunkCallback) error {
-	opts := fastcdc.Options{
-		AverageSize: int(c.avgSize),
-		MinSize:     int(c.minSize),
-		MaxSize:     int(c.maxSize),
+	if c.fixed {
+		return c.splitFixed(reader, callback)
 	}
 
-	chunker, err := fastcdc.NewChunker(reader, opts)
+	chunker, err := fastcdc.NewChunker(reader, c.fastcdcOptions())
 	if err != nil {
 		return err
 	}
@@ -125,6 +165,31 @@ func (c *Chunker) SplitWithCallback(reader io.Reader, callback ChunkCallback) er
 	return nil
 }
 
+// splitFixed cuts reader into blocks of avgSize bytes, reusing one buffer
+func (c *Chunker) splitFixed(reader io.Reader, callback ChunkCallback) error {
+	buf := make([]byte, c.avgSize)
+	for {
+		n, err := io.ReadFull(reader, buf)
+		if n > 0 {
+			data := buf[:n]
+			chunk := Chunk{
+				Data:     data,
+				Hash:     blake3.Sum256(data),
+				OrigSize: uint64(n),
+			}
+			if cbErr := callback(chunk); cbErr != nil {
+				return cbErr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
 // ChunkSize returns the configured average chunk size
 func (c *Chunker) ChunkSize() uint64 {
 	return c.avgSize
@@ -139,3 +204,13 @@ func (c *Chunker) MinSize() uint64 {
 func (c *Chunker) MaxSize() uint64 {
 	return c.maxSize
 }
+
+// Normalization returns the FastCDC normalization level (0 = off)
+func (c *Chunker) Normalization() int {
+	return c.normalization
+}
+
+// Fixed reports whether the chunker cuts fixed-size blocks
+func (c *Chunker) Fixed() bool {
+	return c.fixed
+}
@@ -72,6 +72,77 @@ func TestChunkerSizeBounds(t *testing.T) {
 	}
 }
 
+// TestChunkerCustomBounds checks chunks stay within custom bounds, with
+// and without normalization
+func TestChunkerCustomBounds(t *testing.T) {
+	data := make([]byte, 256*1024)
+	var x uint32 = 1
+	for i := range data {
+		x = x*1664525 + 1013904223
+		data[i] = byte(x >> 24)
+	}
+
+	for _, normalization := range []int{0, 1, 3} {
+		c := NewBounded(4096, 3000, 5000, normalization)
+		if c.Normalization() != normalization {
+			t.Errorf("Expected normalization %d, got %d", normalization, c.Normalization())
+		}
+		chunks, err := c.Split(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("normalization %d: Split failed: %v", normalization, err)
+		}
+		var total int
+		for i, chunk := range chunks {
+			total += len(chunk.Data)
+			if i < len(chunks)-1 && (chunk.OrigSize < 3000 || chunk.OrigSize > 5000) {
+				t.Errorf("normalization %d: chunk %d of %d bytes out of bounds", normalization, i, chunk.OrigSize)
+			}
+		}
+		if total != len(data) {
+			t.Errorf("normalization %d: chunks hold %d bytes, want %d", normalization, total, len(data))
+		}
+	}
+}
+
+// TestChunkerFixed checks fixed-size blocks are cut at block offsets, with
+// a shorter last block, and that streaming cuts the same blocks
+func TestChunkerFixed(t *testing.T) {
+	data := make([]byte, 10*1000+123)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	c := NewFixed(1000)
+	if !c.Fixed() || c.MinSize() != 1000 || c.MaxSize() != 1000 {
+		t.Errorf("Unexpected fixed chunker settings: min %d, max %d", c.MinSize(), c.MaxSize())
+	}
+	chunks, err := c.Split(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if len(chunks) != 11 {
+		t.Fatalf("Expected 11 blocks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		end := min((i+1)*1000, len(data))
+		if !bytes.Equal(chunk.Data, data[i*1000:end]) {
+			t.Errorf("Block %d doesn't hold bytes %d-%d", i, i*1000, end)
+		}
+	}
+
+	var streamed int
+	err = c.SplitWithCallback(bytes.NewReader(data), func(chunk Chunk) error {
+		if chunk.Hash != chunks[streamed].Hash {
+			t.Errorf("Streamed block %d differs", streamed)
+		}
+		streamed++
+		return nil
+	})
+	if err != nil || streamed != len(chunks) {
+		t.Errorf("Streamed %d blocks (%v), want %d", streamed, err, len(chunks))
+	}
+}
+
 func TestChunkerEmptyData(t *testing.T) {
 	c := New(1024)
 
@@ -292,3 +292,35 @@ func BenchmarkUnboundedStore(b *testing.B) {
 		})
 	}
 }
+
+func TestBoundedStoreHitStats(t *testing.T) {
+	store := NewStoreWithCapacity(2)
+	add := func(b byte) {
+		store.GetOrAdd([32]byte{b}, 100, func() (uint64, uint64, error) {
+			return uint64(b) * 100, 50, nil
+		})
+	}
+
+	add(0)
+	add(1)
+	add(1) // cache hit
+	add(2) // evicts 0
+	add(0) // index hit
+
+	stats := store.Stats()
+	if stats.CacheHits != 1 || stats.IndexHits != 1 || stats.InflightWaits != 0 {
+		t.Errorf("Expected 1 cache hit and 1 index hit, got %+v", stats)
+	}
+	if stats.CachedChunks != 2 {
+		t.Errorf("Expected 2 cached chunks, got %d", stats.CachedChunks)
+	}
+	if want := 3*indexEntryBytes + 2*cacheEntryBytes; stats.MemoryBytes != uint64(want) {
+		t.Errorf("Expected %d memory bytes, got %d", want, stats.MemoryBytes)
+	}
+	if rate := stats.HitRate(); rate != 20 {
+		t.Errorf("Expected 20%% hit rate, got %.2f", rate)
+	}
+	if ratio := stats.DedupRatio(); ratio != 40 {
+		t.Errorf("Expected 40%% dedup ratio, got %.2f", ratio)
+	}
+}
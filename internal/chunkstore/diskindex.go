@@ -0,0 +1,231 @@
+// internal/chunkstore/diskindex.go
+package chunkstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// DiskIndex is an Index kept in a temporary file instead of RAM, for
+// datasets whose chunk index would not fit in memory (10TB at 64KB chunks
+// is ~160M chunks, ~14GB as a map).
+//
+// The file is an open-addressing hash table with linear probing. A chunk's
+// home slot is the top bits of its hash, so slot order follows hash order:
+// each run of occupied slots only holds chunks whose home is in the run,
+// and sorting the runs one at a time yields the whole index sorted, in one
+// sequential pass. Probes never wrap around; they run on past the last home
+// slot, and the file just grows. Slots past the end of the file are empty.
+//
+// Lookups go through the OS page cache; the Store's LRU cache keeps the
+// hot chunks out of the file altogether.
+type DiskIndex struct {
+	dir   string
+	f     *os.File
+	bits  uint // the table has 1<<bits home slots
+	count int
+	page  []byte // lookup read buffer
+}
+
+const (
+	// slotSize holds Hash(32) + Offset(8) + CompressedSize(8) +
+	// OriginalSize(8), a stored marker, padding, and a used marker in the
+	// last byte
+	slotSize = 64
+
+	// pageSlots slots are read at once during a probe
+	pageSlots = 64
+
+	// The table starts with 64Ki slots (4MB) and doubles when 70% full
+	initialIndexBits = 16
+	maxLoadPercent   = 70
+)
+
+// NewDiskIndex creates an empty index in a temporary file in dir (the
+// default temp directory if dir is empty). Close removes the file.
+func NewDiskIndex(dir string) (*DiskIndex, error) {
+	d := &DiskIndex{dir: dir, bits: initialIndexBits, page: make([]byte, pageSlots*slotSize)}
+	f, err := d.createFile()
+	if err != nil {
+		return nil, err
+	}
+	d.f = f
+	return d, nil
+}
+
+func (d *DiskIndex) createFile() (*os.File, error) {
+	f, err := os.CreateTemp(d.dir, "godelta-index-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("create chunk index file: %w", err)
+	}
+	return f, nil
+}
+
+// home returns the hash's home slot in a table of 1<<bits slots
+func home(hash [32]byte, bits uint) int64 {
+	return int64(binary.BigEndian.Uint64(hash[:8]) >> (64 - bits))
+}
+
+// probe scans from the hash's home slot and returns the slot holding it,
+// or the first empty slot
+func (d *DiskIndex) probe(hash [32]byte) (int64, ChunkInfo, bool, error) {
+	slot := home(hash, d.bits)
+	for {
+		n, err := d.f.ReadAt(d.page, slot*slotSize)
+		if err != nil && err != io.EOF {
+			return 0, ChunkInfo{}, false, fmt.Errorf("read chunk index: %w", err)
+		}
+		clear(d.page[n:])
+		for i := range pageSlots {
+			s := d.page[i*slotSize : (i+1)*slotSize]
+			if s[slotSize-1] == 0 {
+				return slot, ChunkInfo{}, false, nil
+			}
+			if bytes.Equal(s[:32], hash[:]) {
+				return slot, decodeSlot(s), true, nil
+			}
+			slot++
+		}
+	}
+}
+
+// Get returns the chunk with this hash, if present
+func (d *DiskIndex) Get(hash [32]byte) (ChunkInfo, bool, error) {
+	_, info, ok, err := d.probe(hash)
+	return info, ok, err
+}
+
+// Put adds a chunk; a chunk already present is left as is
+func (d *DiskIndex) Put(info ChunkInfo) error {
+	slot, _, ok, err := d.probe(info.Hash)
+	if err != nil || ok {
+		return err
+	}
+	var buf [slotSize]byte
+	encodeSlot(buf[:], info)
+	if _, err := d.f.WriteAt(buf[:], slot*slotSize); err != nil {
+		return fmt.Errorf("write chunk index: %w", err)
+	}
+	d.count++
+	if d.count*100 > maxLoadPercent<<d.bits {
+		return d.grow()
+	}
+	return nil
+}
+
+// Len returns the number of chunks in the index
+func (d *DiskIndex) Len() int {
+	return d.count
+}
+
+// Each calls fn for every chunk in ascending hash order
+func (d *DiskIndex) Each(fn func(ChunkInfo) error) error {
+	r := bufio.NewReaderSize(io.NewSectionReader(d.f, 0, 1<<62), 1<<20)
+	var run []ChunkInfo
+	flush := func() error {
+		slices.SortFunc(run, func(a, b ChunkInfo) int {
+			return bytes.Compare(a.Hash[:], b.Hash[:])
+		})
+		for _, info := range run {
+			if err := fn(info); err != nil {
+				return err
+			}
+		}
+		run = run[:0]
+		return nil
+	}
+
+	var s [slotSize]byte
+	for {
+		if _, err := io.ReadFull(r, s[:]); err != nil {
+			if err == io.EOF {
+				return flush()
+			}
+			return fmt.Errorf("read chunk index: %w", err)
+		}
+		if s[slotSize-1] != 0 {
+			run = append(run, decodeSlot(s[:]))
+		} else if len(run) > 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// grow rebuilds the table with twice the slots. Chunks come out of Each in
+// hash order, which is also the new table's slot order, so each lands right
+// after the previous one or at its home slot and the new file is written
+// sequentially.
+func (d *DiskIndex) grow() error {
+	f, err := d.createFile()
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriterSize(f, 1<<20)
+	bits := d.bits + 1
+	next := int64(0) // first slot not written yet
+	var zero, buf [slotSize]byte
+
+	err = d.Each(func(info ChunkInfo) error {
+		for slot := home(info.Hash, bits); next < slot; next++ {
+			if _, err := w.Write(zero[:]); err != nil {
+				return err
+			}
+		}
+		encodeSlot(buf[:], info)
+		next++
+		_, err := w.Write(buf[:])
+		return err
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("grow chunk index: %w", err)
+	}
+
+	d.f.Close()
+	os.Remove(d.f.Name())
+	d.f = f
+	d.bits = bits
+	return nil
+}
+
+// Close removes the index file
+func (d *DiskIndex) Close() error {
+	err := d.f.Close()
+	if rmErr := os.Remove(d.f.Name()); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+func encodeSlot(s []byte, info ChunkInfo) {
+	copy(s, info.Hash[:])
+	binary.LittleEndian.PutUint64(s[32:], info.Offset)
+	binary.LittleEndian.PutUint64(s[40:], info.CompressedSize)
+	binary.LittleEndian.PutUint64(s[48:], info.OriginalSize)
+	clear(s[56 : slotSize-1])
+	if info.Stored {
+		s[56] = 1
+	}
+	s[slotSize-1] = 1
+}
+
+func decodeSlot(s []byte) ChunkInfo {
+	var info ChunkInfo
+	copy(info.Hash[:], s)
+	info.Offset = binary.LittleEndian.Uint64(s[32:])
+	info.CompressedSize = binary.LittleEndian.Uint64(s[40:])
+	info.OriginalSize = binary.LittleEndian.Uint64(s[48:])
+	info.Stored = s[56] == 1
+	return info
+}
@@ -0,0 +1,109 @@
+// internal/chunkstore/diskindex_test.go
+package chunkstore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+)
+
+func testHash(i int) [32]byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(i))
+	return sha256.Sum256(b[:])
+}
+
+func TestDiskIndex(t *testing.T) {
+	dir := t.TempDir()
+	idx, err := NewDiskIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Enough chunks to grow the table several times
+	const n = 300_000
+	for i := range n {
+		info := ChunkInfo{Hash: testHash(i), Offset: uint64(i), CompressedSize: 10, OriginalSize: 20}
+		if err := idx.Put(info); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if idx.Len() != n {
+		t.Fatalf("Len: got %d, want %d", idx.Len(), n)
+	}
+
+	for _, i := range []int{0, 1, n / 2, n - 1} {
+		info, ok, err := idx.Get(testHash(i))
+		if err != nil || !ok || info.Offset != uint64(i) {
+			t.Fatalf("Get(%d): %+v, %v, %v", i, info, ok, err)
+		}
+	}
+	if _, ok, _ := idx.Get(testHash(n)); ok {
+		t.Error("Get found a chunk never added")
+	}
+
+	var prev [32]byte
+	count := 0
+	err = idx.Each(func(info ChunkInfo) error {
+		if count > 0 && bytes.Compare(prev[:], info.Hash[:]) >= 0 {
+			t.Fatalf("entry %d out of order", count)
+		}
+		prev = info.Hash
+		count++
+		return nil
+	})
+	if err != nil || count != n {
+		t.Fatalf("Each: %d entries, %v", count, err)
+	}
+
+	if err := idx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("index files left behind: %v", files)
+	}
+}
+
+func TestStoreWithDiskIndex(t *testing.T) {
+	idx, err := NewDiskIndex(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A tiny cache sends most duplicate lookups to the disk index
+	store := NewStoreWithIndex(2, idx)
+	defer store.Close()
+
+	for round := range 2 {
+		for i := range 100 {
+			_, isNew, err := store.GetOrAdd(testHash(i), 100, func() (uint64, uint64, error) {
+				return uint64(i * 50), 50, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isNew != (round == 0) {
+				t.Fatalf("round %d chunk %d: isNew = %v", round, i, isNew)
+			}
+		}
+	}
+
+	stats := store.Stats()
+	if stats.UniqueChunks != 100 || stats.IndexHits < 90 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	all, err := store.All()
+	if err != nil || len(all) != 100 {
+		t.Fatalf("All: %d chunks, %v", len(all), err)
+	}
+	if all[testHash(7)].Offset != 350 {
+		t.Errorf("chunk 7 offset: got %d", all[testHash(7)].Offset)
+	}
+}
+
+func TestDiskIndexMissingDir(t *testing.T) {
+	if _, err := NewDiskIndex(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,68 @@
+// internal/chunkstore/index.go
+package chunkstore
+
+import (
+	"bytes"
+	"slices"
+)
+
+// Index is the permanent hash → ChunkInfo map behind a Store. Every unique
+// chunk of an archive ends up in it, so it is what grows with the input:
+// the LRU cache in front of it only bounds the hot set.
+//
+// Implementations need not be safe for concurrent use; the Store serializes
+// calls, except for concurrent Each calls.
+type Index interface {
+	// Get returns the chunk with this hash, if present
+	Get(hash [32]byte) (ChunkInfo, bool, error)
+
+	// Put adds a chunk that is not in the index yet
+	Put(info ChunkInfo) error
+
+	// Len returns the number of chunks in the index
+	Len() int
+
+	// Each calls fn for every chunk, in ascending hash order (the order of
+	// the archive's chunk index), stopping at the first error
+	Each(fn func(ChunkInfo) error) error
+
+	// Close releases the index's resources
+	Close() error
+}
+
+// memIndex keeps the index in a map, about 88 bytes per chunk
+type memIndex map[[32]byte]ChunkInfo
+
+func (m memIndex) Get(hash [32]byte) (ChunkInfo, bool, error) {
+	info, ok := m[hash]
+	return info, ok, nil
+}
+
+func (m memIndex) Put(info ChunkInfo) error {
+	m[info.Hash] = info
+	return nil
+}
+
+func (m memIndex) Len() int {
+	return len(m)
+}
+
+func (m memIndex) Each(fn func(ChunkInfo) error) error {
+	hashes := make([][32]byte, 0, len(m))
+	for hash := range m {
+		hashes = append(hashes, hash)
+	}
+	slices.SortFunc(hashes, func(a, b [32]byte) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	for _, hash := range hashes {
+		if err := fn(m[hash]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m memIndex) Close() error {
+	return nil
+}
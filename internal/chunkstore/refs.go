@@ -0,0 +1,119 @@
+// internal/chunkstore/refs.go
+package chunkstore
+
+import (
+	"bytes"
+	"cmp"
+	"maps"
+	"math/bits"
+	"slices"
+)
+
+// RefCounter counts the references to each chunk of an archive, to tell
+// where its dedup savings come from. It keeps one small entry per unique
+// chunk; it is not safe for concurrent use.
+type RefCounter struct {
+	chunks     map[[32]byte]*ChunkRefs
+	references uint64
+}
+
+// ChunkRefs is a chunk and the number of times it is referenced
+type ChunkRefs struct {
+	ChunkInfo
+	Refs uint64
+}
+
+// SavedSize returns the original bytes the chunk's references beyond the
+// first didn't store
+func (c ChunkRefs) SavedSize() uint64 {
+	return (c.Refs - 1) * c.OriginalSize
+}
+
+// RefBucket counts the chunks referenced MinRefs to MaxRefs times
+type RefBucket struct {
+	MinRefs, MaxRefs uint64
+	Chunks           uint64
+	CompressedSize   uint64 // Stored size of these chunks
+	SavedSize        uint64 // Original bytes their extra references didn't store
+}
+
+// RefStats summarizes the references counted by a RefCounter
+type RefStats struct {
+	Chunks       uint64 // Unique chunks
+	References   uint64 // References to them
+	LogicalSize  uint64 // Original size summed over the references
+	PhysicalSize uint64 // Compressed size of the unique chunks
+
+	// Top are the most referenced chunks, most referenced first (ties:
+	// largest first)
+	Top []ChunkRefs
+
+	// Distribution buckets the chunks by reference count in powers of
+	// two: 1, 2, 3-4, 5-8, ...; empty buckets are left out
+	Distribution []RefBucket
+}
+
+// NewRefCounter returns an empty RefCounter
+func NewRefCounter() *RefCounter {
+	return &RefCounter{chunks: make(map[[32]byte]*ChunkRefs)}
+}
+
+// Add counts a reference to chunk info
+func (c *RefCounter) Add(info ChunkInfo) {
+	c.references++
+	if refs, ok := c.chunks[info.Hash]; ok {
+		refs.Refs++
+		return
+	}
+	c.chunks[info.Hash] = &ChunkRefs{ChunkInfo: info, Refs: 1}
+}
+
+// Refs returns the references counted to the chunk with this hash
+func (c *RefCounter) Refs(hash [32]byte) uint64 {
+	if refs, ok := c.chunks[hash]; ok {
+		return refs.Refs
+	}
+	return 0
+}
+
+// Stats returns the reference statistics, with the top most referenced
+// chunks
+func (c *RefCounter) Stats(top int) RefStats {
+	stats := RefStats{Chunks: uint64(len(c.chunks)), References: c.references}
+	buckets := make(map[int]*RefBucket)
+	all := make([]ChunkRefs, 0, len(c.chunks))
+	for _, refs := range c.chunks {
+		stats.LogicalSize += refs.Refs * refs.OriginalSize
+		stats.PhysicalSize += refs.CompressedSize
+		all = append(all, *refs)
+
+		// Bucket b holds 2^(b-1)+1 to 2^b references, bucket 0 one
+		b := bits.Len64(refs.Refs - 1)
+		bucket, ok := buckets[b]
+		if !ok {
+			bucket = &RefBucket{MinRefs: 1<<b/2 + 1, MaxRefs: 1 << b}
+			if b == 0 {
+				bucket.MinRefs = 1
+			}
+			buckets[b] = bucket
+		}
+		bucket.Chunks++
+		bucket.CompressedSize += refs.CompressedSize
+		bucket.SavedSize += refs.SavedSize()
+	}
+
+	for _, b := range slices.Sorted(maps.Keys(buckets)) {
+		stats.Distribution = append(stats.Distribution, *buckets[b])
+	}
+	slices.SortFunc(all, func(x, y ChunkRefs) int {
+		if c := cmp.Compare(y.Refs, x.Refs); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(y.OriginalSize, x.OriginalSize); c != 0 {
+			return c
+		}
+		return bytes.Compare(x.Hash[:], y.Hash[:])
+	})
+	stats.Top = all[:min(top, len(all))]
+	return stats
+}
@@ -0,0 +1,44 @@
+// internal/chunkstore/refs_test.go
+package chunkstore
+
+import "testing"
+
+func TestRefCounter(t *testing.T) {
+	refs := NewRefCounter()
+	for i, n := range []int{1, 1, 2, 3, 4, 5, 9} {
+		info := ChunkInfo{Hash: [32]byte{byte(i)}, OriginalSize: 100, CompressedSize: 40}
+		for range n {
+			refs.Add(info)
+		}
+	}
+
+	stats := refs.Stats(3)
+	if stats.Chunks != 7 || stats.References != 25 {
+		t.Errorf("chunks %d, references %d, want 7 and 25", stats.Chunks, stats.References)
+	}
+	if stats.LogicalSize != 2500 || stats.PhysicalSize != 280 {
+		t.Errorf("logical %d, physical %d, want 2500 and 280", stats.LogicalSize, stats.PhysicalSize)
+	}
+	if len(stats.Top) != 3 || stats.Top[0].Refs != 9 || stats.Top[1].Refs != 5 || stats.Top[2].Refs != 4 {
+		t.Errorf("top %+v, want 9, 5 and 4 references", stats.Top)
+	}
+
+	want := []RefBucket{
+		{MinRefs: 1, MaxRefs: 1, Chunks: 2, CompressedSize: 80},
+		{MinRefs: 2, MaxRefs: 2, Chunks: 1, CompressedSize: 40, SavedSize: 100},
+		{MinRefs: 3, MaxRefs: 4, Chunks: 2, CompressedSize: 80, SavedSize: 500},
+		{MinRefs: 5, MaxRefs: 8, Chunks: 1, CompressedSize: 40, SavedSize: 400},
+		{MinRefs: 9, MaxRefs: 16, Chunks: 1, CompressedSize: 40, SavedSize: 800},
+	}
+	if len(stats.Distribution) != len(want) {
+		t.Fatalf("distribution %+v, want %+v", stats.Distribution, want)
+	}
+	for i := range want {
+		if stats.Distribution[i] != want[i] {
+			t.Errorf("bucket %d: %+v, want %+v", i, stats.Distribution[i], want[i])
+		}
+	}
+	if refs.Refs([32]byte{6}) != 9 || refs.Refs([32]byte{42}) != 0 {
+		t.Error("Refs: wrong counts")
+	}
+}
@@ -32,7 +32,8 @@ type inflightChunk struct {
 type Store struct {
 	mu        sync.RWMutex
 	chunks    map[[32]byte]*chunkEntry    // LRU cache for dedup lookups
-	allChunks map[[32]byte]ChunkInfo      // Complete index, never evicted
+	index     Index                       // Complete index, never evicted
+	inMemory  bool                        // index is a memIndex (counted in MemoryBytes)
 	inflight  map[[32]byte]*inflightChunk // Writes in progress
 	lruList   *list.List                  // LRU list of hash keys
 	maxChunks int                         // Maximum chunks to keep in memory (0 = unlimited)
@@ -43,8 +44,20 @@ type Store struct {
 	dedupedChunks atomic.Uint64
 	bytesSaved    atomic.Uint64
 	evictions     atomic.Uint64 // Chunks evicted due to capacity
+	cacheHits     atomic.Uint64 // Duplicates found in the LRU cache
+	indexHits     atomic.Uint64 // Duplicates found in the index after eviction
+	inflightWaits atomic.Uint64 // Duplicates that waited for a concurrent write
+	lockWaits     atomic.Uint64 // Lock acquisitions that had to wait
 }
 
+// Approximate heap cost of one chunk, used for Stats.MemoryBytes:
+// an index entry is the map slot plus ChunkInfo, a cached entry adds the
+// chunkEntry, its list.Element and the LRU map slot
+const (
+	indexEntryBytes = 88
+	cacheEntryBytes = 120
+)
+
 // NewStore creates a new chunk store with unlimited capacity
 func NewStore() *Store {
 	return NewStoreWithCapacity(0)
@@ -53,9 +66,18 @@ func NewStore() *Store {
 // NewStoreWithCapacity creates a chunk store with a maximum capacity
 // maxChunks: maximum number of chunks to keep (0 = unlimited)
 func NewStoreWithCapacity(maxChunks int) *Store {
+	return NewStoreWithIndex(maxChunks, memIndex{})
+}
+
+// NewStoreWithIndex creates a chunk store whose permanent index is index,
+// e.g. a DiskIndex; maxChunks bounds the LRU cache in front of it as in
+// NewStoreWithCapacity. Close closes the index.
+func NewStoreWithIndex(maxChunks int, index Index) *Store {
+	_, inMemory := index.(memIndex)
 	return &Store{
 		chunks:    make(map[[32]byte]*chunkEntry),
-		allChunks: make(map[[32]byte]ChunkInfo), // Never evicted
+		index:     index,
+		inMemory:  inMemory,
 		inflight:  make(map[[32]byte]*inflightChunk),
 		lruList:   list.New(),
 		maxChunks: maxChunks,
@@ -66,11 +88,21 @@ func NewStoreWithCapacity(maxChunks int) *Store {
 // Returns (ChunkInfo, isNew, error)
 // If isNew=false, the chunk was deduplicated
 func (s *Store) GetOrAdd(hash [32]byte, origSize uint64, writeFunc func() (offset uint64, comprSize uint64, err error)) (ChunkInfo, bool, error) {
+	return s.GetOrAddChunk(hash, origSize, func() (ChunkInfo, error) {
+		offset, comprSize, err := writeFunc()
+		return ChunkInfo{Offset: offset, CompressedSize: comprSize}, err
+	})
+}
+
+// GetOrAddChunk is GetOrAdd for writers that describe the chunk they
+// wrote: writeFunc returns its Offset, CompressedSize and Stored, the store
+// fills in the hash and original size
+func (s *Store) GetOrAddChunk(hash [32]byte, origSize uint64, writeFunc func() (ChunkInfo, error)) (ChunkInfo, bool, error) {
 	// Always count total chunks processed
 	s.totalChunks.Add(1)
 
 	for {
-		s.mu.Lock()
+		s.lock()
 
 		// Check LRU cache
 		if entry, exists := s.chunks[hash]; exists {
@@ -79,6 +111,7 @@ func (s *Store) GetOrAdd(hash [32]byte, origSize uint64, writeFunc func() (offse
 			info := entry.info
 			s.mu.Unlock()
 
+			s.cacheHits.Add(1)
 			s.dedupedChunks.Add(1)
 			// Track compressed bytes saved, not original bytes
 			s.bytesSaved.Add(info.CompressedSize)
@@ -86,9 +119,15 @@ func (s *Store) GetOrAdd(hash [32]byte, origSize uint64, writeFunc func() (offse
 		}
 
 		// Check permanent index (evicted from LRU but data already in archive)
-		if info, exists := s.allChunks[hash]; exists {
+		info, exists, err := s.index.Get(hash)
+		if err != nil {
+			s.mu.Unlock()
+			return ChunkInfo{}, false, err
+		}
+		if exists {
 			s.mu.Unlock()
 
+			s.indexHits.Add(1)
 			s.dedupedChunks.Add(1)
 			s.bytesSaved.Add(info.CompressedSize)
 			return info, false, nil
@@ -100,6 +139,7 @@ func (s *Store) GetOrAdd(hash [32]byte, origSize uint64, writeFunc func() (offse
 			s.mu.Unlock()
 			<-fl.done
 			if fl.err == nil {
+				s.inflightWaits.Add(1)
 				s.dedupedChunks.Add(1)
 				s.bytesSaved.Add(fl.info.CompressedSize)
 				return fl.info, false, nil
@@ -113,9 +153,9 @@ func (s *Store) GetOrAdd(hash [32]byte, origSize uint64, writeFunc func() (offse
 		s.inflight[hash] = fl
 		s.mu.Unlock()
 
-		offset, comprSize, err := writeFunc()
+		info, err = writeFunc()
 
-		s.mu.Lock()
+		s.lock()
 		delete(s.inflight, hash)
 		if err != nil {
 			s.mu.Unlock()
@@ -124,15 +164,15 @@ func (s *Store) GetOrAdd(hash [32]byte, origSize uint64, writeFunc func() (offse
 			return ChunkInfo{}, false, err
 		}
 
-		info := ChunkInfo{
-			Hash:           hash,
-			Offset:         offset,
-			CompressedSize: comprSize,
-			OriginalSize:   origSize,
-		}
+		info.Hash, info.OriginalSize = hash, origSize
 
 		// Add to permanent index (never evicted)
-		s.allChunks[hash] = info
+		if err := s.index.Put(info); err != nil {
+			s.mu.Unlock()
+			fl.err = err
+			close(fl.done)
+			return ChunkInfo{}, false, err
+		}
 
 		// Evict LRU chunk if at capacity (only from cache, not from allChunks)
 		if s.maxChunks > 0 && len(s.chunks) >= s.maxChunks {
@@ -155,6 +195,16 @@ func (s *Store) GetOrAdd(hash [32]byte, origSize uint64, writeFunc func() (offse
 	}
 }
 
+// lock acquires the write lock, counting the acquisitions that found it
+// held by another worker
+func (s *Store) lock() {
+	if s.mu.TryLock() {
+		return
+	}
+	s.lockWaits.Add(1)
+	s.mu.Lock()
+}
+
 // evictLRU removes the least recently used chunk
 // Must be called with write lock held
 func (s *Store) evictLRU() {
@@ -186,18 +236,37 @@ func (s *Store) Get(hash [32]byte) (ChunkInfo, bool) {
 
 // All returns all chunks ever seen (including evicted ones)
 // This is critical: evicted chunks are removed from s.chunks but their
-// metadata (hash, offset, sizes) must be preserved for the archive index
-func (s *Store) All() map[[32]byte]ChunkInfo {
+// metadata (hash, offset, sizes) must be preserved for the archive index.
+// It copies the whole index into memory; use Each for a DiskIndex.
+func (s *Store) All() (map[[32]byte]ChunkInfo, error) {
+	result := make(map[[32]byte]ChunkInfo, s.Len())
+	err := s.Each(func(info ChunkInfo) error {
+		result[info.Hash] = info
+		return nil
+	})
+	return result, err
+}
+
+// Each calls fn for all chunks ever seen, in ascending hash order. It must
+// not run concurrently with GetOrAdd.
+func (s *Store) Each(fn func(ChunkInfo) error) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	return s.index.Each(fn)
+}
 
-	// Return all chunks including those evicted from the LRU cache
-	// The allChunks map is never evicted, so it contains complete metadata
-	result := make(map[[32]byte]ChunkInfo, len(s.allChunks))
-	for k, info := range s.allChunks {
-		result[k] = info
-	}
-	return result
+// Len returns the number of chunks ever seen (including evicted ones)
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.index.Len()
+}
+
+// Close releases the index (removing a DiskIndex's file)
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.index.Close()
 }
 
 // Count returns the number of unique chunks
@@ -207,15 +276,34 @@ func (s *Store) Count() int {
 	return len(s.chunks)
 }
 
-// Stats returns deduplication statistics
+// Stats returns deduplication statistics. It only reads counters, so it is
+// cheap enough to call while workers are running; the fields are loaded
+// one by one and may be off by a chunk in flight.
 func (s *Store) Stats() Stats {
+	unique := s.uniqueChunks.Load()
+	evictions := s.evictions.Load()
+	cached := unique - min(evictions, unique)
 	return Stats{
-		TotalChunks:   s.totalChunks.Load(),
-		UniqueChunks:  s.uniqueChunks.Load(),
-		DedupedChunks: s.dedupedChunks.Load(),
-		BytesSaved:    s.bytesSaved.Load(),
-		Evictions:     s.evictions.Load(),
+		TotalChunks:    s.totalChunks.Load(),
+		UniqueChunks:   unique,
+		DedupedChunks:  s.dedupedChunks.Load(),
+		BytesSaved:     s.bytesSaved.Load(),
+		Evictions:      evictions,
+		CacheHits:      s.cacheHits.Load(),
+		IndexHits:      s.indexHits.Load(),
+		InflightWaits:  s.inflightWaits.Load(),
+		LockContention: s.lockWaits.Load(),
+		CachedChunks:   cached,
+		MemoryBytes:    s.indexMemory(unique) + cached*cacheEntryBytes,
+	}
+}
+
+// indexMemory estimates the heap held by the permanent index
+func (s *Store) indexMemory(unique uint64) uint64 {
+	if !s.inMemory {
+		return 0
 	}
+	return unique * indexEntryBytes
 }
 
 // Stats contains deduplication statistics
@@ -225,6 +313,24 @@ type Stats struct {
 	DedupedChunks uint64 // Chunks that were deduplicated
 	BytesSaved    uint64 // Bytes saved through deduplication
 	Evictions     uint64 // Chunks evicted from store due to capacity limit
+
+	// Where the deduplicated chunks were found
+	CacheHits     uint64 // In the LRU cache
+	IndexHits     uint64 // In the permanent index, after eviction from the cache
+	InflightWaits uint64 // Being written by another worker at the time
+
+	LockContention uint64 // Lookups that waited for the store lock
+	CachedChunks   uint64 // Chunks currently in the LRU cache
+	MemoryBytes    uint64 // Estimated memory held by the cache and index (a DiskIndex counts as 0)
+}
+
+// HitRate returns the share of lookups served by the LRU cache, as a
+// percentage. It falls below DedupRatio when the cache is too small.
+func (s Stats) HitRate() float64 {
+	if s.TotalChunks == 0 {
+		return 0
+	}
+	return float64(s.CacheHits) / float64(s.TotalChunks) * 100
 }
 
 // DedupRatio returns the deduplication ratio as a percentage
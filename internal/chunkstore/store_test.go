@@ -176,7 +176,10 @@ func TestStoreAll(t *testing.T) {
 		})
 	}
 
-	all := store.All()
+	all, err := store.All()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if len(all) != len(hashes) {
 		t.Errorf("Expected %d chunks, got %d", len(hashes), len(all))
@@ -9,10 +9,12 @@ import (
 
 const (
 	// Magic signature for go-delta archives
-	ArchiveMagic = "GDELTA01"
-	MagicSize    = 8
+	ArchiveMagic  = "GDELTA01"
+	ArchiveFooter = "GDELTAEND"
+	MagicSize     = 8
 
-	// File entry header size: path_len(2) + orig_size(8) + comp_size(8) + data_offset(8)
+	// File entry header size: path_len(2) + orig_size(8) + comp_size(8) +
+	// data_offset(8, Method in the top byte)
 	FileEntryHeaderSize = 26
 )
 
@@ -61,8 +63,13 @@ func WriteFileEntry(w io.WriteSeeker, relPath string, origSize uint64) (entryPos
 	return entryPos, nil
 }
 
-// UpdateFileEntry updates the compressed size and data offset fields of a previously written entry
-func UpdateFileEntry(w io.WriteSeeker, entryPos int64, compressedSize uint64, dataOffset uint64) error {
+// UpdateFileEntry updates the compressed size and data offset fields of a previously written entry.
+// The entry's method shares the data offset field.
+func UpdateFileEntry(w io.WriteSeeker, entryPos int64, compressedSize uint64, dataOffset uint64, method Method) error {
+	if dataOffset > maxGDelta01Bytes {
+		return fmt.Errorf("data offset %d exceeds the GDELTA01 limit", dataOffset)
+	}
+
 	// Save current position
 	currentPos, err := w.Seek(0, io.SeekCurrent)
 	if err != nil {
@@ -96,7 +103,7 @@ func UpdateFileEntry(w io.WriteSeeker, entryPos int64, compressedSize uint64, da
 	// Write compressed size + data offset in one call
 	var buf [16]byte
 	binary.LittleEndian.PutUint64(buf[:8], compressedSize)
-	binary.LittleEndian.PutUint64(buf[8:], dataOffset)
+	binary.LittleEndian.PutUint64(buf[8:], packDataOffset(dataOffset, method))
 	if _, err := w.Write(buf[:]); err != nil {
 		return fmt.Errorf("write comp size and data offset: %w", err)
 	}
@@ -109,10 +116,21 @@ func UpdateFileEntry(w io.WriteSeeker, entryPos int64, compressedSize uint64, da
 	return nil
 }
 
+// AppendFileEntry appends a complete entry header for e, the layout
+// written by WriteFileEntry and UpdateFileEntry, to buf
+func AppendFileEntry(buf []byte, e *FileEntry) []byte {
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(e.Path)))
+	buf = append(buf, e.Path...)
+	buf = binary.LittleEndian.AppendUint64(buf, e.OriginalSize)
+	compSize, dataOffset := entryFields(e)
+	buf = binary.LittleEndian.AppendUint64(buf, compSize)
+	return binary.LittleEndian.AppendUint64(buf, dataOffset)
+}
+
 // WriteArchiveFooter writes any trailing metadata (currently just a simple end marker)
 func WriteArchiveFooter(w io.Writer) error {
 	// For now, just write an end marker
-	if _, err := w.Write([]byte("GDELTAEND")); err != nil {
+	if _, err := w.Write([]byte(ArchiveFooter)); err != nil {
 		return fmt.Errorf("write footer: %w", err)
 	}
 	return nil
@@ -0,0 +1,203 @@
+// internal/format/codec.go
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/creativeyann17/go-delta/internal/pool"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec is a compression algorithm for file entries and GDELTA02 chunks.
+// Codecs are registered under a Method, the ID byte recorded in archives.
+type Codec interface {
+	// Name is the codec name used by compress.Options.Codec
+	Name() string
+
+	// LevelRange returns the accepted compression levels; both are 0 when
+	// the codec has no levels
+	LevelRange() (min, max int)
+
+	// NewWriter returns a writer compressing to w. Close flushes it.
+	NewWriter(w io.Writer, level int) (io.WriteCloser, error)
+
+	// NewReader returns a reader decompressing r
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[Method]Codec{}
+)
+
+// RegisterCodec makes a codec available under id. It panics if the id or
+// the name is already taken, like database/sql.Register.
+func RegisterCodec(id Method, c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if _, dup := codecs[id]; dup {
+		panic(fmt.Sprintf("format: codec id %d registered twice", id))
+	}
+	for _, other := range codecs {
+		if other.Name() == c.Name() {
+			panic("format: codec " + c.Name() + " registered twice")
+		}
+	}
+	codecs[id] = c
+}
+
+// LookupCodec returns the codec registered under id
+func LookupCodec(id Method) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[id]
+	return c, ok
+}
+
+// CodecByName returns the id and codec registered under name
+func CodecByName(name string) (Method, Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	for id, c := range codecs {
+		if c.Name() == name {
+			return id, c, true
+		}
+	}
+	return 0, nil, false
+}
+
+// CodecNames lists the registered codec names in id order
+func CodecNames() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	ids := make([]Method, 0, len(codecs))
+	for id := range codecs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = codecs[id].Name()
+	}
+	return names
+}
+
+// EncodeBlock compresses src in one go, appending to dst
+func EncodeBlock(c Codec, dst, src []byte, level int) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	w, err := c.NewWriter(buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(src); err != nil {
+		w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeBlock decompresses src in one go, appending to dst
+func DecodeBlock(c Codec, dst, src []byte) ([]byte, error) {
+	r, err := c.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	buf := bytes.NewBuffer(dst)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func init() {
+	RegisterCodec(MethodZstd, zstdCodec{})
+	RegisterCodec(MethodStore, storeCodec{})
+	RegisterCodec(MethodDeflate, deflateCodec{})
+}
+
+// zstdCodec is the generic path for zstd. The compressors keep their own
+// per-worker encoders (and the GDELTA03 dictionary) for speed; this one
+// serves tools that handle any codec. Its encoders and decoders are pooled.
+type zstdCodec struct{}
+
+func (zstdCodec) Name() string               { return "zstd" }
+func (zstdCodec) LevelRange() (min, max int) { return 1, 22 }
+
+func (zstdCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	cfg := pool.EncoderConfig{Level: level, Concurrency: 1}
+	enc, _, err := cfg.Encoder()
+	if err != nil {
+		return nil, err
+	}
+	enc.Reset(w)
+	return &pooledEncoder{enc: enc, cfg: cfg}, nil
+}
+
+func (zstdCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return pool.DecoderReader(r)
+}
+
+// pooledEncoder finishes the stream on Close and returns the encoder
+type pooledEncoder struct {
+	enc *zstd.Encoder
+	cfg pool.EncoderConfig
+}
+
+func (e *pooledEncoder) Write(p []byte) (int, error) {
+	return e.enc.Write(p)
+}
+
+func (e *pooledEncoder) ReadFrom(r io.Reader) (int64, error) {
+	return e.enc.ReadFrom(r)
+}
+
+func (e *pooledEncoder) Close() error {
+	if e.enc == nil {
+		return nil
+	}
+	err := e.enc.Close()
+	e.cfg.PutEncoder(e.enc)
+	e.enc = nil
+	return err
+}
+
+// storeCodec keeps data as is
+type storeCodec struct{}
+
+func (storeCodec) Name() string               { return "store" }
+func (storeCodec) LevelRange() (min, max int) { return 0, 0 }
+
+func (storeCodec) NewWriter(w io.Writer, _ int) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+func (storeCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(r), nil
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// deflateCodec is raw deflate (RFC 1951), the algorithm ZIP uses
+type deflateCodec struct{}
+
+func (deflateCodec) Name() string               { return "deflate" }
+func (deflateCodec) LevelRange() (min, max int) { return 1, 9 }
+
+func (deflateCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return flate.NewWriter(w, level)
+}
+
+func (deflateCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
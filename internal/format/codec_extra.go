@@ -0,0 +1,71 @@
+// internal/format/codec_extra.go
+package format
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/snappy"
+	"github.com/pierrec/lz4/v4"
+)
+
+// Optional codecs, for users who trade ratio for speed (lz4, snappy) or
+// speed for ratio (brotli). zstd stays the default.
+func init() {
+	RegisterCodec(MethodLZ4, lz4Codec{})
+	RegisterCodec(MethodBrotli, brotliCodec{})
+	RegisterCodec(MethodSnappy, snappyCodec{})
+}
+
+// lz4Codec writes the LZ4 frame format. Level 1 is the fast compressor,
+// 2-9 the slower high-compression levels.
+type lz4Codec struct{}
+
+func (lz4Codec) Name() string               { return "lz4" }
+func (lz4Codec) LevelRange() (min, max int) { return 1, 9 }
+
+var lz4Levels = [...]lz4.CompressionLevel{
+	lz4.Fast, lz4.Level2, lz4.Level3, lz4.Level4, lz4.Level5,
+	lz4.Level6, lz4.Level7, lz4.Level8, lz4.Level9,
+}
+
+func (lz4Codec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	zw := lz4.NewWriter(w)
+	level = min(max(level, 1), len(lz4Levels))
+	if err := zw.Apply(lz4.CompressionLevelOption(lz4Levels[level-1]), lz4.ConcurrencyOption(1)); err != nil {
+		return nil, err
+	}
+	return zw, nil
+}
+
+func (lz4Codec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(lz4.NewReader(r)), nil
+}
+
+// brotliCodec is brotli (RFC 7932)
+type brotliCodec struct{}
+
+func (brotliCodec) Name() string               { return "brotli" }
+func (brotliCodec) LevelRange() (min, max int) { return 1, 11 }
+
+func (brotliCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return brotli.NewWriterLevel(w, level), nil
+}
+
+func (brotliCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(brotli.NewReader(r)), nil
+}
+
+// snappyCodec writes the snappy framing format; it has no levels
+type snappyCodec struct{}
+
+func (snappyCodec) Name() string               { return "snappy" }
+func (snappyCodec) LevelRange() (min, max int) { return 0, 0 }
+
+func (snappyCodec) NewWriter(w io.Writer, _ int) (io.WriteCloser, error) {
+	return snappy.NewBufferedWriter(w), nil
+}
+
+func (snappyCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(snappy.NewReader(r)), nil
+}
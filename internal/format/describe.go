@@ -0,0 +1,482 @@
+// internal/format/describe.go
+package format
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Field is one span of an archive described by Describe
+type Field struct {
+	Offset int64
+	Size   int64
+	Name   string // Dotted path such as "header.magic" or "entry[3].path"
+	Value  string // Decoded value, a summary for data and tables
+}
+
+// Layout is the annotated structure of an archive
+type Layout struct {
+	Format ArchiveFormat
+	Size   int64   // Bytes described: the archive in front of any parity
+	Fields []Field // In file order
+
+	// Omitted counts the table entries walked but left out of Fields past
+	// the maxEntries of each table
+	Omitted int
+}
+
+// describer walks an archive field by field, checking each one against
+// the archive size before reading it, so a malformed length stops the
+// walk with an error instead of a huge read
+type describer struct {
+	r          io.ReaderAt
+	pos        int64
+	maxEntries int
+	layout     *Layout
+}
+
+// Describe returns the fields of the archive of the given size in file
+// order: header fields, extension areas, entry tables and data spans. Only
+// the first maxEntries entries of each table are described (0 = all), the
+// others are walked and counted in Layout.Omitted. A field that doesn't
+// fit in the archive stops the walk: the layout so far is returned with
+// the error.
+func Describe(r io.ReaderAt, size int64, maxEntries int) (*Layout, error) {
+	layout := &Layout{Size: size}
+	magic := make([]byte, MagicSize)
+	if size < MagicSize {
+		return layout, fmt.Errorf("archive of %d bytes is too short for a magic", size)
+	}
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		return layout, fmt.Errorf("read magic: %w", err)
+	}
+	layout.Format = DetectFormat(magic)
+
+	d := &describer{r: r, maxEntries: maxEntries, layout: layout}
+	var err error
+	switch layout.Format {
+	case FormatGDelta01:
+		err = d.gdelta01()
+	case FormatGDelta02:
+		err = d.gdelta02()
+	case FormatGDelta03:
+		err = d.gdelta03()
+	default:
+		err = fmt.Errorf("can't describe %s archives", layout.Format)
+	}
+	return layout, err
+}
+
+// listed reports whether entry i of a table goes into Fields, counting it
+// in Omitted otherwise
+func (d *describer) listed(i int) bool {
+	if d.maxEntries > 0 && i >= d.maxEntries {
+		d.layout.Omitted++
+		return false
+	}
+	return true
+}
+
+// span records the n bytes at the current position and moves past them
+func (d *describer) span(show bool, name string, n int64, value string) error {
+	if n < 0 || n > d.layout.Size-d.pos {
+		return fmt.Errorf("%s: %d bytes at offset %d run past the end of the archive (%d bytes)", name, n, d.pos, d.layout.Size)
+	}
+	if show {
+		d.layout.Fields = append(d.layout.Fields, Field{Offset: d.pos, Size: n, Name: name, Value: value})
+	}
+	d.pos += n
+	return nil
+}
+
+// peek returns the n bytes at the current position without moving past
+// them
+func (d *describer) peek(name string, n int64) ([]byte, error) {
+	if n < 0 || n > d.layout.Size-d.pos {
+		return nil, fmt.Errorf("%s: %d bytes at offset %d run past the end of the archive (%d bytes)", name, n, d.pos, d.layout.Size)
+	}
+	buf := make([]byte, n)
+	if _, err := d.r.ReadAt(buf, d.pos); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return buf, nil
+}
+
+// read returns the n bytes at the current position, recording them as a
+// field with the value format returns
+func (d *describer) read(show bool, name string, n int64, format func([]byte) string) ([]byte, error) {
+	buf, err := d.peek(name, n)
+	if err != nil {
+		return nil, err
+	}
+	return buf, d.span(show, name, n, format(buf))
+}
+
+func (d *describer) u16(show bool, name string) (uint16, error) {
+	buf, err := d.read(show, name, 2, func(b []byte) string { return strconv.Itoa(int(binary.LittleEndian.Uint16(b))) })
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(buf), nil
+}
+
+func (d *describer) u32(show bool, name string) (uint32, error) {
+	buf, err := d.read(show, name, 4, func(b []byte) string { return strconv.FormatUint(uint64(binary.LittleEndian.Uint32(b)), 10) })
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(buf), nil
+}
+
+func (d *describer) u64(show bool, name string) (uint64, error) {
+	buf, err := d.read(show, name, 8, func(b []byte) string { return strconv.FormatUint(binary.LittleEndian.Uint64(b), 10) })
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(buf), nil
+}
+
+// marker records the magic or footer expected at the current position
+func (d *describer) marker(name, want string) error {
+	_, err := d.read(true, name, int64(len(want)), func(b []byte) string {
+		if string(b) != want {
+			return fmt.Sprintf("%q, want %q", b, want)
+		}
+		return strconv.Quote(want)
+	})
+	return err
+}
+
+// path records a PathLen(2) + Path pair
+func (d *describer) path(show bool, prefix string) (string, error) {
+	n, err := d.u16(show, prefix+".path_len")
+	if err != nil {
+		return "", err
+	}
+	buf, err := d.read(show, prefix+".path", int64(n), func(b []byte) string { return strconv.Quote(string(b)) })
+	return string(buf), err
+}
+
+// extensions records an extension area, one field per extension
+func (d *describer) extensions(show bool, prefix string) ([]Extension, error) {
+	areaLen, err := d.u32(show, prefix+".extensions.length")
+	if err != nil {
+		return nil, err
+	}
+	if areaLen > maxExtensionArea {
+		return nil, fmt.Errorf("%s.extensions: area of %d bytes (max %d)", prefix, areaLen, maxExtensionArea)
+	}
+	start := d.pos
+	buf, err := d.read(false, prefix+".extensions", int64(areaLen), func([]byte) string { return "" })
+	if err != nil {
+		return nil, err
+	}
+	exts, err := parseExtensions(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s.extensions: %w", prefix, err)
+	}
+	if show {
+		pos := start
+		for i, ext := range exts {
+			name := fmt.Sprintf("%s.extensions[%d]", prefix, i)
+			size := int64(6 + len(ext.Value))
+			d.layout.Fields = append(d.layout.Fields, Field{Offset: pos, Size: size, Name: name, Value: describeExtension(ext)})
+			pos += size
+		}
+	}
+	return exts, nil
+}
+
+// describeExtension summarizes an extension and its value
+func describeExtension(ext Extension) string {
+	v := ext.Value
+	switch {
+	case ext.Type == ExtMethod && len(v) == 1:
+		return "method " + Method(v[0]).String()
+	case ext.Type == ExtChunkBounds && len(v) >= 9:
+		return fmt.Sprintf("chunk bounds min %d max %d", binary.LittleEndian.Uint32(v), binary.LittleEndian.Uint32(v[4:]))
+	case ext.Type == ExtMetadata:
+		return fmt.Sprintf("metadata, %d bytes of JSON", len(v))
+	case ext.Type == ExtLink && len(v) == 4:
+		return fmt.Sprintf("link to entry %d", binary.LittleEndian.Uint32(v))
+	case ext.Type == ExtType:
+		return "type " + strconv.Quote(string(v))
+	case ext.Type == ExtChunkDict:
+		return fmt.Sprintf("chunk dictionary, %d bytes", len(v))
+	case ext.Type == ExtReferenceChunks:
+		if refs, err := ParseReferenceChunks(v); err == nil {
+			return fmt.Sprintf("%d reference chunks in %d files of %d directories", len(refs.Chunks), len(refs.Sources), len(refs.Dirs))
+		}
+		return fmt.Sprintf("reference chunks (malformed), %d bytes", len(v))
+	case ext.Type == ExtFormatSpec:
+		return fmt.Sprintf("format spec, %d bytes of text", len(v))
+	}
+	return fmt.Sprintf("type %d (unknown), %d bytes", ext.Type, len(v))
+}
+
+// footer records the footer and whatever follows it up to the end
+func (d *describer) footer(want string) error {
+	if err := d.marker("footer", want); err != nil {
+		return err
+	}
+	if rest := d.layout.Size - d.pos; rest > 0 {
+		return d.span(true, "trailing", rest, fmt.Sprintf("%d bytes after the footer", rest))
+	}
+	return nil
+}
+
+func (d *describer) gdelta01() error {
+	if err := d.marker("header.magic", ArchiveMagic); err != nil {
+		return err
+	}
+	count, err := d.u32(true, "header.file_count")
+	if err != nil {
+		return err
+	}
+
+	for i := range int(count) {
+		show := d.listed(i)
+		prefix := fmt.Sprintf("entry[%d]", i)
+		if _, err := d.path(show, prefix); err != nil {
+			return err
+		}
+		if _, err := d.u64(show, prefix+".orig_size"); err != nil {
+			return err
+		}
+		fields, err := d.peek(prefix+".comp_size", 16)
+		if err != nil {
+			return err
+		}
+		var e FileEntry
+		setEntryFields(&e, binary.LittleEndian.Uint64(fields), binary.LittleEndian.Uint64(fields[8:]))
+		compValue := strconv.FormatUint(e.CompressedSize, 10)
+		switch {
+		case e.Packed:
+			compValue = fmt.Sprintf("offset %d in the pack", e.PackOffset)
+		case e.Link:
+			compValue = fmt.Sprintf("link to entry %d", e.LinkIndex)
+		}
+		if err := d.span(show, prefix+".comp_size", 8, compValue); err != nil {
+			return err
+		}
+		offsetValue := fmt.Sprintf("%d, method %s", e.DataOffset, e.Method)
+		switch {
+		case e.Pack:
+			offsetValue += ", pack"
+		case e.Packed:
+			offsetValue += ", packed"
+		case e.Link:
+			offsetValue += ", link"
+		}
+		if err := d.span(show, prefix+".data_offset", 8, offsetValue); err != nil {
+			return err
+		}
+		if e.Packed || e.Link {
+			continue
+		}
+		if e.DataOffset != uint64(d.pos) {
+			return fmt.Errorf("%s: data offset %d, want %d (entry not completed)", prefix, e.DataOffset, d.pos)
+		}
+		if err := d.span(show, prefix+".data", int64(e.CompressedSize), fmt.Sprintf("%s data", e.Method)); err != nil {
+			return err
+		}
+	}
+
+	if err := d.marker("footer", ArchiveFooter); err != nil {
+		return err
+	}
+	return d.gdelta01Index(count)
+}
+
+// gdelta01Index records the entry index after a GDELTA01 footer, or the
+// bytes there when they are not an index
+func (d *describer) gdelta01Index(count uint32) error {
+	rest := d.layout.Size - d.pos
+	if rest == 0 {
+		return nil
+	}
+	trailerStart := d.layout.Size - indexTrailerSize01
+	magic := make([]byte, len(IndexMagic01))
+	if rest < indexTrailerSize01 {
+		return d.span(true, "trailing", rest, fmt.Sprintf("%d bytes after the footer", rest))
+	}
+	if _, err := d.r.ReadAt(magic, d.layout.Size-int64(len(magic))); err != nil || string(magic) != IndexMagic01 {
+		return d.span(true, "trailing", rest, fmt.Sprintf("%d bytes after the footer, no index", rest))
+	}
+	if err := d.span(true, "index.entries", trailerStart-d.pos, fmt.Sprintf("%d entries", count)); err != nil {
+		return err
+	}
+	if _, err := d.u64(true, "index.offset"); err != nil {
+		return err
+	}
+	if _, err := d.u32(true, "index.file_count"); err != nil {
+		return err
+	}
+	if _, err := d.read(true, "index.crc32", 4, func(b []byte) string { return fmt.Sprintf("%08x", binary.LittleEndian.Uint32(b)) }); err != nil {
+		return err
+	}
+	return d.marker("index.magic", IndexMagic01)
+}
+
+func (d *describer) gdelta02() error {
+	if err := d.marker("header.magic", ArchiveMagic02); err != nil {
+		return err
+	}
+	buf, err := d.read(true, "header.params", 8, func(b []byte) string {
+		params := binary.LittleEndian.Uint64(b)
+		return fmt.Sprintf("chunk size %d, level %d, codec %s, flags 0x%02x, features %s",
+			params&chunkSizeMask, uint8(params>>levelShift), ChunkCodec(uint8(params>>codecShift)), uint8(params>>flagsShift),
+			Features(params>>featuresShift))
+	})
+	if err != nil {
+		return err
+	}
+	extended := uint8(binary.LittleEndian.Uint64(buf)>>flagsShift)&FlagExtensions != 0
+	fileCount, err := d.u32(true, "header.file_count")
+	if err != nil {
+		return err
+	}
+	chunkCount, err := d.u32(true, "header.chunk_count")
+	if err != nil {
+		return err
+	}
+	if extended {
+		if _, err := d.extensions(true, "header"); err != nil {
+			return err
+		}
+	}
+
+	// Index entries are fixed-size: the listed ones are read, the others
+	// skipped in one span
+	if int64(chunkCount) > (d.layout.Size-d.pos)/chunkIndexEntrySize {
+		return fmt.Errorf("chunk_index: %d entries of %d bytes run past the end of the archive", chunkCount, chunkIndexEntrySize)
+	}
+	for i := range int(chunkCount) {
+		if !d.listed(i) {
+			d.layout.Omitted += int(chunkCount) - i - 1
+			if err := d.span(true, fmt.Sprintf("chunk_index[%d:]", i), int64(int(chunkCount)-i)*chunkIndexEntrySize, fmt.Sprintf("%d more chunks", int(chunkCount)-i)); err != nil {
+				return err
+			}
+			break
+		}
+		prefix := fmt.Sprintf("chunk_index[%d]", i)
+		if _, err := d.read(true, prefix+".hash", 32, func(b []byte) string { return hex.EncodeToString(b[:8]) + "..." }); err != nil {
+			return err
+		}
+		if _, err := d.u64(true, prefix+".offset"); err != nil {
+			return err
+		}
+		if _, err := d.read(true, prefix+".comp_size", 8, func(b []byte) string {
+			size, stored := ParseChunkSizeField(binary.LittleEndian.Uint64(b))
+			if stored {
+				return fmt.Sprintf("%d, stored", size)
+			}
+			return strconv.FormatUint(size, 10)
+		}); err != nil {
+			return err
+		}
+		if _, err := d.u64(true, prefix+".orig_size"); err != nil {
+			return err
+		}
+	}
+
+	for i := range int(fileCount) {
+		show := d.listed(i)
+		prefix := fmt.Sprintf("file[%d]", i)
+		if _, err := d.path(show, prefix); err != nil {
+			return err
+		}
+		if _, err := d.u64(show, prefix+".orig_size"); err != nil {
+			return err
+		}
+		n, err := d.u32(show, prefix+".chunk_count")
+		if err != nil {
+			return err
+		}
+		if err := d.span(show, prefix+".chunk_hashes", 32*int64(n), fmt.Sprintf("hashes of %d chunks", n)); err != nil {
+			return err
+		}
+		if extended {
+			if _, err := d.extensions(show, prefix); err != nil {
+				return err
+			}
+		}
+	}
+
+	dataSize := d.layout.Size - d.pos - int64(len(ArchiveFooter02))
+	if dataSize < 0 {
+		return fmt.Errorf("chunk_data: no room for the footer at offset %d", d.pos)
+	}
+	if err := d.span(true, "chunk_data", dataSize, fmt.Sprintf("%d chunks", chunkCount)); err != nil {
+		return err
+	}
+	return d.footer(ArchiveFooter02)
+}
+
+func (d *describer) gdelta03() error {
+	if err := d.marker("header.magic", ArchiveMagic03); err != nil {
+		return err
+	}
+	if _, err := d.read(true, "header.version", 1, func(b []byte) string { return strconv.Itoa(int(b[0])) }); err != nil {
+		return err
+	}
+	dictSize, err := d.u32(true, "header.dict_size")
+	if err != nil {
+		return err
+	}
+	count, err := d.u32(true, "header.file_count")
+	if err != nil {
+		return err
+	}
+	flags, err := d.read(true, "header.flags", 1, func(b []byte) string { return fmt.Sprintf("0x%02x", b[0]) })
+	if err != nil {
+		return err
+	}
+	if _, err := d.read(true, "header.features", 1, func(b []byte) string { return Features(b[0]).String() }); err != nil {
+		return err
+	}
+	if err := d.span(true, "header.reserved", 2, ""); err != nil {
+		return err
+	}
+	extended := flags[0]&FlagExtensions != 0
+	if extended {
+		if _, err := d.extensions(true, "header"); err != nil {
+			return err
+		}
+	}
+	if err := d.span(true, "dictionary", int64(dictSize), fmt.Sprintf("%d bytes", dictSize)); err != nil {
+		return err
+	}
+
+	for i := range int(count) {
+		show := d.listed(i)
+		prefix := fmt.Sprintf("entry[%d]", i)
+		if _, err := d.path(show, prefix); err != nil {
+			return err
+		}
+		if _, err := d.u64(show, prefix+".orig_size"); err != nil {
+			return err
+		}
+		compSize, err := d.u64(show, prefix+".comp_size")
+		if err != nil {
+			return err
+		}
+		method := MethodZstd
+		if extended {
+			exts, err := d.extensions(show, prefix)
+			if err != nil {
+				return err
+			}
+			method = methodFromExtensions(exts)
+		}
+		if compSize > 0 {
+			if err := d.span(show, prefix+".data", int64(min(compSize, uint64(d.layout.Size))), fmt.Sprintf("%s data", method)); err != nil {
+				return err
+			}
+		}
+	}
+	return d.footer(ArchiveFooter03)
+}
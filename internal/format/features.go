@@ -0,0 +1,77 @@
+// internal/format/features.go
+package format
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Features is the bitfield of capabilities a GDELTA02 or GDELTA03 archive
+// requires from its reader: bits 56-63 of the GDELTA02 params field, the
+// first reserved byte of the GDELTA03 header. A reader refuses an archive
+// with a bit it doesn't know instead of misparsing it; readers written
+// before the field existed ignore it. GDELTA01 has no room for one.
+type Features uint8
+
+const (
+	// FeatureStoredChunks marks a GDELTA02 archive with uncompressed chunks
+	// (ChunkStoredFlag in their index entry)
+	FeatureStoredChunks Features = 1 << 0
+
+	// FeatureChunkDict marks a GDELTA02 archive whose chunks are compressed
+	// with the dictionary of its ExtChunkDict header extension
+	FeatureChunkDict Features = 1 << 1
+
+	// FeatureReferenceChunks marks a GDELTA02 archive taking chunks from
+	// reference directories (ExtReferenceChunks header extension)
+	FeatureReferenceChunks Features = 1 << 2
+)
+
+// SupportedFeatures are the features this version reads
+const SupportedFeatures = FeatureStoredChunks | FeatureChunkDict | FeatureReferenceChunks
+
+// featureNames names the features of this version, by bit
+var featureNames = map[Features]string{
+	FeatureStoredChunks:    "stored-chunks",
+	FeatureChunkDict:       "chunk-dictionary",
+	FeatureReferenceChunks: "reference-chunks",
+}
+
+// ErrUnsupportedFeature is wrapped by the errors of header readers refusing
+// an archive that requires a feature this version doesn't support, reading
+// "archive requires feature bit 5 (upgrade godelta)"
+var ErrUnsupportedFeature = errors.New("upgrade godelta")
+
+// Names returns the name of every feature set, "bit N" for those this
+// version doesn't know
+func (f Features) Names() []string {
+	var names []string
+	for f != 0 {
+		bit := Features(1) << bits.TrailingZeros8(uint8(f))
+		f &^= bit
+		if name, ok := featureNames[bit]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, fmt.Sprintf("bit %d", bits.TrailingZeros8(uint8(bit))))
+		}
+	}
+	return names
+}
+
+func (f Features) String() string {
+	if f == 0 {
+		return "none"
+	}
+	return strings.Join(f.Names(), ", ")
+}
+
+// checkFeatures returns an ErrUnsupportedFeature error naming the features
+// of f this version doesn't support
+func checkFeatures(f Features) error {
+	if missing := f &^ SupportedFeatures; missing != 0 {
+		return fmt.Errorf("archive requires feature %s (%w)", missing, ErrUnsupportedFeature)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+// internal/format/filetype.go
+package format
+
+// ExtType is the GDELTA02/GDELTA03 file entry extension recording the type
+// sniffed from the file's content when it was archived. Value: a MIME type
+// such as "image/png", without parameters.
+const ExtType uint16 = 5
+
+// TypeExtensions returns the extensions recording type mime (none when it
+// is unknown)
+func TypeExtensions(mime string) []Extension {
+	if mime == "" {
+		return nil
+	}
+	return []Extension{{Type: ExtType, Value: []byte(mime)}}
+}
+
+// TypeFromExtensions returns the type recorded in exts, "" if none
+func TypeFromExtensions(exts []Extension) string {
+	value, _ := FindExtension(exts, ExtType)
+	return string(value)
+}
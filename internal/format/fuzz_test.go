@@ -0,0 +1,200 @@
+// internal/format/fuzz_test.go
+package format_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/mmap"
+	"github.com/creativeyann17/go-delta/pkg/compress"
+)
+
+// seedArchives adds an archive of every GDELTA format and layout variant to
+// the corpus: packs, links, extensions, a chunk dictionary, an index
+func seedArchives(f *testing.F) {
+	input := f.TempDir()
+	for name, data := range map[string]string{
+		"a.txt":       "hello world\n",
+		"copy.txt":    "hello world\n",
+		"sub/b.txt":   strings.Repeat("some text ", 300),
+		"sub/c.json":  `{"key": "value"}`,
+		"sub/empty":   "",
+		"sub/big.bin": strings.Repeat("0123456789abcdef", 600),
+	} {
+		path := filepath.Join(input, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			f.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			f.Fatal(err)
+		}
+	}
+
+	for _, mode := range []func(*compress.Options){
+		func(o *compress.Options) {},
+		func(o *compress.Options) { o.PackSmallFiles = 1 << 10; o.DedupFiles = true },
+		func(o *compress.Options) { o.ChunkSize = 4 << 10; o.SniffTypes = true },
+		func(o *compress.Options) { o.ChunkSize = 4 << 10; o.Codec = "lz4" },
+		func(o *compress.Options) { o.UseDictionary = true; o.DedupFiles = true },
+	} {
+		opts := &compress.Options{
+			InputPath:  input,
+			OutputPath: filepath.Join(f.TempDir(), "seed.gdelta"),
+			MaxThreads: 1,
+			Quiet:      true,
+		}
+		mode(opts)
+		if _, err := compress.Compress(opts, nil); err != nil {
+			f.Fatal(err)
+		}
+		data, err := os.ReadFile(opts.OutputPath)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte(format.ArchiveMagic))
+	f.Add([]byte(format.ArchiveMagic02 + "\xff\xff\xff\xff\xff\xff\xff\xff"))
+	f.Add([]byte(format.ArchiveMagic03 + "\x01\xff\xff\xff\xff"))
+}
+
+// FuzzDescribe walks malformed archives: the walk stops with an error, and
+// every field it reports lies in the archive after the previous one
+func FuzzDescribe(f *testing.F) {
+	seedArchives(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		layout, _ := format.Describe(bytes.NewReader(data), int64(len(data)), 4)
+		var end int64
+		for _, field := range layout.Fields {
+			if field.Offset < end || field.Size < 0 || field.Offset+field.Size > int64(len(data)) {
+				t.Fatalf("field %s at %d (%d bytes) out of place: previous ended at %d, archive is %d bytes",
+					field.Name, field.Offset, field.Size, end, len(data))
+			}
+			if !strings.Contains(field.Name, ".extensions[") {
+				end = field.Offset + field.Size
+			}
+		}
+	})
+}
+
+// readData reads length bytes at offset like decompress and verify do,
+// failing the test when a reader let through data past the archive
+func readData(t *testing.T, r io.ReaderAt, size int64, what string, offset, length uint64) {
+	t.Helper()
+	if length > uint64(size) || offset > uint64(size)-length {
+		t.Fatalf("%s: %d bytes at %d accepted in an archive of %d bytes", what, length, offset, size)
+	}
+	if length == 0 {
+		return
+	}
+	var buf []byte
+	if _, err := mmap.Read(r, int64(offset), int64(length), &buf); err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+}
+
+// FuzzReaders runs the readers decompress and verify use over malformed
+// archives, and reads the entry and chunk data they return: they may fail,
+// but not panic, and the data they accept lies in the archive
+func FuzzReaders(f *testing.F) {
+	seedArchives(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r := bytes.NewReader(data)
+		size := int64(len(data))
+		switch format.DetectFormat(data) {
+		case format.FormatGDelta01:
+			entries, _, err := format.ListGDelta01(r, size)
+			if err != nil {
+				return
+			}
+			files, _ := format.SplitPacks(entries)
+			packs := format.NewPackCache(files)
+			for _, e := range files {
+				if e := e.Data(); e != nil && e.Packed {
+					packs.Read(r, e)
+				}
+			}
+			for _, e := range entries {
+				readData(t, r, size, e.Path, e.DataOffset, e.CompressedSize)
+			}
+
+			// The sequential reader of decompress and verify
+			if _, err := r.Seek(0, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			reader, err := format.NewArchiveReader(r)
+			if err != nil {
+				return
+			}
+			for range reader.FileCount() {
+				e, err := reader.ReadFileEntry()
+				if err != nil {
+					return
+				}
+				readData(t, r, size, e.Path, e.DataOffset, e.CompressedSize)
+				if _, err := r.Seek(int64(e.DataOffset+e.CompressedSize), io.SeekStart); err != nil {
+					return
+				}
+			}
+		case format.FormatGDelta02:
+			header, err := format.ReadGDelta02Header(r)
+			if err != nil {
+				return
+			}
+			header.ChunkDictionary()
+			refs, _ := header.ReferenceChunks()
+			chunks, err := format.ReadChunkIndex(r, header.ChunkCount)
+			if err != nil {
+				return
+			}
+			var files []format.FileMetadata
+			for range header.FileCount {
+				m, err := format.ReadFileMetadata(r, header.Extended)
+				if err != nil {
+					return
+				}
+				format.TypeFromExtensions(m.Extensions)
+				files = append(files, m)
+			}
+			dataStart, _ := r.Seek(0, io.SeekCurrent)
+			format.IntactGDelta02Files(r, chunks, refs, files, dataStart, size)
+			for hash, c := range chunks {
+				readData(t, r, size, fmt.Sprintf("chunk %x", hash[:8]), c.Offset, c.CompressedSize)
+			}
+		case format.FormatGDelta03:
+			header, err := format.ReadGDelta03Header(r)
+			if err != nil {
+				return
+			}
+			entries, _, err := format.ScanGDelta03(r, size)
+			if err != nil {
+				return
+			}
+			for _, e := range entries {
+				readData(t, r, size, e.Path, e.DataOffset, e.CompressedSize)
+			}
+
+			// The sequential reader of decompress and verify
+			if _, err := r.Seek(header.DictOffset+int64(header.DictSize), io.SeekStart); err != nil {
+				return
+			}
+			for range header.FileCount {
+				e, err := format.ReadGDelta03FileEntry(r, header.Extended)
+				if err != nil {
+					return
+				}
+				offset, _ := r.Seek(0, io.SeekCurrent)
+				readData(t, r, size, e.Path, uint64(offset), e.CompressedSize)
+				if _, err := r.Seek(int64(e.CompressedSize), io.SeekCurrent); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
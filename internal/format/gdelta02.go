@@ -11,37 +11,227 @@ import (
 
 const (
 	// GDELTA02 with chunking and deduplication
-	ArchiveMagic02 = "GDELTA02"
+	ArchiveMagic02  = "GDELTA02"
+	ArchiveFooter02 = "ENDGDLT2"
 )
 
 // FileMetadata represents a file with its chunk references
 type FileMetadata struct {
 	RelPath     string
 	OrigSize    uint64
-	ChunkHashes [][32]byte // Ordered list of chunk hashes
+	ChunkHashes [][32]byte  // Ordered list of chunk hashes
+	Extensions  []Extension // Optional fields (extended archives only)
 }
 
-// WriteGDelta02Header writes the GDELTA02 archive header
-// Format: Magic(8) + ChunkSize(8) + FileCount(4) + ChunkCount(4)
-func WriteGDelta02Header(w io.Writer, chunkSize uint64, fileCount uint32, chunkCount uint32) error {
-	// Write magic
-	if _, err := w.Write([]byte(ArchiveMagic02)); err != nil {
-		return fmt.Errorf("write magic: %w", err)
+// ChunkCodec identifies the compressor used for GDELTA02 chunk data
+type ChunkCodec uint8
+
+const (
+	// CodecUnknown is reported for archives written before the codec was recorded
+	CodecUnknown ChunkCodec = 0
+
+	// CodecZstd is zstd, one frame per chunk
+	CodecZstd ChunkCodec = 1
+)
+
+// Other codecs are recorded under their Method ID (2 and up), so 0 and 1
+// keep their original meaning.
+
+// ChunkCodecFor returns the header value for chunks compressed with m
+func ChunkCodecFor(m Method) ChunkCodec {
+	if m == MethodZstd {
+		return CodecZstd
 	}
+	return ChunkCodec(m)
+}
 
-	// Write chunk size
-	if err := binary.Write(w, binary.LittleEndian, chunkSize); err != nil {
-		return fmt.Errorf("write chunk size: %w", err)
+// Method returns the codec registry ID of the chunk codec. Archives written
+// before the codec was recorded used zstd.
+func (c ChunkCodec) Method() Method {
+	if c == CodecUnknown || c == CodecZstd {
+		return MethodZstd
 	}
+	return Method(c)
+}
+
+// String returns the codec name
+func (c ChunkCodec) String() string {
+	switch c {
+	case CodecUnknown:
+		return "unknown"
+	case CodecZstd:
+		return "zstd"
+	default:
+		return c.Method().String()
+	}
+}
+
+// GDelta02Header is the fixed GDELTA02 header
+type GDelta02Header struct {
+	ChunkSize  uint64     // Configured average chunk size
+	Codec      ChunkCodec // Chunk data codec (CodecUnknown for older archives)
+	Level      int        // Codec level (0 = not recorded)
+	FileCount  uint32
+	ChunkCount uint32
+
+	// Bounds are the FastCDC settings the chunks were cut with. Bounds
+	// other than DefaultChunkBounds(ChunkSize) are recorded in an
+	// ExtChunkBounds header extension, which needs an extended archive.
+	Bounds ChunkBounds
+
+	// Extended archives have an extension area after this header and in
+	// every file metadata entry (FlagExtensions)
+	Extended   bool
+	Extensions []Extension
+
+	// Features the archive requires from its reader. The writer adds
+	// FeatureChunkDict for an ExtChunkDict extension, FeatureReferenceChunks
+	// for an ExtReferenceChunks one.
+	Features Features
+}
+
+// ExtChunkBounds is the GDELTA02 header extension recording FastCDC
+// bounds other than the defaults. Value: MinSize(4) + MaxSize(4) +
+// Normalization(1) [+ Mode(1), 1 = fixed-size blocks; absent = FastCDC].
+const ExtChunkBounds uint16 = 2
+
+// ExtChunkDict is the GDELTA02 header extension holding the zstd dictionary
+// every chunk was compressed with. Value: the dictionary, as built by
+// dict.BuildZstdDict.
+const ExtChunkDict uint16 = 6
+
+// ChunkDictionary returns the dictionary the chunks of h were compressed
+// with, nil when there is none
+func (h GDelta02Header) ChunkDictionary() []byte {
+	dict, _ := FindExtension(h.Extensions, ExtChunkDict)
+	return dict
+}
+
+// ChunkBounds are the FastCDC settings around the average chunk size
+type ChunkBounds struct {
+	MinSize       uint64 // Smallest chunk (but the last of a file)
+	MaxSize       uint64 // Largest chunk
+	Normalization int    // Normalization level, 0-3 (0 = off)
+	Fixed         bool   // Fixed-size blocks of ChunkSize instead of FastCDC
+}
+
+// FixedChunkBounds returns the bounds of fixed-size blocks of blockSize
+func FixedChunkBounds(blockSize uint64) ChunkBounds {
+	return ChunkBounds{MinSize: blockSize, MaxSize: blockSize, Fixed: true}
+}
+
+// DefaultChunkBounds returns the bounds used for chunkSize unless others
+// are recorded: chunkSize/4 to chunkSize*4, normalization 2
+func DefaultChunkBounds(chunkSize uint64) ChunkBounds {
+	return ChunkBounds{MinSize: chunkSize / 4, MaxSize: chunkSize * 4, Normalization: 2}
+}
+
+// chunkBoundsExtension encodes b as an ExtChunkBounds extension
+func chunkBoundsExtension(b ChunkBounds) (Extension, error) {
+	if b.MinSize > chunkSizeMask || b.MaxSize > chunkSizeMask || b.Normalization < 0 || b.Normalization > 255 {
+		return Extension{}, fmt.Errorf("chunk bounds out of range for archive format: %+v", b)
+	}
+	value := binary.LittleEndian.AppendUint32(nil, uint32(b.MinSize))
+	value = binary.LittleEndian.AppendUint32(value, uint32(b.MaxSize))
+	value = append(value, byte(b.Normalization))
+	if b.Fixed {
+		value = append(value, 1)
+	}
+	return Extension{Type: ExtChunkBounds, Value: value}, nil
+}
+
+// chunkBoundsFromExtensions returns the bounds recorded in exts, or
+// DefaultChunkBounds(chunkSize) when there are none
+func chunkBoundsFromExtensions(exts []Extension, chunkSize uint64) ChunkBounds {
+	for _, ext := range exts {
+		if ext.Type == ExtChunkBounds && (len(ext.Value) == 9 || len(ext.Value) == 10) {
+			return ChunkBounds{
+				MinSize:       uint64(binary.LittleEndian.Uint32(ext.Value)),
+				MaxSize:       uint64(binary.LittleEndian.Uint32(ext.Value[4:])),
+				Normalization: int(ext.Value[8]),
+				Fixed:         len(ext.Value) == 10 && ext.Value[9] == 1,
+			}
+		}
+	}
+	return DefaultChunkBounds(chunkSize)
+}
+
+// The 8-byte chunk size field also carries the codec and level, so the header
+// keeps its size and older readers (which only use the low bits, if at all)
+// still open new archives:
+//
+//	bits 0-31:  chunk size
+//	bits 32-39: level
+//	bits 40-47: codec
+//	bits 48-55: flags (FlagExtensions)
+//	bits 56-63: required Features
+const (
+	chunkSizeMask = 1<<32 - 1
+	levelShift    = 32
+	codecShift    = 40
+	flagsShift    = 48
+	featuresShift = 56
+)
+
+// gdelta02FixedHeaderSize is the header size without extensions
+const gdelta02FixedHeaderSize = 24
 
-	// Write file count
-	if err := binary.Write(w, binary.LittleEndian, fileCount); err != nil {
-		return fmt.Errorf("write file count: %w", err)
+// WriteGDelta02Header writes the GDELTA02 archive header
+// Format: Magic(8) + ChunkSize/Level/Codec/Flags(8) + FileCount(4) + ChunkCount(4)
+// [+ extension area when Extended]
+func WriteGDelta02Header(w io.Writer, h GDelta02Header) error {
+	if h.ChunkSize > chunkSizeMask {
+		return fmt.Errorf("chunk size too large for archive format: %d", h.ChunkSize)
+	}
+	if h.Level < 0 || h.Level > 255 {
+		return fmt.Errorf("invalid compression level for archive format: %d", h.Level)
+	}
+	// Bounds replace any ExtChunkBounds in Extensions (from a header read
+	// back)
+	var exts []Extension
+	if h.Bounds != (ChunkBounds{}) && h.Bounds != DefaultChunkBounds(h.ChunkSize) {
+		ext, err := chunkBoundsExtension(h.Bounds)
+		if err != nil {
+			return err
+		}
+		exts = append(exts, ext)
+	}
+	for _, ext := range h.Extensions {
+		if ext.Type != ExtChunkBounds {
+			exts = append(exts, ext)
+		}
+	}
+	if len(exts) > 0 && !h.Extended {
+		return fmt.Errorf("header extensions require an extended archive")
+	}
+	var flags uint8
+	if h.Extended {
+		flags |= FlagExtensions
+	}
+	features := h.Features
+	if _, ok := FindExtension(exts, ExtChunkDict); ok {
+		features |= FeatureChunkDict
+	}
+	if _, ok := FindExtension(exts, ExtReferenceChunks); ok {
+		features |= FeatureReferenceChunks
+	}
+	params := h.ChunkSize | uint64(h.Level)<<levelShift | uint64(h.Codec)<<codecShift |
+		uint64(flags)<<flagsShift | uint64(features)<<featuresShift
+
+	buf := make([]byte, 0, gdelta02FixedHeaderSize)
+	buf = append(buf, ArchiveMagic02...)
+	buf = binary.LittleEndian.AppendUint64(buf, params)
+	buf = binary.LittleEndian.AppendUint32(buf, h.FileCount)
+	buf = binary.LittleEndian.AppendUint32(buf, h.ChunkCount)
+	if h.Extended {
+		var err error
+		if buf, err = AppendExtensions(buf, exts); err != nil {
+			return err
+		}
 	}
 
-	// Write chunk count
-	if err := binary.Write(w, binary.LittleEndian, chunkCount); err != nil {
-		return fmt.Errorf("write chunk count: %w", err)
+	if _, err := w.Write(buf); err != nil {
+		return fmt.Errorf("write header: %w", err)
 	}
 
 	return nil
@@ -66,15 +256,9 @@ func WriteChunkIndex(w io.Writer, chunks map[[32]byte]ChunkInfo) error {
 		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
 	})
 
-	buf := make([]byte, chunkIndexEntrySize*len(hashes))
-	pos := 0
+	buf := make([]byte, 0, chunkIndexEntrySize*len(hashes))
 	for _, hash := range hashes {
-		chunk := chunks[hash]
-		copy(buf[pos:], chunk.Hash[:])
-		binary.LittleEndian.PutUint64(buf[pos+32:], chunk.Offset)
-		binary.LittleEndian.PutUint64(buf[pos+40:], chunk.CompressedSize)
-		binary.LittleEndian.PutUint64(buf[pos+48:], chunk.OriginalSize)
-		pos += chunkIndexEntrySize
+		buf = AppendChunkIndexEntry(buf, chunks[hash])
 	}
 
 	if _, err := w.Write(buf); err != nil {
@@ -84,12 +268,26 @@ func WriteChunkIndex(w io.Writer, chunks map[[32]byte]ChunkInfo) error {
 	return nil
 }
 
+// AppendChunkIndexEntry appends one chunk index entry to buf. Writers whose
+// index doesn't fit in memory stream the entries with it, in ascending hash
+// order, instead of calling WriteChunkIndex.
+func AppendChunkIndexEntry(buf []byte, chunk ChunkInfo) []byte {
+	buf = append(buf, chunk.Hash[:]...)
+	buf = binary.LittleEndian.AppendUint64(buf, chunk.Offset)
+	buf = binary.LittleEndian.AppendUint64(buf, ChunkSizeField(chunk))
+	return binary.LittleEndian.AppendUint64(buf, chunk.OriginalSize)
+}
+
 // WriteFileMetadata writes a single file metadata entry as one buffered write
 // Format: PathLen(2) + Path + OrigSize(8) + ChunkCount(4) + Hashes(32*count)
-func WriteFileMetadata(w io.Writer, metadata FileMetadata) error {
+// [+ extension area when extended]
+func WriteFileMetadata(w io.Writer, metadata FileMetadata, extended bool) error {
 	if len(metadata.RelPath) > 65535 {
 		return fmt.Errorf("path too long for archive format (%d bytes, max 65535): %s", len(metadata.RelPath), metadata.RelPath)
 	}
+	if len(metadata.Extensions) > 0 && !extended {
+		return fmt.Errorf("%s: extensions require an extended archive", metadata.RelPath)
+	}
 
 	pathLen := len(metadata.RelPath)
 	buf := make([]byte, 0, 2+pathLen+8+4+32*len(metadata.ChunkHashes))
@@ -100,6 +298,12 @@ func WriteFileMetadata(w io.Writer, metadata FileMetadata) error {
 	for _, hash := range metadata.ChunkHashes {
 		buf = append(buf, hash[:]...)
 	}
+	if extended {
+		var err error
+		if buf, err = AppendExtensions(buf, metadata.Extensions); err != nil {
+			return fmt.Errorf("%s: %w", metadata.RelPath, err)
+		}
+	}
 
 	if _, err := w.Write(buf); err != nil {
 		return fmt.Errorf("write file metadata: %w", err)
@@ -114,64 +318,109 @@ type ChunkInfo struct {
 	Offset         uint64
 	CompressedSize uint64
 	OriginalSize   uint64
+
+	// Stored chunks are kept uncompressed, compression having made them
+	// no smaller: their data is the chunk itself
+	Stored bool
+}
+
+// ChunkStoredFlag marks a stored chunk in the compressed size field of its
+// chunk index entry. Older readers take it for a size past the archive and
+// report the chunk damaged instead of decoding raw data.
+const ChunkStoredFlag uint64 = 1 << 63
+
+// ChunkSizeField returns the compressed size field of the index entry of
+// chunk, with ChunkStoredFlag for a stored chunk
+func ChunkSizeField(chunk ChunkInfo) uint64 {
+	if chunk.Stored {
+		return chunk.CompressedSize | ChunkStoredFlag
+	}
+	return chunk.CompressedSize
+}
+
+// ParseChunkSizeField splits the compressed size field of a chunk index
+// entry into the size and whether the chunk is stored
+func ParseChunkSizeField(field uint64) (size uint64, stored bool) {
+	return field &^ ChunkStoredFlag, field&ChunkStoredFlag != 0
 }
 
 // WriteArchiveFooter02 writes the GDELTA02 footer
 func WriteArchiveFooter02(w io.Writer) error {
-	footer := []byte("ENDGDLT2")
-	if _, err := w.Write(footer); err != nil {
+	if _, err := w.Write([]byte(ArchiveFooter02)); err != nil {
 		return fmt.Errorf("write footer: %w", err)
 	}
 	return nil
 }
 
 // ReadGDelta02Header reads and validates the GDELTA02 header
-// Returns chunkSize, fileCount, chunkCount
-func ReadGDelta02Header(r io.Reader) (chunkSize uint64, fileCount uint32, chunkCount uint32, err error) {
-	// Read and verify magic
-	magic := make([]byte, 8)
-	if _, err := io.ReadFull(r, magic); err != nil {
-		return 0, 0, 0, fmt.Errorf("read magic: %w", err)
+func ReadGDelta02Header(r io.Reader) (GDelta02Header, error) {
+	var h GDelta02Header
+
+	buf := make([]byte, gdelta02FixedHeaderSize)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return h, fmt.Errorf("read header: %w", err)
 	}
-	if string(magic) != ArchiveMagic02 {
-		return 0, 0, 0, fmt.Errorf("invalid magic: got %q, want %q", magic, ArchiveMagic02)
+	if string(buf[:8]) != ArchiveMagic02 {
+		return h, fmt.Errorf("invalid magic: got %q, want %q", buf[:8], ArchiveMagic02)
 	}
 
-	// Read chunk size
-	if err := binary.Read(r, binary.LittleEndian, &chunkSize); err != nil {
-		return 0, 0, 0, fmt.Errorf("read chunk size: %w", err)
+	params := binary.LittleEndian.Uint64(buf[8:])
+	h.ChunkSize = params & chunkSizeMask
+	h.Level = int(uint8(params >> levelShift))
+	h.Codec = ChunkCodec(uint8(params >> codecShift))
+	h.Extended = uint8(params>>flagsShift)&FlagExtensions != 0
+	h.Features = Features(params >> featuresShift)
+	if err := checkFeatures(h.Features); err != nil {
+		return h, err
 	}
-
-	// Read file count
-	if err := binary.Read(r, binary.LittleEndian, &fileCount); err != nil {
-		return 0, 0, 0, fmt.Errorf("read file count: %w", err)
+	h.FileCount = binary.LittleEndian.Uint32(buf[16:])
+	h.ChunkCount = binary.LittleEndian.Uint32(buf[20:])
+	l := CurrentLimits()
+	if err := checkLimit("files", h.FileCount, l.MaxFiles); err != nil {
+		return h, err
+	}
+	if err := checkLimit("chunks", h.ChunkCount, l.MaxChunks); err != nil {
+		return h, err
 	}
 
-	// Read chunk count
-	if err := binary.Read(r, binary.LittleEndian, &chunkCount); err != nil {
-		return 0, 0, 0, fmt.Errorf("read chunk count: %w", err)
+	if h.Extended {
+		exts, err := ReadExtensions(r)
+		if err != nil {
+			return h, fmt.Errorf("read header extensions: %w", err)
+		}
+		h.Extensions = exts
 	}
+	h.Bounds = chunkBoundsFromExtensions(h.Extensions, h.ChunkSize)
 
-	return chunkSize, fileCount, chunkCount, nil
+	return h, nil
 }
 
-// ReadChunkIndex reads the chunk index section in one bulk read
+// ReadChunkIndex reads the chunk index section in one bulk read. When r
+// can seek, a chunk whose data can't fit in the archive is refused with
+// ErrMalformed: offsets are relative to the chunk data, which starts
+// further in.
 func ReadChunkIndex(r io.Reader, chunkCount uint32) (map[[32]byte]ChunkInfo, error) {
-	chunks := make(map[[32]byte]ChunkInfo, chunkCount)
-
-	buf := make([]byte, chunkIndexEntrySize*int(chunkCount))
-	if _, err := io.ReadFull(r, buf); err != nil {
+	if err := checkLimit("chunks", chunkCount, CurrentLimits().MaxChunks); err != nil {
+		return nil, err
+	}
+	size := readerSize(r)
+	buf, err := ReadBounded(r, chunkIndexEntrySize*int64(chunkCount))
+	if err != nil {
 		return nil, fmt.Errorf("read chunk index: %w", err)
 	}
+	chunks := make(map[[32]byte]ChunkInfo, CapHint(chunkCount))
 
 	pos := 0
 	for i := uint32(0); i < chunkCount; i++ {
 		var chunk ChunkInfo
 		copy(chunk.Hash[:], buf[pos:])
 		chunk.Offset = binary.LittleEndian.Uint64(buf[pos+32:])
-		chunk.CompressedSize = binary.LittleEndian.Uint64(buf[pos+40:])
+		chunk.CompressedSize, chunk.Stored = ParseChunkSizeField(binary.LittleEndian.Uint64(buf[pos+40:]))
 		chunk.OriginalSize = binary.LittleEndian.Uint64(buf[pos+48:])
 		pos += chunkIndexEntrySize
+		if err := checkSpan(fmt.Sprintf("chunk %x", chunk.Hash[:8]), chunk.Offset, chunk.CompressedSize, size); err != nil {
+			return nil, err
+		}
 
 		chunks[chunk.Hash] = chunk
 	}
@@ -180,8 +429,8 @@ func ReadChunkIndex(r io.Reader, chunkCount uint32) (map[[32]byte]ChunkInfo, err
 }
 
 // ReadFileMetadata reads a single file metadata entry (3 bulk reads instead of
-// one read per field/hash)
-func ReadFileMetadata(r io.Reader) (FileMetadata, error) {
+// one read per field/hash). extended is GDelta02Header.Extended.
+func ReadFileMetadata(r io.Reader, extended bool) (FileMetadata, error) {
 	var metadata FileMetadata
 
 	// Read path length
@@ -201,8 +450,11 @@ func ReadFileMetadata(r io.Reader) (FileMetadata, error) {
 	chunkCount := binary.LittleEndian.Uint32(fixedBuf[pathLen+8:])
 
 	// Read all chunk hashes in one call
-	hashBuf := make([]byte, 32*int(chunkCount))
-	if _, err := io.ReadFull(r, hashBuf); err != nil {
+	if err := checkLimit("chunks of "+metadata.RelPath, chunkCount, CurrentLimits().MaxFileChunks); err != nil {
+		return metadata, err
+	}
+	hashBuf, err := ReadBounded(r, 32*int64(chunkCount))
+	if err != nil {
 		return metadata, fmt.Errorf("read chunk hashes: %w", err)
 	}
 	metadata.ChunkHashes = make([][32]byte, chunkCount)
@@ -210,5 +462,13 @@ func ReadFileMetadata(r io.Reader) (FileMetadata, error) {
 		copy(metadata.ChunkHashes[i][:], hashBuf[i*32:])
 	}
 
+	if extended {
+		exts, err := ReadExtensions(r)
+		if err != nil {
+			return metadata, err
+		}
+		metadata.Extensions = exts
+	}
+
 	return metadata, nil
 }
@@ -19,100 +19,165 @@ const (
 //   Version (1):     0x01
 //   Dict Size (4):   uint32
 //   File Count (4):  uint32
-//   Reserved (4):    0x00000000
+//   Flags (1):       FlagExtensions
+//   Features (1):    required Features
+//   Reserved (2):    0x0000
+//   [Extension area when FlagExtensions is set]
 
 // GDELTA03 File Entry Structure:
 //   Path Length (2):    uint16
 //   Path (variable):    string
 //   Original Size (8):  uint64
 //   Compressed Size (8): uint64
+//   [Extension area when FlagExtensions is set; ExtMethod for stored data,
+//    ExtLink for duplicates, ExtType for sniffed types]
 //   [Compressed data follows immediately]
 
-// WriteGDelta03Header writes the GDELTA03 archive header
-func WriteGDelta03Header(w io.Writer, dictSize uint32, fileCount uint32) error {
-	// Write magic
-	if _, err := w.Write([]byte(ArchiveMagic03)); err != nil {
-		return fmt.Errorf("write magic: %w", err)
-	}
+// gdelta03FixedHeaderSize is the header size without extensions
+const gdelta03FixedHeaderSize = 21
 
-	// Write version
-	if err := binary.Write(w, binary.LittleEndian, byte(GDELTA03Version)); err != nil {
-		return fmt.Errorf("write version: %w", err)
-	}
+// GDelta03Header is the GDELTA03 header
+type GDelta03Header struct {
+	Version   byte
+	DictSize  uint32
+	FileCount uint32
+
+	// Extended archives have an extension area after this header and in
+	// every file entry (FlagExtensions)
+	Extended   bool
+	Extensions []Extension
 
-	// Write dictionary size
-	if err := binary.Write(w, binary.LittleEndian, dictSize); err != nil {
-		return fmt.Errorf("write dict size: %w", err)
+	// Features the archive requires from its reader
+	Features Features
+
+	// DictOffset is where the dictionary starts, right after the header and
+	// its extensions (set by the reader)
+	DictOffset int64
+}
+
+// WriteGDelta03Header writes the GDELTA03 archive header in one write
+func WriteGDelta03Header(w io.Writer, h GDelta03Header) error {
+	if len(h.Extensions) > 0 && !h.Extended {
+		return fmt.Errorf("header extensions require an extended archive")
+	}
+	var flags uint8
+	if h.Extended {
+		flags |= FlagExtensions
 	}
 
-	// Write file count
-	if err := binary.Write(w, binary.LittleEndian, fileCount); err != nil {
-		return fmt.Errorf("write file count: %w", err)
+	buf := make([]byte, 0, gdelta03FixedHeaderSize)
+	buf = append(buf, ArchiveMagic03...)
+	buf = append(buf, GDELTA03Version)
+	buf = binary.LittleEndian.AppendUint32(buf, h.DictSize)
+	buf = binary.LittleEndian.AppendUint32(buf, h.FileCount)
+	buf = append(buf, flags, byte(h.Features), 0, 0)
+	if h.Extended {
+		var err error
+		if buf, err = AppendExtensions(buf, h.Extensions); err != nil {
+			return err
+		}
 	}
 
-	// Write reserved bytes
-	reserved := uint32(0)
-	if err := binary.Write(w, binary.LittleEndian, reserved); err != nil {
-		return fmt.Errorf("write reserved: %w", err)
+	if _, err := w.Write(buf); err != nil {
+		return fmt.Errorf("write header: %w", err)
 	}
 
 	return nil
 }
 
 // ReadGDelta03Header reads the GDELTA03 header including magic
-// Returns version, dictionary size, and file count
-func ReadGDelta03Header(r io.Reader) (version byte, dictSize uint32, fileCount uint32, err error) {
+func ReadGDelta03Header(r io.Reader) (GDelta03Header, error) {
 	// Read and verify magic
 	magic := make([]byte, 8)
 	if _, err := io.ReadFull(r, magic); err != nil {
-		return 0, 0, 0, fmt.Errorf("read magic: %w", err)
+		return GDelta03Header{}, fmt.Errorf("read magic: %w", err)
 	}
 	if string(magic) != ArchiveMagic03 {
-		return 0, 0, 0, fmt.Errorf("invalid magic: got %q, want %q", magic, ArchiveMagic03)
+		return GDelta03Header{}, fmt.Errorf("invalid magic: got %q, want %q", magic, ArchiveMagic03)
 	}
 
 	return ReadGDelta03HeaderAfterMagic(r)
 }
 
 // ReadGDelta03HeaderAfterMagic reads the GDELTA03 header after the magic has been consumed
-// Returns version, dictionary size, and file count
-func ReadGDelta03HeaderAfterMagic(r io.Reader) (version byte, dictSize uint32, fileCount uint32, err error) {
-	// Read version
-	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
-		return 0, 0, 0, fmt.Errorf("read version: %w", err)
-	}
+func ReadGDelta03HeaderAfterMagic(r io.Reader) (GDelta03Header, error) {
+	var h GDelta03Header
 
-	// Read dictionary size
-	if err := binary.Read(r, binary.LittleEndian, &dictSize); err != nil {
-		return 0, 0, 0, fmt.Errorf("read dict size: %w", err)
+	buf := make([]byte, gdelta03FixedHeaderSize-8)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return h, fmt.Errorf("read header: %w", err)
 	}
-
-	// Read file count
-	if err := binary.Read(r, binary.LittleEndian, &fileCount); err != nil {
-		return 0, 0, 0, fmt.Errorf("read file count: %w", err)
+	h.Version = buf[0]
+	h.DictSize = binary.LittleEndian.Uint32(buf[1:])
+	h.FileCount = binary.LittleEndian.Uint32(buf[5:])
+	h.Features = Features(buf[10])
+	if err := checkFeatures(h.Features); err != nil {
+		return h, err
+	}
+	l := CurrentLimits()
+	if err := checkLimit("files", h.FileCount, l.MaxFiles); err != nil {
+		return h, err
 	}
+	if err := checkLimit("dictionary bytes", h.DictSize, l.MaxDictSize); err != nil {
+		return h, err
+	}
+	h.Extended = buf[9]&FlagExtensions != 0
+	h.DictOffset = gdelta03FixedHeaderSize
 
-	// Read and discard reserved bytes
-	var reserved uint32
-	if err := binary.Read(r, binary.LittleEndian, &reserved); err != nil {
-		return 0, 0, 0, fmt.Errorf("read reserved: %w", err)
+	if h.Extended {
+		exts, err := ReadExtensions(r)
+		if err != nil {
+			return h, fmt.Errorf("read header extensions: %w", err)
+		}
+		h.Extensions = exts
+		h.DictOffset += int64(extensionAreaSize(exts))
 	}
 
-	return version, dictSize, fileCount, nil
+	return h, nil
+}
+
+// GDelta03FileEntry represents a file entry in GDELTA03 format
+type GDelta03FileEntry struct {
+	Path           string
+	OriginalSize   uint64
+	CompressedSize uint64
+	Extensions     []Extension // Optional fields (extended archives only)
+
+	// Method of the entry's data; anything but zstd is recorded as an
+	// ExtMethod extension and needs an extended archive
+	Method Method
+
+	// Link marks a duplicate of entry LinkIndex, with no data; it is
+	// recorded as an ExtLink extension and needs an extended archive
+	Link      bool
+	LinkIndex uint32
 }
 
 // WriteGDelta03FileEntry writes a file entry for GDELTA03 as one write
-// Format: PathLen(2) + Path + OrigSize(8) + CompSize(8)
-func WriteGDelta03FileEntry(w io.Writer, relPath string, origSize, compSize uint64) error {
-	if len(relPath) > 65535 {
-		return fmt.Errorf("path too long for archive format (%d bytes, max 65535): %s", len(relPath), relPath)
+// Format: PathLen(2) + Path + OrigSize(8) + CompSize(8) [+ extension area]
+func WriteGDelta03FileEntry(w io.Writer, entry GDelta03FileEntry, extended bool) error {
+	if len(entry.Path) > 65535 {
+		return fmt.Errorf("path too long for archive format (%d bytes, max 65535): %s", len(entry.Path), entry.Path)
+	}
+	exts := append(methodExtensions(entry.Method), entry.Extensions...)
+	if entry.Link {
+		exts = append(exts, linkExtensions(entry.LinkIndex)...)
+	}
+	if len(exts) > 0 && !extended {
+		return fmt.Errorf("%s: extensions require an extended archive", entry.Path)
 	}
 
-	buf := make([]byte, 0, 2+len(relPath)+16)
-	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(relPath)))
-	buf = append(buf, relPath...)
-	buf = binary.LittleEndian.AppendUint64(buf, origSize)
-	buf = binary.LittleEndian.AppendUint64(buf, compSize)
+	buf := make([]byte, 0, 2+len(entry.Path)+16)
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(entry.Path)))
+	buf = append(buf, entry.Path...)
+	buf = binary.LittleEndian.AppendUint64(buf, entry.OriginalSize)
+	buf = binary.LittleEndian.AppendUint64(buf, entry.CompressedSize)
+	if extended {
+		var err error
+		if buf, err = AppendExtensions(buf, exts); err != nil {
+			return fmt.Errorf("%s: %w", entry.Path, err)
+		}
+	}
 
 	if _, err := w.Write(buf); err != nil {
 		return fmt.Errorf("write file entry: %w", err)
@@ -121,15 +186,11 @@ func WriteGDelta03FileEntry(w io.Writer, relPath string, origSize, compSize uint
 	return nil
 }
 
-// GDelta03FileEntry represents a file entry in GDELTA03 format
-type GDelta03FileEntry struct {
-	Path           string
-	OriginalSize   uint64
-	CompressedSize uint64
-}
-
-// ReadGDelta03FileEntry reads a file entry from GDELTA03 archive (2 bulk reads)
-func ReadGDelta03FileEntry(r io.Reader) (*GDelta03FileEntry, error) {
+// ReadGDelta03FileEntry reads a file entry from GDELTA03 archive (2 bulk reads,
+// plus the extension area when extended is GDelta03Header.Extended). When r
+// can seek, an entry whose data, which follows it, runs past the end of the
+// archive is refused with ErrMalformed.
+func ReadGDelta03FileEntry(r io.Reader, extended bool) (*GDelta03FileEntry, error) {
 	entry := &GDelta03FileEntry{}
 
 	// Read path length
@@ -148,6 +209,23 @@ func ReadGDelta03FileEntry(r io.Reader) (*GDelta03FileEntry, error) {
 	entry.OriginalSize = binary.LittleEndian.Uint64(buf[pathLen:])
 	entry.CompressedSize = binary.LittleEndian.Uint64(buf[pathLen+8:])
 
+	if extended {
+		exts, err := ReadExtensions(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		entry.Extensions = exts
+		entry.Method = methodFromExtensions(exts)
+		entry.LinkIndex, entry.Link = linkFromExtensions(exts)
+	}
+
+	if s, ok := r.(io.Seeker); ok {
+		if pos, err := s.Seek(0, io.SeekCurrent); err == nil {
+			if err := checkSpan(entry.Path+" data", uint64(pos), entry.CompressedSize, readerSize(r)); err != nil {
+				return nil, err
+			}
+		}
+	}
 	return entry, nil
 }
 
@@ -0,0 +1,155 @@
+// internal/format/index01.go
+package format
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// A GDELTA01 archive may end with an index of its entries after the footer,
+// so readers can seek straight to any entry instead of walking every entry
+// header (and skipping its data) from the start. Readers that don't know
+// the index stop at the footer and never see it.
+//
+// Index layout (little-endian):
+//
+//	repeated FileCount times, in archive order:
+//	  PathLen (2) + Path
+//	  OrigSize (8)
+//	  CompSize (8)
+//	  DataOffset (8, Method in the top byte, as in the entry header)
+//	Trailer (24):
+//	  IndexOffset (8):  offset of the first index entry
+//	  FileCount (4)
+//	  CRC32 (4):        IEEE checksum of the index entries
+//	  Magic (8):        "GDLTIDX1"
+const (
+	IndexMagic01       = "GDLTIDX1"
+	indexTrailerSize01 = 24
+)
+
+// ErrNoIndex is returned by ReadGDelta01Index for archives without an index
+// (written before it existed, or damaged at the tail)
+var ErrNoIndex = errors.New("archive has no index")
+
+// WriteGDelta01Index writes the index of entries; w must be positioned
+// right after the footer, at offset indexOffset
+func WriteGDelta01Index(w io.Writer, indexOffset int64, entries []*FileEntry) error {
+	var buf []byte
+	for _, e := range entries {
+		if len(e.Path) > 65535 {
+			return fmt.Errorf("path too long for archive format: %s", e.Path)
+		}
+		buf = AppendFileEntry(buf, e)
+	}
+	sum := crc32.ChecksumIEEE(buf)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(indexOffset))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(entries)))
+	buf = binary.LittleEndian.AppendUint32(buf, sum)
+	buf = append(buf, IndexMagic01...)
+
+	if _, err := w.Write(buf); err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+	return nil
+}
+
+// ReadGDelta01Index reads the entries from the index at the end of an
+// archive of the given size. The index is only trusted whole: a bad
+// checksum or an entry whose data lies outside the archive body is
+// reported as an error, and callers fall back to walking the entries.
+func ReadGDelta01Index(r io.ReaderAt, size int64) ([]*FileEntry, error) {
+	if size < MagicSize+4+indexTrailerSize01 {
+		return nil, ErrNoIndex
+	}
+	var trailer [indexTrailerSize01]byte
+	if _, err := r.ReadAt(trailer[:], size-indexTrailerSize01); err != nil {
+		return nil, fmt.Errorf("read index trailer: %w", err)
+	}
+	if string(trailer[16:]) != IndexMagic01 {
+		return nil, ErrNoIndex
+	}
+
+	indexOffset := int64(binary.LittleEndian.Uint64(trailer[0:]))
+	count := int(binary.LittleEndian.Uint32(trailer[8:]))
+	sum := binary.LittleEndian.Uint32(trailer[12:])
+	// The index follows the footer, and each entry takes at least 26 bytes
+	indexEnd := size - indexTrailerSize01
+	bodyEnd := indexOffset - int64(len(ArchiveFooter))
+	if bodyEnd < MagicSize+4 || indexOffset > indexEnd || int64(count) > (indexEnd-indexOffset)/26 {
+		return nil, fmt.Errorf("corrupt index trailer")
+	}
+	footer := make([]byte, len(ArchiveFooter))
+	if _, err := r.ReadAt(footer, bodyEnd); err != nil || string(footer) != ArchiveFooter {
+		return nil, fmt.Errorf("no footer before the index")
+	}
+
+	buf := make([]byte, indexEnd-indexOffset)
+	if _, err := r.ReadAt(buf, indexOffset); err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	if crc32.ChecksumIEEE(buf) != sum {
+		return nil, fmt.Errorf("index checksum mismatch")
+	}
+
+	entries := make([]*FileEntry, 0, count)
+	pos := 0
+	for i := range count {
+		if pos+2 > len(buf) {
+			return nil, fmt.Errorf("index entry %d truncated", i)
+		}
+		pathLen := int(binary.LittleEndian.Uint16(buf[pos:]))
+		pos += 2
+		if pos+pathLen+24 > len(buf) {
+			return nil, fmt.Errorf("index entry %d truncated", i)
+		}
+		path := string(buf[pos : pos+pathLen])
+		pos += pathLen
+		e := &FileEntry{Path: path, OriginalSize: binary.LittleEndian.Uint64(buf[pos:])}
+		setEntryFields(e, binary.LittleEndian.Uint64(buf[pos+8:]), binary.LittleEndian.Uint64(buf[pos+16:]))
+		pos += 24
+		if e.DataOffset < MagicSize+4 || e.CompressedSize > uint64(bodyEnd) || e.DataOffset > uint64(bodyEnd)-e.CompressedSize {
+			return nil, fmt.Errorf("index entry %d (%s) points outside the archive", i, path)
+		}
+		entries = append(entries, e)
+	}
+	if pos != len(buf) {
+		return nil, fmt.Errorf("index has %d trailing bytes", len(buf)-pos)
+	}
+	linkEntries(entries)
+	return entries, nil
+}
+
+// ReadSeekerAt reads an archive both sequentially and at offsets (an
+// *os.File)
+type ReadSeekerAt interface {
+	io.ReadSeeker
+	io.ReaderAt
+}
+
+// ListGDelta01 returns the entries of a GDELTA01 archive of the given size
+// and how much of it is intact. A valid index sits at the very end, so an
+// archive that has one is whole and its entries come from the index in one
+// read; others are walked by ScanGDelta01.
+func ListGDelta01(r ReadSeekerAt, size int64) ([]*FileEntry, *ScanResult, error) {
+	entries, err := ReadGDelta01Index(r, size)
+	if err != nil {
+		return ScanGDelta01(r, size)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	reader, err := NewArchiveReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scan := &ScanResult{Declared: reader.FileCount(), Intact: len(entries), LogicalEnd: MagicSize + 4, FooterValid: true}
+	for _, e := range entries {
+		scan.LogicalEnd = max(scan.LogicalEnd, int64(e.DataOffset+e.CompressedSize))
+	}
+	return entries, scan, nil
+}
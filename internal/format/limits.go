@@ -0,0 +1,115 @@
+// internal/format/limits.go
+package format
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync/atomic"
+)
+
+// ErrMalformed is wrapped by the errors of readers refusing a count or
+// length past Limits, or data said to lie past the end of the archive
+var ErrMalformed = errors.New("malformed archive")
+
+// Limits are the largest counts and lengths readers accept from an
+// archive; 0 means no limit. Tables are read as their data arrives, so a
+// count the archive can't back fails at its end without a matching
+// allocation; the limits bound what an archive of matching size may ask.
+type Limits struct {
+	MaxFiles      uint32 // Files declared by a header
+	MaxChunks     uint32 // GDELTA02 chunk index entries
+	MaxFileChunks uint32 // Chunks of one GDELTA02 file
+	MaxDictSize   uint32 // Size of a GDELTA03 dictionary
+}
+
+// DefaultLimits are well above anything this version writes: 128M files,
+// 1G chunks (64TB at 64KB), 256M chunks per file, a 16MB dictionary
+var DefaultLimits = Limits{
+	MaxFiles:      1 << 27,
+	MaxChunks:     1 << 30,
+	MaxFileChunks: 1 << 28,
+	MaxDictSize:   16 << 20,
+}
+
+var limits atomic.Pointer[Limits]
+
+func init() {
+	SetLimits(DefaultLimits)
+}
+
+// SetLimits replaces the limits of every reader of the process
+func SetLimits(l Limits) {
+	limits.Store(&l)
+}
+
+// CurrentLimits returns the limits readers apply
+func CurrentLimits() Limits {
+	return *limits.Load()
+}
+
+// checkLimit returns an ErrMalformed error when n exceeds max (0 = none)
+func checkLimit(what string, n, max uint32) error {
+	if max > 0 && n > max {
+		return fmt.Errorf("%w: %d %s exceeds the limit of %d", ErrMalformed, n, what, max)
+	}
+	return nil
+}
+
+// checkSpan returns an ErrMalformed error when length bytes at offset
+// don't fit in an archive of size bytes (size < 0: unknown, not checked)
+func checkSpan(what string, offset, length uint64, size int64) error {
+	if size >= 0 && (length > uint64(size) || offset > uint64(size)-length) {
+		return fmt.Errorf("%w: %s of %d bytes at %d runs past the end of the archive (%d bytes)", ErrMalformed, what, length, offset, size)
+	}
+	return nil
+}
+
+// readerSize returns the size of r when it can seek, leaving its position
+// as it was, and -1 otherwise (a pipe)
+func readerSize(r io.Reader) int64 {
+	s, ok := r.(io.Seeker)
+	if !ok {
+		return -1
+	}
+	pos, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1
+	}
+	size, err := s.Seek(0, io.SeekEnd)
+	if _, seekErr := s.Seek(pos, io.SeekStart); err != nil || seekErr != nil {
+		return -1
+	}
+	return size
+}
+
+// readStep is how much ReadBounded reads, and grows its buffer, at a time
+const readStep = 1 << 20
+
+// ReadBounded reads exactly n bytes from r, growing the buffer as the data
+// arrives instead of allocating n bytes up front: a length past the end of
+// the archive fails with io.ErrUnexpectedEOF having allocated about as much
+// as the archive holds
+func ReadBounded(r io.Reader, n int64) ([]byte, error) {
+	buf := make([]byte, 0, min(n, readStep))
+	for int64(len(buf)) < n {
+		step := int(min(n-int64(len(buf)), readStep))
+		buf = slices.Grow(buf, step)
+		got, err := io.ReadFull(r, buf[len(buf):len(buf)+step])
+		buf = buf[:len(buf)+got]
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// CapHint returns a capacity for a slice or map of n elements declared by
+// an archive, trusted only up to a size readers grow from
+func CapHint(n uint32) int {
+	return int(min(n, 1<<16))
+}
@@ -0,0 +1,81 @@
+// internal/format/link.go
+package format
+
+import "encoding/binary"
+
+// File-level dedup: a file with the same content as an earlier one is
+// recorded as a link to that file's entry and has no data of its own.
+// Links come after the entries they point to, which are never links or
+// packs themselves.
+//
+//	GDELTA01: Method | methodPackFlag | methodPackedFlag, CompSize holding
+//	          the target's index among the archive's entries, OrigSize its
+//	          length, DataOffset right after its header (no data)
+//	GDELTA03: CompSize 0 and an ExtLink extension holding the target's
+//	          index, in an extended archive
+//
+// Readers that predate links report an unknown method for GDELTA01 links
+// and an empty file for GDELTA03 ones.
+
+// ExtLink is the GDELTA03 file entry extension marking a link. Value: the
+// index of the target entry (uint32).
+const ExtLink uint16 = 4
+
+// linkExtensions returns the extensions recording a link to entry index
+func linkExtensions(index uint32) []Extension {
+	return []Extension{{Type: ExtLink, Value: binary.LittleEndian.AppendUint32(nil, index)}}
+}
+
+// linkFromExtensions returns the target index recorded in exts, if any
+func linkFromExtensions(exts []Extension) (uint32, bool) {
+	for _, ext := range exts {
+		if ext.Type == ExtLink && len(ext.Value) == 4 {
+			return binary.LittleEndian.Uint32(ext.Value), true
+		}
+	}
+	return 0, false
+}
+
+// resolveLink points link entry e, at index i of entries, at its target.
+// A link to anything but an earlier plain or packed entry keeps a nil
+// Target.
+func resolveLink(e *FileEntry, i int, entries []*FileEntry) {
+	if e.LinkIndex >= uint64(i) {
+		return
+	}
+	if t := entries[e.LinkIndex]; !t.Link && !t.Pack {
+		e.Target = t
+	}
+}
+
+// Entry returns e as a FileEntry whose data starts at dataOffset, a link
+// resolved against the entries read before it
+func (e *GDelta03FileEntry) Entry(dataOffset uint64, read []*FileEntry) *FileEntry {
+	entry := &FileEntry{
+		Path:           e.Path,
+		OriginalSize:   e.OriginalSize,
+		CompressedSize: e.CompressedSize,
+		DataOffset:     dataOffset,
+		Method:         e.Method,
+		Link:           e.Link,
+		LinkIndex:      uint64(e.LinkIndex),
+	}
+	if e.Link {
+		resolveLink(entry, len(read), read)
+	}
+	return entry
+}
+
+// Data returns the entry holding the content of e under e's path: a copy of
+// its target for a link, e itself otherwise, and nil for a link without one
+func (e *FileEntry) Data() *FileEntry {
+	if !e.Link {
+		return e
+	}
+	if e.Target == nil {
+		return nil
+	}
+	data := *e.Target
+	data.Path = e.Path
+	return &data
+}
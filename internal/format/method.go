@@ -0,0 +1,72 @@
+// internal/format/method.go
+package format
+
+import "fmt"
+
+// Method is the compression method of one file entry, and the key of the
+// codec registry (see codec.go)
+type Method uint8
+
+const (
+	MethodZstd    Method = 0 // zstd (with the archive dictionary in GDELTA03)
+	MethodStore   Method = 1 // stored as is, for data that is already compressed
+	MethodDeflate Method = 2
+	MethodLZ4     Method = 3
+	MethodBrotli  Method = 4
+	MethodSnappy  Method = 5
+)
+
+// String returns the codec name
+func (m Method) String() string {
+	if c, ok := LookupCodec(m); ok {
+		return c.Name()
+	}
+	return fmt.Sprintf("method(%d)", uint8(m))
+}
+
+// Known reports whether this version can decode entries using m
+func (m Method) Known() bool {
+	_, ok := LookupCodec(m)
+	return ok
+}
+
+// GDELTA01 records the method in the top byte of an entry's DataOffset
+// field. Entries written before methods existed hold 0 there (zstd), so
+// their layout is unchanged.
+const (
+	methodShift      = 56
+	dataOffsetMask   = 1<<methodShift - 1
+	maxGDelta01Bytes = dataOffsetMask
+)
+
+// packDataOffset combines a GDELTA01 data offset and method into one field
+func packDataOffset(offset uint64, method Method) uint64 {
+	return offset | uint64(method)<<methodShift
+}
+
+// unpackDataOffset splits a GDELTA01 DataOffset field
+func unpackDataOffset(field uint64) (uint64, Method) {
+	return field & dataOffsetMask, Method(field >> methodShift)
+}
+
+// ExtMethod is the file entry extension recording a Method other than
+// zstd in GDELTA03 archives. Value: one byte.
+const ExtMethod uint16 = 1
+
+// methodExtensions returns the extensions recording m (none for zstd)
+func methodExtensions(m Method) []Extension {
+	if m == MethodZstd {
+		return nil
+	}
+	return []Extension{{Type: ExtMethod, Value: []byte{byte(m)}}}
+}
+
+// methodFromExtensions returns the method recorded in exts, zstd if none
+func methodFromExtensions(exts []Extension) Method {
+	for _, ext := range exts {
+		if ext.Type == ExtMethod && len(ext.Value) == 1 {
+			return Method(ext.Value[0])
+		}
+	}
+	return MethodZstd
+}
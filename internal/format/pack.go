@@ -0,0 +1,196 @@
+// internal/format/pack.go
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// GDELTA01 small-file packs: files under a size threshold share one
+// compressed frame instead of paying for an entry's data and a frame each.
+// A pack is an entry named PackPath whose data is the compressed
+// concatenation of its members, and the member headers follow it with no
+// data of their own. Flags in the top bits of the method byte tell them
+// apart:
+//
+//	pack:   Method | methodPackFlag, CompSize and DataOffset of the frame,
+//	        OrigSize of the decompressed frame
+//	member: Method | methodPackedFlag, CompSize holding the member's
+//	        offset in the decompressed frame, OrigSize its length,
+//	        DataOffset right after its header (no data)
+//
+// Readers that predate packs report an unknown method for both.
+const (
+	methodPackFlag   = 0x40
+	methodPackedFlag = 0x80
+	methodFlags      = methodPackFlag | methodPackedFlag
+
+	// PackPath names pack entries; no file can be named so
+	PackPath = "\x00pack"
+
+	// MaxPackSize is the largest decompressed frame readers accept
+	MaxPackSize = 16 << 20
+)
+
+// entryFields returns the CompSize and DataOffset fields recording e
+func entryFields(e *FileEntry) (compSize, dataOffset uint64) {
+	method := e.Method
+	switch {
+	case e.Pack:
+		method |= methodPackFlag
+	case e.Packed:
+		return e.PackOffset, packDataOffset(e.DataOffset, method|methodPackedFlag)
+	case e.Link:
+		return e.LinkIndex, packDataOffset(e.DataOffset, method|methodFlags)
+	}
+	return e.CompressedSize, packDataOffset(e.DataOffset, method)
+}
+
+// setEntryFields fills e from its CompSize and DataOffset fields
+func setEntryFields(e *FileEntry, compSize, dataOffset uint64) {
+	e.DataOffset, e.Method = unpackDataOffset(dataOffset)
+	flags := e.Method & methodFlags
+	e.Method &^= methodFlags
+	switch flags {
+	case methodPackFlag:
+		e.Pack = true
+	case methodPackedFlag:
+		e.Packed, e.PackOffset = true, compSize
+		return
+	case methodFlags:
+		e.Link, e.LinkIndex = true, compSize
+		return
+	}
+	e.CompressedSize = compSize
+}
+
+// linkEntries points the packed entries at the pack before them and the
+// links at their target. A packed entry without a pack keeps a nil InPack.
+func linkEntries(entries []*FileEntry) {
+	var pack *FileEntry
+	for i, e := range entries {
+		switch {
+		case e.Pack:
+			pack = e
+		case e.Packed:
+			e.InPack = pack
+		case e.Link:
+			resolveLink(e, i, entries)
+			pack = nil
+		default:
+			pack = nil
+		}
+	}
+}
+
+// SplitPacks separates the pack entries from the file entries
+func SplitPacks(entries []*FileEntry) (files, packs []*FileEntry) {
+	for _, e := range entries {
+		if e.Pack {
+			packs = append(packs, e)
+		} else {
+			files = append(files, e)
+		}
+	}
+	return files, packs
+}
+
+// ReadPack decompresses the frame of a pack entry
+func ReadPack(r io.ReaderAt, pack *FileEntry) ([]byte, error) {
+	if pack.OriginalSize > MaxPackSize {
+		return nil, fmt.Errorf("pack of %d bytes exceeds the %d byte limit", pack.OriginalSize, MaxPackSize)
+	}
+	codec, ok := LookupCodec(pack.Method)
+	if !ok {
+		return nil, fmt.Errorf("unknown compression method: %s", pack.Method)
+	}
+	src, err := codec.NewReader(io.NewSectionReader(r, int64(pack.DataOffset), int64(pack.CompressedSize)))
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	var data bytes.Buffer
+	data.Grow(int(pack.OriginalSize))
+	n, err := data.ReadFrom(io.LimitReader(src, int64(pack.OriginalSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(n) != pack.OriginalSize {
+		return nil, fmt.Errorf("pack size mismatch: expected %d, got %d", pack.OriginalSize, n)
+	}
+	return data.Bytes(), nil
+}
+
+// PackCache decompresses each pack once for the packed files read from it,
+// from any number of goroutines, and drops a frame once its last file was
+// read
+type PackCache struct {
+	mu    sync.Mutex
+	packs map[*FileEntry]*cachedPack
+}
+
+type cachedPack struct {
+	once sync.Once
+	data []byte
+	err  error
+	left int // files still to read
+}
+
+// NewPackCache returns a cache for the packed entries of files, and for
+// the links to them
+func NewPackCache(files []*FileEntry) *PackCache {
+	c := &PackCache{packs: make(map[*FileEntry]*cachedPack)}
+	for _, e := range files {
+		if e.Link {
+			e = e.Target
+		}
+		if e == nil || e.InPack == nil {
+			continue
+		}
+		p := c.packs[e.InPack]
+		if p == nil {
+			p = &cachedPack{}
+			c.packs[e.InPack] = p
+		}
+		p.left++
+	}
+	return c
+}
+
+// Read returns the content of packed entry e, reading its pack from r the
+// first time. Each entry is read once.
+func (c *PackCache) Read(r io.ReaderAt, e *FileEntry) ([]byte, error) {
+	if e.InPack == nil {
+		return nil, fmt.Errorf("packed file without a pack")
+	}
+	c.mu.Lock()
+	p := c.packs[e.InPack]
+	c.mu.Unlock()
+	if p == nil {
+		return nil, fmt.Errorf("packed file not in the cache")
+	}
+	p.once.Do(func() { p.data, p.err = ReadPack(r, e.InPack) })
+	data, err := p.data, p.err
+	c.Drop(e)
+
+	if err != nil {
+		return nil, err
+	}
+	if e.PackOffset > uint64(len(data)) || e.OriginalSize > uint64(len(data))-e.PackOffset {
+		return nil, fmt.Errorf("packed file lies outside its pack")
+	}
+	return data[e.PackOffset : e.PackOffset+e.OriginalSize], nil
+}
+
+// Drop accounts for packed entry e as read without reading it
+func (c *PackCache) Drop(e *FileEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if p := c.packs[e.InPack]; p != nil {
+		if p.left--; p.left == 0 {
+			delete(c.packs, e.InPack)
+		}
+	}
+}
@@ -10,7 +10,10 @@ import (
 // ArchiveReader provides methods to read archive metadata
 type ArchiveReader struct {
 	r         io.ReadSeeker
+	size      int64 // archive size, -1 when unknown
 	fileCount uint32
+	pack      *FileEntry   // last pack read, for the packed entries after it
+	read      []*FileEntry // entries read so far, for the links after them
 }
 
 // FileEntry represents a file entry in the archive
@@ -19,6 +22,23 @@ type FileEntry struct {
 	OriginalSize   uint64
 	CompressedSize uint64
 	DataOffset     uint64
+	Method         Method
+
+	// Pack marks a GDELTA01 small-file pack (see pack.go), whose data is
+	// the frame holding the packed entries that follow it
+	Pack bool
+
+	// Packed marks a file stored in a pack, with no data of its own: it is
+	// OriginalSize bytes at PackOffset in the decompressed frame of InPack
+	Packed     bool
+	PackOffset uint64
+	InPack     *FileEntry
+
+	// Link marks a duplicate of an earlier file, with no data of its own
+	// (see link.go): it has the content of entry LinkIndex, Target
+	Link      bool
+	LinkIndex uint64
+	Target    *FileEntry
 }
 
 // NewArchiveReader creates a new archive reader and validates the header
@@ -38,9 +58,13 @@ func NewArchiveReader(r io.ReadSeeker) (*ArchiveReader, error) {
 	if err := binary.Read(r, binary.LittleEndian, &fileCount); err != nil {
 		return nil, fmt.Errorf("read file count: %w", err)
 	}
+	if err := checkLimit("files", fileCount, CurrentLimits().MaxFiles); err != nil {
+		return nil, err
+	}
 
 	return &ArchiveReader{
 		r:         r,
+		size:      readerSize(r),
 		fileCount: fileCount,
 	}, nil
 }
@@ -50,7 +74,9 @@ func (ar *ArchiveReader) FileCount() int {
 	return int(ar.fileCount)
 }
 
-// ReadFileEntry reads the next file entry from the archive (2 bulk reads)
+// ReadFileEntry reads the next file entry from the archive (2 bulk reads).
+// An entry whose data runs past the end of the archive is refused with
+// ErrMalformed.
 func (ar *ArchiveReader) ReadFileEntry() (*FileEntry, error) {
 	// Read path length
 	var lenBuf [2]byte
@@ -68,12 +94,27 @@ func (ar *ArchiveReader) ReadFileEntry() (*FileEntry, error) {
 		return nil, fmt.Errorf("read file entry: %w", err)
 	}
 
-	return &FileEntry{
-		Path:           string(buf[:pathLen]),
-		OriginalSize:   binary.LittleEndian.Uint64(buf[pathLen:]),
-		CompressedSize: binary.LittleEndian.Uint64(buf[pathLen+8:]),
-		DataOffset:     binary.LittleEndian.Uint64(buf[pathLen+16:]),
-	}, nil
+	entry := &FileEntry{
+		Path:         string(buf[:pathLen]),
+		OriginalSize: binary.LittleEndian.Uint64(buf[pathLen:]),
+	}
+	setEntryFields(entry, binary.LittleEndian.Uint64(buf[pathLen+8:]), binary.LittleEndian.Uint64(buf[pathLen+16:]))
+	if err := checkSpan(entry.Path+" data", entry.DataOffset, entry.CompressedSize, ar.size); err != nil {
+		return nil, err
+	}
+	switch {
+	case entry.Pack:
+		ar.pack = entry
+	case entry.Packed:
+		entry.InPack = ar.pack
+	case entry.Link:
+		resolveLink(entry, len(ar.read), ar.read)
+		ar.pack = nil
+	default:
+		ar.pack = nil
+	}
+	ar.read = append(ar.read, entry)
+	return entry, nil
 }
 
 // SeekToData seeks to the compressed data for a file entry
@@ -84,7 +125,7 @@ func (ar *ArchiveReader) SeekToData(entry *FileEntry) error {
 
 // ReadAllEntries reads all file entries from the archive
 func (ar *ArchiveReader) ReadAllEntries() ([]*FileEntry, error) {
-	entries := make([]*FileEntry, 0, ar.fileCount)
+	entries := make([]*FileEntry, 0, CapHint(ar.fileCount))
 
 	for i := uint32(0); i < ar.fileCount; i++ {
 		entry, err := ar.ReadFileEntry()
@@ -0,0 +1,256 @@
+// internal/format/recover.go
+package format
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// ScanResult describes how much of a possibly damaged archive is intact
+type ScanResult struct {
+	Declared    int    // File count from the header
+	Intact      int    // Files whose entry and data lie fully within the archive
+	LogicalEnd  int64  // Offset just past the last intact data
+	FooterValid bool   // Footer marker found where the data ends
+	Reason      string // Why the scan stopped on a damaged entry ("" if it didn't)
+}
+
+// Damaged reports whether the scan had to stop before the footer. Archives
+// whose writer skipped unreadable files hold fewer entries than declared but
+// still end in a valid footer, and are not damaged.
+func (s *ScanResult) Damaged() bool {
+	return !s.FooterValid || s.Reason != ""
+}
+
+// ScanGDelta01 walks GDELTA01 entries forward from the start of the archive
+// and returns those that are intact. Each entry header is checked against the
+// archive size and against the writer's layout (data follows its header), so
+// the scan stops at the first truncated, unfinished or garbage entry instead
+// of trusting the header's file count or a footer.
+func ScanGDelta01(r io.ReadSeeker, size int64) ([]*FileEntry, *ScanResult, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	reader, err := NewArchiveReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scan := &ScanResult{Declared: reader.FileCount(), LogicalEnd: MagicSize + 4}
+	entries, err := scanEntries(r, size, scan, ArchiveFooter, 24, false, func(buf []byte, pathLen int) FileEntry {
+		e := FileEntry{OriginalSize: binary.LittleEndian.Uint64(buf[pathLen:])}
+		setEntryFields(&e, binary.LittleEndian.Uint64(buf[pathLen+8:]), binary.LittleEndian.Uint64(buf[pathLen+16:]))
+		return e
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	linkEntries(entries)
+	scan.FooterValid = hasMarker(r, scan.LogicalEnd, size, ArchiveFooter)
+	return entries, scan, nil
+}
+
+// ScanGDelta03 is ScanGDelta01 for GDELTA03: entries follow the dictionary.
+// Returned entries carry the offset of their data like GDELTA01 entries.
+func ScanGDelta03(r io.ReadSeeker, size int64) ([]*FileEntry, *ScanResult, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	header, err := ReadGDelta03Header(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	start := header.DictOffset + int64(header.DictSize)
+	scan := &ScanResult{Declared: int(header.FileCount), LogicalEnd: start}
+	if start > size {
+		scan.Reason = "dictionary truncated"
+		return nil, scan, nil
+	}
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := scanEntries(r, size, scan, ArchiveFooter03, 16, header.Extended, func(buf []byte, pathLen int) FileEntry {
+		// Data follows the header, the method and links are in the extension
+		// area: all are filled in by scanEntries
+		return FileEntry{
+			OriginalSize:   binary.LittleEndian.Uint64(buf[pathLen:]),
+			CompressedSize: binary.LittleEndian.Uint64(buf[pathLen+8:]),
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	linkEntries(entries)
+	scan.FooterValid = hasMarker(r, scan.LogicalEnd, size, ArchiveFooter03)
+	return entries, scan, nil
+}
+
+// scanEntries reads entry headers of the form PathLen(2) + Path + fixed(tail)
+// [+ extension area] until the declared count, the footer, or the first entry
+// that doesn't fit.
+// parse returns the entry of a header, its path aside, with a DataOffset of
+// 0 when the format doesn't store it.
+func scanEntries(
+	r io.ReadSeeker,
+	size int64,
+	scan *ScanResult,
+	footer string,
+	tail int,
+	extended bool,
+	parse func(buf []byte, pathLen int) FileEntry,
+) ([]*FileEntry, error) {
+	var entries []*FileEntry
+	pos := scan.LogicalEnd
+
+	for len(entries) < scan.Declared {
+		if hasMarker(r, pos, size, footer) {
+			break
+		}
+		if pos+2 > size {
+			scan.Reason = fmt.Sprintf("truncated before entry %d", len(entries)+1)
+			break
+		}
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return nil, err
+		}
+		var lenBuf [2]byte
+		if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+			return nil, err
+		}
+		pathLen := int(binary.LittleEndian.Uint16(lenBuf[:]))
+
+		headerEnd := pos + 2 + int64(pathLen) + int64(tail)
+		if pathLen == 0 || headerEnd > size {
+			scan.Reason = fmt.Sprintf("entry %d header is truncated or invalid", len(entries)+1)
+			break
+		}
+		buf := make([]byte, pathLen+tail)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		entry := parse(buf, pathLen)
+		if extended {
+			if headerEnd+4 > size {
+				scan.Reason = fmt.Sprintf("entry %d header is truncated or invalid", len(entries)+1)
+				break
+			}
+			var areaBuf [4]byte
+			if _, err := io.ReadFull(r, areaBuf[:]); err != nil {
+				return nil, err
+			}
+			areaLen := int64(binary.LittleEndian.Uint32(areaBuf[:]))
+			headerEnd += 4 + areaLen
+			if headerEnd > size || areaLen > maxExtensionArea {
+				scan.Reason = fmt.Sprintf("entry %d header is truncated or invalid", len(entries)+1)
+				break
+			}
+			area := make([]byte, areaLen)
+			if _, err := io.ReadFull(r, area); err != nil {
+				return nil, err
+			}
+			exts, err := parseExtensions(area)
+			if err != nil {
+				scan.Reason = fmt.Sprintf("entry %d header is truncated or invalid", len(entries)+1)
+				break
+			}
+			entry.Method = methodFromExtensions(exts)
+			if index, ok := linkFromExtensions(exts); ok {
+				entry.Link, entry.LinkIndex = true, uint64(index)
+			}
+		}
+
+		if entry.DataOffset == 0 {
+			entry.DataOffset = uint64(headerEnd)
+		}
+		dataEnd := int64(entry.DataOffset) + int64(entry.CompressedSize)
+		switch {
+		case entry.DataOffset != uint64(headerEnd):
+			// GDELTA01 fills in the offset after writing the data; a zero or
+			// foreign value means the writer never finished this entry
+			scan.Reason = fmt.Sprintf("entry %d (%s) was not completed", len(entries)+1, string(buf[:pathLen]))
+		case entry.CompressedSize > uint64(size) || dataEnd > size:
+			scan.Reason = fmt.Sprintf("entry %d (%s) data is truncated", len(entries)+1, string(buf[:pathLen]))
+		}
+		if scan.Reason != "" {
+			break
+		}
+
+		entry.Path = string(buf[:pathLen])
+		entries = append(entries, &entry)
+		pos = dataEnd
+		scan.LogicalEnd = pos
+	}
+
+	scan.Intact = len(entries)
+	return entries, nil
+}
+
+// hasMarker reports whether marker is stored at offset
+func hasMarker(r io.ReadSeeker, offset, size int64, marker string) bool {
+	if offset+int64(len(marker)) > size {
+		return false
+	}
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return false
+	}
+	buf := make([]byte, len(marker))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return false
+	}
+	return string(buf) == marker
+}
+
+// IntactGDelta02Files returns the files whose chunks all lie within the
+// archive, given the chunk data start and archive size, or among refs (may
+// be nil). The data ends before the footer when one is present, otherwise
+// at the end of the file.
+func IntactGDelta02Files(r io.ReadSeeker, chunks map[[32]byte]ChunkInfo, refs *ReferenceChunks, files []FileMetadata, dataStart, size int64) ([]FileMetadata, *ScanResult) {
+	scan := &ScanResult{Declared: len(files), LogicalEnd: dataStart}
+
+	// The footer sits right after the last chunk
+	var chunkEnd int64
+	for _, c := range chunks {
+		if end := dataStart + int64(c.Offset) + int64(c.CompressedSize); end > chunkEnd {
+			chunkEnd = end
+		}
+	}
+	if chunkEnd < dataStart {
+		chunkEnd = dataStart
+	}
+	scan.FooterValid = hasMarker(r, chunkEnd, size, ArchiveFooter02)
+
+	var intact []FileMetadata
+	for _, f := range files {
+		ok := true
+		for _, hash := range f.ChunkHashes {
+			c, found := chunks[hash]
+			if !found && refs.Has(hash) {
+				continue
+			}
+			if !found {
+				ok = false
+				break
+			}
+			end := dataStart + int64(c.Offset) + int64(c.CompressedSize)
+			if c.CompressedSize > uint64(size) || end > size {
+				ok = false
+				break
+			}
+			if end > scan.LogicalEnd {
+				scan.LogicalEnd = end
+			}
+		}
+		if ok {
+			intact = append(intact, f)
+		}
+	}
+
+	scan.Intact = len(intact)
+	if scan.Intact < scan.Declared {
+		scan.Reason = fmt.Sprintf("%d files reference chunk data past the end of the archive", scan.Declared-scan.Intact)
+	}
+	return intact, scan
+}
@@ -0,0 +1,193 @@
+// internal/format/reference.go
+package format
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Reference chunks: a GDELTA02 archive made against reference directories
+// (a previous backup extracted on a NAS, say) leaves out the chunks found
+// in them. Their files still list them; the ExtReferenceChunks header
+// extension tells where each one lies in the reference files, and the
+// chunk index doesn't hold them. Readers need FeatureReferenceChunks and
+// the reference directories to restore those files.
+
+// ExtReferenceChunks is the GDELTA02 header extension listing the chunks
+// the archive takes from reference directories. Value:
+//
+//	DirCount (2), then per directory: Len (2) + Path
+//	SourceCount (4), then per file: Dir (2) + Len (2) + slash-separated
+//	  path relative to the directory
+//	ChunkCount (4), then per chunk: Hash (32) + Source (4) + Offset (8) +
+//	  Size (4)
+const ExtReferenceChunks uint16 = 7
+
+// referenceChunkSize is the encoded size of one reference chunk
+const referenceChunkSize = 48
+
+// ReferenceSource is a file of a reference directory holding chunks
+type ReferenceSource struct {
+	Dir  int    // Index into ReferenceChunks.Dirs
+	Path string // Slash-separated, relative to the directory
+}
+
+// ReferenceChunk is where a reference chunk lies: Size bytes at Offset of
+// a source file
+type ReferenceChunk struct {
+	Source uint32 // Index into ReferenceChunks.Sources
+	Offset uint64
+	Size   uint32
+}
+
+// ReferenceChunks are the chunks an archive takes from reference
+// directories. Dirs are recorded as given when compressing; readers may
+// be pointed elsewhere.
+type ReferenceChunks struct {
+	Dirs    []string
+	Sources []ReferenceSource
+	Chunks  map[[32]byte]ReferenceChunk
+}
+
+// Extension returns the ExtReferenceChunks extension recording r
+func (r *ReferenceChunks) Extension() (Extension, error) {
+	if len(r.Dirs) > 0xffff {
+		return Extension{}, fmt.Errorf("%d reference directories (max %d)", len(r.Dirs), 0xffff)
+	}
+	v := binary.LittleEndian.AppendUint16(nil, uint16(len(r.Dirs)))
+	for _, dir := range r.Dirs {
+		if len(dir) > 0xffff {
+			return Extension{}, fmt.Errorf("reference directory path too long: %d bytes", len(dir))
+		}
+		v = binary.LittleEndian.AppendUint16(v, uint16(len(dir)))
+		v = append(v, dir...)
+	}
+	v = binary.LittleEndian.AppendUint32(v, uint32(len(r.Sources)))
+	for _, src := range r.Sources {
+		if len(src.Path) > 0xffff {
+			return Extension{}, fmt.Errorf("reference file path too long: %d bytes", len(src.Path))
+		}
+		v = binary.LittleEndian.AppendUint16(v, uint16(src.Dir))
+		v = binary.LittleEndian.AppendUint16(v, uint16(len(src.Path)))
+		v = append(v, src.Path...)
+	}
+	v = binary.LittleEndian.AppendUint32(v, uint32(len(r.Chunks)))
+	for hash, c := range r.Chunks {
+		v = append(v, hash[:]...)
+		v = binary.LittleEndian.AppendUint32(v, c.Source)
+		v = binary.LittleEndian.AppendUint64(v, c.Offset)
+		v = binary.LittleEndian.AppendUint32(v, c.Size)
+	}
+	if len(v) > maxExtensionArea-6 {
+		return Extension{}, fmt.Errorf("%d reference chunks don't fit in a header extension", len(r.Chunks))
+	}
+	return Extension{Type: ExtReferenceChunks, Value: v}, nil
+}
+
+// ParseReferenceChunks decodes an ExtReferenceChunks value. Sources must
+// name a directory of the table and stay inside it, chunks a source.
+func ParseReferenceChunks(v []byte) (*ReferenceChunks, error) {
+	d := extDecoder{buf: v}
+	r := &ReferenceChunks{}
+	for n := d.u16(); n > 0 && d.err == nil; n-- {
+		r.Dirs = append(r.Dirs, d.str(int(d.u16())))
+	}
+	for n := d.u32(); n > 0 && d.err == nil; n-- {
+		src := ReferenceSource{Dir: int(d.u16())}
+		src.Path = d.str(int(d.u16()))
+		if d.err == nil && (src.Dir >= len(r.Dirs) || !localPath(src.Path)) {
+			return nil, fmt.Errorf("%w: reference file %q of directory %d", ErrMalformed, src.Path, src.Dir)
+		}
+		r.Sources = append(r.Sources, src)
+	}
+	n := d.u32()
+	if d.err == nil && uint64(n)*referenceChunkSize > uint64(len(d.buf)-d.pos) {
+		return nil, fmt.Errorf("%w: %d reference chunks in %d bytes", ErrMalformed, n, len(d.buf)-d.pos)
+	}
+	r.Chunks = make(map[[32]byte]ReferenceChunk, CapHint(n))
+	for ; n > 0 && d.err == nil; n-- {
+		var hash [32]byte
+		copy(hash[:], d.bytes(32))
+		c := ReferenceChunk{Source: d.u32(), Offset: d.u64(), Size: d.u32()}
+		if d.err == nil && int(c.Source) >= len(r.Sources) {
+			return nil, fmt.Errorf("%w: reference chunk %x in file %d", ErrMalformed, hash[:8], c.Source)
+		}
+		r.Chunks[hash] = c
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("%w: reference chunks: %v", ErrMalformed, d.err)
+	}
+	return r, nil
+}
+
+// Has reports whether the chunk with this hash is a reference chunk; false
+// on a nil r
+func (r *ReferenceChunks) Has(hash [32]byte) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.Chunks[hash]
+	return ok
+}
+
+// ReferenceChunks returns the reference chunks of h, nil when it has none
+func (h GDelta02Header) ReferenceChunks() (*ReferenceChunks, error) {
+	v, ok := FindExtension(h.Extensions, ExtReferenceChunks)
+	if !ok {
+		return nil, nil
+	}
+	return ParseReferenceChunks(v)
+}
+
+// localPath reports whether p is a relative slash-separated path that
+// stays inside its directory
+func localPath(p string) bool {
+	return p != "" && !path.IsAbs(p) && !strings.Contains(p, "\\") &&
+		path.Clean(p) == p && p != ".." && !strings.HasPrefix(p, "../")
+}
+
+// extDecoder reads the fields of an extension value, keeping the first
+// error
+type extDecoder struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (d *extDecoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.buf)-d.pos {
+		d.err = fmt.Errorf("truncated at byte %d", d.pos)
+		return nil
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b
+}
+
+func (d *extDecoder) str(n int) string { return string(d.bytes(n)) }
+
+func (d *extDecoder) u16() uint16 {
+	if b := d.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *extDecoder) u32() uint32 {
+	if b := d.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *extDecoder) u64() uint64 {
+	if b := d.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
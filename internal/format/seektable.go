@@ -0,0 +1,113 @@
+// internal/format/seektable.go
+package format
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Segment is one independently compressed zstd frame of a large file
+type Segment struct {
+	CompressedSize uint32
+	OrigSize       uint32
+}
+
+// A segmented zstd entry is a sequence of zstd frames followed by a seek
+// table in the layout of the zstd seekable format. The table sits in a
+// skippable frame, so any zstd decoder (including older godelta versions)
+// reads the entry as one stream and ignores it, while readers that know the
+// table can decode the frames in parallel.
+//
+// Seek table layout (little-endian):
+//
+//	Magic (4):        0x184D2A5E, skippable frame
+//	FrameSize (4):    8*N + 9
+//	repeated N times:
+//	  CompressedSize (4)
+//	  OrigSize (4)
+//	NumFrames (4):    N
+//	Descriptor (1):   0, no per-frame checksums
+//	SeekableMagic (4): 0x8F92EAB1
+const (
+	skippableMagic = 0x184D2A5E
+	seekableMagic  = 0x8F92EAB1
+
+	seekTableFooterSize = 9
+	seekTableEntrySize  = 8
+
+	// maxSegments bounds a table read from an archive so a corrupt count
+	// can't force a huge allocation
+	maxSegments = 1 << 24
+)
+
+// SeekTableSize returns the encoded size of a table with n segments
+func SeekTableSize(n int) int {
+	return 8 + n*seekTableEntrySize + seekTableFooterSize
+}
+
+// WriteSeekTable writes the seek table for segments
+func WriteSeekTable(w io.Writer, segments []Segment) error {
+	buf := make([]byte, SeekTableSize(len(segments)))
+	binary.LittleEndian.PutUint32(buf[0:4], skippableMagic)
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(len(segments)*seekTableEntrySize+seekTableFooterSize))
+	off := 8
+	for _, s := range segments {
+		binary.LittleEndian.PutUint32(buf[off:], s.CompressedSize)
+		binary.LittleEndian.PutUint32(buf[off+4:], s.OrigSize)
+		off += seekTableEntrySize
+	}
+	binary.LittleEndian.PutUint32(buf[off:], uint32(len(segments)))
+	buf[off+4] = 0
+	binary.LittleEndian.PutUint32(buf[off+5:], seekableMagic)
+	_, err := w.Write(buf)
+	return err
+}
+
+// ReadSeekTable reads the seek table at the end of the entry data
+// r[offset:offset+size]. It returns nil without error when the entry has no
+// table (a single zstd stream).
+func ReadSeekTable(r io.ReaderAt, offset, size int64) ([]Segment, error) {
+	if size < int64(SeekTableSize(0)) {
+		return nil, nil
+	}
+	var footer [seekTableFooterSize]byte
+	if _, err := r.ReadAt(footer[:], offset+size-seekTableFooterSize); err != nil {
+		return nil, fmt.Errorf("read seek table footer: %w", err)
+	}
+	if binary.LittleEndian.Uint32(footer[5:9]) != seekableMagic {
+		return nil, nil
+	}
+	if footer[4] != 0 {
+		return nil, fmt.Errorf("unsupported seek table descriptor %#x", footer[4])
+	}
+
+	n := int64(binary.LittleEndian.Uint32(footer[0:4]))
+	tableSize := int64(SeekTableSize(int(n)))
+	if n > maxSegments || tableSize > size {
+		return nil, fmt.Errorf("invalid seek table: %d segments", n)
+	}
+	buf := make([]byte, tableSize-seekTableFooterSize)
+	if _, err := r.ReadAt(buf, offset+size-tableSize); err != nil {
+		return nil, fmt.Errorf("read seek table: %w", err)
+	}
+	if binary.LittleEndian.Uint32(buf[0:4]) != skippableMagic ||
+		int64(binary.LittleEndian.Uint32(buf[4:8])) != tableSize-8 {
+		return nil, fmt.Errorf("invalid seek table header")
+	}
+
+	segments := make([]Segment, n)
+	var total int64
+	for i := range segments {
+		off := 8 + i*seekTableEntrySize
+		segments[i] = Segment{
+			CompressedSize: binary.LittleEndian.Uint32(buf[off:]),
+			OrigSize:       binary.LittleEndian.Uint32(buf[off+4:]),
+		}
+		total += int64(segments[i].CompressedSize)
+	}
+	if total != size-tableSize {
+		return nil, fmt.Errorf("invalid seek table: covers %d bytes, entry has %d", total, size-tableSize)
+	}
+	return segments, nil
+}
@@ -0,0 +1,82 @@
+// internal/format/spec.go
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExtFormatSpec is the GDELTA02/GDELTA03 header extension holding a plain
+// text summary of the archive's layout (FormatSpec), so the archive can be
+// read without this code or its documentation, years after it was made.
+// Value: the text, UTF-8. Readers ignore it.
+const ExtFormatSpec uint16 = 8
+
+// FormatSpecRevision numbers the text of FormatSpec. It changes when the
+// text does, the formats having their own magic.
+const FormatSpecRevision = 1
+
+// Text shared by the GDELTA02 and GDELTA03 summaries
+const (
+	specPreamble = "godelta archive format %s, layout summary revision %d.\n" +
+		"All integers are unsigned little-endian; (n) is a size in bytes.\n" +
+		"Paths are UTF-8 with / separators, relative to the archive root.\n\n"
+
+	specExtensions = "Extension area, present when flags bit 0 is set:\n" +
+		"  AreaLen (4): bytes that follow; then repeated Type (2) + Len (4) + Value.\n" +
+		"  Types: 1 method (1 byte), 2 chunk bounds, 3 metadata (JSON), 4 link\n" +
+		"  (index of the entry holding the data, 4), 5 MIME type, 6 chunk\n" +
+		"  dictionary, 7 reference chunks, 8 this text. Unknown types are skipped.\n\n"
+
+	specCodecs = "Codecs: 0 zstd, 1 stored as is, 2 deflate (RFC 1951), 3 LZ4 frame,\n" +
+		"4 brotli, 5 snappy (framed).\n\n"
+
+	specParity = "Reed-Solomon parity may follow the footer, written by\n" +
+		"'godelta compress --parity'; readers ignore it.\n"
+)
+
+// FormatSpec returns the layout summary ExtFormatSpec embeds in archives
+// of format f, "" for formats without a header extension area
+func FormatSpec(f ArchiveFormat) string {
+	var sb strings.Builder
+	switch f {
+	case FormatGDelta02:
+		fmt.Fprintf(&sb, specPreamble, ArchiveMagic02, FormatSpecRevision)
+		sb.WriteString("Content-defined chunks, deduplicated across files. Layout:\n" +
+			"  Magic (8): \"GDELTA02\"\n" +
+			"  Params (8): bits 0-31 average chunk size, 32-39 level, 40-47 codec\n" +
+			"    (0 or 1 zstd, other values the codec numbers below), 48-55 flags,\n" +
+			"    56-63 required features (bit 0 stored chunks, bit 1 chunk\n" +
+			"    dictionary, bit 2 reference chunks)\n" +
+			"  FileCount (4), ChunkCount (4)\n" +
+			"  [Extension area]\n" +
+			"  Chunk index, ChunkCount entries sorted by hash: Hash (32, BLAKE3-256\n" +
+			"    of the chunk) + Offset (8, from the start of chunk data) +\n" +
+			"    CompressedSize (8, bit 63 set: stored uncompressed) + OriginalSize (8)\n" +
+			"  File entries, FileCount times: PathLen (2) + Path + OriginalSize (8) +\n" +
+			"    ChunkCount (4) + ChunkCount hashes (32 each) [+ extension area]\n" +
+			"  Chunk data: each chunk compressed alone with the header codec (zstd:\n" +
+			"    one frame, with the dictionary of extension 6 when present)\n" +
+			"  Footer (8): \"ENDGDLT2\"\n" +
+			"A file is the concatenation of its chunks, in the order listed.\n\n")
+	case FormatGDelta03:
+		fmt.Fprintf(&sb, specPreamble, ArchiveMagic03, FormatSpecRevision)
+		sb.WriteString("Files compressed with a shared zstd dictionary. Layout:\n" +
+			"  Magic (8): \"GDELTA03\"\n" +
+			"  Version (1), DictSize (4), FileCount (4), Flags (1), required\n" +
+			"    Features (1), Reserved (2)\n" +
+			"  [Extension area]\n" +
+			"  Dictionary (DictSize): a zstd dictionary\n" +
+			"  FileCount times: PathLen (2) + Path + OriginalSize (8) +\n" +
+			"    CompressedSize (8) [+ extension area] + CompressedSize bytes of\n" +
+			"    data, one zstd frame using the dictionary unless extension 1\n" +
+			"    names another codec\n" +
+			"  Footer (8): \"ENDGDLT3\"\n\n")
+	default:
+		return ""
+	}
+	sb.WriteString(specExtensions)
+	sb.WriteString(specCodecs)
+	sb.WriteString(specParity)
+	return sb.String()
+}
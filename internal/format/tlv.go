@@ -0,0 +1,168 @@
+// internal/format/tlv.go
+package format
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Extension is one optional type-length-value field. Headers and file entries
+// of GDELTA02 and GDELTA03 archives carry an extension area when the header's
+// FlagExtensions bit is set, so fields like checksums or xattrs can be added
+// later without another format version. Readers skip types they don't know.
+//
+// Extension area layout:
+//
+//	AreaLen (4):  bytes that follow, so a reader can skip the whole area
+//	repeated:
+//	  Type (2):   uint16
+//	  Len (4):    uint32
+//	  Value (Len)
+type Extension struct {
+	Type  uint16
+	Value []byte
+}
+
+// FlagExtensions marks an archive whose header and file entries each carry
+// an extension area
+const FlagExtensions uint8 = 1 << 0
+
+// maxExtensionArea bounds one extension area so a corrupt length can't force
+// a huge allocation
+const maxExtensionArea = 16 << 20
+
+// knownExtensions lists the extension types this version interprets. Types
+// are added here as fields are defined; anything else is skipped and counted.
+var knownExtensions = map[uint16]bool{
+	ExtMethod:          true,
+	ExtChunkBounds:     true,
+	ExtMetadata:        true,
+	ExtLink:            true,
+	ExtType:            true,
+	ExtChunkDict:       true,
+	ExtReferenceChunks: true,
+	ExtFormatSpec:      true,
+}
+
+// ExtMetadata is the GDELTA02/GDELTA03 header extension describing the
+// archive: creation time, host, version, source, labels and annotations,
+// as a JSON object padded with spaces so annotations can be rewritten in
+// place
+const ExtMetadata uint16 = 3
+
+// KnownExtension reports whether this version interprets extension type t
+func KnownExtension(t uint16) bool {
+	return knownExtensions[t]
+}
+
+// FindExtension returns the value of the first extension of type t
+func FindExtension(exts []Extension, t uint16) ([]byte, bool) {
+	for _, ext := range exts {
+		if ext.Type == t {
+			return ext.Value, true
+		}
+	}
+	return nil, false
+}
+
+// ExtensionValueOffset returns where the value of the first extension of
+// type t starts, counted from the start of the area holding exts
+func ExtensionValueOffset(exts []Extension, t uint16) (int64, bool) {
+	off := int64(4)
+	for _, ext := range exts {
+		off += 6
+		if ext.Type == t {
+			return off, true
+		}
+		off += int64(len(ext.Value))
+	}
+	return 0, false
+}
+
+// HeaderExtensionsOffset returns where the header extension area of an
+// extended GDELTA02 or GDELTA03 archive starts
+func HeaderExtensionsOffset(f ArchiveFormat) (int64, bool) {
+	switch f {
+	case FormatGDelta02:
+		return gdelta02FixedHeaderSize, true
+	case FormatGDelta03:
+		return gdelta03FixedHeaderSize, true
+	}
+	return 0, false
+}
+
+// CountUnknownExtensions returns how many of exts this version skips
+func CountUnknownExtensions(exts []Extension) int {
+	n := 0
+	for _, ext := range exts {
+		if !KnownExtension(ext.Type) {
+			n++
+		}
+	}
+	return n
+}
+
+// extensionAreaSize returns the encoded size of exts, including AreaLen
+func extensionAreaSize(exts []Extension) int {
+	size := 4
+	for _, ext := range exts {
+		size += 6 + len(ext.Value)
+	}
+	return size
+}
+
+// AppendExtensions appends an extension area holding exts to buf
+func AppendExtensions(buf []byte, exts []Extension) ([]byte, error) {
+	areaLen := extensionAreaSize(exts) - 4
+	if areaLen > maxExtensionArea {
+		return nil, fmt.Errorf("extension area too large: %d bytes (max %d)", areaLen, maxExtensionArea)
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(areaLen))
+	for _, ext := range exts {
+		buf = binary.LittleEndian.AppendUint16(buf, ext.Type)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(ext.Value)))
+		buf = append(buf, ext.Value...)
+	}
+	return buf, nil
+}
+
+// ReadExtensions reads one extension area
+func ReadExtensions(r io.Reader) ([]Extension, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return nil, fmt.Errorf("read extension area length: %w", err)
+	}
+	areaLen := binary.LittleEndian.Uint32(lenBuf[:])
+	if areaLen == 0 {
+		return nil, nil
+	}
+	if areaLen > maxExtensionArea {
+		return nil, fmt.Errorf("%w: extension area of %d bytes (max %d)", ErrMalformed, areaLen, maxExtensionArea)
+	}
+
+	buf := make([]byte, areaLen)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("read extension area: %w", err)
+	}
+	return parseExtensions(buf)
+}
+
+// parseExtensions decodes the fields of an extension area (without AreaLen)
+func parseExtensions(buf []byte) ([]Extension, error) {
+	var exts []Extension
+	for pos := 0; pos < len(buf); {
+		if len(buf)-pos < 6 {
+			return nil, fmt.Errorf("extension %d: truncated header", len(exts))
+		}
+		t := binary.LittleEndian.Uint16(buf[pos:])
+		n := int(binary.LittleEndian.Uint32(buf[pos+2:]))
+		pos += 6
+		if n > len(buf)-pos {
+			return nil, fmt.Errorf("extension %d (type %d): length %d exceeds area", len(exts), t, n)
+		}
+		exts = append(exts, Extension{Type: t, Value: buf[pos : pos+n : pos+n]})
+		pos += n
+	}
+	return exts, nil
+}
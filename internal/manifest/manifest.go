@@ -0,0 +1,95 @@
+// internal/manifest/manifest.go
+
+// Package manifest reads and writes SHA-256 manifests in the sha256sum
+// format: one "<hex digest>  <path>" line per file. Like sha256sum, a path
+// holding a backslash or a newline is escaped and its line starts with a
+// backslash, so `sha256sum -c` can check a restore with the manifest.
+package manifest
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Entry is the digest of one file
+type Entry struct {
+	Path string // Slash-separated path relative to the archive root
+	Sum  [32]byte
+}
+
+// Write writes the entries sorted by path
+func Write(w io.Writer, entries []Entry) error {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		path, prefix := e.Path, ""
+		if strings.ContainsAny(path, "\\\n") {
+			path = strings.NewReplacer("\\", "\\\\", "\n", "\\n").Replace(path)
+			prefix = "\\"
+		}
+		if _, err := fmt.Fprintf(bw, "%s%x  %s\n", prefix, e.Sum, path); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Read parses a manifest into a map of digests by path. Lines in binary
+// mode ("<hex> *<path>") are accepted too.
+func Read(r io.Reader) (map[string][32]byte, error) {
+	sums := make(map[string][32]byte)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" {
+			continue
+		}
+		escaped := strings.HasPrefix(text, "\\")
+		if escaped {
+			text = text[1:]
+		}
+		if len(text) < 66 || text[64] != ' ' || (text[65] != ' ' && text[65] != '*') {
+			return nil, fmt.Errorf("line %d: not a sha256sum line", line)
+		}
+		var sum [32]byte
+		if _, err := hex.Decode(sum[:], []byte(text[:64])); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		path := text[66:]
+		if escaped {
+			path = unescape(path)
+		}
+		if _, dup := sums[path]; dup {
+			return nil, fmt.Errorf("line %d: %s listed twice", line, path)
+		}
+		sums[path] = sum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+// unescape reverses the escaping of Write
+func unescape(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+1 < len(path) {
+			i++
+			switch path[i] {
+			case 'n':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(path[i])
+			}
+			continue
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
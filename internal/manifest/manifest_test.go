@@ -0,0 +1,67 @@
+// internal/manifest/manifest_test.go
+package manifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+	entries := []Entry{
+		{Path: "sub/b.txt", Sum: sha256.Sum256([]byte("b"))},
+		{Path: "a.txt", Sum: sha256.Sum256([]byte("a"))},
+		{Path: "odd\\name\nwith newline", Sum: sha256.Sum256([]byte("odd"))},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %q", buf.String())
+	}
+	if want := fmt.Sprintf("%x  a.txt", sha256.Sum256([]byte("a"))); lines[0] != want {
+		t.Errorf("First line %q, want %q", lines[0], want)
+	}
+	if want := fmt.Sprintf("\\%x  odd\\\\name\\nwith newline", sha256.Sum256([]byte("odd"))); lines[1] != want {
+		t.Errorf("Escaped line %q, want %q", lines[1], want)
+	}
+
+	sums, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sums) != len(entries) {
+		t.Fatalf("Read %d entries, want %d", len(sums), len(entries))
+	}
+	for _, e := range entries {
+		if sums[e.Path] != e.Sum {
+			t.Errorf("%q: digest mismatch", e.Path)
+		}
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	sum := fmt.Sprintf("%x", sha256.Sum256(nil))
+	tests := map[string]string{
+		"short line":   "abc  file\n",
+		"bad hex":      strings.Repeat("z", 64) + "  file\n",
+		"one space":    sum + " file\n",
+		"listed twice": sum + "  file\n" + sum + " *file\n",
+	}
+	for name, input := range tests {
+		if _, err := Read(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// Binary mode lines are accepted
+	sums, err := Read(strings.NewReader(sum + " *file\n"))
+	if err != nil || len(sums) != 1 {
+		t.Errorf("Binary mode line: %v, %v", sums, err)
+	}
+}
@@ -0,0 +1,123 @@
+// internal/mmap/mmap.go
+package mmap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// ErrUnsupported is returned by Open for files that can't be mapped: pipes
+// and other non-regular files, empty files, files on network filesystems
+// (where the mapping breaks if the server side changes) and platforms
+// without mmap support. Callers fall back to reading the file.
+var ErrUnsupported = errors.New("memory mapping not supported")
+
+// File is a read-only memory mapping of a whole file. ReadAt and Slice are
+// safe for concurrent use.
+type File struct {
+	data []byte
+}
+
+// Open maps the file at path read-only
+func Open(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// The mapping outlives the descriptor
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%w: %s is not a regular file", ErrUnsupported, path)
+	}
+	if info.Size() == 0 || int64(int(info.Size())) != info.Size() {
+		return nil, fmt.Errorf("%w: %s has size %d", ErrUnsupported, path, info.Size())
+	}
+	if isRemote(f) {
+		return nil, fmt.Errorf("%w: %s is on a network filesystem", ErrUnsupported, path)
+	}
+
+	data, err := mapFile(f, int(info.Size()))
+	if err != nil {
+		return nil, err
+	}
+	return &File{data: data}, nil
+}
+
+// Len returns the size of the mapped file
+func (m *File) Len() int64 {
+	return int64(len(m.data))
+}
+
+// Slice returns the n bytes at off without copying. The slice is read-only
+// and must not be used after Close.
+func (m *File) Slice(off, n int64) ([]byte, error) {
+	if off < 0 || n < 0 || off > m.Len() || n > m.Len()-off {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return m.data[off : off+n : off+n], nil
+}
+
+// ReadAt implements io.ReaderAt
+func (m *File) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("mmap: negative offset")
+	}
+	if off >= m.Len() {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Close unmaps the file
+func (m *File) Close() error {
+	if m.data == nil {
+		return nil
+	}
+	data := m.data
+	m.data = nil
+	return unmap(data)
+}
+
+// readStep is how much Read grows *buf by at a time past its capacity
+const readStep = 1 << 20
+
+// Read returns the n bytes at off in r: a slice of the mapping when r is a
+// *File, otherwise read into *buf. Past its capacity *buf is grown as the
+// data arrives rather than by n up front, so a length past the end of r
+// fails with io.ErrUnexpectedEOF having allocated about as much as r holds.
+// The result is only valid until *buf is reused or the mapping closed.
+func Read(r io.ReaderAt, off, n int64, buf *[]byte) ([]byte, error) {
+	if m, ok := r.(*File); ok {
+		return m.Slice(off, n)
+	}
+	if off < 0 || n < 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := (*buf)[:0]
+	for int64(len(b)) < n {
+		step := int(min(n-int64(len(b)), max(int64(cap(b)-len(b)), readStep)))
+		b = slices.Grow(b, step)
+		got, err := r.ReadAt(b[len(b):len(b)+step], off+int64(len(b)))
+		b = b[:len(b)+got]
+		*buf = b
+		if got < step {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package mmap
+
+import "os"
+
+func mapFile(f *os.File, size int) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+func unmap(data []byte) error {
+	return nil
+}
@@ -0,0 +1,100 @@
+// internal/mmap/mmap_test.go
+package mmap
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("memory mapping is not supported on this platform")
+	}
+	want := bytes.Repeat([]byte("0123456789"), 1000)
+	path := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(path, want, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if m.Len() != int64(len(want)) {
+		t.Fatalf("Len: got %d, want %d", m.Len(), len(want))
+	}
+	b, err := m.Slice(5, 20)
+	if err != nil || !bytes.Equal(b, want[5:25]) {
+		t.Errorf("Slice: %q, %v", b, err)
+	}
+	if _, err := m.Slice(int64(len(want))-5, 10); err == nil {
+		t.Error("Slice past the end should fail")
+	}
+
+	buf := make([]byte, 10)
+	if n, err := m.ReadAt(buf, int64(len(want))-4); n != 4 || err != io.EOF {
+		t.Errorf("short ReadAt: %d, %v", n, err)
+	}
+
+	// Read slices the mapping and reads everything else into the buffer
+	var readBuf []byte
+	got, err := Read(m, 100, 10, &readBuf)
+	if err != nil || !bytes.Equal(got, want[100:110]) || readBuf != nil {
+		t.Errorf("Read from mapping: %q, %v", got, err)
+	}
+	got, err = Read(bytes.NewReader(want), 100, 10, &readBuf)
+	if err != nil || !bytes.Equal(got, want[100:110]) || cap(readBuf) < 10 {
+		t.Errorf("Read from reader: %q, %v", got, err)
+	}
+	if _, err := Read(bytes.NewReader(want), int64(len(want))-5, 10, &readBuf); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("short Read: %v", err)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenUnsupported(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{empty, dir} {
+		if _, err := Open(path); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("%s: expected ErrUnsupported, got %v", path, err)
+		}
+	}
+	if _, err := Open(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("missing file: %v", err)
+	}
+}
+
+func TestReadPastEnd(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 3*readStep)
+	var buf []byte
+	got, err := Read(bytes.NewReader(data), 1, int64(len(data))-1, &buf)
+	if err != nil || len(got) != len(data)-1 {
+		t.Fatalf("Read across steps: %d bytes, %v", len(got), err)
+	}
+
+	// A length far past the end fails without allocating it
+	buf = nil
+	if _, err := Read(bytes.NewReader(data), 0, 1<<50, &buf); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Read past the end: %v", err)
+	}
+	if cap(buf) > 4*len(data) {
+		t.Errorf("Read past the end grew the buffer to %d bytes", cap(buf))
+	}
+	if _, err := Read(bytes.NewReader(data), 0, -1, &buf); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("negative length: %v", err)
+	}
+}
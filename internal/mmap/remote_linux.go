@@ -0,0 +1,31 @@
+//go:build linux
+
+package mmap
+
+import (
+	"os"
+	"syscall"
+)
+
+// Filesystem magic numbers from statfs(2)
+var remoteFilesystems = map[uint32]bool{
+	0x6969:     true, // NFS
+	0x517b:     true, // SMB
+	0xff534d42: true, // CIFS
+	0xfe534d42: true, // SMB2
+	0x65735546: true, // FUSE (sshfs, rclone, ...)
+	0x564c:     true, // NCP
+	0x73757245: true, // Coda
+	0x47504653: true, // GPFS
+	0x0bd00bd0: true, // Lustre
+	0x00c36400: true, // CephFS
+}
+
+// isRemote reports whether f lives on a network filesystem
+func isRemote(f *os.File) bool {
+	var st syscall.Statfs_t
+	if err := syscall.Fstatfs(int(f.Fd()), &st); err != nil {
+		return false
+	}
+	return remoteFilesystems[uint32(st.Type)]
+}
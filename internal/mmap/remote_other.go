@@ -0,0 +1,11 @@
+//go:build !linux
+
+package mmap
+
+import "os"
+
+// isRemote reports whether f lives on a network filesystem; only Linux
+// tells us, elsewhere every regular file is mapped
+func isRemote(f *os.File) bool {
+	return false
+}
@@ -0,0 +1,258 @@
+// internal/parity/parity.go
+package parity
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/klauspost/reedsolomon"
+)
+
+// A parity section can follow the last byte of an archive. It holds
+// Reed-Solomon parity over the archive bytes, so damaged blocks can be
+// rebuilt in place. Archive readers find their own footer with
+// DataSize and never look at the section.
+//
+// The archive is cut into blocks of BlockSize bytes (the last one padded
+// with zeros). Blocks are grouped into stripes of DataShards blocks, each
+// protected by ParityShards parity blocks. Stripes are interleaved within a
+// group of GroupStripes stripes (block i of a group belongs to stripe
+// i % stripes), so a run of consecutive damaged blocks is spread over the
+// stripes of the group: a group survives a burst of up to
+// GroupStripes*ParityShards blocks.
+//
+// Section layout (little-endian):
+//
+//	Parity blocks: per group, per stripe, ParityShards blocks
+//	Checksums: CRC32C (4) of every data block, then of every parity block
+//	Trailer (36):
+//	  DataSize (8):      size of the archive the parity covers
+//	  BlockSize (4)
+//	  DataShards (2)
+//	  ParityShards (2)
+//	  GroupStripes (4)
+//	  ChecksumsCRC (4):  CRC32C of the checksum table
+//	  TrailerCRC (4):    CRC32C of the 24 bytes above
+//	  Magic (8):         "GDLTPAR1"
+const (
+	Magic       = "GDLTPAR1"
+	trailerSize = 36
+
+	// maxDataShards is the stripe width for archives large enough to fill it
+	maxDataShards = 200
+	// Block sizes grow with the archive between these bounds
+	minBlockSize = 512
+	maxBlockSize = 64 << 10
+	// groupBytes caps the archive bytes of a group, which bounds the parity
+	// held in memory while encoding
+	groupBytes = 256 << 20
+
+	// MaxPercent is the largest parity overhead
+	MaxPercent = 25
+)
+
+// ErrNoParity is returned for archives without a parity section, or whose
+// trailer is damaged
+var ErrNoParity = errors.New("archive has no parity")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Layout describes the parity section of an archive
+type Layout struct {
+	DataSize     int64
+	BlockSize    int
+	DataShards   int
+	ParityShards int
+	GroupStripes int
+}
+
+// newLayout picks the layout for dataSize bytes of archive and percent
+// parity overhead
+func newLayout(dataSize int64, percent int) Layout {
+	blockSize := (dataSize + maxDataShards - 1) / maxDataShards
+	blockSize = (blockSize + minBlockSize - 1) / minBlockSize * minBlockSize
+	blockSize = max(minBlockSize, min(maxBlockSize, blockSize))
+
+	l := Layout{DataSize: dataSize, BlockSize: int(blockSize)}
+	blocks := l.DataBlocks()
+	l.DataShards = max(1, min(maxDataShards, blocks))
+	l.ParityShards = (l.DataShards*percent + 99) / 100
+	l.ParityShards = max(1, min(l.ParityShards, l.DataShards, 256-l.DataShards))
+	stripes := (blocks + l.DataShards - 1) / l.DataShards
+	l.GroupStripes = max(1, min(stripes, groupBytes/(l.DataShards*l.BlockSize)))
+	return l
+}
+
+// DataBlocks returns the number of archive blocks
+func (l Layout) DataBlocks() int {
+	return int((l.DataSize + int64(l.BlockSize) - 1) / int64(l.BlockSize))
+}
+
+// groups returns the number of groups
+func (l Layout) groups() int {
+	span := l.GroupStripes * l.DataShards
+	return (l.DataBlocks() + span - 1) / span
+}
+
+// group returns the first data block of group g, its block count and its
+// stripe count (the last group may be smaller)
+func (l Layout) group(g int) (first, blocks, stripes int) {
+	span := l.GroupStripes * l.DataShards
+	first = g * span
+	blocks = min(span, l.DataBlocks()-first)
+	stripes = (blocks + l.DataShards - 1) / l.DataShards
+	return first, blocks, stripes
+}
+
+// ParityBlocks returns the number of parity blocks
+func (l Layout) ParityBlocks() int {
+	n := 0
+	for g := range l.groups() {
+		_, _, stripes := l.group(g)
+		n += stripes * l.ParityShards
+	}
+	return n
+}
+
+// SectionSize returns the size of the parity section
+func (l Layout) SectionSize() int64 {
+	return int64(l.ParityBlocks())*int64(l.BlockSize) + int64(l.DataBlocks()+l.ParityBlocks())*4 + trailerSize
+}
+
+// parityBlock returns the index of parity block j of stripe s in group g
+func (l Layout) parityBlock(g, s, j int) int {
+	return (g*l.GroupStripes+s)*l.ParityShards + j
+}
+
+// Percent returns the parity percentage the layout was picked for, give or
+// take rounding, to protect a rewritten archive the same way
+func (l Layout) Percent() int {
+	return min(MaxPercent, (l.ParityShards*100+l.DataShards-1)/l.DataShards)
+}
+
+// Overhead returns the size of the parity section relative to the archive,
+// in percent
+func (l Layout) Overhead() float64 {
+	if l.DataSize == 0 {
+		return 0
+	}
+	return float64(l.SectionSize()) * 100 / float64(l.DataSize)
+}
+
+// Write appends a parity section for the first dataSize bytes of f, with
+// roughly percent overhead, and returns its layout. f must be readable and
+// positioned anywhere; the section is written at dataSize.
+func Write(f *os.File, dataSize int64, percent int) (Layout, error) {
+	l := newLayout(dataSize, percent)
+	enc, err := reedsolomon.New(l.DataShards, l.ParityShards)
+	if err != nil {
+		return l, err
+	}
+
+	dataSums := make([]uint32, 0, l.DataBlocks())
+	paritySums := make([]uint32, 0, l.ParityBlocks())
+	block := make([]byte, l.BlockSize)
+	offset := dataSize
+
+	for g := range l.groups() {
+		first, blocks, stripes := l.group(g)
+		parity := make([][][]byte, stripes)
+		for s := range parity {
+			parity[s] = make([][]byte, l.ParityShards)
+			for j := range parity[s] {
+				parity[s][j] = make([]byte, l.BlockSize)
+			}
+		}
+
+		for i := range blocks {
+			if err := readBlock(f, l, first+i, block); err != nil {
+				return l, err
+			}
+			dataSums = append(dataSums, crc32.Checksum(block, castagnoli))
+			if err := enc.EncodeIdx(block, i/stripes, parity[i%stripes]); err != nil {
+				return l, fmt.Errorf("encode parity: %w", err)
+			}
+		}
+
+		for s := range parity {
+			for _, p := range parity[s] {
+				if _, err := f.WriteAt(p, offset); err != nil {
+					return l, fmt.Errorf("write parity: %w", err)
+				}
+				offset += int64(len(p))
+				paritySums = append(paritySums, crc32.Checksum(p, castagnoli))
+			}
+		}
+	}
+
+	table := make([]byte, 0, 4*(len(dataSums)+len(paritySums)))
+	for _, sum := range append(dataSums, paritySums...) {
+		table = binary.LittleEndian.AppendUint32(table, sum)
+	}
+	trailer := binary.LittleEndian.AppendUint64(nil, uint64(l.DataSize))
+	trailer = binary.LittleEndian.AppendUint32(trailer, uint32(l.BlockSize))
+	trailer = binary.LittleEndian.AppendUint16(trailer, uint16(l.DataShards))
+	trailer = binary.LittleEndian.AppendUint16(trailer, uint16(l.ParityShards))
+	trailer = binary.LittleEndian.AppendUint32(trailer, uint32(l.GroupStripes))
+	trailer = binary.LittleEndian.AppendUint32(trailer, crc32.Checksum(table, castagnoli))
+	trailer = binary.LittleEndian.AppendUint32(trailer, crc32.Checksum(trailer, castagnoli))
+	trailer = append(trailer, Magic...)
+
+	if _, err := f.WriteAt(append(table, trailer...), offset); err != nil {
+		return l, fmt.Errorf("write parity checksums: %w", err)
+	}
+	return l, nil
+}
+
+// readBlock reads data block i, zero-padding the last one
+func readBlock(r io.ReaderAt, l Layout, i int, block []byte) error {
+	off := int64(i) * int64(l.BlockSize)
+	n := min(int64(l.BlockSize), l.DataSize-off)
+	if _, err := r.ReadAt(block[:n], off); err != nil {
+		return fmt.Errorf("read block %d: %w", i, err)
+	}
+	clear(block[n:])
+	return nil
+}
+
+// ReadLayout reads the trailer of the parity section at the end of an
+// archive of the given size
+func ReadLayout(r io.ReaderAt, size int64) (Layout, error) {
+	var l Layout
+	if size < trailerSize {
+		return l, ErrNoParity
+	}
+	var trailer [trailerSize]byte
+	if _, err := r.ReadAt(trailer[:], size-trailerSize); err != nil {
+		return l, fmt.Errorf("read parity trailer: %w", err)
+	}
+	if string(trailer[28:]) != Magic || crc32.Checksum(trailer[:24], castagnoli) != binary.LittleEndian.Uint32(trailer[24:]) {
+		return l, ErrNoParity
+	}
+	l = Layout{
+		DataSize:     int64(binary.LittleEndian.Uint64(trailer[0:])),
+		BlockSize:    int(binary.LittleEndian.Uint32(trailer[8:])),
+		DataShards:   int(binary.LittleEndian.Uint16(trailer[12:])),
+		ParityShards: int(binary.LittleEndian.Uint16(trailer[14:])),
+		GroupStripes: int(binary.LittleEndian.Uint32(trailer[16:])),
+	}
+	if l.BlockSize < minBlockSize || l.DataShards < 1 || l.ParityShards < 1 || l.DataShards+l.ParityShards > 256 ||
+		l.GroupStripes < 1 || l.DataSize < 0 || l.DataSize+l.SectionSize() != size {
+		return l, ErrNoParity
+	}
+	return l, nil
+}
+
+// DataSize returns the size of the archive in front of its parity section,
+// or size when there is none
+func DataSize(r io.ReaderAt, size int64) int64 {
+	l, err := ReadLayout(r, size)
+	if err != nil {
+		return size
+	}
+	return l.DataSize
+}
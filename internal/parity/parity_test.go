@@ -0,0 +1,116 @@
+// internal/parity/parity_test.go
+package parity
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeArchive writes size random bytes followed by a parity section
+func writeArchive(t *testing.T, size int64, percent int) (*os.File, []byte) {
+	t.Helper()
+	data := make([]byte, size)
+	rand.New(rand.NewSource(size)).Read(data)
+	f, err := os.OpenFile(filepath.Join(t.TempDir(), "archive"), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	if _, err := f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Write(f, size, percent); err != nil {
+		t.Fatal(err)
+	}
+	return f, data
+}
+
+func fileSize(t *testing.T, f *os.File) int64 {
+	t.Helper()
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+func TestRepair(t *testing.T) {
+	for _, size := range []int64{50_000, 100_000, 3_000_017, 14_000_000} {
+		f, data := writeArchive(t, size, 10)
+		total := fileSize(t, f)
+		if got := DataSize(f, total); got != size {
+			t.Fatalf("size %d: DataSize = %d", size, got)
+		}
+
+		report, err := Check(f, total)
+		if err != nil || report.Damaged() {
+			t.Fatalf("size %d: intact archive reported %+v, %v", size, report, err)
+		}
+
+		// A burst at the start, a byte at the end and a parity block
+		burst := min(size, 3*int64(report.Layout.BlockSize))
+		if _, err := f.WriteAt(make([]byte, burst), 0); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.WriteAt([]byte{^data[size-1]}, size-1); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.WriteAt([]byte("junk"), size+10); err != nil {
+			t.Fatal(err)
+		}
+
+		report, err = Check(f, total)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.BadData) == 0 || len(report.BadParity) != 1 || !report.Repairable {
+			t.Fatalf("size %d: report %+v", size, report)
+		}
+		if err := Repair(f, report); err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+
+		got := make([]byte, size)
+		if _, err := f.ReadAt(got, 0); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("size %d: repaired data differs", size)
+		}
+		if report, err := Check(f, total); err != nil || report.Damaged() {
+			t.Fatalf("size %d: repaired archive reported %+v, %v", size, report, err)
+		}
+	}
+}
+
+func TestRepairBeyondParity(t *testing.T) {
+	f, _ := writeArchive(t, 200_000, 1)
+	total := fileSize(t, f)
+	if _, err := f.WriteAt(make([]byte, 100_000), 0); err != nil {
+		t.Fatal(err)
+	}
+	report, err := Check(f, total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Repairable {
+		t.Fatal("half the archive lost should not be repairable")
+	}
+	if err := Repair(f, report); err == nil {
+		t.Fatal("Repair should refuse")
+	}
+}
+
+func TestNoParity(t *testing.T) {
+	r := bytes.NewReader(bytes.Repeat([]byte("x"), 100))
+	if _, err := Check(r, 100); !errors.Is(err, ErrNoParity) {
+		t.Errorf("Check: got %v, want ErrNoParity", err)
+	}
+	if got := DataSize(r, 100); got != 100 {
+		t.Errorf("DataSize = %d, want 100", got)
+	}
+}
@@ -0,0 +1,213 @@
+// internal/parity/repair.go
+package parity
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/klauspost/reedsolomon"
+)
+
+// ErrDamagedChecksums is returned when the checksum table of a parity
+// section is damaged, so damaged blocks can't be located
+var ErrDamagedChecksums = errors.New("parity checksums are damaged")
+
+// Report lists the damaged blocks of an archive with parity
+type Report struct {
+	Layout Layout
+
+	// BadData are the indexes of damaged archive blocks; block i covers
+	// bytes [i*BlockSize, (i+1)*BlockSize) of the archive
+	BadData []int
+
+	// BadParity are the indexes of damaged parity blocks
+	BadParity []int
+
+	// Repairable is false when a stripe lost more blocks than it has parity
+	Repairable bool
+}
+
+// Damaged reports whether any block is damaged
+func (r *Report) Damaged() bool {
+	return len(r.BadData) > 0 || len(r.BadParity) > 0
+}
+
+// DamagedBytes returns the number of archive bytes in damaged blocks
+func (r *Report) DamagedBytes() int64 {
+	var n int64
+	for _, i := range r.BadData {
+		off := int64(i) * int64(r.Layout.BlockSize)
+		n += min(int64(r.Layout.BlockSize), r.Layout.DataSize-off)
+	}
+	return n
+}
+
+// Check compares every block of an archive of the given size and its parity
+// against the checksum table. It returns ErrNoParity when the archive has
+// no parity section.
+func Check(r io.ReaderAt, size int64) (*Report, error) {
+	l, err := ReadLayout(r, size)
+	if err != nil {
+		return nil, err
+	}
+	sums, err := readChecksums(r, l, size)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Layout: l}
+	block := make([]byte, l.BlockSize)
+	for i := range l.DataBlocks() {
+		if err := readBlock(r, l, i, block); err != nil {
+			return nil, err
+		}
+		if crc32.Checksum(block, castagnoli) != sums[i] {
+			report.BadData = append(report.BadData, i)
+		}
+	}
+	for i := range l.ParityBlocks() {
+		if _, err := r.ReadAt(block, l.DataSize+int64(i)*int64(l.BlockSize)); err != nil {
+			return nil, fmt.Errorf("read parity block %d: %w", i, err)
+		}
+		if crc32.Checksum(block, castagnoli) != sums[l.DataBlocks()+i] {
+			report.BadParity = append(report.BadParity, i)
+		}
+	}
+
+	report.Repairable = true
+	for _, lost := range stripeLosses(l, report) {
+		if lost > l.ParityShards {
+			report.Repairable = false
+			break
+		}
+	}
+	return report, nil
+}
+
+// readChecksums reads and checks the checksum table
+func readChecksums(r io.ReaderAt, l Layout, size int64) ([]uint32, error) {
+	n := l.DataBlocks() + l.ParityBlocks()
+	table := make([]byte, 4*n)
+	if _, err := r.ReadAt(table, size-trailerSize-int64(len(table))); err != nil {
+		return nil, fmt.Errorf("read parity checksums: %w", err)
+	}
+	var trailer [trailerSize]byte
+	if _, err := r.ReadAt(trailer[:], size-trailerSize); err != nil {
+		return nil, fmt.Errorf("read parity trailer: %w", err)
+	}
+	if crc32.Checksum(table, castagnoli) != binary.LittleEndian.Uint32(trailer[20:]) {
+		return nil, ErrDamagedChecksums
+	}
+	sums := make([]uint32, n)
+	for i := range sums {
+		sums[i] = binary.LittleEndian.Uint32(table[4*i:])
+	}
+	return sums, nil
+}
+
+// stripe identifies a stripe by its group and its index in the group
+type stripe struct{ group, index int }
+
+// stripeOf returns the stripe of data block i and its shard index there
+func (l Layout) stripeOf(i int) (stripe, int) {
+	span := l.GroupStripes * l.DataShards
+	g := i / span
+	_, _, stripes := l.group(g)
+	local := i - g*span
+	return stripe{g, local % stripes}, local / stripes
+}
+
+// stripeLosses counts the damaged blocks of every damaged stripe
+func stripeLosses(l Layout, report *Report) map[stripe]int {
+	lost := make(map[stripe]int)
+	for _, i := range report.BadData {
+		s, _ := l.stripeOf(i)
+		lost[s]++
+	}
+	for _, i := range report.BadParity {
+		lost[stripe{i / (l.GroupStripes * l.ParityShards), i / l.ParityShards % l.GroupStripes}]++
+	}
+	return lost
+}
+
+// Repair rebuilds the damaged blocks listed in report and writes them back
+// to f in place. The report must come from Check on the same file.
+func Repair(f *os.File, report *Report) error {
+	if !report.Repairable {
+		return errors.New("damage exceeds parity")
+	}
+	l := report.Layout
+	enc, err := reedsolomon.New(l.DataShards, l.ParityShards)
+	if err != nil {
+		return err
+	}
+
+	bad := make(map[int]bool, len(report.BadData))
+	for _, i := range report.BadData {
+		bad[i] = true
+	}
+	badParity := make(map[int]bool, len(report.BadParity))
+	for _, i := range report.BadParity {
+		badParity[i] = true
+	}
+
+	for s := range stripeLosses(l, report) {
+		first, blocks, stripes := l.group(s.group)
+		shards := make([][]byte, l.DataShards+l.ParityShards)
+		for k := range l.DataShards {
+			local := k*stripes + s.index
+			if local >= blocks {
+				// Padding shard past the end of the archive
+				shards[k] = make([]byte, l.BlockSize)
+				continue
+			}
+			if bad[first+local] {
+				continue
+			}
+			shards[k] = make([]byte, l.BlockSize)
+			if err := readBlock(f, l, first+local, shards[k]); err != nil {
+				return err
+			}
+		}
+		for j := range l.ParityShards {
+			p := l.parityBlock(s.group, s.index, j)
+			if badParity[p] {
+				continue
+			}
+			shards[l.DataShards+j] = make([]byte, l.BlockSize)
+			if _, err := f.ReadAt(shards[l.DataShards+j], l.DataSize+int64(p)*int64(l.BlockSize)); err != nil {
+				return fmt.Errorf("read parity block %d: %w", p, err)
+			}
+		}
+
+		if err := enc.Reconstruct(shards); err != nil {
+			return fmt.Errorf("reconstruct stripe: %w", err)
+		}
+
+		for k := range l.DataShards {
+			local := k*stripes + s.index
+			if local >= blocks || !bad[first+local] {
+				continue
+			}
+			off := int64(first+local) * int64(l.BlockSize)
+			n := min(int64(l.BlockSize), l.DataSize-off)
+			if _, err := f.WriteAt(shards[k][:n], off); err != nil {
+				return fmt.Errorf("write block %d: %w", first+local, err)
+			}
+		}
+		for j := range l.ParityShards {
+			p := l.parityBlock(s.group, s.index, j)
+			if !badParity[p] {
+				continue
+			}
+			if _, err := f.WriteAt(shards[l.DataShards+j], l.DataSize+int64(p)*int64(l.BlockSize)); err != nil {
+				return fmt.Errorf("write parity block %d: %w", p, err)
+			}
+		}
+	}
+	return f.Sync()
+}
@@ -0,0 +1,213 @@
+// internal/pool/pool.go
+package pool
+
+import (
+	"io"
+	"math/bits"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Pools shared by the compress, decompress and verify workers. Archives with
+// millions of small files otherwise allocate a copy buffer, a decoder and a
+// compressed-data buffer per entry, and spend their time in the GC.
+//
+// Pooled zstd encoders and decoders run without background goroutines when
+// idle (decoders have concurrency 1; encoders are closed or reset before
+// being returned), so the pool may drop them without a Close.
+
+// BufferSize is the size of the copy buffers. Large enough to keep the
+// syscall count low on multi-MB files without hurting small ones.
+const BufferSize = 256 * 1024
+
+var buffers = sync.Pool{
+	New: func() any {
+		buf := make([]byte, BufferSize)
+		return &buf
+	},
+}
+
+// Buffer returns a BufferSize buffer; give it back with PutBuffer
+func Buffer() *[]byte {
+	return buffers.Get().(*[]byte)
+}
+
+// PutBuffer returns a buffer obtained from Buffer
+func PutBuffer(buf *[]byte) {
+	buffers.Put(buf)
+}
+
+// Copy is io.Copy through a pooled buffer. Like io.Copy, it lets src or dst
+// do the copy when they implement io.WriterTo or io.ReaderFrom.
+func Copy(dst io.Writer, src io.Reader) (int64, error) {
+	buf := Buffer()
+	defer PutBuffer(buf)
+	return io.CopyBuffer(dst, src, *buf)
+}
+
+// Byte slices are pooled by power-of-two size class, from 4 KiB to 16 MiB.
+// Larger slices are allocated and left to the GC: they are rare, and
+// keeping them would pin that much memory per pooled slice.
+const (
+	minClassBits = 12
+	maxClassBits = 24
+)
+
+var slices [maxClassBits - minClassBits + 1]sync.Pool
+
+// sizeClass returns the class whose slices hold n bytes, or -1 if n is too
+// large to pool
+func sizeClass(n int) int {
+	if n <= 1<<minClassBits {
+		return 0
+	}
+	b := bits.Len(uint(n - 1))
+	if b > maxClassBits {
+		return -1
+	}
+	return b - minClassBits
+}
+
+// Bytes returns a slice of length n, with unspecified content; give it back
+// with PutBytes once nothing refers to it
+func Bytes(n int) []byte {
+	class := sizeClass(n)
+	if class < 0 {
+		return make([]byte, n)
+	}
+	if p, ok := slices[class].Get().(*[]byte); ok {
+		return (*p)[:n]
+	}
+	return make([]byte, n, 1<<(class+minClassBits))
+}
+
+// Scratch returns an empty slice with room for n bytes to append decoded
+// data to, or nil when n is too large to pool. n often comes from an
+// archive header, so it is never allocated up front beyond the pooled sizes.
+func Scratch(n int) []byte {
+	if sizeClass(n) < 0 {
+		return nil
+	}
+	return Bytes(n)[:0]
+}
+
+// PutBytes returns a slice obtained from Bytes (or any slice whose capacity
+// is a size class)
+func PutBytes(b []byte) {
+	c := cap(b)
+	class := sizeClass(c)
+	if class < 0 || c != 1<<(class+minClassBits) {
+		return
+	}
+	b = b[:0]
+	slices[class].Put(&b)
+}
+
+var decoders sync.Pool
+
+// Decoder returns a zstd decoder with concurrency 1 and no dictionary; give
+// it back with PutDecoder
+func Decoder() (*zstd.Decoder, error) {
+	if dec, ok := decoders.Get().(*zstd.Decoder); ok {
+		return dec, nil
+	}
+	return zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+}
+
+// PutDecoder returns a decoder obtained from Decoder, dropping its input
+func PutDecoder(dec *zstd.Decoder) {
+	dec.Reset(nil)
+	decoders.Put(dec)
+}
+
+// DictDecoder returns a zstd decoder with concurrency 1 for data compressed
+// with dictionary: a pooled one from Decoder when dictionary is empty, a new
+// one otherwise. release gives it back.
+func DictDecoder(dictionary []byte) (dec *zstd.Decoder, release func(), err error) {
+	if len(dictionary) == 0 {
+		if dec, err = Decoder(); err != nil {
+			return nil, nil, err
+		}
+		return dec, func() { PutDecoder(dec) }, nil
+	}
+	dec, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderDicts(dictionary))
+	if err != nil {
+		return nil, nil, err
+	}
+	return dec, dec.Close, nil
+}
+
+// DecoderReader is a pooled decoder reading from r. Closing it returns the
+// decoder to the pool.
+func DecoderReader(r io.Reader) (io.ReadCloser, error) {
+	dec, err := Decoder()
+	if err != nil {
+		return nil, err
+	}
+	if err := dec.Reset(r); err != nil {
+		PutDecoder(dec)
+		return nil, err
+	}
+	return &decoderReader{dec}, nil
+}
+
+type decoderReader struct {
+	dec *zstd.Decoder
+}
+
+func (r *decoderReader) Read(p []byte) (int, error) {
+	return r.dec.Read(p)
+}
+
+func (r *decoderReader) WriteTo(w io.Writer) (int64, error) {
+	return r.dec.WriteTo(w)
+}
+
+func (r *decoderReader) Close() error {
+	if r.dec != nil {
+		PutDecoder(r.dec)
+		r.dec = nil
+	}
+	return nil
+}
+
+// EncoderConfig identifies interchangeable zstd encoders. Encoders with a
+// dictionary are not pooled: each run trains its own.
+type EncoderConfig struct {
+	Level       int // zstd level, mapped with zstd.EncoderLevelFromZstd
+	Concurrency int // 0 or 1 for a single block encoder
+	Window      int // window size in bytes, 0 for the level default
+}
+
+var encoders sync.Map // EncoderConfig -> *sync.Pool
+
+func (c EncoderConfig) pool() *sync.Pool {
+	p, _ := encoders.LoadOrStore(c, &sync.Pool{})
+	return p.(*sync.Pool)
+}
+
+// Encoder returns an encoder for c and whether it had to be created; give it
+// back with PutEncoder. It writes nothing until Reset or EncodeAll.
+func (c EncoderConfig) Encoder() (enc *zstd.Encoder, created bool, err error) {
+	if enc, ok := c.pool().Get().(*zstd.Encoder); ok {
+		return enc, false, nil
+	}
+	opts := []zstd.EOption{
+		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(c.Level)),
+		zstd.WithZeroFrames(true),
+		zstd.WithEncoderConcurrency(max(c.Concurrency, 1)),
+	}
+	if c.Window > 0 {
+		opts = append(opts, zstd.WithWindowSize(c.Window))
+	}
+	enc, err = zstd.NewWriter(nil, opts...)
+	return enc, err == nil, err
+}
+
+// PutEncoder returns an encoder obtained from c.Encoder. A stream left open
+// is discarded by resetting the encoder.
+func (c EncoderConfig) PutEncoder(enc *zstd.Encoder) {
+	enc.Reset(nil)
+	c.pool().Put(enc)
+}
@@ -0,0 +1,154 @@
+// internal/pool/pool_test.go
+package pool
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestBytes(t *testing.T) {
+	for _, n := range []int{0, 1, 4096, 4097, 1 << 20, 16 << 20} {
+		b := Bytes(n)
+		if len(b) != n {
+			t.Fatalf("Bytes(%d): len %d", n, len(b))
+		}
+		if c := cap(b); c&(c-1) != 0 {
+			t.Errorf("Bytes(%d): capacity %d is not a size class", n, c)
+		}
+		PutBytes(b)
+	}
+
+	if b := Bytes(16<<20 + 1); cap(b) != 16<<20+1 {
+		t.Errorf("oversized slice should be allocated as is, got cap %d", cap(b))
+	}
+	if b := Scratch(1 << 40); b != nil {
+		t.Error("Scratch must not allocate sizes it can't pool")
+	}
+	if b := Scratch(100); len(b) != 0 || cap(b) < 100 {
+		t.Errorf("Scratch(100): len %d cap %d", len(b), cap(b))
+	}
+	PutBytes(make([]byte, 5000)) // not a size class: dropped
+}
+
+func TestEncoderDecoderReuse(t *testing.T) {
+	cfg := EncoderConfig{Level: 3}
+	data := []byte(strings.Repeat("pooled encoders and decoders ", 1000))
+
+	for i := range 3 {
+		enc, created, err := cfg.Encoder()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 && !created {
+			t.Log("encoder already pooled by another test")
+		}
+		var buf bytes.Buffer
+		enc.Reset(&buf)
+		if _, err := enc.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatal(err)
+		}
+		cfg.PutEncoder(enc)
+
+		r, err := DecoderReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("round %d: content mismatch", i)
+		}
+	}
+
+	// An abandoned stream is discarded, not carried into the next use
+	enc, _, _ := cfg.Encoder()
+	enc.Reset(io.Discard)
+	enc.Write(data)
+	cfg.PutEncoder(enc)
+	enc, _, _ = cfg.Encoder()
+	defer cfg.PutEncoder(enc)
+	dec, _ := Decoder()
+	defer PutDecoder(dec)
+	got, err := dec.DecodeAll(enc.EncodeAll(data, nil), nil)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("reused encoder: %v", err)
+	}
+}
+
+// The benchmarks compare a fresh allocation per entry, as the workers did
+// before pooling, with the pools, on entries the size of small source files
+
+var benchEntry = []byte(strings.Repeat("func main() { fmt.Println(\"hello\") }\n", 100))
+
+func BenchmarkDecoder(b *testing.B) {
+	enc, _ := zstd.NewWriter(nil)
+	frame := enc.EncodeAll(benchEntry, nil)
+
+	b.Run("fresh", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			dec, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+			dec.DecodeAll(frame, nil)
+			dec.Close()
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			dec, _ := Decoder()
+			out, _ := dec.DecodeAll(frame, Scratch(len(benchEntry)))
+			PutBytes(out)
+			PutDecoder(dec)
+		}
+	})
+}
+
+func BenchmarkEncoder(b *testing.B) {
+	b.Run("fresh", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+			enc.EncodeAll(benchEntry, nil)
+			enc.Close()
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		cfg := EncoderConfig{Level: 3}
+		b.ReportAllocs()
+		for b.Loop() {
+			enc, _, _ := cfg.Encoder()
+			out := enc.EncodeAll(benchEntry, Scratch(len(benchEntry)))
+			PutBytes(out)
+			cfg.PutEncoder(enc)
+		}
+	})
+}
+
+// onlyReader and onlyWriter hide ReadFrom/WriteTo so the copy buffer is used
+type onlyReader struct{ io.Reader }
+type onlyWriter struct{ io.Writer }
+
+func BenchmarkCopy(b *testing.B) {
+	b.Run("fresh", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			io.Copy(onlyWriter{io.Discard}, onlyReader{bytes.NewReader(benchEntry)})
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			Copy(onlyWriter{io.Discard}, onlyReader{bytes.NewReader(benchEntry)})
+		}
+	})
+}
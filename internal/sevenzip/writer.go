@@ -0,0 +1,336 @@
+// internal/sevenzip/writer.go
+
+// Package sevenzip writes 7z archives. Every entry goes into one solid LZMA2
+// folder, streamed as it is written; the header follows the packed data, and
+// the signature header at the start is patched on Close.
+package sevenzip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"unicode/utf16"
+
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// Signature starts every 7z archive
+var Signature = []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}
+
+// signatureHeaderSize is the size of the fixed header at the start of the
+// archive: signature, version, start header CRC and the start header
+const signatureHeaderSize = 32
+
+// Property IDs used in the archive header
+const (
+	idEnd             = 0x00
+	idHeader          = 0x01
+	idMainStreamsInfo = 0x04
+	idFilesInfo       = 0x05
+	idPackInfo        = 0x06
+	idUnpackInfo      = 0x07
+	idSubStreamsInfo  = 0x08
+	idSize            = 0x09
+	idCRC             = 0x0A
+	idFolder          = 0x0B
+	idCodersUnpack    = 0x0C
+	idNumUnpackStream = 0x0D
+	idEmptyStream     = 0x0E
+	idEmptyFile       = 0x0F
+	idName            = 0x11
+)
+
+// lzma2CoderID identifies the LZMA2 coder in a folder
+const lzma2CoderID = 0x21
+
+// errClosed is returned when writing to a closed Writer
+var errClosed = errors.New("sevenzip: writer closed")
+
+// entry is one file of the archive
+type entry struct {
+	name string
+	size uint64
+	crc  uint32
+}
+
+// Writer writes a 7z archive to an io.WriteSeeker
+type Writer struct {
+	w       io.WriteSeeker
+	start   int64 // offset of the signature header
+	dictCap int
+
+	packed  countingWriter
+	lz      *lzma.Writer2
+	crc     hash.Hash32 // of the current entry
+	entries []entry
+	closed  bool
+}
+
+// NewWriter starts an archive at the current offset of w. dictCap is the
+// LZMA2 dictionary size in bytes; extraction needs as much memory.
+func NewWriter(w io.WriteSeeker, dictCap int) (*Writer, error) {
+	start, err := w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	// Placeholder, rewritten once the header position is known
+	if _, err := w.Write(make([]byte, signatureHeaderSize)); err != nil {
+		return nil, err
+	}
+	return &Writer{
+		w:       w,
+		start:   start,
+		dictCap: dictCap,
+		packed:  countingWriter{w: w},
+		crc:     crc32.NewIEEE(),
+	}, nil
+}
+
+// Create adds a file entry named name (slash-separated) and returns a
+// writer for its content, valid until the next Create or Close. The entry's
+// size is what gets written to it.
+func (w *Writer) Create(name string) (io.Writer, error) {
+	if w.closed {
+		return nil, errClosed
+	}
+	w.finishEntry()
+	w.entries = append(w.entries, entry{name: name})
+	return entryWriter{w}, nil
+}
+
+// finishEntry records the checksum of the current entry
+func (w *Writer) finishEntry() {
+	if len(w.entries) > 0 {
+		w.entries[len(w.entries)-1].crc = w.crc.Sum32()
+	}
+	w.crc.Reset()
+}
+
+type entryWriter struct{ w *Writer }
+
+func (e entryWriter) Write(p []byte) (int, error) {
+	w := e.w
+	if w.closed {
+		return 0, errClosed
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if w.lz == nil {
+		lz, err := lzma.Writer2Config{DictCap: w.dictCap}.NewWriter2(&w.packed)
+		if err != nil {
+			return 0, err
+		}
+		w.lz = lz
+	}
+	n, err := w.lz.Write(p)
+	w.crc.Write(p[:n])
+	w.entries[len(w.entries)-1].size += uint64(n)
+	return n, err
+}
+
+// Close ends the packed stream, writes the header and patches the
+// signature header. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return errClosed
+	}
+	w.finishEntry()
+	w.closed = true
+	if w.lz != nil {
+		if err := w.lz.Close(); err != nil {
+			return err
+		}
+	}
+
+	header := w.header()
+	if _, err := w.w.Write(header); err != nil {
+		return err
+	}
+	end, err := w.w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	// Start header: offset and size of the header, relative to the end
+	// of the signature header
+	start := make([]byte, signatureHeaderSize)
+	copy(start, Signature)
+	start[7] = 4 // format version 0.4
+	binary.LittleEndian.PutUint64(start[12:], w.packed.n)
+	binary.LittleEndian.PutUint64(start[20:], uint64(len(header)))
+	binary.LittleEndian.PutUint32(start[28:], crc32.ChecksumIEEE(header))
+	binary.LittleEndian.PutUint32(start[8:], crc32.ChecksumIEEE(start[12:]))
+	if _, err := w.w.Seek(w.start, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(start); err != nil {
+		return err
+	}
+	_, err = w.w.Seek(end, io.SeekStart)
+	return err
+}
+
+// header encodes the archive header: the single folder and its
+// substreams (one per non-empty entry), then the file names
+func (w *Writer) header() []byte {
+	var b buffer
+	b.byte(idHeader)
+
+	var streams []entry
+	empty := make([]bool, len(w.entries))
+	for i, e := range w.entries {
+		if e.size == 0 {
+			empty[i] = true
+		} else {
+			streams = append(streams, e)
+		}
+	}
+
+	if len(streams) > 0 {
+		var unpacked uint64
+		for _, e := range streams {
+			unpacked += e.size
+		}
+
+		b.byte(idMainStreamsInfo)
+
+		b.byte(idPackInfo)
+		b.number(0) // pack position
+		b.number(1) // pack streams
+		b.byte(idSize)
+		b.number(w.packed.n)
+		b.byte(idEnd)
+
+		b.byte(idUnpackInfo)
+		b.byte(idFolder)
+		b.number(1) // folders
+		b.byte(0)   // not external
+		b.number(1) // coders
+		b.byte(0x20 | 1)
+		b.byte(lzma2CoderID)
+		b.number(1)
+		b.byte(lzma2DictProp(w.dictCap))
+		b.byte(idCodersUnpack)
+		b.number(unpacked)
+		b.byte(idEnd)
+
+		b.byte(idSubStreamsInfo)
+		b.byte(idNumUnpackStream)
+		b.number(uint64(len(streams)))
+		b.byte(idSize)
+		for _, e := range streams[:len(streams)-1] {
+			b.number(e.size)
+		}
+		b.byte(idCRC)
+		b.byte(1) // all defined
+		for _, e := range streams {
+			b.uint32(e.crc)
+		}
+		b.byte(idEnd)
+
+		b.byte(idEnd)
+	}
+
+	b.byte(idFilesInfo)
+	b.number(uint64(len(w.entries)))
+	if len(streams) < len(w.entries) {
+		emptyStream := bitVector(empty)
+		b.byte(idEmptyStream)
+		b.number(uint64(len(emptyStream)))
+		b.Write(emptyStream)
+
+		// Every empty stream is a file, not a directory
+		files := make([]bool, len(w.entries)-len(streams))
+		for i := range files {
+			files[i] = true
+		}
+		emptyFile := bitVector(files)
+		b.byte(idEmptyFile)
+		b.number(uint64(len(emptyFile)))
+		b.Write(emptyFile)
+	}
+
+	var names buffer
+	names.byte(0) // not external
+	for _, e := range w.entries {
+		for _, u := range utf16.Encode([]rune(e.name)) {
+			names.Write([]byte{byte(u), byte(u >> 8)})
+		}
+		names.Write([]byte{0, 0})
+	}
+	b.byte(idName)
+	b.number(uint64(names.Len()))
+	b.Write(names.Bytes())
+	b.byte(idEnd)
+
+	b.byte(idEnd)
+	return b.Bytes()
+}
+
+// lzma2DictProp encodes the smallest LZMA2 dictionary size holding dictCap:
+// 40 possible sizes of 2 or 3 times a power of two, from 4 KiB
+func lzma2DictProp(dictCap int) byte {
+	for p := byte(0); p < 40; p++ {
+		if uint64(2|p&1)<<(p/2+11) >= uint64(dictCap) {
+			return p
+		}
+	}
+	return 40
+}
+
+// bitVector packs bits most significant first
+func bitVector(bits []bool) []byte {
+	v := make([]byte, (len(bits)+7)/8)
+	for i, set := range bits {
+		if set {
+			v[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return v
+}
+
+// buffer adds the 7z encodings to bytes.Buffer
+type buffer struct{ bytes.Buffer }
+
+func (b *buffer) byte(v byte) { b.WriteByte(v) }
+
+func (b *buffer) uint32(v uint32) {
+	b.Write(binary.LittleEndian.AppendUint32(nil, v))
+}
+
+// number writes v in the 7z variable-length encoding: the leading one bits
+// of the first byte count the extra little-endian bytes that follow, and
+// its remaining bits hold the high part of v
+func (b *buffer) number(v uint64) {
+	first, mask := byte(0), byte(0x80)
+	n := 0
+	for ; n < 8; n++ {
+		if v < 1<<(7*(n+1)) {
+			first |= byte(v >> (8 * n))
+			break
+		}
+		first |= mask
+		mask >>= 1
+	}
+	b.WriteByte(first)
+	for ; n > 0; n-- {
+		b.WriteByte(byte(v))
+		v >>= 8
+	}
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n uint64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += uint64(n)
+	return n, err
+}
@@ -0,0 +1,129 @@
+// internal/sevenzip/writer_test.go
+package sevenzip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/ulikunitz/xz/lzma"
+)
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		v    uint64
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{0x7F, []byte{0x7F}},
+		{0x80, []byte{0x80, 0x80}},
+		{0x3FFF, []byte{0xBF, 0xFF}},
+		{0x4000, []byte{0xC0, 0x00, 0x40}},
+		{1 << 56, []byte{0xFF, 0, 0, 0, 0, 0, 0, 0, 1}},
+	}
+	for _, tt := range tests {
+		var b buffer
+		b.number(tt.v)
+		if !bytes.Equal(b.Bytes(), tt.want) {
+			t.Errorf("number(%#x) = % x, want % x", tt.v, b.Bytes(), tt.want)
+		}
+	}
+}
+
+func TestLZMA2DictProp(t *testing.T) {
+	tests := map[int]byte{4096: 0, 6144: 1, 8192: 2, 1 << 20: 16, 3 << 20: 19, 1<<20 + 1: 17, 1 << 26: 28}
+	for dictCap, want := range tests {
+		if got := lzma2DictProp(dictCap); got != want {
+			t.Errorf("lzma2DictProp(%d) = %d, want %d", dictCap, got, want)
+		}
+	}
+}
+
+// TestWriter checks the signature header against the header it points to
+// and decodes the packed stream back to the entries' content
+func TestWriter(t *testing.T) {
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"dir/a.txt", bytes.Repeat([]byte("solid lzma2 folder "), 5000)},
+		{"empty.txt", nil},
+		{"dir/sub/b.txt", []byte("bee")},
+		{"ünï.txt", []byte("unicode name")},
+	}
+
+	path := filepath.Join(t.TempDir(), "a.7z")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const dictCap = 1 << 20
+	w, err := NewWriter(f, dictCap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content []byte
+	for _, file := range files {
+		ew, err := w.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ew.Write(file.data); err != nil {
+			t.Fatal(err)
+		}
+		content = append(content, file.data...)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Create("late.txt"); err == nil {
+		t.Error("Create after Close succeeded")
+	}
+	f.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, Signature) {
+		t.Fatal("missing signature")
+	}
+	if crc32.ChecksumIEEE(data[12:32]) != binary.LittleEndian.Uint32(data[8:]) {
+		t.Error("start header CRC mismatch")
+	}
+	offset := binary.LittleEndian.Uint64(data[12:])
+	size := binary.LittleEndian.Uint64(data[20:])
+	if signatureHeaderSize+offset+size != uint64(len(data)) {
+		t.Fatalf("header at %d+%d doesn't end the %d byte archive", offset, size, len(data))
+	}
+	header := data[signatureHeaderSize+offset:]
+	if crc32.ChecksumIEEE(header) != binary.LittleEndian.Uint32(data[28:]) {
+		t.Error("header CRC mismatch")
+	}
+	for _, file := range files {
+		var name []byte
+		for _, u := range utf16.Encode([]rune(file.name)) {
+			name = append(name, byte(u), byte(u>>8))
+		}
+		if !bytes.Contains(header, name) {
+			t.Errorf("name %q missing from the header", file.name)
+		}
+	}
+
+	lr, err := lzma.Reader2Config{DictCap: dictCap}.NewReader2(bytes.NewReader(data[signatureHeaderSize : signatureHeaderSize+offset]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(lr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("packed stream decodes to %d bytes, want %d", len(got), len(content))
+	}
+}
@@ -0,0 +1,277 @@
+// internal/xzstream/writer.go
+
+// Package xzstream writes .xz streams whose blocks are compressed in
+// parallel. The input is cut into fixed-size blocks, each compressed
+// independently with LZMA2 by a pool of workers and written in order, with
+// its sizes in the block header and in the index, so readers can seek to a
+// block and decompress blocks in parallel too.
+package xzstream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"sync"
+
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// Stream header and footer magic
+var (
+	headerMagic = []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}
+	footerMagic = []byte{'Y', 'Z'}
+)
+
+const (
+	// checkCRC32 is the integrity check stored after each block
+	checkCRC32 = 0x01
+	// lzma2FilterID identifies the LZMA2 filter in a block header
+	lzma2FilterID = 0x21
+	// minDictCap is the smallest LZMA2 dictionary
+	minDictCap = 4096
+)
+
+// errClosed is returned when writing to a closed Writer
+var errClosed = errors.New("xzstream: writer closed")
+
+// block is one block on its way through the workers
+type block struct {
+	data       []byte
+	compressed []byte // the encoded block
+	unpadded   uint64 // its size without the block padding
+	err        error
+	done       chan struct{}
+}
+
+// indexRecord describes a written block for the stream index
+type indexRecord struct {
+	unpadded, uncompressed uint64
+}
+
+// Writer compresses what is written to it into an .xz stream
+type Writer struct {
+	w         io.Writer
+	dictCap   int
+	blockSize int
+
+	buf   []byte
+	jobs  chan *block
+	order chan *block
+	wg    sync.WaitGroup // workers
+	out   sync.WaitGroup // ordered writer
+
+	mu      sync.Mutex // guards err
+	err     error
+	records []indexRecord
+	closed  bool
+}
+
+// NewWriter starts an .xz stream on w. The input is cut into blocks of
+// blockSize bytes compressed by workers goroutines with an LZMA2
+// dictionary of dictCap bytes (at most blockSize). About 2*workers blocks
+// are held in memory at once.
+func NewWriter(w io.Writer, dictCap, blockSize, workers int) (*Writer, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	dictCap = max(min(dictCap, blockSize), minDictCap)
+
+	header := append([]byte(nil), headerMagic...)
+	flags := []byte{0x00, checkCRC32}
+	header = append(header, flags...)
+	header = binary.LittleEndian.AppendUint32(header, crc32.ChecksumIEEE(flags))
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	x := &Writer{
+		w:         w,
+		dictCap:   dictCap,
+		blockSize: blockSize,
+		jobs:      make(chan *block, workers),
+		order:     make(chan *block, workers),
+	}
+	for range workers {
+		x.wg.Add(1)
+		go func() {
+			defer x.wg.Done()
+			for b := range x.jobs {
+				b.compressed, b.unpadded, b.err = x.compressBlock(b.data)
+				close(b.done)
+			}
+		}()
+	}
+	x.out.Add(1)
+	go x.writeBlocks()
+	return x, nil
+}
+
+// Write buffers p, handing every full block to the workers
+func (x *Writer) Write(p []byte) (int, error) {
+	if x.closed {
+		return 0, errClosed
+	}
+	if err := x.failure(); err != nil {
+		return 0, err
+	}
+	n := len(p)
+	for len(p) > 0 {
+		if x.buf == nil {
+			x.buf = make([]byte, 0, x.blockSize)
+		}
+		c := min(len(p), x.blockSize-len(x.buf))
+		x.buf = append(x.buf, p[:c]...)
+		p = p[c:]
+		if len(x.buf) == x.blockSize {
+			x.submit()
+		}
+	}
+	return n, nil
+}
+
+// submit queues the buffered block; order keeps the write sequence while
+// jobs may be picked up in any order
+func (x *Writer) submit() {
+	b := &block{data: x.buf, done: make(chan struct{})}
+	x.buf = nil
+	x.order <- b
+	x.jobs <- b
+}
+
+// writeBlocks writes the compressed blocks in input order
+func (x *Writer) writeBlocks() {
+	defer x.out.Done()
+	for b := range x.order {
+		<-b.done
+		if x.failure() != nil {
+			continue
+		}
+		if b.err == nil {
+			_, b.err = x.w.Write(b.compressed)
+		}
+		if b.err != nil {
+			x.fail(b.err)
+			continue
+		}
+		x.records = append(x.records, indexRecord{unpadded: b.unpadded, uncompressed: uint64(len(b.data))})
+	}
+}
+
+// compressBlock encodes data as a complete xz block (header, LZMA2 data,
+// padding and CRC32 check) and returns it with its unpadded size
+func (x *Writer) compressBlock(data []byte) ([]byte, uint64, error) {
+	var lz bytes.Buffer
+	dictCap := max(min(x.dictCap, len(data)), minDictCap)
+	lw, err := lzma.Writer2Config{DictCap: dictCap}.NewWriter2(&lz)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := lw.Write(data); err != nil {
+		return nil, 0, err
+	}
+	if err := lw.Close(); err != nil {
+		return nil, 0, err
+	}
+
+	// Block header: size, flags (one filter, both sizes present), sizes,
+	// LZMA2 filter flags, padding to a multiple of 4 and CRC32
+	header := []byte{0, 0xC0}
+	header = appendVarint(header, uint64(lz.Len()))
+	header = appendVarint(header, uint64(len(data)))
+	header = append(header, lzma2FilterID, 1, dictProp(dictCap))
+	for len(header)%4 != 0 {
+		header = append(header, 0)
+	}
+	header[0] = byte((len(header)+4)/4 - 1)
+	header = binary.LittleEndian.AppendUint32(header, crc32.ChecksumIEEE(header))
+
+	padding := (4 - lz.Len()%4) % 4
+	out := make([]byte, 0, len(header)+lz.Len()+padding+4)
+	out = append(out, header...)
+	out = append(out, lz.Bytes()...)
+	out = append(out, make([]byte, padding)...)
+	out = binary.LittleEndian.AppendUint32(out, crc32.ChecksumIEEE(data))
+	return out, uint64(len(out) - padding), nil
+}
+
+// Close compresses the last block, waits for every block to be written
+// and ends the stream with the index and footer. It doesn't close the
+// underlying writer.
+func (x *Writer) Close() error {
+	if x.closed {
+		return errClosed
+	}
+	x.closed = true
+	if len(x.buf) > 0 {
+		x.submit()
+	}
+	close(x.jobs)
+	close(x.order)
+	x.wg.Wait()
+	x.out.Wait()
+	if err := x.failure(); err != nil {
+		return err
+	}
+
+	// Index: indicator, record count, records, padding, CRC32
+	index := []byte{0x00}
+	index = appendVarint(index, uint64(len(x.records)))
+	for _, r := range x.records {
+		index = appendVarint(index, r.unpadded)
+		index = appendVarint(index, r.uncompressed)
+	}
+	for len(index)%4 != 0 {
+		index = append(index, 0)
+	}
+	index = binary.LittleEndian.AppendUint32(index, crc32.ChecksumIEEE(index))
+
+	// Footer: CRC32, backward size, stream flags, magic
+	body := binary.LittleEndian.AppendUint32(nil, uint32(len(index)/4-1))
+	body = append(body, 0x00, checkCRC32)
+	footer := binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(body))
+	footer = append(footer, body...)
+	footer = append(footer, footerMagic...)
+
+	if _, err := x.w.Write(index); err != nil {
+		return err
+	}
+	_, err := x.w.Write(footer)
+	return err
+}
+
+func (x *Writer) fail(err error) {
+	x.mu.Lock()
+	if x.err == nil {
+		x.err = err
+	}
+	x.mu.Unlock()
+}
+
+func (x *Writer) failure() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.err
+}
+
+// dictProp encodes the smallest LZMA2 dictionary size holding dictCap:
+// 2 or 3 times a power of two, from 4 KiB
+func dictProp(dictCap int) byte {
+	for p := byte(0); p < 40; p++ {
+		if uint64(2|p&1)<<(p/2+11) >= uint64(dictCap) {
+			return p
+		}
+	}
+	return 40
+}
+
+// appendVarint appends v in the xz multibyte encoding: 7 bits per byte,
+// least significant first, high bit set on all but the last byte
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
@@ -0,0 +1,101 @@
+// internal/xzstream/writer_test.go
+package xzstream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/ulikunitz/xz"
+)
+
+func TestAppendVarint(t *testing.T) {
+	tests := []struct {
+		v    uint64
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{0x7F, []byte{0x7F}},
+		{0x80, []byte{0x80, 0x01}},
+		{300, []byte{0xAC, 0x02}},
+	}
+	for _, tt := range tests {
+		if got := appendVarint(nil, tt.v); !bytes.Equal(got, tt.want) {
+			t.Errorf("appendVarint(%d) = % x, want % x", tt.v, got, tt.want)
+		}
+	}
+}
+
+// TestWriter decodes streams of zero, one and many blocks, with more or
+// fewer workers than blocks
+func TestWriter(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := make([]byte, 100_000)
+	rng.Read(random)
+	mixed := append(bytes.Repeat([]byte("compressible text "), 20_000), random...)
+
+	tests := []struct {
+		data      []byte
+		blockSize int
+		workers   int
+		blocks    int
+	}{
+		{nil, 64 << 10, 2, 0},
+		{[]byte("short"), 64 << 10, 4, 1},
+		{mixed, 64 << 10, 1, (len(mixed) + 64<<10 - 1) / (64 << 10)},
+		{mixed, 64 << 10, 4, (len(mixed) + 64<<10 - 1) / (64 << 10)},
+		{mixed, 100_000, 3, (len(mixed) + 100_000 - 1) / 100_000},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d bytes/%d workers", len(tt.data), tt.workers), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, 1<<20, tt.blockSize, tt.workers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Uneven writes crossing block boundaries
+			for data := tt.data; len(data) > 0; {
+				n := min(len(data), 7777)
+				if _, err := w.Write(data[:n]); err != nil {
+					t.Fatal(err)
+				}
+				data = data[n:]
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte("late")); err == nil {
+				t.Error("Write after Close succeeded")
+			}
+			if len(w.records) != tt.blocks {
+				t.Errorf("%d blocks, want %d", len(w.records), tt.blocks)
+			}
+
+			// Backward size in the footer points at the index
+			stream := buf.Bytes()
+			if len(stream)%4 != 0 {
+				t.Errorf("stream size %d not a multiple of 4", len(stream))
+			}
+			footer := stream[len(stream)-12:]
+			indexSize := (int(binary.LittleEndian.Uint32(footer[4:])) + 1) * 4
+			if stream[len(stream)-12-indexSize] != 0x00 {
+				t.Error("backward size doesn't point at the index")
+			}
+
+			r, err := xz.NewReader(bytes.NewReader(stream))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Errorf("decoded %d bytes, want %d", len(got), len(tt.data))
+			}
+		})
+	}
+}
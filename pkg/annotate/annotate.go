@@ -0,0 +1,130 @@
+// pkg/annotate/annotate.go
+package annotate
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/creativeyann17/go-delta/pkg/timestamp"
+)
+
+// Update sets and removes annotations in the metadata field of a GDELTA02
+// or GDELTA03 archive (see compress.Options.Metadata) and returns the
+// metadata as written. The field is rewritten in place within the room it
+// reserved, so the archive body is left alone and older versions still
+// read it. Parity, if any, is computed again.
+func Update(path string, set map[string]string, unset []string) (*godelta.ArchiveMetadata, error) {
+	if _, err := os.Stat(timestamp.SidecarPath(path)); err == nil {
+		return nil, ErrTimestamped
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+
+	offset, value, err := locateMetadata(f)
+	if err != nil {
+		return nil, err
+	}
+	m, err := godelta.DecodeMetadata(value)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := maps.Clone(m.Annotations)
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	maps.Copy(annotations, set)
+	for _, key := range unset {
+		delete(annotations, key)
+	}
+	m.Annotations = annotations
+	if len(m.Annotations) == 0 {
+		m.Annotations = nil
+	}
+
+	data, err := godelta.EncodeMetadataPadded(m, len(value))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoRoom, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat archive: %w", err)
+	}
+	layout, err := parity.ReadLayout(f, info.Size())
+	hasParity := err == nil
+	if hasParity {
+		report, err := parity.Check(f, info.Size())
+		if err != nil {
+			return nil, err
+		}
+		if len(report.BadData) > 0 || len(report.BadParity) > 0 {
+			return nil, ErrDamagedParity
+		}
+	}
+
+	if _, err := f.WriteAt(data, offset); err != nil {
+		return nil, fmt.Errorf("write metadata: %w", err)
+	}
+	if hasParity {
+		if err := f.Truncate(layout.DataSize); err != nil {
+			return nil, fmt.Errorf("truncate archive: %w", err)
+		}
+		if _, err := parity.Write(f, layout.DataSize, layout.Percent()); err != nil {
+			return nil, fmt.Errorf("rewrite parity: %w", err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// locateMetadata returns the offset and the value of the metadata field in
+// the header of f
+func locateMetadata(f *os.File) (int64, []byte, error) {
+	magic := make([]byte, format.MagicSize)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return 0, nil, fmt.Errorf("read magic: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, nil, fmt.Errorf("seek to start: %w", err)
+	}
+
+	detected := format.DetectFormat(magic)
+	var exts []format.Extension
+	switch detected {
+	case format.FormatGDelta02:
+		header, err := format.ReadGDelta02Header(f)
+		if err != nil {
+			return 0, nil, godelta.WithCategory(godelta.ErrCorrupt, err)
+		}
+		exts = header.Extensions
+	case format.FormatGDelta03:
+		header, err := format.ReadGDelta03Header(f)
+		if err != nil {
+			return 0, nil, godelta.WithCategory(godelta.ErrCorrupt, err)
+		}
+		exts = header.Extensions
+	default:
+		return 0, nil, fmt.Errorf("%w: %s archives don't have one; recreate the archive as GDELTA02 or GDELTA03 with 'godelta compress --metadata --chunk-size ...' (or --dictionary)", ErrNoMetadata, detected)
+	}
+
+	// The header can't grow in place: the entries and offsets follow it
+	value, ok := format.FindExtension(exts, format.ExtMetadata)
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: the %s archive was made without --metadata or --label, and the area can't be added in place; recreate it with 'godelta compress --metadata' and the same options", ErrNoMetadata, detected)
+	}
+	areaOffset, _ := format.HeaderExtensionsOffset(detected)
+	valueOffset, _ := format.ExtensionValueOffset(exts, format.ExtMetadata)
+	return areaOffset + valueOffset, value, nil
+}
@@ -0,0 +1,100 @@
+// pkg/annotate/annotate_test.go
+package annotate_test
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/annotate"
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+func compressWithMetadata(t *testing.T, opts *compress.Options) string {
+	t.Helper()
+	sourceDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte(strings.Repeat("alpha ", 200)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts.InputPath = sourceDir
+	opts.OutputPath = filepath.Join(t.TempDir(), "annotated.gdelta")
+	opts.Quiet = true
+	if _, err := compress.Compress(opts, nil); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	return opts.OutputPath
+}
+
+func TestUpdate(t *testing.T) {
+	modes := map[string]*compress.Options{
+		"GDELTA02":        {ChunkSize: 64 * 1024},
+		"GDELTA03":        {UseDictionary: true},
+		"GDELTA02+parity": {ChunkSize: 64 * 1024, ParityPercent: 10},
+	}
+	for name, opts := range modes {
+		t.Run(name, func(t *testing.T) {
+			opts.Metadata = &godelta.ArchiveMetadata{Labels: map[string]string{"env": "prod"}}
+			path := compressWithMetadata(t, opts)
+			before, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := annotate.Update(path, map[string]string{"note": "pre-migration snapshot", "ticket": "OPS-1"}, nil); err != nil {
+				t.Fatalf("annotate: %v", err)
+			}
+			if _, err := annotate.Update(path, nil, []string{"ticket"}); err != nil {
+				t.Fatalf("unset: %v", err)
+			}
+
+			m, err := verify.ReadMetadata(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := map[string]string{"note": "pre-migration snapshot"}; !maps.Equal(m.Annotations, want) {
+				t.Errorf("annotations %v, want %v", m.Annotations, want)
+			}
+			if m.Labels["env"] != "prod" {
+				t.Errorf("labels %v lost", m.Labels)
+			}
+			// Parity is computed again and may be sized a little differently
+			if after, _ := os.Stat(path); opts.ParityPercent == 0 && after.Size() != before.Size() {
+				t.Errorf("archive size changed from %d to %d", before.Size(), after.Size())
+			}
+
+			result, err := verify.Verify(&verify.Options{InputPath: path, VerifyData: true}, nil)
+			if err != nil || !result.IsValid() {
+				t.Fatalf("verify after annotate: %v\n%s", err, result.Summary())
+			}
+		})
+	}
+}
+
+func TestUpdateErrors(t *testing.T) {
+	path := compressWithMetadata(t, &compress.Options{ChunkSize: 64 * 1024, Metadata: &godelta.ArchiveMetadata{}})
+	big := map[string]string{"note": strings.Repeat("x", godelta.MetadataRoom+1)}
+	if _, err := annotate.Update(path, big, nil); !errors.Is(err, annotate.ErrNoRoom) {
+		t.Errorf("oversized annotation: got %v, want ErrNoRoom", err)
+	}
+
+	plain := compressWithMetadata(t, &compress.Options{})
+	if _, err := annotate.Update(plain, map[string]string{"note": "x"}, nil); !errors.Is(err, annotate.ErrNoMetadata) || !strings.Contains(err.Error(), "GDELTA01 archives don't have one") {
+		t.Errorf("GDELTA01: got %v, want ErrNoMetadata for the format", err)
+	}
+	bare := compressWithMetadata(t, &compress.Options{ChunkSize: 64 * 1024})
+	if _, err := annotate.Update(bare, map[string]string{"note": "x"}, nil); !errors.Is(err, annotate.ErrNoMetadata) || !strings.Contains(err.Error(), "made without --metadata") {
+		t.Errorf("GDELTA02 without metadata: got %v, want ErrNoMetadata for the archive", err)
+	}
+
+	if err := os.WriteFile(path+".tsr", []byte("token"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := annotate.Update(path, map[string]string{"note": "x"}, nil); !errors.Is(err, annotate.ErrTimestamped) {
+		t.Errorf("timestamped: got %v, want ErrTimestamped", err)
+	}
+}
@@ -0,0 +1,24 @@
+// pkg/annotate/errors.go
+package annotate
+
+import "github.com/creativeyann17/go-delta/pkg/godelta"
+
+// Every error matches a godelta error category with errors.Is
+var (
+	// ErrNoMetadata is returned for archives without a metadata field:
+	// GDELTA01, ZIP, XZ and archives compressed without --metadata. The
+	// error says which, and how to make an archive that has one.
+	ErrNoMetadata = godelta.NewError(godelta.ErrUnsupported, "archive has no metadata area to hold annotations")
+
+	// ErrNoRoom is returned when the annotations don't fit in the space the
+	// metadata field reserved
+	ErrNoRoom = godelta.NewError(godelta.ErrUnsupported, "annotations don't fit in the archive's metadata field")
+
+	// ErrTimestamped is returned for archives with a trusted timestamp,
+	// which any change to the archive would invalidate
+	ErrTimestamped = godelta.NewError(godelta.ErrExists, "archive has a trusted timestamp that annotating would invalidate (remove its .tsr first)")
+
+	// ErrDamagedParity is returned when the parity of the archive reports
+	// damage: rewriting it would make the damage permanent
+	ErrDamagedParity = godelta.NewError(godelta.ErrCorrupt, "archive is damaged, run 'godelta repair' before annotating")
+)
@@ -0,0 +1,373 @@
+// pkg/archive/archive.go
+
+// Package archive reads .gdelta archives: it lists their entries and
+// streams each one's original content, for tools that consume archives
+// without extracting them.
+//
+// The package is the stable, read-only face of the archive formats, which
+// are otherwise internal to go-delta. Its exported API only grows: names
+// and behavior documented here stay as they are across releases, and every
+// archive written by an earlier release of go-delta stays readable.
+// Archives using a feature newer than the reader fail to open with an
+// error matching godelta.ErrUnsupported rather than being misread.
+//
+//	a, err := archive.Open("backup.gdelta")
+//	if err != nil {
+//		return err
+//	}
+//	defer a.Close()
+//	for _, e := range a.Entries() {
+//		r, err := e.Open()
+//		...
+//	}
+//
+// An Archive and its entries may be used from several goroutines; each
+// reader returned by Entry.Open is read by one at a time.
+package archive
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Format is the layout of an archive
+type Format string
+
+const (
+	FormatGDelta01 Format = "GDELTA01" // Files compressed one by one
+	FormatGDelta02 Format = "GDELTA02" // Files split into deduplicated chunks
+	FormatGDelta03 Format = "GDELTA03" // Files compressed with a shared dictionary
+)
+
+// Archive is an open .gdelta archive
+type Archive struct {
+	f       *os.File
+	format  Format
+	entries []*Entry
+
+	// dictionary decodes the zstd data of a GDELTA03 archive, or the
+	// chunks of a GDELTA02 archive written with a chunk dictionary
+	dictionary []byte
+
+	// GDELTA02 chunks, by hash and in archive order
+	chunkIndex     map[[32]byte]format.ChunkInfo
+	chunks         []Chunk
+	chunkDataStart int64
+	codec          format.Method
+	decoder        *zstd.Decoder
+
+	// Last GDELTA01 pack read, for the packed files after it
+	mu       sync.Mutex
+	pack     *format.FileEntry
+	packData []byte
+}
+
+// Entry is a file of an archive
+type Entry struct {
+	Path string // Slash-separated path in the archive
+	Size uint64 // Size of the original content
+
+	// CompressedSize is the size of the entry's own data in the archive: 0
+	// for a link, a file packed with others, and GDELTA02 files, whose
+	// chunks are shared (see Chunks)
+	CompressedSize uint64
+
+	// Link marks a duplicate of an earlier file, stored once: it opens to
+	// that file's content
+	Link bool
+
+	archive *Archive
+	file    *format.FileEntry // GDELTA01 and GDELTA03
+	hashes  [][32]byte        // GDELTA02
+}
+
+// Chunk is a piece of GDELTA02 file content, stored once however many
+// files share it
+type Chunk struct {
+	Hash           [32]byte // BLAKE3-256 of the chunk's content
+	Size           uint64   // Size of the content
+	CompressedSize uint64   // Size in the archive
+	Stored         bool     // Kept uncompressed, compression not making it smaller
+}
+
+// Open opens the archive at path and reads its entry list. Damaged
+// archives are refused with ErrDamaged; ZIP and tar.xz archives with
+// ErrUnsupportedFormat.
+func Open(path string) (*Archive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	a, err := newArchive(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+func newArchive(f *os.File) (*Archive, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat archive file: %w", err)
+	}
+	size := parity.DataSize(f, info.Size())
+
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return nil, fmt.Errorf("%w: read magic: %v", ErrUnsupportedFormat, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek to start: %w", err)
+	}
+
+	a := &Archive{f: f}
+	switch detected := format.DetectFormat(magic); detected {
+	case format.FormatGDelta01:
+		a.format = FormatGDelta01
+		err = a.readGDelta01(size)
+	case format.FormatGDelta02:
+		a.format = FormatGDelta02
+		err = a.readGDelta02(size)
+	case format.FormatGDelta03:
+		a.format = FormatGDelta03
+		err = a.readGDelta03(size)
+	default:
+		return nil, fmt.Errorf("%w (got %s)", ErrUnsupportedFormat, detected)
+	}
+	if err != nil {
+		if a.decoder != nil {
+			a.decoder.Close()
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+// Close closes the archive. Readers of its entries fail afterwards.
+func (a *Archive) Close() error {
+	if a.decoder != nil {
+		a.decoder.Close()
+	}
+	return a.f.Close()
+}
+
+// Format returns the layout of the archive
+func (a *Archive) Format() Format {
+	return a.format
+}
+
+// Entries returns the files of the archive in archive order
+func (a *Archive) Entries() []*Entry {
+	return slices.Clone(a.entries)
+}
+
+// Chunks returns the chunks of a GDELTA02 archive in archive order, and
+// nil for the other formats
+func (a *Archive) Chunks() []Chunk {
+	return slices.Clone(a.chunks)
+}
+
+// Chunks returns the chunks making up the content of a GDELTA02 entry in
+// order, and nil for the other formats
+func (e *Entry) Chunks() []Chunk {
+	if e.hashes == nil {
+		return nil
+	}
+	chunks := make([]Chunk, len(e.hashes))
+	for i, hash := range e.hashes {
+		chunks[i] = newChunk(e.archive.chunkIndex[hash])
+	}
+	return chunks
+}
+
+// Open returns a reader over the original content of the entry. Content
+// that doesn't decode to Size bytes fails with ErrCorruptData.
+func (e *Entry) Open() (io.ReadCloser, error) {
+	if e.archive.format == FormatGDelta02 {
+		return &sizedReader{ReadCloser: &chunkReader{archive: e.archive, hashes: e.hashes}, left: e.Size}, nil
+	}
+
+	// A link reads its target's data
+	fe := e.file.Data()
+	if fe == nil {
+		return nil, fmt.Errorf("%s: %w", e.Path, ErrLinkTarget)
+	}
+	var r io.ReadCloser
+	if fe.Packed {
+		data, err := e.archive.readPacked(fe)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Path, err)
+		}
+		r = io.NopCloser(bytes.NewReader(data))
+	} else {
+		var err error
+		if r, err = e.archive.openData(fe); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Path, err)
+		}
+	}
+	return &sizedReader{ReadCloser: r, left: e.Size}, nil
+}
+
+func newChunk(info format.ChunkInfo) Chunk {
+	return Chunk{
+		Hash:           info.Hash,
+		Size:           info.OriginalSize,
+		CompressedSize: info.CompressedSize,
+		Stored:         info.Stored,
+	}
+}
+
+// addEntries lists the files of a GDELTA01 or GDELTA03 archive
+func (a *Archive) addEntries(files []*format.FileEntry) {
+	a.entries = make([]*Entry, len(files))
+	for i, fe := range files {
+		e := &Entry{
+			Path:    fe.Path,
+			Size:    fe.OriginalSize,
+			Link:    fe.Link,
+			archive: a,
+			file:    fe,
+		}
+		if !fe.Link && !fe.Packed {
+			e.CompressedSize = fe.CompressedSize
+		}
+		a.entries[i] = e
+	}
+}
+
+// scanError reports an archive whose entries stop before the footer
+func scanError(scan *format.ScanResult) error {
+	if scan.Reason == "" {
+		return nil
+	}
+	return fmt.Errorf("%w: %d of %d files intact, intact data ends at offset %d (%s)",
+		ErrDamaged, scan.Intact, scan.Declared, scan.LogicalEnd, scan.Reason)
+}
+
+func (a *Archive) readGDelta01(size int64) error {
+	files, scan, err := format.ListGDelta01(a.f, size)
+	if err != nil {
+		return fmt.Errorf("read archive: %w", err)
+	}
+	if err := scanError(scan); err != nil {
+		return err
+	}
+	files, _ = format.SplitPacks(files)
+	a.addEntries(files)
+	return nil
+}
+
+func (a *Archive) readGDelta03(size int64) error {
+	header, err := format.ReadGDelta03Header(a.f)
+	if err != nil {
+		return fmt.Errorf("read GDELTA03 header: %w", err)
+	}
+	if header.Version != format.GDELTA03Version {
+		return fmt.Errorf("%w: GDELTA03 version %d", ErrUnsupportedFormat, header.Version)
+	}
+	if int64(header.DictSize) > size {
+		return fmt.Errorf("%w: dictionary of %d bytes past the end of the archive", ErrDamaged, header.DictSize)
+	}
+	a.dictionary = make([]byte, header.DictSize)
+	if _, err := io.ReadFull(a.f, a.dictionary); err != nil {
+		return fmt.Errorf("read dictionary: %w", err)
+	}
+
+	files, scan, err := format.ScanGDelta03(a.f, size)
+	if err != nil {
+		return fmt.Errorf("read archive: %w", err)
+	}
+	if err := scanError(scan); err != nil {
+		return err
+	}
+	a.addEntries(files)
+	return nil
+}
+
+func (a *Archive) readGDelta02(size int64) error {
+	header, err := format.ReadGDelta02Header(a.f)
+	if err != nil {
+		return fmt.Errorf("read GDELTA02 header: %w", err)
+	}
+	if header.Features&format.FeatureReferenceChunks != 0 {
+		return ErrReferenceChunks
+	}
+	a.chunkIndex, err = format.ReadChunkIndex(a.f, header.ChunkCount)
+	if errors.Is(err, format.ErrMalformed) {
+		return fmt.Errorf("%w: read chunk index: %v", ErrCorruptData, err)
+	} else if err != nil {
+		return fmt.Errorf("read chunk index: %w", err)
+	}
+	metadata := make([]format.FileMetadata, 0, format.CapHint(header.FileCount))
+	for i := range header.FileCount {
+		m, err := format.ReadFileMetadata(a.f, header.Extended)
+		if err != nil {
+			return fmt.Errorf("read file metadata %d: %w", i, err)
+		}
+		metadata = append(metadata, m)
+	}
+	if a.chunkDataStart, err = a.f.Seek(0, io.SeekCurrent); err != nil {
+		return fmt.Errorf("get chunk data start: %w", err)
+	}
+	// Chunks are read with their recorded size: refuse any said to lie past
+	// the end of the archive
+	for hash, info := range a.chunkIndex {
+		if end := uint64(max(size-a.chunkDataStart, 0)); info.Offset > end || info.CompressedSize > end-info.Offset {
+			return fmt.Errorf("%w: chunk %x of %d bytes at %d runs past the end of the archive",
+				ErrCorruptData, hash[:8], info.CompressedSize, info.Offset)
+		}
+	}
+
+	metadata, scan := format.IntactGDelta02Files(a.f, a.chunkIndex, nil, metadata, a.chunkDataStart, size)
+	if err := scanError(scan); err != nil {
+		return err
+	}
+
+	a.codec = header.Codec.Method()
+	a.dictionary = header.ChunkDictionary()
+	if a.codec == format.MethodZstd {
+		var opts []zstd.DOption
+		if len(a.dictionary) > 0 {
+			opts = append(opts, zstd.WithDecoderDicts(a.dictionary))
+		}
+		if a.decoder, err = zstd.NewReader(nil, opts...); err != nil {
+			return fmt.Errorf("create zstd decoder: %w", err)
+		}
+	}
+
+	infos := make([]format.ChunkInfo, 0, len(a.chunkIndex))
+	for _, info := range a.chunkIndex {
+		infos = append(infos, info)
+	}
+	slices.SortFunc(infos, func(x, y format.ChunkInfo) int { return cmp.Compare(x.Offset, y.Offset) })
+	a.chunks = make([]Chunk, len(infos))
+	for i, info := range infos {
+		a.chunks[i] = newChunk(info)
+	}
+
+	a.entries = make([]*Entry, len(metadata))
+	for i, m := range metadata {
+		hashes := m.ChunkHashes
+		if hashes == nil {
+			hashes = [][32]byte{}
+		}
+		a.entries[i] = &Entry{
+			Path:    m.RelPath,
+			Size:    m.OrigSize,
+			archive: a,
+			hashes:  hashes,
+		}
+	}
+	return nil
+}
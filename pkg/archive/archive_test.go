@@ -0,0 +1,215 @@
+// pkg/archive/archive_test.go
+package archive_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/pkg/archive"
+	"github.com/creativeyann17/go-delta/pkg/compress"
+)
+
+// TestOpen reads every entry of an archive in each GDELTA format back to
+// its original content, packed files and links included
+func TestOpen(t *testing.T) {
+	input := t.TempDir()
+	files := map[string]string{
+		"docs/readme.txt": strings.Repeat("read me first. ", 2000),
+		"docs/copy.txt":   strings.Repeat("read me first. ", 2000),
+		"small/a.txt":     "a",
+		"small/b.txt":     "bb",
+		"empty.txt":       "",
+		"data.bin":        strings.Repeat("0123456789abcdef", 8000),
+	}
+	for name, data := range files {
+		path := filepath.Join(input, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		format archive.Format
+		links  int
+		mode   func(*compress.Options)
+	}{
+		"gdelta01": {archive.FormatGDelta01, 1, func(o *compress.Options) { o.PackSmallFiles = 1 << 10; o.DedupFiles = true }},
+		"lz4":      {archive.FormatGDelta01, 0, func(o *compress.Options) { o.Codec = "lz4" }},
+		"gdelta02": {archive.FormatGDelta02, 0, func(o *compress.Options) { o.ChunkSize = 4 << 10; o.ChunkDictionary = true }},
+		"gdelta03": {archive.FormatGDelta03, 1, func(o *compress.Options) { o.UseDictionary = true; o.DedupFiles = true }},
+	} {
+		t.Run(name, func(t *testing.T) {
+			opts := &compress.Options{
+				InputPath:  input,
+				OutputPath: filepath.Join(t.TempDir(), "backup.gdelta"),
+				MaxThreads: 2,
+				Quiet:      true,
+			}
+			tc.mode(opts)
+			if _, err := compress.Compress(opts, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			a, err := archive.Open(opts.OutputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer a.Close()
+			if a.Format() != tc.format {
+				t.Errorf("format %s, want %s", a.Format(), tc.format)
+			}
+
+			entries := a.Entries()
+			if len(entries) != len(files) {
+				t.Fatalf("%d entries, want %d", len(entries), len(files))
+			}
+			var links int
+			for _, e := range entries {
+				if e.Link {
+					links++
+				}
+				want, ok := files[e.Path]
+				if !ok || e.Size != uint64(len(want)) {
+					t.Errorf("entry %s of %d bytes not in the input", e.Path, e.Size)
+					continue
+				}
+				r, err := e.Open()
+				if err != nil {
+					t.Fatalf("%s: %v", e.Path, err)
+				}
+				got, err := io.ReadAll(r)
+				r.Close()
+				if err != nil || string(got) != want {
+					t.Errorf("%s: content mismatch (%v)", e.Path, err)
+				}
+
+				var chunked uint64
+				for _, c := range e.Chunks() {
+					chunked += c.Size
+				}
+				if tc.format == archive.FormatGDelta02 && chunked != e.Size {
+					t.Errorf("%s: chunks hold %d of %d bytes", e.Path, chunked, e.Size)
+				}
+			}
+
+			if links != tc.links {
+				t.Errorf("%d links, want %d", links, tc.links)
+			}
+			if chunks := a.Chunks(); (len(chunks) > 0) != (tc.format == archive.FormatGDelta02) {
+				t.Errorf("%d chunks in a %s archive", len(chunks), tc.format)
+			}
+		})
+	}
+}
+
+func TestOpenUnsupported(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"zip":   "PK\x03\x04 then the rest of a ZIP",
+		"text":  "not an archive at all",
+		"short": "GDELTA",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Open(path); !errors.Is(err, archive.ErrUnsupportedFormat) {
+			t.Errorf("%s: got %v, want ErrUnsupportedFormat", name, err)
+		}
+	}
+}
+
+// TestOpenCorrupt flips a byte in the last chunk of a GDELTA02 archive:
+// reading it fails with ErrCorruptData instead of returning other content
+func TestOpenCorrupt(t *testing.T) {
+	input := t.TempDir()
+	content := strings.Repeat("chunk data ", 1000)
+	if err := os.WriteFile(filepath.Join(input, "a.txt"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "backup.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: input, OutputPath: output, ChunkSize: 4 << 10, Level: 1, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := archive.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := a.Chunks()
+	a.Close()
+	if len(chunks) == 0 {
+		t.Fatal("no chunks")
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The footer follows the chunk data: damage the last chunk's last byte
+	data[len(data)-len("ENDGDLT2")-1] ^= 0xff
+	if err := os.WriteFile(output, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err = archive.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	r, err := a.Entries()[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := io.ReadAll(r); !errors.Is(err, archive.ErrCorruptData) {
+		t.Errorf("got %v, want ErrCorruptData", err)
+	}
+}
+
+// TestOpenOversizedChunk sets the compressed size of a GDELTA02 chunk past
+// the end of the archive: Open fails with ErrCorruptData instead of the
+// chunk being allocated when read
+func TestOpenOversizedChunk(t *testing.T) {
+	input := t.TempDir()
+	if err := os.WriteFile(filepath.Join(input, "a.txt"), []byte("some content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "backup.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: input, OutputPath: output, ChunkSize: 4 << 10, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout, _ := format.Describe(bytes.NewReader(data), int64(len(data)), 1)
+	i := slices.IndexFunc(layout.Fields, func(f format.Field) bool { return f.Name == "chunk_index[0].comp_size" })
+	if i < 0 {
+		t.Fatal("no chunk_index[0].comp_size field")
+	}
+
+	for _, compSize := range []uint64{1 << 40, 1<<63 - 16} {
+		binary.LittleEndian.PutUint64(data[layout.Fields[i].Offset:], compSize)
+		if err := os.WriteFile(output, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if a, err := archive.Open(output); !errors.Is(err, archive.ErrCorruptData) {
+			if err == nil {
+				a.Close()
+			}
+			t.Errorf("comp_size %d: got %v, want ErrCorruptData", compSize, err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// pkg/archive/errors.go
+package archive
+
+import "github.com/creativeyann17/go-delta/pkg/godelta"
+
+// Every error matches a godelta error category with errors.Is
+var (
+	// ErrUnsupportedFormat is returned when opening anything but a GDELTA
+	// archive; ZIP and tar.xz archives are read with archive/zip and an xz
+	// reader
+	ErrUnsupportedFormat = godelta.NewError(godelta.ErrUnsupported, "not a GDELTA archive")
+
+	// ErrDamaged is returned when opening an archive whose entries stop
+	// before the footer
+	ErrDamaged = godelta.NewError(godelta.ErrCorrupt, "archive is damaged")
+
+	// ErrUnknownMethod is returned when opening an entry compressed with a
+	// method this version can't decode
+	ErrUnknownMethod = godelta.NewError(godelta.ErrUnsupported, "unknown compression method")
+
+	// ErrLinkTarget is returned when opening a deduplicated file whose
+	// linked entry isn't in the archive
+	ErrLinkTarget = godelta.NewError(godelta.ErrCorrupt, "linked file not found in the archive")
+
+	// ErrCorruptData is returned by an entry reader whose data doesn't
+	// decode to the recorded content, and when opening a GDELTA02 archive
+	// whose chunk index places chunks past the end of the archive
+	ErrCorruptData = godelta.NewError(godelta.ErrCorrupt, "entry data is corrupt")
+
+	// ErrReferenceChunks is returned when opening a GDELTA02 archive that
+	// takes chunks from reference directories, which only decompress reads
+	ErrReferenceChunks = godelta.NewError(godelta.ErrUnsupported, "archive takes chunks from reference directories; restore it with decompress")
+)
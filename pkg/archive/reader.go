@@ -0,0 +1,140 @@
+// pkg/archive/reader.go
+package archive
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/mmap"
+	"github.com/klauspost/compress/zstd"
+	"github.com/zeebo/blake3"
+)
+
+// openData returns a reader decoding the data of a GDELTA01 or GDELTA03
+// entry straight from the archive
+func (a *Archive) openData(fe *format.FileEntry) (io.ReadCloser, error) {
+	data := io.NewSectionReader(a.f, int64(fe.DataOffset), int64(fe.CompressedSize))
+	switch fe.Method {
+	case format.MethodStore:
+		return io.NopCloser(data), nil
+	case format.MethodZstd:
+		opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+		if len(a.dictionary) > 0 {
+			opts = append(opts, zstd.WithDecoderDicts(a.dictionary))
+		}
+		decoder, err := zstd.NewReader(data, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("create zstd decoder: %w", err)
+		}
+		return decoder.IOReadCloser(), nil
+	}
+	codec, ok := format.LookupCodec(fe.Method)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMethod, fe.Method)
+	}
+	return codec.NewReader(data)
+}
+
+// readPacked returns the content of a GDELTA01 packed file. The last pack
+// read is kept: packed files are listed together, so reading them in
+// order decompresses each pack once.
+func (a *Archive) readPacked(fe *format.FileEntry) ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.pack != fe.InPack {
+		data, err := format.ReadPack(a.f, fe.InPack)
+		if err != nil {
+			return nil, fmt.Errorf("%w: read pack: %v", ErrCorruptData, err)
+		}
+		a.pack, a.packData = fe.InPack, data
+	}
+	if fe.PackOffset > uint64(len(a.packData)) || fe.OriginalSize > uint64(len(a.packData))-fe.PackOffset {
+		return nil, fmt.Errorf("%w: packed file lies outside its pack", ErrCorruptData)
+	}
+	return a.packData[fe.PackOffset : fe.PackOffset+fe.OriginalSize], nil
+}
+
+// readChunk decodes the GDELTA02 chunk with hash, reading it into raw and
+// appending its content to dst. The content is checked against the hash.
+func (a *Archive) readChunk(hash [32]byte, raw *[]byte, dst []byte) ([]byte, error) {
+	info, ok := a.chunkIndex[hash]
+	if !ok {
+		return nil, fmt.Errorf("%w: chunk %x not in the index", ErrCorruptData, hash[:8])
+	}
+	chunk, err := mmap.Read(a.f, a.chunkDataStart+int64(info.Offset), int64(info.CompressedSize), raw)
+	if err != nil {
+		return nil, fmt.Errorf("read chunk %x: %w", hash[:8], err)
+	}
+
+	var data []byte
+	switch {
+	case info.Stored:
+		data = append(dst, chunk...)
+	case a.codec == format.MethodZstd:
+		data, err = a.decoder.DecodeAll(chunk, dst)
+	default:
+		codec, ok := format.LookupCodec(a.codec)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownMethod, a.codec)
+		}
+		data, err = format.DecodeBlock(codec, dst, chunk)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: chunk %x: %v", ErrCorruptData, hash[:8], err)
+	}
+	if blake3.Sum256(data) != hash {
+		return nil, fmt.Errorf("%w: chunk %x does not match its hash", ErrCorruptData, hash[:8])
+	}
+	return data, nil
+}
+
+// chunkReader streams a GDELTA02 file, decoding one chunk at a time
+type chunkReader struct {
+	archive *Archive
+	hashes  [][32]byte // Chunks still to decode
+	buf     []byte     // Rest of the current chunk
+	raw     []byte
+	scratch []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if len(r.hashes) == 0 {
+			return 0, io.EOF
+		}
+		data, err := r.archive.readChunk(r.hashes[0], &r.raw, r.scratch[:0])
+		if err != nil {
+			return 0, err
+		}
+		r.hashes = r.hashes[1:]
+		r.scratch, r.buf = data, data
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *chunkReader) Close() error {
+	r.hashes, r.buf = nil, nil
+	return nil
+}
+
+// sizedReader fails a read that ends short of or past the recorded size
+// of an entry, where a plain EOF would pass truncated content as whole
+type sizedReader struct {
+	io.ReadCloser
+	left uint64
+}
+
+func (r *sizedReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if uint64(n) > r.left {
+		return 0, fmt.Errorf("%w: longer than its recorded size", ErrCorruptData)
+	}
+	r.left -= uint64(n)
+	if err == io.EOF && r.left > 0 {
+		return n, fmt.Errorf("%w: %d bytes short of its recorded size", ErrCorruptData, r.left)
+	}
+	return n, err
+}
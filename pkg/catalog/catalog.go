@@ -0,0 +1,281 @@
+// pkg/catalog/catalog.go
+package catalog
+
+import (
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/creativeyann17/go-delta/internal/manifest"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// catalogVersion is bumped when the file layout changes incompatibly
+const catalogVersion = 1
+
+// Catalog indexes the files of many archives, so the archives holding a
+// file can be found without opening each of them. It is stored as
+// gzip-compressed JSON.
+type Catalog struct {
+	Version  int        `json:"version"`
+	Built    time.Time  `json:"built"`
+	Archives []*Archive `json:"archives"`
+}
+
+// Archive is one cataloged archive. Size and ModTime tell a rebuild
+// whether it must be read again.
+type Archive struct {
+	Path     string                   `json:"path"`
+	Size     int64                    `json:"size"`
+	ModTime  time.Time                `json:"mod_time"`
+	Format   string                   `json:"format"`
+	Metadata *godelta.ArchiveMetadata `json:"metadata,omitempty"`
+	Files    []File                   `json:"files"`
+}
+
+// File is an entry of a cataloged archive. SHA256 comes from the archive's
+// .sha256 manifest ('compress --write-manifest') and is empty without one.
+type File struct {
+	Path   string `json:"path"`
+	Size   uint64 `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// Match is a file found by a search
+type Match struct {
+	Archive *Archive
+	File    File
+}
+
+// BuildResult reports a build
+type BuildResult struct {
+	Read   int     // archives read
+	Reused int     // archives unchanged since the previous catalog
+	Errors []error // archives that couldn't be read, left out
+}
+
+// Build catalogs the archives at paths; directories are searched
+// recursively for .gdelta files. Archives with the same size and
+// modification time as in previous (nil for none) are taken from it
+// without being read again. Archives that can't be read are reported in
+// the result and left out.
+func Build(paths []string, previous *Catalog) (*Catalog, *BuildResult, error) {
+	archives, err := archivePaths(paths)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(archives) == 0 {
+		return nil, nil, ErrNoArchives
+	}
+
+	known := make(map[string]*Archive)
+	if previous != nil {
+		for _, a := range previous.Archives {
+			known[a.Path] = a
+		}
+	}
+
+	c := &Catalog{Version: catalogVersion, Built: time.Now().UTC().Truncate(time.Second)}
+	result := &BuildResult{}
+	for _, p := range archives {
+		info, err := os.Stat(p)
+		if err != nil {
+			result.Errors = append(result.Errors, err)
+			continue
+		}
+		if a := known[p]; a != nil && a.Size == info.Size() && a.ModTime.Equal(info.ModTime()) {
+			c.Archives = append(c.Archives, a)
+			result.Reused++
+			continue
+		}
+		a, err := readArchive(p, info)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", p, err))
+			continue
+		}
+		c.Archives = append(c.Archives, a)
+		result.Read++
+	}
+	return c, result, nil
+}
+
+// archivePaths returns the absolute paths of the archives given, sorted
+// and without duplicates
+func archivePaths(paths []string) ([]string, error) {
+	var archives []string
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			archives = append(archives, abs)
+			continue
+		}
+		err = filepath.WalkDir(abs, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(d.Name(), ".gdelta") {
+				archives = append(archives, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(archives)
+	return slices.Compact(archives), nil
+}
+
+// readArchive lists the entries of the archive at p from a structural
+// verify pass, which reads no file data
+func readArchive(p string, info os.FileInfo) (*Archive, error) {
+	result, err := verify.Verify(&verify.Options{InputPath: p, Quiet: true}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sums, err := readManifest(p + ".sha256")
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{
+		Path:     p,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		Format:   string(result.Format),
+		Metadata: result.Metadata,
+		Files:    make([]File, 0, len(result.Files)),
+	}
+	for _, f := range result.Files {
+		file := File{Path: f.Path, Size: f.OriginalSize}
+		if sum, ok := sums[f.Path]; ok {
+			file.SHA256 = hex.EncodeToString(sum[:])
+		}
+		a.Files = append(a.Files, file)
+	}
+	return a, nil
+}
+
+// readManifest reads the manifest at path; nil when there is none
+func readManifest(path string) (map[string][32]byte, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sums, err := manifest.Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("manifest %s: %w", path, err)
+	}
+	return sums, nil
+}
+
+// Search returns the files matching pattern, a path.Match glob. Patterns
+// with a '/' match whole entry paths, others the file name alone.
+func (c *Catalog) Search(pattern string) ([]Match, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrInvalidPattern, pattern, err)
+	}
+	return c.find(func(f File) bool {
+		name := f.Path
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(name)
+		}
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}), nil
+}
+
+// SearchHash returns the files whose SHA-256 is sum (hex)
+func (c *Catalog) SearchHash(sum string) []Match {
+	sum = strings.ToLower(sum)
+	return c.find(func(f File) bool { return f.SHA256 != "" && f.SHA256 == sum })
+}
+
+// find returns the files accepted by match, archive by archive
+func (c *Catalog) find(match func(File) bool) []Match {
+	var matches []Match
+	for _, a := range c.Archives {
+		for _, f := range a.Files {
+			if match(f) {
+				matches = append(matches, Match{Archive: a, File: f})
+			}
+		}
+	}
+	return matches
+}
+
+// Files returns the number of files across every archive
+func (c *Catalog) Files() int {
+	n := 0
+	for _, a := range c.Archives {
+		n += len(a.Files)
+	}
+	return n
+}
+
+// Load reads the catalog at path
+func Load(path string) (*Catalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open catalog: %w", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCatalog, err)
+	}
+	var c Catalog
+	if err := json.NewDecoder(zr).Decode(&c); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCatalog, err)
+	}
+	if c.Version != catalogVersion {
+		return nil, fmt.Errorf("%w: version %d, want %d", ErrInvalidCatalog, c.Version, catalogVersion)
+	}
+	return &c, nil
+}
+
+// Save writes the catalog to path atomically (temp file + rename)
+func (c *Catalog) Save(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("save catalog: %w", err)
+	}
+	zw := gzip.NewWriter(f)
+	err = json.NewEncoder(zw).Encode(c)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("save catalog: %w", err)
+	}
+	return nil
+}
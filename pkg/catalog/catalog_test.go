@@ -0,0 +1,87 @@
+// pkg/catalog/catalog_test.go
+package catalog_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/catalog"
+	"github.com/creativeyann17/go-delta/pkg/compress"
+)
+
+func writeArchive(t *testing.T, dir, name string, files map[string]string, opts compress.Options) string {
+	t.Helper()
+	src := t.TempDir()
+	for p, content := range files {
+		full := filepath.Join(src, p)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts.InputPath = src
+	opts.OutputPath = filepath.Join(dir, name)
+	opts.Quiet = true
+	if _, err := compress.Compress(&opts, nil); err != nil {
+		t.Fatalf("compress %s: %v", name, err)
+	}
+	return opts.OutputPath
+}
+
+func TestBuildAndSearch(t *testing.T) {
+	dir := t.TempDir()
+	mon := writeArchive(t, filepath.Join(dir, "nightly"), "mon.gdelta", map[string]string{
+		"docs/invoice-2023-01.pdf": "january",
+		"docs/notes.txt":           "notes",
+	}, compress.Options{WriteManifest: true})
+	tue := writeArchive(t, filepath.Join(dir, "nightly"), "tue.gdelta", map[string]string{
+		"docs/invoice-2023-01.pdf": "january",
+		"docs/invoice-2023-02.pdf": "february",
+	}, compress.Options{ChunkSize: 64 * 1024})
+
+	c, result, err := catalog.Build([]string{dir}, nil)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if result.Read != 2 || len(result.Errors) != 0 || c.Files() != 4 {
+		t.Fatalf("read %d, errors %v, files %d", result.Read, result.Errors, c.Files())
+	}
+
+	matches, err := c.Search("invoice-2023*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("got %d matches, want 3", len(matches))
+	}
+	if matches, _ := c.Search("docs/invoice-2023-02.pdf"); len(matches) != 1 || matches[0].Archive.Path != tue {
+		t.Errorf("full path search: %+v", matches)
+	}
+
+	sum := sha256.Sum256([]byte("january"))
+	if matches := c.SearchHash(hex.EncodeToString(sum[:])); len(matches) != 1 || matches[0].Archive.Path != mon {
+		t.Errorf("hash search: %+v (only mon has a manifest)", matches)
+	}
+
+	// Saved and loaded back, unchanged archives aren't read again
+	path := filepath.Join(dir, "catalog.db")
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := catalog.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, result, err = catalog.Build([]string{mon, tue}, loaded); err != nil || result.Reused != 2 || result.Read != 0 {
+		t.Errorf("rebuild: reused %d, read %d, err %v", result.Reused, result.Read, err)
+	}
+
+	if _, err := c.Search("["); err == nil {
+		t.Error("malformed pattern accepted")
+	}
+}
@@ -0,0 +1,16 @@
+// pkg/catalog/errors.go
+package catalog
+
+import "github.com/creativeyann17/go-delta/pkg/godelta"
+
+// Every error matches a godelta error category with errors.Is
+var (
+	// ErrNoArchives is returned when a build is given no archive
+	ErrNoArchives = godelta.NewError(godelta.ErrUsage, "no archives to catalog")
+
+	// ErrInvalidCatalog is returned for a catalog file that can't be read
+	ErrInvalidCatalog = godelta.NewError(godelta.ErrCorrupt, "invalid catalog file")
+
+	// ErrInvalidPattern is returned for a malformed search pattern
+	ErrInvalidPattern = godelta.NewError(godelta.ErrUsage, "invalid search pattern")
+)
@@ -0,0 +1,343 @@
+// pkg/compress/appender.go
+package compress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/pool"
+)
+
+// smallEntrySize is the size up to which files are always compressed in
+// memory before being appended, whatever MaxThreadMemory says
+const smallEntrySize = 1 << 20
+
+// archiveAppender serializes writes at the tail of a GDELTA01 archive.
+// Entries are contiguous (header, then data), so one entry is written at a
+// time. A worker that finds the tail free streams its file straight into
+// the archive while holding it, with no temp file. Workers that find it
+// busy queue their finished entry (in memory, or in a temp file for large
+// ones); whoever holds the tail drains the queue before releasing it, so
+// nobody waits behind a long stream.
+type archiveAppender struct {
+	mu sync.Mutex // held while writing at the tail
+	f  *os.File
+
+	qmu         sync.Mutex
+	queue       []queuedEntry
+	queuedBytes uint64        // in-memory data held by the queue
+	maxQueued   uint64        // above this, add blocks for the tail instead of queuing
+	budget      *memoryBudget // replaces maxQueued when set
+	errs        []error
+
+	entries []*format.FileEntry // written entries, for the index; guarded by mu
+}
+
+// queuedEntry is a compressed entry waiting for the tail
+type queuedEntry struct {
+	relPath  string
+	origSize uint64
+	method   format.Method
+	size     uint64
+	data     []byte // compressed data held in memory, or
+	tempPath string // temp file holding it, removed once written
+
+	files []*format.FileEntry // packed files of a small-file pack, written after its data
+}
+
+func newArchiveAppender(f *os.File, opts *Options, budget *memoryBudget) *archiveAppender {
+	perWorker := max(opts.MaxThreadMemory, smallEntrySize)
+	return &archiveAppender{
+		f:         f,
+		maxQueued: perWorker * uint64(opts.MaxThreads),
+		budget:    budget,
+	}
+}
+
+// stream writes an entry by calling compress with the archive itself when
+// the tail is free. It returns ok=false without calling compress when
+// another entry is being written. On error the partial entry is cut off.
+func (a *archiveAppender) stream(relPath string, origSize uint64, method format.Method, compress func(io.Writer) (uint64, error)) (size uint64, ok bool, err error) {
+	if !a.mu.TryLock() {
+		return 0, false, nil
+	}
+	defer a.release()
+
+	entryStart, dataStart, err := a.begin(relPath, origSize)
+	if err != nil {
+		return 0, true, err
+	}
+	size, err = compress(a.f)
+	if err == nil {
+		err = a.end(entryStart, dataStart, relPath, origSize, size, method)
+	}
+	if err != nil {
+		a.rollback(entryStart)
+		return 0, true, err
+	}
+	return size, true, nil
+}
+
+// add writes a finished entry if the tail is free and queues it otherwise.
+// In-memory data is copied when queued, so the caller may reuse it. Errors
+// writing a queued entry are reported by finish.
+func (a *archiveAppender) add(e queuedEntry) error {
+	if a.mu.TryLock() {
+		defer a.release()
+		return a.write(e)
+	}
+
+	a.qmu.Lock()
+	if !a.reserve(uint64(len(e.data))) {
+		a.qmu.Unlock()
+		a.mu.Lock()
+		defer a.release()
+		return a.write(e)
+	}
+	e.data = bytes.Clone(e.data)
+	a.queue = append(a.queue, e)
+	a.queuedBytes += uint64(len(e.data))
+	a.qmu.Unlock()
+
+	// The holder may have drained just before we queued
+	if a.mu.TryLock() {
+		a.release()
+	}
+	return nil
+}
+
+// reserve accounts for n bytes of queued data, reporting false when the
+// queue is full; the caller holds qmu
+func (a *archiveAppender) reserve(n uint64) bool {
+	if a.budget != nil {
+		return a.budget.tryQueue(n)
+	}
+	return a.queuedBytes+n <= a.maxQueued
+}
+
+// release drains the queue and gives up the tail. The queue is checked again
+// after unlocking: an entry queued while the holder was finishing is written
+// either here or by the worker that queued it.
+func (a *archiveAppender) release() {
+	for {
+		for {
+			a.qmu.Lock()
+			queue := a.queue
+			a.queue, a.queuedBytes = nil, 0
+			a.qmu.Unlock()
+			if len(queue) == 0 {
+				break
+			}
+			for _, e := range queue {
+				err := a.write(e)
+				a.budget.release(uint64(len(e.data)))
+				if err != nil {
+					a.qmu.Lock()
+					a.errs = append(a.errs, e.failed(err)...)
+					a.qmu.Unlock()
+				}
+			}
+		}
+		a.mu.Unlock()
+
+		a.qmu.Lock()
+		empty := len(a.queue) == 0
+		a.qmu.Unlock()
+		if empty || !a.mu.TryLock() {
+			return
+		}
+	}
+}
+
+// finish writes whatever is still queued and returns the errors of queued
+// entries that could not be written
+func (a *archiveAppender) finish() []error {
+	a.mu.Lock()
+	a.release()
+	return a.errs
+}
+
+// discard drops the queue of a canceled run, removing its temp files
+func (a *archiveAppender) discard() {
+	a.qmu.Lock()
+	defer a.qmu.Unlock()
+	for _, e := range a.queue {
+		if e.tempPath != "" {
+			os.Remove(e.tempPath)
+		}
+		a.budget.release(uint64(len(e.data)))
+	}
+	a.queue, a.queuedBytes = nil, 0
+}
+
+// failed returns err for each file of e
+func (e queuedEntry) failed(err error) []error {
+	if e.files == nil {
+		return []error{fmt.Errorf("%s: %w", e.relPath, err)}
+	}
+	errs := make([]error, len(e.files))
+	for i, f := range e.files {
+		errs[i] = fmt.Errorf("%s: %w", f.Path, err)
+	}
+	return errs
+}
+
+// write appends one finished entry; the caller holds the tail
+func (a *archiveAppender) write(e queuedEntry) error {
+	if e.files != nil {
+		return a.writePack(e)
+	}
+	var data io.Reader = bytes.NewReader(e.data)
+	if e.tempPath != "" {
+		defer os.Remove(e.tempPath)
+		f, err := os.Open(e.tempPath)
+		if err != nil {
+			return fmt.Errorf("open temp file: %w", err)
+		}
+		defer f.Close()
+		data = f
+	}
+
+	entryStart, dataStart, err := a.begin(e.relPath, e.origSize)
+	if err != nil {
+		return err
+	}
+	if _, err := pool.Copy(a.f, data); err != nil {
+		a.rollback(entryStart)
+		return fmt.Errorf("copy compressed data: %w", err)
+	}
+	if err := a.end(entryStart, dataStart, e.relPath, e.origSize, e.size, e.method); err != nil {
+		a.rollback(entryStart)
+		return err
+	}
+	return nil
+}
+
+// writePack appends a small-file pack: its entry and frame, then the
+// entries of its files, in one write; the caller holds the tail
+func (a *archiveAppender) writePack(e queuedEntry) error {
+	start, err := a.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("seek: %w", err)
+	}
+	pack := &format.FileEntry{
+		Path:           format.PackPath,
+		OriginalSize:   e.origSize,
+		CompressedSize: e.size,
+		DataOffset:     uint64(start) + format.FileEntryHeaderSize + uint64(len(format.PackPath)),
+		Method:         e.method,
+		Pack:           true,
+	}
+	buf := format.AppendFileEntry(nil, pack)
+	buf = append(buf, e.data...)
+	for _, f := range e.files {
+		if len(f.Path) > 65535 {
+			return fmt.Errorf("path too long for archive format (%d bytes, max 65535): %s", len(f.Path), f.Path)
+		}
+		// Packed files have no data: it would start right after the header
+		f.DataOffset = uint64(start) + uint64(len(buf)) + format.FileEntryHeaderSize + uint64(len(f.Path))
+		buf = format.AppendFileEntry(buf, f)
+	}
+	if _, err := a.f.Write(buf); err != nil {
+		a.rollback(start)
+		return fmt.Errorf("write pack: %w", err)
+	}
+	a.entries = append(a.entries, pack)
+	a.entries = append(a.entries, e.files...)
+	return nil
+}
+
+// writeLinks appends, in one write, a link entry for each duplicate whose
+// first file was written, and returns the others; called once every other
+// entry is written
+func (a *archiveAppender) writeLinks(dups []duplicate) (linked, orphans []duplicate, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	index := make(map[string]int, len(a.entries))
+	for i, e := range a.entries {
+		if !e.Pack {
+			index[e.Path] = i
+		}
+	}
+	start, err := a.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, nil, fmt.Errorf("seek: %w", err)
+	}
+	var buf []byte
+	var entries []*format.FileEntry
+	for _, d := range dups {
+		i, ok := index[d.of]
+		if !ok || len(d.task.RelPath) > 65535 {
+			orphans = append(orphans, d)
+			continue
+		}
+		// Links have no data: it would start right after the header
+		e := &format.FileEntry{
+			Path:         d.task.RelPath,
+			OriginalSize: d.task.OrigSize,
+			DataOffset:   uint64(start) + uint64(len(buf)) + format.FileEntryHeaderSize + uint64(len(d.task.RelPath)),
+			Method:       a.entries[i].Method,
+			Link:         true,
+			LinkIndex:    uint64(i),
+		}
+		buf = format.AppendFileEntry(buf, e)
+		entries = append(entries, e)
+		linked = append(linked, d)
+	}
+	if _, err := a.f.Write(buf); err != nil {
+		a.rollback(start)
+		return nil, nil, fmt.Errorf("write links: %w", err)
+	}
+	a.entries = append(a.entries, entries...)
+	return linked, orphans, nil
+}
+
+// begin writes the entry header with placeholder sizes
+func (a *archiveAppender) begin(relPath string, origSize uint64) (entryStart, dataStart int64, err error) {
+	entryStart, err = format.WriteFileEntry(a.f, relPath, origSize)
+	if err != nil {
+		return 0, 0, fmt.Errorf("write entry: %w", err)
+	}
+	dataStart, err = a.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		a.rollback(entryStart)
+		return 0, 0, fmt.Errorf("seek: %w", err)
+	}
+	return entryStart, dataStart, nil
+}
+
+// end patches the header with the data's size and offset
+func (a *archiveAppender) end(entryStart, dataStart int64, relPath string, origSize, size uint64, method format.Method) error {
+	if err := format.UpdateFileEntry(a.f, entryStart, size, uint64(dataStart), method); err != nil {
+		return fmt.Errorf("update entry: %w", err)
+	}
+	a.entries = append(a.entries, &format.FileEntry{
+		Path:           relPath,
+		OriginalSize:   origSize,
+		CompressedSize: size,
+		DataOffset:     uint64(dataStart),
+		Method:         method,
+	})
+	return nil
+}
+
+// writeIndex writes the index of the entries after the footer; called once
+// every entry is written
+func (a *archiveAppender) writeIndex() error {
+	indexOffset, err := a.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("seek: %w", err)
+	}
+	return format.WriteGDelta01Index(a.f, indexOffset, a.entries)
+}
+
+// rollback cuts a partially written entry off the archive
+func (a *archiveAppender) rollback(entryStart int64) {
+	a.f.Truncate(entryStart)
+	a.f.Seek(entryStart, io.SeekStart)
+}
@@ -0,0 +1,76 @@
+// pkg/compress/archival.go
+package compress
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// DefaultArchivalChunkSize is the chunk size of Options.Archival when
+// ChunkSize is 0
+const DefaultArchivalChunkSize = 64 * 1024
+
+// DefaultArchivalParity is the parity of Options.Archival when
+// ParityPercent is 0
+const DefaultArchivalParity = 10
+
+// applyArchival turns on the options making up Options.Archival, keeping
+// the chunk size, parity and metadata already set
+func (o *Options) applyArchival() error {
+	if o.standardFormat() || o.UseDictionary || o.SplitByFolder {
+		return ErrArchivalFormat
+	}
+	o.Deterministic = true
+	o.EmbedFormatSpec = true
+	o.VerifyAfter = true
+	o.Sync = true
+	if o.FromTar == nil && !o.streamInput() {
+		o.WriteManifest = true
+	}
+	if o.ChunkSize == 0 {
+		o.ChunkSize = DefaultArchivalChunkSize
+	}
+	if o.ParityPercent == 0 {
+		o.ParityPercent = DefaultArchivalParity
+	}
+	if o.Metadata == nil {
+		o.Metadata = &godelta.ArchiveMetadata{}
+	}
+	return nil
+}
+
+// checkDeterministic refuses what Deterministic can't make reproducible
+// and leaves a single thread
+func (o *Options) checkDeterministic() error {
+	// Dictionary training doesn't give the same dictionary twice, and
+	// tuning depends on timings
+	if o.standardFormat() || o.UseDictionary || o.ChunkDictionary {
+		return ErrDeterministicFormat
+	}
+	if o.AutoLevel || o.AutoChunkSize || o.AdaptiveThreads {
+		return ErrDeterministicTuning
+	}
+	o.MaxThreads = 1
+	return nil
+}
+
+// sortFolders puts folders and their files in path order, which the
+// directory walk into a map doesn't keep
+func sortFolders(folders []folderTask) {
+	slices.SortFunc(folders, func(a, b folderTask) int { return cmp.Compare(a.FolderPath, b.FolderPath) })
+	for _, folder := range folders {
+		slices.SortFunc(folder.Files, func(a, b fileTask) int { return cmp.Compare(a.RelPath, b.RelPath) })
+	}
+}
+
+// formatSpecExtension returns the layout summary of archive format f as a
+// header extension, and false without EmbedFormatSpec
+func (o *Options) formatSpecExtension(f format.ArchiveFormat) (format.Extension, bool) {
+	if !o.EmbedFormatSpec {
+		return format.Extension{}, false
+	}
+	return format.Extension{Type: format.ExtFormatSpec, Value: []byte(format.FormatSpec(f))}, true
+}
@@ -0,0 +1,95 @@
+// pkg/compress/archival_test.go
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+func TestArchival(t *testing.T) {
+	input := t.TempDir()
+	for i := range 40 {
+		createFile(t, input, fmt.Sprintf("dir%d/file%d.txt", i%4, i), strings.Repeat(fmt.Sprintf("line %d of some text ", i), 500+i))
+	}
+
+	// Two runs over the same files give the same archive and manifest
+	var archives, manifests [][]byte
+	for range 2 {
+		opts := &Options{
+			InputPath:  input,
+			OutputPath: filepath.Join(t.TempDir(), "out.gdelta"),
+			MaxThreads: 4,
+			Archival:   true,
+			Quiet:      true,
+		}
+		result, err := Compress(opts, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts.MaxThreads != 1 || opts.ChunkSize != DefaultArchivalChunkSize || opts.ParityPercent != DefaultArchivalParity {
+			t.Errorf("options not applied: %d threads, chunk size %d, parity %d", opts.MaxThreads, opts.ChunkSize, opts.ParityPercent)
+		}
+		if result.Verify == nil || !result.Verify.IsValid() || result.Verify.Manifest == nil {
+			t.Fatalf("verify result %+v", result.Verify)
+		}
+
+		spec, err := verify.ReadFormatSpec(opts.OutputPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if spec != format.FormatSpec(format.FormatGDelta02) {
+			t.Errorf("embedded format spec %q", spec)
+		}
+		m, err := verify.ReadMetadata(opts.OutputPath)
+		if err != nil || m == nil || !m.Created.IsZero() || m.Host != "" || m.Source != "" {
+			t.Errorf("metadata %+v, %v", m, err)
+		}
+
+		archive, err := os.ReadFile(opts.OutputPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifest, err := os.ReadFile(ManifestPath(opts))
+		if err != nil {
+			t.Fatal(err)
+		}
+		archives, manifests = append(archives, archive), append(manifests, manifest)
+	}
+	if !bytes.Equal(archives[0], archives[1]) {
+		t.Error("archives differ between runs")
+	}
+	if !bytes.Equal(manifests[0], manifests[1]) {
+		t.Error("manifests differ between runs")
+	}
+}
+
+func TestArchivalErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		opts Options
+		want error
+	}{
+		"zip":              {Options{Archival: true, UseZipFormat: true}, ErrArchivalFormat},
+		"dictionary":       {Options{Archival: true, UseDictionary: true}, ErrArchivalFormat},
+		"split":            {Options{Archival: true, SplitByFolder: true}, ErrArchivalFormat},
+		"chunk dict":       {Options{Archival: true, ChunkDictionary: true}, ErrDeterministicFormat},
+		"auto level":       {Options{Archival: true, AutoLevel: true}, ErrDeterministicTuning},
+		"adaptive":         {Options{Deterministic: true, AdaptiveThreads: true}, ErrDeterministicTuning},
+		"spec gdelta01":    {Options{EmbedFormatSpec: true}, ErrFormatSpecFormat},
+		"deterministic 7z": {Options{Deterministic: true, Use7zFormat: true}, ErrDeterministicFormat},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc.opts.InputPath = t.TempDir()
+			if err := tc.opts.Validate(); !errors.Is(err, tc.want) {
+				t.Errorf("got %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
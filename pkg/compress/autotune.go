@@ -0,0 +1,146 @@
+// pkg/compress/autotune.go
+package compress
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+)
+
+// DefaultAutoTuneSample is the input each auto-tuning candidate compresses
+// when Options.AutoTuneSample is 0
+const DefaultAutoTuneSample = 16 * 1024 * 1024
+
+// AutoTuneLevels are the levels Options.AutoLevel compares, those outside
+// the range of the codec left out
+var AutoTuneLevels = []int{1, 3, 5, 9}
+
+// AutoTuneChunkSizes are the chunk sizes Options.AutoChunkSize compares
+var AutoTuneChunkSizes = []uint64{64 * 1024, 256 * 1024, 1024 * 1024}
+
+// AutoTune reports the settings picked by Options.AutoLevel and
+// AutoChunkSize
+type AutoTune struct {
+	Level     int    // Level of the run
+	ChunkSize uint64 // Chunk size of the run
+
+	SampleFiles int    // Files of the sample each candidate compressed
+	SampleSize  uint64 // Their size
+
+	// Runs holds one entry per candidate, the picked one included
+	Runs []BenchRun
+}
+
+// autoTune compresses the first AutoTuneSample bytes of the input of
+// validated opts with each candidate level and chunk size, and returns the
+// options of the run with the ones saving the most bytes per second.
+// Without input files the options are returned unchanged, for the run to
+// report ErrNoFiles.
+func autoTune(ctx context.Context, opts *Options) (*Options, *AutoTune, error) {
+	levels := []int{opts.Level}
+	if opts.AutoLevel && len(autoTuneLevels(opts.Codec)) > 0 {
+		levels = autoTuneLevels(opts.Codec)
+	}
+	chunkSizes := []uint64{opts.ChunkSize}
+	if opts.AutoChunkSize {
+		chunkSizes = AutoTuneChunkSizes
+	}
+
+	folders, totalFiles, _, err := collectFiles(opts, &Result{})
+	if err != nil {
+		return nil, nil, err
+	}
+	if totalFiles == 0 {
+		return opts, nil, nil
+	}
+	sampleSize := opts.AutoTuneSample
+	if sampleSize == 0 {
+		sampleSize = DefaultAutoTuneSample
+	}
+	tune := &AutoTune{}
+	var sample []folderTask
+	sample, tune.SampleFiles, tune.SampleSize = headInput(folders, sampleSize)
+
+	dir, err := os.MkdirTemp("", "godelta-autotune-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	best := -1
+	for _, chunkSize := range chunkSizes {
+		for _, level := range levels {
+			candidate := benchCandidate(opts, level, chunkSize, opts.UseDictionary, opts.MaxThreads)
+			if err := candidate.Validate(); err != nil {
+				return nil, nil, fmt.Errorf("auto-tune level %d, chunk size %s: %w", level, FormatSize(chunkSize), err)
+			}
+			candidate.sample = sample
+			candidate.OutputPath = filepath.Join(dir, fmt.Sprintf("run-%d.gdelta", len(tune.Runs)))
+			run, err := benchOnce(ctx, candidate)
+			if err != nil {
+				return nil, nil, fmt.Errorf("auto-tuning canceled: %w", err)
+			}
+			tune.Runs = append(tune.Runs, run)
+			if best < 0 || run.savedPerSecond() > tune.Runs[best].savedPerSecond() {
+				best = len(tune.Runs) - 1
+			}
+		}
+	}
+
+	tune.Level, tune.ChunkSize = tune.Runs[best].Level, tune.Runs[best].ChunkSize
+	tuned := *opts
+	tuned.AutoLevel, tuned.AutoChunkSize = false, false
+	tuned.Level = tune.Level
+	if opts.AutoChunkSize {
+		tuned.ChunkSize = tune.ChunkSize
+		tuned.ChunkMinSize, tuned.ChunkMaxSize = 0, 0
+	}
+	if err := tuned.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return &tuned, tune, nil
+}
+
+// autoTuneLevels returns the AutoTuneLevels the codec named accepts, none
+// for a codec without levels
+func autoTuneLevels(codecName string) []int {
+	_, codec, _ := format.CodecByName(codecName)
+	lo, hi := codec.LevelRange()
+	if hi == 0 {
+		return nil
+	}
+	var levels []int
+	for _, level := range AutoTuneLevels {
+		if level >= lo && level <= hi {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// headInput picks the first files of folders, in scan order, until they
+// are worth size bytes
+func headInput(folders []folderTask, size uint64) ([]folderTask, int, uint64) {
+	var sample []folderTask
+	var files int
+	var sampled uint64
+	for _, folder := range folders {
+		if sampled >= size {
+			break
+		}
+		picked := folderTask{FolderPath: folder.FolderPath}
+		for _, task := range folder.Files {
+			if sampled >= size {
+				break
+			}
+			picked.Files = append(picked.Files, task)
+			sampled += task.OrigSize
+			files++
+		}
+		sample = append(sample, picked)
+	}
+	return sample, files, sampled
+}
@@ -0,0 +1,75 @@
+// pkg/compress/autotune_test.go
+package compress
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompressAutoTune(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in")
+	if err := os.MkdirAll(input, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 10 {
+		content := strings.Repeat(fmt.Sprintf("record %d with a repeated payload\n", i), 2000)
+		if err := os.WriteFile(filepath.Join(input, fmt.Sprintf("f%d.txt", i)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Compress(&Options{
+		InputPath:      input,
+		OutputPath:     filepath.Join(dir, "out.gdelta"),
+		AutoLevel:      true,
+		AutoChunkSize:  true,
+		AutoTuneSample: 200 * 1024,
+		Quiet:          true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tune := result.AutoTune
+	if tune == nil {
+		t.Fatal("no AutoTune in the result")
+	}
+	if len(tune.Runs) != len(AutoTuneLevels)*len(AutoTuneChunkSizes) {
+		t.Errorf("%d candidates, want %d", len(tune.Runs), len(AutoTuneLevels)*len(AutoTuneChunkSizes))
+	}
+	// The sample stops at the file reaching AutoTuneSample
+	if tune.SampleFiles != 4 || tune.SampleSize < 200*1024 {
+		t.Errorf("sampled %d files, %d bytes", tune.SampleFiles, tune.SampleSize)
+	}
+	if !slices.Contains(AutoTuneLevels, tune.Level) || !slices.Contains(AutoTuneChunkSizes, tune.ChunkSize) {
+		t.Errorf("picked level %d, chunk size %d", tune.Level, tune.ChunkSize)
+	}
+	if result.ChunkSize != tune.ChunkSize || result.FilesProcessed != 10 {
+		t.Errorf("run used chunk size %d for %d files, want %d for 10", result.ChunkSize, result.FilesProcessed, tune.ChunkSize)
+	}
+
+	plain, err := Compress(&Options{InputPath: input, OutputPath: filepath.Join(dir, "plain.gdelta"), Quiet: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.AutoTune != nil {
+		t.Error("AutoTune set without auto settings")
+	}
+}
+
+func TestAutoTuneRejectsStreamAndFormats(t *testing.T) {
+	if err := (&Options{FromStream: os.Stdin, AutoLevel: true}).Validate(); !errors.Is(err, ErrAutoTuneStream) {
+		t.Errorf("stream: got %v, want ErrAutoTuneStream", err)
+	}
+	if err := (&Options{InputPath: ".", UseZipFormat: true, AutoLevel: true}).Validate(); !errors.Is(err, ErrAutoTuneFormat) {
+		t.Errorf("zip: got %v, want ErrAutoTuneFormat", err)
+	}
+	if err := (&Options{InputPath: ".", UseDictionary: true, AutoChunkSize: true}).Validate(); !errors.Is(err, ErrDictionaryNoChunking) {
+		t.Errorf("dictionary: got %v, want ErrDictionaryNoChunking", err)
+	}
+}
@@ -0,0 +1,374 @@
+// pkg/compress/bench.go
+package compress
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// DefaultBenchSampleSize is the input Bench compresses when the matrix sets
+// no sample size
+const DefaultBenchSampleSize = 32 * 1024 * 1024
+
+// BenchMatrix is the settings Bench compares. Every combination of the
+// axes is a run; dictionary runs don't chunk, so they are combined with
+// levels and threads only.
+type BenchMatrix struct {
+	// Levels to compare
+	// Default: 1, 5, 9
+	Levels []int
+
+	// ChunkSizes to compare, 0 for whole-file compression
+	// Default: 0, 64KB, 1MB
+	ChunkSizes []uint64
+
+	// Dictionary values to compare: false runs the chunk sizes, true a
+	// GDELTA03 dictionary run
+	// Default: false, true
+	Dictionary []bool
+
+	// Threads to compare
+	// Default: 1 and the number of CPUs
+	Threads []int
+
+	// SampleSize is about how much of the input each run compresses
+	// 0 = DefaultBenchSampleSize
+	SampleSize uint64
+}
+
+// withDefaults returns the matrix with its empty axes set to the defaults
+func (m BenchMatrix) withDefaults() BenchMatrix {
+	if len(m.Levels) == 0 {
+		m.Levels = []int{1, 5, 9}
+	}
+	if len(m.ChunkSizes) == 0 {
+		m.ChunkSizes = []uint64{0, 64 * 1024, 1024 * 1024}
+	}
+	if len(m.Dictionary) == 0 {
+		m.Dictionary = []bool{false, true}
+	}
+	if len(m.Threads) == 0 {
+		m.Threads = []int{1, runtime.NumCPU()}
+	}
+	if m.SampleSize == 0 {
+		m.SampleSize = DefaultBenchSampleSize
+	}
+	m.Levels = slices.Compact(slices.Sorted(slices.Values(m.Levels)))
+	m.ChunkSizes = slices.Compact(slices.Sorted(slices.Values(m.ChunkSizes)))
+	m.Threads = slices.Compact(slices.Sorted(slices.Values(m.Threads)))
+	return m
+}
+
+// BenchRun is the outcome of one combination of the matrix
+type BenchRun struct {
+	Level      int    `json:"level"`
+	ChunkSize  uint64 `json:"chunk_size"` // 0 = whole files
+	Dictionary bool   `json:"dictionary"`
+	Threads    int    `json:"threads"`
+
+	OriginalSize   uint64        `json:"original_size"`
+	CompressedSize uint64        `json:"compressed_size"` // Size of the archive written
+	Duration       time.Duration `json:"duration_ns"`
+
+	// Ratio is the compressed size as a percentage of the original
+	Ratio float64 `json:"ratio"`
+
+	// BytesPerSecond is the original bytes compressed per second
+	BytesPerSecond float64 `json:"bytes_per_second"`
+
+	// Error is set when the run failed; the other results are then zero
+	Error string `json:"error,omitempty"`
+}
+
+// Mode describes the chunking of the run: "dictionary", "whole files" or
+// the chunk size
+func (r BenchRun) Mode() string {
+	switch {
+	case r.Dictionary:
+		return "dictionary"
+	case r.ChunkSize == 0:
+		return "whole files"
+	}
+	return FormatSize(r.ChunkSize) + " chunks"
+}
+
+// BenchResult reports a benchmark
+type BenchResult struct {
+	InputFiles  int    `json:"input_files"`
+	InputSize   uint64 `json:"input_size"`
+	SampleFiles int    `json:"sample_files"`
+	SampleSize  uint64 `json:"sample_size"`
+
+	// Runs holds one entry per combination, in the order they ran
+	Runs []BenchRun `json:"runs"`
+
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Smallest returns the successful run with the smallest archive, the
+// fastest of them on a tie, or nil when every run failed
+func (r *BenchResult) Smallest() *BenchRun {
+	return r.best(func(a, b *BenchRun) bool {
+		if a.CompressedSize != b.CompressedSize {
+			return a.CompressedSize < b.CompressedSize
+		}
+		return a.Duration < b.Duration
+	})
+}
+
+// Fastest returns the successful run with the highest throughput, or nil
+// when every run failed
+func (r *BenchResult) Fastest() *BenchRun {
+	return r.best(func(a, b *BenchRun) bool { return a.BytesPerSecond > b.BytesPerSecond })
+}
+
+// Balanced returns the successful run saving the most bytes per second,
+// the trade-off Options.AutoLevel and AutoChunkSize pick, or nil when
+// every run failed or none saved anything
+func (r *BenchResult) Balanced() *BenchRun {
+	best := r.best(func(a, b *BenchRun) bool { return a.savedPerSecond() > b.savedPerSecond() })
+	if best == nil || best.savedPerSecond() == 0 {
+		return nil
+	}
+	return best
+}
+
+// savedPerSecond returns the bytes the run's compression saved per
+// second, which favors a better ratio only as long as it costs little
+// speed. A failed run saved none.
+func (r BenchRun) savedPerSecond() float64 {
+	if r.Error != "" || r.Duration <= 0 || r.CompressedSize >= r.OriginalSize {
+		return 0
+	}
+	return float64(r.OriginalSize-r.CompressedSize) / r.Duration.Seconds()
+}
+
+// best returns the successful run no other one is better than
+func (r *BenchResult) best(better func(a, b *BenchRun) bool) *BenchRun {
+	var best *BenchRun
+	for i := range r.Runs {
+		run := &r.Runs[i]
+		if run.Error == "" && (best == nil || better(run, best)) {
+			best = run
+		}
+	}
+	return best
+}
+
+// Summary returns a human-readable table of the runs
+func (r *BenchResult) Summary() string {
+	out := fmt.Sprintf("Sampled %d / %d files, %s of %s; %d runs in %v\n\n",
+		r.SampleFiles, r.InputFiles, FormatSize(r.SampleSize), FormatSize(r.InputSize), len(r.Runs), r.Duration.Round(time.Millisecond))
+	out += fmt.Sprintf("  %5s  %-16s %7s %12s %8s %12s %10s\n", "Level", "Mode", "Threads", "Size", "Ratio", "Speed", "Time")
+	smallest, fastest, balanced := r.Smallest(), r.Fastest(), r.Balanced()
+	for i := range r.Runs {
+		run := &r.Runs[i]
+		if run.Error != "" {
+			out += fmt.Sprintf("  %5d  %-16s %7d  ✗ %s\n", run.Level, run.Mode(), run.Threads, run.Error)
+			continue
+		}
+		mark := ""
+		switch run {
+		case smallest:
+			mark = "  <- smallest"
+		case fastest:
+			mark = "  <- fastest"
+		case balanced:
+			mark = "  <- best trade-off"
+		}
+		out += fmt.Sprintf("  %5d  %-16s %7d %12s %7.1f%% %10s/s %10v%s\n",
+			run.Level, run.Mode(), run.Threads, FormatSize(run.CompressedSize), run.Ratio,
+			FormatSize(uint64(run.BytesPerSecond)), run.Duration.Round(time.Millisecond), mark)
+	}
+	out += "\nRatio = archive size / original size; Speed = original bytes per second\n"
+	return out
+}
+
+// Bench compresses a sample of the input of opts with every combination of
+// matrix and reports the size and throughput of each, so settings can be
+// picked from measurements. The sample is about SampleSize bytes of files
+// spread over the whole input. opts selects the input (InputPath or Files,
+// Excludes, IgnoreFiles, UseGitignore, ModifiedSince) and the other settings of the
+// runs, such as Codec or ChunkingMode; each run overrides Level,
+// ChunkSize, UseDictionary and MaxThreads. Archives are written to a temp
+// directory and removed after each run, so the throughput includes the
+// writes to it. onRun, if set, is called after each run.
+func Bench(ctx context.Context, opts *Options, matrix BenchMatrix, onRun func(BenchRun)) (*BenchResult, error) {
+	if opts.FromTar != nil || opts.streamInput() {
+		return nil, ErrBenchStream
+	}
+	if opts.standardFormat() {
+		return nil, ErrBenchFormat
+	}
+	matrix = matrix.withDefaults()
+
+	// Every combination is validated before anything runs
+	var candidates []*Options
+	for _, dictionary := range matrix.Dictionary {
+		chunkSizes := matrix.ChunkSizes
+		if dictionary {
+			chunkSizes = []uint64{0}
+		}
+		for _, chunkSize := range chunkSizes {
+			for _, level := range matrix.Levels {
+				for _, threads := range matrix.Threads {
+					candidate := benchCandidate(opts, level, chunkSize, dictionary, threads)
+					if err := candidate.Validate(); err != nil {
+						run := BenchRun{Level: level, ChunkSize: chunkSize, Dictionary: dictionary, Threads: threads}
+						return nil, fmt.Errorf("level %d, %s, %d threads: %w", level, run.Mode(), threads, err)
+					}
+					candidates = append(candidates, candidate)
+				}
+			}
+		}
+	}
+
+	start := time.Now()
+	result := &BenchResult{}
+	defer func() { result.Duration = time.Since(start) }()
+
+	folders, totalFiles, totalOrigSize, err := collectFiles(candidates[0], &Result{})
+	if err != nil {
+		return nil, err
+	}
+	if totalFiles == 0 {
+		return nil, ErrNoFiles
+	}
+	result.InputFiles, result.InputSize = totalFiles, totalOrigSize
+	sample, sampleFiles, sampleSize := sampleInput(folders, totalOrigSize, matrix.SampleSize)
+	result.SampleFiles, result.SampleSize = sampleFiles, sampleSize
+
+	dir, err := os.MkdirTemp("", "godelta-bench-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	for i, candidate := range candidates {
+		candidate.sample = sample
+		candidate.OutputPath = filepath.Join(dir, fmt.Sprintf("run-%d.gdelta", i))
+		run, err := benchOnce(ctx, candidate)
+		if err != nil {
+			return nil, fmt.Errorf("benchmark canceled: %w", err)
+		}
+		result.Runs = append(result.Runs, run)
+		if onRun != nil {
+			onRun(run)
+		}
+	}
+	return result, nil
+}
+
+// benchCandidate returns the options of one run: opts with the settings of
+// the combination, writing a bare archive quietly
+func benchCandidate(opts *Options, level int, chunkSize uint64, dictionary bool, threads int) *Options {
+	candidate := *opts
+	candidate.Level = level
+	candidate.ChunkSize = chunkSize
+	candidate.ChunkMinSize, candidate.ChunkMaxSize = 0, 0
+	candidate.UseDictionary = dictionary
+	candidate.MaxThreads = threads
+	candidate.AutoLevel, candidate.AutoChunkSize = false, false
+	candidate.ParityPercent = 0
+	candidate.WriteManifest = false
+	candidate.Metadata = nil
+	candidate.SnapshotMode = SnapshotNone
+	candidate.DryRun = false
+	candidate.Verbose = false
+	candidate.Quiet = true
+	candidate.ProgressWriter = nil
+	return &candidate
+}
+
+// benchOnce compresses the sample of candidate and removes the archive.
+// A failed run is reported in the BenchRun; only a cancellation of ctx is
+// returned.
+func benchOnce(ctx context.Context, candidate *Options) (BenchRun, error) {
+	run := BenchRun{
+		Level:      candidate.Level,
+		ChunkSize:  candidate.ChunkSize,
+		Dictionary: candidate.UseDictionary,
+		Threads:    candidate.MaxThreads,
+	}
+	defer os.Remove(candidate.OutputPath)
+
+	start := time.Now()
+	result, err := CompressContext(ctx, candidate, nil)
+	duration := time.Since(start)
+	if ctx.Err() != nil {
+		return run, ctx.Err()
+	}
+	if err == nil && len(result.Errors) > 0 {
+		err = fmt.Errorf("%d files failed, first: %w", len(result.Errors), result.Errors[0])
+	}
+	var info os.FileInfo
+	if err == nil {
+		info, err = os.Stat(candidate.OutputPath)
+	}
+	if err != nil {
+		run.Error = err.Error()
+		return run, nil
+	}
+
+	run.OriginalSize = result.OriginalSize
+	run.CompressedSize = uint64(info.Size())
+	run.Duration = duration
+	if run.OriginalSize > 0 {
+		run.Ratio = float64(run.CompressedSize) / float64(run.OriginalSize) * 100
+	}
+	if duration > 0 {
+		run.BytesPerSecond = float64(run.OriginalSize) / duration.Seconds()
+	}
+	return run, nil
+}
+
+// sampleInput picks files of folders worth about size bytes out of total,
+// spread over the whole input: a file is taken whenever the sample falls
+// behind size/total of the bytes seen so far. The whole input is taken
+// when it isn't larger than size.
+func sampleInput(folders []folderTask, total, size uint64) ([]folderTask, int, uint64) {
+	var sample []folderTask
+	var files int
+	var sampled, seen uint64
+	share := float64(size) / float64(max(total, 1))
+	for _, folder := range folders {
+		var picked []fileTask
+		for _, task := range folder.Files {
+			seen += task.OrigSize
+			if total > size && float64(sampled) >= float64(seen)*share {
+				continue
+			}
+			picked = append(picked, task)
+			sampled += task.OrigSize
+			files++
+		}
+		if len(picked) > 0 {
+			sample = append(sample, folderTask{FolderPath: folder.FolderPath, Files: picked})
+		}
+	}
+	return sample, files, sampled
+}
+
+// sampleFolders returns the sampled files of a Bench run as collectFiles
+// would, bound to the sources of the run
+func sampleFolders(sample []folderTask, sources *sourceSet) ([]folderTask, int, uint64, error) {
+	folders := make([]folderTask, len(sample))
+	var totalFiles int
+	var totalOrigSize uint64
+	for i, folder := range sample {
+		files := make([]fileTask, len(folder.Files))
+		for j, task := range folder.Files {
+			task.sources = sources
+			files[j] = task
+			totalOrigSize += task.OrigSize
+		}
+		totalFiles += len(files)
+		folders[i] = folderTask{FolderPath: folder.FolderPath, Files: files}
+	}
+	return folders, totalFiles, totalOrigSize, nil
+}
@@ -0,0 +1,85 @@
+// pkg/compress/bench_test.go
+package compress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBench(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 20 {
+		content := strings.Repeat(fmt.Sprintf("line %d of a log file with some text\n", i), 500)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%02d.log", i)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var seen int
+	result, err := Bench(context.Background(), &Options{InputPath: dir}, BenchMatrix{
+		Levels:     []int{1, 9},
+		ChunkSizes: []uint64{0, 8 * 1024},
+		Dictionary: []bool{false, true},
+		Threads:    []int{2},
+	}, func(BenchRun) { seen++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Whole files and 8KB chunks, then the dictionary, at each level
+	if len(result.Runs) != 6 || seen != 6 {
+		t.Fatalf("%d runs, %d reported, want 6", len(result.Runs), seen)
+	}
+	if result.SampleFiles != 20 || result.SampleSize != result.InputSize {
+		t.Errorf("sampled %d files, %d of %d bytes, want the whole input", result.SampleFiles, result.SampleSize, result.InputSize)
+	}
+	for _, run := range result.Runs {
+		if run.Error != "" {
+			t.Errorf("%s level %d: %s", run.Mode(), run.Level, run.Error)
+			continue
+		}
+		if run.OriginalSize != result.SampleSize || run.CompressedSize == 0 || run.Ratio >= 100 {
+			t.Errorf("%s level %d: %+v", run.Mode(), run.Level, run)
+		}
+	}
+	if !result.Runs[4].Dictionary || result.Runs[4].ChunkSize != 0 {
+		t.Errorf("run 4 %+v, want a dictionary run", result.Runs[4])
+	}
+	if result.Smallest() == nil || result.Fastest() == nil {
+		t.Error("no smallest or fastest run")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 20 {
+		t.Errorf("input holds %d entries after the benchmark, want 20", len(entries))
+	}
+}
+
+func TestSampleInputSpreadsOverInput(t *testing.T) {
+	var folders []folderTask
+	for i := range 10 {
+		folder := folderTask{FolderPath: fmt.Sprintf("d%d", i)}
+		for j := range 10 {
+			folder.Files = append(folder.Files, fileTask{RelPath: fmt.Sprintf("d%d/f%d", i, j), OrigSize: 100})
+		}
+		folders = append(folders, folder)
+	}
+
+	sample, files, size := sampleInput(folders, 10000, 1000)
+	if files != 10 || size != 1000 {
+		t.Errorf("sampled %d files, %d bytes, want 10 and 1000", files, size)
+	}
+	if len(sample) != 10 {
+		t.Errorf("sample spans %d folders, want all 10", len(sample))
+	}
+}
+
+func TestBenchRejectsStream(t *testing.T) {
+	_, err := Bench(context.Background(), &Options{FromStream: os.Stdin}, BenchMatrix{}, nil)
+	if !errors.Is(err, ErrBenchStream) {
+		t.Errorf("got %v, want ErrBenchStream", err)
+	}
+}
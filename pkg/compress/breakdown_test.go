@@ -0,0 +1,84 @@
+// pkg/compress/breakdown_test.go
+package compress
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResultBreakdown(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in")
+	files := map[string]string{
+		"docs/a.md":    strings.Repeat("a", 4000),
+		"docs/b.MD":    strings.Repeat("b", 2000),
+		"src/main.go":  strings.Repeat("c", 1000),
+		"README":       "readme",
+		"src/sub/x.go": strings.Repeat("d", 500),
+	}
+	for name, data := range files {
+		path := filepath.Join(input, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	modes := map[string]func(*Options){
+		"gdelta01": func(*Options) {},
+		"gdelta02": func(o *Options) { o.ChunkSize = 4 * 1024 },
+		"zip":      func(o *Options) { o.UseZipFormat = true },
+	}
+	for name, mode := range modes {
+		t.Run(name, func(t *testing.T) {
+			opts := &Options{
+				InputPath:  input,
+				OutputPath: filepath.Join(dir, name+".out"),
+				MaxThreads: 2,
+				Quiet:      true,
+			}
+			mode(opts)
+			result, err := Compress(opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wantFolders := map[string]GroupStats{
+				"docs": {Files: 2, OriginalSize: 6000},
+				"src":  {Files: 2, OriginalSize: 1500},
+				".":    {Files: 1, OriginalSize: 6},
+			}
+			wantExts := map[string]GroupStats{
+				".md": {Files: 2, OriginalSize: 6000},
+				".go": {Files: 2, OriginalSize: 1500},
+				"":    {Files: 1, OriginalSize: 6},
+			}
+			checks := []struct {
+				got, want map[string]GroupStats
+			}{
+				{result.ByFolder, wantFolders},
+				{result.ByExtension, wantExts},
+			}
+			for _, c := range checks {
+				if len(c.got) != len(c.want) {
+					t.Errorf("got groups %v, want %v", c.got, c.want)
+				}
+				for key, w := range c.want {
+					g := c.got[key]
+					if g.Files != w.Files || g.OriginalSize != w.OriginalSize {
+						t.Errorf("%q: got %+v, want %d files of %d bytes", key, g, w.Files, w.OriginalSize)
+					}
+				}
+			}
+
+			// Multi-part ZIP has no per-file compressed size
+			if name != "zip" && result.ByFolder["docs"].CompressedSize == 0 {
+				t.Error("expected a compressed size for docs")
+			}
+		})
+	}
+}
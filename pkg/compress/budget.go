@@ -0,0 +1,211 @@
+// pkg/compress/budget.go
+package compress
+
+import (
+	"fmt"
+	"math/bits"
+	"runtime"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// memoryBudget is a counting semaphore over bytes. The large allocations of
+// a run (worker encoders and buffers, queued entries, segments in flight,
+// dictionary samples) acquire their estimated size from it before
+// allocating, so the total stays under Options.MemoryBudget.
+//
+// A nil budget is unlimited: every method is a no-op.
+type memoryBudget struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	limit uint64
+	used  uint64
+	peak  uint64
+
+	worker uint64 // fixed slot held by each compression worker
+	spare  uint64 // kept free of queued entries so segments make progress
+}
+
+func newMemoryBudget(limit uint64) *memoryBudget {
+	if limit == 0 {
+		return nil
+	}
+	b := &memoryBudget{limit: limit}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// acquire blocks until n bytes are available. Callers must not hold other
+// budget bytes they are waiting on someone else to free, except for the
+// fixed worker slots, which planMemory sizes so that this always proceeds.
+func (b *memoryBudget) acquire(n uint64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.used+n > b.limit {
+		b.cond.Wait()
+	}
+	b.take(n)
+}
+
+// tryQueue takes n bytes for an entry waiting on the archive tail if they
+// are available right now, leaving the segment spare untouched
+func (b *memoryBudget) tryQueue(n uint64) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.used+n+b.spare > b.limit {
+		return false
+	}
+	b.take(n)
+	return true
+}
+
+func (b *memoryBudget) take(n uint64) {
+	b.used += n
+	b.peak = max(b.peak, b.used)
+}
+
+func (b *memoryBudget) release(n uint64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.used -= n
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+// acquireWorkers takes the fixed slots of n workers
+func (b *memoryBudget) acquireWorkers(n int) {
+	if b != nil {
+		b.acquire(b.worker * uint64(n))
+	}
+}
+
+func (b *memoryBudget) releaseWorkers(n int) {
+	if b != nil {
+		b.release(b.worker * uint64(n))
+	}
+}
+
+// free returns the bytes not held right now
+func (b *memoryBudget) free() uint64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.limit - b.used
+}
+
+// peakUsage returns the most bytes held at once
+func (b *memoryBudget) peakUsage() uint64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.peak
+}
+
+// planMemory creates the run's budget and lowers MaxThreads until one fixed
+// slot per worker fits, halving MaxThreadMemory and SegmentSize first if not
+// even one does.
+// In GDELTA01 mode it also keeps room for one segment in flight: segments
+// acquire while their worker holds its slot, so they must always be able to
+// proceed. Returns nil when there is no budget.
+func planMemory(opts *Options) (*memoryBudget, error) {
+	budget := newMemoryBudget(opts.MemoryBudget)
+	if budget == nil {
+		return nil, nil
+	}
+
+	for workers := opts.MaxThreads; ; {
+		budget.spare = 0
+		if opts.ChunkSize == 0 && !opts.UseDictionary {
+			if s := newSegmenter(opts, nil); s != nil {
+				budget.spare = s.memory()
+			}
+		}
+		slot := workerMemory(opts, workers)
+		if slot+budget.spare > budget.limit {
+			if opts.MaxThreadMemory > smallEntrySize {
+				opts.MaxThreadMemory /= 2
+				continue
+			}
+			if s := newSegmenter(opts, nil); budget.spare > 0 && s.size > minSegmentSize {
+				opts.SegmentSize = uint64(s.size) / 2
+				continue
+			}
+			return nil, fmt.Errorf("%w: one worker needs about %s", ErrMemoryBudgetTooSmall, FormatSize(slot+budget.spare))
+		}
+		fit := int((budget.limit - budget.spare) / slot)
+		if fit >= workers {
+			opts.MaxThreads = workers
+			budget.worker = slot
+			return budget, nil
+		}
+		workers = fit
+	}
+}
+
+// workerMemory estimates what one compression worker holds for the whole
+// run: its encoder plus its largest buffer
+func workerMemory(opts *Options, workers int) uint64 {
+	if opts.ChunkSize > 0 {
+		// Chunks reach ChunkMaxSize (4x the average size by default); the
+		// chunker's read buffer holds two of them. The chunk pipeline adds a
+		// queued and an in-flight copy per worker, pooled by power-of-two
+		// size, and the compressed copy.
+		maxChunk := opts.ChunkMaxSize
+		chunkCopy := uint64(1) << bits.Len64(maxChunk-1)
+		return encoderMemory(opts.Level, 0, 1) + 2*maxChunk + 2*chunkCopy + compressBound(maxChunk)
+	}
+	if opts.UseDictionary {
+		// Entries go through temp files: the encoder and its dictionary
+		return encoderMemory(opts.Level, opts.zstdWindow(), 2) + MaxDictSize
+	}
+	// A streaming encoder fills one block while the previous one is
+	// compressed, whatever its concurrency
+	concurrency := min(max(runtime.GOMAXPROCS(0)/workers, 1), 2)
+	// The buffer of in-memory entries, sized by the largest compressed one
+	buffer := max(opts.MaxThreadMemory, smallEntrySize)
+	return encoderMemory(opts.Level, opts.zstdWindow(), concurrency) + compressBound(buffer)
+}
+
+// encoderMemory estimates a zstd encoder's footprint: each concurrent block
+// encoder keeps a history of twice the window, plus match tables that grow
+// with the level. window 0 means the level's default.
+func encoderMemory(level, window, concurrency int) uint64 {
+	encLevel := zstd.EncoderLevelFromZstd(level)
+	if window == 0 {
+		window = 8 << 20
+		if encLevel == zstd.SpeedFastest {
+			window = 4 << 20
+		}
+	}
+	var tables uint64
+	switch encLevel {
+	case zstd.SpeedFastest:
+		tables = 256 << 10
+	case zstd.SpeedDefault:
+		tables = 1 << 20
+	case zstd.SpeedBetterCompression:
+		tables = 4 << 20
+	default:
+		tables = 16 << 20
+	}
+	return uint64(concurrency) * (2*uint64(window) + tables)
+}
+
+// compressBound is the largest compressed size of n bytes (zstd's bound,
+// which also covers the stored method)
+func compressBound(n uint64) uint64 {
+	return n + n/128 + 64<<10
+}
@@ -0,0 +1,112 @@
+// pkg/compress/budget_test.go
+package compress
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryBudgetAcquire(t *testing.T) {
+	b := newMemoryBudget(100)
+	b.acquire(60)
+	if b.tryQueue(50) {
+		t.Fatal("tryQueue should fail past the limit")
+	}
+
+	var wg sync.WaitGroup
+	acquired := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		b.acquire(50)
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquire should block until memory is released")
+	case <-time.After(20 * time.Millisecond):
+	}
+	b.release(60)
+	wg.Wait()
+
+	if got := b.peakUsage(); got != 60 {
+		t.Errorf("peak: got %d, want 60", got)
+	}
+
+	// Queued entries leave the segment spare alone
+	b.spare = 40
+	if b.tryQueue(20) {
+		t.Error("tryQueue should not dip into the spare")
+	}
+	if !b.tryQueue(10) {
+		t.Error("tryQueue should succeed outside the spare")
+	}
+
+	var unlimited *memoryBudget
+	unlimited.acquire(1 << 40)
+	if !unlimited.tryQueue(1 << 40) {
+		t.Error("a nil budget is unlimited")
+	}
+}
+
+func TestPlanMemory(t *testing.T) {
+	base := Options{InputPath: "in", Level: 5, MaxThreads: 64}
+	if err := base.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		opts := base
+		budget, err := planMemory(&opts)
+		if err != nil || budget != nil || opts.MaxThreads != 64 {
+			t.Fatalf("got budget %v, err %v, %d threads", budget, err, opts.MaxThreads)
+		}
+	})
+
+	t.Run("lowers threads", func(t *testing.T) {
+		opts := base
+		opts.MemoryBudget = 256 << 20
+		budget, err := planMemory(&opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts.MaxThreads >= 64 || opts.MaxThreads < 1 {
+			t.Fatalf("expected fewer threads, got %d", opts.MaxThreads)
+		}
+		if used := budget.worker*uint64(opts.MaxThreads) + budget.spare; used > opts.MemoryBudget {
+			t.Errorf("plan uses %d bytes of a %d budget", used, opts.MemoryBudget)
+		}
+	})
+
+	t.Run("shrinks buffers", func(t *testing.T) {
+		opts := base
+		opts.MaxThreadMemory = 1 << 30
+		opts.MemoryBudget = 128 << 20
+		if _, err := planMemory(&opts); err != nil {
+			t.Fatal(err)
+		}
+		if opts.MaxThreadMemory >= 1<<30 {
+			t.Errorf("MaxThreadMemory not lowered: %d", opts.MaxThreadMemory)
+		}
+	})
+
+	t.Run("too small", func(t *testing.T) {
+		opts := base
+		opts.MemoryBudget = 1 << 20
+		if _, err := planMemory(&opts); !errors.Is(err, ErrMemoryBudgetTooSmall) {
+			t.Fatalf("expected ErrMemoryBudgetTooSmall, got %v", err)
+		}
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		opts := base
+		opts.UseZipFormat = true
+		opts.MemoryBudget = 1 << 30
+		if err := opts.Validate(); !errors.Is(err, ErrMemoryBudgetFormat) {
+			t.Fatalf("expected ErrMemoryBudgetFormat, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+// pkg/compress/change.go
+package compress
+
+import (
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// sourceFile reads a file to compress as it was scanned: exactly
+// task.OrigSize bytes, cut short if the file grew and padded with zeros if
+// it shrank, so entry headers written from the scanned size stay
+// consistent. Close records the file as changed when its size or
+// modification time moved while it was read.
+type sourceFile struct {
+	file   *os.File
+	task   fileTask
+	off    int64 // Read position
+	shrunk atomic.Bool
+	closed bool
+}
+
+// openSource opens the file of task for compression
+func openSource(task fileTask) (*sourceFile, error) {
+	file, err := task.sources.open(task)
+	if err != nil {
+		return nil, err
+	}
+	return &sourceFile{file: file, task: task}, nil
+}
+
+func (s *sourceFile) Read(p []byte) (int, error) {
+	if s.off >= int64(s.task.OrigSize) {
+		return 0, io.EOF
+	}
+	n, err := s.ReadAt(p, s.off)
+	s.off += int64(n)
+	return n, err
+}
+
+// ReadAt is safe for concurrent use, as segments read the file in parallel
+func (s *sourceFile) ReadAt(p []byte, off int64) (int, error) {
+	size := int64(s.task.OrigSize)
+	if off >= size {
+		return 0, io.EOF
+	}
+	if remaining := size - off; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := s.file.ReadAt(p, off)
+	s.task.sources.uncache(s.file, off, int64(n))
+	if err == io.EOF {
+		// Shorter than scanned: zeros keep the entry at its recorded size
+		clear(p[n:])
+		s.shrunk.Store(true)
+		n, err = len(p), nil
+	}
+	return n, err
+}
+
+// Close checks the file against its scan before closing it
+func (s *sourceFile) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.changed() {
+		s.task.sources.changed(s.task.RelPath)
+	}
+	s.task.sources.uncache(s.file, 0, 0)
+	return s.file.Close()
+}
+
+// changed reports whether the file no longer matches its scan
+func (s *sourceFile) changed() bool {
+	if s.shrunk.Load() {
+		return true
+	}
+	info, err := s.file.Stat()
+	if err != nil {
+		return true
+	}
+	if info.Size() != int64(s.task.OrigSize) {
+		return true
+	}
+	return s.task.Info != nil && !info.ModTime().Equal(s.task.Info.ModTime())
+}
@@ -0,0 +1,112 @@
+// pkg/compress/change_test.go
+package compress
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+)
+
+func TestSourceFileKeepsScannedSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "live.log")
+	if err := os.WriteFile(path, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(change func()) ([]byte, bool) {
+		sources := newSourceSet(&Options{})
+		src, err := openSource(fileTask{AbsPath: path, RelPath: "live.log", Info: info, OrigSize: 10, sources: sources})
+		if err != nil {
+			t.Fatal(err)
+		}
+		change()
+		data, err := io.ReadAll(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		src.Close()
+		return data, len(sources.fuzzy.snapshot()) == 1
+	}
+
+	if data, changed := read(func() {}); string(data) != "0123456789" || changed {
+		t.Errorf("unchanged file: read %q, changed %v", data, changed)
+	}
+
+	grow := func() {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.WriteString("more")
+		f.Close()
+	}
+	if data, changed := read(grow); string(data) != "0123456789" || !changed {
+		t.Errorf("grown file: read %q, changed %v", data, changed)
+	}
+
+	shrink := func() { os.Truncate(path, 4) }
+	if data, changed := read(shrink); !bytes.Equal(data, []byte("0123\x00\x00\x00\x00\x00\x00")) || !changed {
+		t.Errorf("shrunk file: read %q, changed %v", data, changed)
+	}
+}
+
+func TestCompressChangeRetries(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in")
+	if err := os.MkdirAll(input, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(input, "live.log")
+
+	for _, retries := range []int{0, 1} {
+		if err := os.WriteFile(path, []byte("first line\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		archive := filepath.Join(dir, "out.gdelta")
+		os.Remove(archive)
+
+		// The file grows between the scan and the read
+		result, err := Compress(&Options{
+			InputPath:     input,
+			OutputPath:    archive,
+			MaxThreads:    1,
+			ChangeRetries: retries,
+			Quiet:         true,
+		}, func(event ProgressEvent) {
+			if event.Type == EventStart {
+				if err := os.WriteFile(path, []byte("first line\nsecond line\n"), 0644); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want, fuzzy := "first line\nsecond line\n", []string(nil)
+		if retries == 0 {
+			want, fuzzy = "first line\n", []string{"live.log"}
+		}
+		if !slices.Equal(result.Fuzzy, fuzzy) {
+			t.Errorf("retries %d: fuzzy %v, want %v", retries, result.Fuzzy, fuzzy)
+		}
+
+		out := filepath.Join(dir, "out")
+		os.RemoveAll(out)
+		if _, err := decompress.Decompress(&decompress.Options{InputPath: archive, OutputPath: out, Quiet: true}, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := os.ReadFile(filepath.Join(out, "live.log")); string(got) != want {
+			t.Errorf("retries %d: restored %q, want %q", retries, got, want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// pkg/compress/chunk_dict.go
+package compress
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// trainChunkDictionary builds the GDELTA02 chunk dictionary
+// (Options.ChunkDictionary). Samples are taken the way GDELTA03 takes them
+// but are at most one chunk long, so the dictionary learns what chunks have
+// in common, and it is kept small: every chunk is compressed with it. An
+// input too small to train on yields an empty dictionary.
+func trainChunkDictionary(ctx context.Context, folders []folderTask, opts *Options, progressCb ProgressCallback, result *Result, budget *memoryBudget) ([]byte, error) {
+	var files []fileTask
+	for _, folder := range folders {
+		files = append(files, folder.Files...)
+	}
+
+	if progressCb != nil {
+		progressCb(ProgressEvent{
+			Type:     EventDictTraining,
+			FilePath: "Training chunk dictionary...",
+		})
+	}
+
+	params := analyzeDictParams(files, opts.Verbose)
+	params.maxDictSize = MinDictSize
+	params.maxSampleSize = min(params.maxSampleSize, int64(opts.ChunkSize))
+
+	trainStart := time.Now()
+	dictionary, err := buildDictionary(ctx, files, params, opts.MaxThreads, budget, opts.Verbose)
+	result.Timing.DictTraining = time.Since(trainStart)
+	if err != nil {
+		return nil, fmt.Errorf("train chunk dictionary: %w", err)
+	}
+
+	if opts.Verbose {
+		if len(dictionary) > 0 {
+			fmt.Printf("Chunk dictionary built: %d bytes\n", len(dictionary))
+		} else {
+			fmt.Printf("Chunk dictionary empty - chunks will be compressed without one\n")
+		}
+	}
+	return dictionary, nil
+}
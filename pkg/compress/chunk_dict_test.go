@@ -0,0 +1,104 @@
+// pkg/compress/chunk_dict_test.go
+package compress
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// TestChunkDictionary compresses many small similar records with and without
+// a chunk dictionary: the dictionary lands in the header, shrinks the
+// archive, and verify and decompress read the chunks back with it
+func TestChunkDictionary(t *testing.T) {
+	input := t.TempDir()
+	files := make(map[string]string)
+	for i := range 300 {
+		name := fmt.Sprintf("records/%03d.json", i)
+		files[name] = fmt.Sprintf(`{"id": %d, "kind": "sensor-reading", "location": {"site": "plant-%d", "rack": %d},
+"status": "nominal", "firmware": "v2.4.%d", "tags": ["temperature", "humidity", "pressure"], "value": %d.%02d}
+`, i, i%7, i%13, i%5, i*37%1000, i%100)
+		createFile(t, input, name, files[name])
+	}
+
+	dir := t.TempDir()
+	sizes := make(map[bool]uint64)
+	for _, dict := range []bool{false, true} {
+		output := filepath.Join(dir, fmt.Sprintf("dict-%v.gdelta", dict))
+		result, err := Compress(&Options{
+			InputPath:       input,
+			OutputPath:      output,
+			ChunkSize:       4 << 10,
+			ChunkDictionary: dict,
+			MaxThreads:      2,
+			Quiet:           true,
+		}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes[dict] = result.CompressedSize
+
+		f, err := os.Open(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header, err := format.ReadGDelta02Header(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(header.ChunkDictionary()) > 0; got != dict {
+			t.Fatalf("dictionary in header: %v, want %v", got, dict)
+		}
+
+		vres, err := verify.Verify(&verify.Options{InputPath: output, VerifyData: true, Quiet: true}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !vres.IsValid() || vres.ChunksVerified != int(result.UniqueChunks) {
+			t.Fatalf("verify: %d of %d chunks, errors %v", vres.ChunksVerified, result.UniqueChunks, vres.Errors)
+		}
+		if dict && vres.DictSize == 0 {
+			t.Error("verify reports no chunk dictionary")
+		}
+
+		out := t.TempDir()
+		dres, err := decompress.Decompress(&decompress.Options{InputPath: output, OutputPath: out, Quiet: true}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !dres.Success() || dres.FilesProcessed != len(files) {
+			t.Fatalf("restored %d files, errors %v", dres.FilesProcessed, dres.Errors)
+		}
+		for name, data := range files {
+			got, err := os.ReadFile(filepath.Join(out, name))
+			if err != nil || string(got) != data {
+				t.Fatalf("%s: content mismatch (%v)", name, err)
+			}
+		}
+	}
+
+	if sizes[true] >= sizes[false] {
+		t.Errorf("archive with a chunk dictionary is %d bytes, %d without", sizes[true], sizes[false])
+	}
+}
+
+func TestChunkDictionaryValidation(t *testing.T) {
+	for name, opts := range map[string]*Options{
+		"no chunking": {InputPath: ".", ChunkDictionary: true},
+		"codec":       {InputPath: ".", ChunkSize: 64 << 10, Codec: "lz4", ChunkDictionary: true},
+		"stream":      {InputPath: "-", FromStream: strings.NewReader("x"), ChunkDictionary: true},
+		"tar":         {FromTar: strings.NewReader(""), ChunkDictionary: true},
+	} {
+		if err := opts.Validate(); !errors.Is(err, ErrChunkDictionaryFormat) {
+			t.Errorf("%s: got %v, want ErrChunkDictionaryFormat", name, err)
+		}
+	}
+}
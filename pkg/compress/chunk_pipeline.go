@@ -0,0 +1,181 @@
+// pkg/compress/chunk_pipeline.go
+package compress
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/creativeyann17/go-delta/internal/chunkstore"
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/pool"
+)
+
+// chunkPipeline is the second stage of GDELTA02 compression. File workers
+// read, split and hash; they hand each chunk to this pool, whose workers
+// look it up in the store and compress and write the new ones. Reading one
+// file thus overlaps with compressing the chunks of the previous ones, and
+// a few huge files keep every core busy.
+type chunkPipeline struct {
+	jobs  chan chunkJob
+	wg    sync.WaitGroup
+	store *chunkstore.Store
+	refs  *referenceIndex // nil without reference directories
+	codec format.Method
+	level int
+
+	writer   io.Writer // nil in dry-run mode
+	writerMu sync.Mutex
+	offset   uint64
+
+	// storedChunks counts the chunks kept uncompressed
+	storedChunks atomic.Uint64
+
+	// extensions tallies the dedup figures of each file once its chunks
+	// are stored
+	extensions *extensionTally
+}
+
+// chunkJob is one chunk waiting for a chunk worker; data is a pooled copy
+type chunkJob struct {
+	hash [32]byte
+	data []byte
+	file *fileChunks
+}
+
+// fileChunks tracks the chunks of one file still in the pipeline
+type fileChunks struct {
+	wg   sync.WaitGroup
+	once sync.Once
+	err  error
+
+	deduped      atomic.Uint64 // Chunks found already stored
+	dedupedBytes atomic.Uint64 // Their original size
+	storedBytes  atomic.Uint64 // Compressed size of the chunks it stored first
+}
+
+func (f *fileChunks) fail(err error) {
+	f.once.Do(func() { f.err = err })
+}
+
+// wait blocks until every chunk submitted for the file is stored and
+// returns the first error
+func (f *fileChunks) wait() error {
+	f.wg.Wait()
+	return f.err
+}
+
+// newChunkPipeline starts workers chunk workers writing to writer. The
+// queue holds one chunk per worker, so a file worker runs at most that far
+// ahead of compression. zstd chunks are compressed with dictionary when it
+// isn't empty. Chunks found in refs (may be nil) are left to the reference
+// directories instead of stored.
+func newChunkPipeline(workers int, store *chunkstore.Store, refs *referenceIndex, writer io.Writer, codec format.Method, level int, dictionary []byte) (*chunkPipeline, error) {
+	p := &chunkPipeline{
+		jobs:       make(chan chunkJob, workers),
+		store:      store,
+		refs:       refs,
+		codec:      codec,
+		level:      level,
+		writer:     writer,
+		extensions: newExtensionTally(),
+	}
+
+	// Encoders are created up front so a failure is reported before any
+	// file is read
+	cfg := pool.EncoderConfig{Level: level, Concurrency: 1}
+	for range workers {
+		var enc *zstd.Encoder
+		var release func()
+		var err error
+		if len(dictionary) > 0 {
+			if enc, err = newWorkerEncoder(level, workers, 0, dictionary); err == nil {
+				release = func() { enc.Close() }
+			}
+		} else if enc, _, err = cfg.Encoder(); err == nil {
+			release = func() { cfg.PutEncoder(enc) }
+		}
+		if err != nil {
+			close(p.jobs)
+			p.wg.Wait()
+			return nil, fmt.Errorf("create zstd encoder: %w", err)
+		}
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			defer release()
+
+			// Reusable buffer for compressed chunk data (EncodeAll appends into it)
+			var compressBuf []byte
+			for job := range p.jobs {
+				if p.refs.take(job.hash) {
+					job.file.deduped.Add(1)
+					job.file.dedupedBytes.Add(uint64(len(job.data)))
+					pool.PutBytes(job.data)
+					job.file.wg.Done()
+					continue
+				}
+				info, isNew, err := p.store.GetOrAddChunk(job.hash, uint64(len(job.data)), func() (chunkstore.ChunkInfo, error) {
+					compressed, err := encodeChunk(compressBuf[:0], job.data, p.codec, p.level, enc)
+					if err != nil {
+						return chunkstore.ChunkInfo{}, fmt.Errorf("compress chunk: %w", err)
+					}
+					compressBuf = compressed // keep grown capacity for next chunk
+					// A chunk compression doesn't shrink (media, archives)
+					// is kept as it is: no larger, and copied instead of
+					// decoded on restore
+					data, stored := compressed, false
+					if len(compressed) >= len(job.data) {
+						data, stored = job.data, true
+						p.storedChunks.Add(1)
+					}
+					offset, err := p.write(data)
+					return chunkstore.ChunkInfo{Offset: offset, CompressedSize: uint64(len(data)), Stored: stored}, err
+				})
+				if err != nil {
+					job.file.fail(fmt.Errorf("process chunk: %w", err))
+				} else if isNew {
+					job.file.storedBytes.Add(info.CompressedSize)
+				} else {
+					job.file.deduped.Add(1)
+					job.file.dedupedBytes.Add(uint64(len(job.data)))
+				}
+				pool.PutBytes(job.data)
+				job.file.wg.Done()
+			}
+		}()
+	}
+	return p, nil
+}
+
+// submit queues a copy of data, which the caller may then reuse
+func (p *chunkPipeline) submit(file *fileChunks, hash [32]byte, data []byte) {
+	buf := pool.Bytes(len(data))
+	copy(buf, data)
+	file.wg.Add(1)
+	p.jobs <- chunkJob{hash: hash, data: buf, file: file}
+}
+
+// write appends compressed chunk data and returns its offset in the chunk
+// data section
+func (p *chunkPipeline) write(data []byte) (uint64, error) {
+	p.writerMu.Lock()
+	defer p.writerMu.Unlock()
+	offset := p.offset
+	if p.writer != nil {
+		if _, err := p.writer.Write(data); err != nil {
+			return 0, fmt.Errorf("write chunk to file: %w", err)
+		}
+	}
+	p.offset += uint64(len(data))
+	return offset, nil
+}
+
+// close waits for the queued chunks and stops the workers
+func (p *chunkPipeline) close() {
+	close(p.jobs)
+	p.wg.Wait()
+}
@@ -0,0 +1,97 @@
+// pkg/compress/chunk_pipeline_test.go
+package compress
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/internal/chunkstore"
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestChunkPipeline(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var distinct [][]byte
+	for range 16 {
+		data := make([]byte, 8<<10+rng.Intn(8<<10))
+		rng.Read(data)
+		distinct = append(distinct, data)
+	}
+
+	var out bytes.Buffer
+	store := chunkstore.NewStore()
+	p, err := newChunkPipeline(4, store, nil, &out, format.MethodZstd, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Several "files" submit the same chunks concurrently, reusing their
+	// buffer after each submit like the chunker does
+	var wg sync.WaitGroup
+	for f := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var pending fileChunks
+			buf := make([]byte, 16<<10)
+			for i := range 32 {
+				data := distinct[(f+i)%len(distinct)]
+				n := copy(buf, data)
+				p.submit(&pending, sha256.Sum256(data), buf[:n])
+				clear(buf)
+			}
+			if err := pending.wait(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	p.close()
+
+	if got := store.Stats().UniqueChunks; got != uint64(len(distinct)) {
+		t.Fatalf("unique chunks: got %d, want %d", got, len(distinct))
+	}
+	dec, _ := zstd.NewReader(nil)
+	defer dec.Close()
+	for _, data := range distinct {
+		info, ok := store.Get(sha256.Sum256(data))
+		if !ok {
+			t.Fatal("chunk missing from store")
+		}
+		// Random chunks don't compress and are stored as they are
+		got := out.Bytes()[info.Offset : info.Offset+info.CompressedSize]
+		if !info.Stored {
+			got, err = dec.DecodeAll(got, nil)
+		}
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("chunk at %d does not round trip: %v", info.Offset, err)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestChunkPipelineWriteError(t *testing.T) {
+	p, err := newChunkPipeline(2, chunkstore.NewStore(), nil, failingWriter{}, format.MethodZstd, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.close()
+
+	var pending fileChunks
+	for i := range 4 {
+		data := []byte(fmt.Sprintf("chunk %d", i))
+		p.submit(&pending, sha256.Sum256(data), data)
+	}
+	if err := pending.wait(); err == nil {
+		t.Fatal("expected the write error to reach the file")
+	}
+}
@@ -3,16 +3,21 @@ package compress
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/pool"
 	"github.com/creativeyann17/go-delta/pkg/godelta"
 	"github.com/klauspost/compress/zstd"
 )
@@ -44,15 +49,39 @@ func resolveParallelism(parallelism Parallelism, folders []folderTask, maxThread
 // feedTasks streams every file into a shared channel, folder by folder, then
 // closes it. Workers pull from the channel as they become free, so load stays
 // balanced regardless of how files are distributed across folders.
-func feedTasks(folders []folderTask, capacity int) <-chan fileTask {
+// Feeding stops early when ctx is canceled: workers finish their current file
+// and exit once the channel drains.
+func feedTasks(ctx context.Context, folders []folderTask, capacity int) <-chan fileTask {
 	ch := make(chan fileTask, capacity)
 	go func() {
+		defer close(ch)
 		for _, folder := range folders {
 			for _, task := range folder.Files {
-				ch <- task
+				select {
+				case ch <- task:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+// feedFolders is the folder-mode counterpart of feedTasks: whole folders are
+// handed out, and feeding stops when ctx is canceled. Workers should also
+// check ctx between the files of a folder.
+func feedFolders(ctx context.Context, folders []folderTask) <-chan folderTask {
+	ch := make(chan folderTask, len(folders))
+	go func() {
+		defer close(ch)
+		for _, folder := range folders {
+			select {
+			case ch <- folder:
+			case <-ctx.Done():
+				return
 			}
 		}
-		close(ch)
 	}()
 	return ch
 }
@@ -61,16 +90,16 @@ func feedTasks(folders []folderTask, capacity int) <-chan fileTask {
 // The encoder is reused across files/chunks via Reset/EncodeAll instead of
 // being recreated per item (zstd.NewWriter allocates large buffers).
 // Internal encoder concurrency is divided by the worker count so the pool
-// doesn't oversubscribe CPUs.
-func newWorkerEncoder(level, maxThreads int, dictionary []byte) (*zstd.Encoder, error) {
-	concurrency := runtime.GOMAXPROCS(0) / maxThreads
-	if concurrency < 1 {
-		concurrency = 1
-	}
+// doesn't oversubscribe CPUs. window is the window size in bytes, 0 for the
+// level default.
+func newWorkerEncoder(level, maxThreads, window int, dictionary []byte) (*zstd.Encoder, error) {
 	encOpts := []zstd.EOption{
 		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
 		zstd.WithZeroFrames(true),
-		zstd.WithEncoderConcurrency(concurrency),
+		zstd.WithEncoderConcurrency(workerConcurrency(maxThreads)),
+	}
+	if window > 0 {
+		encOpts = append(encOpts, zstd.WithWindowSize(window))
 	}
 	if len(dictionary) > 0 {
 		encOpts = append(encOpts, zstd.WithEncoderDict(dictionary))
@@ -78,11 +107,64 @@ func newWorkerEncoder(level, maxThreads int, dictionary []byte) (*zstd.Encoder,
 	return zstd.NewWriter(nil, encOpts...)
 }
 
+// workerConcurrency is the internal concurrency of each worker's encoder
+func workerConcurrency(maxThreads int) int {
+	return max(runtime.GOMAXPROCS(0)/maxThreads, 1)
+}
+
+// encoderTimer counts encoder creations and their cost across workers,
+// reported in Result.Timing
+type encoderTimer struct {
+	count atomic.Int64
+	nanos atomic.Int64
+}
+
+// newWorkerEncoder returns a worker encoder and the function giving it back,
+// recording how long creating it took. Encoders without a dictionary come
+// from a pool shared across runs (watch mode and the daemon compress over
+// and over), so only those the pool lacked are counted.
+func (t *encoderTimer) newWorkerEncoder(level, maxThreads, window int, dictionary []byte) (*zstd.Encoder, func(), error) {
+	start := time.Now()
+	if len(dictionary) == 0 {
+		cfg := pool.EncoderConfig{Level: level, Concurrency: workerConcurrency(maxThreads), Window: window}
+		enc, created, err := cfg.Encoder()
+		if err != nil {
+			return nil, nil, err
+		}
+		if created {
+			t.add(time.Since(start))
+		}
+		return enc, func() { cfg.PutEncoder(enc) }, nil
+	}
+
+	enc, err := newWorkerEncoder(level, maxThreads, window, dictionary)
+	if err != nil {
+		return nil, nil, err
+	}
+	t.add(time.Since(start))
+	return enc, func() { enc.Close() }, nil
+}
+
+func (t *encoderTimer) add(d time.Duration) {
+	t.count.Add(1)
+	t.nanos.Add(int64(d))
+}
+
+// record adds the accumulated counts to timing
+func (t *encoderTimer) record(timing *Timing) {
+	timing.EncodersCreated += int(t.count.Load())
+	timing.EncoderSetup += time.Duration(t.nanos.Load())
+}
+
 type fileTask struct {
 	AbsPath  string
 	RelPath  string
 	Info     os.FileInfo
 	OrigSize uint64
+	Type     string // MIME type sniffed from the content (Options.SniffTypes)
+
+	sources *sourceSet // how the file is opened, where a change is recorded
+	pack    []fileTask // files of a small-file pack, OrigSize in all
 }
 
 type folderTask struct {
@@ -95,13 +177,25 @@ type ProgressCallback func(event ProgressEvent)
 
 // ProgressEvent contains progress information
 type ProgressEvent struct {
-	Type           EventType
-	FilePath       string
-	Current        int64
-	Total          int64
-	CurrentBytes   uint64
-	TotalBytes     uint64
-	CompressedSize uint64
+	Type           EventType `json:"type"`
+	FilePath       string    `json:"file,omitempty"`
+	Current        int64     `json:"current"`
+	Total          int64     `json:"total"`
+	CurrentBytes   uint64    `json:"current_bytes,omitempty"`
+	TotalBytes     uint64    `json:"total_bytes,omitempty"`
+	CompressedSize uint64    `json:"compressed_size,omitempty"`
+
+	// Run timing so far: average throughput, throughput over the last
+	// second and the estimated time left (0 until it can be estimated).
+	// Durations are in nanoseconds in JSON.
+	Elapsed               time.Duration `json:"elapsed_ns,omitempty"`
+	BytesPerSecond        float64       `json:"bytes_per_second,omitempty"`
+	InstantBytesPerSecond float64       `json:"instant_bytes_per_second,omitempty"`
+	ETA                   time.Duration `json:"eta_ns,omitempty"`
+
+	// ChunkStats is a chunk store snapshot, set on file_complete and
+	// complete events of chunked (GDELTA02) runs
+	ChunkStats *ChunkStoreStats `json:"chunk_stats,omitempty"`
 }
 
 // EventType indicates the type of progress event
@@ -117,24 +211,186 @@ const (
 	EventDictTraining // Dictionary training phase for GDELTA03
 )
 
+var eventTypeNames = [...]string{
+	EventStart:        "start",
+	EventFileStart:    "file_start",
+	EventFileProgress: "file_progress",
+	EventFileComplete: "file_complete",
+	EventComplete:     "complete",
+	EventError:        "error",
+	EventDictTraining: "dict_training",
+}
+
+// String returns the event name used in JSON progress output
+func (t EventType) String() string {
+	if t >= 0 && int(t) < len(eventTypeNames) {
+		return eventTypeNames[t]
+	}
+	return fmt.Sprintf("event(%d)", int(t))
+}
+
+// MarshalText encodes the event type by name
+func (t EventType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// throttleProgress wraps progressCb to apply opts.ProgressInterval and
+// ProgressBytes and fill in the run timing
+func throttleProgress(progressCb ProgressCallback, opts *Options) ProgressCallback {
+	if progressCb == nil {
+		return nil
+	}
+	throttle := godelta.NewProgressThrottle(opts.ProgressInterval, opts.ProgressBytes)
+	return func(event ProgressEvent) {
+		deliver, rates := throttle.Observe(event.generic())
+		if deliver {
+			event.Elapsed = rates.Elapsed
+			event.BytesPerSecond, event.InstantBytesPerSecond = rates.BytesPerSecond, rates.InstantBytesPerSecond
+			event.ETA = rates.ETA
+			progressCb(event)
+		}
+	}
+}
+
 // Compress compresses files from inputPath into an archive at outputPath
 func Compress(opts *Options, progressCb ProgressCallback) (*Result, error) {
+	return CompressContext(context.Background(), opts, progressCb)
+}
+
+// CompressContext is like Compress but stops when ctx is canceled.
+// Every mode checks ctx between files (and dictionary training between
+// samples), removes the partial archive and returns an error wrapping
+// ctx.Err(). A file already being compressed is finished first. Going over
+// the FailFast or MaxErrors limit stops the run the same way, returning
+// ErrErrorBudget.
+func CompressContext(ctx context.Context, opts *Options, progressCb ProgressCallback) (_ *Result, err error) {
 	if err := opts.Validate(); err != nil {
 		return nil, err
 	}
+	// Workers started from now on inherit the lowered priority
+	if err := lowerPriority(opts.CPUPriority, opts.IOPriority); err != nil {
+		return nil, fmt.Errorf("lower priority: %w", err)
+	}
+	// Level and chunk size are picked before memory is planned for them
+	var tune *AutoTune
+	if opts.AutoLevel || opts.AutoChunkSize {
+		if opts, tune, err = autoTune(ctx, opts); err != nil {
+			return nil, err
+		}
+	}
+	budget, err := planMemory(opts)
+	if err != nil {
+		return nil, err
+	}
+	progressCb = throttleProgress(progressCb, opts)
+
+	// The input is read from a snapshot taken for the run
+	if opts.SnapshotMode != SnapshotNone {
+		snap, snapErr := takeSnapshot(opts.SnapshotMode, opts.InputPath)
+		if snapErr != nil {
+			return nil, fmt.Errorf("snapshot %s: %w", opts.InputPath, snapErr)
+		}
+		defer func() {
+			if releaseErr := snap.release(); releaseErr != nil && err == nil {
+				err = fmt.Errorf("release snapshot: %w", releaseErr)
+			}
+		}()
+		snapOpts := *opts
+		snapOpts.InputPath = snap.path
+		opts = &snapOpts
+	}
+	sources := newSourceSet(opts)
+
+	// Going over the error limit cancels the run like ctx would
+	ctx, limit, stopLimit := withErrorLimit(ctx, opts, sources)
+	defer stopLimit()
+	progressCb = limit.watch(progressCb)
+
+	start := time.Now()
+	result := &Result{MemoryBudget: opts.MemoryBudget, AutoTune: tune, breakdown: newBreakdownTally(), sources: sources}
+	result.governor = startGovernor(ctx, opts)
+	defer func() {
+		result.Timing.Total = time.Since(start)
+		result.MemoryPeak = budget.peakUsage()
+		result.governor.stop(result)
+		result.ByFolder, result.ByExtension = result.breakdown.snapshot()
+		result.Fuzzy, result.Locked = sources.fuzzy.snapshot(), sources.locked.snapshot()
+		if result.Locked != nil {
+			result.Errors = slices.DeleteFunc(result.Errors, func(e error) bool { return errors.Is(e, ErrFileLocked) })
+		}
+		err = limit.check(result, err)
+	}()
+
+	// The finished archive is read back last, after its manifest; split
+	// runs verify each of their archives
+	if opts.VerifyAfter && !opts.SplitByFolder {
+		defer func() {
+			if err == nil {
+				err = verifyArchive(opts, result)
+			}
+		}()
+	}
 
-	result := &Result{}
+	// A stream or block device is chunked as it is read, without a scan
+	if opts.streamInput() {
+		defer recordRun(opts, result, start)
+		result.ChunkSize = opts.ChunkSize
+		return result, compressFromStream(ctx, opts, progressCb, result, budget)
+	}
+
+	// A tar stream is read as it is converted, without a scan
+	if opts.FromTar != nil {
+		defer recordRun(opts, result, start)
+		result.ChunkSize = opts.ChunkSize
+		return result, compressFromTar(ctx, opts, progressCb, result, budget)
+	}
 
 	// Collect all files from either Files list or InputPath
 	foldersToCompress, totalFiles, totalOrigSize, err := collectFiles(opts, result)
 	if err != nil {
 		return nil, err
 	}
+	if opts.SniffTypes {
+		if err := sniffTypes(ctx, foldersToCompress, opts.MaxThreads, result); err != nil {
+			return nil, fmt.Errorf("compression canceled: %w", err)
+		}
+	}
+	if opts.Deterministic {
+		sortFolders(foldersToCompress)
+	}
+	result.Timing.Scan = time.Since(start)
 
+	// Paths that couldn't be scanned count against the error limit
+	if limit.add(len(result.Errors)) {
+		return result, context.Cause(ctx)
+	}
 	if totalFiles == 0 {
 		return nil, ErrNoFiles
 	}
 
+	// Each top-level folder goes to an archive of its own, each run
+	// recording its metrics and writing its manifest
+	if opts.SplitByFolder {
+		result.FilesTotal = totalFiles
+		result.OriginalSize = totalOrigSize
+		result.ChunkSize = opts.ChunkSize
+		return result, compressSplit(ctx, opts, progressCb, foldersToCompress, result)
+	}
+	defer recordRun(opts, result, start)
+
+	// The manifest is written once the archive is complete, even with
+	// per-file errors; canceled or failed runs leave no archive behind
+	if opts.WriteManifest && !opts.DryRun {
+		defer func() {
+			if ctx.Err() != nil || (err != nil && len(result.Errors) == 0) {
+				return
+			}
+			if manifestErr := writeManifest(ctx, opts, foldersToCompress, result); manifestErr != nil && err == nil {
+				err = manifestErr
+			}
+		}()
+	}
+
 	result.FilesTotal = totalFiles
 	result.OriginalSize = totalOrigSize
 	result.ChunkSize = opts.ChunkSize
@@ -142,6 +398,14 @@ func Compress(opts *Options, progressCb ProgressCallback) (*Result, error) {
 	// Resolve parallelism strategy
 	resolvedParallelism := resolveParallelism(opts.Parallelism, foldersToCompress, opts.MaxThreads)
 
+	// Ordering replaces the folders with its groups, once the strategy was
+	// resolved from the real folder layout
+	if opts.FileOrder != nil {
+		if foldersToCompress, err = orderFiles(opts.FileOrder, foldersToCompress); err != nil {
+			return nil, err
+		}
+	}
+
 	if progressCb != nil {
 		progressCb(ProgressEvent{
 			Type:       EventStart,
@@ -153,31 +417,53 @@ func Compress(opts *Options, progressCb ProgressCallback) (*Result, error) {
 	// Route to ZIP compression if UseZipFormat is enabled
 	// (ZIP mode uses a shared work queue, no parallelism strategy needed)
 	if opts.UseZipFormat {
-		return result, compressToZip(opts, progressCb, foldersToCompress, totalFiles, totalOrigSize, result)
+		return result, compressToZip(ctx, opts, progressCb, foldersToCompress, totalFiles, totalOrigSize, result)
 	}
 
 	// Route to XZ compression if UseXzFormat is enabled
 	// (XZ mode uses a shared work queue, no parallelism strategy needed)
 	if opts.UseXzFormat {
-		return result, compressToXz(opts, progressCb, foldersToCompress, totalFiles, totalOrigSize, result)
+		return result, compressToXz(ctx, opts, progressCb, foldersToCompress, totalFiles, totalOrigSize, result)
+	}
+
+	// tar.gz and 7z parts are written like XZ ones
+	if opts.UseTarGzFormat {
+		return result, compressToTarGz(ctx, opts, progressCb, foldersToCompress, totalFiles, totalOrigSize, result)
+	}
+	if opts.Use7zFormat {
+		return result, compressTo7z(ctx, opts, progressCb, foldersToCompress, totalFiles, totalOrigSize, result)
 	}
 
 	// Route to dictionary compression if UseDictionary is enabled
 	if opts.UseDictionary {
-		return result, compressWithDictionary(opts, progressCb, foldersToCompress, totalFiles, totalOrigSize, result, resolvedParallelism)
+		return result, compressWithDictionary(ctx, opts, progressCb, foldersToCompress, totalFiles, totalOrigSize, result, resolvedParallelism, budget)
 	}
 
 	// Route to chunked compression if ChunkSize > 0
 	if opts.ChunkSize > 0 {
-		return result, compressWithChunking(opts, progressCb, foldersToCompress, totalFiles, totalOrigSize, result, resolvedParallelism)
+		return result, compressWithChunking(ctx, opts, progressCb, foldersToCompress, totalFiles, totalOrigSize, result, resolvedParallelism, budget)
 	}
 
 	// Traditional GDELTA01 compression (file-level)
-	// Uses streaming through temp files to avoid memory accumulation
+	// Files stream straight into the archive when its tail is free (see
+	// archiveAppender), through memory or temp files otherwise
+
+	// Files repeating an earlier one are left for links, written once
+	// every other entry is
+	unique, dups := foldersToCompress, []duplicate(nil)
+	if opts.DedupFiles {
+		if unique, dups, err = findDuplicates(ctx, foldersToCompress, opts.MaxThreads); err != nil {
+			return nil, fmt.Errorf("compression canceled: %w", err)
+		}
+	}
+
+	// Small files are grouped into packs, each one entry more
+	tasks, packs := packFiles(opts, unique)
 
 	// Create archive file (if not dry-run)
 	var writer io.WriteSeeker
-	var writerMu sync.Mutex
+	var outFile *os.File
+	var tail *archiveAppender
 
 	if !opts.DryRun {
 		// Ensure output directory exists
@@ -186,7 +472,7 @@ func Compress(opts *Options, progressCb ProgressCallback) (*Result, error) {
 			return nil, fmt.Errorf("create output directory: %w", err)
 		}
 
-		outFile, err := os.Create(opts.OutputPath)
+		outFile, err = os.Create(opts.OutputPath)
 		if err != nil {
 			return nil, fmt.Errorf("create output file: %w", err)
 		}
@@ -195,46 +481,23 @@ func Compress(opts *Options, progressCb ProgressCallback) (*Result, error) {
 		writer = outFile
 
 		// Write archive header
-		if err := format.WriteArchiveHeader(writer, uint32(totalFiles)); err != nil {
+		if err := format.WriteArchiveHeader(writer, uint32(totalFiles+packs)); err != nil {
 			return nil, fmt.Errorf("write archive header: %w", err)
 		}
+		tail = newArchiveAppender(outFile, opts, budget)
 	}
 
 	// Process files with worker pool
 	var totalComprSize uint64
 	var processedCount atomic.Uint32
+	var storedCount atomic.Uint32
+	var segmentedCount atomic.Uint32
+	var packedCount, packCount atomic.Uint32
+	segments := newSegmenter(opts, budget)
 	var errorsMu sync.Mutex
 
 	var wg sync.WaitGroup
-
-	// Helper function to write a single file entry, streaming compressed data
-	writeFileEntry := func(relPath string, origSize uint64, data io.Reader, compressedSize uint64) error {
-		writerMu.Lock()
-		defer writerMu.Unlock()
-
-		// Write file entry header
-		entryStart, err := format.WriteFileEntry(writer, relPath, origSize)
-		if err != nil {
-			return fmt.Errorf("write entry: %w", err)
-		}
-
-		// Get data offset
-		dataStart, err := writer.Seek(0, io.SeekCurrent)
-		if err != nil {
-			return fmt.Errorf("seek: %w", err)
-		}
-
-		if _, err := io.Copy(writer, data); err != nil {
-			return fmt.Errorf("copy compressed data: %w", err)
-		}
-
-		// Update entry with compressed size and offset
-		if err := format.UpdateFileEntry(writer, entryStart, compressedSize, uint64(dataStart)); err != nil {
-			return fmt.Errorf("update entry: %w", err)
-		}
-
-		return nil
-	}
+	var encoders encoderTimer
 
 	recordError := func(task fileTask, err error) {
 		errorsMu.Lock()
@@ -248,10 +511,74 @@ func Compress(opts *Options, progressCb ProgressCallback) (*Result, error) {
 		}
 	}
 
+	// handlePack compresses the files of a pack into one frame and writes
+	// it to the archive, followed by their entries
+	handlePack := func(task fileTask, enc *zstd.Encoder, memBuf *bytes.Buffer) {
+		method := opts.codecMethod()
+		frame, entries, failed, errs := readPack(task.pack, task.OrigSize, method)
+		defer pool.PutBytes(frame)
+		for i, err := range errs {
+			recordError(failed[i], err)
+		}
+		if len(entries) == 0 {
+			return
+		}
+
+		var out io.Writer = io.Discard
+		if !opts.DryRun {
+			memBuf.Reset()
+			out = memBuf
+		}
+		counter := &godelta.ProgressWriter{Writer: out}
+		var comprSize uint64
+		counter.OnWrite = func(n int) { comprSize += uint64(n) }
+		err := encodeEntry(counter, bytes.NewReader(frame), method, opts.Level, enc)
+		if err == nil && !opts.DryRun {
+			err = tail.add(queuedEntry{origSize: uint64(len(frame)), method: method, size: comprSize, data: memBuf.Bytes(), files: entries})
+		}
+		if err != nil {
+			for _, e := range entries {
+				recordError(fileTask{RelPath: e.Path}, err)
+			}
+			return
+		}
+		atomic.AddUint64(&totalComprSize, comprSize)
+
+		packCount.Add(1)
+		for _, e := range entries {
+			// Each file is credited its share of the frame
+			var share uint64
+			if len(frame) > 0 {
+				share = comprSize * e.OriginalSize / uint64(len(frame))
+			}
+			processedCount.Add(1)
+			packedCount.Add(1)
+			recordFile(opts, e.OriginalSize)
+			result.breakdown.add(e.Path, e.OriginalSize, share)
+			if progressCb != nil {
+				progressCb(ProgressEvent{
+					Type:           EventFileComplete,
+					FilePath:       e.Path,
+					Current:        int64(e.OriginalSize),
+					Total:          int64(e.OriginalSize),
+					CompressedSize: share,
+				})
+			}
+		}
+	}
+
 	// handleTask compresses one file and writes it to the archive.
 	// Small files (<= MaxThreadMemory) are compressed into a memory buffer and
 	// written directly; larger files stream through a temp file to bound RAM.
 	handleTask := func(task fileTask, enc *zstd.Encoder, memBuf *bytes.Buffer) {
+		result.governor.enter()
+		defer result.governor.leave()
+
+		if task.pack != nil {
+			handlePack(task, enc, memBuf)
+			return
+		}
+
 		// Skip progress bar for 0-byte files (no progress to show)
 		if progressCb != nil && task.OrigSize > 0 {
 			progressCb(ProgressEvent{
@@ -263,32 +590,94 @@ func Compress(opts *Options, progressCb ProgressCallback) (*Result, error) {
 
 		var comprSize uint64
 		var err error
+		method := opts.methodFor(task)
+
+		// Large zstd files are split into frames compressed in parallel
+		segmented := method == format.MethodZstd && segments.applies(task.OrigSize)
+		compressTo := func(w io.Writer) (uint64, error) {
+			if segmented {
+				return segments.compress(task, w, func(done uint64) {
+					if progressCb != nil {
+						progressCb(ProgressEvent{
+							Type:         EventFileProgress,
+							FilePath:     task.RelPath,
+							Current:      int64(done),
+							Total:        int64(task.OrigSize),
+							CurrentBytes: done,
+						})
+					}
+				})
+			}
+			return compressFileToWriter(task, w, enc, method, opts.Level, progressCb)
+		}
+
+		// A file that changed while it was read is read again, at its new
+		// size, while its entry is still buffered; reset empties the buffer
+		compressBuffered := func(w io.Writer, reset func() error) (uint64, error) {
+			for attempt := 0; ; attempt++ {
+				size, err := compressTo(w)
+				if err != nil || attempt == opts.ChangeRetries || !result.sources.retry(task.RelPath) {
+					return size, err
+				}
+				if err := reset(); err != nil {
+					return 0, err
+				}
+				info, err := os.Stat(task.AbsPath)
+				if err != nil {
+					return 0, err
+				}
+				task.Info, task.OrigSize = info, uint64(info.Size())
+			}
+		}
 
 		switch {
 		case opts.DryRun:
 			// Dry-run mode: just compress to discard
-			_, err = compressFileToWriter(task, io.Discard, enc, progressCb)
+			comprSize, err = compressBuffered(io.Discard, func() error { return nil })
 			if err != nil {
 				recordError(task, err)
 				return
 			}
 
-		case opts.MaxThreadMemory > 0 && task.OrigSize <= opts.MaxThreadMemory:
-			// In-memory path: avoids writing compressed data to disk twice
+		case task.OrigSize == 0:
+			// Empty files have nothing to read or compress
+			if err := tail.add(queuedEntry{relPath: task.RelPath, method: format.MethodStore}); err != nil {
+				recordError(task, err)
+				return
+			}
+
+		case task.OrigSize <= max(opts.MaxThreadMemory, smallEntrySize):
+			// In-memory path: compressed without holding the archive tail
 			memBuf.Reset()
-			comprSize, err = compressFileToWriter(task, memBuf, enc, progressCb)
+			comprSize, err = compressBuffered(memBuf, func() error {
+				memBuf.Reset()
+				return nil
+			})
 			if err != nil {
 				recordError(task, err)
 				return
 			}
-			if err := writeFileEntry(task.RelPath, task.OrigSize, memBuf, comprSize); err != nil {
+			entry := queuedEntry{relPath: task.RelPath, origSize: task.OrigSize, method: method, size: comprSize, data: memBuf.Bytes()}
+			if err := tail.add(entry); err != nil {
 				recordError(task, err)
 				return
 			}
 			atomic.AddUint64(&totalComprSize, comprSize)
 
 		default:
-			// Temp-file path: bounded memory for large files
+			// Stream into the archive if no other entry is being written
+			var streamed bool
+			comprSize, streamed, err = tail.stream(task.RelPath, task.OrigSize, method, compressTo)
+			if err != nil {
+				recordError(task, err)
+				return
+			}
+			if streamed {
+				atomic.AddUint64(&totalComprSize, comprSize)
+				break
+			}
+
+			// Tail busy: compress to a temp file and queue it (bounded memory)
 			tempFile, err := os.CreateTemp("", "godelta-file-*.tmp")
 			if err != nil {
 				recordError(task, fmt.Errorf("create temp file: %w", err))
@@ -296,24 +685,20 @@ func Compress(opts *Options, progressCb ProgressCallback) (*Result, error) {
 			}
 			tempPath := tempFile.Name()
 
-			comprSize, err = compressFileToWriter(task, tempFile, enc, progressCb)
+			comprSize, err = compressBuffered(tempFile, func() error {
+				if _, err := tempFile.Seek(0, io.SeekStart); err != nil {
+					return err
+				}
+				return tempFile.Truncate(0)
+			})
 			tempFile.Close()
 			if err != nil {
 				os.Remove(tempPath)
 				recordError(task, err)
 				return
 			}
-
-			tempData, err := os.Open(tempPath)
-			if err != nil {
-				os.Remove(tempPath)
-				recordError(task, fmt.Errorf("open temp file: %w", err))
-				return
-			}
-			err = writeFileEntry(task.RelPath, task.OrigSize, tempData, comprSize)
-			tempData.Close()
-			os.Remove(tempPath)
-			if err != nil {
+			entry := queuedEntry{relPath: task.RelPath, origSize: task.OrigSize, method: method, size: comprSize, tempPath: tempPath}
+			if err := tail.add(entry); err != nil {
 				recordError(task, err)
 				return
 			}
@@ -321,6 +706,14 @@ func Compress(opts *Options, progressCb ProgressCallback) (*Result, error) {
 		}
 
 		processedCount.Add(1)
+		if method == format.MethodStore {
+			storedCount.Add(1)
+		}
+		if segmented {
+			segmentedCount.Add(1)
+		}
+		recordFile(opts, task.OrigSize)
+		result.breakdown.add(task.RelPath, task.OrigSize, comprSize)
 		if progressCb != nil {
 			progressCb(ProgressEvent{
 				Type:           EventFileComplete,
@@ -332,57 +725,55 @@ func Compress(opts *Options, progressCb ProgressCallback) (*Result, error) {
 		}
 	}
 
+	// Workers hold their memory slot for the whole run
+	budget.acquireWorkers(opts.MaxThreads)
+
 	if resolvedParallelism == ParallelismFolder {
 		// Folder-based parallelism: workers grab whole folders
-		folderCh := make(chan folderTask, len(foldersToCompress))
+		folderCh := feedFolders(ctx, tasks)
 
 		for i := 0; i < opts.MaxThreads; i++ {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
 
-				enc, err := newWorkerEncoder(opts.Level, opts.MaxThreads, nil)
+				enc, release, err := encoders.newWorkerEncoder(opts.Level, opts.MaxThreads, opts.zstdWindow(), nil)
 				if err != nil {
 					errorsMu.Lock()
 					result.Errors = append(result.Errors, fmt.Errorf("create zstd encoder: %w", err))
 					errorsMu.Unlock()
 					return
 				}
-				defer enc.Close()
+				defer release()
 				var memBuf bytes.Buffer
 
 				for folder := range folderCh {
 					for _, task := range folder.Files {
+						if ctx.Err() != nil {
+							break
+						}
 						handleTask(task, enc, &memBuf)
 					}
 				}
 			}()
 		}
-
-		// Feed folder tasks
-		go func() {
-			for _, task := range foldersToCompress {
-				folderCh <- task
-			}
-			close(folderCh)
-		}()
 	} else {
 		// File-based parallelism: shared work queue, workers pull as they free up
-		taskCh := feedTasks(foldersToCompress, opts.MaxThreads*16)
+		taskCh := feedTasks(ctx, tasks, opts.MaxThreads*16)
 
 		for i := 0; i < opts.MaxThreads; i++ {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
 
-				enc, err := newWorkerEncoder(opts.Level, opts.MaxThreads, nil)
+				enc, release, err := encoders.newWorkerEncoder(opts.Level, opts.MaxThreads, opts.zstdWindow(), nil)
 				if err != nil {
 					errorsMu.Lock()
 					result.Errors = append(result.Errors, fmt.Errorf("create zstd encoder: %w", err))
 					errorsMu.Unlock()
 					return
 				}
-				defer enc.Close()
+				defer release()
 				var memBuf bytes.Buffer
 
 				for task := range taskCh {
@@ -393,15 +784,74 @@ func Compress(opts *Options, progressCb ProgressCallback) (*Result, error) {
 	}
 
 	wg.Wait()
+	encoders.record(&result.Timing)
+	budget.releaseWorkers(opts.MaxThreads)
+
+	if err := ctx.Err(); err != nil {
+		if outFile != nil {
+			tail.discard()
+			outFile.Close()
+			os.Remove(opts.OutputPath)
+		}
+		return nil, fmt.Errorf("compression canceled: %w", err)
+	}
+
+	// Write entries still queued for the tail
+	if tail != nil {
+		for _, err := range tail.finish() {
+			result.Errors = append(result.Errors, err)
+			processedCount.Add(^uint32(0))
+		}
+	}
+
+	// Duplicates link to the entry of their first file; those whose first
+	// file couldn't be archived are compressed after all
+	if len(dups) > 0 {
+		linked, orphans := dups, []duplicate(nil)
+		if tail != nil {
+			if linked, orphans, err = tail.writeLinks(dups); err != nil {
+				linked, orphans = nil, nil
+				for _, d := range dups {
+					recordError(d.task, err)
+				}
+			}
+		}
+		processedCount.Add(uint32(len(linked)))
+		recordLinks(opts, linked, result, progressCb)
+		if len(orphans) > 0 {
+			enc, release, err := encoders.newWorkerEncoder(opts.Level, 1, opts.zstdWindow(), nil)
+			if err != nil {
+				return nil, fmt.Errorf("create zstd encoder: %w", err)
+			}
+			var memBuf bytes.Buffer
+			for _, d := range orphans {
+				handleTask(d.task, enc, &memBuf)
+			}
+			release()
+		}
+	}
 
 	// Write archive footer (if not dry-run)
 	if !opts.DryRun && writer != nil {
 		if err := format.WriteArchiveFooter(writer); err != nil {
 			return nil, fmt.Errorf("write archive footer: %w", err)
 		}
+		if err := tail.writeIndex(); err != nil {
+			return nil, fmt.Errorf("write archive index: %w", err)
+		}
+		if err := appendParity(outFile, opts, result); err != nil {
+			return nil, err
+		}
+		if err := syncFile(opts, outFile); err != nil {
+			return nil, err
+		}
 	}
 
 	result.FilesProcessed = int(processedCount.Load())
+	result.StoredFiles = int(storedCount.Load())
+	result.SegmentedFiles = int(segmentedCount.Load())
+	result.PackedFiles = int(packedCount.Load())
+	result.Packs = int(packCount.Load())
 	result.CompressedSize = totalComprSize
 
 	if progressCb != nil {
@@ -418,14 +868,17 @@ func Compress(opts *Options, progressCb ProgressCallback) (*Result, error) {
 }
 
 // compressFileToWriter compresses a file directly to a writer.
-// The encoder is owned by the calling worker and reused across files via Reset.
+// The zstd encoder is owned by the calling worker and reused across files via
+// Reset; MethodStore and the other codecs leave it untouched.
 func compressFileToWriter(
 	task fileTask,
 	writer io.Writer,
 	enc *zstd.Encoder,
+	method format.Method,
+	level int,
 	progressCb ProgressCallback,
 ) (uint64, error) {
-	src, err := os.Open(task.AbsPath)
+	src, err := openSource(task)
 	if err != nil {
 		return 0, fmt.Errorf("open source file: %w", err)
 	}
@@ -440,8 +893,6 @@ func compressFileToWriter(
 		},
 	}
 
-	enc.Reset(targetWriter)
-
 	// Progress tracking reader (throttled; EventFileComplete finishes the bar)
 	var uncompressedRead, lastReported uint64
 	proxy := &godelta.ProgressReader{
@@ -462,15 +913,8 @@ func compressFileToWriter(
 	}
 
 	// Perform compression
-	_, err = io.Copy(enc, proxy)
-	if err != nil {
-		enc.Close()
-		return 0, fmt.Errorf("copy/compress failed: %w", err)
-	}
-
-	// Flush and finalize the frame (encoder stays reusable after Reset)
-	if err = enc.Close(); err != nil {
-		return 0, fmt.Errorf("close zstd encoder: %w", err)
+	if err := encodeEntry(targetWriter, proxy, method, level, enc); err != nil {
+		return 0, err
 	}
 
 	return compressedBytes, nil
@@ -479,13 +923,25 @@ func compressFileToWriter(
 // collectFiles gathers all files from either the Files list or InputPath
 // Returns folder tasks, total file count, total size, and any error
 func collectFiles(opts *Options, result *Result) ([]folderTask, int, uint64, error) {
+	if opts.sample != nil {
+		return sampleFolders(opts.sample, result.sources)
+	}
+
 	folderMap := make(map[string][]fileTask)
 	seenRelPaths := make(map[string]string) // relPath -> original source (for overlap detection)
 	var totalOrigSize uint64
 	var totalFiles int
+	changed := newPathSet(opts.ChangedPaths)
 
 	// Function to add a file task with overlap checking
 	addFile := func(absPath, relPath string, info os.FileInfo, source string) error {
+		// Incremental mode: unchanged files are not part of this archive
+		if !opts.ModifiedSince.IsZero() && !info.ModTime().After(opts.ModifiedSince) && !changed.covers(absPath) {
+			return nil
+		}
+
+		relPath = opts.PathNormalization.Apply(relPath)
+
 		// Check for overlapping relative paths
 		if existingSource, exists := seenRelPaths[relPath]; exists {
 			return fmt.Errorf("path overlap: %q from %q conflicts with %q", relPath, source, existingSource)
@@ -503,6 +959,7 @@ func collectFiles(opts *Options, result *Result) ([]folderTask, int, uint64, err
 			RelPath:  relPath,
 			Info:     info,
 			OrigSize: uint64(info.Size()),
+			sources:  result.sources,
 		}
 
 		folderMap[folderPath] = append(folderMap[folderPath], task)
@@ -511,25 +968,42 @@ func collectFiles(opts *Options, result *Result) ([]folderTask, int, uint64, err
 		return nil
 	}
 
+	// Ignore files and exclude patterns apply relative to each walked root
+	rootIgnores, err := opts.readIgnoreFiles()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
 	if len(opts.Files) > 0 {
-		// Custom file list mode: use paths as provided by the user
-		for _, inputPath := range opts.Files {
-			cleanPath := filepath.Clean(inputPath)
+		// Custom file list mode: use paths as provided by the user, named
+		// by PathMode
+		cleanPaths := make([]string, len(opts.Files))
+		for i, inputPath := range opts.Files {
+			cleanPaths[i] = filepath.Clean(inputPath)
+		}
+		namer, err := newEntryNamer(opts, cleanPaths)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		dirIgnores := make(map[string]*gitignoreMatcher) // matchers of listed files' directories
+		for i, inputPath := range opts.Files {
+			cleanPath := cleanPaths[i]
 			info, err := os.Stat(cleanPath)
 			if err != nil {
 				result.Errors = append(result.Errors, fmt.Errorf("%s: %w", inputPath, err))
 				continue
 			}
+			name, err := namer.name(cleanPath)
+			if err != nil {
+				return nil, 0, 0, err
+			}
 
 			if info.IsDir() {
-				// Create gitignore matcher for this directory if enabled
-				var matcher *gitignoreMatcher
-				if opts.UseGitignore {
-					matcher, _ = newGitignoreMatcher(cleanPath)
-				}
+				// Create the ignore matcher for this directory, honoring
+				// the ignore files of its whole tree
+				matcher, _ := newIgnoreMatcher(cleanPath, opts.filesIgnoreNames(), rootIgnores, opts.Excludes)
 
 				// Walk directory, paths are relative to this directory
-				dirBase := filepath.Base(cleanPath)
 				err := filepath.Walk(cleanPath, func(path string, finfo os.FileInfo, err error) error {
 					if err != nil {
 						result.Errors = append(result.Errors, fmt.Errorf("%s: %w", path, err))
@@ -539,9 +1013,9 @@ func collectFiles(opts *Options, result *Result) ([]folderTask, int, uint64, err
 					// Calculate relative path within the walked directory (for gitignore matching)
 					relToDir, _ := filepath.Rel(cleanPath, path)
 
-					// Check gitignore for directories (prune entire subtree)
+					// Check ignore files and excludes for directories (prune entire subtree)
 					if finfo.IsDir() {
-						if path != cleanPath && matcher != nil && matcher.ShouldIgnoreDir(relToDir) {
+						if path != cleanPath && (matcher.ShouldIgnoreDir(relToDir)) {
 							return filepath.SkipDir
 						}
 						return nil
@@ -551,13 +1025,13 @@ func collectFiles(opts *Options, result *Result) ([]folderTask, int, uint64, err
 						return nil
 					}
 
-					// Check gitignore for files
-					if matcher != nil && matcher.ShouldIgnore(relToDir) {
+					// Check ignore files and excludes for files
+					if matcher.ShouldIgnore(relToDir) {
 						return nil
 					}
 
-					// RelPath = dirBase + path relative to cleanPath
-					relPath := filepath.Join(dirBase, relToDir)
+					// RelPath = the directory's name + path relative to cleanPath
+					relPath := filepath.Join(name, relToDir)
 
 					if err := addFile(path, relPath, finfo, inputPath); err != nil {
 						return err
@@ -568,9 +1042,18 @@ func collectFiles(opts *Options, result *Result) ([]folderTask, int, uint64, err
 					return nil, 0, 0, err
 				}
 			} else if info.Mode().IsRegular() {
-				// Single file: use just the filename
-				relPath := filepath.Base(cleanPath)
-				if err := addFile(cleanPath, relPath, info, inputPath); err != nil {
+				// Single file: its name is matched against the ignore files
+				// of its own directory
+				dir := filepath.Dir(cleanPath)
+				matcher, ok := dirIgnores[dir]
+				if !ok {
+					matcher = newDirIgnoreMatcher(dir, opts.filesIgnoreNames(), rootIgnores, opts.Excludes)
+					dirIgnores[dir] = matcher
+				}
+				if matcher.ShouldIgnore(filepath.Base(cleanPath)) {
+					continue
+				}
+				if err := addFile(cleanPath, name, info, inputPath); err != nil {
 					return nil, 0, 0, err
 				}
 			}
@@ -579,11 +1062,8 @@ func collectFiles(opts *Options, result *Result) ([]folderTask, int, uint64, err
 		// InputPath mode: walk and use paths relative to InputPath
 		baseDir := opts.InputPath
 
-		// Create gitignore matcher if enabled
-		var matcher *gitignoreMatcher
-		if opts.UseGitignore {
-			matcher, _ = newGitignoreMatcher(baseDir)
-		}
+		// Create the ignore matcher
+		matcher, _ := newIgnoreMatcher(baseDir, opts.ignoreNames(), rootIgnores, opts.Excludes)
 
 		err := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
@@ -596,9 +1076,9 @@ func collectFiles(opts *Options, result *Result) ([]folderTask, int, uint64, err
 				relPath = filepath.Base(path)
 			}
 
-			// Check gitignore for directories (prune entire subtree)
+			// Check ignore files and excludes for directories (prune entire subtree)
 			if info.IsDir() {
-				if path != baseDir && matcher != nil && matcher.ShouldIgnoreDir(relPath) {
+				if path != baseDir && (matcher.ShouldIgnoreDir(relPath)) {
 					return filepath.SkipDir
 				}
 				return nil
@@ -608,8 +1088,8 @@ func collectFiles(opts *Options, result *Result) ([]folderTask, int, uint64, err
 				return nil
 			}
 
-			// Check gitignore for files
-			if matcher != nil && matcher.ShouldIgnore(relPath) {
+			// Check ignore files and excludes for files
+			if matcher.ShouldIgnore(relPath) {
 				return nil
 			}
 
@@ -634,3 +1114,38 @@ func collectFiles(opts *Options, result *Result) ([]folderTask, int, uint64, err
 
 	return foldersToCompress, totalFiles, totalOrigSize, nil
 }
+
+// pathSet is a set of absolute paths, for Options.ChangedPaths
+type pathSet map[string]bool
+
+// newPathSet returns the set of paths, made absolute
+func newPathSet(paths []string) pathSet {
+	set := make(pathSet, len(paths))
+	for _, path := range paths {
+		if abs, err := filepath.Abs(path); err == nil {
+			set[abs] = true
+		}
+	}
+	return set
+}
+
+// covers reports whether path or one of its parent directories is in the set
+func (s pathSet) covers(path string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for {
+		if s[path] {
+			return true
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return false
+		}
+		path = parent
+	}
+}
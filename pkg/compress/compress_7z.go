@@ -0,0 +1,42 @@
+// pkg/compress/compress_7z.go
+package compress
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/creativeyann17/go-delta/internal/sevenzip"
+)
+
+// sevenZipParts writes 7z archives, each a single solid LZMA2 stream
+var sevenZipParts = partFormat{
+	name:          "7z",
+	ext:           ".7z",
+	dryRunPercent: 30,
+	open: func(f *os.File, opts *Options) (partArchive, error) {
+		w, err := sevenzip.NewWriter(f, lzma2DictCap(opts.Level))
+		if err != nil {
+			return nil, err
+		}
+		return sevenZipPart{w}, nil
+	},
+}
+
+// sevenZipPart adapts sevenzip.Writer to partArchive; entry sizes are
+// taken from the data written
+type sevenZipPart struct{ w *sevenzip.Writer }
+
+func (p sevenZipPart) create(name string, _ uint64) (io.Writer, error) {
+	return p.w.Create(name)
+}
+
+func (p sevenZipPart) close() error {
+	return p.w.Close()
+}
+
+// compressTo7z compresses files into multiple .7z archives (one per thread) for true parallelism
+// Output: archive_01.7z, archive_02.7z, ..., archive_N.7z
+func compressTo7z(ctx context.Context, opts *Options, progressCb ProgressCallback, foldersToCompress []folderTask, totalFiles int, totalOrigSize uint64, result *Result) error {
+	return compressToParts(ctx, opts, progressCb, foldersToCompress, totalFiles, result, sevenZipParts)
+}
@@ -2,6 +2,8 @@
 package compress
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -12,75 +14,50 @@ import (
 	"github.com/creativeyann17/go-delta/internal/chunker"
 	"github.com/creativeyann17/go-delta/internal/chunkstore"
 	"github.com/creativeyann17/go-delta/internal/format"
-	"github.com/klauspost/compress/zstd"
+	"github.com/creativeyann17/go-delta/internal/pool"
 )
 
-// compressWithChunking performs compression with chunk-level deduplication (GDELTA02)
-func compressWithChunking(opts *Options, progressCb ProgressCallback, filesToCompress []folderTask, totalFiles int, totalOrigSize uint64, result *Result, parallelism Parallelism) error {
-	// Calculate max chunks for bounded store
-	maxChunks := 0
-	if opts.ChunkStoreSize > 0 && opts.ChunkSize > 0 {
-		// ChunkStoreSize is in MB, convert to bytes
-		storeSizeBytes := opts.ChunkStoreSize * 1024 * 1024
+// DefaultDiskIndexCacheChunks is the chunk store cache size used with
+// Options.ChunkIndexDir when ChunkStoreSize is 0. The cache bounds the RAM
+// the run spends on dedup lookups, about 120 bytes per chunk.
+const DefaultDiskIndexCacheChunks = 1 << 20
 
-		// Account for memory overhead per chunk:
-		// - Compressed chunk data: ~ChunkSize (varies, but use full size for safety)
-		// - ChunkInfo struct: ~56 bytes (Hash + Offset + CompressedSize + OriginalSize)
-		// - chunkEntry overhead: ~16 bytes (refCount + lruNode pointer)
-		// - list.Element: ~32 bytes (prev/next pointers + value interface)
-		// - Map entry: ~16 bytes
-		// Total overhead: ~120 bytes per chunk
-		const overheadPerChunk = 120
-		effectiveBytesPerChunk := opts.ChunkSize + overheadPerChunk
-
-		maxChunks = int(storeSizeBytes / effectiveBytesPerChunk)
-		if maxChunks < 1 {
-			maxChunks = 1 // At least 1 chunk
+// compressWithChunking performs compression with chunk-level deduplication (GDELTA02)
+func compressWithChunking(ctx context.Context, opts *Options, progressCb ProgressCallback, filesToCompress []folderTask, totalFiles int, totalOrigSize uint64, result *Result, parallelism Parallelism, budget *memoryBudget) error {
+	store, err := newChunkStore(opts)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	chunkerInstance := opts.newChunker()
+
+	// Chunks of the reference directories are known before the input is read
+	var refs *referenceIndex
+	if len(opts.ReferenceDirs) > 0 {
+		if refs, err = buildReferenceIndex(ctx, opts); err != nil {
+			return err
 		}
 	}
 
-	// Create chunk store for deduplication with capacity limit
-	store := chunkstore.NewStoreWithCapacity(maxChunks)
-	chunkerInstance := chunker.New(opts.ChunkSize)
-
 	// Metadata for files (will be written to archive)
 	var fileMetadataList []format.FileMetadata
 	var metadataMu sync.Mutex
 
 	// Create archive file and temporary file for chunk data
-	var writer io.WriteSeeker
+	var outFile *os.File
 	var chunkDataFile *os.File
 	var chunkDataWriter io.Writer
 	currentChunkOffset := uint64(0)
 	var chunkOffsetMu sync.Mutex
 
 	if !opts.DryRun {
-		// Ensure output directory exists
-		outputDir := filepath.Dir(opts.OutputPath)
-		if err := os.MkdirAll(outputDir, 0755); err != nil {
-			return fmt.Errorf("create output directory: %w", err)
-		}
-
-		outFile, err := os.Create(opts.OutputPath)
+		var cleanup func()
+		var err error
+		outFile, chunkDataFile, cleanup, err = createGDelta02Files(opts.OutputPath)
 		if err != nil {
-			return fmt.Errorf("create output file: %w", err)
+			return err
 		}
-		defer outFile.Close()
-		writer = outFile
-
-		// Create temporary file for chunk data
-		// Note: no signal handler here — a library must not call os.Exit or
-		// install process-wide handlers; interrupt cleanup is the CLI's job.
-		chunkDataFile, err = os.CreateTemp("", "godelta-chunks-*.tmp")
-		if err != nil {
-			return fmt.Errorf("create temp file: %w", err)
-		}
-		tempFilePath := chunkDataFile.Name()
-		defer func() {
-			chunkDataFile.Close()
-			os.Remove(tempFilePath)
-		}()
-
+		defer cleanup()
 		chunkDataWriter = chunkDataFile
 	}
 
@@ -90,8 +67,32 @@ func compressWithChunking(opts *Options, progressCb ProgressCallback, filesToCom
 
 	var wg sync.WaitGroup
 
+	// The chunk dictionary is trained before any chunk is compressed
+	var dictionary []byte
+	if opts.ChunkDictionary && !opts.DryRun {
+		if dictionary, err = trainChunkDictionary(ctx, filesToCompress, opts, progressCb, result, budget); err != nil {
+			return err
+		}
+	}
+
+	// Chunks are compressed and written by a second pool of workers, so
+	// reading and hashing a file overlaps with compressing its chunks
+	var chunks *chunkPipeline
+	extensions := newExtensionTally()
+	if !opts.DryRun {
+		var err error
+		chunks, err = newChunkPipeline(opts.MaxThreads, store, refs, chunkDataWriter, opts.codecMethod(), opts.Level, dictionary)
+		if err != nil {
+			return err
+		}
+		extensions = chunks.extensions
+	}
+
 	// Worker function to process a single file task
-	processFileTask := func(task fileTask, workerID int, enc *zstd.Encoder) {
+	processFileTask := func(task fileTask, workerID int) {
+		result.governor.enter()
+		defer result.governor.leave()
+
 		// Skip progress bar for 0-byte files (no progress to show)
 		if progressCb != nil && task.OrigSize > 0 {
 			progressCb(ProgressEvent{
@@ -103,7 +104,7 @@ func compressWithChunking(opts *Options, progressCb ProgressCallback, filesToCom
 
 		if opts.DryRun {
 			// Dry-run: chunk the file and track dedup stats without writing
-			file, err := os.Open(task.AbsPath)
+			file, err := openSource(task)
 			if err != nil {
 				errorsMu.Lock()
 				result.Errors = append(result.Errors, fmt.Errorf("%s: %w", task.RelPath, err))
@@ -112,13 +113,21 @@ func compressWithChunking(opts *Options, progressCb ProgressCallback, filesToCom
 			}
 
 			// Use streaming callback to avoid loading all chunks into memory
+			var bytesRead, chunkCount, deduped, dedupedBytes, estimated uint64
 			err = chunkerInstance.SplitWithCallback(file, func(chunk chunker.Chunk) error {
+				bytesRead += chunk.OrigSize
+				chunkCount++
+				if refs.take(chunk.Hash) {
+					deduped++
+					dedupedBytes += chunk.OrigSize
+					return nil
+				}
 				// Estimate compressed size as 50% of original (typical for zstd)
 				estimatedComprSize := chunk.OrigSize / 2
 				if estimatedComprSize == 0 {
 					estimatedComprSize = 1
 				}
-				_, _, err := store.GetOrAdd(chunk.Hash, chunk.OrigSize, func() (uint64, uint64, error) {
+				_, isNew, err := store.GetOrAdd(chunk.Hash, chunk.OrigSize, func() (uint64, uint64, error) {
 					// No-op writeFunc for dry-run - just return estimated values
 					chunkOffsetMu.Lock()
 					offset := currentChunkOffset
@@ -126,6 +135,12 @@ func compressWithChunking(opts *Options, progressCb ProgressCallback, filesToCom
 					chunkOffsetMu.Unlock()
 					return offset, estimatedComprSize, nil
 				})
+				if err == nil && isNew {
+					estimated += estimatedComprSize
+				} else if err == nil {
+					deduped++
+					dedupedBytes += chunk.OrigSize
+				}
 				return err
 			})
 			file.Close()
@@ -136,18 +151,11 @@ func compressWithChunking(opts *Options, progressCb ProgressCallback, filesToCom
 				errorsMu.Unlock()
 				return
 			}
+			extensions.add(task.RelPath, bytesRead, chunkCount, deduped, dedupedBytes)
+			result.breakdown.add(task.RelPath, bytesRead, estimated)
 		} else {
 			// Real compression with chunking
-			metadata, err := compressFileChunked(
-				task,
-				chunkerInstance,
-				store,
-				chunkDataWriter,
-				&chunkOffsetMu,
-				&currentChunkOffset,
-				enc,
-				progressCb,
-			)
+			metadata, stored, err := compressFileChunked(task, chunkerInstance, chunks, progressCb)
 
 			if err != nil {
 				errorsMu.Lock()
@@ -166,6 +174,8 @@ func compressWithChunking(opts *Options, progressCb ProgressCallback, filesToCom
 				fmt.Printf("  [Worker %d] %s: %d chunks\n", workerID, task.RelPath, len(metadata.ChunkHashes))
 			}
 
+			result.breakdown.add(task.RelPath, metadata.OrigSize, stored)
+
 			// Store file metadata
 			metadataMu.Lock()
 			fileMetadataList = append(fileMetadataList, metadata)
@@ -173,151 +183,83 @@ func compressWithChunking(opts *Options, progressCb ProgressCallback, filesToCom
 		}
 
 		processedCount.Add(1)
+		recordFile(opts, task.OrigSize)
 		if progressCb != nil {
+			stats := chunkStoreStats(store.Stats())
 			progressCb(ProgressEvent{
-				Type:     EventFileComplete,
-				FilePath: task.RelPath,
-				Current:  int64(task.OrigSize),
-				Total:    int64(task.OrigSize),
+				Type:       EventFileComplete,
+				FilePath:   task.RelPath,
+				Current:    int64(task.OrigSize),
+				Total:      int64(task.OrigSize),
+				ChunkStats: &stats,
 			})
 		}
 	}
 
-	// newChunkEncoder creates the per-worker encoder used via EncodeAll on
-	// small chunks; internal concurrency of 1 avoids goroutine oversubscription.
-	newChunkEncoder := func() (*zstd.Encoder, error) {
-		return zstd.NewWriter(nil,
-			zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(opts.Level)),
-			zstd.WithZeroFrames(true),
-			zstd.WithEncoderConcurrency(1),
-		)
-	}
+	// Workers hold their memory slot for the whole run; a slot covers one
+	// file worker and one chunk worker
+	budget.acquireWorkers(opts.MaxThreads)
 
 	if parallelism == ParallelismFolder {
 		// Folder-based parallelism: workers grab whole folders
-		folderCh := make(chan folderTask, len(filesToCompress))
+		folderCh := feedFolders(ctx, filesToCompress)
 
 		for i := 0; i < opts.MaxThreads; i++ {
 			wg.Add(1)
 			go func(workerID int) {
 				defer wg.Done()
 
-				enc, err := newChunkEncoder()
-				if err != nil {
-					errorsMu.Lock()
-					result.Errors = append(result.Errors, fmt.Errorf("create zstd encoder: %w", err))
-					errorsMu.Unlock()
-					return
-				}
-				defer enc.Close()
-
 				for folder := range folderCh {
 					for _, task := range folder.Files {
-						processFileTask(task, workerID, enc)
+						if ctx.Err() != nil {
+							break
+						}
+						processFileTask(task, workerID)
 					}
 				}
 			}(i + 1)
 		}
-
-		// Feed folder tasks
-		go func() {
-			for _, task := range filesToCompress {
-				folderCh <- task
-			}
-			close(folderCh)
-		}()
 	} else {
 		// File-based parallelism: shared work queue, workers pull as they free up
-		taskCh := feedTasks(filesToCompress, opts.MaxThreads*16)
+		taskCh := feedTasks(ctx, filesToCompress, opts.MaxThreads*16)
 
 		for i := 0; i < opts.MaxThreads; i++ {
 			wg.Add(1)
 			go func(workerID int) {
 				defer wg.Done()
 
-				enc, err := newChunkEncoder()
-				if err != nil {
-					errorsMu.Lock()
-					result.Errors = append(result.Errors, fmt.Errorf("create zstd encoder: %w", err))
-					errorsMu.Unlock()
-					return
-				}
-				defer enc.Close()
-
 				for task := range taskCh {
-					processFileTask(task, workerID, enc)
+					processFileTask(task, workerID)
 				}
 			}(i + 1)
 		}
 	}
 
 	wg.Wait()
-
-	// Flush temp file to ensure all data is written
-	if chunkDataFile != nil {
-		if err := chunkDataFile.Sync(); err != nil {
-			return fmt.Errorf("sync temp file: %w", err)
-		}
+	if chunks != nil {
+		chunks.close()
 	}
+	budget.releaseWorkers(opts.MaxThreads)
 
-	// Write GDELTA02 archive
-	if !opts.DryRun && writer != nil {
-		chunkIndex := store.All()
-
-		if opts.Verbose {
-			fmt.Printf("\nWriting GDELTA02 archive...\n")
-			fmt.Printf("  Files: %d\n", len(fileMetadataList))
-			fmt.Printf("  Unique chunks: %d\n", len(chunkIndex))
-			if chunkDataFile != nil {
-				// Get temp file size
-				tempFileInfo, err := chunkDataFile.Stat()
-				if err == nil {
-					tempSizeMB := float64(tempFileInfo.Size()) / (1024 * 1024)
-					fmt.Printf("  Temp file size: %.2f MiB (compressed chunks)\n", tempSizeMB)
-				}
-			}
-		}
-
-		// Write header
-		if err := format.WriteGDelta02Header(writer, opts.ChunkSize, uint32(len(fileMetadataList)), uint32(len(chunkIndex))); err != nil {
-			return fmt.Errorf("write header: %w", err)
-		}
-
-		// Write chunk index (chunkstore.ChunkInfo is now an alias for format.ChunkInfo)
-		if err := format.WriteChunkIndex(writer, chunkIndex); err != nil {
-			return fmt.Errorf("write chunk index: %w", err)
-		}
-
-		// Write file metadata
-		for _, metadata := range fileMetadataList {
-			if err := format.WriteFileMetadata(writer, metadata); err != nil {
-				return fmt.Errorf("write file metadata: %w", err)
-			}
-		}
-
-		// Copy chunk data from temp file to main archive
-		if chunkDataFile != nil {
-			// Seek to beginning of temp file
-			if _, err := chunkDataFile.Seek(0, io.SeekStart); err != nil {
-				return fmt.Errorf("seek temp file: %w", err)
-			}
-
-			// Copy all chunk data
-			if _, err := io.Copy(writer, chunkDataFile); err != nil {
-				return fmt.Errorf("copy chunk data: %w", err)
-			}
+	if err := ctx.Err(); err != nil {
+		if outFile != nil {
+			outFile.Close()
+			os.Remove(opts.OutputPath)
 		}
+		return fmt.Errorf("compression canceled: %w", err)
+	}
 
-		// Write footer
-		if err := format.WriteArchiveFooter02(writer); err != nil {
-			return fmt.Errorf("write footer: %w", err)
-		}
+	if chunks != nil {
+		result.StoredChunks = chunks.storedChunks.Load()
+	}
+	if refs != nil {
+		result.RefChunks, result.RefBytes = uint64(len(refs.used)), refs.bytes
+	}
 
-		// Get final archive size (includes all metadata + chunk data)
-		if file, ok := writer.(*os.File); ok {
-			if fileInfo, err := file.Stat(); err == nil {
-				result.CompressedSize = uint64(fileInfo.Size())
-			}
+	// Write GDELTA02 archive
+	if outFile != nil {
+		if err := writeGDelta02Archive(outFile, chunkDataFile, store, refs, fileMetadataList, dictionary, opts, result); err != nil {
+			return err
 		}
 	}
 
@@ -329,6 +271,9 @@ func compressWithChunking(opts *Options, progressCb ProgressCallback, filesToCom
 	result.UniqueChunks = stats.UniqueChunks
 	result.DedupedChunks = stats.DedupedChunks
 	result.BytesSaved = stats.BytesSaved
+	result.Evictions = stats.Evictions
+	result.ChunkStore = chunkStoreStats(stats)
+	result.Extensions = extensions.snapshot()
 
 	if progressCb != nil {
 		progressCb(ProgressEvent{
@@ -337,40 +282,228 @@ func compressWithChunking(opts *Options, progressCb ProgressCallback, filesToCom
 			Total:          int64(result.FilesTotal),
 			TotalBytes:     result.OriginalSize,
 			CompressedSize: result.CompressedSize,
+			ChunkStats:     &result.ChunkStore,
 		})
 	}
 
 	return nil
 }
 
-// compressFileChunked compresses a file using chunking and deduplication
-// Uses streaming processing to avoid loading entire file into memory
+// writeChunkIndex writes the store's chunks as the archive's chunk index
+func writeChunkIndex(w io.Writer, store *chunkstore.Store) error {
+	bw := bufio.NewWriterSize(w, pool.BufferSize)
+	entry := make([]byte, 0, 56)
+	err := store.Each(func(info chunkstore.ChunkInfo) error {
+		_, err := bw.Write(format.AppendChunkIndexEntry(entry[:0], info))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// newChunkStore creates the dedup store, bounded by ChunkStoreSize and
+// backed by a disk index with ChunkIndexDir
+func newChunkStore(opts *Options) (*chunkstore.Store, error) {
+	// Calculate max chunks for bounded store
+	maxChunks := 0
+	if opts.ChunkStoreSize > 0 && opts.ChunkSize > 0 {
+		// ChunkStoreSize is in MB, convert to bytes
+		storeSizeBytes := opts.ChunkStoreSize * 1024 * 1024
+
+		// Account for memory overhead per chunk:
+		// - Compressed chunk data: ~ChunkSize (varies, but use full size for safety)
+		// - ChunkInfo struct: ~56 bytes (Hash + Offset + CompressedSize + OriginalSize)
+		// - chunkEntry overhead: ~16 bytes (refCount + lruNode pointer)
+		// - list.Element: ~32 bytes (prev/next pointers + value interface)
+		// - Map entry: ~16 bytes
+		// Total overhead: ~120 bytes per chunk
+		const overheadPerChunk = 120
+		effectiveBytesPerChunk := opts.ChunkSize + overheadPerChunk
+
+		maxChunks = int(storeSizeBytes / effectiveBytesPerChunk)
+		if maxChunks < 1 {
+			maxChunks = 1 // At least 1 chunk
+		}
+	}
+
+	// Create chunk store for deduplication with capacity limit
+	if opts.ChunkIndexDir != "" {
+		index, err := chunkstore.NewDiskIndex(opts.ChunkIndexDir)
+		if err != nil {
+			return nil, err
+		}
+		if maxChunks == 0 {
+			maxChunks = DefaultDiskIndexCacheChunks
+		}
+		return chunkstore.NewStoreWithIndex(maxChunks, index), nil
+	}
+	return chunkstore.NewStoreWithCapacity(maxChunks), nil
+}
+
+// createGDelta02Files creates the archive and the temporary file its chunk
+// data is staged in until the index is known. cleanup closes both and
+// removes the temporary file.
+func createGDelta02Files(outputPath string) (outFile, chunkDataFile *os.File, cleanup func(), err error) {
+	// Ensure output directory exists
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return nil, nil, nil, fmt.Errorf("create output directory: %w", err)
+	}
+	outFile, err = os.Create(outputPath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("create output file: %w", err)
+	}
+
+	// Note: no signal handler here — a library must not call os.Exit or
+	// install process-wide handlers; interrupt cleanup is the CLI's job.
+	chunkDataFile, err = os.CreateTemp("", "godelta-chunks-*.tmp")
+	if err != nil {
+		outFile.Close()
+		return nil, nil, nil, fmt.Errorf("create temp file: %w", err)
+	}
+	cleanup = func() {
+		chunkDataFile.Close()
+		os.Remove(chunkDataFile.Name())
+		outFile.Close()
+	}
+	return outFile, chunkDataFile, cleanup, nil
+}
+
+// writeGDelta02Archive writes the header, chunk index and file metadata to
+// outFile, followed by the chunk data staged in chunkDataFile. dictionary is
+// the one the chunks were compressed with, if any; refs (may be nil) holds
+// the chunks taken from reference directories.
+func writeGDelta02Archive(outFile, chunkDataFile *os.File, store *chunkstore.Store, refs *referenceIndex, fileMetadataList []format.FileMetadata, dictionary []byte, opts *Options, result *Result) error {
+	// The staged chunks reach the disk before they are copied in
+	if opts.Sync {
+		if err := chunkDataFile.Sync(); err != nil {
+			return fmt.Errorf("sync temp file: %w", err)
+		}
+	}
+
+	chunkCount := store.Len()
+
+	if opts.Verbose {
+		fmt.Printf("\nWriting GDELTA02 archive...\n")
+		fmt.Printf("  Files: %d\n", len(fileMetadataList))
+		fmt.Printf("  Unique chunks: %d\n", chunkCount)
+		// Get temp file size
+		if tempFileInfo, err := chunkDataFile.Stat(); err == nil {
+			tempSizeMB := float64(tempFileInfo.Size()) / (1024 * 1024)
+			fmt.Printf("  Temp file size: %.2f MiB (compressed chunks)\n", tempSizeMB)
+		}
+	}
+
+	// Write header
+	header := format.GDelta02Header{
+		ChunkSize:  opts.ChunkSize,
+		Codec:      format.ChunkCodecFor(opts.codecMethod()),
+		Level:      opts.Level,
+		FileCount:  uint32(len(fileMetadataList)),
+		ChunkCount: uint32(chunkCount),
+		Bounds:     opts.chunkBounds(),
+	}
+	if result.StoredChunks > 0 {
+		header.Features |= format.FeatureStoredChunks
+	}
+	// Custom bounds, the chunk dictionary and metadata go in header
+	// extensions, sniffed types in file ones
+	header.Extended = header.Bounds != format.DefaultChunkBounds(opts.ChunkSize) || opts.SniffTypes
+	if len(dictionary) > 0 {
+		header.Extensions = append(header.Extensions, format.Extension{Type: format.ExtChunkDict, Value: dictionary})
+		header.Extended = true
+	}
+	if ext, ok, err := refs.extension(); err != nil {
+		return fmt.Errorf("reference chunks: %w", err)
+	} else if ok {
+		header.Extensions = append(header.Extensions, ext)
+		header.Extended = true
+	}
+	if ext, ok, err := opts.metadataExtension(); err != nil {
+		return err
+	} else if ok {
+		header.Extensions = append(header.Extensions, ext)
+		header.Extended = true
+	}
+	if ext, ok := opts.formatSpecExtension(format.FormatGDelta02); ok {
+		header.Extensions = append(header.Extensions, ext)
+		header.Extended = true
+	}
+	if err := format.WriteGDelta02Header(outFile, header); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	// Write chunk index, streamed from the store in hash order so a disk
+	// index never has to fit in memory
+	if err := writeChunkIndex(outFile, store); err != nil {
+		return fmt.Errorf("write chunk index: %w", err)
+	}
+
+	// Write file metadata
+	for _, metadata := range fileMetadataList {
+		if err := format.WriteFileMetadata(outFile, metadata, header.Extended); err != nil {
+			return fmt.Errorf("write file metadata: %w", err)
+		}
+	}
+
+	// Copy chunk data from temp file to main archive
+	if _, err := chunkDataFile.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("seek temp file: %w", err)
+	}
+	if _, err := io.Copy(outFile, chunkDataFile); err != nil {
+		return fmt.Errorf("copy chunk data: %w", err)
+	}
+
+	// Write footer
+	if err := format.WriteArchiveFooter02(outFile); err != nil {
+		return fmt.Errorf("write footer: %w", err)
+	}
+
+	// Get final archive size (includes all metadata + chunk data)
+	if fileInfo, err := outFile.Stat(); err == nil {
+		result.CompressedSize = uint64(fileInfo.Size())
+	}
+	if err := appendParity(outFile, opts, result); err != nil {
+		return err
+	}
+	return syncFile(opts, outFile)
+}
+
+// compressFileChunked splits a file into chunks and hands them to the chunk
+// pipeline, returning once all of them are stored. Uses streaming processing
+// to avoid loading the entire file into memory.
 func compressFileChunked(
 	task fileTask,
 	chunkerInstance *chunker.Chunker,
-	store *chunkstore.Store,
-	writer io.Writer,
-	writerMu *sync.Mutex,
-	currentOffset *uint64,
-	enc *zstd.Encoder,
+	chunks *chunkPipeline,
 	progressCb ProgressCallback,
-) (format.FileMetadata, error) {
+) (format.FileMetadata, uint64, error) {
 	// Open file
-	file, err := os.Open(task.AbsPath)
+	file, err := openSource(task)
 	if err != nil {
-		return format.FileMetadata{}, fmt.Errorf("open file: %w", err)
+		return format.FileMetadata{}, 0, fmt.Errorf("open file: %w", err)
 	}
 	defer file.Close()
+	return chunkReader(task, file, chunkerInstance, chunks, progressCb)
+}
 
+// chunkReader splits the content of task read from r into chunks and
+// hands them to the chunk pipeline, returning once all of them are stored.
+// It also returns the compressed size of the chunks this file stored first.
+func chunkReader(
+	task fileTask,
+	r io.Reader,
+	chunkerInstance *chunker.Chunker,
+	chunks *chunkPipeline,
+	progressCb ProgressCallback,
+) (format.FileMetadata, uint64, error) {
 	// Process chunks via streaming callback
 	chunkHashes := make([][32]byte, 0, 8)
 	bytesRead := uint64(0)
-	var chunkErr error
+	var pending fileChunks
 
-	// Reusable buffer for compressed chunk data (EncodeAll appends into it)
-	var compressBuf []byte
-
-	err = chunkerInstance.SplitWithCallback(file, func(chunk chunker.Chunk) error {
+	err := chunkerInstance.SplitWithCallback(r, func(chunk chunker.Chunk) error {
 		bytesRead += chunk.OrigSize
 
 		// Report progress
@@ -384,47 +517,28 @@ func compressFileChunked(
 			})
 		}
 
-		// Try to deduplicate
-		chunkInfo, _, err := store.GetOrAdd(chunk.Hash, chunk.OrigSize, func() (offset uint64, comprSize uint64, err error) {
-			// Compress the chunk with the worker's reusable encoder
-			compressedData := enc.EncodeAll(chunk.Data, compressBuf[:0])
-			compressBuf = compressedData // keep grown capacity for next chunk
-
-			// Write directly to file (if writer is provided)
-			if writer != nil {
-				writerMu.Lock()
-				offset = *currentOffset
-				if _, err := writer.Write(compressedData); err != nil {
-					writerMu.Unlock()
-					return 0, 0, fmt.Errorf("write chunk to file: %w", err)
-				}
-				*currentOffset += uint64(len(compressedData))
-				writerMu.Unlock()
-			} else {
-				// Dry run - just calculate offset
-				offset = *currentOffset
-				*currentOffset += uint64(len(compressedData))
-			}
-
-			return offset, uint64(len(compressedData)), nil
-		})
-
-		if err != nil {
-			chunkErr = fmt.Errorf("process chunk: %w", err)
-			return chunkErr
-		}
-
-		chunkHashes = append(chunkHashes, chunkInfo.Hash)
+		// chunk.Data is only valid during the callback: submit copies it
+		chunks.submit(&pending, chunk.Hash, chunk.Data)
+		chunkHashes = append(chunkHashes, chunk.Hash)
 		return nil
 	})
 
+	// Chunks already queued must be stored (or failed) before returning,
+	// whatever happened to the split
+	chunkErr := pending.wait()
 	if err != nil {
-		return format.FileMetadata{}, fmt.Errorf("split chunks: %w", err)
+		return format.FileMetadata{}, 0, fmt.Errorf("split chunks: %w", err)
+	}
+	if chunkErr != nil {
+		return format.FileMetadata{}, 0, chunkErr
 	}
+	chunks.extensions.add(task.RelPath, bytesRead, uint64(len(chunkHashes)), pending.deduped.Load(), pending.dedupedBytes.Load())
 
+	// The bytes read, not task.OrigSize: a stream's size isn't known before
 	return format.FileMetadata{
 		RelPath:     task.RelPath,
-		OrigSize:    task.OrigSize,
+		OrigSize:    bytesRead,
 		ChunkHashes: chunkHashes,
-	}, nil
+		Extensions:  format.TypeExtensions(task.Type),
+	}, pending.storedBytes.Load(), nil
 }
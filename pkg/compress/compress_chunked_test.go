@@ -3,13 +3,16 @@ package compress
 
 import (
 	"bytes"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/creativeyann17/go-delta/internal/chunker"
 	"github.com/creativeyann17/go-delta/internal/chunkstore"
+	"github.com/creativeyann17/go-delta/internal/format"
 	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
 )
 
 func TestChunkedCompression(t *testing.T) {
@@ -648,3 +651,350 @@ func TestDryRunDedupStats(t *testing.T) {
 		t.Error("Dry-run should not create archive file")
 	}
 }
+
+// TestChunkStatsProgress checks that chunked runs attach live chunk store
+// snapshots to progress events and that the last one matches the result
+func TestChunkStatsProgress(t *testing.T) {
+	tempDir := t.TempDir()
+	content := bytes.Repeat([]byte("ABCDEFGHIJ"), 10000)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var snapshots []ChunkStoreStats
+	var final *ChunkStoreStats
+	result, err := Compress(&Options{
+		InputPath:  tempDir,
+		OutputPath: filepath.Join(t.TempDir(), "test.gdelta"),
+		ChunkSize:  16 * 1024,
+		MaxThreads: 1,
+		Quiet:      true,
+	}, func(event ProgressEvent) {
+		switch event.Type {
+		case EventFileComplete:
+			if event.ChunkStats == nil {
+				t.Errorf("%s: file_complete without chunk stats", event.FilePath)
+				return
+			}
+			snapshots = append(snapshots, *event.ChunkStats)
+		case EventComplete:
+			final = event.ChunkStats
+		}
+	})
+	if err != nil {
+		t.Fatalf("Compression failed: %v", err)
+	}
+
+	if len(snapshots) != 3 {
+		t.Fatalf("Expected 3 snapshots, got %d", len(snapshots))
+	}
+	for i := 1; i < len(snapshots); i++ {
+		if snapshots[i].TotalChunks <= snapshots[i-1].TotalChunks {
+			t.Errorf("Snapshot %d did not advance: %+v", i, snapshots[i])
+		}
+	}
+	if final == nil || *final != result.ChunkStore {
+		t.Fatalf("Complete event stats %+v differ from result %+v", final, result.ChunkStore)
+	}
+	if result.ChunkStore.TotalChunks != result.TotalChunks || result.ChunkStore.DedupedChunks != result.DedupedChunks {
+		t.Errorf("Result chunk store %+v disagrees with result totals", result.ChunkStore)
+	}
+	if result.ChunkStore.CacheHits == 0 || result.ChunkStore.HitRate() == 0 {
+		t.Errorf("Expected cache hits for identical files, got %+v", result.ChunkStore)
+	}
+	if result.ChunkStore.MemoryBytes == 0 {
+		t.Error("Expected a memory estimate")
+	}
+}
+
+func TestChunkedDiskIndex(t *testing.T) {
+	tempDir := t.TempDir()
+	inputDir := filepath.Join(tempDir, "input")
+	indexDir := filepath.Join(tempDir, "index")
+	for _, dir := range []string{inputDir, indexDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testFiles := map[string][]byte{
+		"a.txt": bytes.Repeat([]byte("Disk index content. "), 20000),
+		"b.txt": bytes.Repeat([]byte("Other content here. "), 20000),
+		"c.txt": bytes.Repeat([]byte("Disk index content. "), 20000), // Same as a
+	}
+	for filename, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(inputDir, filename), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// One thread makes the chunk layout deterministic, so both archives
+	// must be identical
+	compressTo := func(name, indexDir string) []byte {
+		archivePath := filepath.Join(tempDir, name)
+		opts := &Options{
+			InputPath:     inputDir,
+			OutputPath:    archivePath,
+			ChunkSize:     8 * 1024,
+			ChunkIndexDir: indexDir,
+			MaxThreads:    1,
+		}
+		if _, err := Compress(opts, nil); err != nil {
+			t.Fatalf("Compression failed: %v", err)
+		}
+		data, err := os.ReadFile(archivePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	inMemory := compressTo("memory.gdelta", "")
+	onDisk := compressTo("disk.gdelta", indexDir)
+	if !bytes.Equal(inMemory, onDisk) {
+		t.Fatal("disk index archive differs from in-memory index archive")
+	}
+
+	if entries, _ := os.ReadDir(indexDir); len(entries) != 0 {
+		t.Errorf("index file left behind in %s", indexDir)
+	}
+
+	outputDir := filepath.Join(tempDir, "output")
+	if _, err := decompress.Decompress(&decompress.Options{InputPath: filepath.Join(tempDir, "disk.gdelta"), OutputPath: outputDir}, nil); err != nil {
+		t.Fatal(err)
+	}
+	for filename, expected := range testFiles {
+		got, err := os.ReadFile(filepath.Join(outputDir, filename))
+		if err != nil || !bytes.Equal(got, expected) {
+			t.Errorf("%s: content mismatch (%v)", filename, err)
+		}
+	}
+}
+
+// TestChunkedCustomBounds checks that custom chunk bounds shape the chunks,
+// are recorded in the header and that the archive still decompresses, and
+// that default bounds leave the header unextended
+func TestChunkedCustomBounds(t *testing.T) {
+	tempDir := t.TempDir()
+	inputDir := filepath.Join(tempDir, "input")
+	if err := os.MkdirAll(inputDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(content)
+	if err := os.WriteFile(filepath.Join(inputDir, "data.bin"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	readHeader := func(path string) format.GDelta02Header {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		header, err := format.ReadGDelta02Header(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return header
+	}
+
+	archivePath := filepath.Join(tempDir, "bounded.gdelta")
+	result, err := Compress(&Options{
+		InputPath:          inputDir,
+		OutputPath:         archivePath,
+		ChunkSize:          16 * 1024,
+		ChunkMinSize:       12 * 1024,
+		ChunkMaxSize:       20 * 1024,
+		ChunkNormalization: ChunkNormalizationOff,
+		Quiet:              true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Compression failed: %v", err)
+	}
+	// Random data: chunks of 12-20KB, so at least 52 of them
+	if result.TotalChunks < uint64(len(content)/(20*1024)) || result.TotalChunks > uint64(len(content)/(12*1024))+1 {
+		t.Errorf("%d chunks for 1MB within 12-20KB bounds", result.TotalChunks)
+	}
+	header := readHeader(archivePath)
+	want := format.ChunkBounds{MinSize: 12 * 1024, MaxSize: 20 * 1024, Normalization: 0}
+	if !header.Extended || header.Bounds != want {
+		t.Errorf("Header bounds %+v (extended %v), want %+v", header.Bounds, header.Extended, want)
+	}
+	if _, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: filepath.Join(tempDir, "out"), Quiet: true}, nil); err != nil {
+		t.Fatalf("Decompression failed: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(tempDir, "out", "data.bin"))
+	if err != nil || !bytes.Equal(got, content) {
+		t.Errorf("Decompressed content mismatch (%v)", err)
+	}
+
+	defaultPath := filepath.Join(tempDir, "default.gdelta")
+	if _, err := Compress(&Options{InputPath: inputDir, OutputPath: defaultPath, ChunkSize: 16 * 1024, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if header := readHeader(defaultPath); header.Extended || header.Bounds != format.DefaultChunkBounds(16*1024) {
+		t.Errorf("Default bounds: %+v (extended %v)", header.Bounds, header.Extended)
+	}
+
+	for _, opts := range []Options{
+		{ChunkSize: 16 * 1024, ChunkMinSize: 32 * 1024},
+		{ChunkSize: 16 * 1024, ChunkMaxSize: 8 * 1024},
+		{ChunkSize: 16 * 1024, ChunkNormalization: 4},
+		{ChunkMaxSize: 64 * 1024},
+	} {
+		opts.InputPath = inputDir
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Validate accepted %+v", opts)
+		}
+	}
+}
+
+// TestChunkedFixed checks that fixed-size chunking cuts blocks at block
+// offsets, so an image with one block changed in place shares every other
+// block, and that the mode is recorded in the header
+func TestChunkedFixed(t *testing.T) {
+	tempDir := t.TempDir()
+	inputDir := filepath.Join(tempDir, "input")
+	if err := os.MkdirAll(inputDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	const blockSize = 16 * 1024
+	image := make([]byte, 10*blockSize+1000)
+	rand.New(rand.NewSource(1)).Read(image)
+	changed := bytes.Clone(image)
+	changed[5*blockSize+100] ^= 0xff
+	if err := os.WriteFile(filepath.Join(inputDir, "a.img"), image, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(inputDir, "b.img"), changed, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	archivePath := filepath.Join(tempDir, "fixed.gdelta")
+	result, err := Compress(&Options{
+		InputPath:    inputDir,
+		OutputPath:   archivePath,
+		ChunkSize:    blockSize,
+		ChunkingMode: ChunkingFixed,
+		Quiet:        true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Compression failed: %v", err)
+	}
+	if result.TotalChunks != 22 || result.UniqueChunks != 12 {
+		t.Errorf("%d chunks, %d unique; want 22 and 12", result.TotalChunks, result.UniqueChunks)
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := format.ReadGDelta02Header(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !header.Extended || header.Bounds != format.FixedChunkBounds(blockSize) {
+		t.Errorf("Header bounds %+v (extended %v), want fixed blocks", header.Bounds, header.Extended)
+	}
+
+	outDir := filepath.Join(tempDir, "out")
+	if _, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: outDir, Quiet: true}, nil); err != nil {
+		t.Fatalf("Decompression failed: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(outDir, "b.img"))
+	if err != nil || !bytes.Equal(got, changed) {
+		t.Errorf("Decompressed content mismatch (%v)", err)
+	}
+
+	for _, opts := range []Options{
+		{ChunkSize: blockSize, ChunkingMode: ChunkingFixed, ChunkMaxSize: 4 * blockSize},
+		{ChunkSize: blockSize, ChunkingMode: ChunkingFixed, ChunkNormalization: 1},
+		{ChunkSize: blockSize, ChunkingMode: "rabin"},
+		{ChunkingMode: ChunkingFixed},
+	} {
+		opts.InputPath = inputDir
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Validate accepted %+v", opts)
+		}
+	}
+}
+
+// TestChunkedStoresIncompressible keeps the chunks compression doesn't
+// shrink uncompressed, flagged in the index, with the in-memory and the
+// disk chunk index, and reads them back
+func TestChunkedStoresIncompressible(t *testing.T) {
+	inputDir := t.TempDir()
+	random := make([]byte, 200*1024)
+	rand.New(rand.NewSource(1)).Read(random)
+	text := bytes.Repeat([]byte("compressible text "), 5000)
+	if err := os.WriteFile(filepath.Join(inputDir, "photo.raw"), random, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(inputDir, "notes.txt"), text, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, indexDir := range map[string]string{"memory": "", "disk": t.TempDir()} {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "mixed.gdelta")
+			result, err := Compress(&Options{
+				InputPath:     inputDir,
+				OutputPath:    archivePath,
+				ChunkSize:     16 * 1024,
+				ChunkIndexDir: indexDir,
+				Quiet:         true,
+			}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.StoredChunks == 0 || result.StoredChunks >= result.UniqueChunks {
+				t.Fatalf("%d of %d chunks stored", result.StoredChunks, result.UniqueChunks)
+			}
+
+			f, err := os.Open(archivePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			header, err := format.ReadGDelta02Header(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			index, err := format.ReadChunkIndex(f, header.ChunkCount)
+			f.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var stored uint64
+			for _, info := range index {
+				if info.Stored {
+					stored++
+					if info.CompressedSize != info.OriginalSize {
+						t.Errorf("stored chunk %x: %d bytes for %d", info.Hash[:8], info.CompressedSize, info.OriginalSize)
+					}
+				}
+			}
+			if stored != result.StoredChunks {
+				t.Errorf("index flags %d stored chunks, result %d", stored, result.StoredChunks)
+			}
+
+			vres, err := verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true, Quiet: true}, nil)
+			if err != nil || !vres.IsValid() {
+				t.Fatalf("verify: %v %v", err, vres.Errors)
+			}
+
+			outDir := t.TempDir()
+			if _, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: outDir, Quiet: true}, nil); err != nil {
+				t.Fatal(err)
+			}
+			for file, want := range map[string][]byte{"photo.raw": random, "notes.txt": text} {
+				got, err := os.ReadFile(filepath.Join(outDir, file))
+				if err != nil || !bytes.Equal(got, want) {
+					t.Errorf("%s: content mismatch (%v)", file, err)
+				}
+			}
+		})
+	}
+}
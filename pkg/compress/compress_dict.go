@@ -2,14 +2,20 @@
 package compress
 
 import (
+	"context"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/pool"
 	"github.com/creativeyann17/go-delta/pkg/godelta"
 	"github.com/klauspost/compress/dict"
 	"github.com/klauspost/compress/zstd"
@@ -27,6 +33,10 @@ const (
 	// Small samples are fine - the library handles them well
 	// Only skip truly tiny samples that add noise without useful patterns
 	MinSampleSizeForDict = 64
+
+	// dictTrainingFactor is the memory used by training per sample byte:
+	// the samples themselves plus the builder's match index over them
+	dictTrainingFactor = 4
 )
 
 // dictParams holds auto-computed dictionary training parameters
@@ -129,6 +139,7 @@ func analyzeDictParams(files []fileTask, verbose bool) dictParams {
 
 // compressWithDictionary compresses files using GDELTA03 dictionary-based compression
 func compressWithDictionary(
+	ctx context.Context,
 	opts *Options,
 	progressCb ProgressCallback,
 	foldersToCompress []folderTask,
@@ -136,7 +147,18 @@ func compressWithDictionary(
 	totalOrigSize uint64,
 	result *Result,
 	resolvedParallelism Parallelism,
+	budget *memoryBudget,
 ) error {
+	// Files repeating an earlier one are left for links, written once
+	// every other entry is
+	var dups []duplicate
+	if opts.DedupFiles {
+		var err error
+		if foldersToCompress, dups, err = findDuplicates(ctx, foldersToCompress, opts.MaxThreads); err != nil {
+			return fmt.Errorf("compression canceled: %w", err)
+		}
+	}
+
 	// Flatten files for processing
 	var allFiles []fileTask
 	for _, folder := range foldersToCompress {
@@ -151,7 +173,9 @@ func compressWithDictionary(
 		})
 	}
 
-	dictionary, err := trainDictionary(allFiles, opts.Verbose)
+	trainStart := time.Now()
+	dictionary, err := trainDictionary(ctx, allFiles, opts.MaxThreads, budget, opts.Verbose)
+	result.Timing.DictTraining = time.Since(trainStart)
 	if err != nil {
 		return fmt.Errorf("train dictionary: %w", err)
 	}
@@ -166,7 +190,9 @@ func compressWithDictionary(
 
 	if opts.DryRun {
 		// In dry-run mode, just simulate compression
-		return dryRunDictCompression(allFiles, dictionary, opts, progressCb, result)
+		result.FilesProcessed += len(dups)
+		recordLinks(opts, dups, result, progressCb)
+		return dryRunDictCompression(ctx, allFiles, dictionary, opts, progressCb, result)
 	}
 
 	// Phase 2: Create archive
@@ -181,8 +207,31 @@ func compressWithDictionary(
 	}
 	defer outFile.Close()
 
-	// Write header with dictionary
-	if err := format.WriteGDelta03Header(outFile, uint32(len(dictionary)), uint32(totalFiles)); err != nil {
+	// Decide each file's method up front (entropy sampling reads the file):
+	// stored entries, links and sniffed types are recorded in an extension
+	// area, so the archive is only extended when it has some.
+	methods := make(map[string]format.Method, len(allFiles))
+	extended := len(dups) > 0 || opts.SniffTypes
+	for _, task := range allFiles {
+		methods[task.RelPath] = opts.methodFor(task)
+		extended = extended || methods[task.RelPath] == format.MethodStore
+	}
+	header := format.GDelta03Header{
+		DictSize:  uint32(len(dictionary)),
+		FileCount: uint32(totalFiles),
+		Extended:  extended,
+	}
+	if ext, ok, err := opts.metadataExtension(); err != nil {
+		return err
+	} else if ok {
+		header.Extensions = append(header.Extensions, ext)
+		header.Extended = true
+	}
+	if ext, ok := opts.formatSpecExtension(format.FormatGDelta03); ok {
+		header.Extensions = append(header.Extensions, ext)
+		header.Extended = true
+	}
+	if err := format.WriteGDelta03Header(outFile, header); err != nil {
 		return fmt.Errorf("write header: %w", err)
 	}
 
@@ -194,17 +243,28 @@ func compressWithDictionary(
 	// Phase 3: Parallel compression using temp files
 	var totalComprSize uint64
 	var processedCount atomic.Uint32
+	var storedCount atomic.Uint32
 	var writerMu sync.Mutex
 	var errorsMu sync.Mutex
 	var wg sync.WaitGroup
+	var encoders encoderTimer
+	index := make(map[string]uint32) // entry index of each written file, for links; guarded by writerMu
+	var written uint32
 
 	// Helper to write a completed file entry to the archive
-	writeFileEntry := func(task fileTask, tempFilePath string, compressedSize uint64) error {
+	writeFileEntry := func(task fileTask, tempFilePath string, compressedSize uint64, method format.Method) error {
 		writerMu.Lock()
 		defer writerMu.Unlock()
 
 		// Write file entry header
-		if err := format.WriteGDelta03FileEntry(outFile, task.RelPath, task.OrigSize, compressedSize); err != nil {
+		entry := format.GDelta03FileEntry{
+			Path:           task.RelPath,
+			OriginalSize:   task.OrigSize,
+			CompressedSize: compressedSize,
+			Method:         method,
+			Extensions:     format.TypeExtensions(task.Type),
+		}
+		if err := format.WriteGDelta03FileEntry(outFile, entry, header.Extended); err != nil {
 			return fmt.Errorf("write entry: %w", err)
 		}
 
@@ -215,15 +275,17 @@ func compressWithDictionary(
 		}
 		defer tempFile.Close()
 
-		if _, err := io.Copy(outFile, tempFile); err != nil {
+		if _, err := pool.Copy(outFile, tempFile); err != nil {
 			return fmt.Errorf("copy compressed data: %w", err)
 		}
 
+		index[task.RelPath] = written
+		written++
 		return nil
 	}
 
 	// Worker function to compress a single file
-	processFileTask := func(task fileTask, enc *zstd.Encoder) (tempPath string, comprSize uint64, err error) {
+	processFileTask := func(task fileTask, enc *zstd.Encoder, method format.Method) (tempPath string, comprSize uint64, err error) {
 		if progressCb != nil && task.OrigSize > 0 {
 			progressCb(ProgressEvent{
 				Type:     EventFileStart,
@@ -240,7 +302,7 @@ func compressWithDictionary(
 		tempPath = tempFile.Name()
 
 		// Compress with dictionary
-		compressedSize, err := compressFileWithDict(task, tempFile, enc, progressCb)
+		compressedSize, err := compressFileWithDict(task, tempFile, enc, method, progressCb)
 		tempFile.Close()
 
 		if err != nil {
@@ -253,7 +315,11 @@ func compressWithDictionary(
 
 	// handleTask compresses one file and appends it to the archive
 	handleTask := func(task fileTask, enc *zstd.Encoder) {
-		tempPath, comprSize, err := processFileTask(task, enc)
+		result.governor.enter()
+		defer result.governor.leave()
+
+		method := methods[task.RelPath]
+		tempPath, comprSize, err := processFileTask(task, enc, method)
 
 		if err != nil {
 			errorsMu.Lock()
@@ -265,7 +331,7 @@ func compressWithDictionary(
 			return
 		}
 
-		err = writeFileEntry(task, tempPath, comprSize)
+		err = writeFileEntry(task, tempPath, comprSize, method)
 		os.Remove(tempPath)
 		if err != nil {
 			errorsMu.Lock()
@@ -279,6 +345,11 @@ func compressWithDictionary(
 		atomic.AddUint64(&totalComprSize, comprSize)
 
 		processedCount.Add(1)
+		if method == format.MethodStore {
+			storedCount.Add(1)
+		}
+		recordFile(opts, task.OrigSize)
+		result.breakdown.add(task.RelPath, task.OrigSize, comprSize)
 		if progressCb != nil {
 			progressCb(ProgressEvent{
 				Type:           EventFileComplete,
@@ -290,55 +361,55 @@ func compressWithDictionary(
 		}
 	}
 
+	// Workers hold their memory slot for the whole run
+	budget.acquireWorkers(opts.MaxThreads)
+
 	if resolvedParallelism == ParallelismFolder {
 		// Folder-based parallelism
-		folderCh := make(chan folderTask, len(foldersToCompress))
+		folderCh := feedFolders(ctx, foldersToCompress)
 
 		for i := 0; i < opts.MaxThreads; i++ {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
 
-				enc, err := newWorkerEncoder(opts.Level, opts.MaxThreads, dictionary)
+				enc, release, err := encoders.newWorkerEncoder(opts.Level, opts.MaxThreads, opts.zstdWindow(), dictionary)
 				if err != nil {
 					errorsMu.Lock()
 					result.Errors = append(result.Errors, fmt.Errorf("create zstd encoder: %w", err))
 					errorsMu.Unlock()
 					return
 				}
-				defer enc.Close()
+				defer release()
 
 				for folder := range folderCh {
 					for _, task := range folder.Files {
+						if ctx.Err() != nil {
+							break
+						}
 						handleTask(task, enc)
 					}
 				}
 			}()
 		}
 
-		go func() {
-			for _, task := range foldersToCompress {
-				folderCh <- task
-			}
-			close(folderCh)
-		}()
 	} else {
 		// File-based parallelism: shared work queue, workers pull as they free up
-		taskCh := feedTasks(foldersToCompress, opts.MaxThreads*16)
+		taskCh := feedTasks(ctx, foldersToCompress, opts.MaxThreads*16)
 
 		for i := 0; i < opts.MaxThreads; i++ {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
 
-				enc, err := newWorkerEncoder(opts.Level, opts.MaxThreads, dictionary)
+				enc, release, err := encoders.newWorkerEncoder(opts.Level, opts.MaxThreads, opts.zstdWindow(), dictionary)
 				if err != nil {
 					errorsMu.Lock()
 					result.Errors = append(result.Errors, fmt.Errorf("create zstd encoder: %w", err))
 					errorsMu.Unlock()
 					return
 				}
-				defer enc.Close()
+				defer release()
 
 				for task := range taskCh {
 					handleTask(task, enc)
@@ -348,16 +419,67 @@ func compressWithDictionary(
 	}
 
 	wg.Wait()
+	encoders.record(&result.Timing)
+	budget.releaseWorkers(opts.MaxThreads)
+
+	if err := ctx.Err(); err != nil {
+		outFile.Close()
+		os.Remove(opts.OutputPath)
+		return fmt.Errorf("compression canceled: %w", err)
+	}
+
+	// Duplicates link to the entry of their first file; those whose first
+	// file couldn't be archived are compressed after all
+	var linked, orphans []duplicate
+	for _, d := range dups {
+		target, ok := index[d.of]
+		if !ok {
+			orphans = append(orphans, d)
+			continue
+		}
+		entry := format.GDelta03FileEntry{
+			Path:         d.task.RelPath,
+			OriginalSize: d.task.OrigSize,
+			Extensions:   format.TypeExtensions(d.task.Type),
+			Link:         true,
+			LinkIndex:    target,
+		}
+		if err := format.WriteGDelta03FileEntry(outFile, entry, header.Extended); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: write entry: %w", d.task.RelPath, err))
+			continue
+		}
+		written++
+		linked = append(linked, d)
+	}
+	processedCount.Add(uint32(len(linked)))
+	recordLinks(opts, linked, result, progressCb)
+	if len(orphans) > 0 {
+		enc, release, err := encoders.newWorkerEncoder(opts.Level, 1, opts.zstdWindow(), dictionary)
+		if err != nil {
+			return fmt.Errorf("create zstd encoder: %w", err)
+		}
+		for _, d := range orphans {
+			handleTask(d.task, enc)
+		}
+		release()
+	}
 
 	// Write footer
 	if err := format.WriteArchiveFooter03(outFile); err != nil {
 		return fmt.Errorf("write footer: %w", err)
 	}
+	if err := appendParity(outFile, opts, result); err != nil {
+		return err
+	}
+	if err := syncFile(opts, outFile); err != nil {
+		return err
+	}
 
 	// Calculate total archive overhead: header(21) + dictionary + footer(8)
 	archiveOverhead := uint64(21 + len(dictionary) + 8)
 
 	result.FilesProcessed = int(processedCount.Load())
+	result.StoredFiles = int(storedCount.Load())
 	result.CompressedSize = totalComprSize + archiveOverhead
 
 	if progressCb != nil {
@@ -373,36 +495,64 @@ func compressWithDictionary(
 	return nil
 }
 
-// trainDictionary collects samples from files and builds a zstd dictionary
-func trainDictionary(files []fileTask, verbose bool) ([]byte, error) {
+// trainDictionary collects samples from files and builds a zstd dictionary.
+// Files are chosen by selectSampleFiles (stratified by folder and extension)
+// and read by up to maxThreads workers; ctx is checked before every
+// read and while the dictionary is built, so a canceled run returns promptly.
+// With a memory budget, the samples and the builder's working memory are
+// acquired from it, and fewer samples are taken if they don't fit.
+func trainDictionary(ctx context.Context, files []fileTask, maxThreads int, budget *memoryBudget, verbose bool) ([]byte, error) {
 	// Auto-compute optimal parameters based on input
-	params := analyzeDictParams(files, verbose)
+	return buildDictionary(ctx, files, analyzeDictParams(files, verbose), maxThreads, budget, verbose)
+}
 
-	var samples [][]byte
-	var totalSampled int64
-	var skippedEmpty, skippedError int
+// buildDictionary is trainDictionary with the given parameters
+func buildDictionary(ctx context.Context, files []fileTask, params dictParams, maxThreads int, budget *memoryBudget, verbose bool) ([]byte, error) {
+	if budget != nil {
+		// The last sample may overshoot the total by up to maxSampleSize
+		params.maxTotalSamples = min(params.maxTotalSamples, int64(budget.free()/dictTrainingFactor/2))
+		params.maxSampleSize = min(params.maxSampleSize, params.maxTotalSamples)
+		held := uint64(params.maxTotalSamples+params.maxSampleSize) * dictTrainingFactor
+		budget.acquire(held)
+		defer budget.release(held)
+	}
 
-	var skippedTooSmall int
+	jobs, skippedEmpty := selectSampleFiles(files, params)
+	var skippedError int
 
-	// Collect samples from files
-	for _, file := range files {
-		if totalSampled >= params.maxTotalSamples {
-			break
-		}
+	// Read samples in parallel; each worker fills its own slots
+	read := make([][]byte, len(jobs))
+	readErrs := make([]error, len(jobs))
+	if maxThreads < 1 {
+		maxThreads = 1
+	}
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < maxThreads && w < len(jobs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(jobs) || ctx.Err() != nil {
+					return
+				}
+				read[i], readErrs[i] = readFileSample(ctx, jobs[i].path, jobs[i].size)
+			}
+		}()
+	}
+	wg.Wait()
 
-		// Read sample from file
-		sampleSize := params.maxSampleSize
-		if file.OrigSize < uint64(sampleSize) {
-			sampleSize = int64(file.OrigSize)
-		}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-		if sampleSize == 0 {
-			skippedEmpty++
-			continue // Skip empty files
-		}
+	var samples [][]byte
+	var totalSampled int64
+	var skippedTooSmall int
 
-		sample, err := readFileSample(file.AbsPath, sampleSize)
-		if err != nil {
+	for i, sample := range read {
+		if readErrs[i] != nil {
 			skippedError++
 			continue
 		}
@@ -472,30 +622,120 @@ func trainDictionary(files []fileTask, verbose bool) ([]byte, error) {
 		ZstdLevel:   zstd.SpeedFastest,
 	}
 
-	var dictBytes []byte
-	var buildErr error
+	// The builder has no cancellation hook: run it aside and stop waiting on
+	// cancel (the abandoned build finishes in the background and is dropped)
+	type buildResult struct {
+		dict []byte
+		err  error
+	}
+	done := make(chan buildResult, 1)
 
-	func() {
+	go func() {
+		var res buildResult
 		defer func() {
 			if r := recover(); r != nil {
 				if verbose {
 					fmt.Printf("Dictionary training failed (library panic): %v - proceeding without dictionary\n", r)
 				}
-				dictBytes = []byte{}
+				res = buildResult{dict: []byte{}}
 			}
+			done <- res
 		}()
-		dictBytes, buildErr = dict.BuildZstdDict(samples, dictOpts)
+		res.dict, res.err = dict.BuildZstdDict(samples, dictOpts)
 	}()
 
-	if buildErr != nil {
-		return nil, fmt.Errorf("build dictionary: %w", buildErr)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-done:
+		if res.err != nil {
+			return nil, fmt.Errorf("build dictionary: %w", res.err)
+		}
+		return res.dict, nil
 	}
+}
 
-	return dictBytes, nil
+// sampleJob is one file chosen for dictionary training
+type sampleJob struct {
+	path string
+	size int64 // bytes to read from the start of the file
+}
+
+// selectSampleFiles picks the training files until the sample budget is spent.
+// Files are grouped into strata by folder and extension, and strata are
+// visited round-robin (one file each per round), so the budget is spread over
+// the whole tree instead of exhausted by whichever folders come first in the
+// (map-derived) input order. Within a stratum files are ordered by a hash of
+// their path: a deterministic pseudo-random pick rather than the first names
+// alphabetically. Returns the jobs and the number of empty files skipped.
+func selectSampleFiles(files []fileTask, params dictParams) ([]sampleJob, int) {
+	strata := make(map[string][]fileTask)
+	var skippedEmpty int
+	for _, file := range files {
+		if file.OrigSize == 0 {
+			skippedEmpty++
+			continue
+		}
+		key := filepath.Dir(file.RelPath) + "\x00" + strings.ToLower(filepath.Ext(file.RelPath))
+		strata[key] = append(strata[key], file)
+	}
+
+	keys := make([]string, 0, len(strata))
+	for key, group := range strata {
+		keys = append(keys, key)
+		sort.Slice(group, func(i, j int) bool {
+			hi, hj := pathHash(group[i].RelPath), pathHash(group[j].RelPath)
+			if hi != hj {
+				return hi < hj
+			}
+			return group[i].RelPath < group[j].RelPath
+		})
+	}
+	sort.Strings(keys)
+
+	var jobs []sampleJob
+	var budget int64
+	for round := 0; budget < params.maxTotalSamples; round++ {
+		picked := false
+		for _, key := range keys {
+			group := strata[key]
+			if round >= len(group) {
+				continue
+			}
+			picked = true
+
+			file := group[round]
+			sampleSize := params.maxSampleSize
+			if file.OrigSize < uint64(sampleSize) {
+				sampleSize = int64(file.OrigSize)
+			}
+			jobs = append(jobs, sampleJob{path: file.AbsPath, size: sampleSize})
+			budget += sampleSize
+			if budget >= params.maxTotalSamples {
+				break
+			}
+		}
+		if !picked {
+			break // every stratum exhausted
+		}
+	}
+
+	return jobs, skippedEmpty
+}
+
+// pathHash is the FNV-1a hash of a path, used for stable pseudo-random order
+func pathHash(path string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(path))
+	return h.Sum64()
 }
 
 // readFileSample reads up to maxBytes from the beginning of a file
-func readFileSample(path string, maxBytes int64) ([]byte, error) {
+func readFileSample(ctx context.Context, path string, maxBytes int64) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -512,14 +752,15 @@ func readFileSample(path string, maxBytes int64) ([]byte, error) {
 }
 
 // compressFileWithDict compresses a file using the worker's dictionary-loaded
-// encoder, reused across files via Reset.
+// encoder, reused across files via Reset. MethodStore copies the file as-is.
 func compressFileWithDict(
 	task fileTask,
 	writer io.Writer,
 	enc *zstd.Encoder,
+	method format.Method,
 	progressCb ProgressCallback,
 ) (uint64, error) {
-	src, err := os.Open(task.AbsPath)
+	src, err := openSource(task)
 	if err != nil {
 		return 0, fmt.Errorf("open source file: %w", err)
 	}
@@ -534,8 +775,6 @@ func compressFileWithDict(
 		},
 	}
 
-	enc.Reset(targetWriter)
-
 	// Progress tracking (throttled; EventFileComplete finishes the bar)
 	var uncompressedRead, lastReported uint64
 	proxy := &godelta.ProgressReader{
@@ -555,14 +794,9 @@ func compressFileWithDict(
 		},
 	}
 
-	// Compress
-	if _, err := io.Copy(enc, proxy); err != nil {
-		enc.Close()
-		return 0, fmt.Errorf("compress: %w", err)
-	}
-
-	if err := enc.Close(); err != nil {
-		return 0, fmt.Errorf("close encoder: %w", err)
+	// Compress (the dictionary is loaded in the worker's encoder)
+	if err := encodeEntry(targetWriter, proxy, method, 0, enc); err != nil {
+		return 0, err
 	}
 
 	return compressedBytes, nil
@@ -570,6 +804,7 @@ func compressFileWithDict(
 
 // dryRunDictCompression simulates dictionary compression without writing
 func dryRunDictCompression(
+	ctx context.Context,
 	files []fileTask,
 	dictionary []byte,
 	opts *Options,
@@ -578,13 +813,19 @@ func dryRunDictCompression(
 ) error {
 	var totalComprSize uint64
 
-	enc, err := newWorkerEncoder(opts.Level, 1, dictionary)
+	var encoders encoderTimer
+	enc, release, err := encoders.newWorkerEncoder(opts.Level, 1, opts.zstdWindow(), dictionary)
 	if err != nil {
 		return fmt.Errorf("create zstd encoder: %w", err)
 	}
-	defer enc.Close()
+	defer release()
+	encoders.record(&result.Timing)
 
 	for _, task := range files {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("compression canceled: %w", err)
+		}
+
 		if progressCb != nil && task.OrigSize > 0 {
 			progressCb(ProgressEvent{
 				Type:     EventFileStart,
@@ -594,7 +835,8 @@ func dryRunDictCompression(
 		}
 
 		// Compress to discard to measure size
-		comprSize, err := compressFileWithDict(task, &godelta.DiscardCounter{}, enc, progressCb)
+		method := opts.methodFor(task)
+		comprSize, err := compressFileWithDict(task, &godelta.DiscardCounter{}, enc, method, progressCb)
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", task.RelPath, err))
 			if progressCb != nil {
@@ -605,6 +847,11 @@ func dryRunDictCompression(
 
 		totalComprSize += comprSize
 		result.FilesProcessed++
+		if method == format.MethodStore {
+			result.StoredFiles++
+		}
+		recordFile(opts, task.OrigSize)
+		result.breakdown.add(task.RelPath, task.OrigSize, comprSize)
 
 		if progressCb != nil {
 			progressCb(ProgressEvent{
@@ -2,6 +2,8 @@
 package compress
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -463,3 +465,173 @@ func TestDictionaryComparison(t *testing.T) {
 		t.Logf("Dictionary saved %.1f%% vs non-dictionary", savings)
 	}
 }
+
+// TestTrainDictionaryCanceled checks that training stops at the first
+// cancellation point instead of sampling every file
+func TestTrainDictionaryCanceled(t *testing.T) {
+	inputDir := t.TempDir()
+	var files []fileTask
+	for i := 0; i < 50; i++ {
+		path := filepath.Join(inputDir, fmt.Sprintf("file%02d.txt", i))
+		content := strings.Repeat(fmt.Sprintf("line %d of a sample file\n", i), 100)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, fileTask{AbsPath: path, RelPath: filepath.Base(path), OrigSize: uint64(len(content))})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := trainDictionary(ctx, files, 4, nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	// Same input trains normally with a live context
+	dictionary, err := trainDictionary(context.Background(), files, 4, nil, false)
+	if err != nil {
+		t.Fatalf("train dictionary: %v", err)
+	}
+	if len(dictionary) == 0 {
+		t.Error("expected a non-empty dictionary")
+	}
+}
+
+// TestCompressContextCanceled checks that a canceled run reports the
+// cancellation and leaves no partial archive behind, in every mode
+func TestCompressContextCanceled(t *testing.T) {
+	inputDir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		content := strings.Repeat("cancellation test data\n", 50)
+		if err := os.WriteFile(filepath.Join(inputDir, fmt.Sprintf("f%02d.txt", i)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	modes := []struct {
+		name  string
+		opts  func(*Options)
+		parts string
+	}{
+		{"gdelta01", func(o *Options) {}, "out.gdelta"},
+		{"chunked", func(o *Options) { o.ChunkSize = 4 * 1024 }, "out.gdelta"},
+		{"dictionary", func(o *Options) { o.UseDictionary = true }, "out.gdelta"},
+		{"zip", func(o *Options) { o.UseZipFormat = true }, "out_*.zip"},
+		{"xz", func(o *Options) { o.UseXzFormat = true }, "out*"},
+		{"xz parts", func(o *Options) { o.UseXzFormat, o.XzParts = true, true }, "out_*.tar.xz"},
+	}
+
+	for _, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+			outDir := t.TempDir()
+			opts := &Options{
+				InputPath:  inputDir,
+				OutputPath: filepath.Join(outDir, "out.gdelta"),
+				MaxThreads: 2,
+				Quiet:      true,
+			}
+			if mode.name == "zip" || strings.HasPrefix(mode.name, "xz") {
+				opts.OutputPath = filepath.Join(outDir, "out")
+			}
+			mode.opts(opts)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := CompressContext(ctx, opts, nil)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected context.Canceled, got %v", err)
+			}
+
+			leftovers, _ := filepath.Glob(filepath.Join(outDir, mode.parts))
+			if len(leftovers) != 0 {
+				t.Errorf("partial archive left behind: %v", leftovers)
+			}
+		})
+	}
+}
+
+// TestSelectSampleFilesStratified checks that a small sample budget is spread
+// across folders and extensions and doesn't depend on input order
+func TestSelectSampleFilesStratified(t *testing.T) {
+	var files []fileTask
+	for i := 0; i < 100; i++ {
+		files = append(files, fileTask{AbsPath: fmt.Sprintf("/big/f%03d.log", i), RelPath: fmt.Sprintf("big/f%03d.log", i), OrigSize: 4096})
+	}
+	for i := 0; i < 3; i++ {
+		files = append(files, fileTask{AbsPath: fmt.Sprintf("/small/s%d.json", i), RelPath: fmt.Sprintf("small/s%d.json", i), OrigSize: 4096})
+		files = append(files, fileTask{AbsPath: fmt.Sprintf("/small/s%d.yaml", i), RelPath: fmt.Sprintf("small/s%d.yaml", i), OrigSize: 4096})
+	}
+	files = append(files, fileTask{AbsPath: "/empty.txt", RelPath: "empty.txt", OrigSize: 0})
+
+	params := dictParams{maxSampleSize: 4096, maxTotalSamples: 6 * 4096}
+	jobs, skippedEmpty := selectSampleFiles(files, params)
+
+	if skippedEmpty != 1 {
+		t.Errorf("skippedEmpty = %d, want 1", skippedEmpty)
+	}
+	if len(jobs) != 6 {
+		t.Fatalf("got %d jobs, want 6", len(jobs))
+	}
+	perStratum := map[string]int{}
+	for _, job := range jobs {
+		perStratum[filepath.ToSlash(filepath.Dir(job.path))+filepath.Ext(job.path)]++
+	}
+	for _, stratum := range []string{"/big.log", "/small.json", "/small.yaml"} {
+		if perStratum[stratum] != 2 {
+			t.Errorf("stratum %s sampled %d times, want 2 (got %v)", stratum, perStratum[stratum], perStratum)
+		}
+	}
+
+	// Reversed input order selects the same files
+	reversed := make([]fileTask, len(files))
+	for i, f := range files {
+		reversed[len(files)-1-i] = f
+	}
+	again, _ := selectSampleFiles(reversed, params)
+	for i := range jobs {
+		if jobs[i] != again[i] {
+			t.Fatalf("selection depends on input order: %v vs %v", jobs, again)
+		}
+	}
+}
+
+// TestDictionaryEncoderReuse checks that GDELTA03 creates one encoder per
+// worker rather than one per file, and reports it in Result.Timing
+func TestDictionaryEncoderReuse(t *testing.T) {
+	inputDir := t.TempDir()
+	for i := 0; i < 40; i++ {
+		content := fmt.Sprintf("{\"id\": %d, \"name\": \"item\", \"tags\": [\"a\", \"b\"]}\n", i)
+		if err := os.WriteFile(filepath.Join(inputDir, fmt.Sprintf("item%02d.json", i)), []byte(strings.Repeat(content, 20)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := &Options{
+		InputPath:     inputDir,
+		OutputPath:    filepath.Join(t.TempDir(), "out.gdelta"),
+		MaxThreads:    4,
+		UseDictionary: true,
+		Quiet:         true,
+	}
+	result, err := Compress(opts, nil)
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+
+	timing := result.Timing
+	if timing.EncodersCreated < 1 || timing.EncodersCreated > opts.MaxThreads {
+		t.Errorf("EncodersCreated = %d, want 1..%d for %d files", timing.EncodersCreated, opts.MaxThreads, result.FilesProcessed)
+	}
+	if timing.Total <= 0 || timing.Scan > timing.Total || timing.DictTraining > timing.Total {
+		t.Errorf("inconsistent timing: %+v", timing)
+	}
+	if timing.EncoderSetup > 0 && result.EncoderSetupSaved() <= 0 {
+		t.Errorf("expected a positive setup saving for %d files, got %v", result.FilesProcessed, result.EncoderSetupSaved())
+	}
+
+	opts.Verbose = true
+	if !strings.Contains(FormatSummary(result, opts), "Encoders:") {
+		t.Errorf("verbose summary is missing the timing section")
+	}
+}
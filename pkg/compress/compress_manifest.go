@@ -0,0 +1,119 @@
+// pkg/compress/compress_manifest.go
+package compress
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/creativeyann17/go-delta/internal/manifest"
+)
+
+// ManifestExt is added to the archive name to name its manifest
+const ManifestExt = ".sha256"
+
+// ManifestPath returns where WriteManifest puts the manifest of an archive
+// written with opts: next to it, or named after the whole set for archives
+// written in parts (backup_01.zip, backup_02.zip, ... get backup.zip.sha256)
+func ManifestPath(opts *Options) string {
+	switch {
+	case opts.UseZipFormat && !opts.SingleZip:
+		return strings.TrimSuffix(opts.OutputPath, ".zip") + ".zip" + ManifestExt
+	case opts.UseXzFormat && opts.XzParts:
+		return xzParts.basePath(opts.OutputPath) + xzParts.ext + ManifestExt
+	case opts.UseTarGzFormat:
+		return tarGzParts.basePath(opts.OutputPath) + tarGzParts.ext + ManifestExt
+	case opts.Use7zFormat:
+		return sevenZipParts.basePath(opts.OutputPath) + sevenZipParts.ext + ManifestExt
+	}
+	return opts.OutputPath + ManifestExt
+}
+
+// writeManifest hashes the archived files on MaxThreads workers and writes
+// their SHA-256 to ManifestPath in the sha256sum format. Files are read
+// again once the archive is written; a file that can't be read is reported
+// in result.Errors and left out.
+func writeManifest(ctx context.Context, opts *Options, foldersToCompress []folderTask, result *Result) error {
+	var entries []manifest.Entry
+	var mu sync.Mutex
+
+	taskCh := feedTasks(ctx, foldersToCompress, opts.MaxThreads*16)
+	var wg sync.WaitGroup
+	for range opts.MaxThreads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := getReadBuffer()
+			defer putReadBuffer(buf)
+			for task := range taskCh {
+				sum, err := hashFile(task, buf)
+				mu.Lock()
+				if err != nil {
+					result.Errors = append(result.Errors, fmt.Errorf("%s: manifest: %w", task.RelPath, err))
+				} else {
+					entries = append(entries, manifest.Entry{Path: filepath.ToSlash(task.RelPath), Sum: sum})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+
+	path := ManifestPath(opts)
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create manifest: %w", err)
+	}
+	if err := manifest.Write(f, entries); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := syncFile(opts, f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	result.ManifestPath = path
+	return nil
+}
+
+// hashFile returns the SHA-256 of the content of task's file
+func hashFile(task fileTask, buf []byte) ([32]byte, error) {
+	var sum [32]byte
+	f, err := os.Open(task.AbsPath)
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+	if task.sources != nil && task.sources.noPageCache {
+		adviseNoCache(f)
+	}
+	h := sha256.New()
+	for off := int64(0); ; {
+		n, err := f.Read(buf)
+		h.Write(buf[:n])
+		task.sources.uncache(f, off, int64(n))
+		off += int64(n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return sum, err
+		}
+	}
+	h.Sum(sum[:0])
+	return sum, nil
+}
@@ -0,0 +1,90 @@
+// pkg/compress/compress_manifest_test.go
+package compress
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifestPath(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{Options{OutputPath: "out/backup.gdelta"}, "out/backup.gdelta.sha256"},
+		{Options{OutputPath: "backup", UseZipFormat: true}, "backup.zip.sha256"},
+		{Options{OutputPath: "backup.zip", UseZipFormat: true}, "backup.zip.sha256"},
+		{Options{OutputPath: "backup.zip", UseZipFormat: true, SingleZip: true}, "backup.zip.sha256"},
+		{Options{OutputPath: "backup.tar.xz", UseXzFormat: true}, "backup.tar.xz.sha256"},
+		{Options{OutputPath: "backup.xz", UseXzFormat: true, XzParts: true}, "backup.tar.xz.sha256"},
+		{Options{OutputPath: "backup.tgz", UseTarGzFormat: true}, "backup.tar.gz.sha256"},
+		{Options{OutputPath: "backup", Use7zFormat: true}, "backup.7z.sha256"},
+	}
+	for _, tt := range tests {
+		if got := ManifestPath(&tt.opts); got != tt.want {
+			t.Errorf("ManifestPath(%q) = %q, want %q", tt.opts.OutputPath, got, tt.want)
+		}
+	}
+}
+
+// TestWriteManifest checks the manifest lists every file with the
+// SHA-256 of its content, sorted, and isn't written in dry-run mode
+func TestWriteManifest(t *testing.T) {
+	tempDir := t.TempDir()
+	inputDir := filepath.Join(tempDir, "input")
+	testFiles := map[string]string{
+		"b.txt":     "second",
+		"a.txt":     "first",
+		"sub/c.txt": "third",
+		"empty.txt": "",
+	}
+	for relPath, content := range testFiles {
+		fullPath := filepath.Join(inputDir, relPath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archivePath := filepath.Join(tempDir, "backup.gdelta")
+	opts := &Options{InputPath: inputDir, OutputPath: archivePath, WriteManifest: true, DryRun: true, Quiet: true}
+	result, err := Compress(opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ManifestPath != "" {
+		t.Errorf("Dry run wrote manifest %s", result.ManifestPath)
+	}
+
+	opts.DryRun = false
+	result, err = Compress(opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ManifestPath != archivePath+ManifestExt {
+		t.Fatalf("Manifest written to %q", result.ManifestPath)
+	}
+	data, err := os.ReadFile(result.ManifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want strings.Builder
+	for _, relPath := range []string{"a.txt", "b.txt", "empty.txt", "sub/c.txt"} {
+		fmt.Fprintf(&want, "%x  %s\n", sha256.Sum256([]byte(testFiles[relPath])), relPath)
+	}
+	if string(data) != want.String() {
+		t.Errorf("Manifest:\n%s\nwant:\n%s", data, want.String())
+	}
+
+	opts.FromTar = strings.NewReader("")
+	if _, err := Compress(opts, nil); !errors.Is(err, ErrManifestFromTar) {
+		t.Errorf("Expected ErrManifestFromTar, got %v", err)
+	}
+}
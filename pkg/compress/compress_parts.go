@@ -0,0 +1,345 @@
+// pkg/compress/compress_parts.go
+package compress
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// partFormat is a standard archive format written as one part per worker
+// (tar.xz, tar.gz, 7z), for true parallelism without a shared stream
+type partFormat struct {
+	// name is used in messages, e.g. "XZ"
+	name string
+	// ext is the extension of the parts; it and trimExts are removed from
+	// OutputPath before the part number is added
+	ext      string
+	trimExts []string
+	// dryRunPercent is the compressed size assumed in dry-run mode, as a
+	// percentage of the original size
+	dryRunPercent uint64
+	// open starts a part in f
+	open func(f *os.File, opts *Options) (partArchive, error)
+}
+
+// partArchive is one part being written by a worker
+type partArchive interface {
+	// create starts an entry of size bytes and returns the writer for its
+	// content, valid until the next create or close
+	create(name string, size uint64) (io.Writer, error)
+	// close finishes the part; the caller closes the file
+	close() error
+}
+
+// basePath returns outputPath without the format's extensions, to which
+// the part numbers are added
+func (pf partFormat) basePath(outputPath string) string {
+	for _, ext := range append([]string{pf.ext}, pf.trimExts...) {
+		if strings.HasSuffix(outputPath, ext) {
+			return strings.TrimSuffix(outputPath, ext)
+		}
+	}
+	return outputPath
+}
+
+// compressToParts compresses files into multiple archives of the given
+// format (one per thread) for true parallelism
+// Output: archive_01<ext>, archive_02<ext>, ..., archive_N<ext>
+func compressToParts(ctx context.Context, opts *Options, progressCb ProgressCallback, foldersToCompress []folderTask, totalFiles int, result *Result, pf partFormat) error {
+	baseOutputPath := pf.basePath(opts.OutputPath)
+
+	// Process files with worker pool - each worker writes to its own part
+	var totalCompSize atomic.Uint64
+	var processedCount atomic.Uint32
+	var errorsMu sync.Mutex
+
+	var wg sync.WaitGroup
+
+	// Shared task channel: workers pull files as they become free
+	taskCh := feedTasks(ctx, foldersToCompress, opts.MaxThreads*16)
+
+	// Track archive files created for later cleanup/stats
+	type archiveFileInfo struct {
+		path string
+		size uint64
+	}
+	archiveFiles := make([]archiveFileInfo, opts.MaxThreads)
+	var archiveFilesMu sync.Mutex
+
+	// Parts are numbered contiguously in order of first file received, so
+	// idle workers don't leave empty (or gap-numbered) archives behind.
+	var partCounter atomic.Int32
+
+	// Start worker goroutines - each creates its own part on first use
+	for i := 0; i < opts.MaxThreads; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+
+			var workerArchive partArchive
+			var workerFile *os.File
+			var workerFilePath string
+
+			// ensureArchive lazily creates this worker's archive on first task
+			ensureArchive := func() error {
+				if workerFile != nil {
+					return nil
+				}
+				partNum := int(partCounter.Add(1))
+				workerFilePath = fmt.Sprintf("%s_%02d%s", baseOutputPath, partNum, pf.ext)
+
+				// Ensure output directory exists
+				outputDir := filepath.Dir(workerFilePath)
+				if err := os.MkdirAll(outputDir, 0755); err != nil {
+					return fmt.Errorf("worker %d: create output directory: %w", workerID, err)
+				}
+
+				var err error
+				workerFile, err = os.Create(workerFilePath)
+				if err != nil {
+					return fmt.Errorf("worker %d: create archive: %w", workerID, err)
+				}
+
+				workerArchive, err = pf.open(workerFile, opts)
+				if err != nil {
+					workerFile.Close()
+					workerFile = nil
+					return fmt.Errorf("worker %d: create %s writer: %w", workerID, pf.name, err)
+				}
+
+				// Track archive file for stats
+				archiveFilesMu.Lock()
+				archiveFiles[workerID].path = workerFilePath
+				archiveFilesMu.Unlock()
+				return nil
+			}
+
+			for task := range taskCh {
+				if !opts.DryRun {
+					if err := ensureArchive(); err != nil {
+						errorsMu.Lock()
+						result.Errors = append(result.Errors, err)
+						errorsMu.Unlock()
+						return
+					}
+				}
+				// Skip progress bar for 0-byte files
+				if progressCb != nil && task.OrigSize > 0 {
+					progressCb(ProgressEvent{
+						Type:     EventFileStart,
+						FilePath: task.RelPath,
+						Total:    int64(task.OrigSize),
+					})
+				}
+
+				// Open file for reading
+				file, err := openSource(task)
+				if err != nil {
+					errorsMu.Lock()
+					result.Errors = append(result.Errors, fmt.Errorf("%s: open: %w", task.RelPath, err))
+					errorsMu.Unlock()
+
+					if progressCb != nil {
+						progressCb(ProgressEvent{
+							Type:     EventError,
+							FilePath: task.RelPath,
+						})
+					}
+					continue
+				}
+
+				if !opts.DryRun && workerArchive != nil {
+					// Start the entry
+					entryWriter, err := workerArchive.create(task.RelPath, task.OrigSize)
+					if err != nil {
+						file.Close()
+						errorsMu.Lock()
+						result.Errors = append(result.Errors, fmt.Errorf("%s: write header: %w", task.RelPath, err))
+						errorsMu.Unlock()
+						continue
+					}
+
+					// Write file data with progress reporting
+					buf := getReadBuffer()
+					var written, lastReported int64
+					for {
+						nr, errRead := file.Read(buf)
+						if nr > 0 {
+							nw, errWrite := entryWriter.Write(buf[0:nr])
+							if errWrite != nil {
+								file.Close()
+								errorsMu.Lock()
+								result.Errors = append(result.Errors, fmt.Errorf("%s: write: %w", task.RelPath, errWrite))
+								errorsMu.Unlock()
+								break
+							}
+							written += int64(nw)
+
+							// Report progress (throttled; EventFileComplete finishes the bar)
+							if progressCb != nil && written-lastReported >= progressReportStep {
+								lastReported = written
+								progressCb(ProgressEvent{
+									Type:     EventFileProgress,
+									FilePath: task.RelPath,
+									Current:  written,
+									Total:    int64(task.OrigSize),
+								})
+							}
+						}
+						if errRead == io.EOF {
+							break
+						}
+						if errRead != nil {
+							file.Close()
+							errorsMu.Lock()
+							result.Errors = append(result.Errors, fmt.Errorf("%s: read: %w", task.RelPath, errRead))
+							errorsMu.Unlock()
+							break
+						}
+					}
+					putReadBuffer(buf)
+				} else if opts.DryRun {
+					// Dry-run: estimate compression
+					totalCompSize.Add(task.OrigSize * pf.dryRunPercent / 100)
+				}
+
+				file.Close()
+
+				// Notify file complete. CompressedSize stays 0: per-file
+				// compressed size is unknown inside a shared stream.
+				processedCount.Add(1)
+				recordFile(opts, task.OrigSize)
+				result.breakdown.add(task.RelPath, task.OrigSize, 0)
+				if progressCb != nil {
+					progressCb(ProgressEvent{
+						Type:     EventFileComplete,
+						FilePath: task.RelPath,
+						Current:  int64(task.OrigSize),
+						Total:    int64(task.OrigSize),
+					})
+				}
+			}
+
+			// Close worker archive and record final size
+			if !opts.DryRun && workerFile != nil {
+				if err := workerArchive.close(); err != nil {
+					workerFile.Close()
+					errorsMu.Lock()
+					result.Errors = append(result.Errors, fmt.Errorf("worker %d: close %s: %w", workerID, pf.name, err))
+					errorsMu.Unlock()
+					return
+				}
+				if err := syncFile(opts, workerFile); err != nil {
+					workerFile.Close()
+					errorsMu.Lock()
+					result.Errors = append(result.Errors, fmt.Errorf("worker %d: %w", workerID, err))
+					errorsMu.Unlock()
+					return
+				}
+				if err := workerFile.Close(); err != nil {
+					errorsMu.Lock()
+					result.Errors = append(result.Errors, fmt.Errorf("worker %d: close file: %w", workerID, err))
+					errorsMu.Unlock()
+					return
+				}
+
+				// Record final archive size
+				stat, err := os.Stat(workerFilePath)
+				if err == nil {
+					archiveFilesMu.Lock()
+					archiveFiles[workerID].size = uint64(stat.Size())
+					archiveFilesMu.Unlock()
+				}
+			}
+		}(i)
+	}
+
+	// Wait for all workers to complete
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		for _, info := range archiveFiles {
+			if info.path != "" {
+				os.Remove(info.path)
+			}
+		}
+		return fmt.Errorf("compression canceled: %w", err)
+	}
+
+	result.FilesProcessed = int(processedCount.Load())
+
+	// Calculate total compressed size from all worker archives
+	if !opts.DryRun {
+		var totalSize uint64
+		for _, info := range archiveFiles {
+			if info.size > 0 {
+				totalSize += info.size
+			}
+		}
+		result.CompressedSize = totalSize
+
+		// Log multi-part archive info if verbose
+		if opts.Verbose && !opts.Quiet {
+			fmt.Printf("\nCreated %d %s archives:\n", opts.MaxThreads, pf.name)
+			for _, info := range archiveFiles {
+				if info.size > 0 {
+					fmt.Printf("  %s (%.2f MB)\n",
+						filepath.Base(info.path), float64(info.size)/(1024*1024))
+				}
+			}
+		}
+	} else {
+		result.CompressedSize = totalCompSize.Load()
+	}
+
+	if progressCb != nil {
+		progressCb(ProgressEvent{
+			Type:           EventComplete,
+			Current:        int64(result.FilesProcessed),
+			Total:          int64(totalFiles),
+			CompressedSize: result.CompressedSize,
+		})
+	}
+
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("completed with %d errors (see result.Errors)", len(result.Errors))
+	}
+
+	return nil
+}
+
+// tarPart is a part holding a tar stream compressed by comp
+type tarPart struct {
+	tw   *tar.Writer
+	comp io.WriteCloser
+}
+
+func newTarPart(comp io.WriteCloser) *tarPart {
+	return &tarPart{tw: tar.NewWriter(comp), comp: comp}
+}
+
+func (p *tarPart) create(name string, size uint64) (io.Writer, error) {
+	header := &tar.Header{
+		Name: name,
+		Mode: 0644,
+		Size: int64(size),
+	}
+	if err := p.tw.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	return p.tw, nil
+}
+
+func (p *tarPart) close() error {
+	if err := p.tw.Close(); err != nil {
+		return fmt.Errorf("close tar: %w", err)
+	}
+	return p.comp.Close()
+}
@@ -0,0 +1,153 @@
+// pkg/compress/compress_parts_test.go
+package compress
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+
+	"github.com/creativeyann17/go-delta/internal/sevenzip"
+)
+
+// writePartsInput creates files spread over folders, one of them empty
+func writePartsInput(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+	files := map[string][]byte{
+		"a.txt":         bytes.Repeat([]byte("alpha "), 4000),
+		"dir/b.txt":     []byte("bravo"),
+		"dir/sub/c.txt": bytes.Repeat([]byte("charlie "), 100),
+		"empty.txt":     {},
+	}
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return files
+}
+
+func TestTarGzCompress(t *testing.T) {
+	inputDir := t.TempDir()
+	want := writePartsInput(t, inputDir)
+	outDir := t.TempDir()
+
+	result, err := Compress(&Options{
+		InputPath:      inputDir,
+		OutputPath:     filepath.Join(outDir, "backup.tgz"),
+		MaxThreads:     2,
+		Level:          6,
+		UseTarGzFormat: true,
+		Quiet:          true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FilesProcessed != len(want) || result.CompressedSize == 0 {
+		t.Fatalf("%d files processed, %d bytes", result.FilesProcessed, result.CompressedSize)
+	}
+
+	parts, _ := filepath.Glob(filepath.Join(outDir, "backup_*.tar.gz"))
+	if len(parts) == 0 || len(parts) > 2 {
+		t.Fatalf("expected 1-2 parts, got %v", parts)
+	}
+	got := make(map[string][]byte)
+	for _, part := range parts {
+		f, err := os.Open(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("%s: %v", part, err)
+		}
+		tr := tar.NewReader(zr)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", part, err)
+			}
+			got[header.Name], _ = io.ReadAll(tr)
+		}
+		f.Close()
+	}
+	for rel, content := range want {
+		if !bytes.Equal(got[rel], content) {
+			t.Errorf("%s: content mismatch", rel)
+		}
+	}
+}
+
+func Test7zCompress(t *testing.T) {
+	inputDir := t.TempDir()
+	want := writePartsInput(t, inputDir)
+	outDir := t.TempDir()
+
+	result, err := Compress(&Options{
+		InputPath:   inputDir,
+		OutputPath:  filepath.Join(outDir, "backup.7z"),
+		MaxThreads:  2,
+		Level:       3,
+		Use7zFormat: true,
+		Quiet:       true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FilesProcessed != len(want) || result.CompressedSize == 0 {
+		t.Fatalf("%d files processed, %d bytes", result.FilesProcessed, result.CompressedSize)
+	}
+
+	parts, _ := filepath.Glob(filepath.Join(outDir, "backup_*.7z"))
+	if len(parts) == 0 || len(parts) > 2 {
+		t.Fatalf("expected 1-2 parts, got %v", parts)
+	}
+	for _, part := range parts {
+		data, err := os.ReadFile(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(data, sevenzip.Signature) {
+			t.Errorf("%s: missing 7z signature", part)
+		}
+	}
+}
+
+func TestStandardFormatOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want error
+	}{
+		{"tar.gz and 7z", Options{UseTarGzFormat: true, Use7zFormat: true}, ErrFormatConflict},
+		{"zip and 7z", Options{UseZipFormat: true, Use7zFormat: true}, ErrFormatConflict},
+		{"tar.gz level", Options{UseTarGzFormat: true, Level: 12}, ErrInvalidLevelTarGz},
+		{"tar.gz chunking", Options{UseTarGzFormat: true, ChunkSize: 64 * 1024}, ErrTarGzNoChunking},
+		{"tar.gz dictionary", Options{UseTarGzFormat: true, UseDictionary: true}, ErrTarGzNoDictionary},
+		{"7z level", Options{Use7zFormat: true, Level: 12}, ErrInvalidLevel7z},
+		{"7z chunking", Options{Use7zFormat: true, ChunkSize: 64 * 1024}, Err7zNoChunking},
+		{"7z dictionary", Options{Use7zFormat: true, UseDictionary: true}, Err7zNoDictionary},
+		{"7z codec", Options{Use7zFormat: true, Codec: "lz4"}, ErrCodecFormat},
+		{"tar.gz parity", Options{UseTarGzFormat: true, ParityPercent: 5}, ErrParityFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.InputPath = "in"
+			if err := tt.opts.Validate(); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
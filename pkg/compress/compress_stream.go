@@ -0,0 +1,189 @@
+// pkg/compress/compress_stream.go
+package compress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/creativeyann17/go-delta/internal/chunker"
+	"github.com/creativeyann17/go-delta/internal/chunkstore"
+	"github.com/creativeyann17/go-delta/internal/format"
+)
+
+// DefaultStreamName is the entry name of Options.FromStream when StreamName
+// is empty
+const DefaultStreamName = "stream"
+
+// streamInput reports whether the run reads a single stream: FromStream,
+// or an InputPath naming a block device
+func (o *Options) streamInput() bool {
+	return o.FromStream != nil || (len(o.Files) == 0 && o.FromTar == nil && IsBlockDevice(o.InputPath))
+}
+
+// IsBlockDevice reports whether path is a block device (/dev/sdb1, ...),
+// which reads like a file of the device's size but isn't a regular file.
+// Compress stores such an InputPath as a single stream entry.
+func IsBlockDevice(path string) bool {
+	if path == "" {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	mode := info.Mode()
+	return mode&os.ModeDevice != 0 && mode&os.ModeCharDevice == 0
+}
+
+// readEntriesFunc reads the entries of a stream, submitting their chunks to
+// the pipeline, and returns their metadata in archive order
+type readEntriesFunc func(chunkerInstance *chunker.Chunker, chunks *chunkPipeline, store *chunkstore.Store) ([]format.FileMetadata, error)
+
+// compressFromStream stores opts.FromStream, or the block device at
+// opts.InputPath, as a single GDELTA02 entry named opts.StreamName. The
+// stream is chunked as it is read and never buffered whole, so it can be a
+// whole disk or a database dump of any size; its size is only known up
+// front for a device.
+func compressFromStream(ctx context.Context, opts *Options, progressCb ProgressCallback, result *Result, budget *memoryBudget) error {
+	r := opts.FromStream
+	var size uint64
+	if r == nil {
+		f, err := os.Open(opts.InputPath)
+		if err != nil {
+			return fmt.Errorf("open device: %w", err)
+		}
+		defer f.Close()
+		end, err := f.Seek(0, io.SeekEnd)
+		if err != nil {
+			return fmt.Errorf("device size: %w", err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("device size: %w", err)
+		}
+		r, size = f, uint64(end)
+	}
+
+	if progressCb != nil {
+		progressCb(ProgressEvent{Type: EventStart, Total: 1, TotalBytes: size})
+	}
+	return compressStream(ctx, opts, progressCb, result, budget, func(chunkerInstance *chunker.Chunker, chunks *chunkPipeline, store *chunkstore.Store) ([]format.FileMetadata, error) {
+		task := fileTask{RelPath: opts.StreamName, OrigSize: size}
+		if progressCb != nil {
+			progressCb(ProgressEvent{Type: EventFileStart, FilePath: task.RelPath, Total: int64(size)})
+		}
+		metadata, stored, err := chunkReader(task, &contextReader{ctx: ctx, r: r}, chunkerInstance, chunks, progressCb)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", task.RelPath, err)
+		}
+		result.OriginalSize += metadata.OrigSize
+		recordFile(opts, metadata.OrigSize)
+		result.breakdown.add(task.RelPath, metadata.OrigSize, stored)
+
+		if progressCb != nil {
+			stats := chunkStoreStats(store.Stats())
+			progressCb(ProgressEvent{
+				Type:       EventFileComplete,
+				FilePath:   task.RelPath,
+				Current:    int64(metadata.OrigSize),
+				Total:      int64(metadata.OrigSize),
+				ChunkStats: &stats,
+			})
+		}
+		return []format.FileMetadata{metadata}, nil
+	})
+}
+
+// contextReader stops a stream read when the run is canceled, since the
+// chunker only returns between reads
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, fmt.Errorf("compression canceled: %w", err)
+	}
+	return c.r.Read(p)
+}
+
+// compressStream writes the entries returned by readEntries into a GDELTA02
+// archive. Entries are read one after the other (a stream can't be split
+// between workers), while the chunk pipeline compresses their chunks in
+// parallel.
+func compressStream(ctx context.Context, opts *Options, progressCb ProgressCallback, result *Result, budget *memoryBudget, readEntries readEntriesFunc) error {
+	store, err := newChunkStore(opts)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var outFile, chunkDataFile *os.File
+	var chunkDataWriter io.Writer
+	if !opts.DryRun {
+		var cleanup func()
+		outFile, chunkDataFile, cleanup, err = createGDelta02Files(opts.OutputPath)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		chunkDataWriter = chunkDataFile
+	}
+	removeOutput := func() {
+		if outFile != nil {
+			outFile.Close()
+			os.Remove(opts.OutputPath)
+		}
+	}
+
+	budget.acquireWorkers(opts.MaxThreads)
+	chunks, err := newChunkPipeline(opts.MaxThreads, store, nil, chunkDataWriter, opts.codecMethod(), opts.Level, nil)
+	if err != nil {
+		budget.releaseWorkers(opts.MaxThreads)
+		removeOutput()
+		return err
+	}
+	files, err := readEntries(opts.newChunker(), chunks, store)
+	chunks.close()
+	result.Extensions = chunks.extensions.snapshot()
+	budget.releaseWorkers(opts.MaxThreads)
+	if err != nil {
+		removeOutput()
+		return err
+	}
+	if len(files) == 0 {
+		removeOutput()
+		return ErrNoFiles
+	}
+
+	result.FilesTotal = len(files) + len(result.Errors)
+	result.FilesProcessed = len(files)
+	if outFile != nil {
+		if err := writeGDelta02Archive(outFile, chunkDataFile, store, nil, files, nil, opts, result); err != nil {
+			return err
+		}
+	}
+
+	stats := store.Stats()
+	result.TotalChunks = stats.TotalChunks
+	result.UniqueChunks = stats.UniqueChunks
+	result.DedupedChunks = stats.DedupedChunks
+	result.BytesSaved = stats.BytesSaved
+	result.Evictions = stats.Evictions
+	result.ChunkStore = chunkStoreStats(stats)
+	result.StoredChunks = chunks.storedChunks.Load()
+
+	if progressCb != nil {
+		progressCb(ProgressEvent{
+			Type:           EventComplete,
+			Current:        int64(result.FilesProcessed),
+			Total:          int64(result.FilesTotal),
+			TotalBytes:     result.OriginalSize,
+			CompressedSize: result.CompressedSize,
+			ChunkStats:     &result.ChunkStore,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// pkg/compress/compress_stream_test.go
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+)
+
+func TestCompressFromStream(t *testing.T) {
+	dir := t.TempDir()
+	block := make([]byte, 256*1024)
+	rand.New(rand.NewSource(1)).Read(block)
+	content := bytes.Join([][]byte{block, []byte("header"), block, block}, nil)
+
+	archivePath := filepath.Join(dir, "dump.gdelta")
+	// A pipe-like reader: no Seek, no size
+	stream := struct{ io.Reader }{bytes.NewReader(content)}
+	result, err := Compress(&Options{
+		FromStream: stream,
+		StreamName: "db/dump.sql",
+		InputPath:  "ignored",
+		OutputPath: archivePath,
+		Quiet:      true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FilesProcessed != 1 || result.OriginalSize != uint64(len(content)) {
+		t.Errorf("%d files, %d bytes; want 1 and %d", result.FilesProcessed, result.OriginalSize, len(content))
+	}
+	if result.ChunkSize != DefaultTarChunkSize || result.DedupedChunks == 0 {
+		t.Errorf("chunk size %d, %d deduplicated chunks", result.ChunkSize, result.DedupedChunks)
+	}
+
+	outDir := filepath.Join(dir, "out")
+	dres, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: outDir, Quiet: true}, nil)
+	if err != nil || len(dres.Errors) > 0 {
+		t.Fatalf("decompress: %v %v", err, dres.Errors)
+	}
+	got, err := os.ReadFile(filepath.Join(outDir, "db", "dump.sql"))
+	if err != nil || !bytes.Equal(got, content) {
+		t.Errorf("Decompressed content mismatch (%v)", err)
+	}
+
+	opts := &Options{FromStream: bytes.NewReader(nil), OutputPath: filepath.Join(dir, "default.gdelta"), DryRun: true, Quiet: true}
+	if _, err := Compress(opts, nil); err != nil || opts.StreamName != DefaultStreamName {
+		t.Errorf("Default name %q (%v)", opts.StreamName, err)
+	}
+}
+
+func TestCompressFromStreamErrors(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "a.gdelta")
+	tests := []struct {
+		name string
+		opts Options
+		want error
+	}{
+		{"zip output", Options{UseZipFormat: true}, ErrFromStreamFormat},
+		{"tar input", Options{FromTar: bytes.NewReader(nil)}, ErrFromStreamFormat},
+		{"manifest", Options{WriteManifest: true}, ErrManifestFromStream},
+		{"unsafe name", Options{StreamName: "../dump"}, errUnsafeTarPath},
+	}
+	for _, tt := range tests {
+		tt.opts.FromStream = bytes.NewReader([]byte("data"))
+		tt.opts.OutputPath = out
+		tt.opts.Quiet = true
+		if _, err := Compress(&tt.opts, nil); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// Regular files and character devices aren't read as streams
+	if IsBlockDevice(out) || IsBlockDevice(os.DevNull) {
+		t.Error("IsBlockDevice accepted a file or a character device")
+	}
+}
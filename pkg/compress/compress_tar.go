@@ -0,0 +1,158 @@
+// pkg/compress/compress_tar.go
+package compress
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/creativeyann17/go-delta/internal/chunker"
+	"github.com/creativeyann17/go-delta/internal/chunkstore"
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultTarChunkSize is the chunk size used for Options.FromTar when
+// ChunkSize is 0
+const DefaultTarChunkSize = 64 * 1024
+
+// Magic bytes of the compressed tar streams FromTar accepts
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// errUnsafeTarPath is recorded for tar entries whose name leaves the archive
+// root
+var errUnsafeTarPath = errors.New("path leaves the archive root")
+
+// compressFromTar converts the tar stream in opts.FromTar into a GDELTA02
+// archive. Entries are read one after the other (a stream can't be split
+// between workers), while the chunk pipeline compresses their chunks in
+// parallel. The file count is only known at the end, so no EventStart is
+// emitted.
+func compressFromTar(ctx context.Context, opts *Options, progressCb ProgressCallback, result *Result, budget *memoryBudget) error {
+	tr, closeStream, err := openTarStream(opts.FromTar)
+	if err != nil {
+		return err
+	}
+	defer closeStream()
+
+	return compressStream(ctx, opts, progressCb, result, budget, func(chunkerInstance *chunker.Chunker, chunks *chunkPipeline, store *chunkstore.Store) ([]format.FileMetadata, error) {
+		return readTarEntries(ctx, tr, chunkerInstance, chunks, store, opts, progressCb, result)
+	})
+}
+
+// openTarStream returns a tar reader over r, decompressing it first when
+// it starts with the gzip or zstd magic
+func openTarStream(r io.Reader) (*tar.Reader, func(), error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open gzip stream: %w", err)
+		}
+		return tar.NewReader(zr), func() { zr.Close() }, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open zstd stream: %w", err)
+		}
+		return tar.NewReader(zr), zr.Close, nil
+	default:
+		return tar.NewReader(br), func() {}, nil
+	}
+}
+
+// readTarEntries chunks every regular file of the tar stream and returns
+// their metadata in stream order. A name seen again replaces the earlier
+// entry, as it would on extraction. Entries with unsafe names are recorded
+// in result.Errors; a broken stream or a failed chunk write ends the run.
+func readTarEntries(ctx context.Context, tr *tar.Reader, chunkerInstance *chunker.Chunker, chunks *chunkPipeline, store *chunkstore.Store, opts *Options, progressCb ProgressCallback, result *Result) ([]format.FileMetadata, error) {
+	var files []format.FileMetadata
+	seen := make(map[string]int)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("compression canceled: %w", err)
+		}
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read tar: %w", err)
+		}
+		// Directories are implied by file paths; links and special files
+		// have no content to store
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		relPath, err := tarEntryPath(header.Name)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", header.Name, err))
+			if progressCb != nil {
+				progressCb(ProgressEvent{Type: EventError, FilePath: header.Name})
+			}
+			continue
+		}
+
+		task := fileTask{RelPath: opts.PathNormalization.Apply(relPath), OrigSize: uint64(header.Size)}
+		if progressCb != nil && task.OrigSize > 0 {
+			progressCb(ProgressEvent{
+				Type:     EventFileStart,
+				FilePath: task.RelPath,
+				Total:    int64(task.OrigSize),
+			})
+		}
+		metadata, stored, err := chunkReader(task, tr, chunkerInstance, chunks, progressCb)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", relPath, err)
+		}
+		if opts.Verbose && len(metadata.ChunkHashes) > 0 {
+			fmt.Printf("  %s: %d chunks\n", relPath, len(metadata.ChunkHashes))
+		}
+
+		if i, ok := seen[relPath]; ok {
+			result.OriginalSize -= files[i].OrigSize
+			files[i] = metadata
+		} else {
+			seen[relPath] = len(files)
+			files = append(files, metadata)
+		}
+		result.OriginalSize += task.OrigSize
+		recordFile(opts, task.OrigSize)
+		result.breakdown.add(task.RelPath, task.OrigSize, stored)
+
+		if progressCb != nil {
+			stats := chunkStoreStats(store.Stats())
+			progressCb(ProgressEvent{
+				Type:       EventFileComplete,
+				FilePath:   task.RelPath,
+				Current:    int64(task.OrigSize),
+				Total:      int64(task.OrigSize),
+				ChunkStats: &stats,
+			})
+		}
+	}
+	return files, nil
+}
+
+// tarEntryPath returns the archive path for a tar entry name: cleaned,
+// without leading "./" or "/", and refused if it climbs above the root
+func tarEntryPath(name string) (string, error) {
+	p := path.Clean(strings.TrimLeft(name, "/"))
+	if p == "." || p == ".." || strings.HasPrefix(p, "../") {
+		return "", errUnsafeTarPath
+	}
+	return p, nil
+}
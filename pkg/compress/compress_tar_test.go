@@ -0,0 +1,155 @@
+// pkg/compress/compress_tar_test.go
+package compress
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+)
+
+// buildTar writes a tar holding two copies of the same content, a file
+// given twice, a directory, a link and an entry escaping the root
+func buildTar(t *testing.T) []byte {
+	t.Helper()
+	shared := bytes.Repeat([]byte("same content in two files "), 8000)
+	entries := []struct {
+		header  tar.Header
+		content []byte
+	}{
+		{tar.Header{Name: "./data/", Typeflag: tar.TypeDir, Mode: 0755}, nil},
+		{tar.Header{Name: "./data/a.bin", Typeflag: tar.TypeReg, Mode: 0644}, shared},
+		{tar.Header{Name: "./data/b.bin", Typeflag: tar.TypeReg, Mode: 0644}, shared},
+		{tar.Header{Name: "notes.txt", Typeflag: tar.TypeReg, Mode: 0644}, []byte("first")},
+		{tar.Header{Name: "empty.txt", Typeflag: tar.TypeReg, Mode: 0644}, nil},
+		{tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "notes.txt"}, nil},
+		{tar.Header{Name: "../evil.txt", Typeflag: tar.TypeReg, Mode: 0644}, []byte("evil")},
+		{tar.Header{Name: "notes.txt", Typeflag: tar.TypeReg, Mode: 0644}, []byte("second")},
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		e.header.Size = int64(len(e.content))
+		if err := tw.WriteHeader(&e.header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(e.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompressFromTar(t *testing.T) {
+	raw := buildTar(t)
+	want := map[string][]byte{
+		"data/a.bin": bytes.Repeat([]byte("same content in two files "), 8000),
+		"data/b.bin": bytes.Repeat([]byte("same content in two files "), 8000),
+		"notes.txt":  []byte("second"),
+		"empty.txt":  {},
+	}
+
+	streams := map[string]func() []byte{
+		"tar": func() []byte { return raw },
+		"tar.gz": func() []byte {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			zw.Write(raw)
+			zw.Close()
+			return buf.Bytes()
+		},
+		"tar.zst": func() []byte {
+			var buf bytes.Buffer
+			zw, _ := zstd.NewWriter(&buf)
+			zw.Write(raw)
+			zw.Close()
+			return buf.Bytes()
+		},
+	}
+	for name, stream := range streams {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			archivePath := filepath.Join(dir, "a.gdelta")
+			result, err := Compress(&Options{
+				FromTar:    bytes.NewReader(stream()),
+				OutputPath: archivePath,
+				Level:      3,
+				Quiet:      true,
+			}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ChunkSize != DefaultTarChunkSize {
+				t.Errorf("chunk size %d, want %d", result.ChunkSize, DefaultTarChunkSize)
+			}
+			if result.FilesProcessed != len(want) || len(result.Errors) != 1 {
+				t.Fatalf("%d files, errors %v", result.FilesProcessed, result.Errors)
+			}
+			if result.DedupedChunks == 0 {
+				t.Error("expected the duplicate file to be deduplicated")
+			}
+
+			outDir := filepath.Join(dir, "out")
+			dres, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: outDir, Quiet: true}, nil)
+			if err != nil || len(dres.Errors) > 0 {
+				t.Fatalf("decompress: %v %v", err, dres.Errors)
+			}
+			for rel, content := range want {
+				got, err := os.ReadFile(filepath.Join(outDir, rel))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, content) {
+					t.Errorf("%s: content mismatch", rel)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(dir, "evil.txt")); err == nil {
+				t.Error("entry escaping the root was written")
+			}
+		})
+	}
+}
+
+func TestCompressFromTarErrors(t *testing.T) {
+	dir := t.TempDir()
+	empty := func() io.Reader {
+		var buf bytes.Buffer
+		tar.NewWriter(&buf).Close()
+		return &buf
+	}
+
+	_, err := Compress(&Options{FromTar: empty(), OutputPath: filepath.Join(dir, "a.gdelta"), UseZipFormat: true, Quiet: true}, nil)
+	if !errors.Is(err, ErrFromTarFormat) {
+		t.Errorf("zip output: got %v, want ErrFromTarFormat", err)
+	}
+
+	archivePath := filepath.Join(dir, "empty.gdelta")
+	_, err = Compress(&Options{FromTar: empty(), OutputPath: archivePath, Quiet: true}, nil)
+	if !errors.Is(err, ErrNoFiles) {
+		t.Errorf("empty tar: got %v, want ErrNoFiles", err)
+	}
+	if _, err := os.Stat(archivePath); err == nil {
+		t.Error("archive left behind for an empty tar")
+	}
+
+	truncated := buildTar(t)[:3000]
+	_, err = Compress(&Options{FromTar: bytes.NewReader(truncated), OutputPath: archivePath, Quiet: true}, nil)
+	if err == nil {
+		t.Error("expected an error for a truncated tar")
+	}
+	if _, err := os.Stat(archivePath); err == nil {
+		t.Error("archive left behind for a truncated tar")
+	}
+}
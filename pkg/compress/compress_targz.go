@@ -0,0 +1,30 @@
+// pkg/compress/compress_targz.go
+package compress
+
+import (
+	"context"
+	"os"
+
+	"github.com/klauspost/compress/gzip"
+)
+
+// tarGzParts writes tar archives compressed with gzip
+var tarGzParts = partFormat{
+	name:          "tar.gz",
+	ext:           ".tar.gz",
+	trimExts:      []string{".tgz", ".gz"},
+	dryRunPercent: 40,
+	open: func(f *os.File, opts *Options) (partArchive, error) {
+		gzWriter, err := gzip.NewWriterLevel(f, opts.Level)
+		if err != nil {
+			return nil, err
+		}
+		return newTarPart(gzWriter), nil
+	},
+}
+
+// compressToTarGz compresses files into multiple .tar.gz archives (one per thread) for true parallelism
+// Output: archive_01.tar.gz, archive_02.tar.gz, ..., archive_N.tar.gz
+func compressToTarGz(ctx context.Context, opts *Options, progressCb ProgressCallback, foldersToCompress []folderTask, totalFiles int, totalOrigSize uint64, result *Result) error {
+	return compressToParts(ctx, opts, progressCb, foldersToCompress, totalFiles, result, tarGzParts)
+}
@@ -2,278 +2,133 @@
 package compress
 
 import (
-	"archive/tar"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
-	"sync"
-	"sync/atomic"
 
 	"github.com/ulikunitz/xz"
-)
-
-// compressToXz compresses files into multiple .tar.xz archives (one per thread) for true parallelism
-// Output: archive_01.tar.xz, archive_02.tar.xz, ..., archive_N.tar.xz
-func compressToXz(opts *Options, progressCb ProgressCallback, foldersToCompress []folderTask, totalFiles int, totalOrigSize uint64, result *Result) error {
-	// Prepare output path base (remove .tar.xz or .xz extension if present)
-	baseOutputPath := opts.OutputPath
-	if strings.HasSuffix(baseOutputPath, ".tar.xz") {
-		baseOutputPath = baseOutputPath[:len(baseOutputPath)-7]
-	} else if strings.HasSuffix(baseOutputPath, ".xz") {
-		baseOutputPath = baseOutputPath[:len(baseOutputPath)-3]
-	}
 
-	// Process files with worker pool - each worker writes to its own .tar.xz file
-	var totalCompSize atomic.Uint64
-	var processedCount atomic.Uint32
-	var errorsMu sync.Mutex
+	"github.com/creativeyann17/go-delta/internal/xzstream"
+)
 
-	var wg sync.WaitGroup
+// xzParts writes tar archives compressed with LZMA2
+var xzParts = partFormat{
+	name:          "XZ",
+	ext:           ".tar.xz",
+	trimExts:      []string{".xz"},
+	dryRunPercent: 30,
+	open: func(f *os.File, opts *Options) (partArchive, error) {
+		xzConfig := xz.WriterConfig{DictCap: lzma2DictCap(opts.Level)}
+		xzWriter, err := xzConfig.NewWriter(f)
+		if err != nil {
+			return nil, err
+		}
+		return newTarPart(xzWriter), nil
+	},
+}
 
-	// Shared task channel: workers pull files as they become free
-	taskCh := feedTasks(foldersToCompress, opts.MaxThreads*16)
+// Block size bounds of the single-output stream
+const (
+	minXzBlockSize = 1 << 20
+	maxXzBlockSize = 32 << 20
+)
 
-	// Track archive files created for later cleanup/stats
-	type archiveFileInfo struct {
-		path string
-		size uint64
+// compressToXz compresses files into a single .tar.xz at OutputPath, or into
+// one .tar.xz per thread with XzParts
+// Output: archive.tar.xz, or archive_01.tar.xz, ..., archive_N.tar.xz
+func compressToXz(ctx context.Context, opts *Options, progressCb ProgressCallback, foldersToCompress []folderTask, totalFiles int, totalOrigSize uint64, result *Result) error {
+	if opts.XzParts {
+		return compressToParts(ctx, opts, progressCb, foldersToCompress, totalFiles, result, xzParts)
 	}
-	archiveFiles := make([]archiveFileInfo, opts.MaxThreads)
-	var archiveFilesMu sync.Mutex
-
-	// Parts are numbered contiguously in order of first file received, so
-	// idle workers don't leave empty (or gap-numbered) archives behind.
-	var partCounter atomic.Int32
-
-	// Start worker goroutines - each creates its own .tar.xz file on first use
-	for i := 0; i < opts.MaxThreads; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-
-			var workerTarWriter *tar.Writer
-			var workerXzWriter *xz.Writer
-			var workerFile *os.File
-			var workerFilePath string
-
-			// ensureArchive lazily creates this worker's archive on first task
-			ensureArchive := func() error {
-				if workerFile != nil {
-					return nil
-				}
-				partNum := int(partCounter.Add(1))
-				workerFilePath = fmt.Sprintf("%s_%02d.tar.xz", baseOutputPath, partNum)
-
-				// Ensure output directory exists
-				outputDir := filepath.Dir(workerFilePath)
-				if err := os.MkdirAll(outputDir, 0755); err != nil {
-					return fmt.Errorf("worker %d: create output directory: %w", workerID, err)
-				}
-
-				var err error
-				workerFile, err = os.Create(workerFilePath)
-				if err != nil {
-					return fmt.Errorf("worker %d: create archive: %w", workerID, err)
-				}
-
-				// Create XZ writer with compression level
-				xzConfig := xz.WriterConfig{
-					DictCap: 1 << (20 + opts.Level), // Scale dictionary with level
-				}
-				if opts.Level >= 7 {
-					xzConfig.DictCap = 1 << 26 // 64MB for high levels
-				}
+	return compressToXzStream(ctx, opts, progressCb, foldersToCompress, totalFiles, totalOrigSize, result)
+}
 
-				workerXzWriter, err = xzConfig.NewWriter(workerFile)
-				if err != nil {
-					workerFile.Close()
-					workerFile = nil
-					return fmt.Errorf("worker %d: create xz writer: %w", workerID, err)
-				}
+// compressToXzStream writes one tar stream into a multi-block .xz: the tar
+// is built sequentially while MaxThreads workers compress its blocks
+func compressToXzStream(ctx context.Context, opts *Options, progressCb ProgressCallback, foldersToCompress []folderTask, totalFiles int, totalOrigSize uint64, result *Result) error {
+	var archive partArchive
+	var file *os.File
+	if !opts.DryRun {
+		if err := os.MkdirAll(filepath.Dir(opts.OutputPath), 0755); err != nil {
+			return fmt.Errorf("create output directory: %w", err)
+		}
+		var err error
+		file, err = os.Create(opts.OutputPath)
+		if err != nil {
+			return fmt.Errorf("create archive: %w", err)
+		}
+		dictCap := lzma2DictCap(opts.Level)
+		blockSize := min(max(3*dictCap, minXzBlockSize), maxXzBlockSize)
+		xzWriter, err := xzstream.NewWriter(file, dictCap, blockSize, opts.MaxThreads)
+		if err != nil {
+			file.Close()
+			os.Remove(opts.OutputPath)
+			return fmt.Errorf("create XZ writer: %w", err)
+		}
+		archive = newTarPart(xzWriter)
+	}
 
-				workerTarWriter = tar.NewWriter(workerXzWriter)
+	// abort drops the partial archive on a fatal error
+	abort := func(err error) error {
+		if file != nil {
+			file.Close()
+			os.Remove(opts.OutputPath)
+		}
+		return err
+	}
 
-				// Track archive file for stats
-				archiveFilesMu.Lock()
-				archiveFiles[workerID].path = workerFilePath
-				archiveFilesMu.Unlock()
-				return nil
+	for _, folder := range foldersToCompress {
+		for _, task := range folder.Files {
+			if err := ctx.Err(); err != nil {
+				return abort(fmt.Errorf("compression canceled: %w", err))
 			}
-
-			for task := range taskCh {
-				if !opts.DryRun {
-					if err := ensureArchive(); err != nil {
-						errorsMu.Lock()
-						result.Errors = append(result.Errors, err)
-						errorsMu.Unlock()
-						return
-					}
-				}
-				// Skip progress bar for 0-byte files
-				if progressCb != nil && task.OrigSize > 0 {
-					progressCb(ProgressEvent{
-						Type:     EventFileStart,
-						FilePath: task.RelPath,
-						Total:    int64(task.OrigSize),
-					})
-				}
-
-				// Open file for reading
-				file, err := os.Open(task.AbsPath)
-				if err != nil {
-					errorsMu.Lock()
-					result.Errors = append(result.Errors, fmt.Errorf("%s: open: %w", task.RelPath, err))
-					errorsMu.Unlock()
-
-					if progressCb != nil {
-						progressCb(ProgressEvent{
-							Type:     EventError,
-							FilePath: task.RelPath,
-						})
-					}
-					continue
-				}
-
-				if !opts.DryRun && workerTarWriter != nil {
-					// Write tar header
-					header := &tar.Header{
-						Name: task.RelPath,
-						Mode: 0644,
-						Size: int64(task.OrigSize),
-					}
-
-					if err := workerTarWriter.WriteHeader(header); err != nil {
-						file.Close()
-						errorsMu.Lock()
-						result.Errors = append(result.Errors, fmt.Errorf("%s: write header: %w", task.RelPath, err))
-						errorsMu.Unlock()
-						continue
-					}
-
-					// Write file data with progress reporting
-					buf := getReadBuffer()
-					var written, lastReported int64
-					for {
-						nr, errRead := file.Read(buf)
-						if nr > 0 {
-							nw, errWrite := workerTarWriter.Write(buf[0:nr])
-							if errWrite != nil {
-								file.Close()
-								errorsMu.Lock()
-								result.Errors = append(result.Errors, fmt.Errorf("%s: write: %w", task.RelPath, errWrite))
-								errorsMu.Unlock()
-								break
-							}
-							written += int64(nw)
-
-							// Report progress (throttled; EventFileComplete finishes the bar)
-							if progressCb != nil && written-lastReported >= progressReportStep {
-								lastReported = written
-								progressCb(ProgressEvent{
-									Type:     EventFileProgress,
-									FilePath: task.RelPath,
-									Current:  written,
-									Total:    int64(task.OrigSize),
-								})
-							}
-						}
-						if errRead == io.EOF {
-							break
-						}
-						if errRead != nil {
-							file.Close()
-							errorsMu.Lock()
-							result.Errors = append(result.Errors, fmt.Errorf("%s: read: %w", task.RelPath, errRead))
-							errorsMu.Unlock()
-							break
-						}
-					}
-					putReadBuffer(buf)
-				} else if opts.DryRun {
-					// Dry-run: estimate compression (assume 30% for LZMA2)
-					totalCompSize.Add(task.OrigSize * 30 / 100)
-				}
-
-				file.Close()
-
-				// Notify file complete. CompressedSize stays 0: per-file
-				// compressed size is unknown inside a shared xz stream.
-				processedCount.Add(1)
+			fatal, err := writeXzEntry(archive, task, opts, progressCb)
+			if fatal {
+				return abort(err)
+			}
+			if err != nil {
+				result.Errors = append(result.Errors, err)
 				if progressCb != nil {
 					progressCb(ProgressEvent{
-						Type:     EventFileComplete,
+						Type:     EventError,
 						FilePath: task.RelPath,
-						Current:  int64(task.OrigSize),
-						Total:    int64(task.OrigSize),
 					})
 				}
+				continue
 			}
 
-			// Close worker archive and record final size
-			if !opts.DryRun && workerFile != nil {
-				if workerTarWriter != nil {
-					if err := workerTarWriter.Close(); err != nil {
-						errorsMu.Lock()
-						result.Errors = append(result.Errors, fmt.Errorf("worker %d: close tar: %w", workerID, err))
-						errorsMu.Unlock()
-						return
-					}
-				}
-				if workerXzWriter != nil {
-					if err := workerXzWriter.Close(); err != nil {
-						errorsMu.Lock()
-						result.Errors = append(result.Errors, fmt.Errorf("worker %d: close xz: %w", workerID, err))
-						errorsMu.Unlock()
-						return
-					}
-				}
-				if err := workerFile.Close(); err != nil {
-					errorsMu.Lock()
-					result.Errors = append(result.Errors, fmt.Errorf("worker %d: close file: %w", workerID, err))
-					errorsMu.Unlock()
-					return
-				}
-
-				// Record final archive size
-				stat, err := os.Stat(workerFilePath)
-				if err == nil {
-					archiveFilesMu.Lock()
-					archiveFiles[workerID].size = uint64(stat.Size())
-					archiveFilesMu.Unlock()
-				}
+			result.FilesProcessed++
+			recordFile(opts, task.OrigSize)
+			result.breakdown.add(task.RelPath, task.OrigSize, 0)
+			if progressCb != nil {
+				progressCb(ProgressEvent{
+					Type:     EventFileComplete,
+					FilePath: task.RelPath,
+					Current:  int64(task.OrigSize),
+					Total:    int64(task.OrigSize),
+				})
 			}
-		}(i)
+		}
 	}
 
-	// Wait for all workers to complete
-	wg.Wait()
-
-	result.FilesProcessed = int(processedCount.Load())
-
-	// Calculate total compressed size from all worker archives
-	if !opts.DryRun {
-		var totalSize uint64
-		for _, info := range archiveFiles {
-			if info.size > 0 {
-				totalSize += info.size
-			}
+	if opts.DryRun {
+		result.CompressedSize = totalOrigSize * xzParts.dryRunPercent / 100
+	} else {
+		if err := archive.close(); err != nil {
+			return abort(fmt.Errorf("close XZ: %w", err))
 		}
-		result.CompressedSize = totalSize
-
-		// Log multi-part archive info if verbose
-		if opts.Verbose && !opts.Quiet {
-			fmt.Printf("\nCreated %d XZ archives:\n", opts.MaxThreads)
-			for _, info := range archiveFiles {
-				if info.size > 0 {
-					fmt.Printf("  %s (%.2f MB)\n",
-						filepath.Base(info.path), float64(info.size)/(1024*1024))
-				}
-			}
+		if err := syncFile(opts, file); err != nil {
+			return abort(err)
+		}
+		if err := file.Close(); err != nil {
+			os.Remove(opts.OutputPath)
+			return fmt.Errorf("close file: %w", err)
+		}
+		if stat, err := os.Stat(opts.OutputPath); err == nil {
+			result.CompressedSize = uint64(stat.Size())
 		}
-	} else {
-		result.CompressedSize = totalCompSize.Load()
 	}
 
 	if progressCb != nil {
@@ -291,3 +146,92 @@ func compressToXz(opts *Options, progressCb ProgressCallback, foldersToCompress
 
 	return nil
 }
+
+// writeXzEntry adds one file to the tar stream. Errors about the file are
+// returned with fatal false; once its header is written the entry must be
+// completed, so a file that shrank is padded with zeros and reported, and a
+// failed write to the stream is fatal.
+func writeXzEntry(archive partArchive, task fileTask, opts *Options, progressCb ProgressCallback) (fatal bool, err error) {
+	// Skip progress bar for 0-byte files
+	if progressCb != nil && task.OrigSize > 0 {
+		progressCb(ProgressEvent{
+			Type:     EventFileStart,
+			FilePath: task.RelPath,
+			Total:    int64(task.OrigSize),
+		})
+	}
+
+	file, err := openSource(task)
+	if err != nil {
+		return false, fmt.Errorf("%s: open: %w", task.RelPath, err)
+	}
+	defer file.Close()
+	if opts.DryRun {
+		return false, nil
+	}
+
+	entryWriter, err := archive.create(task.RelPath, task.OrigSize)
+	if err != nil {
+		return true, fmt.Errorf("%s: write header: %w", task.RelPath, err)
+	}
+
+	buf := getReadBuffer()
+	defer putReadBuffer(buf)
+	var written, lastReported int64
+	size := int64(task.OrigSize)
+	var readErr error
+	for written < size {
+		nr, errRead := file.Read(buf[:min(int64(len(buf)), size-written)])
+		if nr > 0 {
+			nw, errWrite := entryWriter.Write(buf[:nr])
+			written += int64(nw)
+			if errWrite != nil {
+				return true, fmt.Errorf("%s: write: %w", task.RelPath, errWrite)
+			}
+
+			// Report progress (throttled; EventFileComplete finishes the bar)
+			if progressCb != nil && written-lastReported >= progressReportStep {
+				lastReported = written
+				progressCb(ProgressEvent{
+					Type:     EventFileProgress,
+					FilePath: task.RelPath,
+					Current:  written,
+					Total:    size,
+				})
+			}
+		}
+		if errRead == io.EOF && written < size {
+			readErr = fmt.Errorf("%s: file shrank to %d bytes while compressing", task.RelPath, written)
+			break
+		}
+		if errRead != nil && errRead != io.EOF {
+			readErr = fmt.Errorf("%s: read: %w", task.RelPath, errRead)
+			break
+		}
+	}
+
+	// Complete the entry the header announced
+	if written < size {
+		if _, err := io.CopyN(entryWriter, zeroReader{}, size-written); err != nil {
+			return true, fmt.Errorf("%s: write: %w", task.RelPath, err)
+		}
+	}
+	return false, readErr
+}
+
+// zeroReader reads zeros forever
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// lzma2DictCap scales the LZMA2 dictionary with the level: 2 MiB at
+// level 1 up to 64 MiB from level 6
+func lzma2DictCap(level int) int {
+	if level >= 7 {
+		return 1 << 26
+	}
+	return 1 << (20 + level)
+}
@@ -3,7 +3,9 @@ package compress
 
 import (
 	"archive/tar"
+	"bytes"
 	"crypto/md5"
+	"encoding/binary"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -52,6 +54,7 @@ func TestXzCompressDecompress(t *testing.T) {
 		MaxThreads:  2,
 		Level:       5,
 		UseXzFormat: true,
+		XzParts:     true,
 		Verbose:     false,
 		Quiet:       true,
 	}
@@ -137,6 +140,76 @@ func TestXzCompressDecompress(t *testing.T) {
 	}
 }
 
+// TestXzSingleStream checks the default output: one .tar.xz at OutputPath
+// holding several blocks compressed in parallel
+func TestXzSingleStream(t *testing.T) {
+	tempDir := t.TempDir()
+	inputDir := filepath.Join(tempDir, "input")
+	outputXz := filepath.Join(tempDir, "backup.tar.xz")
+
+	// Level 1 cuts 6 MiB blocks: 16 MiB of input makes three
+	testFiles := map[string][]byte{
+		"big.txt":       bytes.Repeat([]byte("a long line of compressible text\n"), 16<<20/33),
+		"sub/small.txt": []byte("small"),
+		"empty.txt":     {},
+	}
+	for relPath, content := range testFiles {
+		fullPath := filepath.Join(inputDir, relPath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Compress(&Options{
+		InputPath:   inputDir,
+		OutputPath:  outputXz,
+		MaxThreads:  4,
+		Level:       1,
+		UseXzFormat: true,
+		Quiet:       true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+	if result.FilesProcessed != len(testFiles) {
+		t.Errorf("Expected %d files, got %d", len(testFiles), result.FilesProcessed)
+	}
+	if parts, _ := filepath.Glob(filepath.Join(tempDir, "backup_*")); len(parts) > 0 {
+		t.Errorf("Unexpected parts: %v", parts)
+	}
+
+	data, err := os.ReadFile(outputXz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint64(len(data)) != result.CompressedSize {
+		t.Errorf("Compressed size %d, file is %d bytes", result.CompressedSize, len(data))
+	}
+	// The record count follows the index indicator; the footer's backward
+	// size locates the index
+	indexSize := (int(binary.LittleEndian.Uint32(data[len(data)-8:])) + 1) * 4
+	if blocks := data[len(data)-12-indexSize+1]; blocks < 3 {
+		t.Errorf("Expected at least 3 blocks, got %d", blocks)
+	}
+
+	extractDir := filepath.Join(tempDir, "extracted")
+	if _, err := decompress.Decompress(&decompress.Options{InputPath: outputXz, OutputPath: extractDir, Quiet: true}, nil); err != nil {
+		t.Fatalf("Decompress failed: %v", err)
+	}
+	for relPath, content := range testFiles {
+		got, err := os.ReadFile(filepath.Join(extractDir, relPath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Errorf("Content mismatch for %s", relPath)
+		}
+	}
+}
+
 func TestXzCompressionLevels(t *testing.T) {
 	tempDir := t.TempDir()
 	inputDir := filepath.Join(tempDir, "input")
@@ -219,8 +292,10 @@ func TestXzDryRun(t *testing.T) {
 
 	// Verify no file was created
 	baseOutput := strings.TrimSuffix(outputXz, ".tar.xz")
-	if _, err := os.Stat(baseOutput + "_01.tar.xz"); err == nil {
-		t.Error("Dry run should not create output file")
+	for _, path := range []string{outputXz, baseOutput + "_01.tar.xz"} {
+		if _, err := os.Stat(path); err == nil {
+			t.Error("Dry run should not create output file")
+		}
 	}
 }
 
@@ -270,6 +345,7 @@ func TestXzThreadSafety(t *testing.T) {
 		MaxThreads:  4,
 		Level:       1, // Low level for speed
 		UseXzFormat: true,
+		XzParts:     true,
 		Quiet:       true,
 	}
 
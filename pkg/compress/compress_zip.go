@@ -3,6 +3,7 @@ package compress
 
 import (
 	"archive/zip"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -14,6 +15,7 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"github.com/creativeyann17/go-delta/internal/format"
 	"github.com/klauspost/compress/flate"
 )
 
@@ -24,13 +26,14 @@ const progressReportStep = 1 << 20
 
 // compressToZip compresses files into multiple ZIP archives (one per thread) for true parallelism
 // Output: archive_01.zip, archive_02.zip, ..., archive_N.zip
-func compressToZip(opts *Options, progressCb ProgressCallback, foldersToCompress []folderTask, totalFiles int, totalOrigSize uint64, result *Result) error {
+func compressToZip(ctx context.Context, opts *Options, progressCb ProgressCallback, foldersToCompress []folderTask, totalFiles int, totalOrigSize uint64, result *Result) error {
+	if opts.SingleZip {
+		return compressToSingleZip(ctx, opts, progressCb, foldersToCompress, totalFiles, result)
+	}
+
 	// GC control: disable GC during compression if requested
 	if opts.DisableGC {
-		// Force GC before disabling to start with a clean heap
-		runtime.GC()
-		oldGCPercent := debug.SetGCPercent(-1)
-		defer debug.SetGCPercent(oldGCPercent)
+		defer disableGC()()
 	}
 
 	// Prepare output path base (remove .zip extension if present)
@@ -42,6 +45,7 @@ func compressToZip(opts *Options, progressCb ProgressCallback, foldersToCompress
 	// Process files with worker pool - each worker writes to its own ZIP file
 	var totalCompSize atomic.Uint64
 	var processedCount atomic.Uint32
+	var storedCount atomic.Uint32
 	var errorsMu sync.Mutex
 
 	var wg sync.WaitGroup
@@ -49,17 +53,7 @@ func compressToZip(opts *Options, progressCb ProgressCallback, foldersToCompress
 	// Shared task channel: workers pull files as they become free.
 	// Folder-hash affinity routing was dropped because it sent every file of a
 	// folder to one worker — a flat input directory ran single-threaded.
-	// Files are fed largest-first (LPT scheduling): a multi-hundred-MB file
-	// picked up last would leave one worker compressing alone while the rest
-	// idle (~12% wall time on skewed sizes). Progress consumers should be
-	// byte-weighted, or large-first order makes file-count progress feel jumpy.
-	allTasks := make([]fileTask, 0, totalFiles)
-	for _, folder := range foldersToCompress {
-		allTasks = append(allTasks, folder.Files...)
-	}
-	sort.Slice(allTasks, func(i, j int) bool {
-		return allTasks[i].OrigSize > allTasks[j].OrigSize
-	})
+	allTasks := largestFirst(foldersToCompress, totalFiles)
 	taskCh := make(chan fileTask, opts.MaxThreads*16)
 
 	// Track ZIP files created for later cleanup/stats
@@ -106,18 +100,9 @@ func compressToZip(opts *Options, progressCb ProgressCallback, foldersToCompress
 
 				workerZipWriter = zip.NewWriter(workerZipFile)
 
-				// Register custom deflate compressor with our compression level.
-				// Level 9 maps to flate 8 on purpose: measured on real data,
-				// flate 9 is ~2.3x slower than 8 for <0.2pp better ratio.
+				// Register custom deflate compressor with our compression level
 				workerZipWriter.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
-					if opts.Level <= 1 {
-						return flate.NewWriter(out, flate.NoCompression)
-					}
-					flateLevel := opts.Level - 1
-					if flateLevel > flate.BestCompression {
-						flateLevel = flate.BestCompression
-					}
-					return flate.NewWriter(out, flateLevel)
+					return flate.NewWriter(out, zipFlateLevel(opts.Level))
 				})
 
 				// Track ZIP file for stats
@@ -146,7 +131,7 @@ func compressToZip(opts *Options, progressCb ProgressCallback, foldersToCompress
 				}
 
 				// Open file for reading
-				file, err := os.Open(task.AbsPath)
+				file, err := openSource(task)
 				if err != nil {
 					errorsMu.Lock()
 					result.Errors = append(result.Errors, fmt.Errorf("%s: open: %w", task.RelPath, err))
@@ -161,6 +146,7 @@ func compressToZip(opts *Options, progressCb ProgressCallback, foldersToCompress
 					continue
 				}
 
+				method := opts.methodFor(task)
 				if !opts.DryRun && workerZipWriter != nil {
 					// Write to worker's own ZIP file (NO MUTEX NEEDED - each worker has its own file!)
 					header := &zip.FileHeader{
@@ -168,8 +154,9 @@ func compressToZip(opts *Options, progressCb ProgressCallback, foldersToCompress
 						Method: zip.Deflate,
 					}
 
-					// Use Store method for level 1 (no compression)
-					if opts.Level == 1 {
+					// Use Store method for level 1 (no compression) and for
+					// formats that are already compressed
+					if opts.Level == 1 || method == format.MethodStore {
 						header.Method = zip.Store
 					}
 
@@ -223,7 +210,11 @@ func compressToZip(opts *Options, progressCb ProgressCallback, foldersToCompress
 					putReadBuffer(buf)
 				} else if opts.DryRun {
 					// Dry-run: estimate compression (assume 50% compression ratio for deflate)
-					totalCompSize.Add(task.OrigSize / 2)
+					if method == format.MethodStore {
+						totalCompSize.Add(task.OrigSize)
+					} else {
+						totalCompSize.Add(task.OrigSize / 2)
+					}
 				}
 
 				file.Close()
@@ -232,6 +223,11 @@ func compressToZip(opts *Options, progressCb ProgressCallback, foldersToCompress
 				// real compressed size is only known once the writer closes
 				// the entry, so reporting an estimate here would be a lie.
 				processedCount.Add(1)
+				if method == format.MethodStore {
+					storedCount.Add(1)
+				}
+				recordFile(opts, task.OrigSize)
+				result.breakdown.add(task.RelPath, task.OrigSize, 0)
 				if progressCb != nil {
 					progressCb(ProgressEvent{
 						Type:     EventFileComplete,
@@ -250,6 +246,13 @@ func compressToZip(opts *Options, progressCb ProgressCallback, foldersToCompress
 					errorsMu.Unlock()
 					return
 				}
+				if err := syncFile(opts, workerZipFile); err != nil {
+					workerZipFile.Close()
+					errorsMu.Lock()
+					result.Errors = append(result.Errors, fmt.Errorf("worker %d: %w", workerID, err))
+					errorsMu.Unlock()
+					return
+				}
 				if err := workerZipFile.Close(); err != nil {
 					errorsMu.Lock()
 					result.Errors = append(result.Errors, fmt.Errorf("worker %d: close file: %w", workerID, err))
@@ -270,16 +273,30 @@ func compressToZip(opts *Options, progressCb ProgressCallback, foldersToCompress
 
 	// Feed all files into the shared channel, largest first
 	go func() {
+		defer close(taskCh)
 		for _, task := range allTasks {
-			taskCh <- task
+			select {
+			case taskCh <- task:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(taskCh)
 	}()
 
 	// Wait for all workers to complete
 	wg.Wait()
 
+	if err := ctx.Err(); err != nil {
+		for _, info := range zipFiles {
+			if info.path != "" {
+				os.Remove(info.path)
+			}
+		}
+		return fmt.Errorf("compression canceled: %w", err)
+	}
+
 	result.FilesProcessed = int(processedCount.Load())
+	result.StoredFiles = int(storedCount.Load())
 
 	// Calculate total compressed size from all worker ZIP files
 	if !opts.DryRun {
@@ -320,3 +337,37 @@ func compressToZip(opts *Options, progressCb ProgressCallback, foldersToCompress
 
 	return nil
 }
+
+// zipFlateLevel maps a ZIP level (1-9) to a flate level. Level 9 maps to
+// flate 8 on purpose: measured on real data, flate 9 is ~2.3x slower than 8
+// for <0.2pp better ratio.
+func zipFlateLevel(level int) int {
+	if level <= 1 {
+		return flate.NoCompression
+	}
+	return min(level-1, flate.BestCompression)
+}
+
+// largestFirst flattens the folders into one task list, largest file first
+// (LPT scheduling): a multi-hundred-MB file picked up last would leave one
+// worker compressing alone while the rest idle (~12% wall time on skewed
+// sizes). Progress consumers should be byte-weighted, or large-first order
+// makes file-count progress feel jumpy.
+func largestFirst(foldersToCompress []folderTask, totalFiles int) []fileTask {
+	allTasks := make([]fileTask, 0, totalFiles)
+	for _, folder := range foldersToCompress {
+		allTasks = append(allTasks, folder.Files...)
+	}
+	sort.Slice(allTasks, func(i, j int) bool {
+		return allTasks[i].OrigSize > allTasks[j].OrigSize
+	})
+	return allTasks
+}
+
+// disableGC forces a collection to start with a clean heap, then turns the
+// GC off until the returned function is called
+func disableGC() (restore func()) {
+	runtime.GC()
+	oldGCPercent := debug.SetGCPercent(-1)
+	return func() { debug.SetGCPercent(oldGCPercent) }
+}
@@ -0,0 +1,309 @@
+// pkg/compress/compress_zip_single.go
+package compress
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/flate"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+)
+
+// zipMemEntryLimit is the largest file pre-compressed in memory for a
+// single ZIP; bigger ones are compressed to a temp file
+const zipMemEntryLimit = 4 << 20
+
+// compressToSingleZip compresses files into one ZIP at OutputPath. Workers
+// deflate files in parallel into memory or a temp file, then copy the raw
+// stream into the archive one at a time. archive/zip adds the ZIP64 records
+// when an entry or the archive passes 4GB or there are more than 65535
+// entries.
+func compressToSingleZip(ctx context.Context, opts *Options, progressCb ProgressCallback, foldersToCompress []folderTask, totalFiles int, result *Result) error {
+	if opts.DisableGC {
+		defer disableGC()()
+	}
+
+	var zipFile *os.File
+	var zipWriter *zip.Writer
+	var zipMu sync.Mutex // serializes entries in zipWriter
+	if !opts.DryRun {
+		if err := os.MkdirAll(filepath.Dir(opts.OutputPath), 0755); err != nil {
+			return fmt.Errorf("create output directory: %w", err)
+		}
+		var err error
+		zipFile, err = os.Create(opts.OutputPath)
+		if err != nil {
+			return fmt.Errorf("create zip: %w", err)
+		}
+		zipWriter = zip.NewWriter(zipFile)
+	}
+
+	var totalCompSize atomic.Uint64
+	var processedCount atomic.Uint32
+	var storedCount atomic.Uint32
+	var errorsMu sync.Mutex
+	recordError := func(task fileTask, err error) {
+		errorsMu.Lock()
+		result.Errors = append(result.Errors, err)
+		errorsMu.Unlock()
+		if progressCb != nil {
+			progressCb(ProgressEvent{
+				Type:     EventError,
+				FilePath: task.RelPath,
+			})
+		}
+	}
+
+	allTasks := largestFirst(foldersToCompress, totalFiles)
+	taskCh := make(chan fileTask, opts.MaxThreads*16)
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.MaxThreads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var memBuf bytes.Buffer
+			var fw *flate.Writer
+
+			for task := range taskCh {
+				// Skip progress bar for 0-byte files (no progress to show)
+				if progressCb != nil && task.OrigSize > 0 {
+					progressCb(ProgressEvent{
+						Type:     EventFileStart,
+						FilePath: task.RelPath,
+						Total:    int64(task.OrigSize),
+					})
+				}
+
+				file, err := openSource(task)
+				if err != nil {
+					recordError(task, fmt.Errorf("%s: open: %w", task.RelPath, err))
+					continue
+				}
+
+				method := opts.methodFor(task)
+				stored := opts.Level == 1 || method == format.MethodStore
+				var comprSize uint64
+				switch {
+				case opts.DryRun:
+					// Dry-run: estimate compression (assume 50% compression ratio for deflate)
+					comprSize = task.OrigSize
+					if !stored {
+						comprSize /= 2
+					}
+					totalCompSize.Add(comprSize)
+
+				case stored:
+					// Nothing to compute ahead: copy straight into the archive
+					zipMu.Lock()
+					err = func() error {
+						w, err := zipWriter.CreateHeader(&zip.FileHeader{Name: task.RelPath, Method: zip.Store})
+						if err != nil {
+							return fmt.Errorf("%s: create header: %w", task.RelPath, err)
+						}
+						n, err := copyWithProgress(w, file, task, progressCb)
+						comprSize = uint64(n)
+						return err
+					}()
+					zipMu.Unlock()
+
+				default:
+					err = func() error {
+						// Deflate outside the lock, into memory or a temp file
+						var spill io.ReadWriter = &memBuf
+						memBuf.Reset()
+						if task.OrigSize > zipMemEntryLimit {
+							tempFile, err := os.CreateTemp("", "godelta-zip-*.tmp")
+							if err != nil {
+								return fmt.Errorf("%s: create temp file: %w", task.RelPath, err)
+							}
+							defer func() {
+								tempFile.Close()
+								os.Remove(tempFile.Name())
+							}()
+							spill = tempFile
+						}
+
+						counter := &countingWriter{w: spill}
+						if fw == nil {
+							fw, err = flate.NewWriter(counter, zipFlateLevel(opts.Level))
+							if err != nil {
+								return fmt.Errorf("%s: create deflate writer: %w", task.RelPath, err)
+							}
+						} else {
+							fw.Reset(counter)
+						}
+						crc := crc32.NewIEEE()
+						read, err := copyWithProgress(io.MultiWriter(crc, fw), file, task, progressCb)
+						if err != nil {
+							return err
+						}
+						if err := fw.Close(); err != nil {
+							return fmt.Errorf("%s: deflate: %w", task.RelPath, err)
+						}
+						if f, ok := spill.(*os.File); ok {
+							if _, err := f.Seek(0, io.SeekStart); err != nil {
+								return fmt.Errorf("%s: rewind temp file: %w", task.RelPath, err)
+							}
+						}
+
+						zipMu.Lock()
+						defer zipMu.Unlock()
+						w, err := zipWriter.CreateRaw(&zip.FileHeader{
+							Name:               task.RelPath,
+							Method:             zip.Deflate,
+							CRC32:              crc.Sum32(),
+							CompressedSize64:   counter.n,
+							UncompressedSize64: uint64(read),
+						})
+						if err != nil {
+							return fmt.Errorf("%s: create header: %w", task.RelPath, err)
+						}
+						if _, err := io.Copy(w, spill); err != nil {
+							return fmt.Errorf("%s: write: %w", task.RelPath, err)
+						}
+						comprSize = counter.n
+						return nil
+					}()
+				}
+				file.Close()
+				if err != nil {
+					recordError(task, err)
+					continue
+				}
+
+				processedCount.Add(1)
+				if method == format.MethodStore {
+					storedCount.Add(1)
+				}
+				recordFile(opts, task.OrigSize)
+				result.breakdown.add(task.RelPath, task.OrigSize, comprSize)
+				if progressCb != nil {
+					progressCb(ProgressEvent{
+						Type:     EventFileComplete,
+						FilePath: task.RelPath,
+						Current:  int64(task.OrigSize),
+						Total:    int64(task.OrigSize),
+					})
+				}
+			}
+		}()
+	}
+
+	// Feed all files into the shared channel, largest first
+	go func() {
+		defer close(taskCh)
+		for _, task := range allTasks {
+			select {
+			case taskCh <- task:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		if zipFile != nil {
+			zipFile.Close()
+			os.Remove(opts.OutputPath)
+		}
+		return fmt.Errorf("compression canceled: %w", err)
+	}
+
+	result.FilesProcessed = int(processedCount.Load())
+	result.StoredFiles = int(storedCount.Load())
+
+	if !opts.DryRun {
+		if err := zipWriter.Close(); err != nil {
+			zipFile.Close()
+			os.Remove(opts.OutputPath)
+			return fmt.Errorf("close zip: %w", err)
+		}
+		if err := syncFile(opts, zipFile); err != nil {
+			zipFile.Close()
+			os.Remove(opts.OutputPath)
+			return err
+		}
+		if err := zipFile.Close(); err != nil {
+			os.Remove(opts.OutputPath)
+			return fmt.Errorf("close file: %w", err)
+		}
+		if stat, err := os.Stat(opts.OutputPath); err == nil {
+			result.CompressedSize = uint64(stat.Size())
+		}
+	} else {
+		result.CompressedSize = totalCompSize.Load()
+	}
+
+	if progressCb != nil {
+		progressCb(ProgressEvent{
+			Type:           EventComplete,
+			Current:        int64(result.FilesProcessed),
+			Total:          int64(totalFiles),
+			CompressedSize: result.CompressedSize,
+		})
+	}
+
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("completed with %d errors (see result.Errors)", len(result.Errors))
+	}
+
+	return nil
+}
+
+// copyWithProgress copies a file's content to w, reporting progress
+// (throttled; EventFileComplete finishes the bar), and returns the bytes read
+func copyWithProgress(w io.Writer, file io.Reader, task fileTask, progressCb ProgressCallback) (int64, error) {
+	buf := getReadBuffer()
+	defer putReadBuffer(buf)
+	var written, lastReported int64
+	for {
+		nr, errRead := file.Read(buf)
+		if nr > 0 {
+			nw, errWrite := w.Write(buf[:nr])
+			written += int64(nw)
+			if errWrite != nil {
+				return written, fmt.Errorf("%s: write: %w", task.RelPath, errWrite)
+			}
+			if progressCb != nil && written-lastReported >= progressReportStep {
+				lastReported = written
+				progressCb(ProgressEvent{
+					Type:     EventFileProgress,
+					FilePath: task.RelPath,
+					Current:  written,
+					Total:    int64(task.OrigSize),
+				})
+			}
+		}
+		if errRead == io.EOF {
+			return written, nil
+		}
+		if errRead != nil {
+			return written, fmt.Errorf("%s: read: %w", task.RelPath, errRead)
+		}
+	}
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n uint64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += uint64(n)
+	return n, err
+}
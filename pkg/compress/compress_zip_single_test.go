@@ -0,0 +1,161 @@
+// pkg/compress/compress_zip_single_test.go
+package compress
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+)
+
+// TestSingleZip writes small, large (compressed through a temp file),
+// stored and empty files into one ZIP and reads them back
+func TestSingleZip(t *testing.T) {
+	tempDir := t.TempDir()
+	inputDir := filepath.Join(tempDir, "input")
+	zipPath := filepath.Join(tempDir, "backup.zip")
+
+	random := make([]byte, 64*1024)
+	rand.New(rand.NewSource(1)).Read(random)
+	testFiles := map[string][]byte{
+		"large.txt":     bytes.Repeat([]byte("large file spilled to disk "), (zipMemEntryLimit+1<<20)/27),
+		"photo.jpg":     random,
+		"sub/small.txt": []byte("small file"),
+		"empty.txt":     {},
+	}
+	for i := range 20 {
+		testFiles[fmt.Sprintf("many/f%02d.txt", i)] = bytes.Repeat([]byte{byte('a' + i)}, 1000*i)
+	}
+	for relPath, content := range testFiles {
+		fullPath := filepath.Join(inputDir, relPath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Compress(&Options{
+		InputPath:    inputDir,
+		OutputPath:   zipPath,
+		MaxThreads:   4,
+		Level:        6,
+		UseZipFormat: true,
+		SingleZip:    true,
+		Quiet:        true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+	if result.FilesProcessed != len(testFiles) || result.StoredFiles != 1 {
+		t.Errorf("%d files processed, %d stored", result.FilesProcessed, result.StoredFiles)
+	}
+	if parts, _ := filepath.Glob(filepath.Join(tempDir, "backup_*")); len(parts) > 0 {
+		t.Errorf("Unexpected parts: %v", parts)
+	}
+	stat, err := os.Stat(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint64(stat.Size()) != result.CompressedSize {
+		t.Errorf("Compressed size %d, file is %d bytes", result.CompressedSize, stat.Size())
+	}
+
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	if len(zr.File) != len(testFiles) {
+		t.Fatalf("Expected %d entries, got %d", len(testFiles), len(zr.File))
+	}
+	for _, f := range zr.File {
+		wantMethod := zip.Deflate
+		if f.Name == "photo.jpg" {
+			wantMethod = zip.Store
+		}
+		if f.Method != wantMethod {
+			t.Errorf("%s: method %d, want %d", f.Name, f.Method, wantMethod)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(rc) // checks the CRC32
+		rc.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		if !bytes.Equal(got, testFiles[f.Name]) {
+			t.Errorf("%s: content mismatch", f.Name)
+		}
+	}
+
+	extractDir := filepath.Join(tempDir, "extracted")
+	dres, err := decompress.Decompress(&decompress.Options{InputPath: zipPath, OutputPath: extractDir, Quiet: true}, nil)
+	if err != nil {
+		t.Fatalf("Decompress failed: %v", err)
+	}
+	if dres.FilesProcessed != len(testFiles) {
+		t.Errorf("Expected %d files decompressed, got %d", len(testFiles), dres.FilesProcessed)
+	}
+}
+
+// TestSingleZip64Entries checks that more than 65535 entries get the
+// ZIP64 end of central directory
+func TestSingleZip64Entries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("creates 66000 files")
+	}
+	tempDir := t.TempDir()
+	inputDir := filepath.Join(tempDir, "input")
+	zipPath := filepath.Join(tempDir, "many.zip")
+
+	const numFiles = 66000
+	for i := range numFiles {
+		dir := filepath.Join(inputDir, fmt.Sprintf("d%03d", i/1000))
+		if i%1000 == 0 {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d", i)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := Compress(&Options{
+		InputPath:    inputDir,
+		OutputPath:   zipPath,
+		MaxThreads:   4,
+		Level:        1,
+		UseZipFormat: true,
+		SingleZip:    true,
+		Quiet:        true,
+	}, nil); err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+
+	data, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data[len(data)-200:], []byte("PK\x06\x06")) {
+		t.Error("Missing ZIP64 end of central directory record")
+	}
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	if len(zr.File) != numFiles {
+		t.Errorf("Expected %d entries, got %d", numFiles, len(zr.File))
+	}
+}
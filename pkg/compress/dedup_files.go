@@ -0,0 +1,150 @@
+// pkg/compress/dedup_files.go
+package compress
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+// duplicate is a file with the content of an earlier one, archived as a
+// link to that file's entry instead of a copy of its data
+type duplicate struct {
+	task fileTask
+	of   string // RelPath of the file archived with the content
+}
+
+// findDuplicates takes the files sharing their content with an earlier one
+// out of folders. Only files of the same size are compared: hardlinks of
+// one file by identity, the others by the SHA-256 of their content, read on
+// threads workers. Empty files and files that can't be read are left alone.
+func findDuplicates(ctx context.Context, folders []folderTask, threads int) ([]folderTask, []duplicate, error) {
+	type ref struct{ folder, file int }
+	bySize := make(map[uint64][]ref)
+	var order []uint64 // sizes in the order first seen, so results are stable
+	for i, folder := range folders {
+		for j, task := range folder.Files {
+			if task.OrigSize == 0 {
+				continue
+			}
+			if bySize[task.OrigSize] == nil {
+				order = append(order, task.OrigSize)
+			}
+			bySize[task.OrigSize] = append(bySize[task.OrigSize], ref{i, j})
+		}
+	}
+	at := func(r ref) fileTask { return folders[r.folder].Files[r.file] }
+
+	// Hardlinks need no hashing; the other candidates are hashed in parallel
+	of := make(map[ref]ref)
+	var toHash []ref
+	for _, size := range order {
+		group := bySize[size]
+		if len(group) < 2 {
+			continue
+		}
+		for k, r := range group {
+			linked := false
+			if info := at(r).Info; info != nil {
+				for _, first := range group[:k] {
+					if _, dup := of[first]; !dup && at(first).Info != nil && os.SameFile(at(first).Info, info) {
+						of[r], linked = first, true
+						break
+					}
+				}
+			}
+			if !linked {
+				toHash = append(toHash, r)
+			}
+		}
+	}
+
+	sums := make(map[ref][32]byte, len(toHash))
+	var mu sync.Mutex
+	jobs := make(chan ref)
+	var wg sync.WaitGroup
+	for range max(threads, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := getReadBuffer()
+			defer putReadBuffer(buf)
+			for r := range jobs {
+				sum, err := hashFile(at(r), buf)
+				if err != nil {
+					continue
+				}
+				mu.Lock()
+				sums[r] = sum
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, r := range toHash {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- r
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	for _, size := range order {
+		first := make(map[[32]byte]ref)
+		for _, r := range bySize[size] {
+			sum, ok := sums[r]
+			if !ok {
+				continue
+			}
+			if f, seen := first[sum]; seen {
+				of[r] = f
+			} else {
+				first[sum] = r
+			}
+		}
+	}
+	if len(of) == 0 {
+		return folders, nil, nil
+	}
+
+	var dups []duplicate
+	kept := make([]folderTask, 0, len(folders))
+	for i, folder := range folders {
+		out := folderTask{FolderPath: folder.FolderPath}
+		for j, task := range folder.Files {
+			if f, dup := of[ref{i, j}]; dup {
+				// A hardlink's first file may itself repeat an earlier one
+				for g, ok := of[f]; ok; g, ok = of[f] {
+					f = g
+				}
+				dups = append(dups, duplicate{task: task, of: at(f).RelPath})
+				continue
+			}
+			out.Files = append(out.Files, task)
+		}
+		kept = append(kept, out)
+	}
+	return kept, dups, nil
+}
+
+// recordLinks counts the duplicates archived as links in result and reports
+// them complete, with no compressed data of their own
+func recordLinks(opts *Options, linked []duplicate, result *Result, progressCb ProgressCallback) {
+	for _, d := range linked {
+		result.LinkedFiles++
+		result.LinkedBytes += d.task.OrigSize
+		recordFile(opts, d.task.OrigSize)
+		result.breakdown.add(d.task.RelPath, d.task.OrigSize, 0)
+		if progressCb != nil {
+			progressCb(ProgressEvent{
+				Type:     EventFileComplete,
+				FilePath: d.task.RelPath,
+				Current:  int64(d.task.OrigSize),
+				Total:    int64(d.task.OrigSize),
+			})
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// pkg/compress/dedup_files_test.go
+package compress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"first": "same", "other": "diff", "copy": "same", "empty1": "", "empty2": ""} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A hardlink of a copy links to the first file of the content
+	if err := os.Link(filepath.Join(dir, "copy"), filepath.Join(dir, "link")); err != nil {
+		t.Skipf("hardlinks not supported: %v", err)
+	}
+	var folder folderTask
+	for _, name := range []string{"first", "other", "copy", "link", "empty1", "empty2"} {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		folder.Files = append(folder.Files, fileTask{AbsPath: path, RelPath: name, Info: info, OrigSize: uint64(info.Size())})
+	}
+
+	kept, dups, err := findDuplicates(context.Background(), []folderTask{folder}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, task := range kept[0].Files {
+		names = append(names, task.RelPath)
+	}
+	if fmt.Sprint(names) != "[first other empty1 empty2]" {
+		t.Errorf("kept %v", names)
+	}
+	var got []string
+	for _, d := range dups {
+		got = append(got, d.task.RelPath+"->"+d.of)
+	}
+	if fmt.Sprint(got) != "[copy->first link->first]" {
+		t.Errorf("duplicates %v", got)
+	}
+}
+
+func TestDedupFilesValidation(t *testing.T) {
+	for name, opts := range map[string]*Options{
+		"zip":     {InputPath: ".", UseZipFormat: true, DedupFiles: true},
+		"chunked": {InputPath: ".", ChunkSize: 64 << 10, DedupFiles: true},
+	} {
+		if err := opts.Validate(); !errors.Is(err, ErrDedupFilesFormat) {
+			t.Errorf("%s: got %v, want ErrDedupFilesFormat", name, err)
+		}
+	}
+	if err := (&Options{InputPath: ".", UseDictionary: true, DedupFiles: true}).Validate(); err != nil {
+		t.Errorf("dictionary: %v", err)
+	}
+}
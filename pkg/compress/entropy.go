@@ -0,0 +1,74 @@
+// pkg/compress/entropy.go
+package compress
+
+import (
+	"io"
+	"math"
+	"os"
+)
+
+// DefaultEntropyThreshold is the sampled entropy, in bits per byte, at or
+// above which a file is treated as incompressible. Text sits around 4-5,
+// executables around 6; compressed media and encrypted data come close to 8.
+const DefaultEntropyThreshold = 7.9
+
+// Files are sampled at the start, middle and end; smaller files are always
+// compressed since the sample would cost about as much as compressing them
+const (
+	entropySampleSize  = 16 * 1024
+	entropySampleCount = 3
+	minEntropyFileSize = 64 * 1024
+)
+
+// looksIncompressible reports whether the sampled entropy of the file at
+// path reaches threshold. Read errors count as compressible: the real read
+// that follows reports them.
+func looksIncompressible(path string, size uint64, threshold float64) bool {
+	if size < minEntropyFileSize {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	entropy, err := sampleEntropy(f, int64(size))
+	if err != nil {
+		return false
+	}
+	return entropy >= threshold
+}
+
+// sampleEntropy estimates the Shannon entropy of r, in bits per byte, from
+// evenly spaced blocks
+func sampleEntropy(r io.ReaderAt, size int64) (float64, error) {
+	var hist [256]uint64
+	var total uint64
+	buf := make([]byte, entropySampleSize)
+
+	step := max((size-entropySampleSize)/(entropySampleCount-1), 0)
+	for i := range int64(entropySampleCount) {
+		n, err := r.ReadAt(buf, i*step)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		for _, b := range buf[:n] {
+			hist[b]++
+		}
+		total += uint64(n)
+	}
+	if total == 0 {
+		return 0, nil
+	}
+
+	var entropy float64
+	for _, count := range hist {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy, nil
+}
@@ -0,0 +1,69 @@
+// pkg/compress/errlimit.go
+package compress
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// errorLimit cancels a run once it records more errors than FailFast or
+// MaxErrors allow. A nil errorLimit never does.
+type errorLimit struct {
+	max     int
+	count   atomic.Int64
+	cancel  context.CancelCauseFunc
+	sources *sourceSet // files skipped as locked aren't errors
+}
+
+// withErrorLimit returns ctx, canceled with ErrErrorBudget once the limit
+// of opts is exceeded, and a function releasing it; the limit is nil when
+// opts set none
+func withErrorLimit(ctx context.Context, opts *Options, sources *sourceSet) (context.Context, *errorLimit, func()) {
+	if !opts.FailFast && opts.MaxErrors == 0 {
+		return ctx, nil, func() {}
+	}
+	limit := &errorLimit{max: opts.MaxErrors, sources: sources}
+	if opts.FailFast {
+		limit.max = 0
+	}
+	ctx, limit.cancel = context.WithCancelCause(ctx)
+	return ctx, limit, func() { limit.cancel(nil) }
+}
+
+// add records n errors and reports whether the limit is now exceeded
+func (l *errorLimit) add(n int) bool {
+	if l == nil || n == 0 {
+		return false
+	}
+	if l.count.Add(int64(n)) > int64(l.max) {
+		l.cancel(ErrErrorBudget)
+		return true
+	}
+	return false
+}
+
+// watch wraps progressCb to count error events. Every per-file error is
+// reported by one, so the workers need no other hook.
+func (l *errorLimit) watch(progressCb ProgressCallback) ProgressCallback {
+	if l == nil {
+		return progressCb
+	}
+	return func(event ProgressEvent) {
+		if event.Type == EventError && !l.sources.skipped(event.FilePath) {
+			l.add(1)
+		}
+		if progressCb != nil {
+			progressCb(event)
+		}
+	}
+}
+
+// check returns the run's error: ErrErrorBudget when result holds more
+// errors than the limit allows, err otherwise
+func (l *errorLimit) check(result *Result, err error) error {
+	if l == nil || len(result.Errors) <= l.max {
+		return err
+	}
+	return fmt.Errorf("%w: %d, first: %v", ErrErrorBudget, len(result.Errors), result.Errors[0])
+}
@@ -0,0 +1,62 @@
+// pkg/compress/errlimit_test.go
+package compress
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestErrorLimitCancelsOnErrorEvents(t *testing.T) {
+	ctx, limit, stop := withErrorLimit(context.Background(), &Options{MaxErrors: 1}, nil)
+	defer stop()
+
+	var delivered int
+	progressCb := limit.watch(func(ProgressEvent) { delivered++ })
+	progressCb(ProgressEvent{Type: EventError, FilePath: "a"})
+	if ctx.Err() != nil {
+		t.Fatal("canceled after one error with MaxErrors 1")
+	}
+	progressCb(ProgressEvent{Type: EventFileComplete, FilePath: "b"})
+	progressCb(ProgressEvent{Type: EventError, FilePath: "c"})
+	if !errors.Is(context.Cause(ctx), ErrErrorBudget) {
+		t.Errorf("cause %v, want ErrErrorBudget", context.Cause(ctx))
+	}
+	if delivered != 3 {
+		t.Errorf("delivered %d events, want 3", delivered)
+	}
+
+	if _, limit, _ := withErrorLimit(context.Background(), &Options{}, nil); limit != nil {
+		t.Error("limit set without FailFast or MaxErrors")
+	}
+}
+
+func TestCompressFailFast(t *testing.T) {
+	dir := t.TempDir()
+	present := filepath.Join(dir, "present.txt")
+	if err := os.WriteFile(present, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "out.gdelta")
+	files := []string{present, filepath.Join(dir, "missing.txt")}
+
+	result, err := Compress(&Options{Files: files, OutputPath: output, Quiet: true}, nil)
+	if err != nil || len(result.Errors) != 1 {
+		t.Fatalf("without a limit: err %v, %d errors", err, len(result.Errors))
+	}
+
+	os.Remove(output)
+	_, err = Compress(&Options{Files: files, OutputPath: output, Quiet: true, FailFast: true}, nil)
+	if !errors.Is(err, ErrErrorBudget) {
+		t.Fatalf("fail fast: got %v, want ErrErrorBudget", err)
+	}
+	if _, statErr := os.Stat(output); !os.IsNotExist(statErr) {
+		t.Errorf("fail fast left an archive behind: %v", statErr)
+	}
+
+	if _, err := Compress(&Options{Files: files, OutputPath: output, Quiet: true, MaxErrors: 1}, nil); err != nil {
+		t.Errorf("one error within MaxErrors 1: %v", err)
+	}
+}
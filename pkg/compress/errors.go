@@ -1,48 +1,263 @@
 // pkg/compress/errors.go
 package compress
 
-import "errors"
+import "github.com/creativeyann17/go-delta/pkg/godelta"
 
+// Every error matches a godelta error category with errors.Is
 var (
 	// ErrInputRequired is returned when input path is not specified
-	ErrInputRequired = errors.New("input path is required")
+	ErrInputRequired = godelta.NewError(godelta.ErrUsage, "input path is required")
 
 	// ErrInvalidLevelZstd is returned when zstd compression level is out of range
-	ErrInvalidLevelZstd = errors.New("compression level for GDELTA (zstd) must be between 1 and 22")
+	ErrInvalidLevelZstd = godelta.NewError(godelta.ErrUsage, "compression level for GDELTA (zstd) must be between 1 and 22")
 
 	// ErrInvalidLevelZip is returned when zip compression level is out of range
-	ErrInvalidLevelZip = errors.New("compression level for ZIP (deflate) must be between 1 and 9")
+	ErrInvalidLevelZip = godelta.NewError(godelta.ErrUsage, "compression level for ZIP (deflate) must be between 1 and 9")
 
 	// ErrNoFiles is returned when no files are found to compress
-	ErrNoFiles = errors.New("no regular files found to compress")
+	ErrNoFiles = godelta.NewError(godelta.ErrNotFound, "no regular files found to compress")
 
 	// ErrZipNoChunking is returned when trying to use chunking with ZIP format
-	ErrZipNoChunking = errors.New("chunk-based deduplication is not supported in ZIP format")
+	ErrZipNoChunking = godelta.NewError(godelta.ErrUsage, "chunk-based deduplication is not supported in ZIP format")
 
 	// ErrZipNoDictionary is returned when trying to use dictionary with ZIP format
-	ErrZipNoDictionary = errors.New("dictionary compression is not supported in ZIP format")
+	ErrZipNoDictionary = godelta.NewError(godelta.ErrUsage, "dictionary compression is not supported in ZIP format")
 
 	// ErrXzNoChunking is returned when trying to use chunking with XZ format
-	ErrXzNoChunking = errors.New("chunk-based deduplication is not supported in XZ format")
+	ErrXzNoChunking = godelta.NewError(godelta.ErrUsage, "chunk-based deduplication is not supported in XZ format")
 
 	// ErrXzNoDictionary is returned when trying to use dictionary with XZ format
-	ErrXzNoDictionary = errors.New("dictionary compression is not supported in XZ format")
+	ErrXzNoDictionary = godelta.NewError(godelta.ErrUsage, "dictionary compression is not supported in XZ format")
 
 	// ErrXzNoZip is returned when trying to use both XZ and ZIP formats
-	ErrXzNoZip = errors.New("cannot use both XZ and ZIP formats")
+	ErrXzNoZip = godelta.NewError(godelta.ErrUsage, "cannot use both XZ and ZIP formats")
 
 	// ErrInvalidLevelXz is returned when XZ compression level is out of range
-	ErrInvalidLevelXz = errors.New("compression level for XZ (LZMA2) must be between 1 and 9")
+	ErrInvalidLevelXz = godelta.NewError(godelta.ErrUsage, "compression level for XZ (LZMA2) must be between 1 and 9")
 
 	// ErrDictionaryNoChunking is returned when trying to use both dictionary and chunking
-	ErrDictionaryNoChunking = errors.New("dictionary compression cannot be combined with chunking")
+	ErrDictionaryNoChunking = godelta.NewError(godelta.ErrUsage, "dictionary compression cannot be combined with chunking")
 
 	// ErrInvalidParallelism is returned when parallelism strategy is invalid
-	ErrInvalidParallelism = errors.New("parallelism must be 'auto', 'folder', or 'file'")
+	ErrInvalidParallelism = godelta.NewError(godelta.ErrUsage, "parallelism must be 'auto', 'folder', or 'file'")
 
 	// ErrChunkSizeTooSmall is returned when chunk size is below minimum
-	ErrChunkSizeTooSmall = errors.New("chunk size must be at least 4KB (4096 bytes)")
+	ErrChunkSizeTooSmall = godelta.NewError(godelta.ErrUsage, "chunk size must be at least 4KB (4096 bytes)")
 
 	// ErrChunkSizeTooLarge is returned when chunk size exceeds reasonable maximum
-	ErrChunkSizeTooLarge = errors.New("chunk size must not exceed 64MB (67108864 bytes)")
+	ErrChunkSizeTooLarge = godelta.NewError(godelta.ErrUsage, "chunk size must not exceed 64MB (67108864 bytes)")
+
+	// ErrInvalidChunkBounds is returned when the chunk bounds don't surround the chunk size
+	ErrInvalidChunkBounds = godelta.NewError(godelta.ErrUsage, "chunk bounds must satisfy 64B <= min <= chunk size <= max <= 1GB, with min < max")
+
+	// ErrInvalidChunkNormalization is returned when ChunkNormalization is out of range
+	ErrInvalidChunkNormalization = godelta.NewError(godelta.ErrUsage, "chunk normalization must be 1-3, or ChunkNormalizationOff")
+
+	// ErrChunkBoundsNoChunking is returned when chunk bounds or fixed-size chunking are set without a chunk size
+	ErrChunkBoundsNoChunking = godelta.NewError(godelta.ErrUsage, "chunk bounds, normalization and fixed-size chunking need chunking (ChunkSize)")
+
+	// ErrInvalidChunkingMode is returned when ChunkingMode is unknown
+	ErrInvalidChunkingMode = godelta.NewError(godelta.ErrUsage, "chunking mode must be 'cdc' or 'fixed'")
+
+	// ErrFixedChunkBounds is returned when chunk bounds or normalization are set with fixed-size chunking
+	ErrFixedChunkBounds = godelta.NewError(godelta.ErrUsage, "fixed-size chunking cuts blocks of the chunk size: chunk bounds and normalization don't apply")
+
+	// ErrInvalidStoreExtension is returned when a store extension is empty
+	ErrInvalidStoreExtension = godelta.NewError(godelta.ErrUsage, "store extension must not be empty")
+
+	// ErrUnknownCodec is returned when Codec names no registered codec
+	ErrUnknownCodec = godelta.NewError(godelta.ErrUsage, "unknown codec")
+
+	// ErrCodecFormat is returned when a codec is combined with a standard archive format
+	ErrCodecFormat = godelta.NewError(godelta.ErrUsage, "codec selection applies to GDELTA archives only (ZIP and tar.gz use deflate, XZ and 7z use LZMA2)")
+
+	// ErrCodecNoDictionary is returned when a codec other than zstd is combined with dictionary compression
+	ErrCodecNoDictionary = godelta.NewError(godelta.ErrUsage, "dictionary compression requires the zstd codec")
+
+	// ErrInvalidLevelCodec is returned when the level is out of the codec's range
+	ErrInvalidLevelCodec = godelta.NewError(godelta.ErrUsage, "compression level out of range for codec")
+
+	// ErrInvalidWindowLog is returned when WindowLog is outside the supported range
+	ErrInvalidWindowLog = godelta.NewError(godelta.ErrUsage, "invalid zstd window log")
+
+	// ErrWindowZstdOnly is returned when window tuning is combined with a non-zstd codec or format
+	ErrWindowZstdOnly = godelta.NewError(godelta.ErrUsage, "window size and long distance matching apply to the zstd codec only")
+
+	// ErrInvalidEntropyThreshold is returned when EntropyThreshold is outside 0-8 bits per byte
+	ErrInvalidEntropyThreshold = godelta.NewError(godelta.ErrUsage, "entropy threshold must be between 0 and 8 bits per byte")
+
+	// ErrMemoryBudgetFormat is returned when a memory budget is combined with a standard archive format
+	ErrMemoryBudgetFormat = godelta.NewError(godelta.ErrUsage, "memory budget applies to GDELTA archives only")
+
+	// ErrMemoryBudgetTooSmall is returned when not even one worker fits in the memory budget
+	ErrMemoryBudgetTooSmall = godelta.NewError(godelta.ErrUsage, "memory budget too small")
+
+	// ErrInvalidParityPercent is returned when ParityPercent is out of range
+	ErrInvalidParityPercent = godelta.NewError(godelta.ErrUsage, "invalid parity percentage")
+
+	// ErrMetadataFormat is returned when Metadata is set for a GDELTA01 or standard archive
+	ErrMetadataFormat = godelta.NewError(godelta.ErrUsage, "archive metadata needs a GDELTA02 or GDELTA03 archive (chunking or dictionary mode)")
+
+	// ErrParityFormat is returned when parity is combined with a standard archive format
+	ErrParityFormat = godelta.NewError(godelta.ErrUsage, "parity applies to GDELTA archives only")
+
+	// ErrFromTarFormat is returned when a tar input is combined with a standard archive format or dictionary output
+	ErrFromTarFormat = godelta.NewError(godelta.ErrUsage, "tar input is converted to GDELTA02 archives only")
+
+	// ErrManifestFromTar is returned when a manifest is requested for a tar input
+	ErrManifestFromTar = godelta.NewError(godelta.ErrUsage, "manifests can't be written for tar input")
+
+	// ErrFromStreamFormat is returned when a stream or block device input is combined with a tar input, a standard archive format or dictionary output
+	ErrFromStreamFormat = godelta.NewError(godelta.ErrUsage, "stream and block device input is stored in GDELTA02 archives only, without a tar input")
+
+	// ErrManifestFromStream is returned when a manifest is requested for a stream or block device input
+	ErrManifestFromStream = godelta.NewError(godelta.ErrUsage, "manifests can't be written for stream or block device input")
+
+	// ErrEstimateStream is returned when Estimate is given a tar, stdin or block device input
+	ErrEstimateStream = godelta.NewError(godelta.ErrUsage, "estimation needs a file or directory input, not a stream or block device")
+
+	// ErrBenchStream is returned when Bench is given a tar, stdin or block device input
+	ErrBenchStream = godelta.NewError(godelta.ErrUsage, "benchmark needs a file or directory input, not a stream or block device")
+
+	// ErrBenchFormat is returned when Bench is asked for a standard archive format
+	ErrBenchFormat = godelta.NewError(godelta.ErrUsage, "benchmark compares GDELTA settings, not standard archive formats")
+
+	// ErrAutoTuneStream is returned when AutoLevel or AutoChunkSize is set with a tar, stdin or block device input
+	ErrAutoTuneStream = godelta.NewError(godelta.ErrUsage, "auto level and chunk size need a file or directory input, not a stream or block device")
+
+	// ErrAutoTuneFormat is returned when AutoLevel or AutoChunkSize is set with a standard archive format
+	ErrAutoTuneFormat = godelta.NewError(godelta.ErrUsage, "auto level and chunk size are only available for GDELTA archives")
+
+	// ErrInvalidFileOrder is returned when a FileOrder doesn't return every file exactly once
+	ErrInvalidFileOrder = godelta.NewError(godelta.ErrUsage, "file order must return every file exactly once")
+
+	// ErrInvalidMaxErrors is returned when MaxErrors is negative
+	ErrInvalidMaxErrors = godelta.NewError(godelta.ErrUsage, "max errors must not be negative")
+
+	// ErrInvalidChangeRetries is returned when ChangeRetries is negative
+	ErrInvalidChangeRetries = godelta.NewError(godelta.ErrUsage, "change retries must not be negative")
+
+	// ErrInvalidLockedRetries is returned when LockedFileRetries is negative
+	ErrInvalidLockedRetries = godelta.NewError(godelta.ErrUsage, "locked file retries must not be negative")
+
+	// ErrInvalidPathMode is returned when PathMode is unknown
+	ErrInvalidPathMode = godelta.NewError(godelta.ErrUsage, "path mode must be 'basename', 'common-prefix', 'full-sans-root' or 'custom'")
+
+	// ErrPathModeFiles is returned when PathMode or PathMapping is set without Files
+	ErrPathModeFiles = godelta.NewError(godelta.ErrUsage, "path modes apply to a list of files (Files)")
+
+	// ErrPathMapping is returned when PathMapping is set without PathCustom, or missing with it
+	ErrPathMapping = godelta.NewError(godelta.ErrUsage, "a path mapping goes with the custom path mode, which needs one")
+
+	// ErrPathUnmapped is returned for a Files entry that no PathMapping prefix covers
+	ErrPathUnmapped = godelta.NewError(godelta.ErrUsage, "not covered by the path mapping")
+
+	// ErrEmptyArchivePath is returned for a Files entry whose archive path would be empty or outside the archive
+	ErrEmptyArchivePath = godelta.NewError(godelta.ErrUsage, "archive path is empty or outside the archive")
+
+	// ErrNoCommonPrefix is returned when Files entries have no common directory (different volumes)
+	ErrNoCommonPrefix = godelta.NewError(godelta.ErrUsage, "no directory holds every entry (different volumes)")
+
+	// ErrInvalidPathNormalization is returned when PathNormalization is unknown
+	ErrInvalidPathNormalization = godelta.NewError(godelta.ErrUsage, "path normalization must be 'preserve', 'nfc' or 'nfd'")
+
+	// ErrFileLocked is returned for a file another process keeps locked
+	ErrFileLocked = godelta.NewError(godelta.ErrIO, "file is locked by another process")
+
+	// ErrInvalidSnapshotMode is returned when SnapshotMode is unknown
+	ErrInvalidSnapshotMode = godelta.NewError(godelta.ErrUsage, "snapshot mode must be 'auto', 'vss' or empty")
+
+	// ErrSnapshotInput is returned when a snapshot is requested without an InputPath, or with Files, FromTar or FromStream
+	ErrSnapshotInput = godelta.NewError(godelta.ErrUsage, "snapshots need an input path, not a file list or a stream")
+
+	// ErrSnapshotUnsupported is returned when the snapshot mode isn't available on this platform or volume
+	ErrSnapshotUnsupported = godelta.NewError(godelta.ErrUnsupported, "snapshot mode not supported here")
+
+	// ErrAdaptiveThreadsFormat is returned when AdaptiveThreads is set with a standard archive format or a stream input
+	ErrAdaptiveThreadsFormat = godelta.NewError(godelta.ErrUsage, "adaptive threads need a GDELTA archive of files or directories")
+
+	// ErrInvalidMinThreads is returned when MinThreads is negative or above MaxThreads
+	ErrInvalidMinThreads = godelta.NewError(godelta.ErrUsage, "min threads must be between 1 and max threads")
+
+	// ErrPackFormat is returned when PackSmallFiles is set for another format than GDELTA01 or a stream input
+	ErrPackFormat = godelta.NewError(godelta.ErrUsage, "small-file packing needs a GDELTA01 archive of files or directories")
+
+	// ErrInvalidPackSize is returned when PackSmallFiles is above PackFrameSize
+	ErrInvalidPackSize = godelta.NewError(godelta.ErrUsage, "small-file packing threshold must be at most 1MB")
+
+	// ErrDedupFilesFormat is returned when DedupFiles is set for another format than GDELTA01 or GDELTA03
+	ErrDedupFilesFormat = godelta.NewError(godelta.ErrUsage, "file-level dedup needs a GDELTA01 or GDELTA03 archive of files or directories")
+
+	// ErrVerifyAfterFormat is returned when VerifyAfter is set with a standard archive format or a dry run
+	ErrVerifyAfterFormat = godelta.NewError(godelta.ErrUsage, "verifying after compression needs a GDELTA archive to be written")
+
+	// ErrVerifyFailed is returned when the archive written with VerifyAfter doesn't pass verification
+	ErrVerifyFailed = godelta.NewError(godelta.ErrCorrupt, "archive failed verification after compression")
+
+	// ErrSplitByFolderFormat is returned when SplitByFolder is set with a standard archive format or a stream input
+	ErrSplitByFolderFormat = godelta.NewError(godelta.ErrUsage, "splitting by folder needs a GDELTA archive of files or directories")
+
+	// ErrChunkDictionaryFormat is returned when ChunkDictionary is set without zstd chunking of files or directories
+	ErrChunkDictionaryFormat = godelta.NewError(godelta.ErrUsage, "a chunk dictionary needs zstd chunking (ChunkSize) of files or directories")
+
+	// ErrReferenceDirsFormat is returned when ReferenceDirs is set without chunking of files or directories
+	ErrReferenceDirsFormat = godelta.NewError(godelta.ErrUsage, "reference directories need chunking (ChunkSize) of files or directories")
+
+	// ErrReferenceDir is returned when a reference directory can't be read
+	ErrReferenceDir = godelta.NewError(godelta.ErrIO, "read reference directory")
+
+	// ErrSniffTypesSource is returned when SniffTypes is set for a stream or a tar input
+	ErrSniffTypesSource = godelta.NewError(godelta.ErrUsage, "type sniffing reads files during the scan and can't be combined with a stream or tar input")
+
+	// ErrTypeCodecsFormat is returned when TypeCodecs is set for another format than GDELTA01
+	ErrTypeCodecsFormat = godelta.NewError(godelta.ErrUsage, "codecs by type need a GDELTA01 archive (without dictionary or chunking)")
+
+	// ErrInvalidTypePattern is returned when a TypeCodecs key is not a MIME type or a major/* pattern
+	ErrInvalidTypePattern = godelta.NewError(godelta.ErrUsage, "type pattern must be a MIME type or major/*")
+
+	// ErrInvalidCPUPriority is returned when CPUPriority is outside 0-MaxCPUPriority
+	ErrInvalidCPUPriority = godelta.NewError(godelta.ErrUsage, "CPU priority must be between 0 and 19")
+
+	// ErrInvalidIOPriority is returned when IOPriority is not low or idle
+	ErrInvalidIOPriority = godelta.NewError(godelta.ErrUsage, "invalid I/O priority (use low or idle)")
+
+	// ErrPriorityUnsupported is returned when the priority asked for can't be set on this platform
+	ErrPriorityUnsupported = godelta.NewError(godelta.ErrUnsupported, "priority not supported here")
+
+	// ErrErrorBudget is returned when a run records more errors than
+	// MaxErrors allows, or any with FailFast
+	ErrErrorBudget = godelta.NewError(godelta.ErrPartial, "too many errors")
+
+	// ErrFormatConflict is returned when more than one standard archive format is selected
+	ErrFormatConflict = godelta.NewError(godelta.ErrUsage, "choose only one of the ZIP, XZ, tar.gz and 7z formats")
+
+	// ErrInvalidLevelTarGz is returned when tar.gz compression level is out of range
+	ErrInvalidLevelTarGz = godelta.NewError(godelta.ErrUsage, "compression level for tar.gz (gzip) must be between 1 and 9")
+
+	// ErrTarGzNoChunking is returned when trying to use chunking with tar.gz format
+	ErrTarGzNoChunking = godelta.NewError(godelta.ErrUsage, "chunk-based deduplication is not supported in tar.gz format")
+
+	// ErrTarGzNoDictionary is returned when trying to use dictionary with tar.gz format
+	ErrTarGzNoDictionary = godelta.NewError(godelta.ErrUsage, "dictionary compression is not supported in tar.gz format")
+
+	// ErrInvalidLevel7z is returned when 7z compression level is out of range
+	ErrInvalidLevel7z = godelta.NewError(godelta.ErrUsage, "compression level for 7z (LZMA2) must be between 1 and 9")
+
+	// Err7zNoChunking is returned when trying to use chunking with 7z format
+	Err7zNoChunking = godelta.NewError(godelta.ErrUsage, "chunk-based deduplication is not supported in 7z format")
+
+	// Err7zNoDictionary is returned when trying to use dictionary with 7z format
+	Err7zNoDictionary = godelta.NewError(godelta.ErrUsage, "dictionary compression is not supported in 7z format")
+
+	// ErrDeterministicFormat is returned when Deterministic is set with a standard archive format or a dictionary
+	ErrDeterministicFormat = godelta.NewError(godelta.ErrUsage, "deterministic output needs a GDELTA01 or GDELTA02 archive without a dictionary")
+
+	// ErrDeterministicTuning is returned when Deterministic is combined with auto-tuning or adaptive threads
+	ErrDeterministicTuning = godelta.NewError(godelta.ErrUsage, "deterministic output cannot be combined with auto-tuning or adaptive threads")
+
+	// ErrFormatSpecFormat is returned when EmbedFormatSpec is set for a GDELTA01 or standard archive
+	ErrFormatSpecFormat = godelta.NewError(godelta.ErrUsage, "embedding the format spec needs a GDELTA02 or GDELTA03 archive (chunking or dictionary mode)")
+
+	// ErrArchivalFormat is returned when Archival is set with another format than a single GDELTA02 archive
+	ErrArchivalFormat = godelta.NewError(godelta.ErrUsage, "archival mode writes a single GDELTA02 archive: no standard format, dictionary or split by folder")
 )
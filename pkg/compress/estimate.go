@@ -0,0 +1,280 @@
+// pkg/compress/estimate.go
+package compress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/creativeyann17/go-delta/internal/chunker"
+	"github.com/creativeyann17/go-delta/internal/chunkstore"
+)
+
+// DefaultEstimateChunkSizes are the chunk sizes Estimate compares when
+// none are given
+var DefaultEstimateChunkSizes = []uint64{
+	16 * 1024, 32 * 1024, 64 * 1024, 128 * 1024, 256 * 1024, 512 * 1024, 1024 * 1024,
+}
+
+// GDELTA02 metadata per chunk: an index entry per unique chunk and a hash
+// reference per chunk of a file
+const (
+	chunkIndexEntrySize = 56
+	chunkRefSize        = 32
+)
+
+// ChunkEstimate is the projected deduplication of one chunk size
+type ChunkEstimate struct {
+	ChunkSize    uint64
+	TotalChunks  uint64 // Chunks cut from the files
+	UniqueChunks uint64 // Chunks a GDELTA02 archive would store
+	UniqueBytes  uint64 // Their original size: the data left to compress
+	OriginalSize uint64 // Bytes chunked
+}
+
+// MetadataSize returns the chunk index and file references a GDELTA02
+// archive would hold for these chunks
+func (e ChunkEstimate) MetadataSize() uint64 {
+	return e.UniqueChunks*chunkIndexEntrySize + e.TotalChunks*chunkRefSize
+}
+
+// ProjectedSize returns the unique data plus its metadata, the size of the
+// archive before compression
+func (e ChunkEstimate) ProjectedSize() uint64 {
+	return e.UniqueBytes + e.MetadataSize()
+}
+
+// DedupRatio returns the share of the original bytes removed by
+// deduplication, as a percentage
+func (e ChunkEstimate) DedupRatio() float64 {
+	if e.OriginalSize == 0 {
+		return 0
+	}
+	return float64(e.OriginalSize-e.UniqueBytes) / float64(e.OriginalSize) * 100
+}
+
+// EstimateResult reports a dedup estimation
+type EstimateResult struct {
+	FilesTotal     int
+	FilesProcessed int
+	OriginalSize   uint64
+
+	// Estimates holds one entry per chunk size, smallest first
+	Estimates []ChunkEstimate
+
+	// Recommended is the chunk size with the smallest projected size
+	Recommended uint64
+
+	Errors   []error
+	Duration time.Duration
+}
+
+// Estimate chunks the input of opts with each of chunkSizes
+// (DefaultEstimateChunkSizes if empty) and reports how much data a GDELTA02
+// archive would store for each, without compressing or writing anything.
+// Each file is read once and fed to every chunker. opts selects the input
+// (InputPath or Files, Excludes, IgnoreFiles, UseGitignore, ModifiedSince),
+// MaxThreads, ChunkingMode and ChunkNormalization; chunk bounds are the
+// defaults of each size. Every unique chunk of every size is kept in
+// memory, about 120 bytes each.
+func Estimate(ctx context.Context, opts *Options, chunkSizes []uint64, progressCb ProgressCallback) (*EstimateResult, error) {
+	if opts.FromTar != nil || opts.streamInput() {
+		return nil, ErrEstimateStream
+	}
+	if len(chunkSizes) == 0 {
+		chunkSizes = DefaultEstimateChunkSizes
+	}
+	chunkSizes = slices.Compact(slices.Sorted(slices.Values(chunkSizes)))
+
+	// Each size is validated like a chunked run of its own
+	candidates := make([]*Options, len(chunkSizes))
+	for i, size := range chunkSizes {
+		if size == 0 {
+			return nil, ErrChunkSizeTooSmall
+		}
+		candidate := *opts
+		candidate.ChunkSize = size
+		candidate.ChunkMinSize, candidate.ChunkMaxSize = 0, 0
+		if err := candidate.Validate(); err != nil {
+			return nil, fmt.Errorf("chunk size %s: %w", FormatSize(size), err)
+		}
+		candidates[i] = &candidate
+	}
+	base := candidates[0]
+
+	progressCb = throttleProgress(progressCb, base)
+
+	start := time.Now()
+	result := &EstimateResult{}
+	defer func() { result.Duration = time.Since(start) }()
+
+	folders, totalFiles, totalOrigSize, err := collectFiles(base, &Result{})
+	if err != nil {
+		return nil, err
+	}
+	if totalFiles == 0 {
+		return nil, ErrNoFiles
+	}
+	result.FilesTotal = totalFiles
+	if progressCb != nil {
+		progressCb(ProgressEvent{Type: EventStart, Total: int64(totalFiles), TotalBytes: totalOrigSize})
+	}
+
+	estimators := make([]*chunkEstimator, len(candidates))
+	for i, candidate := range candidates {
+		estimators[i] = &chunkEstimator{chunker: candidate.newChunker(), store: chunkstore.NewStore()}
+	}
+
+	var processed atomic.Uint32
+	var originalSize atomic.Uint64
+	var errorsMu sync.Mutex
+	var wg sync.WaitGroup
+	taskCh := feedTasks(ctx, folders, base.MaxThreads*16)
+	for range base.MaxThreads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range taskCh {
+				size, err := estimateFile(task, estimators)
+				if err != nil {
+					errorsMu.Lock()
+					result.Errors = append(result.Errors, fmt.Errorf("%s: %w", task.RelPath, err))
+					errorsMu.Unlock()
+					if progressCb != nil {
+						progressCb(ProgressEvent{Type: EventError, FilePath: task.RelPath})
+					}
+					continue
+				}
+				processed.Add(1)
+				originalSize.Add(size)
+				if progressCb != nil {
+					progressCb(ProgressEvent{
+						Type:     EventFileComplete,
+						FilePath: task.RelPath,
+						Current:  int64(size),
+						Total:    int64(size),
+					})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("estimation canceled: %w", err)
+	}
+
+	result.FilesProcessed = int(processed.Load())
+	result.OriginalSize = originalSize.Load()
+	var best uint64
+	for _, e := range estimators {
+		stats := e.store.Stats()
+		estimate := ChunkEstimate{
+			ChunkSize:    e.chunker.ChunkSize(),
+			TotalChunks:  stats.TotalChunks,
+			UniqueChunks: stats.UniqueChunks,
+			UniqueBytes:  e.uniqueBytes.Load(),
+			OriginalSize: result.OriginalSize,
+		}
+		if len(result.Estimates) == 0 || estimate.ProjectedSize() < best {
+			best = estimate.ProjectedSize()
+			result.Recommended = estimate.ChunkSize
+		}
+		result.Estimates = append(result.Estimates, estimate)
+	}
+
+	if progressCb != nil {
+		progressCb(ProgressEvent{
+			Type:       EventComplete,
+			Current:    int64(result.FilesProcessed),
+			Total:      int64(result.FilesTotal),
+			TotalBytes: result.OriginalSize,
+		})
+	}
+	return result, nil
+}
+
+// Summary returns a human-readable table of the estimates
+func (r *EstimateResult) Summary() string {
+	out := fmt.Sprintf("Estimated %d / %d files, %s in %v\n\n", r.FilesProcessed, r.FilesTotal, FormatSize(r.OriginalSize), r.Duration.Round(time.Millisecond))
+	out += fmt.Sprintf("  %-10s %12s %12s %12s %8s %12s\n", "Chunk size", "Chunks", "Unique", "Unique data", "Dedup", "Projected")
+	for _, e := range r.Estimates {
+		mark := ""
+		if e.ChunkSize == r.Recommended {
+			mark = "  <- recommended"
+		}
+		out += fmt.Sprintf("  %-10s %12d %12d %12s %7.1f%% %12s%s\n",
+			FormatSize(e.ChunkSize), e.TotalChunks, e.UniqueChunks, FormatSize(e.UniqueBytes), e.DedupRatio(), FormatSize(e.ProjectedSize()), mark)
+	}
+	out += "\nProjected = unique data + chunk metadata, before compression\n"
+	if len(r.Errors) > 0 {
+		out += fmt.Sprintf("\n%d files could not be read:\n", len(r.Errors))
+		for _, err := range r.Errors {
+			out += fmt.Sprintf("  ✗ %v\n", err)
+		}
+	}
+	return out
+}
+
+// chunkEstimator tallies the chunks of one chunk size
+type chunkEstimator struct {
+	chunker     *chunker.Chunker
+	store       *chunkstore.Store
+	uniqueBytes atomic.Uint64
+}
+
+// add records one chunk; nothing is written, so the store only counts
+func (e *chunkEstimator) add(chunk chunker.Chunk) error {
+	_, isNew, err := e.store.GetOrAdd(chunk.Hash, chunk.OrigSize, func() (uint64, uint64, error) {
+		return 0, 0, nil
+	})
+	if err == nil && isNew {
+		e.uniqueBytes.Add(chunk.OrigSize)
+	}
+	return err
+}
+
+// estimateFile reads task once, piping its content to every estimator's
+// chunker, and returns the bytes read
+func estimateFile(task fileTask, estimators []*chunkEstimator) (uint64, error) {
+	file, err := os.Open(task.AbsPath)
+	if err != nil {
+		return 0, fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+
+	writers := make([]io.Writer, len(estimators))
+	pipes := make([]*io.PipeWriter, len(estimators))
+	errs := make([]error, len(estimators))
+	var wg sync.WaitGroup
+	for i, e := range estimators {
+		pr, pw := io.Pipe()
+		writers[i], pipes[i] = pw, pw
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = e.chunker.SplitWithCallback(pr, e.add)
+			// Unblock the reader if the chunker stopped early
+			pr.CloseWithError(errs[i])
+		}()
+	}
+
+	n, err := io.Copy(io.MultiWriter(writers...), file)
+	for _, pw := range pipes {
+		pw.CloseWithError(err)
+	}
+	wg.Wait()
+	if err != nil {
+		return 0, fmt.Errorf("read: %w", err)
+	}
+	for _, err := range errs {
+		if err != nil {
+			return 0, fmt.Errorf("split chunks: %w", err)
+		}
+	}
+	return uint64(n), nil
+}
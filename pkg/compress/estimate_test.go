@@ -0,0 +1,60 @@
+// pkg/compress/estimate_test.go
+package compress
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	dir := t.TempDir()
+	data := make([]byte, 256*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+	for _, name := range []string{"a.bin", "copy/a.bin"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Estimate(context.Background(), &Options{InputPath: dir, MaxThreads: 2}, []uint64{64 * 1024, 8 * 1024, 64 * 1024}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FilesProcessed != 2 || result.OriginalSize != uint64(2*len(data)) {
+		t.Errorf("processed %d files of %d bytes", result.FilesProcessed, result.OriginalSize)
+	}
+	if len(result.Estimates) != 2 || result.Estimates[0].ChunkSize != 8*1024 || result.Estimates[1].ChunkSize != 64*1024 {
+		t.Fatalf("estimates %+v, want 8KB then 64KB", result.Estimates)
+	}
+	for _, e := range result.Estimates {
+		// The copy only repeats chunks of the original
+		if e.UniqueBytes != uint64(len(data)) || e.TotalChunks != 2*e.UniqueChunks {
+			t.Errorf("%d: %+v", e.ChunkSize, e)
+		}
+		if e.DedupRatio() != 50 {
+			t.Errorf("%d: dedup %.1f%%, want 50%%", e.ChunkSize, e.DedupRatio())
+		}
+	}
+	// Same unique data: the bigger size carries less metadata
+	if result.Recommended != 64*1024 {
+		t.Errorf("recommended %d, want 64KB", result.Recommended)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "archive.delta")); !os.IsNotExist(err) {
+		t.Errorf("estimate wrote an archive: %v", err)
+	}
+}
+
+func TestEstimateRejectsStream(t *testing.T) {
+	_, err := Estimate(context.Background(), &Options{FromStream: os.Stdin}, nil, nil)
+	if !errors.Is(err, ErrEstimateStream) {
+		t.Errorf("got %v, want ErrEstimateStream", err)
+	}
+}
@@ -0,0 +1,167 @@
+// pkg/compress/filetype.go
+package compress
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// sniffLen is how much of the start of a file is read to detect its type
+const sniffLen = 512
+
+// emptyType is reported for empty files, which have no content to sniff
+const emptyType = "inode/x-empty"
+
+// signatures are the magic numbers checked before http.DetectContentType,
+// for compressed and binary formats it doesn't know
+var signatures = []struct {
+	offset int
+	magic  string
+	mime   string
+}{
+	{0, "\x28\xb5\x2f\xfd", "application/zstd"},
+	{0, "\xfd7zXZ\x00", "application/x-xz"},
+	{0, "BZh", "application/x-bzip2"},
+	{0, "\x1f\x8b", "application/gzip"},
+	{0, "7z\xbc\xaf\x27\x1c", "application/x-7z-compressed"},
+	{0, "Rar!\x1a\x07", "application/vnd.rar"},
+	{0, "\x04\x22\x4d\x18", "application/x-lz4"},
+	{0, "wOF2", "font/woff2"},
+	{0, "GDELTA0", "application/x-godelta"},
+	{0, "\x7fELF", "application/x-executable"},
+	{0, "SQLite format 3\x00", "application/vnd.sqlite3"},
+	{0, "fLaC", "audio/flac"},
+	{4, "ftypheic", "image/heic"},
+	{4, "ftypavif", "image/avif"},
+	{4, "ftypM4A", "audio/mp4"},
+	{4, "ftyp", "video/mp4"},
+	{257, "ustar", "application/x-tar"},
+}
+
+// compressedTypes lists the sniffed types whose content is already
+// compressed, stored as-is like DefaultStoreExtensions. Entries ending in
+// "/" match a whole major type.
+var compressedTypes = []string{
+	"image/jpeg", "image/png", "image/gif", "image/webp", "image/heic", "image/avif",
+	"video/", "audio/mpeg", "audio/mp4", "audio/aac", "audio/ogg", "audio/flac",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-bzip2",
+	"application/x-xz", "application/zstd", "application/x-7z-compressed",
+	"application/vnd.rar", "application/x-rar-compressed", "application/x-lz4",
+	"application/x-godelta", "font/woff2",
+}
+
+// detectType returns the MIME type of content starting with head (at most
+// sniffLen bytes), without parameters
+func detectType(head []byte) string {
+	if len(head) == 0 {
+		return emptyType
+	}
+	for _, s := range signatures {
+		if len(head) > s.offset && bytes.HasPrefix(head[s.offset:], []byte(s.magic)) {
+			return s.mime
+		}
+	}
+	mime, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	return mime
+}
+
+// sniffFile returns the type of the file at path, "" when it can't be read:
+// the real read that follows reports the error
+func sniffFile(path string, buf []byte) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	n, err := io.ReadFull(f, buf[:sniffLen])
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return ""
+	}
+	return detectType(buf[:n])
+}
+
+// compressedType reports whether files of type mime are already compressed
+func compressedType(mime string) bool {
+	for _, t := range compressedTypes {
+		if mime == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(mime, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sniffTypes detects the type of every file of folders without one on
+// threads workers, setting fileTask.Type in place, and counts them in
+// result.Types
+func sniffTypes(ctx context.Context, folders []folderTask, threads int, result *Result) error {
+	type ref struct{ folder, file int }
+	refs := make(chan ref, threads*16)
+	go func() {
+		defer close(refs)
+		for i := range folders {
+			for j := range folders[i].Files {
+				select {
+				case refs <- ref{i, j}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range threads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, sniffLen)
+			for r := range refs {
+				// Files of a split run were sniffed by the scan already
+				task := &folders[r.folder].Files[r.file]
+				if task.Type == "" {
+					task.Type = sniffFile(task.AbsPath, buf)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	result.Types = make(map[string]int)
+	for _, folder := range folders {
+		for _, task := range folder.Files {
+			if task.Type != "" {
+				result.Types[task.Type]++
+			}
+		}
+	}
+	return nil
+}
+
+// formatTypes lists the n most common types of a Result.Types histogram
+// with their counts, most common first
+func formatTypes(types map[string]int, n int) string {
+	names := slices.Collect(maps.Keys(types))
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(types[b], types[a]), strings.Compare(a, b))
+	})
+	var parts []string
+	for _, name := range names[:min(n, len(names))] {
+		parts = append(parts, fmt.Sprintf("%s %d", name, types[name]))
+	}
+	if len(names) > n {
+		parts = append(parts, fmt.Sprintf("%d more", len(names)-n))
+	}
+	return strings.Join(parts, ", ")
+}
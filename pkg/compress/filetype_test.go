@@ -0,0 +1,74 @@
+// pkg/compress/filetype_test.go
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+)
+
+func TestDetectType(t *testing.T) {
+	tar := make([]byte, 512)
+	copy(tar[257:], "ustar\x0000")
+	for want, head := range map[string][]byte{
+		"image/png":                []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+		"application/gzip":         {0x1f, 0x8b, 8, 0},
+		"application/zstd":         {0x28, 0xb5, 0x2f, 0xfd, 0x24},
+		"application/x-tar":        tar,
+		"video/mp4":                []byte("\x00\x00\x00\x18ftypisom\x00\x00\x02\x00"),
+		"text/plain":               []byte("just some words\n"),
+		"application/pdf":          []byte("%PDF-1.7\n"),
+		"application/x-executable": []byte("\x7fELF\x02\x01\x01"),
+		emptyType:                  nil,
+	} {
+		if got := detectType(head); got != want {
+			t.Errorf("%q: got %s, want %s", head[:min(len(head), 16)], got, want)
+		}
+	}
+}
+
+func TestMethodForType(t *testing.T) {
+	opts := &Options{TypeCodecs: map[string]string{"text/*": "brotli", "text/html": "lz4"}, Level: 5}
+	for _, c := range []struct {
+		task fileTask
+		want string
+	}{
+		{fileTask{RelPath: "data.bin", Type: "application/gzip"}, "store"},
+		{fileTask{RelPath: "notes.txt", Type: "text/plain"}, "brotli"},
+		{fileTask{RelPath: "index.html", Type: "text/html"}, "lz4"},
+		{fileTask{RelPath: "data.bin", Type: "application/octet-stream"}, "zstd"},
+	} {
+		if got := opts.methodFor(c.task); got.String() != c.want {
+			t.Errorf("%s (%s): got %s, want %s", c.task.RelPath, c.task.Type, got, c.want)
+		}
+	}
+	if got := (&Options{CompressAll: true}).methodFor(fileTask{Type: "image/png"}); got != format.MethodZstd {
+		t.Errorf("CompressAll: got %s", got)
+	}
+}
+
+func TestTypeCodecsValidation(t *testing.T) {
+	for name, c := range map[string]struct {
+		opts *Options
+		want error
+	}{
+		"dictionary": {&Options{UseDictionary: true, TypeCodecs: map[string]string{"text/*": "zstd"}}, ErrTypeCodecsFormat},
+		"pattern":    {&Options{TypeCodecs: map[string]string{"*/*": "zstd"}}, ErrInvalidTypePattern},
+		"codec":      {&Options{TypeCodecs: map[string]string{"text/*": "rot13"}}, ErrUnknownCodec},
+		"stream":     {&Options{FromStream: bytes.NewReader(nil), SniffTypes: true}, ErrSniffTypesSource},
+	} {
+		c.opts.InputPath = "."
+		if c.opts.FromStream != nil {
+			c.opts.InputPath = ""
+		}
+		if err := c.opts.Validate(); !errors.Is(err, c.want) {
+			t.Errorf("%s: got %v, want %v", name, err, c.want)
+		}
+	}
+	opts := &Options{InputPath: ".", TypeCodecs: map[string]string{"text/*": "brotli"}}
+	if err := opts.Validate(); err != nil || !opts.SniffTypes {
+		t.Errorf("got %v, sniffing %v", err, opts.SniffTypes)
+	}
+}
@@ -2,14 +2,68 @@
 package compress
 
 import (
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
 	ignore "github.com/sabhiram/go-gitignore"
 )
 
+// Ignore files read in every directory of a walked input: .gitignore with
+// Options.UseGitignore, .godeltaignore always. In a directory holding both,
+// .godeltaignore lines come last and win (a "!" line there re-includes what
+// .gitignore excludes).
+const (
+	gitignoreName     = ".gitignore"
+	godeltaignoreName = ".godeltaignore"
+)
+
+// GlobalIgnoreFile returns the user's ignore file, which the CLI adds in
+// front of Options.IgnoreFiles when it exists: godelta/ignore in the user
+// config directory (~/.config/godelta/ignore on Linux, honoring
+// XDG_CONFIG_HOME). It returns "" when there is no config directory.
+func GlobalIgnoreFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "godelta", "ignore")
+}
+
+// ignoreNames returns the ignore files read in every walked directory
+func (o *Options) ignoreNames() []string {
+	if o.UseGitignore {
+		return []string{gitignoreName, godeltaignoreName}
+	}
+	return []string{godeltaignoreName}
+}
+
+// filesIgnoreNames returns the ignore files read for the entries of
+// Options.Files: those of ignoreNames, none with NoIgnoreInFiles
+func (o *Options) filesIgnoreNames() []string {
+	if o.NoIgnoreInFiles {
+		return nil
+	}
+	return o.ignoreNames()
+}
+
+// readIgnoreFiles returns the lines of Options.IgnoreFiles, in order
+func (o *Options) readIgnoreFiles() ([]string, error) {
+	var lines []string
+	for _, path := range o.IgnoreFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read ignore file: %w", err)
+		}
+		lines = append(lines, strings.Split(string(data), "\n")...)
+	}
+	return lines, nil
+}
+
 // gitignoreMatcher handles .gitignore pattern matching with proper hierarchy support.
 // It pre-scans the directory tree for .gitignore files and compiles them into matchers.
 type gitignoreMatcher struct {
@@ -21,24 +75,38 @@ type gitignoreMatcher struct {
 // newGitignoreMatcher creates a matcher that pre-scans the directory tree for .gitignore files.
 // Returns nil if no .gitignore files are found (no-op for performance).
 func newGitignoreMatcher(baseDir string) (*gitignoreMatcher, error) {
+	return newIgnoreMatcher(baseDir, []string{gitignoreName}, nil, nil)
+}
+
+// newIgnoreMatcher creates a matcher from the ignore files named names in
+// every directory of the tree (pre-scanned), merging the root directory's
+// lines between before and after. Later lines win within a directory, so
+// precedence at the root runs from before (Options.IgnoreFiles) through the
+// root's own files to after (Options.Excludes). Returns nil if there are no
+// patterns at all.
+func newIgnoreMatcher(baseDir string, names, before, after []string) (*gitignoreMatcher, error) {
 	baseDir = filepath.Clean(baseDir)
 	gm := &gitignoreMatcher{
 		baseDir:  baseDir,
 		matchers: make(map[string]*ignore.GitIgnore),
 	}
+	lines := make(map[string][]string) // Key: relative dir path, Value: lines of its files
 
-	// Scan for all .gitignore files in the tree
-	err := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
+	// Scan for all ignore files in the tree
+	err := filepath.WalkDir(baseDir, func(path string, d fs.DirEntry, err error) error {
+		if len(names) == 0 {
+			return filepath.SkipAll
+		}
 		if err != nil {
 			// Skip inaccessible paths
 			return nil
 		}
 
-		if info.IsDir() {
+		if d.IsDir() {
 			return nil
 		}
 
-		if filepath.Base(path) != ".gitignore" {
+		if !slices.Contains(names, d.Name()) {
 			return nil
 		}
 
@@ -52,29 +120,56 @@ func newGitignoreMatcher(baseDir string) (*gitignoreMatcher, error) {
 			relDir = ""
 		}
 
-		// Compile the gitignore file
-		matcher, err := ignore.CompileIgnoreFile(path)
+		data, err := os.ReadFile(path)
 		if err != nil {
-			// Skip invalid .gitignore files silently
+			// Skip unreadable ignore files silently
 			return nil
 		}
-
-		gm.matchers[relDir] = matcher
+		lines[relDir] = append(lines[relDir], strings.Split(string(data), "\n")...)
 		return nil
 	})
 
 	if err != nil {
 		return nil, err
 	}
+	if len(before) > 0 || len(after) > 0 {
+		lines[""] = slices.Concat(before, lines[""], after)
+	}
 
-	// If no .gitignore files found, return nil (caller can skip filtering)
-	if len(gm.matchers) == 0 {
+	// If no ignore files or patterns found, return nil (caller can skip filtering)
+	if len(lines) == 0 {
 		return nil, nil
 	}
 
+	// A directory's files are compiled as one, in the walk's lexical order,
+	// which puts .gitignore before .godeltaignore
+	for relDir, dirLines := range lines {
+		gm.matchers[relDir] = ignore.CompileIgnoreLines(dirLines...)
+	}
 	return gm, nil
 }
 
+// newDirIgnoreMatcher creates a matcher for the files directly in dir, from
+// its own ignore files named names only, merged between before and after
+// like the root of newIgnoreMatcher. Used for files listed one by one in
+// Options.Files. Returns nil if there are no patterns at all.
+func newDirIgnoreMatcher(dir string, names, before, after []string) *gitignoreMatcher {
+	var own []string
+	for _, name := range names {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+			own = append(own, strings.Split(string(data), "\n")...)
+		}
+	}
+	lines := slices.Concat(before, own, after)
+	if len(lines) == 0 {
+		return nil
+	}
+	return &gitignoreMatcher{
+		baseDir:  filepath.Clean(dir),
+		matchers: map[string]*ignore.GitIgnore{"": ignore.CompileIgnoreLines(lines...)},
+	}
+}
+
 // ShouldIgnore checks if a file at relPath should be ignored.
 // relPath should be relative to the matcher's baseDir.
 // Returns true if the file matches any ignore pattern.
@@ -2,8 +2,10 @@
 package compress
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 )
 
@@ -429,3 +431,117 @@ func createDir(t *testing.T, base, relPath string) {
 		t.Fatal(err)
 	}
 }
+
+func TestCompressExcludes(t *testing.T) {
+	tmpDir := t.TempDir()
+	createFile(t, tmpDir, "keep.txt", "content")
+	createFile(t, tmpDir, "debug.log", "log content")
+	createDir(t, tmpDir, "node_modules")
+	createFile(t, tmpDir, "node_modules/lib.js", "module")
+	createDir(t, tmpDir, "src")
+	createFile(t, tmpDir, "src/main.go", "package main")
+	createFile(t, tmpDir, "src/trace.log", "log content")
+
+	opts := &Options{
+		InputPath: tmpDir,
+		Excludes:  []string{"*.log", "node_modules/"},
+		DryRun:    true,
+		Quiet:     true,
+	}
+	result, err := Compress(opts, nil)
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if result.FilesTotal != 2 {
+		t.Errorf("FilesTotal = %d, want 2 (keep.txt, src/main.go)", result.FilesTotal)
+	}
+}
+
+func TestIgnoreSources(t *testing.T) {
+	tmpDir := t.TempDir()
+	createFile(t, tmpDir, ".gitignore", "*.log\n")
+	createFile(t, tmpDir, ".godeltaignore", "!keep.log\n*.tmp\n")
+	createFile(t, tmpDir, "sub/.godeltaignore", "*.bak\n")
+	for _, name := range []string{"a.txt", "a.csv", "b.csv", "a.log", "keep.log", "a.tmp", "b.tmp", "sub/c.bak", "sub/c.txt"} {
+		createFile(t, tmpDir, name, "content")
+	}
+	global := filepath.Join(t.TempDir(), "ignore")
+	if err := os.WriteFile(global, []byte("*.csv\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	extra := filepath.Join(t.TempDir(), "extra")
+	if err := os.WriteFile(extra, []byte("!b.csv\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ignore files in order, then the root's .gitignore and .godeltaignore,
+	// then the excludes
+	for name, c := range map[string]struct {
+		opts Options
+		want string
+	}{
+		"godeltaignore": {Options{}, "[.gitignore .godeltaignore a.csv a.log a.txt b.csv keep.log sub/.godeltaignore sub/c.txt]"},
+		"gitignore":     {Options{UseGitignore: true}, "[.gitignore .godeltaignore a.csv a.txt b.csv keep.log sub/.godeltaignore sub/c.txt]"},
+		"ignore files":  {Options{IgnoreFiles: []string{global, extra}}, "[.gitignore .godeltaignore a.log a.txt b.csv keep.log sub/.godeltaignore sub/c.txt]"},
+		"excludes":      {Options{IgnoreFiles: []string{global}, Excludes: []string{"!a.tmp", ".*ignore"}}, "[a.log a.tmp a.txt keep.log sub/c.txt]"},
+	} {
+		opts := c.opts
+		opts.InputPath = tmpDir
+		folders, _, _, err := collectFiles(&opts, &Result{})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var got []string
+		for _, folder := range folders {
+			for _, task := range folder.Files {
+				got = append(got, filepath.ToSlash(task.RelPath))
+			}
+		}
+		sort.Strings(got)
+		if fmt.Sprint(got) != c.want {
+			t.Errorf("%s: got %v, want %s", name, got, c.want)
+		}
+	}
+
+	opts := &Options{InputPath: tmpDir, IgnoreFiles: []string{filepath.Join(tmpDir, "missing")}}
+	if _, _, _, err := collectFiles(opts, &Result{}); err == nil {
+		t.Error("missing ignore file accepted")
+	}
+}
+
+func TestIgnoreInFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	createFile(t, tmpDir, "proj/.gitignore", "*.log\n")
+	createFile(t, tmpDir, "proj/sub/.gitignore", "*.bak\n")
+	for _, name := range []string{"proj/a.txt", "proj/a.log", "proj/sub/b.bak", "proj/sub/b.txt", "loose/x.log", "loose/y.txt"} {
+		createFile(t, tmpDir, name, "content")
+	}
+	createFile(t, tmpDir, "loose/.godeltaignore", "*.log\n")
+	files := []string{filepath.Join(tmpDir, "proj"), filepath.Join(tmpDir, "loose/x.log"), filepath.Join(tmpDir, "loose/y.txt")}
+
+	for name, c := range map[string]struct {
+		opts Options
+		want string
+	}{
+		"honored":  {Options{UseGitignore: true}, "[proj/.gitignore proj/a.txt proj/sub/.gitignore proj/sub/b.txt y.txt]"},
+		"disabled": {Options{UseGitignore: true, NoIgnoreInFiles: true}, "[proj/.gitignore proj/a.log proj/a.txt proj/sub/.gitignore proj/sub/b.bak proj/sub/b.txt x.log y.txt]"},
+		"excludes": {Options{NoIgnoreInFiles: true, Excludes: []string{"*.txt"}}, "[proj/.gitignore proj/a.log proj/sub/.gitignore proj/sub/b.bak x.log]"},
+	} {
+		opts := c.opts
+		opts.Files = files
+		folders, _, _, err := collectFiles(&opts, &Result{})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var got []string
+		for _, folder := range folders {
+			for _, task := range folder.Files {
+				got = append(got, filepath.ToSlash(task.RelPath))
+			}
+		}
+		sort.Strings(got)
+		if fmt.Sprint(got) != c.want {
+			t.Errorf("%s: got %v, want %s", name, got, c.want)
+		}
+	}
+}
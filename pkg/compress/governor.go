@@ -0,0 +1,168 @@
+// pkg/compress/governor.go
+package compress
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Governor tuning: the load is sampled every governorInterval; the worker
+// limit drops by a quarter when other processes keep the CPUs or the disks
+// busy, and grows back by one when the machine is quiet again
+const (
+	governorInterval   = 2 * time.Second
+	governorBusyCPU    = 0.50 // share of CPU time used by other processes
+	governorBusyIOWait = 0.25 // share of CPU time waiting for the disks
+	governorIdleCPU    = 0.25
+	governorIdleIOWait = 0.10
+)
+
+// cpuTimes are cumulative CPU times of the machine and of this process
+type cpuTimes struct {
+	total  uint64 // all CPU time
+	idle   uint64 // idle, iowait excluded
+	iowait uint64 // idle with disk requests pending
+	self   uint64 // spent by this process
+}
+
+// systemLoad is the use of the CPUs between two cpuTimes, as shares of the
+// CPU time elapsed
+type systemLoad struct {
+	others float64 // busy with other processes
+	iowait float64
+}
+
+// loadBetween returns the load between the samples a and b
+func loadBetween(a, b cpuTimes) systemLoad {
+	if b.total <= a.total {
+		return systemLoad{}
+	}
+	total := float64(b.total - a.total)
+	busy := float64((b.total - b.idle - b.iowait) - (a.total - a.idle - a.iowait))
+	self := float64(b.self - a.self)
+	return systemLoad{
+		others: max(busy-self, 0) / total,
+		iowait: float64(b.iowait-a.iowait) / total,
+	}
+}
+
+// governor caps the number of files compressed at once between
+// Options.MinThreads and MaxThreads, following the load of the machine.
+// Workers call enter before a file and leave after it; the ones over the
+// limit wait. A nil governor never does.
+type governor struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	min    int
+	max    int
+	limit  int
+	active int
+
+	lowest      int // lowest limit reached
+	adjustments int // times the limit changed
+	done        chan struct{}
+	stopped     sync.WaitGroup
+}
+
+// startGovernor starts the governor of opts, nil unless AdaptiveThreads is
+// set. Without a way to read the load on this platform, the workers run at
+// MaxThreads.
+func startGovernor(ctx context.Context, opts *Options) *governor {
+	if !opts.AdaptiveThreads {
+		return nil
+	}
+	// The memory budget may have lowered MaxThreads below MinThreads
+	g := newGovernor(min(opts.MinThreads, opts.MaxThreads), opts.MaxThreads)
+	prev, err := readCPUTimes()
+	if err != nil {
+		return g
+	}
+	g.stopped.Add(1)
+	go func() {
+		defer g.stopped.Done()
+		ticker := time.NewTicker(governorInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			case <-g.done:
+				return
+			}
+			cur, err := readCPUTimes()
+			if err != nil {
+				return
+			}
+			g.adjust(loadBetween(prev, cur))
+			prev = cur
+		}
+	}()
+	return g
+}
+
+// newGovernor returns a governor letting hi workers in at first, and never
+// fewer than lo
+func newGovernor(lo, hi int) *governor {
+	g := &governor{min: lo, max: hi, limit: hi, lowest: hi, done: make(chan struct{})}
+	g.cond = sync.NewCond(&g.mu)
+	return g
+}
+
+// adjust moves the limit for load: down by a quarter (at least one) when
+// the machine is busy, up by one when it is quiet
+func (g *governor) adjust(load systemLoad) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	limit := g.limit
+	switch {
+	case load.others > governorBusyCPU || load.iowait > governorBusyIOWait:
+		limit = max(g.min, limit-max(1, limit/4))
+	case load.others < governorIdleCPU && load.iowait < governorIdleIOWait:
+		limit = min(g.max, limit+1)
+	}
+	if limit == g.limit {
+		return
+	}
+	g.limit = limit
+	g.lowest = min(g.lowest, limit)
+	g.adjustments++
+	g.cond.Broadcast()
+}
+
+// enter waits until the worker may compress a file
+func (g *governor) enter() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	for g.active >= g.limit {
+		g.cond.Wait()
+	}
+	g.active++
+	g.mu.Unlock()
+}
+
+// leave ends the file entered
+func (g *governor) leave() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	g.active--
+	g.cond.Signal()
+	g.mu.Unlock()
+}
+
+// stop ends the sampling and records what the governor did in result
+func (g *governor) stop(result *Result) {
+	if g == nil {
+		return
+	}
+	close(g.done)
+	g.stopped.Wait()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	result.ThreadsLowest, result.ThreadAdjustments = g.lowest, g.adjustments
+}
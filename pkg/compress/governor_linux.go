@@ -0,0 +1,71 @@
+//go:build linux
+
+package compress
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readCPUTimes reads the machine's CPU times from the first line of
+// /proc/stat and the process's from /proc/self/stat, both in clock ticks
+func readCPUTimes() (cpuTimes, error) {
+	var t cpuTimes
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return t, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		return t, fmt.Errorf("/proc/stat: empty")
+	}
+	// cpu user nice system idle iowait irq softirq steal (guest time is
+	// counted in user)
+	fields := strings.Fields(scanner.Text())
+	if len(fields) < 9 || fields[0] != "cpu" {
+		return t, fmt.Errorf("/proc/stat: unexpected line %q", scanner.Text())
+	}
+	values, err := parseTicks(fields[1:9])
+	if err != nil {
+		return t, fmt.Errorf("/proc/stat: %w", err)
+	}
+	for _, v := range values {
+		t.total += v
+	}
+	t.idle, t.iowait = values[3], values[4]
+
+	stat, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return t, err
+	}
+	// pid (comm) state ... utime stime: the name may hold spaces, so the
+	// fields are counted from its closing parenthesis
+	end := strings.LastIndexByte(string(stat), ')')
+	fields = strings.Fields(string(stat[end+1:]))
+	if end < 0 || len(fields) < 13 {
+		return t, fmt.Errorf("/proc/self/stat: unexpected content")
+	}
+	values, err = parseTicks(fields[11:13])
+	if err != nil {
+		return t, fmt.Errorf("/proc/self/stat: %w", err)
+	}
+	t.self = values[0] + values[1]
+	return t, nil
+}
+
+// parseTicks parses clock tick counters
+func parseTicks(fields []string) ([]uint64, error) {
+	values := make([]uint64, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package compress
+
+import "errors"
+
+// readCPUTimes has no load to read on this platform, so the governor keeps
+// MaxThreads workers
+func readCPUTimes() (cpuTimes, error) {
+	return cpuTimes{}, errors.ErrUnsupported
+}
@@ -0,0 +1,114 @@
+// pkg/compress/governor_test.go
+package compress
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadBetween(t *testing.T) {
+	a := cpuTimes{total: 1000, idle: 600, iowait: 100, self: 100}
+	b := cpuTimes{total: 2000, idle: 900, iowait: 300, self: 300}
+	// 500 busy ticks, 200 of them ours
+	load := loadBetween(a, b)
+	if load.others != 0.3 || load.iowait != 0.2 {
+		t.Errorf("load %+v, want others 0.3 and iowait 0.2", load)
+	}
+	if load := loadBetween(b, b); load != (systemLoad{}) {
+		t.Errorf("no time elapsed: load %+v", load)
+	}
+}
+
+func TestGovernorAdjust(t *testing.T) {
+	g := newGovernor(2, 8)
+	busy := systemLoad{others: 0.9}
+	quiet := systemLoad{others: 0.1, iowait: 0.05}
+
+	for _, want := range []int{6, 5, 4, 3, 2, 2} {
+		g.adjust(busy)
+		if g.limit != want {
+			t.Fatalf("busy: limit %d, want %d", g.limit, want)
+		}
+	}
+	g.adjust(systemLoad{iowait: 0.5})
+	g.adjust(systemLoad{others: 0.4}) // neither busy nor quiet
+	if g.limit != 2 {
+		t.Errorf("limit %d after a steady load, want 2", g.limit)
+	}
+	g.adjust(quiet)
+	if g.limit != 3 || g.lowest != 2 || g.adjustments != 6 {
+		t.Errorf("quiet: limit %d, lowest %d, %d adjustments", g.limit, g.lowest, g.adjustments)
+	}
+}
+
+func TestGovernorLimitsWorkers(t *testing.T) {
+	g := newGovernor(1, 4)
+	for range 3 {
+		g.adjust(systemLoad{others: 0.9})
+	}
+	if g.limit != 1 {
+		t.Fatalf("limit %d, want 1", g.limit)
+	}
+
+	var active, peak atomic.Int32
+	done := make(chan struct{})
+	for range 4 {
+		go func() {
+			g.enter()
+			n := active.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			active.Add(-1)
+			g.leave()
+			done <- struct{}{}
+		}()
+	}
+	for range 4 {
+		<-done
+	}
+	if peak.Load() != 1 {
+		t.Errorf("%d files at once, want 1", peak.Load())
+	}
+}
+
+func TestCompressAdaptiveThreads(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in")
+	if err := os.MkdirAll(input, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(input, name), []byte("some content "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Compress(&Options{
+		InputPath:       input,
+		OutputPath:      filepath.Join(dir, "out.gdelta"),
+		MaxThreads:      4,
+		AdaptiveThreads: true,
+		ChunkSize:       64 * 1024,
+		Quiet:           true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Too short for a sample: the governor kept every worker
+	if result.FilesProcessed != 3 || result.ThreadsLowest != 4 || result.ThreadAdjustments != 0 {
+		t.Errorf("%d files, lowest %d threads, %d adjustments", result.FilesProcessed, result.ThreadsLowest, result.ThreadAdjustments)
+	}
+
+	if err := (&Options{InputPath: input, AdaptiveThreads: true, UseZipFormat: true}).Validate(); !errors.Is(err, ErrAdaptiveThreadsFormat) {
+		t.Errorf("zip: got %v, want ErrAdaptiveThreadsFormat", err)
+	}
+}
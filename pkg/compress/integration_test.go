@@ -1,10 +1,14 @@
 package compress_test
 
 import (
+	"bufio"
+	"bytes"
 	"crypto/md5"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
 	"testing"
@@ -675,3 +679,284 @@ func TestCustomFilesNoCommonBase(t *testing.T) {
 		t.Errorf("Expected 2 extracted files, got %d", len(extractedFiles))
 	}
 }
+
+// TestJSONProgress checks that JSON progress output is one valid event per
+// line, from start to complete, for both compression and decompression
+func TestJSONProgress(t *testing.T) {
+	sourceDir := t.TempDir()
+	archivePath := filepath.Join(t.TempDir(), "test.gdelta")
+	for i := 0; i < 5; i++ {
+		if err := os.WriteFile(filepath.Join(sourceDir, fmt.Sprintf("f%d.txt", i)), []byte("json progress"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	readEvents := func(buf *bytes.Buffer) []map[string]interface{} {
+		t.Helper()
+		var events []map[string]interface{}
+		scanner := bufio.NewScanner(buf)
+		for scanner.Scan() {
+			var event map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+			}
+			events = append(events, event)
+		}
+		return events
+	}
+
+	var compressOut bytes.Buffer
+	_, err := compress.Compress(&compress.Options{
+		InputPath:  sourceDir,
+		OutputPath: archivePath,
+		MaxThreads: 2,
+		Quiet:      true,
+	}, compress.JSONProgressCallback(&compressOut))
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+
+	events := readEvents(&compressOut)
+	if len(events) < 2 || events[0]["type"] != "start" || events[len(events)-1]["type"] != "complete" {
+		t.Fatalf("unexpected compress events: %v", events)
+	}
+	completed := 0
+	for _, e := range events {
+		if e["type"] == "file_complete" {
+			completed++
+		}
+	}
+	if completed != 5 {
+		t.Errorf("file_complete events = %d, want 5", completed)
+	}
+
+	var decompressOut bytes.Buffer
+	_, err = decompress.Decompress(&decompress.Options{
+		InputPath:  archivePath,
+		OutputPath: t.TempDir(),
+		Quiet:      true,
+	}, decompress.JSONProgressCallback(&decompressOut))
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	events = readEvents(&decompressOut)
+	if len(events) < 2 || events[0]["type"] != "start" || events[len(events)-1]["type"] != "complete" {
+		t.Fatalf("unexpected decompress events: %v", events)
+	}
+}
+
+func TestCodecValidation(t *testing.T) {
+	for name, tc := range map[string]struct {
+		opts compress.Options
+		want error
+	}{
+		"unknown":       {compress.Options{Codec: "lzma"}, compress.ErrUnknownCodec},
+		"store":         {compress.Options{Codec: "store"}, compress.ErrUnknownCodec},
+		"zip":           {compress.Options{Codec: "lz4", UseZipFormat: true}, compress.ErrCodecFormat},
+		"dictionary":    {compress.Options{Codec: "brotli", UseDictionary: true}, compress.ErrCodecNoDictionary},
+		"level":         {compress.Options{Codec: "deflate", Level: 12}, compress.ErrInvalidLevelCodec},
+		"snappy levels": {compress.Options{Codec: "snappy", Level: 19}, nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc.opts.InputPath = "in"
+			if err := tc.opts.Validate(); !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+// TestWindowLog checks that a wide window finds a repeat further back than
+// the default window reaches, and that the archive still round-trips
+func TestWindowLog(t *testing.T) {
+	inputDir := t.TempDir()
+	// Incompressible on its own, so only the repeat can shrink it
+	block := make([]byte, 10*1024*1024)
+	rand.NewChaCha8([32]byte{1}).Read(block)
+	content := append(append([]byte{}, block...), block...)
+	if err := os.WriteFile(filepath.Join(inputDir, "image.bin"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	compressed := make(map[string]uint64)
+	for name, opts := range map[string]compress.Options{
+		"default": {},
+		"long":    {EnableLongDistanceMatching: true},
+	} {
+		archivePath := filepath.Join(t.TempDir(), name+".gdelta")
+		opts.InputPath = inputDir
+		opts.OutputPath = archivePath
+		opts.Level = 1
+		opts.NoEntropyCheck = true // random on purpose
+		opts.Quiet = true
+		result, err := compress.Compress(&opts, nil)
+		if err != nil {
+			t.Fatalf("%s: compress: %v", name, err)
+		}
+		compressed[name] = result.CompressedSize
+
+		outDir := t.TempDir()
+		if _, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: outDir, Quiet: true}, nil); err != nil {
+			t.Fatalf("%s: decompress: %v", name, err)
+		}
+		got, err := os.ReadFile(filepath.Join(outDir, "image.bin"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Errorf("%s: content mismatch", name)
+		}
+	}
+	if compressed["long"]*3/2 > compressed["default"] {
+		t.Errorf("expected the long window to catch the repeat: %d vs %d bytes", compressed["long"], compressed["default"])
+	}
+
+	for name, tc := range map[string]struct {
+		opts compress.Options
+		want error
+	}{
+		"too small": {compress.Options{WindowLog: 9}, compress.ErrInvalidWindowLog},
+		"too large": {compress.Options{WindowLog: 30}, compress.ErrInvalidWindowLog},
+		"codec":     {compress.Options{Codec: "lz4", EnableLongDistanceMatching: true}, compress.ErrWindowZstdOnly},
+		"xz":        {compress.Options{UseXzFormat: true, WindowLog: 24}, compress.ErrWindowZstdOnly},
+		"explicit":  {compress.Options{WindowLog: 24, EnableLongDistanceMatching: true}, nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc.opts.InputPath = "in"
+			if err := tc.opts.Validate(); !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+// TestStreamingAppend mixes small and large files across workers so entries
+// are both streamed into the archive and queued behind a stream, then checks
+// the archive round-trips and no temp file is left behind
+func TestStreamingAppend(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	inputDir := t.TempDir()
+	want := make(map[string][]byte)
+	for i := range 40 {
+		want[fmt.Sprintf("small/%02d.txt", i)] = bytes.Repeat([]byte(fmt.Sprintf("line %d\n", i)), 100+i*50)
+	}
+	for i := range 4 {
+		want[fmt.Sprintf("large/%d.log", i)] = bytes.Repeat([]byte(fmt.Sprintf("event %d happened\n", i)), 200000)
+	}
+	for rel, content := range want {
+		full := filepath.Join(inputDir, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+	result, err := compress.Compress(&compress.Options{
+		InputPath:   inputDir,
+		OutputPath:  archivePath,
+		MaxThreads:  4,
+		Parallelism: compress.ParallelismFile,
+		Quiet:       true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if !result.Success() {
+		t.Fatalf("compress errors: %v", result.Errors)
+	}
+	if leftover, _ := os.ReadDir(tmp); len(leftover) != 0 {
+		t.Errorf("expected no temp files, found %d", len(leftover))
+	}
+
+	outDir := t.TempDir()
+	dresult, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: outDir, Quiet: true}, nil)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	if dresult.FilesProcessed != len(want) {
+		t.Fatalf("expected %d files, got %d (errors: %v)", len(want), dresult.FilesProcessed, dresult.Errors)
+	}
+	for rel, content := range want {
+		got, err := os.ReadFile(filepath.Join(outDir, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Errorf("%s: content mismatch", rel)
+		}
+	}
+}
+
+func TestMemoryBudget(t *testing.T) {
+	inputDir := t.TempDir()
+	want := make(map[string][]byte)
+	for i := range 30 {
+		want[fmt.Sprintf("small/%02d.txt", i)] = bytes.Repeat([]byte(fmt.Sprintf("line %d\n", i)), 1000+i*500)
+	}
+	want["large/huge.log"] = bytes.Repeat([]byte("a long repeated log line for segments\n"), 200000)
+	for rel, content := range want {
+		full := filepath.Join(inputDir, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts compress.Options
+	}{
+		{"gdelta01", compress.Options{SegmentSize: 1 << 20}},
+		{"gdelta02", compress.Options{ChunkSize: 64 << 10}},
+		{"gdelta03", compress.Options{UseDictionary: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			const budget = 96 << 20
+			opts := tc.opts
+			opts.InputPath = inputDir
+			opts.OutputPath = filepath.Join(t.TempDir(), "a.gdelta")
+			opts.MaxThreads = 32
+			opts.MemoryBudget = budget
+			opts.Quiet = true
+
+			result, err := compress.Compress(&opts, nil)
+			if err != nil {
+				t.Fatalf("compress: %v", err)
+			}
+			if !result.Success() {
+				t.Fatalf("compress errors: %v", result.Errors)
+			}
+			if opts.MaxThreads >= 32 {
+				t.Errorf("expected the budget to lower MaxThreads, got %d", opts.MaxThreads)
+			}
+			if result.MemoryPeak == 0 || result.MemoryPeak > budget {
+				t.Errorf("memory peak %d outside budget %d", result.MemoryPeak, budget)
+			}
+
+			outDir := t.TempDir()
+			dresult, err := decompress.Decompress(&decompress.Options{InputPath: opts.OutputPath, OutputPath: outDir, Quiet: true}, nil)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			if dresult.FilesProcessed != len(want) {
+				t.Fatalf("expected %d files, got %d (errors: %v)", len(want), dresult.FilesProcessed, dresult.Errors)
+			}
+			for rel, content := range want {
+				got, err := os.ReadFile(filepath.Join(outDir, rel))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, content) {
+					t.Errorf("%s: content mismatch", rel)
+				}
+			}
+		})
+	}
+}
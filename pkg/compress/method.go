@@ -0,0 +1,141 @@
+// pkg/compress/method.go
+package compress
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/pool"
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultStoreExtensions lists formats that are already compressed. Running
+// them through zstd or deflate again costs CPU and rarely saves a byte, so
+// they are stored as-is unless Options.CompressAll is set.
+var DefaultStoreExtensions = []string{
+	// Images
+	".jpg", ".jpeg", ".png", ".gif", ".webp", ".heic", ".avif",
+	// Video and audio
+	".mp4", ".m4v", ".mkv", ".mov", ".avi", ".webm",
+	".mp3", ".m4a", ".aac", ".ogg", ".opus", ".flac",
+	// Archives and compressed streams
+	".zip", ".gz", ".tgz", ".bz2", ".xz", ".txz", ".zst", ".lz4", ".7z", ".rar", ".br",
+	".jar", ".apk", ".gdelta",
+	// Fonts
+	".woff2",
+}
+
+// methodFor picks how a file is written to the archive: stored as-is when
+// its extension or sniffed type marks it as already compressed or a sample
+// of its content looks random, with the codec of its type otherwise. Text
+// isn't sampled.
+func (o *Options) methodFor(task fileTask) format.Method {
+	codec := o.typeCodec(task.Type)
+	if o.CompressAll {
+		return codec
+	}
+	if o.storedByExtension(task.RelPath) || compressedType(task.Type) {
+		return format.MethodStore
+	}
+	if !o.NoEntropyCheck && !strings.HasPrefix(task.Type, "text/") &&
+		looksIncompressible(task.AbsPath, task.OrigSize, o.EntropyThreshold) {
+		return format.MethodStore
+	}
+	return codec
+}
+
+// typeCodec returns the registry ID of the codec for files of type mime:
+// the TypeCodecs entry for the type or its major type ("text/*"), Codec
+// otherwise
+func (o *Options) typeCodec(mime string) format.Method {
+	if mime != "" && len(o.TypeCodecs) > 0 {
+		major, _, _ := strings.Cut(mime, "/")
+		for _, key := range []string{mime, major + "/*"} {
+			if name, ok := o.TypeCodecs[key]; ok {
+				if id, _, ok := format.CodecByName(name); ok {
+					return id
+				}
+			}
+		}
+	}
+	return o.codecMethod()
+}
+
+// storedByExtension reports whether files named relPath are stored for
+// their extension alone
+func (o *Options) storedByExtension(relPath string) bool {
+	if o.CompressAll {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(relPath))
+	return ext != "" && (slices.Contains(DefaultStoreExtensions, ext) || slices.Contains(o.StoreExtensions, ext))
+}
+
+// codecMethod returns the registry ID of the configured codec (zstd when
+// unset; Validate rejects unknown names)
+func (o *Options) codecMethod() format.Method {
+	id, _, ok := format.CodecByName(o.Codec)
+	if !ok {
+		return format.MethodZstd
+	}
+	return id
+}
+
+// encodeEntry compresses src into dst with method. zstd goes through the
+// worker's encoder (reused via Reset); other codecs come from the registry.
+func encodeEntry(dst io.Writer, src io.Reader, method format.Method, level int, enc *zstd.Encoder) error {
+	switch method {
+	case format.MethodStore:
+		if _, err := pool.Copy(dst, src); err != nil {
+			return fmt.Errorf("copy: %w", err)
+		}
+		return nil
+
+	case format.MethodZstd:
+		enc.Reset(dst)
+		if _, err := io.Copy(enc, src); err != nil {
+			enc.Close()
+			return fmt.Errorf("copy/compress failed: %w", err)
+		}
+		// Flush and finalize the frame (encoder stays reusable after Reset)
+		if err := enc.Close(); err != nil {
+			return fmt.Errorf("close zstd encoder: %w", err)
+		}
+		return nil
+
+	default:
+		codec, ok := format.LookupCodec(method)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownCodec, method)
+		}
+		w, err := codec.NewWriter(dst, level)
+		if err != nil {
+			return fmt.Errorf("create %s writer: %w", codec.Name(), err)
+		}
+		if _, err := pool.Copy(w, src); err != nil {
+			w.Close()
+			return fmt.Errorf("copy/compress failed: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("close %s writer: %w", codec.Name(), err)
+		}
+		return nil
+	}
+}
+
+// encodeChunk compresses one GDELTA02 chunk, appending to dst. zstd uses the
+// worker's encoder via EncodeAll.
+func encodeChunk(dst, src []byte, method format.Method, level int, enc *zstd.Encoder) ([]byte, error) {
+	if method == format.MethodZstd {
+		return enc.EncodeAll(src, dst), nil
+	}
+	codec, ok := format.LookupCodec(method)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCodec, method)
+	}
+	return format.EncodeBlock(codec, dst, src, level)
+}
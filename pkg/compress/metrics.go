@@ -0,0 +1,33 @@
+// pkg/compress/metrics.go
+package compress
+
+import (
+	"time"
+
+	"github.com/creativeyann17/go-delta/pkg/metrics"
+)
+
+// recordFile counts one compressed file; workers call it as they finish.
+// Dry runs write nothing and are not recorded.
+func recordFile(opts *Options, origSize uint64) {
+	if opts.DryRun {
+		return
+	}
+	metrics.FilesProcessed.Inc(metrics.OpCompress)
+	metrics.BytesIn.Add(float64(origSize))
+}
+
+// recordRun records the totals only known once a run is over
+func recordRun(opts *Options, result *Result, start time.Time) {
+	if opts.DryRun {
+		return
+	}
+	metrics.OperationDuration.Observe(time.Since(start).Seconds(), metrics.OpCompress)
+	metrics.FilesFailed.Add(float64(len(result.Errors)), metrics.OpCompress)
+	metrics.BytesOut.Add(float64(result.CompressedSize))
+	if result.TotalChunks > 0 {
+		metrics.Chunks.Add(float64(result.TotalChunks))
+		metrics.ChunksDeduped.Add(float64(result.DedupedChunks))
+		metrics.DedupRatio.Set(result.DedupRatio() / 100)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build darwin
+
+package compress
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// adviseNoCache turns off caching for the reads of f (F_NOCACHE)
+func adviseNoCache(f *os.File) {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return
+	}
+	rc.Control(func(fd uintptr) {
+		unix.FcntlInt(fd, unix.F_NOCACHE, 1)
+	})
+}
+
+// dropCache does nothing: F_NOCACHE keeps the pages out already
+func dropCache(f *os.File, off, n int64) {}
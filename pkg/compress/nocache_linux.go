@@ -0,0 +1,31 @@
+//go:build linux
+
+package compress
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// adviseNoCache does nothing: Linux drops the pages as they are read
+func adviseNoCache(f *os.File) {}
+
+// dropCache asks the kernel to evict the cached pages of f from off for n
+// bytes (to the end when n is 0), widened to whole pages so pages shared
+// by two reads go too
+func dropCache(f *os.File, off, n int64) {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return
+	}
+	page := int64(os.Getpagesize())
+	start := off &^ (page - 1)
+	var length int64
+	if n > 0 {
+		length = (off+n+page-1)&^(page-1) - start
+	}
+	rc.Control(func(fd uintptr) {
+		unix.Fadvise(int(fd), start, length, unix.FADV_DONTNEED)
+	})
+}
@@ -0,0 +1,99 @@
+//go:build linux
+
+package compress
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// residentPages counts the pages of path held in the page cache
+func residentPages(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := unix.Mmap(int(f.Fd()), 0, int(info.Size()), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Munmap(data)
+	page := os.Getpagesize()
+	vec := make([]byte, (len(data)+page-1)/page)
+	if _, _, errno := unix.Syscall(unix.SYS_MINCORE, uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)), uintptr(unsafe.Pointer(&vec[0]))); errno != 0 {
+		t.Fatal(errno)
+	}
+	var n int
+	for _, v := range vec {
+		n += int(v & 1)
+	}
+	return n
+}
+
+func TestNoPageCache(t *testing.T) {
+	input := t.TempDir()
+	data := make([]byte, 4<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	path := filepath.Join(input, "big.bin")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// tmpfs and some other filesystems keep their pages whatever we ask
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unix.Fsync(int(f.Fd()))
+	dropCache(f, 0, 0)
+	f.Close()
+	if residentPages(t, path) > 0 {
+		t.Skip("the filesystem of the temp dir keeps its pages cached")
+	}
+
+	for name, mode := range map[string]func(*Options){
+		"gdelta01": func(o *Options) { o.WriteManifest = true },
+		"gdelta02": func(o *Options) { o.ChunkSize = 64 << 10 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := os.ReadFile(path); err != nil {
+				t.Fatal(err)
+			}
+			opts := &Options{
+				InputPath:   input,
+				OutputPath:  filepath.Join(t.TempDir(), "out.gdelta"),
+				MaxThreads:  1,
+				NoPageCache: true,
+				Quiet:       true,
+			}
+			mode(opts)
+			if _, err := Compress(opts, nil); err != nil {
+				t.Fatal(err)
+			}
+			if n := residentPages(t, path); n > 0 {
+				t.Errorf("%d pages still cached", n)
+			}
+		})
+	}
+
+	// The content read is unaffected
+	task := fileTask{AbsPath: path, sources: &sourceSet{noPageCache: true}}
+	got, err := hashFile(task, make([]byte, 32<<10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := hashFile(fileTask{AbsPath: path}, make([]byte, 32<<10))
+	if err != nil || got != want {
+		t.Errorf("hash %x, want %x (%v)", got, want, err)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package compress
+
+import "os"
+
+// adviseNoCache does nothing: files are read through the cache here
+func adviseNoCache(f *os.File) {}
+
+// dropCache does nothing: files are read through the cache here
+func dropCache(f *os.File, off, n int64) {}
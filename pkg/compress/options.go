@@ -2,8 +2,19 @@
 package compress
 
 import (
+	"fmt"
 	"io"
+	"maps"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
+
+	"github.com/creativeyann17/go-delta/internal/chunker"
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
 )
 
 // Parallelism defines the parallelism strategy
@@ -24,6 +35,20 @@ const (
 	ParallelismFile Parallelism = "file"
 )
 
+// ChunkingMode defines how GDELTA02 cuts files into chunks
+type ChunkingMode string
+
+const (
+	// ChunkingCDC cuts content-defined chunks with FastCDC, so repeated
+	// data is found even when it moves
+	ChunkingCDC ChunkingMode = "cdc"
+
+	// ChunkingFixed cuts fixed-size blocks of ChunkSize, aligned with the
+	// blocks of disk images, block devices and database files
+	// Best when: data changes in place and never shifts
+	ChunkingFixed ChunkingMode = "fixed"
+)
+
 // Options configures the compression behavior
 type Options struct {
 	// Input path (file or directory)
@@ -33,56 +58,282 @@ type Options struct {
 	// Files allows library users to provide a custom list of files/folders to compress
 	// When set, InputPath is ignored
 	// Each path can be absolute or relative, file or directory
-	// This option is for library use only (not exposed in CLI)
+	// Directories honor the ignore files of their tree like InputPath;
+	// files listed one by one, those of their own directory (see
+	// NoIgnoreInFiles). IgnoreFiles and Excludes apply to both.
+	// The CLI fills it from --files-from
 	Files []string
 
+	// PathMode derives the archive paths of Files entries: PathBasename
+	// (the last element of each entry), PathCommonPrefix (relative to the
+	// directory holding all of them), PathFull (the absolute path without
+	// its root) or PathCustom (PathMapping). Directories keep their tree
+	// below that name either way.
+	// Default: PathBasename
+	PathMode PathMode
+
+	// PathMapping maps source path prefixes to archive path prefixes for
+	// PathCustom, e.g. {"/home/me/project": "project", "/etc/nginx":
+	// "config/nginx"}. The longest prefix covering an entry wins; an entry
+	// no prefix covers fails the scan with ErrPathUnmapped.
+	// Default: nil
+	PathMapping map[string]string
+
+	// NoIgnoreInFiles takes the entries of Files as given: .gitignore and
+	// .godeltaignore files are not read for them, only IgnoreFiles and
+	// Excludes apply
+	// Default: false
+	NoIgnoreInFiles bool
+
+	// FromTar reads the files to archive from this tar stream instead of
+	// InputPath or Files. Plain, gzip and zstd compressed tars are
+	// recognized by their magic bytes. Regular file entries are chunked
+	// and deduplicated into a GDELTA02 archive in stream order; other
+	// entries are skipped. Entry data is never buffered whole, so the tar
+	// can be of any size.
+	// Cannot be combined with UseDictionary or a standard archive format
+	// Default: nil
+	FromTar io.Reader
+
+	// FromStream stores this stream (stdin, a database dump...) as a single
+	// GDELTA02 entry named StreamName, chunked as it is read so it can be
+	// of any size. An InputPath naming a block device (/dev/sdb1) is read
+	// the same way, as an entry named after the device.
+	// Cannot be combined with FromTar, UseDictionary or a standard archive
+	// format
+	// Default: nil
+	FromStream io.Reader
+
+	// StreamName is the entry name of FromStream
+	// "" = DefaultStreamName, or the device name for a block device
+	// Default: ""
+	StreamName string
+
 	// Output archive path
 	OutputPath string
 
+	// SplitByFolder writes one archive per top-level directory of the
+	// input from a single scan, named after OutputPath with the directory
+	// added: backup.gdelta becomes backup_home.gdelta, backup_etc.gdelta...
+	// Files at the top of the input go to OutputPath itself. Entries keep
+	// their whole path, so each archive restores into the same tree.
+	// Archives are written one after another with shared progress events;
+	// dedup and dictionaries don't cross archives. A canceled or failed run
+	// removes the archives already written. GDELTA formats with a file or
+	// directory input only.
+	// Default: false
+	SplitByFolder bool
+
 	// Maximum number of concurrent compression threads
 	// Default: runtime.NumCPU()
 	MaxThreads int
 
+	// AdaptiveThreads lets a governor shrink the number of files compressed
+	// at once down to MinThreads while other processes keep the CPUs or the
+	// disks busy, and grow it back to MaxThreads once the machine is quiet,
+	// so long runs back off on a busy machine. The load is sampled every
+	// two seconds (Linux; elsewhere MaxThreads are kept). GDELTA formats
+	// with a file or directory input only.
+	// Default: false
+	AdaptiveThreads bool
+
+	// MinThreads is the fewest files AdaptiveThreads compresses at once
+	// 0 = 1
+	// Default: 0
+	MinThreads int
+
 	// Parallelism strategy: "auto", "folder", or "file"
 	// Default: "auto"
 	Parallelism Parallelism
 
+	// FileOrder regroups and reorders the files before they are handed to
+	// workers, e.g. OrderByType to chunk files of a type one after another
+	// nil = walk order, folder by folder
+	// Default: nil
+	FileOrder FileOrder
+
 	// Maximum memory per thread for in-memory compression (bytes).
-	// GDELTA01 mode: files up to this size are compressed in RAM and written
-	// straight to the archive, skipping the temp-file round trip (each worker
-	// may hold up to this much compressed data).
-	// 0 = always stream through temp files (bounded memory)
+	// GDELTA01 mode: files up to this size (and always up to 1 MiB) are
+	// compressed in RAM, then appended to the archive. Larger files stream
+	// straight into the archive when no other entry is being written, and
+	// through a temp file otherwise. Each worker may hold up to this much
+	// compressed data, plus as much again queued for the archive.
+	// 0 = only files up to 1 MiB are buffered in RAM
 	// Default: 0
 	MaxThreadMemory uint64
 
+	// MemoryBudget caps the memory the run allocates for compression, in
+	// bytes: worker encoders and buffers, entries queued for the archive,
+	// segments in flight and dictionary samples all acquire their estimated
+	// size from it before allocating, and wait when it is spent. MaxThreads
+	// is lowered until one worker each fits. The GDELTA02 chunk index and
+	// the file list grow with the input and are not counted.
+	// GDELTA formats only.
+	// 0 = unlimited
+	// Default: 0
+	MemoryBudget uint64
+
 	// Chunk size for content-based deduplication (bytes)
 	// 0 = disabled (traditional file-level compression)
 	// Default: 0
 	ChunkSize uint64
 
+	// AutoChunkSize picks ChunkSize among AutoTuneChunkSizes before the
+	// run, the way AutoLevel picks the level. Chunk bounds are the
+	// defaults of the size picked.
+	// Default: false
+	AutoChunkSize bool
+
+	// ChunkMinSize and ChunkMaxSize bound the size of content-defined
+	// chunks around ChunkSize: narrow bounds suit aligned data such as VM
+	// images, wide ones shifting data such as source trees. Custom bounds
+	// are recorded in the GDELTA02 header.
+	// 0 = ChunkSize/4 and ChunkSize*4
+	// Default: 0
+	ChunkMinSize uint64
+	ChunkMaxSize uint64
+
+	// ChunkNormalization is the FastCDC normalization level (1-3): how
+	// strongly chunk sizes are pulled toward ChunkSize.
+	// ChunkNormalizationOff (-1) disables it. Recorded with the bounds.
+	// 0 = 2
+	// Default: 0
+	ChunkNormalization int
+
+	// ChunkingMode selects content-defined chunks ("cdc") or fixed-size
+	// blocks of ChunkSize ("fixed", e.g. 4MB for a VM image or a database
+	// file). Fixed blocks have no bounds or normalization. Recorded in the
+	// GDELTA02 header.
+	// "" = "cdc"
+	// Default: ""
+	ChunkingMode ChunkingMode
+
+	// ChunkDictionary trains a small zstd dictionary from chunk-sized
+	// samples of the input before the run and compresses every chunk with
+	// it. The dictionary is stored in the GDELTA02 header. Archives of many
+	// small similar chunks (configs, logs, JSON records) compress much
+	// better than with per-chunk zstd alone; large chunks gain little.
+	// Needs ChunkSize, the zstd codec and files or directories as input.
+	// Default: false
+	ChunkDictionary bool
+
+	// ReferenceDirs are directories holding a previous version of the
+	// input, e.g. yesterday's backup extracted on a NAS. Their files are
+	// split with the run's chunker before it starts; chunks found in them
+	// aren't stored but recorded as references to where they lie, so the
+	// archive only holds what changed (a forward delta). Restoring it
+	// needs the directories, unchanged (decompress.Options.ReferenceDirs).
+	// Needs ChunkSize and files or directories as input.
+	// Default: nil
+	ReferenceDirs []string
+
 	// Maximum chunk store size in MB (bounds memory usage for deduplication)
 	// Calculated as: maxChunks = ChunkStoreSize / (ChunkSize / 1MB)
 	// 0 = unlimited (store all unique chunks)
 	// Default: 0
 	ChunkStoreSize uint64
 
+	// ChunkIndexDir keeps the GDELTA02 chunk index in a hash table file in
+	// this directory instead of RAM, so the index of a dataset of any size
+	// fits (about 100 bytes of disk per unique chunk). The file is removed
+	// when the run ends. Lookups of recent chunks are served by the chunk
+	// store cache, which defaults to DefaultDiskIndexCacheChunks entries
+	// when ChunkStoreSize is 0.
+	// "" = in-memory index
+	// Default: ""
+	ChunkIndexDir string
+
 	// Compression level (1-22 for zstd, 1-9 for zip deflate)
 	// 1=fastest, 9=balanced, 19+=maximum compression (zstd only)
 	// Default: 5
 	Level int
 
+	// AutoLevel picks Level before the run: the first AutoTuneSample bytes
+	// of the input are compressed with each of AutoTuneLevels the codec
+	// accepts, and the level saving the most bytes per second is kept for
+	// the whole run (see Result.AutoTune). GDELTA formats with a file or
+	// directory input only.
+	// Default: false
+	AutoLevel bool
+
+	// AutoTuneSample is how much of the input, from its first files, each
+	// AutoLevel or AutoChunkSize candidate compresses
+	// 0 = DefaultAutoTuneSample
+	// Default: 0
+	AutoTuneSample uint64
+
+	// Codec compresses GDELTA file entries and chunks: "zstd", "deflate",
+	// "lz4", "brotli" or "snappy" (see Codecs). Level is
+	// interpreted by the codec (lz4 1-9 with 1 = fast mode, brotli 1-11,
+	// snappy has no levels). Readers pick the decoder recorded in the archive.
+	// Cannot be combined with UseDictionary or a standard archive format
+	// Default: "zstd"
+	Codec string
+
+	// WindowLog sets the zstd window to 1<<WindowLog bytes (10-29): how far
+	// back matches are searched. Larger windows find repeats across big,
+	// similar files in GDELTA01 without chunking, at the cost of a window
+	// sized buffer per encoder (and per decoder on extraction).
+	// GDELTA02 chunks are compressed one by one, so there the window never
+	// reaches past a chunk and this setting is ignored
+	// 0 = chosen by the level (8 MiB at most)
+	// Default: 0
+	WindowLog int
+
+	// EnableLongDistanceMatching widens the zstd window for long-range
+	// repeats. The Go encoder has no separate LDM pass, so this selects a
+	// 128 MiB window (WindowLog 27, like zstd --long) unless WindowLog is set
+	// Default: false
+	EnableLongDistanceMatching bool
+
+	// SegmentSize splits GDELTA01 files larger than this many bytes into
+	// independent zstd frames, compressed in parallel by up to MaxThreads
+	// goroutines and indexed by a seek table so extraction is parallel too.
+	// Raised to four zstd windows and capped by MaxThreadMemory/2
+	// (1 MiB-1 GiB). zstd codec only.
+	// 0 = DefaultSegmentSize (64 MiB)
+	SegmentSize uint64
+
+	// DisableSegments compresses every file as a single zstd stream
+	// Default: false
+	DisableSegments bool
+
 	// UseZipFormat creates a standard ZIP archive instead of GDELTA format
 	// Uses Deflate compression (universally compatible)
 	// Cannot be combined with ChunkSize (deduplication not supported in ZIP mode)
 	// Default: false
 	UseZipFormat bool
 
-	// UseXzFormat creates standard .tar.xz archives instead of GDELTA format
-	// Uses LZMA2 compression (best compression ratio, slower than zstd)
+	// SingleZip writes one ZIP at OutputPath instead of one per thread
+	// (archive_01.zip, ...). Files are still deflated in parallel. Only
+	// affects ZIP.
+	// Default: false
+	SingleZip bool
+
+	// UseXzFormat creates a standard .tar.xz archive instead of GDELTA format
+	// Uses LZMA2 compression (best compression ratio, slower than zstd),
+	// written as one multi-block stream compressed by MaxThreads workers
 	// Cannot be combined with ChunkSize or UseDictionary
 	// Default: false
 	UseXzFormat bool
 
+	// XzParts writes one .tar.xz per thread (archive_01.tar.xz, ...) instead
+	// of a single stream. Only affects XZ.
+	// Default: false
+	XzParts bool
+
+	// UseTarGzFormat creates standard .tar.gz archives instead of GDELTA format
+	// Uses gzip (deflate) compression, 1-9 levels
+	// Cannot be combined with ChunkSize or UseDictionary
+	// Default: false
+	UseTarGzFormat bool
+
+	// Use7zFormat creates standard .7z archives instead of GDELTA format
+	// Each part is one solid LZMA2 stream, 1-9 levels as for XZ
+	// Cannot be combined with ChunkSize or UseDictionary
+	// Default: false
+	Use7zFormat bool
+
 	// UseDictionary enables GDELTA03 dictionary-based compression
 	// Trains a zstd dictionary from input files for better compression
 	// Especially effective for many small files with common patterns
@@ -90,6 +341,199 @@ type Options struct {
 	// Default: false
 	UseDictionary bool
 
+	// StoreExtensions are extra file extensions (e.g. ".dat") stored without
+	// compression, on top of DefaultStoreExtensions. Matching is
+	// case-insensitive; a missing leading dot is added.
+	// Applies to GDELTA01, GDELTA03 and ZIP (GDELTA02 chunks are shared
+	// across files and always compressed)
+	StoreExtensions []string
+
+	// CompressAll disables the store policy: every file is compressed,
+	// including already-compressed formats
+	// Default: false
+	CompressAll bool
+
+	// PackSmallFiles packs files up to this size (bytes) into shared
+	// compressed frames of up to PackFrameSize, each file recorded as an
+	// offset and length within its frame, instead of an entry and a frame
+	// per file. Empty files are packed without being opened. Files stored
+	// by extension are not packed. GDELTA01 only.
+	// 0 = no packing; at most PackFrameSize
+	// Default: 0
+	PackSmallFiles uint64
+
+	// DedupFiles stores a file with the same content as an earlier one
+	// (a hardlink of it or a copy, found by size then SHA-256 before
+	// compressing) as a link to that file's entry, with no data of its
+	// own. Decompression restores links as copies, or as hardlinks with
+	// decompress.Options.Hardlinks. GDELTA01 and GDELTA03 only (GDELTA02
+	// already shares chunks across files).
+	// Default: false
+	DedupFiles bool
+
+	// SniffTypes reads the first 512 bytes of every file during the scan
+	// to detect its type from its content (magic numbers, then text
+	// detection), as a MIME type such as "image/png" or "text/plain".
+	// Files of an already-compressed type are stored like
+	// StoreExtensions, text skips the entropy sampling, TypeCodecs pick
+	// codecs by type, Result.Types counts the types, and GDELTA02 and
+	// GDELTA03 archives record each file's type (see godelta list).
+	// Default: false
+	SniffTypes bool
+
+	// TypeCodecs picks the codec of files by sniffed type instead of
+	// Codec: MIME types or "major/*" patterns mapped to codec names, e.g.
+	// {"text/*": "brotli"}. Exact types win over patterns. Implies
+	// SniffTypes. GDELTA01 only, whose entries each record their codec;
+	// Level must suit every codec named.
+	// Default: nil
+	TypeCodecs map[string]string
+
+	// EntropyThreshold is the sampled entropy, in bits per byte (0-8), at
+	// or above which a file of 64 KiB or more is stored as-is: three 16 KiB
+	// blocks are read before compressing, so near-random content (media
+	// without a known extension, encrypted blobs) doesn't burn CPU at high
+	// levels. Applies where StoreExtensions do.
+	// Default: DefaultEntropyThreshold (7.9)
+	EntropyThreshold float64
+
+	// NoEntropyCheck skips the entropy sampling; only extensions decide
+	// which files are stored
+	// Default: false
+	NoEntropyCheck bool
+
+	// ParityPercent appends Reed-Solomon parity worth about this share of
+	// the archive size (1-25) after the archive, so damaged blocks can be
+	// rebuilt in place with godelta repair. Readers ignore the parity.
+	// GDELTA formats only.
+	// 0 = no parity
+	// Default: 0
+	ParityPercent int
+
+	// WriteManifest writes the SHA-256 of every archived file to
+	// ManifestPath(opts) (archive.gdelta.sha256) in the sha256sum format, so
+	// restores can be audited with `sha256sum -c` and verify cross-checks
+	// the archive against it. Files are hashed after the archive is written.
+	// Not available with FromTar.
+	// Default: false
+	WriteManifest bool
+
+	// NoPageCache keeps the files read out of the OS page cache, so backing
+	// up a dataset much larger than memory doesn't evict the cache of other
+	// services: on Linux the pages read are dropped as the run goes
+	// (posix_fadvise DONTNEED), on macOS files are read with F_NOCACHE.
+	// Elsewhere files are read as usual. Files are read no slower, but
+	// reading one twice (manifest, file-level dedup) hits the disk twice.
+	// Default: false
+	NoPageCache bool
+
+	// Sync flushes every file the run writes (archives, parts, manifests)
+	// to disk with fsync before returning, then the directory holding it,
+	// so an archive reported as written survives a power loss or crash.
+	// The GDELTA02 chunk staging file is flushed before it is copied into
+	// the archive too. The CLI turns it on (--no-sync turns it off).
+	// Default: false
+	Sync bool
+
+	// VerifyAfter reads the archive back once it is written, like
+	// verify --data (and checks the manifest written with WriteManifest),
+	// before the run is reported as successful: Result.Verify holds the
+	// outcome, and an archive that doesn't check out fails the run with
+	// ErrVerifyFailed. The archive is left in place for inspection. With
+	// SplitByFolder, each archive is verified. GDELTA formats only; not
+	// with DryRun.
+	// Default: false
+	VerifyAfter bool
+
+	// Metadata is recorded in the header of GDELTA02 and GDELTA03 archives
+	// (chunked or dictionary mode), so archives can be catalogued. An empty
+	// Created, Host or Source is filled in with the start of the run, the
+	// hostname and the absolute InputPath; Version is left to the caller.
+	// The field keeps godelta.MetadataRoom bytes free for annotate.Update.
+	// Default: nil (no metadata)
+	Metadata *godelta.ArchiveMetadata
+
+	// Deterministic makes the archive depend on the input only, so two
+	// runs over the same files give the same bytes: files are compressed
+	// one at a time in path order, and Metadata's Created, Host and Source
+	// are left as set. Sets MaxThreads to 1. Not with a standard format,
+	// UseDictionary, ChunkDictionary, auto-tuning or AdaptiveThreads.
+	// Default: false
+	Deterministic bool
+
+	// EmbedFormatSpec records a plain text summary of the archive layout
+	// in the header (format.FormatSpec), so the archive documents how to
+	// read it. GDELTA02 and GDELTA03 archives only.
+	// Default: false
+	EmbedFormatSpec bool
+
+	// Archival is a preset for archives that must stay readable for a
+	// decade: a single GDELTA02 archive (ChunkSize defaults to
+	// DefaultArchivalChunkSize), whose chunks carry full BLAKE3-256
+	// hashes, with Deterministic, EmbedFormatSpec, WriteManifest (except
+	// from a tar or a stream), VerifyAfter, Sync, DefaultArchivalParity
+	// percent of parity unless ParityPercent is set, and Metadata (empty
+	// unless set). Not with a standard format, UseDictionary or
+	// SplitByFolder.
+	// Default: false
+	Archival bool
+
+	// SnapshotMode reads InputPath from a point-in-time snapshot of its
+	// volume, taken for the run and removed afterwards, so files in use are
+	// read whole and consistent with each other: SnapshotAuto, a btrfs or
+	// LVM snapshot on Linux or a Volume Shadow Copy on Windows, or
+	// SnapshotVSS. Snapshots need root (and the btrfs or lvm2 tools) on
+	// Linux, an elevated process on Windows.
+	// Not available with Files, FromTar or FromStream.
+	// Default: SnapshotNone
+	SnapshotMode SnapshotMode
+
+	// LockedFileRetries is how many more times a file another process keeps
+	// locked is opened, every half second, before it fails (Windows; files
+	// are opened sharing read, write and delete access, so only exclusive
+	// locks get in the way)
+	// Default: 0
+	LockedFileRetries int
+
+	// SkipLockedFiles lists files still locked after LockedFileRetries in
+	// Result.Locked instead of Result.Errors
+	// Default: false
+	SkipLockedFiles bool
+
+	// ChangeRetries is how many times a file that changed while it was
+	// read is read again, where its entry is still buffered: GDELTA01
+	// files up to MaxThreadMemory or spilled to a temp file, and dry runs.
+	// A file still changing, or in another mode, is stored as read and
+	// listed in Result.Fuzzy.
+	// Default: 0
+	ChangeRetries int
+
+	// FailFast aborts the run on its first error, a file that can't be
+	// read or compressed, returning ErrErrorBudget. Like a cancellation,
+	// the partial archive is removed.
+	// Default: false (failed files are listed in Result.Errors)
+	FailFast bool
+
+	// MaxErrors aborts the run like FailFast once more than this many
+	// errors were recorded
+	// 0 = no limit
+	// Default: 0
+	MaxErrors int
+
+	// CPUPriority lowers the scheduler priority of the process for the run,
+	// as nice does: 1-19, higher is lower. On Windows, 1-9 is the below
+	// normal priority class and 10-19 idle. An unprivileged process can't
+	// raise it back, so it stays lowered after the run.
+	// 0 = unchanged
+	// Default: 0
+	CPUPriority int
+
+	// IOPriority lowers the disk priority of the process like ionice:
+	// IOPriorityLow or IOPriorityIdle. Linux and Windows (background mode)
+	// only; it stays lowered after the run.
+	// Default: IOPriorityNormal
+	IOPriority IOPriority
+
 	// DryRun simulates compression without writing
 	DryRun bool
 
@@ -100,17 +544,61 @@ type Options struct {
 	// If nil and Quiet=false, progress goes to stdout
 	ProgressWriter io.Writer
 
+	// ProgressInterval and ProgressBytes thin out file progress events:
+	// one is delivered once this much time has passed, or this many more
+	// bytes were processed, since the last one. Other events always are.
+	// 0 and 0 = every event (one per buffer read)
+	// Default: 0
+	ProgressInterval time.Duration
+	ProgressBytes    uint64
+
 	// Quiet suppresses all output except errors
 	Quiet bool
 
-	// UseGitignore respects .gitignore files to exclude matching paths
+	// UseGitignore respects .gitignore files to exclude matching paths.
+	// .godeltaignore files (same syntax) are always respected, and win over
+	// a .gitignore in the same directory.
 	UseGitignore bool
 
+	// IgnoreFiles are files of gitignore-style patterns applied at the root
+	// of every walked directory, in order, before the root's own ignore
+	// files (the CLI puts GlobalIgnoreFile first when it exists). A file
+	// that can't be read fails the scan.
+	// Default: nil
+	IgnoreFiles []string
+
+	// Excludes are extra gitignore-style patterns (e.g. "*.log", "node_modules/")
+	// matched against paths relative to the input directory, after every
+	// ignore file: a "!" pattern re-includes what they exclude at the root
+	Excludes []string
+
+	// PathNormalization stores archived paths in this Unicode form, e.g.
+	// godelta.NormalizeNFC for archives made on macOS (which names files
+	// in NFD) and restored elsewhere. Files whose paths become equal once
+	// normalized are an overlap error.
+	// Default: paths are stored as they are
+	PathNormalization godelta.PathNormalization
+
+	// ModifiedSince, when non-zero, skips files whose modification time is not
+	// after it, producing an incremental archive of changed/new files only.
+	// Deletions are not recorded.
+	// This option is for library use only (used by watch mode)
+	ModifiedSince time.Time
+
+	// ChangedPaths are kept by ModifiedSince whatever their modification
+	// time, with every file below those that are directories: files moved
+	// or copied in with their old time kept (mv, cp -p, rsync -a).
+	// This option is for library use only (used by watch mode)
+	ChangedPaths []string
+
 	// DisableGC disables garbage collection during compression for maximum
 	// throughput. Uses pooled buffers to minimize allocations. GC is re-enabled
 	// after compression completes. Only affects ZIP compression mode.
 	// Default: false
 	DisableGC bool
+
+	// sample, set by Bench, is compressed instead of scanning the input
+	sample []folderTask
 }
 
 // DefaultOptions returns options with sensible defaults
@@ -129,12 +617,22 @@ func DefaultOptions() *Options {
 
 // Validate checks if options are valid
 func (o *Options) Validate() error {
-	if o.InputPath == "" && len(o.Files) == 0 {
+	if o.InputPath == "" && len(o.Files) == 0 && o.FromTar == nil && o.FromStream == nil {
 		return ErrInputRequired
 	}
 	if o.OutputPath == "" {
 		o.OutputPath = "archive.delta"
 	}
+	if o.Archival {
+		if err := o.applyArchival(); err != nil {
+			return err
+		}
+	}
+	if o.Deterministic {
+		if err := o.checkDeterministic(); err != nil {
+			return err
+		}
+	}
 	if o.MaxThreads <= 0 {
 		o.MaxThreads = runtime.NumCPU()
 	}
@@ -150,11 +648,154 @@ func (o *Options) Validate() error {
 		return ErrInvalidParallelism
 	}
 
+	if o.AutoLevel || o.AutoChunkSize {
+		if o.FromTar != nil || o.streamInput() {
+			return ErrAutoTuneStream
+		}
+		if o.standardFormat() {
+			return ErrAutoTuneFormat
+		}
+		// Checked as a chunked run; the size is picked when the run starts
+		if o.AutoChunkSize && o.ChunkSize == 0 {
+			o.ChunkSize = AutoTuneChunkSizes[0]
+		}
+	}
+
 	// Set default level if not specified
 	if o.Level == 0 {
 		o.Level = 5
 	}
 
+	if o.Codec == "" {
+		o.Codec = "zstd"
+	}
+	codecID, codec, ok := format.CodecByName(o.Codec)
+	if !ok || codecID == format.MethodStore {
+		return fmt.Errorf("%w: %q (available: %s)", ErrUnknownCodec, o.Codec, strings.Join(Codecs(), ", "))
+	}
+	if codecID != format.MethodZstd {
+		if o.standardFormat() {
+			return ErrCodecFormat
+		}
+		if o.UseDictionary {
+			return ErrCodecNoDictionary
+		}
+		if lo, hi := codec.LevelRange(); hi > 0 && (o.Level < lo || o.Level > hi) {
+			return fmt.Errorf("%w: %s accepts %d-%d", ErrInvalidLevelCodec, o.Codec, lo, hi)
+		}
+	}
+
+	if o.WindowLog != 0 || o.EnableLongDistanceMatching {
+		if o.standardFormat() || codecID != format.MethodZstd {
+			return ErrWindowZstdOnly
+		}
+		if o.WindowLog == 0 {
+			o.WindowLog = longWindowLog
+		}
+		if o.WindowLog < minWindowLog || o.WindowLog > maxWindowLog {
+			return fmt.Errorf("%w: got %d, accepts %d-%d", ErrInvalidWindowLog, o.WindowLog, minWindowLog, maxWindowLog)
+		}
+	}
+
+	if o.MemoryBudget > 0 && o.standardFormat() {
+		return ErrMemoryBudgetFormat
+	}
+	if o.MaxErrors < 0 {
+		return ErrInvalidMaxErrors
+	}
+	if o.ChangeRetries < 0 {
+		return ErrInvalidChangeRetries
+	}
+	if !o.PathMode.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidPathMode, o.PathMode)
+	}
+	if (o.PathMode != "" || len(o.PathMapping) > 0) && len(o.Files) == 0 {
+		return ErrPathModeFiles
+	}
+	if (o.PathMode == PathCustom) != (len(o.PathMapping) > 0) {
+		return ErrPathMapping
+	}
+	if !o.PathNormalization.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidPathNormalization, o.PathNormalization)
+	}
+	if o.LockedFileRetries < 0 {
+		return ErrInvalidLockedRetries
+	}
+	if o.AdaptiveThreads {
+		if o.standardFormat() || o.FromTar != nil || o.streamInput() {
+			return ErrAdaptiveThreadsFormat
+		}
+		if o.MinThreads == 0 {
+			o.MinThreads = 1
+		}
+		if o.MinThreads < 0 || o.MinThreads > o.MaxThreads {
+			return fmt.Errorf("%w: %d (max threads %d)", ErrInvalidMinThreads, o.MinThreads, o.MaxThreads)
+		}
+	}
+	if o.PackSmallFiles > 0 {
+		if o.standardFormat() || o.UseDictionary || o.ChunkSize > 0 || o.FromTar != nil || o.streamInput() {
+			return ErrPackFormat
+		}
+		if o.PackSmallFiles > PackFrameSize {
+			return fmt.Errorf("%w: got %s", ErrInvalidPackSize, FormatSize(o.PackSmallFiles))
+		}
+	}
+	if o.DedupFiles && (o.standardFormat() || o.ChunkSize > 0 || o.FromTar != nil || o.streamInput()) {
+		return ErrDedupFilesFormat
+	}
+	if o.VerifyAfter && (o.standardFormat() || o.DryRun) {
+		return ErrVerifyAfterFormat
+	}
+	if o.SplitByFolder && (o.standardFormat() || o.FromTar != nil || o.streamInput()) {
+		return ErrSplitByFolderFormat
+	}
+	if len(o.TypeCodecs) > 0 {
+		if o.standardFormat() || o.UseDictionary || o.ChunkSize > 0 {
+			return ErrTypeCodecsFormat
+		}
+		for pattern, name := range o.TypeCodecs {
+			if major, minor, ok := strings.Cut(pattern, "/"); !ok || major == "" || minor == "" || strings.Contains(major, "*") {
+				return fmt.Errorf("%w: %q", ErrInvalidTypePattern, pattern)
+			}
+			id, codec, ok := format.CodecByName(name)
+			if !ok || id == format.MethodStore {
+				return fmt.Errorf("%w: %q for %s (available: %s)", ErrUnknownCodec, name, pattern, strings.Join(Codecs(), ", "))
+			}
+			if lo, hi := codec.LevelRange(); id != format.MethodZstd && hi > 0 && (o.Level < lo || o.Level > hi) {
+				return fmt.Errorf("%w: %s accepts %d-%d", ErrInvalidLevelCodec, name, lo, hi)
+			}
+		}
+		o.SniffTypes = true
+	}
+	if o.SniffTypes && (o.FromTar != nil || o.streamInput()) {
+		return ErrSniffTypesSource
+	}
+	if o.CPUPriority < 0 || o.CPUPriority > MaxCPUPriority {
+		return ErrInvalidCPUPriority
+	}
+	if !o.IOPriority.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidIOPriority, o.IOPriority)
+	}
+	switch o.SnapshotMode {
+	case SnapshotNone:
+	case SnapshotAuto, SnapshotVSS:
+		if o.InputPath == "" || len(o.Files) > 0 || o.FromTar != nil || o.streamInput() {
+			return ErrSnapshotInput
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidSnapshotMode, o.SnapshotMode)
+	}
+	if o.ParityPercent < 0 || o.ParityPercent > parity.MaxPercent {
+		return fmt.Errorf("%w: got %d, accepts 1-%d", ErrInvalidParityPercent, o.ParityPercent, parity.MaxPercent)
+	}
+	if o.ParityPercent > 0 && o.standardFormat() {
+		return ErrParityFormat
+	}
+
+	if formats := countTrue(o.UseZipFormat, o.UseXzFormat, o.UseTarGzFormat, o.Use7zFormat); formats > 1 && (o.UseTarGzFormat || o.Use7zFormat) {
+		return ErrFormatConflict
+	}
+
 	// XZ mode uses LZMA2 compression (1-9 levels)
 	if o.UseXzFormat {
 		if o.UseZipFormat {
@@ -180,13 +821,73 @@ func (o *Options) Validate() error {
 		if o.UseDictionary {
 			return ErrZipNoDictionary
 		}
-	} else {
-		// GDELTA mode uses zstd (1-22 levels)
+	} else if o.UseTarGzFormat {
+		// tar.gz mode uses gzip compression (1-9 levels)
+		if o.Level < 1 || o.Level > 9 {
+			return ErrInvalidLevelTarGz
+		}
+		if o.ChunkSize > 0 {
+			return ErrTarGzNoChunking
+		}
+		if o.UseDictionary {
+			return ErrTarGzNoDictionary
+		}
+	} else if o.Use7zFormat {
+		// 7z mode uses LZMA2 compression (1-9 levels)
+		if o.Level < 1 || o.Level > 9 {
+			return ErrInvalidLevel7z
+		}
+		if o.ChunkSize > 0 {
+			return Err7zNoChunking
+		}
+		if o.UseDictionary {
+			return Err7zNoDictionary
+		}
+	} else if o.Codec == "zstd" {
+		// GDELTA mode uses zstd (1-22 levels) unless another codec is set
 		if o.Level < 1 || o.Level > 22 {
 			return ErrInvalidLevelZstd
 		}
 	}
 
+	// A tar stream is always chunked into GDELTA02
+	if o.FromTar != nil {
+		if o.WriteManifest {
+			return ErrManifestFromTar
+		}
+		if o.standardFormat() || o.UseDictionary {
+			return ErrFromTarFormat
+		}
+		if o.ChunkSize == 0 {
+			o.ChunkSize = DefaultTarChunkSize
+		}
+	}
+
+	// A stream or block device is always chunked into a single GDELTA02
+	// entry
+	if o.streamInput() {
+		if o.FromTar != nil || o.standardFormat() || o.UseDictionary {
+			return ErrFromStreamFormat
+		}
+		if o.WriteManifest {
+			return ErrManifestFromStream
+		}
+		if o.ChunkSize == 0 {
+			o.ChunkSize = DefaultTarChunkSize
+		}
+		if o.StreamName == "" {
+			o.StreamName = DefaultStreamName
+			if o.FromStream == nil {
+				o.StreamName = filepath.Base(o.InputPath)
+			}
+		}
+		name, err := tarEntryPath(filepath.ToSlash(o.StreamName))
+		if err != nil {
+			return fmt.Errorf("stream name %q: %w", o.StreamName, err)
+		}
+		o.StreamName = name
+	}
+
 	// Dictionary mode is mutually exclusive with chunking
 	if o.UseDictionary && o.ChunkSize > 0 {
 		return ErrDictionaryNoChunking
@@ -202,9 +903,183 @@ func (o *Options) Validate() error {
 		if o.ChunkSize > maxChunkSize {
 			return ErrChunkSizeTooLarge
 		}
+		if o.ChunkingMode == "" {
+			o.ChunkingMode = ChunkingCDC
+		}
+		switch o.ChunkingMode {
+		case ChunkingCDC:
+			bounds := format.DefaultChunkBounds(o.ChunkSize)
+			if o.ChunkMinSize == 0 {
+				o.ChunkMinSize = bounds.MinSize
+			}
+			if o.ChunkMaxSize == 0 {
+				o.ChunkMaxSize = bounds.MaxSize
+			}
+			if o.ChunkMinSize < minChunkBound || o.ChunkMinSize > o.ChunkSize || o.ChunkMaxSize < o.ChunkSize || o.ChunkMaxSize > maxChunkBound || o.ChunkMinSize == o.ChunkMaxSize {
+				return fmt.Errorf("%w: min %d, average %d, max %d", ErrInvalidChunkBounds, o.ChunkMinSize, o.ChunkSize, o.ChunkMaxSize)
+			}
+			if o.ChunkNormalization < ChunkNormalizationOff || o.ChunkNormalization > 3 {
+				return fmt.Errorf("%w: got %d", ErrInvalidChunkNormalization, o.ChunkNormalization)
+			}
+		case ChunkingFixed:
+			// Bounds are filled with the block size, so validating again passes
+			if (o.ChunkMinSize != 0 && o.ChunkMinSize != o.ChunkSize) || (o.ChunkMaxSize != 0 && o.ChunkMaxSize != o.ChunkSize) || o.ChunkNormalization != 0 {
+				return ErrFixedChunkBounds
+			}
+			o.ChunkMinSize, o.ChunkMaxSize = o.ChunkSize, o.ChunkSize
+		default:
+			return ErrInvalidChunkingMode
+		}
+	} else if o.ChunkMinSize > 0 || o.ChunkMaxSize > 0 || o.ChunkNormalization != 0 || o.ChunkingMode == ChunkingFixed {
+		return ErrChunkBoundsNoChunking
+	}
+	if o.ChunkDictionary && (o.ChunkSize == 0 || codecID != format.MethodZstd || o.FromTar != nil || o.streamInput()) {
+		return ErrChunkDictionaryFormat
+	}
+	if len(o.ReferenceDirs) > 0 && (o.ChunkSize == 0 || o.UseDictionary || o.FromTar != nil || o.streamInput()) {
+		return ErrReferenceDirsFormat
+	}
+	if o.EntropyThreshold == 0 {
+		o.EntropyThreshold = DefaultEntropyThreshold
+	}
+	if o.EntropyThreshold < 0 || o.EntropyThreshold > 8 {
+		return ErrInvalidEntropyThreshold
+	}
+	for i, ext := range o.StoreExtensions {
+		if ext == "" || ext == "." {
+			return ErrInvalidStoreExtension
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		o.StoreExtensions[i] = strings.ToLower(ext)
+	}
+	if o.Metadata != nil {
+		if o.standardFormat() || (o.ChunkSize == 0 && !o.UseDictionary) {
+			return ErrMetadataFormat
+		}
+		o.Metadata = o.fillMetadata()
+	}
+	if o.EmbedFormatSpec && (o.standardFormat() || (o.ChunkSize == 0 && !o.UseDictionary)) {
+		return ErrFormatSpecFormat
 	}
 	if o.Quiet {
 		o.Verbose = false
 	}
 	return nil
 }
+
+// fillMetadata returns a copy of Metadata with the fields it leaves empty
+// filled in, unless the output is Deterministic
+func (o *Options) fillMetadata() *godelta.ArchiveMetadata {
+	m := *o.Metadata
+	m.Labels = maps.Clone(m.Labels)
+	if o.Deterministic {
+		return &m
+	}
+	if m.Created.IsZero() {
+		m.Created = time.Now().UTC().Truncate(time.Second)
+	}
+	if m.Host == "" {
+		m.Host, _ = os.Hostname()
+	}
+	if m.Source == "" && o.InputPath != "" && o.FromStream == nil {
+		m.Source, _ = filepath.Abs(o.InputPath)
+	}
+	return &m
+}
+
+// metadataExtension returns Metadata as a header extension, with
+// godelta.MetadataRoom bytes of padding for later annotations, and false
+// without Metadata
+func (o *Options) metadataExtension() (format.Extension, bool, error) {
+	if o.Metadata == nil {
+		return format.Extension{}, false, nil
+	}
+	value, err := godelta.EncodeMetadata(o.Metadata)
+	if err == nil {
+		value, err = godelta.EncodeMetadataPadded(o.Metadata, len(value)+godelta.MetadataRoom)
+	}
+	if err != nil {
+		return format.Extension{}, false, fmt.Errorf("encode metadata: %w", err)
+	}
+	return format.Extension{Type: format.ExtMetadata, Value: value}, true, nil
+}
+
+// standardFormat reports whether the output is a standard archive format
+// (ZIP, tar.xz, tar.gz or 7z) rather than GDELTA
+func (o *Options) standardFormat() bool {
+	return o.UseZipFormat || o.UseXzFormat || o.UseTarGzFormat || o.Use7zFormat
+}
+
+// countTrue returns how many of flags are set
+func countTrue(flags ...bool) int {
+	n := 0
+	for _, f := range flags {
+		if f {
+			n++
+		}
+	}
+	return n
+}
+
+// zstd window bounds supported by the encoder, and the window selected by
+// EnableLongDistanceMatching
+const (
+	minWindowLog  = 10
+	maxWindowLog  = 29
+	longWindowLog = 27
+)
+
+// zstdWindow returns the encoder window size in bytes, 0 for the level default
+func (o *Options) zstdWindow() int {
+	if o.WindowLog == 0 {
+		return 0
+	}
+	return 1 << o.WindowLog
+}
+
+// ChunkNormalizationOff disables FastCDC normalization (see
+// Options.ChunkNormalization)
+const ChunkNormalizationOff = -1
+
+// Chunk bounds supported by FastCDC
+const (
+	minChunkBound = 64
+	maxChunkBound = 1 << 30
+)
+
+// chunkBounds returns the FastCDC settings of a validated chunked run
+func (o *Options) chunkBounds() format.ChunkBounds {
+	if o.ChunkingMode == ChunkingFixed {
+		return format.FixedChunkBounds(o.ChunkSize)
+	}
+	normalization := o.ChunkNormalization
+	switch normalization {
+	case 0:
+		normalization = chunker.DefaultNormalization
+	case ChunkNormalizationOff:
+		normalization = 0
+	}
+	return format.ChunkBounds{MinSize: o.ChunkMinSize, MaxSize: o.ChunkMaxSize, Normalization: normalization}
+}
+
+// newChunker returns the chunker of a validated chunked run
+func (o *Options) newChunker() *chunker.Chunker {
+	if o.ChunkingMode == ChunkingFixed {
+		return chunker.NewFixed(o.ChunkSize)
+	}
+	b := o.chunkBounds()
+	return chunker.NewBounded(o.ChunkSize, b.MinSize, b.MaxSize, b.Normalization)
+}
+
+// Codecs lists the codec names accepted by Options.Codec
+func Codecs() []string {
+	var names []string
+	for _, name := range format.CodecNames() {
+		if name != format.MethodStore.String() {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,86 @@
+// pkg/compress/order.go
+package compress
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FileRef is a file of the run, as seen by a FileOrder
+type FileRef struct {
+	RelPath string // Path in the archive
+	Size    uint64 // Size in bytes
+}
+
+// FileOrder arranges the files of a run into groups handed to the workers
+// one after the other. In folder parallelism a whole group goes to one
+// worker; in file parallelism the groups are queued in order. Each file
+// must be in exactly one group.
+//
+// Chunks of similar files are more likely to be found in the chunk store
+// cache when those files are chunked close together, which matters once
+// ChunkStoreSize bounds the cache.
+type FileOrder func(files []FileRef) [][]FileRef
+
+// OrderByType groups files by extension (case-insensitive), in extension
+// order, and sorts each group by size then path: files of a type are
+// chunked one after another, next to their closest-sized peers.
+func OrderByType(files []FileRef) [][]FileRef {
+	sorted := slices.Clone(files)
+	slices.SortFunc(sorted, func(a, b FileRef) int {
+		return cmp.Or(
+			strings.Compare(fileExt(a.RelPath), fileExt(b.RelPath)),
+			cmp.Compare(a.Size, b.Size),
+			strings.Compare(a.RelPath, b.RelPath),
+		)
+	})
+
+	var groups [][]FileRef
+	for i, f := range sorted {
+		if i == 0 || fileExt(f.RelPath) != fileExt(sorted[i-1].RelPath) {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], f)
+	}
+	return groups
+}
+
+// fileExt returns the lowercased extension of path ("" for none), the key
+// of OrderByType groups and Result.Extensions
+func fileExt(path string) string {
+	return strings.ToLower(filepath.Ext(path))
+}
+
+// orderFiles replaces the folders of a run with the groups of order
+func orderFiles(order FileOrder, folders []folderTask) ([]folderTask, error) {
+	tasks := make(map[string]fileTask)
+	var refs []FileRef
+	for _, folder := range folders {
+		for _, task := range folder.Files {
+			tasks[task.RelPath] = task
+			refs = append(refs, FileRef{RelPath: task.RelPath, Size: task.OrigSize})
+		}
+	}
+
+	groups := order(refs)
+	ordered := make([]folderTask, 0, len(groups))
+	for _, group := range groups {
+		folder := folderTask{Files: make([]fileTask, 0, len(group))}
+		for _, ref := range group {
+			task, ok := tasks[ref.RelPath]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s is unknown or listed twice", ErrInvalidFileOrder, ref.RelPath)
+			}
+			delete(tasks, ref.RelPath)
+			folder.Files = append(folder.Files, task)
+		}
+		ordered = append(ordered, folder)
+	}
+	if len(tasks) > 0 {
+		return nil, fmt.Errorf("%w: %d files left out", ErrInvalidFileOrder, len(tasks))
+	}
+	return ordered, nil
+}
@@ -0,0 +1,104 @@
+// pkg/compress/order_test.go
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOrderByType(t *testing.T) {
+	groups := OrderByType([]FileRef{
+		{RelPath: "a/big.log", Size: 300},
+		{RelPath: "b/x.TXT", Size: 20},
+		{RelPath: "Makefile", Size: 5},
+		{RelPath: "b/small.log", Size: 100},
+		{RelPath: "a/y.txt", Size: 10},
+	})
+
+	want := [][]string{
+		{"Makefile"},
+		{"b/small.log", "a/big.log"},
+		{"a/y.txt", "b/x.TXT"},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d: %v", len(groups), len(want), groups)
+	}
+	for i, group := range groups {
+		if len(group) != len(want[i]) {
+			t.Fatalf("group %d: got %v, want %v", i, group, want[i])
+		}
+		for j, f := range group {
+			if f.RelPath != want[i][j] {
+				t.Errorf("group %d file %d: got %s, want %s", i, j, f.RelPath, want[i][j])
+			}
+		}
+	}
+}
+
+func TestOrderFilesRejectsIncompleteOrder(t *testing.T) {
+	folders := []folderTask{{Files: []fileTask{{RelPath: "a"}, {RelPath: "b"}}}}
+
+	dropLast := func(files []FileRef) [][]FileRef { return [][]FileRef{files[:1]} }
+	if _, err := orderFiles(dropLast, folders); !errors.Is(err, ErrInvalidFileOrder) {
+		t.Errorf("missing file: got %v, want ErrInvalidFileOrder", err)
+	}
+
+	twice := func(files []FileRef) [][]FileRef { return [][]FileRef{files, files[:1]} }
+	if _, err := orderFiles(twice, folders); !errors.Is(err, ErrInvalidFileOrder) {
+		t.Errorf("duplicate file: got %v, want ErrInvalidFileOrder", err)
+	}
+}
+
+func TestCompressOrderByTypeExtensionStats(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in")
+	shared := bytes.Repeat([]byte("shared log line\n"), 2048)
+	files := map[string][]byte{
+		"a/app.log":  shared,
+		"b/app.log":  shared,
+		"a/note.txt": []byte("just a note"),
+	}
+	for name, data := range files {
+		path := filepath.Join(input, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, dryRun := range []bool{false, true} {
+		result, err := Compress(&Options{
+			InputPath:   input,
+			OutputPath:  filepath.Join(dir, "out.gdelta"),
+			ChunkSize:   4 * 1024,
+			MaxThreads:  2,
+			Parallelism: ParallelismFolder,
+			FileOrder:   OrderByType,
+			DryRun:      dryRun,
+			Quiet:       true,
+		}, nil)
+		if err != nil {
+			t.Fatalf("dry run %v: %v", dryRun, err)
+		}
+		if result.FilesProcessed != 3 {
+			t.Errorf("dry run %v: processed %d files, want 3", dryRun, result.FilesProcessed)
+		}
+
+		logs := result.Extensions[".log"]
+		if logs.Files != 2 || logs.OriginalSize != uint64(2*len(shared)) {
+			t.Errorf("dry run %v: .log stats %+v", dryRun, logs)
+		}
+		// The second log file only repeats chunks of the first
+		if logs.DedupRatio() < 50 {
+			t.Errorf("dry run %v: .log dedup %.1f%%, want at least 50%%", dryRun, logs.DedupRatio())
+		}
+		if txt := result.Extensions[".txt"]; txt.Files != 1 || txt.DedupedChunks != 0 {
+			t.Errorf("dry run %v: .txt stats %+v", dryRun, txt)
+		}
+	}
+}
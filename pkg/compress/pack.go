@@ -0,0 +1,97 @@
+// pkg/compress/pack.go
+package compress
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/pool"
+)
+
+// PackFrameSize is the most input one small-file pack holds, and the
+// largest Options.PackSmallFiles
+const PackFrameSize = 1 << 20
+
+// packFiles groups the files of folders up to opts.PackSmallFiles bytes,
+// in order, into pack tasks of up to PackFrameSize bytes, each placed where
+// its last file was. Files stored by extension and packs of a single file
+// are left alone. It returns the new folders and the number of packs.
+func packFiles(opts *Options, folders []folderTask) ([]folderTask, int) {
+	if opts.PackSmallFiles == 0 {
+		return folders, 0
+	}
+	var packs int
+	var pending []fileTask
+	var pendingSize uint64
+	packed := make([]folderTask, 0, len(folders))
+	flush := func(folder *folderTask) {
+		switch len(pending) {
+		case 0:
+		case 1:
+			folder.Files = append(folder.Files, pending[0])
+		default:
+			folder.Files = append(folder.Files, fileTask{RelPath: pending[0].RelPath, OrigSize: pendingSize, pack: pending})
+			packs++
+		}
+		pending, pendingSize = nil, 0
+	}
+	for i, folder := range folders {
+		out := folderTask{FolderPath: folder.FolderPath}
+		for _, task := range folder.Files {
+			if task.OrigSize > opts.PackSmallFiles || opts.storedByExtension(task.RelPath) {
+				out.Files = append(out.Files, task)
+				continue
+			}
+			if pendingSize+task.OrigSize > PackFrameSize {
+				flush(&out)
+			}
+			pending = append(pending, task)
+			pendingSize += task.OrigSize
+		}
+		if i == len(folders)-1 {
+			flush(&out)
+		}
+		packed = append(packed, out)
+	}
+	return packed, packs
+}
+
+// readPack reads the files of a pack, one after another, into one frame
+// from the pool. Files that can't be read are left out and returned with
+// their error; the entries of the others record where they are in the
+// frame.
+func readPack(files []fileTask, size uint64, method format.Method) (frame []byte, entries []*format.FileEntry, failed []fileTask, errs []error) {
+	frame = pool.Bytes(int(size))
+	var off uint64
+	for _, task := range files {
+		if task.OrigSize > 0 {
+			if err := readSourceInto(task, frame[off:off+task.OrigSize]); err != nil {
+				failed, errs = append(failed, task), append(errs, err)
+				continue
+			}
+		}
+		entries = append(entries, &format.FileEntry{
+			Path:         task.RelPath,
+			OriginalSize: task.OrigSize,
+			Method:       method,
+			Packed:       true,
+			PackOffset:   off,
+		})
+		off += task.OrigSize
+	}
+	return frame[:off], entries, failed, errs
+}
+
+// readSourceInto reads the whole file of task into buf, of its scanned size
+func readSourceInto(task fileTask, buf []byte) error {
+	src, err := openSource(task)
+	if err != nil {
+		return fmt.Errorf("open source file: %w", err)
+	}
+	defer src.Close()
+	if _, err := io.ReadFull(src, buf); err != nil {
+		return fmt.Errorf("read source file: %w", err)
+	}
+	return nil
+}
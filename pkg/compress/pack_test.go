@@ -0,0 +1,57 @@
+// pkg/compress/pack_test.go
+package compress
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPackFiles(t *testing.T) {
+	var a, b folderTask
+	a.FolderPath, b.FolderPath = "a", "b"
+	for i := range 3 {
+		a.Files = append(a.Files, fileTask{RelPath: fmt.Sprintf("a/%d.txt", i), OrigSize: 400 << 10})
+	}
+	a.Files = append(a.Files, fileTask{RelPath: "a/big.bin", OrigSize: 2 << 20}, fileTask{RelPath: "a/pic.png", OrigSize: 10})
+	b.Files = append(b.Files, fileTask{RelPath: "b/empty", OrigSize: 0}, fileTask{RelPath: "b/last.txt", OrigSize: 10})
+
+	folders, packs := packFiles(&Options{PackSmallFiles: 512 << 10}, []folderTask{a, b})
+	if packs != 2 {
+		t.Fatalf("%d packs, want 2", packs)
+	}
+	// The third file doesn't fit the first pack and starts the second,
+	// closed at the end of the input in folder b
+	var got []string
+	for _, folder := range folders {
+		for _, task := range folder.Files {
+			got = append(got, fmt.Sprintf("%s:%s:%d", folder.FolderPath, task.RelPath, len(task.pack)))
+		}
+	}
+	want := []string{"a:a/0.txt:2", "a:a/big.bin:0", "a:a/pic.png:0", "b:a/2.txt:3"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if folders[1].Files[0].OrigSize != 400<<10+10 {
+		t.Errorf("second pack holds %d bytes", folders[1].Files[0].OrigSize)
+	}
+
+	if unpacked, packs := packFiles(&Options{}, []folderTask{a, b}); packs != 0 || len(unpacked[0].Files) != len(a.Files) {
+		t.Error("files packed without PackSmallFiles")
+	}
+}
+
+func TestPackSmallFilesValidation(t *testing.T) {
+	for name, opts := range map[string]*Options{
+		"zip":        {InputPath: ".", UseZipFormat: true, PackSmallFiles: 4096},
+		"chunked":    {InputPath: ".", ChunkSize: 64 << 10, PackSmallFiles: 4096},
+		"dictionary": {InputPath: ".", UseDictionary: true, PackSmallFiles: 4096},
+	} {
+		if err := opts.Validate(); !errors.Is(err, ErrPackFormat) {
+			t.Errorf("%s: got %v, want ErrPackFormat", name, err)
+		}
+	}
+	if err := (&Options{InputPath: ".", PackSmallFiles: PackFrameSize + 1}).Validate(); !errors.Is(err, ErrInvalidPackSize) {
+		t.Errorf("got %v, want ErrInvalidPackSize", err)
+	}
+}
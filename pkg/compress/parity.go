@@ -0,0 +1,27 @@
+// pkg/compress/parity.go
+package compress
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/creativeyann17/go-delta/internal/parity"
+)
+
+// appendParity appends the parity section of Options.ParityPercent after
+// the last byte of a finished archive
+func appendParity(f *os.File, opts *Options, result *Result) error {
+	if opts.ParityPercent == 0 {
+		return nil
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat archive: %w", err)
+	}
+	layout, err := parity.Write(f, info.Size(), opts.ParityPercent)
+	if err != nil {
+		return fmt.Errorf("write parity: %w", err)
+	}
+	result.ParitySize = uint64(layout.SectionSize())
+	return nil
+}
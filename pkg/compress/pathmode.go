@@ -0,0 +1,134 @@
+// pkg/compress/pathmode.go
+package compress
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// PathMode selects how the archive paths of Options.Files entries are
+// derived from their location on disk. Directories keep their tree below
+// the path given to them.
+type PathMode string
+
+const (
+	// PathBasename names each entry by its last element: /a/x.txt and
+	// /b/x.txt both become x.txt and collide
+	PathBasename PathMode = "basename"
+
+	// PathCommonPrefix names entries relative to the deepest directory
+	// holding all of them: /data/a/x.txt and /data/b/x.txt become a/x.txt
+	// and b/x.txt
+	PathCommonPrefix PathMode = "common-prefix"
+
+	// PathFull keeps the whole absolute path without its root (and volume
+	// on Windows): /data/a/x.txt becomes data/a/x.txt
+	PathFull PathMode = "full-sans-root"
+
+	// PathCustom maps entries by Options.PathMapping
+	PathCustom PathMode = "custom"
+)
+
+// Valid reports whether m is a known mode; empty means PathBasename
+func (m PathMode) Valid() bool {
+	switch m {
+	case "", PathBasename, PathCommonPrefix, PathFull, PathCustom:
+		return true
+	}
+	return false
+}
+
+// entryNamer gives each Options.Files entry its archive path
+type entryNamer struct {
+	mode    PathMode
+	common  string            // PathCommonPrefix: the directory holding every entry
+	mapping map[string]string // PathCustom: absolute source prefix -> archive prefix
+}
+
+// newEntryNamer prepares the naming of entries, which are cleaned paths
+func newEntryNamer(opts *Options, entries []string) (*entryNamer, error) {
+	n := &entryNamer{mode: opts.PathMode}
+	switch n.mode {
+	case PathCommonPrefix:
+		for i, entry := range entries {
+			abs, err := filepath.Abs(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", entry, err)
+			}
+			// The directory holding the entry, so no entry is named "."
+			dir := filepath.Dir(abs)
+			if i == 0 {
+				n.common = dir
+			}
+			for !within(dir, n.common) {
+				parent := filepath.Dir(n.common)
+				if parent == n.common {
+					// Another volume (Windows)
+					return nil, fmt.Errorf("%s: %w", entry, ErrNoCommonPrefix)
+				}
+				n.common = parent
+			}
+		}
+	case PathCustom:
+		n.mapping = make(map[string]string, len(opts.PathMapping))
+		for from, to := range opts.PathMapping {
+			abs, err := filepath.Abs(from)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", from, err)
+			}
+			n.mapping[abs] = filepath.Clean(filepath.FromSlash(to))
+		}
+	}
+	return n, nil
+}
+
+// name returns the archive path of entry
+func (n *entryNamer) name(entry string) (string, error) {
+	if n.mode == "" || n.mode == PathBasename {
+		return filepath.Base(entry), nil
+	}
+	abs, err := filepath.Abs(entry)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", entry, err)
+	}
+
+	var name string
+	switch n.mode {
+	case PathCommonPrefix:
+		name, err = filepath.Rel(n.common, abs)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", entry, err)
+		}
+	case PathFull:
+		name = strings.TrimLeft(abs[len(filepath.VolumeName(abs)):], `/\`)
+	case PathCustom:
+		// The longest mapped prefix wins
+		from := ""
+		for prefix := range n.mapping {
+			if within(abs, prefix) && len(prefix) > len(from) {
+				from = prefix
+			}
+		}
+		if from == "" {
+			return "", fmt.Errorf("%s: %w", entry, ErrPathUnmapped)
+		}
+		rest, _ := filepath.Rel(from, abs)
+		name = filepath.Join(n.mapping[from], rest)
+	}
+	if name == "" || name == "." || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s: %w", entry, ErrEmptyArchivePath)
+	}
+	return name, nil
+}
+
+// within reports whether path is dir or below it
+func within(path, dir string) bool {
+	if path == dir {
+		return true
+	}
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, dir)
+}
@@ -0,0 +1,78 @@
+// pkg/compress/pathmode_test.go
+package compress
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPathMode(t *testing.T) {
+	base := t.TempDir()
+	createFile(t, base, "data/a/x.txt", "a")
+	createFile(t, base, "data/b/x.txt", "b")
+	createFile(t, base, "data/tree/sub/y.txt", "y")
+	files := []string{
+		filepath.Join(base, "data/a/x.txt"),
+		filepath.Join(base, "data/b/x.txt"),
+		filepath.Join(base, "data/tree"),
+	}
+	full := strings.TrimLeft(filepath.ToSlash(base[len(filepath.VolumeName(base)):]), "/")
+
+	for name, c := range map[string]struct {
+		opts Options
+		want string
+	}{
+		"common-prefix":  {Options{PathMode: PathCommonPrefix}, "[a/x.txt b/x.txt tree/sub/y.txt]"},
+		"full-sans-root": {Options{PathMode: PathFull}, fmt.Sprintf("[%[1]s/data/a/x.txt %[1]s/data/b/x.txt %[1]s/data/tree/sub/y.txt]", full)},
+		"custom": {Options{PathMode: PathCustom, PathMapping: map[string]string{
+			filepath.Join(base, "data"):   "all",
+			filepath.Join(base, "data/b"): "second",
+		}}, "[all/a/x.txt all/tree/sub/y.txt second/x.txt]"},
+	} {
+		opts := c.opts
+		opts.Files = files
+		folders, _, _, err := collectFiles(&opts, &Result{})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var got []string
+		for _, folder := range folders {
+			for _, task := range folder.Files {
+				got = append(got, filepath.ToSlash(task.RelPath))
+			}
+		}
+		sort.Strings(got)
+		if fmt.Sprint(got) != c.want {
+			t.Errorf("%s: got %v, want %s", name, got, c.want)
+		}
+	}
+
+	// The default names both files x.txt
+	if _, _, _, err := collectFiles(&Options{Files: files}, &Result{}); err == nil || !strings.Contains(err.Error(), "path overlap") {
+		t.Errorf("basename: got %v, want a path overlap", err)
+	}
+	opts := &Options{Files: files, PathMode: PathCustom, PathMapping: map[string]string{filepath.Join(base, "data/a"): "a"}}
+	if _, _, _, err := collectFiles(opts, &Result{}); !errors.Is(err, ErrPathUnmapped) {
+		t.Errorf("unmapped: got %v", err)
+	}
+}
+
+func TestPathModeValidation(t *testing.T) {
+	for name, c := range map[string]struct {
+		opts Options
+		want error
+	}{
+		"unknown":    {Options{Files: []string{"."}, PathMode: "flat"}, ErrInvalidPathMode},
+		"input path": {Options{InputPath: ".", PathMode: PathFull}, ErrPathModeFiles},
+		"no mapping": {Options{Files: []string{"."}, PathMode: PathCustom}, ErrPathMapping},
+		"no custom":  {Options{Files: []string{"."}, PathMapping: map[string]string{".": "x"}}, ErrPathMapping},
+	} {
+		if err := c.opts.Validate(); !errors.Is(err, c.want) {
+			t.Errorf("%s: got %v, want %v", name, err, c.want)
+		}
+	}
+}
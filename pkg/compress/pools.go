@@ -1,26 +1,15 @@
 // pkg/compress/pools.go
 package compress
 
-import "sync"
+import "github.com/creativeyann17/go-delta/internal/pool"
 
-// readBufferSize is the file IO buffer size. Large enough to keep syscall
-// count low on multi-MB files without hurting many-small-file workloads
-// (buffers are pooled, not per-file allocations).
-const readBufferSize = 256 * 1024
-
-var readBufferPool = sync.Pool{
-	New: func() any {
-		buf := make([]byte, readBufferSize)
-		return &buf
-	},
-}
-
-// getReadBuffer returns a read buffer from the pool
+// getReadBuffer returns a pool.BufferSize read buffer from the pool shared
+// with the decompress and verify workers
 func getReadBuffer() []byte {
-	return *readBufferPool.Get().(*[]byte)
+	return *pool.Buffer()
 }
 
 // putReadBuffer returns a buffer to the pool
 func putReadBuffer(buf []byte) {
-	readBufferPool.Put(&buf)
+	pool.PutBuffer(&buf)
 }
@@ -0,0 +1,45 @@
+// pkg/compress/priority.go
+package compress
+
+import "fmt"
+
+// MaxCPUPriority is the lowest scheduler priority Options.CPUPriority
+// accepts, the highest nice value
+const MaxCPUPriority = 19
+
+// IOPriority is the disk priority of a run
+type IOPriority string
+
+const (
+	// IOPriorityNormal leaves the disk priority unchanged
+	IOPriorityNormal IOPriority = ""
+
+	// IOPriorityLow serves the run's reads and writes after those of
+	// normal processes (best-effort class, lowest level, on Linux)
+	IOPriorityLow IOPriority = "low"
+
+	// IOPriorityIdle serves them only when no other process uses the disk
+	IOPriorityIdle IOPriority = "idle"
+)
+
+// Valid reports whether p is a known priority
+func (p IOPriority) Valid() bool {
+	switch p {
+	case IOPriorityNormal, IOPriorityLow, IOPriorityIdle:
+		return true
+	}
+	return false
+}
+
+// LowerPriority lowers the CPU and disk priority of the process the way
+// Options.CPUPriority and Options.IOPriority do, for long runs of other
+// operations
+func LowerPriority(cpu int, io IOPriority) error {
+	if cpu < 0 || cpu > MaxCPUPriority {
+		return ErrInvalidCPUPriority
+	}
+	if !io.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidIOPriority, io)
+	}
+	return lowerPriority(cpu, io)
+}
@@ -0,0 +1,77 @@
+//go:build linux
+
+package compress
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// ioprio_set values: the class in the top bits, the level below
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+	ioprioClassBE    = 2
+	ioprioClassIdle  = 3
+	ioprioLowestBE   = 7
+)
+
+// lowerPriority lowers the nice value and the I/O class of every thread of
+// the process. Linux keeps both per thread and new threads inherit them
+// from the thread creating them, so the workers started afterwards run at
+// the lowered priority too. A thread already below cpu is left alone.
+func lowerPriority(cpu int, io IOPriority) error {
+	if cpu == 0 && io == IOPriorityNormal {
+		return nil
+	}
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		if err := lowerThreadPriority(tid, cpu, io); err != nil {
+			if os.IsNotExist(err) || err == unix.ESRCH {
+				continue // the thread exited meanwhile
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// lowerThreadPriority applies cpu and io to the thread tid
+func lowerThreadPriority(tid, cpu int, io IOPriority) error {
+	if cpu > 0 {
+		// The raw syscall returns 20 - nice
+		prio, err := unix.Getpriority(unix.PRIO_PROCESS, tid)
+		if err != nil {
+			return err
+		}
+		if 20-prio < cpu {
+			if err := unix.Setpriority(unix.PRIO_PROCESS, tid, cpu); err != nil {
+				return fmt.Errorf("nice %d: %w", cpu, err)
+			}
+		}
+	}
+
+	var value int
+	switch io {
+	case IOPriorityLow:
+		value = ioprioClassBE<<ioprioClassShift | ioprioLowestBE
+	case IOPriorityIdle:
+		value = ioprioClassIdle << ioprioClassShift
+	default:
+		return nil
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), uintptr(value)); errno != 0 {
+		return fmt.Errorf("ionice %s: %w", io, errno)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+//go:build linux
+
+package compress
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestLowerPriority(t *testing.T) {
+	if err := lowerPriority(5, IOPriorityLow); err != nil {
+		t.Skipf("priority can't be lowered here: %v", err)
+	}
+
+	// A thread started afterwards inherits the lowered priority
+	done := make(chan [2]int)
+	go func() {
+		runtime.LockOSThread()
+		prio, _ := unix.Getpriority(unix.PRIO_PROCESS, 0)
+		ioprio, _, _ := unix.Syscall(unix.SYS_IOPRIO_GET, ioprioWhoProcess, 0, 0)
+		done <- [2]int{20 - prio, int(ioprio)}
+	}()
+	got := <-done
+	if got[0] < 5 {
+		t.Errorf("nice %d, want at least 5", got[0])
+	}
+	if want := ioprioClassBE<<ioprioClassShift | ioprioLowestBE; got[1] != want {
+		t.Errorf("I/O priority %#x, want %#x", got[1], want)
+	}
+}
+
+func TestValidatePriority(t *testing.T) {
+	if err := (&Options{InputPath: ".", CPUPriority: 20}).Validate(); !errors.Is(err, ErrInvalidCPUPriority) {
+		t.Errorf("nice 20: got %v, want ErrInvalidCPUPriority", err)
+	}
+	if err := (&Options{InputPath: ".", IOPriority: "realtime"}).Validate(); !errors.Is(err, ErrInvalidIOPriority) {
+		t.Errorf("realtime: got %v, want ErrInvalidIOPriority", err)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix && !windows
+
+package compress
+
+import "fmt"
+
+// lowerPriority has no priority to lower on this platform
+func lowerPriority(cpu int, io IOPriority) error {
+	if cpu == 0 && io == IOPriorityNormal {
+		return nil
+	}
+	return fmt.Errorf("%w: process priority", ErrPriorityUnsupported)
+}
@@ -0,0 +1,30 @@
+//go:build unix && !linux
+
+package compress
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// lowerPriority raises the nice value of the process. These systems offer
+// no disk priority to a plain system call, so io is refused.
+func lowerPriority(cpu int, io IOPriority) error {
+	if io != IOPriorityNormal {
+		return fmt.Errorf("%w: disk priority %s", ErrPriorityUnsupported, io)
+	}
+	if cpu == 0 {
+		return nil
+	}
+	nice, err := unix.Getpriority(unix.PRIO_PROCESS, 0)
+	if err != nil {
+		return err
+	}
+	if nice < cpu {
+		if err := unix.Setpriority(unix.PRIO_PROCESS, 0, cpu); err != nil {
+			return fmt.Errorf("nice %d: %w", cpu, err)
+		}
+	}
+	return nil
+}
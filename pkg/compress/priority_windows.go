@@ -0,0 +1,33 @@
+//go:build windows
+
+package compress
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// lowerPriority sets the priority class of the process: below normal up
+// to nice 9, idle above. A lowered disk priority puts the process in
+// background mode, which also lowers its CPU and memory priority; Windows
+// has a single background level, so low and idle are the same.
+func lowerPriority(cpu int, io IOPriority) error {
+	process := windows.CurrentProcess()
+	if io != IOPriorityNormal {
+		if err := windows.SetPriorityClass(process, windows.PROCESS_MODE_BACKGROUND_BEGIN); err != nil {
+			return fmt.Errorf("background mode: %w", err)
+		}
+	}
+	if cpu == 0 {
+		return nil
+	}
+	class := uint32(windows.BELOW_NORMAL_PRIORITY_CLASS)
+	if cpu >= 10 {
+		class = windows.IDLE_PRIORITY_CLASS
+	}
+	if err := windows.SetPriorityClass(process, class); err != nil {
+		return fmt.Errorf("priority class: %w", err)
+	}
+	return nil
+}
@@ -2,8 +2,13 @@
 package compress
 
 import (
+	"cmp"
 	"fmt"
+	"io"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/creativeyann17/go-delta/pkg/godelta"
 	"github.com/vbauerster/mpb/v8"
@@ -16,19 +21,36 @@ func ProgressBarCallback() (ProgressCallback, *mpb.Progress) {
 
 	// Wrap the generic callback to adapt compress.ProgressEvent to godelta.ProgressEvent
 	callback := func(event ProgressEvent) {
-		genericCb(godelta.ProgressEvent{
-			Type:         godelta.EventType(event.Type),
-			FilePath:     event.FilePath,
-			Current:      event.Current,
-			Total:        event.Total,
-			CurrentBytes: event.CurrentBytes,
-			TotalBytes:   event.TotalBytes,
-		})
+		genericCb(event.generic())
 	}
 
 	return callback, progress
 }
 
+// generic converts the event to a godelta.ProgressEvent
+func (e ProgressEvent) generic() godelta.ProgressEvent {
+	return godelta.ProgressEvent{
+		Type:                  godelta.EventType(e.Type),
+		FilePath:              e.FilePath,
+		Current:               e.Current,
+		Total:                 e.Total,
+		CurrentBytes:          e.CurrentBytes,
+		TotalBytes:            e.TotalBytes,
+		BytesPerSecond:        e.BytesPerSecond,
+		InstantBytesPerSecond: e.InstantBytesPerSecond,
+		ETA:                   e.ETA,
+	}
+}
+
+// JSONProgressCallback creates a progress callback writing each event to w as
+// one line of JSON, for wrappers and UIs that track jobs programmatically
+func JSONProgressCallback(w io.Writer) ProgressCallback {
+	emit := godelta.JSONLines(w)
+	return func(event ProgressEvent) {
+		emit(event)
+	}
+}
+
 // FormatSummary formats a compression result into a human-readable summary string
 func FormatSummary(result *Result, opts *Options) string {
 	var sb strings.Builder
@@ -37,16 +59,128 @@ func FormatSummary(result *Result, opts *Options) string {
 	isDryRun := opts != nil && opts.DryRun
 	sb.WriteString(godelta.FormatSummary(result, godelta.OperationCompress, isDryRun))
 
+	if result.StoredFiles > 0 {
+		fmt.Fprintf(&sb, "  Stored as-is:    %d files (already compressed)\n", result.StoredFiles)
+	}
+	if t := result.AutoTune; t != nil {
+		fmt.Fprintf(&sb, "  Auto-tuned:      level %d", t.Level)
+		if t.ChunkSize > 0 {
+			fmt.Fprintf(&sb, ", %s chunks", FormatSize(t.ChunkSize))
+		}
+		fmt.Fprintf(&sb, " (%d candidates on %s)\n", len(t.Runs), FormatSize(t.SampleSize))
+	}
+	if result.SegmentedFiles > 0 {
+		fmt.Fprintf(&sb, "  Segmented:       %d files (parallel zstd frames)\n", result.SegmentedFiles)
+	}
+	if result.PackedFiles > 0 {
+		fmt.Fprintf(&sb, "  Packed:          %d files in %d shared frames\n", result.PackedFiles, result.Packs)
+	}
+	if result.LinkedFiles > 0 {
+		fmt.Fprintf(&sb, "  Deduplicated:    %d files (%s) stored as links\n", result.LinkedFiles, FormatSize(result.LinkedBytes))
+	}
+	if len(result.Types) > 0 {
+		fmt.Fprintf(&sb, "  Types:           %s\n", formatTypes(result.Types, 5))
+	}
+	if result.ParitySize > 0 {
+		fmt.Fprintf(&sb, "  Parity:          %s (%d%% requested)\n", FormatSize(result.ParitySize), opts.ParityPercent)
+	}
+	if result.ManifestPath != "" {
+		fmt.Fprintf(&sb, "  Manifest:        %s\n", result.ManifestPath)
+	}
+	if v := result.Verify; v != nil && v.IsValid() {
+		fmt.Fprintf(&sb, "  Verified:        %d files read back intact\n", v.FilesVerified)
+	}
+	if len(result.Fuzzy) > 0 {
+		fmt.Fprintf(&sb, "  Changed:         %d files while read, stored as read (fuzzy)\n", len(result.Fuzzy))
+		if opts != nil && opts.Verbose {
+			for _, path := range result.Fuzzy {
+				fmt.Fprintf(&sb, "    ~ %s\n", path)
+			}
+		}
+	}
+	if len(result.Locked) > 0 {
+		fmt.Fprintf(&sb, "  Locked:          %d files skipped (in use by another process)\n", len(result.Locked))
+		if opts != nil && opts.Verbose {
+			for _, path := range result.Locked {
+				fmt.Fprintf(&sb, "    ! %s\n", path)
+			}
+		}
+	}
+	if result.ThreadAdjustments > 0 {
+		fmt.Fprintf(&sb, "  Threads:         adjusted %d times to the load, down to %d\n", result.ThreadAdjustments, result.ThreadsLowest)
+	}
+	if result.MemoryBudget > 0 {
+		fmt.Fprintf(&sb, "  Memory peak:     %s of %s budget\n", FormatSize(result.MemoryPeak), FormatSize(result.MemoryBudget))
+	}
+
+	if len(result.Splits) > 0 {
+		sb.WriteString("\nArchives:\n")
+		for _, split := range result.Splits {
+			folder := split.Folder
+			if folder == "" {
+				folder = "(top)"
+			}
+			fmt.Fprintf(&sb, "  %-24s %6d files  %10s -> %10s  %s", TruncateLeft(folder, 24), split.Files,
+				FormatSize(split.OriginalSize), FormatSize(split.CompressedSize), split.OutputPath)
+			if split.Verify != nil && split.Verify.IsValid() {
+				sb.WriteString(" (verified)")
+			}
+			sb.WriteString("\n")
+		}
+	}
+
 	// Add deduplication stats if chunking was enabled
 	if result.TotalChunks > 0 {
 		sb.WriteString("\nDeduplication:\n")
 		fmt.Fprintf(&sb, "  Total chunks:    %d\n", result.TotalChunks)
 		fmt.Fprintf(&sb, "  Unique chunks:   %d\n", result.UniqueChunks)
 		fmt.Fprintf(&sb, "  Deduped chunks:  %d\n", result.DedupedChunks)
+		if result.StoredChunks > 0 {
+			fmt.Fprintf(&sb, "  Stored as-is:    %d chunks (incompressible)\n", result.StoredChunks)
+		}
+		if result.RefChunks > 0 {
+			fmt.Fprintf(&sb, "  Referenced:      %d chunks, %.2f MiB (reference directories)\n", result.RefChunks, float64(result.RefBytes)/1024/1024)
+		}
 		fmt.Fprintf(&sb, "  Dedup ratio:     %.1f%%\n", result.DedupRatio())
 		fmt.Fprintf(&sb, "  Bytes saved:     %.2f MiB\n", float64(result.BytesSaved)/1024/1024)
 		if result.Evictions > 0 {
 			fmt.Fprintf(&sb, "  Evictions:       %d (LRU cache)\n", result.Evictions)
+			fmt.Fprintf(&sb, "  Cache hit rate:  %.1f%%\n", result.ChunkStore.HitRate())
+		}
+	}
+
+	// Per-extension dedup (verbose only), biggest extensions first
+	if opts != nil && opts.Verbose && len(result.Extensions) > 0 {
+		exts := slices.SortedFunc(maps.Keys(result.Extensions), func(a, b string) int {
+			return cmp.Or(
+				cmp.Compare(result.Extensions[b].OriginalSize, result.Extensions[a].OriginalSize),
+				strings.Compare(a, b),
+			)
+		})
+		sb.WriteString("\nDedup by extension:\n")
+		for _, ext := range exts {
+			s := result.Extensions[ext]
+			name := ext
+			if name == "" {
+				name = "(none)"
+			}
+			fmt.Fprintf(&sb, "  %-16s %d files, %s, %.1f%% deduped\n", name, s.Files, FormatSize(s.OriginalSize), s.DedupRatio())
+		}
+	}
+
+	// Timing breakdown (verbose only, it's mostly useful when tuning)
+	if opts != nil && opts.Verbose && result.Timing.Total > 0 {
+		t := result.Timing
+		sb.WriteString("\nTiming:\n")
+		fmt.Fprintf(&sb, "  Scan:            %s\n", t.Scan.Round(time.Millisecond))
+		if t.DictTraining > 0 {
+			fmt.Fprintf(&sb, "  Dict training:   %s\n", t.DictTraining.Round(time.Millisecond))
+		}
+		fmt.Fprintf(&sb, "  Total:           %s\n", t.Total.Round(time.Millisecond))
+		if t.EncodersCreated > 0 {
+			fmt.Fprintf(&sb, "  Encoders:        %d created in %s, reused across %d files (~%s saved)\n",
+				t.EncodersCreated, t.EncoderSetup.Round(time.Microsecond), result.FilesProcessed,
+				result.EncoderSetupSaved().Round(time.Microsecond))
 		}
 	}
 
@@ -57,6 +191,36 @@ func FormatSummary(result *Result, opts *Options) string {
 	return sb.String()
 }
 
+// FormatBreakdown formats Result.ByFolder or Result.ByExtension as a table
+// under title, biggest groups first
+func FormatBreakdown(title string, stats map[string]GroupStats) string {
+	if len(stats) == 0 {
+		return ""
+	}
+	keys := slices.SortedFunc(maps.Keys(stats), func(a, b string) int {
+		return cmp.Or(
+			cmp.Compare(stats[b].OriginalSize, stats[a].OriginalSize),
+			strings.Compare(a, b),
+		)
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n%s:\n", title)
+	for _, key := range keys {
+		s := stats[key]
+		name := key
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Fprintf(&sb, "  %-24s %6d files  %10s", TruncateLeft(name, 24), s.Files, FormatSize(s.OriginalSize))
+		if s.CompressedSize > 0 {
+			fmt.Fprintf(&sb, " -> %10s", FormatSize(s.CompressedSize))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
 // FormatSize formats bytes into human-readable string
 func FormatSize(bytes uint64) string {
 	return godelta.FormatSize(bytes)
@@ -0,0 +1,72 @@
+// pkg/compress/progress_test.go
+package compress
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProgressThrottling(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in")
+	if err := os.MkdirAll(input, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("progress "), 1<<20) // 9MB, a progress event per MB
+	if err := os.WriteFile(filepath.Join(input, "big.txt"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var withETA int
+	run := func(interval time.Duration, bytes uint64) (progress int, last ProgressEvent) {
+		var mu sync.Mutex
+		_, err := Compress(&Options{
+			InputPath:        input,
+			OutputPath:       filepath.Join(dir, "out.gdelta"),
+			MaxThreads:       1,
+			DisableSegments:  true,
+			ProgressInterval: interval,
+			ProgressBytes:    bytes,
+		}, func(event ProgressEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			if event.Type == EventFileProgress {
+				progress++
+				if event.ETA > 0 {
+					withETA++
+				}
+			}
+			last = event
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return progress, last
+	}
+
+	all, last := run(0, 0)
+	if all < 5 {
+		t.Fatalf("unthrottled run sent %d progress events", all)
+	}
+	// Every progress event but the one reaching the end has time left
+	if withETA == 0 {
+		t.Errorf("no progress event carried an ETA")
+	}
+	if last.Type != EventComplete || last.BytesPerSecond <= 0 || last.InstantBytesPerSecond <= 0 || last.Elapsed <= 0 {
+		t.Errorf("last event %+v, want complete with a throughput", last)
+	}
+	if last.ETA != 0 {
+		t.Errorf("complete event ETA %v, want 0", last.ETA)
+	}
+
+	if n, last := run(time.Hour, 0); n != 0 || last.Type != EventComplete {
+		t.Errorf("hourly interval: %d progress events, last %v", n, last.Type)
+	}
+	if n, _ := run(0, 4<<20); n == 0 || n > 2 {
+		t.Errorf("every 4MB of 9MB: %d progress events, want 1 or 2", n)
+	}
+}
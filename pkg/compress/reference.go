@@ -0,0 +1,135 @@
+// pkg/compress/reference.go
+package compress
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/creativeyann17/go-delta/internal/chunker"
+	"github.com/creativeyann17/go-delta/internal/format"
+)
+
+// referenceIndex maps the chunks of Options.ReferenceDirs to where they
+// lie, and records the ones the run takes from them instead of storing
+type referenceIndex struct {
+	all *format.ReferenceChunks
+
+	mu    sync.Mutex
+	used  map[[32]byte]struct{}
+	bytes uint64 // Original size of the used chunks
+}
+
+// buildReferenceIndex splits every regular file of the reference
+// directories with the run's chunker. A chunk found in several files keeps
+// its first location.
+func buildReferenceIndex(ctx context.Context, opts *Options) (*referenceIndex, error) {
+	refs := &referenceIndex{
+		all:  &format.ReferenceChunks{Chunks: make(map[[32]byte]format.ReferenceChunk)},
+		used: make(map[[32]byte]struct{}),
+	}
+	split := opts.newChunker()
+	for i, dir := range opts.ReferenceDirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("reference directory %s: %w", dir, err)
+		}
+		refs.all.Dirs = append(refs.all.Dirs, abs)
+		err = filepath.WalkDir(abs, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(abs, path)
+			if err != nil {
+				return err
+			}
+			return refs.addFile(split, i, path, filepath.ToSlash(rel))
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrReferenceDir, dir, err)
+		}
+	}
+	if opts.Verbose {
+		fmt.Printf("Reference chunks: %d in %d files\n", len(refs.all.Chunks), len(refs.all.Sources))
+	}
+	return refs, nil
+}
+
+// addFile indexes the chunks of the file at path, rel in directory dir
+func (r *referenceIndex) addFile(split *chunker.Chunker, dir int, path, rel string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	source := uint32(len(r.all.Sources))
+	var offset uint64
+	added := false
+	err = split.SplitWithCallback(f, func(c chunker.Chunk) error {
+		if _, ok := r.all.Chunks[c.Hash]; !ok {
+			r.all.Chunks[c.Hash] = format.ReferenceChunk{Source: source, Offset: offset, Size: uint32(c.OrigSize)}
+			added = true
+		}
+		offset += c.OrigSize
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", rel, err)
+	}
+	if added {
+		r.all.Sources = append(r.all.Sources, format.ReferenceSource{Dir: dir, Path: rel})
+	}
+	return nil
+}
+
+// take reports whether the chunk with this hash lies in a reference
+// directory, recording its use. Safe for concurrent use; false on a nil
+// index.
+func (r *referenceIndex) take(hash [32]byte) bool {
+	if r == nil {
+		return false
+	}
+	c, ok := r.all.Chunks[hash]
+	if ok {
+		r.mu.Lock()
+		if _, seen := r.used[hash]; !seen {
+			r.used[hash] = struct{}{}
+			r.bytes += uint64(c.Size)
+		}
+		r.mu.Unlock()
+	}
+	return ok
+}
+
+// extension returns the ExtReferenceChunks extension listing the chunks
+// taken, and the files holding them; ok is false when none was
+func (r *referenceIndex) extension() (ext format.Extension, ok bool, err error) {
+	if r == nil || len(r.used) == 0 {
+		return format.Extension{}, false, nil
+	}
+	taken := &format.ReferenceChunks{Dirs: r.all.Dirs, Chunks: make(map[[32]byte]format.ReferenceChunk, len(r.used))}
+	sources := make(map[uint32]uint32)
+	for hash := range r.used {
+		c := r.all.Chunks[hash]
+		source, ok := sources[c.Source]
+		if !ok {
+			source = uint32(len(taken.Sources))
+			sources[c.Source] = source
+			taken.Sources = append(taken.Sources, r.all.Sources[c.Source])
+		}
+		c.Source = source
+		taken.Chunks[hash] = c
+	}
+	ext, err = taken.Extension()
+	return ext, err == nil, err
+}
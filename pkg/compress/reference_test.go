@@ -0,0 +1,121 @@
+// pkg/compress/reference_test.go
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+func TestReferenceDirs(t *testing.T) {
+	dir := t.TempDir()
+	rng := rand.New(rand.NewSource(1))
+	old := make([]byte, 128*1024)
+	rng.Read(old)
+	extra := make([]byte, 16*1024)
+	rng.Read(extra)
+	grown := append(append([]byte{}, old...), extra...)
+
+	// Yesterday's backup, and today's input where big.bin grew by a block
+	writeFiles := func(root string, files map[string][]byte) {
+		t.Helper()
+		for name, data := range files {
+			path := filepath.Join(root, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	refDir := filepath.Join(dir, "yesterday")
+	input := filepath.Join(dir, "today")
+	writeFiles(refDir, map[string][]byte{"data/big.bin": old, "gone.txt": []byte("deleted since")})
+	writeFiles(input, map[string][]byte{"data/big.bin": grown, "new.txt": []byte("hello")})
+
+	archive := filepath.Join(dir, "delta.gdelta")
+	result, err := Compress(&Options{
+		InputPath:     input,
+		OutputPath:    archive,
+		ChunkSize:     16 * 1024,
+		ChunkingMode:  ChunkingFixed,
+		ReferenceDirs: []string{refDir},
+		MaxThreads:    2,
+		Quiet:         true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RefChunks != 8 || result.RefBytes != uint64(len(old)) {
+		t.Errorf("referenced %d chunks, %d bytes, want 8 and %d", result.RefChunks, result.RefBytes, len(old))
+	}
+	if result.CompressedSize > 32*1024 {
+		t.Errorf("archive is %d bytes, want only the new block", result.CompressedSize)
+	}
+
+	v, err := verify.Verify(&verify.Options{InputPath: archive, VerifyData: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.IsValid() || v.RefChunks != 8 || v.ChunkCount != 2 {
+		t.Errorf("verify: valid %v, %d reference chunks, %d chunks: %v", v.IsValid(), v.RefChunks, v.ChunkCount, v.Errors)
+	}
+
+	restore := func(refDirs ...string) error {
+		out := t.TempDir()
+		r, err := decompress.Decompress(&decompress.Options{
+			InputPath:     archive,
+			OutputPath:    out,
+			ReferenceDirs: refDirs,
+			Quiet:         true,
+		}, nil)
+		if err != nil {
+			return err
+		}
+		if len(r.Errors) > 0 {
+			return r.Errors[0]
+		}
+		got, err := os.ReadFile(filepath.Join(out, "data", "big.bin"))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, grown) {
+			t.Error("restored big.bin differs")
+		}
+		return nil
+	}
+	if err := restore(); err != nil {
+		t.Fatalf("restore from the recorded directory: %v", err)
+	}
+
+	// The reference directory moved
+	moved := filepath.Join(dir, "nas")
+	if err := os.Rename(refDir, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := restore(moved); err != nil {
+		t.Fatalf("restore from the moved directory: %v", err)
+	}
+	if err := restore(moved, dir); !errors.Is(err, decompress.ErrReferenceDirs) {
+		t.Errorf("two directories: got %v, want ErrReferenceDirs", err)
+	}
+
+	// The reference file changed
+	changed := append([]byte{}, old...)
+	changed[0] ^= 1
+	writeFiles(moved, map[string][]byte{"data/big.bin": changed})
+	if err := restore(moved); err == nil || !errors.Is(err, decompress.ErrReferenceChunk) {
+		t.Errorf("changed reference: got %v, want ErrReferenceChunk", err)
+	}
+
+	if _, err := Compress(&Options{InputPath: input, OutputPath: archive, ReferenceDirs: []string{moved}}, nil); !errors.Is(err, ErrReferenceDirsFormat) {
+		t.Errorf("without chunking: got %v, want ErrReferenceDirsFormat", err)
+	}
+}
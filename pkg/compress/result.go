@@ -1,6 +1,17 @@
 // pkg/compress/result.go
 package compress
 
+import (
+	"maps"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creativeyann17/go-delta/internal/chunkstore"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
 // Result contains statistics about the compression operation
 type Result struct {
 	// Total number of files found
@@ -9,12 +20,39 @@ type Result struct {
 	// Number of files successfully compressed
 	FilesProcessed int
 
+	// StoredFiles counts processed files written without compression
+	// because their extension marks them as already compressed
+	StoredFiles int
+
+	// SegmentedFiles counts processed files split into zstd frames that
+	// were compressed in parallel (see Options.SegmentSize)
+	SegmentedFiles int
+
+	// PackedFiles counts processed files written into Packs shared frames
+	// (see Options.PackSmallFiles)
+	PackedFiles int
+	Packs       int
+
+	// LinkedFiles counts processed files archived as links to an earlier
+	// file with the same content, LinkedBytes their size (see
+	// Options.DedupFiles)
+	LinkedFiles int
+	LinkedBytes uint64
+
+	// Types counts the scanned files by type sniffed from their content
+	// (see Options.SniffTypes), nil when types weren't sniffed
+	Types map[string]int
+
 	// Total original size in bytes
 	OriginalSize uint64
 
 	// Total compressed size in bytes
 	CompressedSize uint64
 
+	// ParitySize is the size of the parity section appended to the archive
+	// (0 without Options.ParityPercent); CompressedSize doesn't include it
+	ParitySize uint64
+
 	// ChunkSize is the configured chunk size (0 if chunking disabled)
 	ChunkSize uint64
 
@@ -22,13 +60,292 @@ type Result struct {
 	TotalChunks   uint64 // Total chunks processed
 	UniqueChunks  uint64 // Unique chunks stored
 	DedupedChunks uint64 // Chunks that were deduplicated
+	StoredChunks  uint64 // Unique chunks kept uncompressed, compression not shrinking them
+	RefChunks     uint64 // Unique chunks taken from Options.ReferenceDirs instead of stored
+	RefBytes      uint64 // Their original size
 	BytesSaved    uint64 // Bytes saved through deduplication
 	Evictions     uint64 // Chunks evicted from LRU cache (doesn't affect archive)
 
+	// ChunkStore is the final chunk store snapshot (when chunking enabled)
+	ChunkStore ChunkStoreStats
+
+	// Extensions breaks deduplication down by lowercased file extension
+	// ("" for files without one), when chunking enabled
+	Extensions map[string]ExtensionStats
+
+	// ByFolder and ByExtension break the processed files down by top-level
+	// folder ("." for files at the root) and by lowercased extension ("" for
+	// files without one)
+	ByFolder    map[string]GroupStats
+	ByExtension map[string]GroupStats
+
+	// MemoryBudget is Options.MemoryBudget, and MemoryPeak the most of it
+	// held at once (both 0 without a budget)
+	MemoryBudget uint64
+	MemoryPeak   uint64
+
+	// ThreadsLowest is the fewest files Options.AdaptiveThreads let the run
+	// compress at once, and ThreadAdjustments how often the governor changed
+	// that limit (both 0 without AdaptiveThreads)
+	ThreadsLowest     int
+	ThreadAdjustments int
+
+	// governor caps the files compressed at once with AdaptiveThreads
+	governor *governor
+
+	// AutoTune reports how Options.AutoLevel and AutoChunkSize picked the
+	// settings of the run (nil without them)
+	AutoTune *AutoTune
+
+	// Fuzzy lists the files that changed while they were read (size or
+	// modification time) and were stored as read, cut to or padded with
+	// zeros up to their scanned size: their entry is consistent but its
+	// content may mix old and new data (see Options.ChangeRetries)
+	Fuzzy []string
+
+	// breakdown accumulates ByFolder and ByExtension during the run
+	breakdown *breakdownTally
+
+	// Locked lists the files skipped because another process kept them
+	// locked (Options.SkipLockedFiles)
+	Locked []string
+
+	// sources opens the files and collects Fuzzy and Locked during the run
+	sources *sourceSet
+
+	// ManifestPath is the SHA-256 manifest written with
+	// Options.WriteManifest ("" without one, or with SplitByFolder)
+	ManifestPath string
+
+	// Verify is the read-back of the archive with Options.VerifyAfter (nil
+	// without it, or with SplitByFolder where each split has its own)
+	Verify *verify.Result
+
+	// Splits lists the archives written with Options.SplitByFolder, in
+	// the order they were written (nil without it)
+	Splits []SplitArchive
+
+	// Timing breaks down where the run spent its time
+	Timing Timing
+
 	// List of errors encountered (non-fatal)
 	Errors []error
 }
 
+// ChunkStoreStats is a snapshot of the GDELTA02 chunk store. Progress
+// events carry one while chunking runs, so dashboards can follow dedup
+// effectiveness and cache pressure live.
+type ChunkStoreStats struct {
+	TotalChunks   uint64 `json:"total_chunks"`   // Chunks looked up so far
+	UniqueChunks  uint64 `json:"unique_chunks"`  // Chunks written to the archive
+	DedupedChunks uint64 `json:"deduped_chunks"` // Chunks found already stored
+	BytesSaved    uint64 `json:"bytes_saved"`    // Compressed bytes not written thanks to dedup
+
+	CacheHits     uint64 `json:"cache_hits"`     // Duplicates found in the LRU cache
+	IndexHits     uint64 `json:"index_hits"`     // Duplicates found in the index after eviction
+	InflightWaits uint64 `json:"inflight_waits"` // Duplicates that waited for another worker's write
+	Evictions     uint64 `json:"evictions"`      // Chunks evicted from the LRU cache
+
+	CachedChunks uint64 `json:"cached_chunks"` // Chunks currently in the LRU cache
+	MemoryBytes  uint64 `json:"memory_bytes"`  // Estimated memory held by the cache and index
+
+	// LockContention counts lookups that found the store lock held by
+	// another worker; the store has a single lock, so a high value relative
+	// to TotalChunks means the workers are serializing on it
+	LockContention uint64 `json:"lock_contention"`
+}
+
+// HitRate returns the share of lookups served by the LRU cache as a percentage
+func (s ChunkStoreStats) HitRate() float64 {
+	if s.TotalChunks == 0 {
+		return 0
+	}
+	return float64(s.CacheHits) / float64(s.TotalChunks) * 100
+}
+
+// DedupRatio returns the deduplication ratio as a percentage
+func (s ChunkStoreStats) DedupRatio() float64 {
+	if s.TotalChunks == 0 {
+		return 0
+	}
+	return float64(s.DedupedChunks) / float64(s.TotalChunks) * 100
+}
+
+// ExtensionStats are the deduplication figures of the files of one
+// extension
+type ExtensionStats struct {
+	Files         int    // Files chunked
+	OriginalSize  uint64 // Their total size
+	Chunks        uint64 // Chunks cut from them
+	DedupedChunks uint64 // Chunks found already stored
+	DedupedBytes  uint64 // Original size of the deduplicated chunks
+}
+
+// DedupRatio returns the share of the extension's bytes that were
+// deduplicated, as a percentage
+func (s ExtensionStats) DedupRatio() float64 {
+	if s.OriginalSize == 0 {
+		return 0
+	}
+	return float64(s.DedupedBytes) / float64(s.OriginalSize) * 100
+}
+
+// extensionTally accumulates Result.Extensions across workers
+type extensionTally struct {
+	mu    sync.Mutex
+	stats map[string]ExtensionStats
+}
+
+func newExtensionTally() *extensionTally {
+	return &extensionTally{stats: make(map[string]ExtensionStats)}
+}
+
+// add records one chunked file
+func (t *extensionTally) add(relPath string, size, chunks, deduped, dedupedBytes uint64) {
+	ext := fileExt(relPath)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.stats[ext]
+	s.Files++
+	s.OriginalSize += size
+	s.Chunks += chunks
+	s.DedupedChunks += deduped
+	s.DedupedBytes += dedupedBytes
+	t.stats[ext] = s
+}
+
+// snapshot returns the tallied figures, nil when no file was chunked
+func (t *extensionTally) snapshot() map[string]ExtensionStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.stats) == 0 {
+		return nil
+	}
+	return maps.Clone(t.stats)
+}
+
+// GroupStats are the totals of the processed files of a folder or an
+// extension. CompressedSize is their share of the archive: 0 where the
+// format has no per-file size (XZ, tar.gz and 7z streams, multi-part ZIP)
+// and, in GDELTA02, the chunks first stored by these files.
+type GroupStats struct {
+	Files          int
+	OriginalSize   uint64
+	CompressedSize uint64
+}
+
+// breakdownTally accumulates Result.ByFolder and Result.ByExtension across
+// workers
+type breakdownTally struct {
+	mu         sync.Mutex
+	folders    map[string]GroupStats
+	extensions map[string]GroupStats
+}
+
+func newBreakdownTally() *breakdownTally {
+	return &breakdownTally{
+		folders:    make(map[string]GroupStats),
+		extensions: make(map[string]GroupStats),
+	}
+}
+
+// add records one processed file; a nil tally records nothing
+func (t *breakdownTally) add(relPath string, origSize, comprSize uint64) {
+	if t == nil {
+		return
+	}
+	folder, _, ok := strings.Cut(filepath.ToSlash(relPath), "/")
+	if !ok {
+		folder = "."
+	}
+	ext := fileExt(relPath)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, group := range []struct {
+		stats map[string]GroupStats
+		key   string
+	}{{t.folders, folder}, {t.extensions, ext}} {
+		s := group.stats[group.key]
+		s.Files++
+		s.OriginalSize += origSize
+		s.CompressedSize += comprSize
+		group.stats[group.key] = s
+	}
+}
+
+// merge adds the tallies of another run
+func (t *breakdownTally) merge(folders, extensions map[string]GroupStats) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, group := range []struct {
+		into, from map[string]GroupStats
+	}{{t.folders, folders}, {t.extensions, extensions}} {
+		for key, from := range group.from {
+			s := group.into[key]
+			s.Files += from.Files
+			s.OriginalSize += from.OriginalSize
+			s.CompressedSize += from.CompressedSize
+			group.into[key] = s
+		}
+	}
+}
+
+// snapshot returns the tallied folders and extensions, nil when no file
+// was processed
+func (t *breakdownTally) snapshot() (folders, extensions map[string]GroupStats) {
+	if t == nil {
+		return nil, nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.folders) == 0 {
+		return nil, nil
+	}
+	return maps.Clone(t.folders), maps.Clone(t.extensions)
+}
+
+func chunkStoreStats(s chunkstore.Stats) ChunkStoreStats {
+	return ChunkStoreStats{
+		TotalChunks:    s.TotalChunks,
+		UniqueChunks:   s.UniqueChunks,
+		DedupedChunks:  s.DedupedChunks,
+		BytesSaved:     s.BytesSaved,
+		CacheHits:      s.CacheHits,
+		IndexHits:      s.IndexHits,
+		InflightWaits:  s.InflightWaits,
+		Evictions:      s.Evictions,
+		CachedChunks:   s.CachedChunks,
+		MemoryBytes:    s.MemoryBytes,
+		LockContention: s.LockContention,
+	}
+}
+
+// Timing records phase durations and zstd encoder setup cost
+type Timing struct {
+	Scan         time.Duration // Walking the input and collecting files
+	DictTraining time.Duration // Sampling and building the dictionary (GDELTA03, ChunkDictionary)
+	Total        time.Duration // Whole run, including the phases above
+
+	// Encoders are created once per worker and reused across files via
+	// Reset, so EncodersCreated tracks the worker count, not the file count.
+	// Encoders without a dictionary are also pooled across runs, so a
+	// repeated run may create none.
+	EncodersCreated int
+	EncoderSetup    time.Duration // Total time spent creating encoders
+}
+
+// EncoderSetupSaved estimates the setup time avoided by reusing encoders,
+// compared to creating a fresh one for every processed file
+func (r *Result) EncoderSetupSaved() time.Duration {
+	t := r.Timing
+	if t.EncodersCreated == 0 || r.FilesProcessed <= t.EncodersCreated {
+		return 0
+	}
+	perEncoder := t.EncoderSetup / time.Duration(t.EncodersCreated)
+	return perEncoder * time.Duration(r.FilesProcessed-t.EncodersCreated)
+}
+
 // CompressionRatio returns the compression ratio as a percentage
 func (r *Result) CompressionRatio() float64 {
 	if r.OriginalSize == 0 {
@@ -0,0 +1,175 @@
+// pkg/compress/segment.go
+package compress
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/pool"
+)
+
+// DefaultSegmentSize is the size of the independent zstd frames a large
+// file is split into when Options.SegmentSize is 0
+const DefaultSegmentSize = 64 << 20
+
+// Segment size bounds: small segments hurt the ratio, and the seek table
+// records sizes as 32-bit values
+const (
+	minSegmentSize = 1 << 20
+	maxSegmentSize = 1 << 30
+)
+
+// segmenter compresses large files as independent zstd frames in parallel.
+// It is shared by all workers of a run: sem bounds the segments in flight
+// across files, so two huge files don't double the memory used.
+type segmenter struct {
+	size   int // bytes of original data per segment
+	level  int
+	window int
+	sem    chan struct{}
+	budget *memoryBudget
+
+	encoders pool.EncoderConfig // concurrency 1, used via EncodeAll
+}
+
+// newSegmenter returns the run's segmenter, or nil when segmenting is off.
+// The segment size grows to four windows so a long window keeps its reach,
+// and shrinks so that a segment and its output fit in MaxThreadMemory.
+// Segments in flight also acquire their memory from budget.
+func newSegmenter(opts *Options, budget *memoryBudget) *segmenter {
+	if opts.DisableSegments || opts.codecMethod() != format.MethodZstd {
+		return nil
+	}
+	size := opts.SegmentSize
+	if size == 0 {
+		size = DefaultSegmentSize
+	}
+	if window := uint64(opts.zstdWindow()); size < 4*window {
+		size = 4 * window
+	}
+	if opts.MaxThreadMemory > 0 && size > opts.MaxThreadMemory/2 {
+		size = opts.MaxThreadMemory / 2
+	}
+	size = min(max(size, minSegmentSize), maxSegmentSize)
+	return &segmenter{
+		size:   int(size),
+		level:  opts.Level,
+		window: opts.zstdWindow(),
+		sem:    make(chan struct{}, opts.MaxThreads),
+		budget: budget,
+
+		encoders: pool.EncoderConfig{Level: opts.Level, Concurrency: 1, Window: opts.zstdWindow()},
+	}
+}
+
+// memory estimates what one segment in flight holds: its data, the
+// compressed copy and a single-threaded encoder
+func (s *segmenter) memory() uint64 {
+	return uint64(s.size) + compressBound(uint64(s.size)) + encoderMemory(s.level, s.window, 1)
+}
+
+// applies reports whether a file of origSize bytes is split into segments
+func (s *segmenter) applies(origSize uint64) bool {
+	return s != nil && origSize > uint64(s.size)
+}
+
+// segmentResult is one compressed segment, handed back in file order
+type segmentResult struct {
+	data    []byte
+	origLen int
+	err     error
+}
+
+// compress writes the file as a sequence of zstd frames followed by a seek
+// table, returning the bytes written. Segments are read and compressed
+// concurrently and written in order; a segment holds its semaphore slot
+// and its memory until it is written, so finished segments waiting on a
+// slow one count against the limits too.
+func (s *segmenter) compress(task fileTask, w io.Writer, onProgress func(done uint64)) (uint64, error) {
+	src, err := openSource(task)
+	if err != nil {
+		return 0, fmt.Errorf("open source file: %w", err)
+	}
+	defer src.Close()
+
+	count := int((task.OrigSize + uint64(s.size) - 1) / uint64(s.size))
+	pending := make(chan chan segmentResult, cap(s.sem))
+	stop := make(chan struct{})
+
+	// Producer: one goroutine per segment, at most cap(s.sem) at a time
+	go func() {
+		defer close(pending)
+		for i := range count {
+			select {
+			case s.sem <- struct{}{}:
+			case <-stop:
+				return
+			}
+			s.budget.acquire(s.memory())
+			out := make(chan segmentResult, 1)
+			select {
+			case pending <- out:
+			case <-stop:
+				s.budget.release(s.memory())
+				<-s.sem
+				return
+			}
+			go func(off int64) {
+				out <- s.compressSegment(src, off)
+			}(int64(i) * int64(s.size))
+		}
+	}()
+
+	segments := make([]format.Segment, 0, count)
+	var written, done uint64
+	for out := range pending {
+		res := <-out
+		if err == nil && res.err != nil {
+			err = res.err
+			close(stop)
+		}
+		// After an error, keep draining so in-flight segments release
+		// their slots
+		if err == nil {
+			if _, werr := w.Write(res.data); werr != nil {
+				err = fmt.Errorf("write segment: %w", werr)
+				close(stop)
+			} else {
+				segments = append(segments, format.Segment{CompressedSize: uint32(len(res.data)), OrigSize: uint32(res.origLen)})
+				written += uint64(len(res.data))
+				done += uint64(res.origLen)
+				onProgress(done)
+			}
+		}
+		s.budget.release(s.memory())
+		<-s.sem
+	}
+	if err != nil {
+		return 0, err
+	}
+	if done != task.OrigSize {
+		return 0, fmt.Errorf("file changed during compression: read %d of %d bytes", done, task.OrigSize)
+	}
+
+	if err := format.WriteSeekTable(w, segments); err != nil {
+		return 0, fmt.Errorf("write seek table: %w", err)
+	}
+	return written + uint64(format.SeekTableSize(len(segments))), nil
+}
+
+// compressSegment reads and compresses the segment starting at off
+func (s *segmenter) compressSegment(src io.ReaderAt, off int64) segmentResult {
+	buf := make([]byte, s.size)
+	n, err := src.ReadAt(buf, off)
+	if err != nil && err != io.EOF {
+		return segmentResult{err: fmt.Errorf("read segment at %d: %w", off, err)}
+	}
+	enc, _, err := s.encoders.Encoder()
+	if err != nil {
+		return segmentResult{err: fmt.Errorf("create zstd encoder: %w", err)}
+	}
+	data := enc.EncodeAll(buf[:n], make([]byte, 0, n/2))
+	s.encoders.PutEncoder(enc)
+	return segmentResult{data: data, origLen: n}
+}
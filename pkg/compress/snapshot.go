@@ -0,0 +1,23 @@
+// pkg/compress/snapshot.go
+package compress
+
+// SnapshotMode selects how the input is frozen for a run
+type SnapshotMode string
+
+const (
+	// SnapshotNone reads the live files
+	SnapshotNone SnapshotMode = ""
+
+	// SnapshotAuto takes the platform's snapshot of the input's volume: a
+	// btrfs or LVM snapshot on Linux, a Volume Shadow Copy on Windows
+	SnapshotAuto SnapshotMode = "auto"
+
+	// SnapshotVSS reads a Volume Shadow Copy of the input's volume (Windows)
+	SnapshotVSS SnapshotMode = "vss"
+)
+
+// snapshot is a point-in-time copy of the input, removed by release
+type snapshot struct {
+	path    string // the input path inside the snapshot
+	release func() error
+}
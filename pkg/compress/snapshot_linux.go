@@ -0,0 +1,248 @@
+//go:build linux
+
+package compress
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// btrfsSubvolumeIno is the inode number of every btrfs subvolume root
+const btrfsSubvolumeIno = 256
+
+// lvmSnapshotExtents is the copy-on-write space of a classic LVM snapshot,
+// which is dropped if the origin changes more than that during the run
+const lvmSnapshotExtents = "10%ORIGIN"
+
+// takeSnapshot snapshots the volume holding path: a read-only snapshot of
+// its btrfs subvolume, or an LVM snapshot mounted read-only in a temp
+// directory. Both need root and the btrfs or lvm2 tools.
+func takeSnapshot(mode SnapshotMode, path string) (*snapshot, error) {
+	if mode != SnapshotAuto {
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotUnsupported, mode)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if abs, err = filepath.EvalSymlinks(abs); err != nil {
+		return nil, err
+	}
+	mounts, err := readMountInfo("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	mount, ok := findMount(mounts, abs)
+	if !ok {
+		return nil, fmt.Errorf("%w: no mount holds %s", ErrSnapshotUnsupported, abs)
+	}
+
+	if mount.fsType == "btrfs" {
+		return btrfsSnapshot(mount, abs)
+	}
+	return lvmSnapshot(mount, abs)
+}
+
+// btrfsSnapshot snapshots the subvolume holding abs inside itself
+func btrfsSnapshot(mount mountInfo, abs string) (*snapshot, error) {
+	subvol, err := btrfsSubvolume(mount, abs)
+	if err != nil {
+		return nil, err
+	}
+	snapDir := filepath.Join(subvol, fmt.Sprintf(".godelta-snapshot-%d", os.Getpid()))
+	if _, err := runTool("btrfs", "subvolume", "snapshot", "-r", subvol, snapDir); err != nil {
+		return nil, fmt.Errorf("create btrfs snapshot: %w", err)
+	}
+	rel, _ := filepath.Rel(subvol, abs)
+	return &snapshot{
+		path: filepath.Join(snapDir, rel),
+		release: func() error {
+			_, err := runTool("btrfs", "subvolume", "delete", snapDir)
+			return err
+		},
+	}, nil
+}
+
+// btrfsSubvolume returns the root of the subvolume holding abs, the first
+// directory up from it with the subvolume inode number
+func btrfsSubvolume(mount mountInfo, abs string) (string, error) {
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return "", err
+		}
+		if st, ok := info.Sys().(*syscall.Stat_t); ok && info.IsDir() && st.Ino == btrfsSubvolumeIno {
+			return dir, nil
+		}
+		if dir == mount.point || dir == filepath.Dir(dir) {
+			return "", fmt.Errorf("%w: no btrfs subvolume holds %s", ErrSnapshotUnsupported, abs)
+		}
+	}
+}
+
+// lvmSnapshot snapshots the logical volume mounted at mount and mounts the
+// snapshot read-only in a temp directory
+func lvmSnapshot(mount mountInfo, abs string) (*snapshot, error) {
+	out, err := runTool("lvs", "--noheadings", "--separator", "|", "-o", "vg_name,lv_name,segtype", mount.source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s (%s) is not a btrfs subvolume or an LVM volume: %v",
+			ErrSnapshotUnsupported, mount.point, mount.source, err)
+	}
+	fields := strings.Split(out, "|")
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("lvs %s: unexpected output %q", mount.source, out)
+	}
+	vg, lv, segType := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1]), strings.TrimSpace(fields[2])
+
+	name := fmt.Sprintf("godelta-snapshot-%d", os.Getpid())
+	args := []string{"--snapshot", "--name", name}
+	if segType == "thin" {
+		// Thin snapshots share the pool and skip activation by default
+		args = append(args, "--setactivationskip", "n")
+	} else {
+		args = append(args, "--extents", lvmSnapshotExtents)
+	}
+	if _, err := runTool("lvcreate", append(args, vg+"/"+lv)...); err != nil {
+		return nil, fmt.Errorf("create LVM snapshot: %w", err)
+	}
+	remove := func() error {
+		_, err := runTool("lvremove", "--force", vg+"/"+name)
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "godelta-snapshot-")
+	if err != nil {
+		return nil, errors.Join(err, remove())
+	}
+	mountOpts := "ro"
+	if mount.fsType == "xfs" {
+		// The snapshot has the UUID of the mounted origin
+		mountOpts += ",nouuid"
+	}
+	if _, err := runTool("mount", "-t", mount.fsType, "-o", mountOpts, "/dev/"+vg+"/"+name, dir); err != nil {
+		return nil, errors.Join(fmt.Errorf("mount LVM snapshot: %w", err), os.Remove(dir), remove())
+	}
+
+	rel, _ := filepath.Rel(mount.point, abs)
+	return &snapshot{
+		path: filepath.Join(dir, mount.root, rel),
+		release: func() error {
+			if _, err := runTool("umount", dir); err != nil {
+				return err
+			}
+			return errors.Join(os.Remove(dir), remove())
+		},
+	}, nil
+}
+
+// mountInfo is a line of /proc/self/mountinfo
+type mountInfo struct {
+	root   string // directory of the filesystem mounted
+	point  string
+	fsType string
+	source string
+}
+
+// readMountInfo parses the mountinfo file at path
+func readMountInfo(path string) ([]mountInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []mountInfo
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if mount, ok := parseMountInfo(scanner.Text()); ok {
+			mounts = append(mounts, mount)
+		}
+	}
+	return mounts, scanner.Err()
+}
+
+// parseMountInfo parses "id parent major:minor root point options
+// [optional...] - fstype source superoptions"
+func parseMountInfo(line string) (mountInfo, bool) {
+	fields := strings.Fields(line)
+	sep := -1
+	for i := 6; i < len(fields); i++ {
+		if fields[i] == "-" {
+			sep = i
+			break
+		}
+	}
+	if len(fields) < 5 || sep < 0 || sep+2 >= len(fields) {
+		return mountInfo{}, false
+	}
+	return mountInfo{
+		root:   unescapeMountPath(fields[3]),
+		point:  unescapeMountPath(fields[4]),
+		fsType: fields[sep+1],
+		source: unescapeMountPath(fields[sep+2]),
+	}, true
+}
+
+// unescapeMountPath decodes the octal escapes (\040 for a space) of a
+// mountinfo path
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// findMount returns the mount holding path, the last one mounted at its
+// deepest mount point
+func findMount(mounts []mountInfo, path string) (mountInfo, bool) {
+	var best mountInfo
+	found := false
+	for _, mount := range mounts {
+		if !pathWithin(path, mount.point) {
+			continue
+		}
+		if !found || len(mount.point) >= len(best.point) {
+			best, found = mount, true
+		}
+	}
+	return best, found
+}
+
+// pathWithin reports whether path is dir or below it
+func pathWithin(path, dir string) bool {
+	if dir == "/" || path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, dir+"/")
+}
+
+// runTool runs a command and returns its trimmed output
+func runTool(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
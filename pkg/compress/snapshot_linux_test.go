@@ -0,0 +1,39 @@
+//go:build linux
+
+package compress
+
+import "testing"
+
+func TestFindMount(t *testing.T) {
+	lines := []string{
+		"22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw",
+		"35 22 253:0 / /srv rw,relatime shared:2 - xfs /dev/mapper/vg0-srv rw",
+		"36 22 0:40 /@home /home rw,relatime shared:3 - btrfs /dev/sdb1 rw,subvol=/@home",
+		"37 22 253:1 / /mnt/my\\040disk rw - ext4 /dev/mapper/vg0-data rw",
+		"garbage",
+	}
+	var mounts []mountInfo
+	for _, line := range lines {
+		if mount, ok := parseMountInfo(line); ok {
+			mounts = append(mounts, mount)
+		}
+	}
+	if len(mounts) != 4 {
+		t.Fatalf("parsed %d mounts, want 4", len(mounts))
+	}
+
+	tests := []struct {
+		path, point, fsType, root string
+	}{
+		{"/srv/data/db", "/srv", "xfs", "/"},
+		{"/srvx", "/", "ext4", "/"},
+		{"/home/me", "/home", "btrfs", "/@home"},
+		{"/mnt/my disk/photos", "/mnt/my disk", "ext4", "/"},
+	}
+	for _, tt := range tests {
+		mount, ok := findMount(mounts, tt.path)
+		if !ok || mount.point != tt.point || mount.fsType != tt.fsType || mount.root != tt.root {
+			t.Errorf("%s: got %+v, want mount %s (%s, root %s)", tt.path, mount, tt.point, tt.fsType, tt.root)
+		}
+	}
+}
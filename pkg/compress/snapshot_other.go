@@ -0,0 +1,10 @@
+//go:build !windows && !linux
+
+package compress
+
+import "fmt"
+
+// takeSnapshot has no snapshot mode to offer on this platform
+func takeSnapshot(mode SnapshotMode, _ string) (*snapshot, error) {
+	return nil, fmt.Errorf("%w: %s", ErrSnapshotUnsupported, mode)
+}
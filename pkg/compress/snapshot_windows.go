@@ -0,0 +1,62 @@
+//go:build windows
+
+package compress
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// takeSnapshot creates a Volume Shadow Copy of the volume holding path
+// through the Win32_ShadowCopy WMI class, which needs an elevated process.
+// The copy is read through its device path and deleted by release.
+// SnapshotAuto is a shadow copy too.
+func takeSnapshot(mode SnapshotMode, path string) (*snapshot, error) {
+	if mode != SnapshotVSS && mode != SnapshotAuto {
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotUnsupported, mode)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	volume := filepath.VolumeName(abs)
+	if len(volume) != 2 || volume[1] != ':' {
+		return nil, fmt.Errorf("%w: shadow copies need a local drive, not %q", ErrSnapshotUnsupported, volume)
+	}
+
+	out, err := powershell(fmt.Sprintf(
+		`$r = (Get-WmiObject -List Win32_ShadowCopy).Create('%s\', 'ClientAccessible'); `+
+			`if ($r.ReturnValue -ne 0) { Write-Error "Win32_ShadowCopy.Create returned $($r.ReturnValue)"; exit 1 }; `+
+			`$s = Get-WmiObject Win32_ShadowCopy | Where-Object { $_.ID -eq $r.ShadowID }; `+
+			`Write-Output "$($s.ID)|$($s.DeviceObject)"`, volume))
+	if err != nil {
+		return nil, fmt.Errorf("create shadow copy: %w", err)
+	}
+	id, device, ok := strings.Cut(out, "|")
+	if !ok || device == "" {
+		return nil, fmt.Errorf("create shadow copy: unexpected output %q", out)
+	}
+
+	return &snapshot{
+		path: device + abs[len(volume):],
+		release: func() error {
+			_, err := powershell(fmt.Sprintf(
+				`Get-WmiObject Win32_ShadowCopy | Where-Object { $_.ID -eq '%s' } | ForEach-Object { $_.Delete() }`, id))
+			return err
+		},
+	}, nil
+}
+
+// powershell runs script and returns its trimmed output
+func powershell(script string) (string, error) {
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,136 @@
+// pkg/compress/source.go
+package compress
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// lockedRetryDelay is the wait before opening a locked file again
+const lockedRetryDelay = 500 * time.Millisecond
+
+// sourceSet is the state a run shares across the files it reads: how
+// locked files are handled, and which files changed while read or were
+// skipped as locked. A nil sourceSet opens files once and records nothing.
+type sourceSet struct {
+	lockedRetries int
+	skipLocked    bool
+
+	noPageCache bool // Options.NoPageCache
+
+	fuzzy  *pathTally // Result.Fuzzy
+	locked *pathTally // Result.Locked
+}
+
+func newSourceSet(opts *Options) *sourceSet {
+	s := &sourceSet{
+		lockedRetries: opts.LockedFileRetries,
+		skipLocked:    opts.SkipLockedFiles,
+		noPageCache:   opts.NoPageCache,
+		fuzzy:         newPathTally(),
+	}
+	if s.skipLocked {
+		s.locked = newPathTally()
+	}
+	return s
+}
+
+// open opens the file of task without denying other processes access to
+// it, trying again while another process holds it locked
+func (s *sourceSet) open(task fileTask) (*os.File, error) {
+	for attempt := 0; ; attempt++ {
+		file, err := openShared(task.AbsPath)
+		if err == nil && s != nil && s.noPageCache {
+			adviseNoCache(file)
+		}
+		if err == nil || !isLocked(err) {
+			return file, err
+		}
+		if s == nil || attempt >= s.lockedRetries {
+			if s != nil && s.skipLocked {
+				s.locked.add(task.RelPath)
+			}
+			return nil, fmt.Errorf("%w: %w", ErrFileLocked, err)
+		}
+		time.Sleep(lockedRetryDelay)
+	}
+}
+
+// uncache evicts the pages of file read from off for n bytes (to the end
+// when n is 0) with Options.NoPageCache
+func (s *sourceSet) uncache(file *os.File, off, n int64) {
+	if s != nil && s.noPageCache {
+		dropCache(file, off, n)
+	}
+}
+
+// changed records a file that changed while it was read
+func (s *sourceSet) changed(relPath string) {
+	if s != nil {
+		s.fuzzy.add(relPath)
+	}
+}
+
+// retry takes back the change of a file about to be read again, reporting
+// whether it had changed
+func (s *sourceSet) retry(relPath string) bool {
+	return s != nil && s.fuzzy.take(relPath)
+}
+
+// skipped reports whether relPath was skipped as locked
+func (s *sourceSet) skipped(relPath string) bool {
+	return s != nil && s.locked.has(relPath)
+}
+
+// pathTally is a set of relative paths, safe for concurrent use. A nil
+// pathTally holds nothing.
+type pathTally struct {
+	mu    sync.Mutex
+	paths map[string]struct{}
+}
+
+func newPathTally() *pathTally {
+	return &pathTally{paths: make(map[string]struct{})}
+}
+
+func (t *pathTally) add(relPath string) {
+	t.mu.Lock()
+	t.paths[relPath] = struct{}{}
+	t.mu.Unlock()
+}
+
+func (t *pathTally) has(relPath string) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.paths[relPath]
+	return ok
+}
+
+// take removes relPath, reporting whether it was there
+func (t *pathTally) take(relPath string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.paths[relPath]
+	delete(t.paths, relPath)
+	return ok
+}
+
+// snapshot returns the paths, sorted
+func (t *pathTally) snapshot() []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.paths) == 0 {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(t.paths))
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package compress
+
+import "os"
+
+// openShared opens path for reading; other platforms don't deny access to
+// open files
+func openShared(path string) (*os.File, error) {
+	return os.Open(path)
+}
+
+// isLocked reports whether err is a locked file, which only happens on
+// Windows
+func isLocked(error) bool {
+	return false
+}
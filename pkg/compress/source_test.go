@@ -0,0 +1,54 @@
+// pkg/compress/source_test.go
+package compress
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSourceSetTallies(t *testing.T) {
+	sources := newSourceSet(&Options{SkipLockedFiles: true})
+	sources.changed("b.log")
+	sources.changed("a.log")
+	if got := sources.fuzzy.snapshot(); !slices.Equal(got, []string{"a.log", "b.log"}) {
+		t.Errorf("fuzzy %v, want sorted [a.log b.log]", got)
+	}
+	if !sources.retry("a.log") || sources.retry("a.log") {
+		t.Error("retry should take a changed file back exactly once")
+	}
+
+	sources.locked.add("db.lock")
+	if !sources.skipped("db.lock") || sources.skipped("a.log") {
+		t.Error("skipped should report only locked files")
+	}
+
+	// Without SkipLockedFiles, and for a nil set, nothing is skipped
+	if newSourceSet(&Options{}).skipped("db.lock") {
+		t.Error("locked file skipped without SkipLockedFiles")
+	}
+	var none *sourceSet
+	none.changed("a.log")
+	if none.retry("a.log") || none.skipped("a.log") {
+		t.Error("nil sourceSet recorded a file")
+	}
+}
+
+func TestValidateSnapshotMode(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want error
+	}{
+		{"unknown mode", Options{InputPath: "in", SnapshotMode: "zfs"}, ErrInvalidSnapshotMode},
+		{"file list", Options{Files: []string{"a"}, SnapshotMode: SnapshotVSS}, ErrSnapshotInput},
+		{"negative locked retries", Options{InputPath: "in", LockedFileRetries: -1}, ErrInvalidLockedRetries},
+	}
+	for _, tt := range tests {
+		opts := tt.opts
+		opts.OutputPath = "out.gdelta"
+		if err := opts.Validate(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// pkg/compress/split.go
+package compress
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// SplitArchive is one archive of a SplitByFolder run
+type SplitArchive struct {
+	Folder         string         // Top-level folder, "" for the files at the top of the input
+	OutputPath     string         // Where the archive was written
+	ManifestPath   string         // Its manifest with WriteManifest, "" otherwise
+	Verify         *verify.Result // Its read-back with VerifyAfter, nil otherwise
+	Files          int            // Files archived
+	OriginalSize   uint64         // Their total size
+	CompressedSize uint64         // Size of the archive
+}
+
+// SplitPath returns the archive of folder in a SplitByFolder run writing
+// to outputPath: the folder is added before the extension, and files at
+// the top of the input ("") go to outputPath itself
+func SplitPath(outputPath, folder string) string {
+	if folder == "" {
+		return outputPath
+	}
+	ext := filepath.Ext(outputPath)
+	return strings.TrimSuffix(outputPath, ext) + "_" + folder + ext
+}
+
+// splitGroup is the share of the scanned folders going to one archive
+type splitGroup struct {
+	folder  string
+	folders []folderTask
+}
+
+// splitByFolder groups the scanned folders by top-level folder of their
+// files, the files at the top first and the others by name
+func splitByFolder(folders []folderTask) []splitGroup {
+	index := make(map[string]int)
+	var groups []splitGroup
+	for _, folder := range folders {
+		for _, task := range folder.Files {
+			top, _, ok := strings.Cut(filepath.ToSlash(task.RelPath), "/")
+			if !ok {
+				top = ""
+			}
+			i, seen := index[top]
+			if !seen {
+				i = len(groups)
+				index[top] = i
+				groups = append(groups, splitGroup{folder: top})
+			}
+			// Files of a folder share its top-level folder, so each
+			// folder adds one folderTask to its group
+			g := &groups[i]
+			if n := len(g.folders); n == 0 || g.folders[n-1].FolderPath != folder.FolderPath {
+				g.folders = append(g.folders, folderTask{FolderPath: folder.FolderPath})
+			}
+			last := &g.folders[len(g.folders)-1]
+			last.Files = append(last.Files, task)
+		}
+	}
+	slices.SortFunc(groups, func(a, b splitGroup) int { return cmp.Compare(a.folder, b.folder) })
+	return groups
+}
+
+// compressSplit writes the scanned folders into one archive per top-level
+// folder, each by a run of its own over its share of the scan. The runs
+// report to progressCb as one: their start and complete events are left
+// out for those of the whole input. Their figures add up in result.
+func compressSplit(ctx context.Context, opts *Options, progressCb ProgressCallback, folders []folderTask, result *Result) (err error) {
+	if progressCb != nil {
+		progressCb(ProgressEvent{
+			Type:       EventStart,
+			Total:      int64(result.FilesTotal),
+			TotalBytes: result.OriginalSize,
+		})
+	}
+	var splitCb ProgressCallback
+	if progressCb != nil {
+		splitCb = func(event ProgressEvent) {
+			if event.Type != EventStart && event.Type != EventComplete {
+				progressCb(event)
+			}
+		}
+	}
+
+	// Like a single archive, a canceled or failed run leaves none behind;
+	// one that failed verification keeps them for inspection
+	defer func() {
+		if err == nil || opts.DryRun || errors.Is(err, ErrVerifyFailed) {
+			return
+		}
+		for _, split := range result.Splits {
+			os.Remove(split.OutputPath)
+			if split.ManifestPath != "" {
+				os.Remove(split.ManifestPath)
+			}
+		}
+	}()
+
+	for _, group := range splitByFolder(folders) {
+		// The scan, snapshot and tuning of the whole run are reused
+		splitOpts := *opts
+		splitOpts.OutputPath = SplitPath(opts.OutputPath, group.folder)
+		splitOpts.SplitByFolder = false
+		splitOpts.SnapshotMode = SnapshotNone
+		splitOpts.AutoLevel, splitOpts.AutoChunkSize = false, false
+		splitOpts.sample = group.folders
+
+		splitResult, splitErr := CompressContext(ctx, &splitOpts, splitCb)
+		if splitResult != nil {
+			result.addSplit(splitResult)
+		}
+		if splitErr != nil {
+			return splitErr
+		}
+		split := SplitArchive{
+			Folder:         group.folder,
+			OutputPath:     splitOpts.OutputPath,
+			ManifestPath:   splitResult.ManifestPath,
+			Verify:         splitResult.Verify,
+			Files:          splitResult.FilesProcessed,
+			OriginalSize:   splitResult.OriginalSize,
+			CompressedSize: splitResult.CompressedSize,
+		}
+		result.Splits = append(result.Splits, split)
+	}
+
+	if progressCb != nil {
+		progressCb(ProgressEvent{
+			Type:           EventComplete,
+			Current:        int64(result.FilesProcessed),
+			Total:          int64(result.FilesTotal),
+			TotalBytes:     result.OriginalSize,
+			CompressedSize: result.CompressedSize,
+		})
+	}
+	return nil
+}
+
+// addSplit adds the figures of the run of one split archive to r
+func (r *Result) addSplit(split *Result) {
+	r.FilesProcessed += split.FilesProcessed
+	r.StoredFiles += split.StoredFiles
+	r.SegmentedFiles += split.SegmentedFiles
+	r.PackedFiles += split.PackedFiles
+	r.Packs += split.Packs
+	r.LinkedFiles += split.LinkedFiles
+	r.LinkedBytes += split.LinkedBytes
+	r.CompressedSize += split.CompressedSize
+	r.ParitySize += split.ParitySize
+
+	r.TotalChunks += split.TotalChunks
+	r.UniqueChunks += split.UniqueChunks
+	r.DedupedChunks += split.DedupedChunks
+	r.StoredChunks += split.StoredChunks
+	r.BytesSaved += split.BytesSaved
+	r.Evictions += split.Evictions
+	for ext, from := range split.Extensions {
+		if r.Extensions == nil {
+			r.Extensions = make(map[string]ExtensionStats)
+		}
+		s := r.Extensions[ext]
+		s.Files += from.Files
+		s.OriginalSize += from.OriginalSize
+		s.Chunks += from.Chunks
+		s.DedupedChunks += from.DedupedChunks
+		s.DedupedBytes += from.DedupedBytes
+		r.Extensions[ext] = s
+	}
+
+	r.breakdown.merge(split.ByFolder, split.ByExtension)
+	for _, path := range split.Fuzzy {
+		r.sources.fuzzy.add(path)
+	}
+	if r.sources.locked != nil {
+		for _, path := range split.Locked {
+			r.sources.locked.add(path)
+		}
+	}
+
+	r.Timing.DictTraining += split.Timing.DictTraining
+	r.Timing.EncodersCreated += split.Timing.EncodersCreated
+	r.Timing.EncoderSetup += split.Timing.EncoderSetup
+	r.Errors = append(r.Errors, split.Errors...)
+}
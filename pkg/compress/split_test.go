@@ -0,0 +1,140 @@
+// pkg/compress/split_test.go
+package compress
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+)
+
+func TestSplitPath(t *testing.T) {
+	for _, tc := range []struct{ output, folder, want string }{
+		{"backup.gdelta", "home", "backup_home.gdelta"},
+		{"out/backup.gdelta", ".config", "out/backup_.config.gdelta"},
+		{"backup", "etc", "backup_etc"},
+		{"backup.gdelta", "", "backup.gdelta"},
+	} {
+		if got := SplitPath(tc.output, tc.folder); got != tc.want {
+			t.Errorf("SplitPath(%q, %q) = %q, want %q", tc.output, tc.folder, got, tc.want)
+		}
+	}
+}
+
+// TestSplitByFolder writes one archive per top-level folder in every
+// GDELTA format, reporting progress as one run, and restores them all
+// into the input's tree
+func TestSplitByFolder(t *testing.T) {
+	input := t.TempDir()
+	files := map[string]string{
+		"home/me/notes.txt": strings.Repeat("notes ", 500),
+		"home/me/todo.txt":  "todo",
+		"home/you/a.txt":    strings.Repeat("a", 3000),
+		"etc/hosts":         "127.0.0.1 localhost\n",
+		"top.txt":           "at the top",
+	}
+	for name, data := range files {
+		createFile(t, input, name, data)
+	}
+
+	for name, mode := range map[string]func(*Options){
+		"gdelta01": func(*Options) {},
+		"gdelta02": func(o *Options) { o.ChunkSize = 4 << 10 },
+		"gdelta03": func(o *Options) { o.UseDictionary = true },
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := &Options{
+				InputPath:     input,
+				OutputPath:    filepath.Join(dir, "backup.gdelta"),
+				MaxThreads:    2,
+				SplitByFolder: true,
+				WriteManifest: true,
+				Quiet:         true,
+			}
+			mode(opts)
+			var starts, completes, fileCompletes int
+			result, err := Compress(opts, func(e ProgressEvent) {
+				switch e.Type {
+				case EventStart:
+					starts++
+					if e.Total != int64(len(files)) {
+						t.Errorf("start total %d, want %d", e.Total, len(files))
+					}
+				case EventComplete:
+					completes++
+				case EventFileComplete:
+					fileCompletes++
+				}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if starts != 1 || completes != 1 || fileCompletes != len(files) {
+				t.Errorf("%d start, %d complete and %d file complete events", starts, completes, fileCompletes)
+			}
+			if result.FilesProcessed != len(files) || result.FilesTotal != len(files) {
+				t.Errorf("processed %d of %d files", result.FilesProcessed, result.FilesTotal)
+			}
+			if len(result.ByFolder) != 3 {
+				t.Errorf("by folder %v", result.ByFolder)
+			}
+
+			want := []struct {
+				folder string
+				files  int
+			}{{"", 1}, {"etc", 1}, {"home", 3}}
+			if len(result.Splits) != len(want) {
+				t.Fatalf("splits %+v", result.Splits)
+			}
+			var compressed uint64
+			for i, w := range want {
+				split := result.Splits[i]
+				if split.Folder != w.folder || split.Files != w.files || split.OutputPath != SplitPath(opts.OutputPath, w.folder) {
+					t.Errorf("split %d: %+v, want %s with %d files", i, split, w.folder, w.files)
+				}
+				if _, err := os.Stat(split.ManifestPath); err != nil {
+					t.Errorf("manifest of %s: %v", split.Folder, err)
+				}
+				compressed += split.CompressedSize
+			}
+			if compressed != result.CompressedSize {
+				t.Errorf("splits hold %d bytes, result %d", compressed, result.CompressedSize)
+			}
+
+			// Every archive restores its share into the same tree
+			out := t.TempDir()
+			for _, split := range result.Splits {
+				dres, err := decompress.Decompress(&decompress.Options{InputPath: split.OutputPath, OutputPath: out, Quiet: true}, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !dres.Success() || dres.FilesProcessed != split.Files {
+					t.Fatalf("%s: restored %d files, errors %v", split.OutputPath, dres.FilesProcessed, dres.Errors)
+				}
+			}
+			for name, data := range files {
+				got, err := os.ReadFile(filepath.Join(out, name))
+				if err != nil || string(got) != data {
+					t.Errorf("%s: content mismatch (%v)", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitByFolderValidation(t *testing.T) {
+	for name, opts := range map[string]*Options{
+		"zip":    {InputPath: ".", UseZipFormat: true, SplitByFolder: true},
+		"xz":     {InputPath: ".", UseXzFormat: true, SplitByFolder: true},
+		"stream": {InputPath: "-", FromStream: strings.NewReader("x"), SplitByFolder: true},
+		"tar":    {FromTar: strings.NewReader(""), SplitByFolder: true},
+	} {
+		if err := opts.Validate(); !errors.Is(err, ErrSplitByFolderFormat) {
+			t.Errorf("%s: got %v, want ErrSplitByFolderFormat", name, err)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+// pkg/compress/sync.go
+package compress
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// syncFile flushes f to disk with Options.Sync, then the directory
+// holding it so its name survives a crash too
+func syncFile(opts *Options, f *os.File) error {
+	if !opts.Sync {
+		return nil
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("sync %s: %w", f.Name(), err)
+	}
+	dir := filepath.Dir(f.Name())
+	if err := syncDir(dir); err != nil {
+		return fmt.Errorf("sync %s: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package compress
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// syncDir flushes the entries of dir to disk. Filesystems that can't sync
+// a directory (some network and FUSE ones) report EINVAL, which is
+// ignored: there is nothing more to do for them.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
+		return err
+	}
+	return nil
+}
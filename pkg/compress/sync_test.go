@@ -0,0 +1,48 @@
+// pkg/compress/sync_test.go
+package compress
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSync writes every kind of output with Sync, which must not change
+// what is written
+func TestSync(t *testing.T) {
+	input := t.TempDir()
+	createFile(t, input, "a.txt", strings.Repeat("durable ", 1000))
+	createFile(t, input, "dir/b.txt", "b")
+
+	for name, mode := range map[string]func(*Options){
+		"gdelta01": func(o *Options) { o.WriteManifest = true },
+		"gdelta02": func(o *Options) { o.ChunkSize = 4 << 10 },
+		"gdelta03": func(o *Options) { o.UseDictionary = true },
+		"parity":   func(o *Options) { o.ParityPercent = 5 },
+		"zip":      func(o *Options) { o.UseZipFormat = true },
+		"zip1":     func(o *Options) { o.UseZipFormat, o.SingleZip = true, true },
+		"xz":       func(o *Options) { o.UseXzFormat = true },
+		"tar.gz":   func(o *Options) { o.UseTarGzFormat = true },
+	} {
+		t.Run(name, func(t *testing.T) {
+			opts := &Options{
+				InputPath:  input,
+				OutputPath: filepath.Join(t.TempDir(), "out"),
+				MaxThreads: 2,
+				Sync:       true,
+				Quiet:      true,
+			}
+			mode(opts)
+			result, err := Compress(opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.FilesProcessed != 2 || len(result.Errors) > 0 {
+				t.Errorf("processed %d files, errors %v", result.FilesProcessed, result.Errors)
+			}
+		})
+	}
+	if err := syncDir(t.TempDir()); err != nil {
+		t.Errorf("syncDir: %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package compress
+
+// syncDir does nothing: directories can't be flushed on Windows, where
+// NTFS journals their entries
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,32 @@
+// pkg/compress/verify_after.go
+package compress
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// verifyArchive reads back the archive just written with verify.Verify
+// and VerifyData, including its manifest when one was written, and
+// records the outcome in result.Verify. An archive that doesn't check
+// out, or doesn't hold every processed file, fails the run with
+// ErrVerifyFailed and is left for inspection.
+func verifyArchive(opts *Options, result *Result) error {
+	v, err := verify.Verify(&verify.Options{InputPath: opts.OutputPath, VerifyData: true, MaxThreads: opts.MaxThreads, Quiet: true}, nil)
+	result.Verify = v
+	if err == nil && !v.IsValid() {
+		err = errors.Join(v.Errors...)
+		if err == nil {
+			err = fmt.Errorf("%d corrupt files", v.CorruptFiles)
+		}
+	}
+	if err == nil && v.FileCount != result.FilesProcessed {
+		err = fmt.Errorf("archive holds %d files, %d were compressed", v.FileCount, result.FilesProcessed)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrVerifyFailed, opts.OutputPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// pkg/compress/verify_after_test.go
+package compress
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyAfter(t *testing.T) {
+	input := t.TempDir()
+	for name, data := range map[string]string{
+		"a.txt":     strings.Repeat("some text to compress ", 400),
+		"dir/b.txt": strings.Repeat("more text ", 300),
+		"dir/empty": "",
+	} {
+		createFile(t, input, name, data)
+	}
+
+	for name, mode := range map[string]func(*Options){
+		"gdelta01": func(*Options) {},
+		"gdelta02": func(o *Options) { o.ChunkSize = 4 << 10 },
+		"gdelta03": func(o *Options) { o.UseDictionary = true },
+		"manifest": func(o *Options) { o.WriteManifest = true },
+		"packed":   func(o *Options) { o.PackSmallFiles = 1 << 10 },
+		"dedup":    func(o *Options) { o.DedupFiles = true },
+	} {
+		t.Run(name, func(t *testing.T) {
+			opts := &Options{
+				InputPath:   input,
+				OutputPath:  filepath.Join(t.TempDir(), "out.gdelta"),
+				MaxThreads:  2,
+				VerifyAfter: true,
+				Quiet:       true,
+			}
+			mode(opts)
+			result, err := Compress(opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Verify == nil || !result.Verify.DataVerified || result.Verify.FilesVerified != 3 {
+				t.Fatalf("verify result %+v", result.Verify)
+			}
+			if opts.WriteManifest && result.Verify.Manifest == nil {
+				t.Error("manifest not checked")
+			}
+
+			// A damaged archive fails the check and is kept
+			data, err := os.ReadFile(opts.OutputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := len(data) / 3; i < len(data)/2; i++ {
+				data[i] ^= 0xff
+			}
+			if err := os.WriteFile(opts.OutputPath, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := verifyArchive(opts, result); !errors.Is(err, ErrVerifyFailed) {
+				t.Errorf("damaged archive: got %v, want ErrVerifyFailed", err)
+			}
+		})
+	}
+}
+
+func TestVerifyAfterValidation(t *testing.T) {
+	for name, opts := range map[string]*Options{
+		"zip":     {InputPath: ".", UseZipFormat: true, VerifyAfter: true},
+		"tar.gz":  {InputPath: ".", UseTarGzFormat: true, VerifyAfter: true},
+		"dry-run": {InputPath: ".", DryRun: true, VerifyAfter: true},
+	} {
+		if err := opts.Validate(); !errors.Is(err, ErrVerifyAfterFormat) {
+			t.Errorf("%s: got %v, want ErrVerifyAfterFormat", name, err)
+		}
+	}
+}
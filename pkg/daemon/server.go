@@ -0,0 +1,340 @@
+// pkg/daemon/server.go
+package daemon
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/creativeyann17/go-delta/api/godeltapb"
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// Server implements the GoDelta gRPC service on top of the compress,
+// decompress and verify packages. Paths in requests are resolved on the
+// daemon host, so the daemon should only listen where callers are trusted.
+type Server struct {
+	pb.UnimplementedGoDeltaServer
+}
+
+// NewServer returns a GoDelta service implementation
+func NewServer() *Server {
+	return &Server{}
+}
+
+// Register creates a gRPC server with the GoDelta service registered
+func Register(opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	pb.RegisterGoDeltaServer(s, NewServer())
+	return s
+}
+
+// streamSender serializes Send calls: progress callbacks fire from every
+// worker goroutine, but a gRPC stream allows only one concurrent sender.
+// After the first failed Send (client gone) further messages are dropped so
+// workers are not slowed down by a dead stream.
+type streamSender[T any] struct {
+	mu     sync.Mutex
+	stream grpc.ServerStreamingServer[T]
+	err    error
+}
+
+func (s *streamSender[T]) send(msg *T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.err = s.stream.Send(msg)
+	return s.err
+}
+
+// Compress runs a compression and streams its progress events
+func (s *Server) Compress(req *pb.CompressRequest, stream grpc.ServerStreamingServer[pb.CompressResponse]) error {
+	opts := &compress.Options{
+		InputPath:       req.GetInputPath(),
+		Files:           req.GetFiles(),
+		OutputPath:      req.GetOutputPath(),
+		MaxThreads:      int(req.GetMaxThreads()),
+		Parallelism:     compress.Parallelism(req.GetParallelism()),
+		MaxThreadMemory: req.GetMaxThreadMemory(),
+		ChunkSize:       req.GetChunkSize(),
+		ChunkStoreSize:  req.GetChunkStoreSize(),
+		Level:           int(req.GetLevel()),
+		UseZipFormat:    req.GetZip(),
+		UseXzFormat:     req.GetXz(),
+		UseDictionary:   req.GetDictionary(),
+		DryRun:          req.GetDryRun(),
+		UseGitignore:    req.GetGitignore(),
+		Quiet:           true,
+	}
+	if err := opts.Validate(); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	sender := &streamSender[pb.CompressResponse]{stream: stream}
+	result, err := compress.CompressContext(stream.Context(), opts, func(event compress.ProgressEvent) {
+		_ = sender.send(&pb.CompressResponse{Payload: &pb.CompressResponse_Progress{
+			Progress: &pb.ProgressEvent{
+				Type:           compressEventType(event.Type),
+				FilePath:       event.FilePath,
+				Current:        event.Current,
+				Total:          event.Total,
+				CurrentBytes:   event.CurrentBytes,
+				TotalBytes:     event.TotalBytes,
+				CompressedSize: event.CompressedSize,
+			},
+		}})
+	})
+	if result != nil {
+		msg := &pb.CompressResult{
+			FilesTotal:     int64(result.FilesTotal),
+			FilesProcessed: int64(result.FilesProcessed),
+			OriginalSize:   result.OriginalSize,
+			CompressedSize: result.CompressedSize,
+			TotalChunks:    result.TotalChunks,
+			UniqueChunks:   result.UniqueChunks,
+			DedupedChunks:  result.DedupedChunks,
+			BytesSaved:     result.BytesSaved,
+			Errors:         errorStrings(result.Errors),
+			Summary:        compress.FormatSummary(result, opts),
+		}
+		if sendErr := sender.send(&pb.CompressResponse{Payload: &pb.CompressResponse_Result{Result: msg}}); sendErr != nil {
+			return sendErr
+		}
+	}
+	if err != nil {
+		return statusError(err)
+	}
+	return sender.err
+}
+
+// Decompress runs an extraction and streams its progress events
+func (s *Server) Decompress(req *pb.DecompressRequest, stream grpc.ServerStreamingServer[pb.DecompressResponse]) error {
+	opts := &decompress.Options{
+		InputPath:  req.GetInputPath(),
+		OutputPath: req.GetOutputPath(),
+		MaxThreads: int(req.GetMaxThreads()),
+		Overwrite:  req.GetOverwrite(),
+		Quiet:      true,
+	}
+	if err := opts.Validate(); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	sender := &streamSender[pb.DecompressResponse]{stream: stream}
+	result, err := decompress.DecompressContext(stream.Context(), opts, func(event decompress.ProgressEvent) {
+		_ = sender.send(&pb.DecompressResponse{Payload: &pb.DecompressResponse_Progress{
+			Progress: &pb.ProgressEvent{
+				Type:             decompressEventType(event.Type),
+				FilePath:         event.FilePath,
+				Current:          event.Current,
+				Total:            event.Total,
+				CurrentBytes:     event.CurrentBytes,
+				TotalBytes:       event.TotalBytes,
+				DecompressedSize: event.DecompressedSize,
+			},
+		}})
+	})
+	if result != nil {
+		degradations := make([]string, len(result.Degradations))
+		for i, d := range result.Degradations {
+			degradations[i] = d.String()
+		}
+		msg := &pb.DecompressResult{
+			FilesTotal:       int64(result.FilesTotal),
+			FilesProcessed:   int64(result.FilesProcessed),
+			CompressedSize:   result.CompressedSize,
+			DecompressedSize: result.DecompressedSize,
+			Errors:           errorStrings(result.Errors),
+			Degradations:     degradations,
+			Summary:          decompress.FormatSummary(result),
+		}
+		if sendErr := sender.send(&pb.DecompressResponse{Payload: &pb.DecompressResponse_Result{Result: msg}}); sendErr != nil {
+			return sendErr
+		}
+	}
+	if err != nil {
+		return statusError(err)
+	}
+	return sender.err
+}
+
+// Verify checks an archive and streams its progress events. An invalid
+// archive is reported through VerifyResult.valid, not as an RPC error.
+func (s *Server) Verify(req *pb.VerifyRequest, stream grpc.ServerStreamingServer[pb.VerifyResponse]) error {
+	opts := &verify.Options{
+		InputPath:  req.GetInputPath(),
+		VerifyData: req.GetVerifyData(),
+		Quiet:      true,
+	}
+	if err := opts.Validate(); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	sender := &streamSender[pb.VerifyResponse]{stream: stream}
+	result, err := verify.VerifyContext(stream.Context(), opts, func(event verify.ProgressEvent) {
+		_ = sender.send(&pb.VerifyResponse{Payload: &pb.VerifyResponse_Progress{
+			Progress: verifyProgress(event),
+		}})
+	})
+	if result == nil {
+		return statusError(err)
+	}
+
+	msg := &pb.VerifyResult{
+		Format:        string(result.Format),
+		Valid:         result.IsValid(),
+		FileCount:     int64(result.FileCount),
+		TotalOrigSize: result.TotalOrigSize,
+		TotalCompSize: result.TotalCompSize,
+		CorruptFiles:  int64(result.CorruptFiles),
+		CorruptChunks: int64(result.CorruptChunks),
+		Errors:        errorStrings(result.Errors),
+		Summary:       result.Summary(),
+	}
+	return sender.send(&pb.VerifyResponse{Payload: &pb.VerifyResponse_Result{Result: msg}})
+}
+
+// List streams the entries of an archive. Entries come from a structural
+// verify pass, so listing never decompresses file data.
+func (s *Server) List(req *pb.ListRequest, stream grpc.ServerStreamingServer[pb.ListResponse]) error {
+	opts := &verify.Options{
+		InputPath: req.GetInputPath(),
+		Quiet:     true,
+	}
+	if err := opts.Validate(); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	sender := &streamSender[pb.ListResponse]{stream: stream}
+	result, err := verify.VerifyContext(stream.Context(), opts, func(event verify.ProgressEvent) {
+		_ = sender.send(&pb.ListResponse{Payload: &pb.ListResponse_Progress{
+			Progress: verifyProgress(event),
+		}})
+	})
+	if result == nil {
+		return statusError(err)
+	}
+
+	for _, f := range result.Files {
+		entry := &pb.FileEntry{
+			Path:           f.Path,
+			OriginalSize:   f.OriginalSize,
+			CompressedSize: f.CompressedSize,
+			ChunkCount:     int64(f.ChunkCount),
+		}
+		if sendErr := sender.send(&pb.ListResponse{Payload: &pb.ListResponse_Entry{Entry: entry}}); sendErr != nil {
+			return sendErr
+		}
+	}
+	if err != nil {
+		return statusError(err)
+	}
+	return nil
+}
+
+// compressEventType maps compress events onto the wire enum
+func compressEventType(t compress.EventType) pb.EventType {
+	switch t {
+	case compress.EventStart:
+		return pb.EventType_EVENT_TYPE_START
+	case compress.EventFileStart:
+		return pb.EventType_EVENT_TYPE_FILE_START
+	case compress.EventFileProgress:
+		return pb.EventType_EVENT_TYPE_FILE_PROGRESS
+	case compress.EventFileComplete:
+		return pb.EventType_EVENT_TYPE_FILE_COMPLETE
+	case compress.EventComplete:
+		return pb.EventType_EVENT_TYPE_COMPLETE
+	case compress.EventError:
+		return pb.EventType_EVENT_TYPE_ERROR
+	case compress.EventDictTraining:
+		return pb.EventType_EVENT_TYPE_DICT_TRAINING
+	default:
+		return pb.EventType_EVENT_TYPE_UNSPECIFIED
+	}
+}
+
+// decompressEventType maps decompress events onto the wire enum
+func decompressEventType(t decompress.EventType) pb.EventType {
+	switch t {
+	case decompress.EventStart:
+		return pb.EventType_EVENT_TYPE_START
+	case decompress.EventFileStart:
+		return pb.EventType_EVENT_TYPE_FILE_START
+	case decompress.EventFileProgress:
+		return pb.EventType_EVENT_TYPE_FILE_PROGRESS
+	case decompress.EventFileComplete:
+		return pb.EventType_EVENT_TYPE_FILE_COMPLETE
+	case decompress.EventComplete:
+		return pb.EventType_EVENT_TYPE_COMPLETE
+	case decompress.EventError:
+		return pb.EventType_EVENT_TYPE_ERROR
+	default:
+		return pb.EventType_EVENT_TYPE_UNSPECIFIED
+	}
+}
+
+// verifyProgress converts a verify event into its wire form
+func verifyProgress(event verify.ProgressEvent) *pb.ProgressEvent {
+	var t pb.EventType
+	switch event.Type {
+	case verify.EventStart:
+		t = pb.EventType_EVENT_TYPE_START
+	case verify.EventFileVerify:
+		t = pb.EventType_EVENT_TYPE_FILE_VERIFY
+	case verify.EventChunkVerify:
+		t = pb.EventType_EVENT_TYPE_CHUNK_VERIFY
+	case verify.EventComplete:
+		t = pb.EventType_EVENT_TYPE_COMPLETE
+	case verify.EventError:
+		t = pb.EventType_EVENT_TYPE_ERROR
+	}
+	return &pb.ProgressEvent{
+		Type:     t,
+		FilePath: event.FilePath,
+		Current:  int64(event.Current),
+		Total:    int64(event.Total),
+		Message:  event.Message,
+	}
+}
+
+// errorStrings flattens non-fatal errors for the wire
+func errorStrings(errs []error) []string {
+	if len(errs) == 0 {
+		return nil
+	}
+	out := make([]string, len(errs))
+	for i, err := range errs {
+		out[i] = err.Error()
+	}
+	return out
+}
+
+// statusError maps a fatal library error onto a gRPC status
+func statusError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, fs.ErrNotExist):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, fs.ErrPermission):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, decompress.ErrFileExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
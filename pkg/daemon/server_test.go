@@ -0,0 +1,210 @@
+// pkg/daemon/server_test.go
+package daemon_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/creativeyann17/go-delta/api/godeltapb"
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/daemon"
+)
+
+func newTestClient(t *testing.T) pb.GoDeltaClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := daemon.Register()
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewGoDeltaClient(conn)
+}
+
+// recvAll drains a server stream, returning every message received
+func recvAll[T any](t *testing.T, stream grpc.ServerStreamingClient[T]) []*T {
+	t.Helper()
+	var msgs []*T
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return msgs
+		}
+		if err != nil {
+			t.Fatalf("recv: %v", err)
+		}
+		msgs = append(msgs, msg)
+	}
+}
+
+func TestDaemonRoundTrip(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	inputDir := t.TempDir()
+	files := map[string]string{
+		"a.txt":     "alpha alpha alpha",
+		"sub/b.txt": "bravo bravo bravo",
+	}
+	for name, content := range files {
+		path := filepath.Join(inputDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive := filepath.Join(t.TempDir(), "test.gdelta")
+
+	// Compress: progress events then exactly one result, as the last message
+	cstream, err := client.Compress(ctx, &pb.CompressRequest{InputPath: inputDir, OutputPath: archive})
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	cmsgs := recvAll(t, cstream)
+	last := cmsgs[len(cmsgs)-1].GetResult()
+	if last == nil {
+		t.Fatalf("last compress message is not a result")
+	}
+	if last.FilesProcessed != 2 || len(last.Errors) != 0 {
+		t.Fatalf("unexpected compress result: %+v", last)
+	}
+	if cmsgs[0].GetProgress().GetType() != pb.EventType_EVENT_TYPE_START {
+		t.Errorf("first event = %v, want START", cmsgs[0].GetProgress().GetType())
+	}
+
+	// List
+	lstream, err := client.List(ctx, &pb.ListRequest{InputPath: archive})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	listed := map[string]uint64{}
+	for _, msg := range recvAll(t, lstream) {
+		if e := msg.GetEntry(); e != nil {
+			listed[filepath.ToSlash(e.Path)] = e.OriginalSize
+		}
+	}
+	for name, content := range files {
+		if listed[name] != uint64(len(content)) {
+			t.Errorf("list entry %s: size %d, want %d", name, listed[name], len(content))
+		}
+	}
+
+	// Verify
+	vstream, err := client.Verify(ctx, &pb.VerifyRequest{InputPath: archive, VerifyData: true})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	vmsgs := recvAll(t, vstream)
+	if res := vmsgs[len(vmsgs)-1].GetResult(); res == nil || !res.Valid {
+		t.Fatalf("archive not reported valid: %+v", res)
+	}
+
+	// Decompress
+	outDir := t.TempDir()
+	dstream, err := client.Decompress(ctx, &pb.DecompressRequest{InputPath: archive, OutputPath: outDir})
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	dmsgs := recvAll(t, dstream)
+	if res := dmsgs[len(dmsgs)-1].GetResult(); res == nil || res.FilesProcessed != 2 {
+		t.Fatalf("unexpected decompress result: %+v", res)
+	}
+	for name, content := range files {
+		got, err := os.ReadFile(filepath.Join(outDir, name))
+		if err != nil || string(got) != content {
+			t.Errorf("%s: got %q, err %v", name, got, err)
+		}
+	}
+}
+
+func TestDaemonInvalidArgument(t *testing.T) {
+	client := newTestClient(t)
+
+	stream, err := client.Compress(context.Background(), &pb.CompressRequest{})
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	_, err = stream.Recv()
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+// cancelingStream is a server stream whose client goes away after the first
+// file is restored
+type cancelingStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *cancelingStream) Context() context.Context { return s.ctx }
+
+func (s *cancelingStream) Send(msg *pb.DecompressResponse) error {
+	if msg.GetProgress().GetType() == pb.EventType_EVENT_TYPE_FILE_COMPLETE {
+		s.cancel()
+	}
+	if err := s.ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return nil
+}
+
+func TestDaemonDecompressCanceled(t *testing.T) {
+	inputDir := t.TempDir()
+	const total = 50
+	for i := range total {
+		if err := os.WriteFile(filepath.Join(inputDir, fmt.Sprintf("f%02d.txt", i)), []byte(fmt.Sprintf("content %d", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive := filepath.Join(t.TempDir(), "test.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: inputDir, OutputPath: archive, Quiet: true}, nil); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+
+	outDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := daemon.NewServer().Decompress(
+		&pb.DecompressRequest{InputPath: archive, OutputPath: outDir, MaxThreads: 1},
+		&cancelingStream{ctx: ctx, cancel: cancel})
+	if status.Code(err) != codes.Canceled {
+		t.Fatalf("expected Canceled, got %v", err)
+	}
+
+	restored, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) == 0 || len(restored) >= total {
+		t.Errorf("restored %d of %d files, want the restore stopped after the first", len(restored), total)
+	}
+	for _, e := range restored {
+		if filepath.Ext(e.Name()) == ".partial" {
+			t.Errorf("partial file left: %s", e.Name())
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// pkg/decompress/casefold.go
+package decompress
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// CaseCollision is an entry that restores onto the same file as another
+// one on a case-insensitive filesystem (README.md and readme.md)
+type CaseCollision struct {
+	Path string // the entry not restored under its own name
+	With string // the entry that keeps the name
+}
+
+// caseCollisions is the outcome of the pre-scan: the output paths of the
+// entries that give way to another one, and the report of them
+type caseCollisions struct {
+	losers map[string]bool
+	report []CaseCollision
+}
+
+// loses reports whether the entry restored at outPath gives way to another
+// entry folding to the same name. A nil caseCollisions has none.
+func (c *caseCollisions) loses(outPath string) bool {
+	return c != nil && c.losers[outPath]
+}
+
+// applyCaseCollisions decides, before extraction to a case-insensitive
+// output, which entry of each colliding group keeps the name, following
+// the conflict policy. ConflictError refuses the restore with
+// ErrCaseCollision.
+func applyCaseCollisions(entries []planEntry, opts *Options, result *Result) error {
+	opts.collisions = findCaseCollisions(entries, opts)
+	if opts.collisions == nil {
+		return nil
+	}
+	result.CaseCollisions = opts.collisions.report
+	if opts.conflictPolicy() == ConflictError {
+		first := result.CaseCollisions[0]
+		return fmt.Errorf("%w: %d entries, first %s and %s (choose --on-conflict)",
+			ErrCaseCollision, len(result.CaseCollisions), first.With, first.Path)
+	}
+	return nil
+}
+
+// findCaseCollisions groups the entries by output path, case-folded and
+// Unicode-normalized. The entry keeping the name is the one the policy
+// would leave on disk after a sequential restore: the first one (skip,
+// rename, error), the last one (overwrite) or the newest one (keep-newer).
+// Returns nil without collisions.
+func findCaseCollisions(entries []planEntry, opts *Options) *caseCollisions {
+	groups := make(map[string][]int)
+	var order []string
+	for i, e := range entries {
+		outPath, err := safeJoin(opts.OutputPath, opts.rewriter.apply(e.path))
+		if err != nil {
+			continue // reported by the extraction
+		}
+		key := strings.ToLower(godelta.NormalizeNFC.Apply(outPath))
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	c := &caseCollisions{losers: make(map[string]bool)}
+	for _, key := range order {
+		group := groups[key]
+		if len(group) < 2 {
+			continue
+		}
+		keep := group[0]
+		switch opts.conflictPolicy() {
+		case ConflictOverwrite:
+			keep = group[len(group)-1]
+		case ConflictKeepNewer:
+			for _, i := range group[1:] {
+				if !entries[i].modTime.Before(entries[keep].modTime) {
+					keep = i
+				}
+			}
+		}
+		keepPath, _ := safeJoin(opts.OutputPath, opts.rewriter.apply(entries[keep].path))
+		for _, i := range group {
+			outPath, _ := safeJoin(opts.OutputPath, opts.rewriter.apply(entries[i].path))
+			if i == keep || outPath == keepPath {
+				continue // the entry itself, or an exact duplicate of it
+			}
+			c.losers[outPath] = true
+			c.report = append(c.report, CaseCollision{Path: entries[i].path, With: entries[keep].path})
+		}
+	}
+	if len(c.report) == 0 {
+		return nil
+	}
+	return c
+}
+
+// caseInsensitive reports whether the filesystem holding dir ignores the
+// case of names, by creating a file and looking it up in upper case. When
+// dir isn't writable, macOS and Windows are assumed to be.
+func caseInsensitive(dir string) bool {
+	f, err := os.CreateTemp(dir, ".godelta-case-")
+	if err != nil {
+		return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+	}
+	name := f.Name()
+	f.Close()
+	defer os.Remove(name)
+
+	upper := filepath.Join(filepath.Dir(name), strings.ToUpper(filepath.Base(name)))
+	_, err = os.Stat(upper)
+	return err == nil
+}
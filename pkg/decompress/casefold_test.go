@@ -0,0 +1,67 @@
+// pkg/decompress/casefold_test.go
+package decompress_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+)
+
+// TestCaseCollisions restores README.md and readme.md as if the output were
+// case-insensitive, under the policies that decide which one keeps the name
+func TestCaseCollisions(t *testing.T) {
+	inputDir := t.TempDir()
+	for name, content := range map[string]string{"README.md": "upper", "readme.md": "lower", "other.txt": "x"} {
+		if err := os.WriteFile(filepath.Join(inputDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive := filepath.Join(t.TempDir(), "a.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: inputDir, OutputPath: archive, MaxThreads: 1, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		policy decompress.ConflictPolicy
+		want   []string // files restored
+	}{
+		{decompress.ConflictError, nil},
+		{decompress.ConflictSkip, []string{"README.md", "other.txt"}},
+		{decompress.ConflictRename, []string{"README.md", "other.txt", "readme.1.md"}},
+		{decompress.ConflictOverwrite, []string{"other.txt", "readme.md"}},
+	}
+	for _, tc := range cases {
+		outDir := t.TempDir()
+		result, err := decompress.Decompress(&decompress.Options{
+			InputPath:       archive,
+			OutputPath:      outDir,
+			ConflictPolicy:  tc.policy,
+			CaseInsensitive: true,
+			Quiet:           true,
+		}, nil)
+		if tc.policy == decompress.ConflictError {
+			if !errors.Is(err, decompress.ErrCaseCollision) {
+				t.Errorf("%s: got %v, want ErrCaseCollision", tc.policy, err)
+			}
+		} else if err != nil {
+			t.Fatalf("%s: %v", tc.policy, err)
+		}
+		if result == nil || len(result.CaseCollisions) != 1 {
+			t.Fatalf("%s: collisions %+v, want one", tc.policy, result)
+		}
+
+		entries, _ := os.ReadDir(outDir)
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Name())
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: restored %v, want %v", tc.policy, got, tc.want)
+		}
+	}
+}
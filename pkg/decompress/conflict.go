@@ -0,0 +1,103 @@
+// pkg/decompress/conflict.go
+package decompress
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// errEntrySkipped is returned by the per-entry extractors when
+// ConflictPolicy left the existing file in place. Counted in
+// Result.FilesSkipped, not reported as an error.
+var errEntrySkipped = errors.New("existing file kept")
+
+// resolveConflict applies opts.conflictPolicy() to an entry about to be
+// written at outPath. Returns the path to write, which differs from outPath
+// for ConflictRename, or errEntrySkipped when the existing file stays.
+// entryTime is the entry's modification time; zero for formats that don't
+// store one.
+func resolveConflict(outPath string, entryTime time.Time, opts *Options) (string, error) {
+	// Another entry folding to the same name keeps it (see checkCaseCollisions)
+	if opts.collisions.loses(outPath) {
+		if opts.conflictPolicy() == ConflictRename {
+			return claimFreeName(outPath)
+		}
+		return "", errEntrySkipped
+	}
+
+	info, err := os.Stat(outPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return outPath, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	switch opts.conflictPolicy() {
+	case ConflictOverwrite:
+		return outPath, nil
+	case ConflictSkip:
+		removeStalePartial(outPath)
+		return "", errEntrySkipped
+	case ConflictKeepNewer:
+		if !entryNewer(info, entryTime, opts) {
+			removeStalePartial(outPath)
+			return "", errEntrySkipped
+		}
+		return outPath, nil
+	case ConflictRename:
+		return claimFreeName(outPath)
+	default:
+		removeStalePartial(outPath)
+		return "", ErrFileExists
+	}
+}
+
+// dosEpoch is the earliest time a ZIP entry can hold. Earlier entry times are
+// placeholders from archivers that store none (tar's Unix 0, ZIP's empty
+// MS-DOS date), including this package's own ZIP and tar.xz writers.
+var dosEpoch = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// entryNewer reports whether an entry modified at entryTime is newer than the
+// existing file. Without an entry time, the archive's modification time is
+// used; when that can't be read either, the existing file wins.
+func entryNewer(existing fs.FileInfo, entryTime time.Time, opts *Options) bool {
+	if entryTime.Before(dosEpoch) {
+		archive, err := os.Stat(opts.InputPath)
+		if err != nil {
+			return false
+		}
+		entryTime = archive.ModTime()
+	}
+	return entryTime.After(existing.ModTime())
+}
+
+// maxRenameAttempts bounds the search for a free name next to a conflicting
+// file
+const maxRenameAttempts = 10000
+
+// claimFreeName finds the first of file.1.txt, file.2.txt, ... that doesn't
+// exist next to path and creates it empty, so concurrent extractions can't
+// pick the same name
+func claimFreeName(path string) (string, error) {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	for n := 1; n <= maxRenameAttempts; n++ {
+		candidate := fmt.Sprintf("%s.%d%s", stem, n, ext)
+		f, err := os.OpenFile(candidate, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("rename: %w", err)
+		}
+		f.Close()
+		return candidate, nil
+	}
+	return "", fmt.Errorf("rename: no free name after %d attempts: %w", maxRenameAttempts, ErrFileExists)
+}
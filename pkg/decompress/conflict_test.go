@@ -0,0 +1,125 @@
+// pkg/decompress/conflict_test.go
+package decompress_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+)
+
+// TestConflictPolicy restores every format into a directory holding one of
+// the archive's files under each conflict policy
+func TestConflictPolicy(t *testing.T) {
+	inputDir := t.TempDir()
+	all := buildTestInput(t, inputDir)
+	existing := "sub1/file_001.txt"
+	renamed := "sub1/file_001.1.txt"
+
+	formats := map[string]*compress.Options{
+		"GDELTA01": {Level: 3},
+		"GDELTA02": {Level: 3, ChunkSize: 16 * 1024},
+		"GDELTA03": {Level: 3, UseDictionary: true},
+		"ZIP":      {Level: 3, UseZipFormat: true, MaxThreads: 2},
+		"XZ":       {Level: 1, UseXzFormat: true, MaxThreads: 1},
+	}
+	past := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	future := time.Now().Add(24 * time.Hour)
+
+	cases := []struct {
+		policy       decompress.ConflictPolicy
+		existingTime time.Time
+		wantErr      error
+		wantSkipped  int
+		wantRestored bool // existing file replaced by the entry
+		wantRenamed  bool
+	}{
+		{policy: decompress.ConflictError, wantErr: decompress.ErrFileExists},
+		{policy: decompress.ConflictOverwrite, wantRestored: true},
+		{policy: decompress.ConflictSkip, wantSkipped: 1},
+		{policy: decompress.ConflictRename, wantRenamed: true},
+		{policy: decompress.ConflictKeepNewer, existingTime: past, wantRestored: true},
+		{policy: decompress.ConflictKeepNewer, existingTime: future, wantSkipped: 1},
+	}
+
+	for name, compressOpts := range formats {
+		t.Run(name, func(t *testing.T) {
+			archiveDir := t.TempDir()
+			archivePath := filepath.Join(archiveDir, "a.gdelta")
+			switch {
+			case compressOpts.UseZipFormat:
+				archivePath = filepath.Join(archiveDir, "a.zip")
+			case compressOpts.UseXzFormat:
+				archivePath = filepath.Join(archiveDir, "a.tar.xz")
+			}
+			compressOpts.InputPath = inputDir
+			compressOpts.OutputPath = archivePath
+			compressOpts.Quiet = true
+			if _, err := compress.Compress(compressOpts, nil); err != nil {
+				t.Fatal(err)
+			}
+			if compressOpts.UseZipFormat {
+				archivePath = filepath.Join(archiveDir, "a_01.zip")
+			}
+
+			for _, tc := range cases {
+				outDir := t.TempDir()
+				existingPath := filepath.Join(outDir, existing)
+				if err := os.MkdirAll(filepath.Dir(existingPath), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(existingPath, []byte("old"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if !tc.existingTime.IsZero() {
+					if err := os.Chtimes(existingPath, tc.existingTime, tc.existingTime); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				result, err := decompress.Decompress(&decompress.Options{
+					InputPath:      archivePath,
+					OutputPath:     outDir,
+					ConflictPolicy: tc.policy,
+					Quiet:          true,
+				}, nil)
+				if err != nil {
+					t.Fatalf("%s: %v", tc.policy, err)
+				}
+
+				if tc.wantErr != nil {
+					if len(result.Errors) != 1 || !errors.Is(result.Errors[0], tc.wantErr) {
+						t.Errorf("%s: errors %v, want %v", tc.policy, result.Errors, tc.wantErr)
+					}
+				} else if !result.Success() {
+					t.Errorf("%s: errors %v, %d/%d processed", tc.policy, result.Errors, result.FilesProcessed, result.FilesTotal)
+				}
+				if result.FilesSkipped != tc.wantSkipped {
+					t.Errorf("%s: %d skipped, want %d", tc.policy, result.FilesSkipped, tc.wantSkipped)
+				}
+
+				content, _ := os.ReadFile(existingPath)
+				if tc.wantRestored != bytes.Equal(content, all[existing]) {
+					t.Errorf("%s: existing file holds %d bytes, restored=%v", tc.policy, len(content), tc.wantRestored)
+				}
+				if !tc.wantRestored && string(content) != "old" {
+					t.Errorf("%s: existing file changed", tc.policy)
+				}
+				renamedContent, err := os.ReadFile(filepath.Join(outDir, renamed))
+				if tc.wantRenamed != (err == nil) || (tc.wantRenamed && !bytes.Equal(renamedContent, all[existing])) {
+					t.Errorf("%s: renamed file: %d bytes, %v", tc.policy, len(renamedContent), err)
+				}
+			}
+		})
+	}
+
+	_, err := decompress.Decompress(&decompress.Options{InputPath: "x.gdelta", ConflictPolicy: "newest"}, nil)
+	if !errors.Is(err, decompress.ErrConflictPolicy) {
+		t.Errorf("unknown policy: got %v, want ErrConflictPolicy", err)
+	}
+}
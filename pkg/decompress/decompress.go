@@ -2,13 +2,19 @@
 package decompress
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/creativeyann17/go-delta/internal/pool"
 	"github.com/creativeyann17/go-delta/pkg/godelta"
 	"github.com/klauspost/compress/zstd"
 )
@@ -18,13 +24,21 @@ type ProgressCallback func(event ProgressEvent)
 
 // ProgressEvent contains progress information
 type ProgressEvent struct {
-	Type             EventType
-	FilePath         string
-	Current          int64
-	Total            int64
-	CurrentBytes     uint64
-	TotalBytes       uint64
-	DecompressedSize uint64
+	Type             EventType `json:"type"`
+	FilePath         string    `json:"file,omitempty"`
+	Current          int64     `json:"current"`
+	Total            int64     `json:"total"`
+	CurrentBytes     uint64    `json:"current_bytes,omitempty"`
+	TotalBytes       uint64    `json:"total_bytes,omitempty"`
+	DecompressedSize uint64    `json:"decompressed_size,omitempty"`
+
+	// Run timing so far: average throughput, throughput over the last
+	// second and the estimated time left (0 until it can be estimated).
+	// Durations are in nanoseconds in JSON.
+	Elapsed               time.Duration `json:"elapsed_ns,omitempty"`
+	BytesPerSecond        float64       `json:"bytes_per_second,omitempty"`
+	InstantBytesPerSecond float64       `json:"instant_bytes_per_second,omitempty"`
+	ETA                   time.Duration `json:"eta_ns,omitempty"`
 }
 
 // EventType indicates the type of progress event
@@ -39,13 +53,65 @@ const (
 	EventError
 )
 
+var eventTypeNames = [...]string{
+	EventStart:        "start",
+	EventFileStart:    "file_start",
+	EventFileProgress: "file_progress",
+	EventFileComplete: "file_complete",
+	EventComplete:     "complete",
+	EventError:        "error",
+}
+
+// String returns the event name used in JSON progress output
+func (t EventType) String() string {
+	if t >= 0 && int(t) < len(eventTypeNames) {
+		return eventTypeNames[t]
+	}
+	return fmt.Sprintf("event(%d)", int(t))
+}
+
+// MarshalText encodes the event type by name
+func (t EventType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// throttleProgress wraps progressCb to apply opts.ProgressInterval and
+// ProgressBytes and fill in the run timing
+func throttleProgress(progressCb ProgressCallback, opts *Options) ProgressCallback {
+	if progressCb == nil {
+		return nil
+	}
+	throttle := godelta.NewProgressThrottle(opts.ProgressInterval, opts.ProgressBytes)
+	return func(event ProgressEvent) {
+		deliver, rates := throttle.Observe(event.generic())
+		if deliver {
+			event.Elapsed = rates.Elapsed
+			event.BytesPerSecond, event.InstantBytesPerSecond = rates.BytesPerSecond, rates.InstantBytesPerSecond
+			event.ETA = rates.ETA
+			progressCb(event)
+		}
+	}
+}
+
 // Decompress decompresses an archive from inputPath to outputPath
 func Decompress(opts *Options, progressCb ProgressCallback) (*Result, error) {
+	return DecompressContext(context.Background(), opts, progressCb)
+}
+
+// DecompressContext is like Decompress but stops when ctx is canceled.
+// Every format checks ctx before each entry and between the writes of an
+// entry; the entries in flight are dropped, so every restored file is
+// complete, and the error wraps ctx.Err().
+func DecompressContext(ctx context.Context, opts *Options, progressCb ProgressCallback) (_ *Result, err error) {
 	if err := opts.Validate(); err != nil {
 		return nil, err
 	}
+	opts.ctx = ctx
+	progressCb = throttleProgress(progressCb, opts)
 
 	result := &Result{}
+	defer recordRun(result, time.Now())
+	defer func() { err = canceledError(ctx, result, err) }()
 
 	// Open archive file
 	archiveFile, err := os.Open(opts.InputPath)
@@ -67,6 +133,33 @@ func Decompress(opts *Options, progressCb ProgressCallback) (*Result, error) {
 
 	// Detect and route based on format
 	detectedFormat := format.DetectFormat(magic)
+	if len(opts.Paths) > 0 && (detectedFormat == format.FormatZIP || detectedFormat == format.FormatXZ) {
+		return nil, ErrPathsFormat
+	}
+	if opts.DryRun {
+		return result, planRestore(archiveFile, detectedFormat, opts, result)
+	}
+	if opts.RepackPath != "" {
+		return result, repack(archiveFile, detectedFormat, opts, progressCb, result, func(_ int, modTime time.Time) (*repackSink, error) {
+			return newRepackSink(opts.RepackPath, opts.conflictPolicy() == ConflictOverwrite, modTime)
+		})
+	}
+	if opts.TarOutput != nil {
+		return result, repack(archiveFile, detectedFormat, opts, progressCb, result, func(_ int, modTime time.Time) (*repackSink, error) {
+			return newStreamSink(opts.TarOutput, nil, false, modTime), nil
+		})
+	}
+	if isStreamTarget(opts.OutputPath) {
+		return result, repack(archiveFile, detectedFormat, opts, progressCb, result, openStreamSink(opts))
+	}
+
+	if err := preflight(archiveFile, detectedFormat, opts, result); err != nil {
+		return result, err
+	}
+	opts.space = watchSpace(opts)
+	opts.links = newLinker(opts)
+	defer func() { err = opts.space.stop(result, err) }()
+
 	switch detectedFormat {
 	case format.FormatZIP:
 		archiveFile.Close() // ZIP reader needs file path, not handle
@@ -89,8 +182,31 @@ func Decompress(opts *Options, progressCb ProgressCallback) (*Result, error) {
 		return result, err
 
 	default:
-		return nil, fmt.Errorf("unknown archive format: %q", magic)
+		if writeOnlyMagic(magic) {
+			return nil, ErrWriteOnlyFormat
+		}
+		return nil, godelta.WithCategory(godelta.ErrUnsupported, fmt.Errorf("unknown archive format: %q", magic))
+	}
+}
+
+// writeOnlyMagic reports whether magic starts a gzip stream or a 7z archive,
+// the formats compress only writes
+func writeOnlyMagic(magic []byte) bool {
+	return bytes.HasPrefix(magic, []byte{0x1f, 0x8b}) || bytes.HasPrefix(magic, []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c})
+}
+
+// WriteOnlyExtensions are the extensions of the archives compress writes
+// but Decompress can't read
+var WriteOnlyExtensions = []string{".tar.gz", ".tgz", ".7z"}
+
+// canceledError returns the run's error: err, or the cancellation of ctx
+// when it stopped entries, replacing their errors
+func canceledError(ctx context.Context, result *Result, err error) error {
+	if ctx.Err() == nil || (err == nil && len(result.Errors) == 0) {
+		return err
 	}
+	result.Errors = slices.DeleteFunc(result.Errors, func(e error) bool { return errors.Is(e, ctx.Err()) })
+	return fmt.Errorf("decompression canceled after %d of %d files: %w", result.FilesProcessed, result.FilesTotal, ctx.Err())
 }
 
 // decompressGDelta01 handles the traditional GDELTA01 format.
@@ -105,42 +221,79 @@ func decompressGDelta01(archiveFile *os.File, opts *Options, progressCb Progress
 	}
 
 	fileCount := reader.FileCount()
-	result.FilesTotal = fileCount
-
-	if progressCb != nil {
-		progressCb(ProgressEvent{
-			Type:  EventStart,
-			Total: int64(fileCount),
-		})
-	}
 
 	// Create output directory
 	if err := os.MkdirAll(opts.OutputPath, 0755); err != nil {
 		return fmt.Errorf("create output directory: %w", err)
 	}
 
-	// Read all entry headers, skipping over the data sections
+	info, err := archiveFile.Stat()
+	if err != nil {
+		return fmt.Errorf("stat archive file: %w", err)
+	}
+	size := parity.DataSize(archiveFile, info.Size())
+
+	// Read all entry headers: from the index at the end of the archive when
+	// there is one, otherwise by skipping from entry to entry over the data
 	var entries []*format.FileEntry
-	var totalCompSize uint64
-	for i := 0; i < fileCount; i++ {
-		entry, err := reader.ReadFileEntry()
+	if opts.Recover {
+		// Only trust entries that lie fully within the archive
+		var scan *format.ScanResult
+		entries, scan, err = format.ListGDelta01(archiveFile, size)
 		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("read entry %d: %w", i, err))
-			// Can't continue after a failed read - file position is unknown
-			break
+			return fmt.Errorf("scan archive: %w", err)
 		}
-		entries = append(entries, entry)
-		totalCompSize += entry.CompressedSize
-
-		// Skip the compressed data to reach the next entry header
-		if i < fileCount-1 {
-			if _, err := archiveFile.Seek(int64(entry.DataOffset+entry.CompressedSize), io.SeekStart); err != nil {
-				result.Errors = append(result.Errors, fmt.Errorf("seek past entry %d: %w", i, err))
+		if err := recoveryError(scan); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+	} else if entries, err = format.ReadGDelta01Index(archiveFile, size); err != nil {
+		entries = nil
+		if opts.Verbose && !errors.Is(err, format.ErrNoIndex) {
+			fmt.Printf("Ignoring entry index: %v\n", err)
+		}
+		for i := 0; i < fileCount; i++ {
+			entry, err := reader.ReadFileEntry()
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("read entry %d: %w", i, err))
+				// Can't continue after a failed read - file position is unknown
 				break
 			}
+			entries = append(entries, entry)
+
+			// Skip the compressed data to reach the next entry header
+			if i < fileCount-1 {
+				if _, err := archiveFile.Seek(int64(entry.DataOffset+entry.CompressedSize), io.SeekStart); err != nil {
+					result.Errors = append(result.Errors, fmt.Errorf("seek past entry %d: %w", i, err))
+					break
+				}
+			}
 		}
 	}
 
+	// Small-file packs hold the data of the files after them
+	entries, packs := format.SplitPacks(entries)
+	result.FilesTotal = fileCount - len(packs)
+	if sel := newPathSelector(opts.Paths); sel != nil {
+		entries = slices.DeleteFunc(entries, func(e *format.FileEntry) bool { return !sel.match(e.Path) })
+		result.FilesTotal = len(entries)
+		result.Errors = append(result.Errors, sel.unmatched()...)
+	}
+	var totalCompSize uint64
+	for _, entry := range packs {
+		totalCompSize += entry.CompressedSize
+	}
+	for _, entry := range entries {
+		totalCompSize += entry.CompressedSize
+	}
+	packCache := format.NewPackCache(entries)
+
+	if progressCb != nil {
+		progressCb(ProgressEvent{
+			Type:  EventStart,
+			Total: int64(result.FilesTotal),
+		})
+	}
+
 	// Decompress entries in parallel
 	workers := opts.MaxThreads
 	if workers > len(entries) {
@@ -149,79 +302,111 @@ func decompressGDelta01(archiveFile *os.File, opts *Options, progressCb Progress
 
 	var mu sync.Mutex // guards result and totals
 	var totalDecompSize uint64
-	var wg sync.WaitGroup
-	entryCh := make(chan *format.FileEntry, workers*4)
+	segmentSem := make(chan struct{}, opts.MaxThreads) // segments decoding at once, across entries
 
-	for i := 0; i < workers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+	mapped := mapArchive(opts)
+	if mapped != nil {
+		defer mapped.Close()
+	}
 
-			// Each worker reads through its own file handle (independent seeks)
-			f, err := os.Open(opts.InputPath)
-			if err != nil {
-				mu.Lock()
-				result.Errors = append(result.Errors, fmt.Errorf("open archive: %w", err))
-				mu.Unlock()
-				return
-			}
-			defer f.Close()
+	degrade := func(d Degradation) {
+		mu.Lock()
+		result.Degradations = append(result.Degradations, d)
+		mu.Unlock()
+	}
 
-			decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
-			if err != nil {
-				mu.Lock()
-				result.Errors = append(result.Errors, fmt.Errorf("create zstd decoder: %w", err))
-				mu.Unlock()
-				return
-			}
-			defer decoder.Close()
+	// run decompresses a batch of entries on the workers
+	run := func(batch []*format.FileEntry) {
+		var wg sync.WaitGroup
+		entryCh := make(chan *format.FileEntry, workers*4)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
 
-			for entry := range entryCh {
-				if progressCb != nil {
-					progressCb(ProgressEvent{
-						Type:     EventFileStart,
-						FilePath: entry.Path,
-						Total:    int64(entry.OriginalSize),
-					})
+				src, closeSrc, err := openArchiveSource(opts.InputPath, mapped)
+				if err != nil {
+					mu.Lock()
+					result.Errors = append(result.Errors, fmt.Errorf("open archive: %w", err))
+					mu.Unlock()
+					return
 				}
+				defer closeSrc()
 
-				decompSize, err := decompressEntryAt(f, entry, decoder, opts, progressCb)
-
+				decoder, err := pool.Decoder()
 				if err != nil {
 					mu.Lock()
-					result.Errors = append(result.Errors, fmt.Errorf("%s: %w", entry.Path, err))
+					result.Errors = append(result.Errors, fmt.Errorf("create zstd decoder: %w", err))
 					mu.Unlock()
+					return
+				}
+				defer pool.PutDecoder(decoder)
+
+				for entry := range entryCh {
 					if progressCb != nil {
 						progressCb(ProgressEvent{
-							Type:     EventError,
+							Type:     EventFileStart,
 							FilePath: entry.Path,
+							Total:    int64(entry.OriginalSize),
 						})
 					}
-					continue
-				}
 
-				mu.Lock()
-				totalDecompSize += decompSize
-				result.FilesProcessed++
-				mu.Unlock()
-				if progressCb != nil {
-					progressCb(ProgressEvent{
-						Type:             EventFileComplete,
-						FilePath:         entry.Path,
-						Current:          int64(entry.OriginalSize),
-						Total:            int64(entry.OriginalSize),
-						DecompressedSize: decompSize,
-					})
+					decompSize, err := decompressEntryAt(src, entry, decoder, segmentSem, packCache, opts, progressCb, degrade)
+
+					if errors.Is(err, errEntrySkipped) {
+						mu.Lock()
+						result.FilesSkipped++
+						mu.Unlock()
+						if progressCb != nil {
+							progressCb(ProgressEvent{Type: EventFileComplete, FilePath: entry.Path})
+						}
+						continue
+					}
+					if err != nil {
+						mu.Lock()
+						result.Errors = append(result.Errors, fmt.Errorf("%s: %w", entry.Path, err))
+						mu.Unlock()
+						if progressCb != nil {
+							progressCb(ProgressEvent{
+								Type:     EventError,
+								FilePath: entry.Path,
+							})
+						}
+						continue
+					}
+
+					mu.Lock()
+					totalDecompSize += decompSize
+					result.FilesProcessed++
+					mu.Unlock()
+					if progressCb != nil {
+						progressCb(ProgressEvent{
+							Type:             EventFileComplete,
+							FilePath:         entry.Path,
+							Current:          int64(entry.OriginalSize),
+							Total:            int64(entry.OriginalSize),
+							DecompressedSize: decompSize,
+						})
+					}
 				}
-			}
-		}()
+			}()
+		}
+
+		for _, entry := range batch {
+			entryCh <- entry
+		}
+		close(entryCh)
+		wg.Wait()
 	}
 
-	for _, entry := range entries {
-		entryCh <- entry
+	// Hardlinks need the file of their target restored first
+	if opts.links != nil {
+		files, links := splitLinks(entries)
+		run(files)
+		run(links)
+	} else {
+		run(entries)
 	}
-	close(entryCh)
-	wg.Wait()
 
 	result.CompressedSize = totalCompSize
 	result.DecompressedSize = totalDecompSize
@@ -240,50 +425,114 @@ func decompressGDelta01(archiveFile *os.File, opts *Options, progressCb Progress
 }
 
 // decompressEntryAt decompresses one file entry from its stored data offset.
-// The archive handle and decoder are owned by the calling worker.
+// The archive source and decoder are owned by the calling worker; segmented
+// zstd entries are decoded in parallel under segmentSem, packed entries are
+// cut from their pack through packs, and links restore their target's data
+// or a hardlink to its restored file.
 func decompressEntryAt(
-	archiveFile *os.File,
+	archive io.ReaderAt,
 	entry *format.FileEntry,
 	decoder *zstd.Decoder,
+	segmentSem chan struct{},
+	packs *format.PackCache,
 	opts *Options,
 	progressCb ProgressCallback,
+	degrade func(Degradation),
 ) (decompressedSize uint64, err error) {
+	if entry.Link {
+		linked, err := opts.links.link(opts, entry, degrade)
+		if linked || err != nil {
+			if entry.Target.Packed {
+				packs.Drop(entry.Target)
+			}
+			if err != nil {
+				return 0, err
+			}
+			return entry.OriginalSize, nil
+		}
+		if entry = entry.Data(); entry == nil {
+			return 0, ErrLinkTarget
+		}
+	}
+
+	// A packed entry is read first, for its pack to be released even if
+	// the file is skipped
+	var packed []byte
+	if entry.Packed {
+		if packed, err = packs.Read(archive, entry); err != nil {
+			return 0, err
+		}
+	}
+
 	// Construct output path, rejecting entries that would escape OutputPath
-	outPath, err := safeJoin(opts.OutputPath, entry.Path)
+	name := opts.rewriter.apply(entry.Path)
+	outPath, err := safeJoin(opts.OutputPath, name)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", entry.Path, err)
 	}
 
-	// Check if file exists
-	if !opts.Overwrite {
-		if _, err := os.Stat(outPath); err == nil {
-			return 0, ErrFileExists
-		}
+	// Apply the conflict policy if the file exists
+	outPath, err = resolveConflict(outPath, time.Time{}, opts)
+	if err != nil {
+		return 0, err
 	}
 
-	// Create parent directories
-	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
-		return 0, fmt.Errorf("create directories: %w", err)
+	// Create output file (and parents), renaming it if the filesystem
+	// rejects the stored name
+	outFile, degradation, err := createOutputFile(opts, name, outPath)
+	if err != nil {
+		return 0, err
+	}
+	// Move the file into place only once it is complete
+	defer func() {
+		if err != nil {
+			outFile.discard()
+		} else if err = outFile.commit(); err == nil {
+			opts.links.restored(entry, outFile.path)
+		}
+	}()
+	if degradation != nil {
+		degrade(*degradation)
 	}
 
-	// Create output file
-	outFile, err := os.Create(outPath)
-	if err != nil {
-		return 0, fmt.Errorf("create output file: %w", err)
+	if entry.Packed {
+		if _, err := outFile.Write(packed); err != nil {
+			return 0, fmt.Errorf("write: %w", err)
+		}
+		return uint64(len(packed)), nil
 	}
-	defer outFile.Close()
 
-	// Seek to this entry's compressed data
-	if _, err := archiveFile.Seek(int64(entry.DataOffset), io.SeekStart); err != nil {
-		return 0, fmt.Errorf("seek to data: %w", err)
+	// Large zstd entries written as independent frames carry a seek table
+	if entry.Method == format.MethodZstd {
+		segments, err := format.ReadSeekTable(archive, int64(entry.DataOffset), int64(entry.CompressedSize))
+		if err != nil {
+			return 0, err
+		}
+		if len(segments) > 1 {
+			return decodeSegments(archive, int64(entry.DataOffset), segments, outFile, segmentSem, func(done uint64) {
+				if progressCb != nil {
+					progressCb(ProgressEvent{
+						Type:         EventFileProgress,
+						FilePath:     entry.Path,
+						Current:      int64(done),
+						Total:        int64(entry.OriginalSize),
+						CurrentBytes: done,
+					})
+				}
+			})
+		}
 	}
 
-	// Create limited reader for compressed data
-	limitedReader := io.LimitReader(archiveFile, int64(entry.CompressedSize))
+	// Reader over this entry's compressed data
+	data, err := sectionReader(archive, int64(entry.DataOffset), int64(entry.CompressedSize))
+	if err != nil {
+		return 0, fmt.Errorf("read data: %w", err)
+	}
 
-	// Reset the worker's zstd decoder onto this entry's data
-	if err := decoder.Reset(limitedReader); err != nil {
-		return 0, fmt.Errorf("reset zstd decoder: %w", err)
+	// Stored entries are copied as-is, others go through the worker's decoder
+	src, err := entryReader(entry.Method, data, decoder)
+	if err != nil {
+		return 0, err
 	}
 
 	// Progress tracking writer (throttled; EventFileComplete finishes the bar)
@@ -306,7 +555,7 @@ func decompressEntryAt(
 	}
 
 	// Decompress
-	_, err = io.Copy(proxy, decoder)
+	_, err = pool.Copy(proxy, src)
 	if err != nil {
 		return 0, fmt.Errorf("decompress: %w", err)
 	}
@@ -2,14 +2,20 @@
 package decompress
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/creativeyann17/go-delta/internal/format"
-	"github.com/klauspost/compress/zstd"
+	"github.com/creativeyann17/go-delta/internal/mmap"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/creativeyann17/go-delta/internal/pool"
+	"github.com/creativeyann17/go-delta/pkg/metrics"
+	"github.com/zeebo/blake3"
 )
 
 // maxChunkCacheBytes bounds the decompressed-chunk cache memory
@@ -53,6 +59,11 @@ func (c *chunkCache) take(hash [32]byte) ([]byte, bool) {
 		delete(c.data, hash)
 		delete(c.refs, hash)
 	}
+	if ok {
+		metrics.ChunkCacheHits.Inc()
+	} else {
+		metrics.ChunkCacheMisses.Inc()
+	}
 	return d, ok
 }
 
@@ -83,13 +94,18 @@ func decompressGDelta02(archiveFile *os.File, opts *Options, progressCb Progress
 	if err != nil {
 		return fmt.Errorf("stat archive file: %w", err)
 	}
-	result.CompressedSize = uint64(archiveInfo.Size())
+	result.CompressedSize = uint64(parity.DataSize(archiveFile, archiveInfo.Size()))
 
 	// Read GDELTA02 header
-	_, fileCount, chunkCount, err := format.ReadGDelta02Header(archiveFile)
+	header, err := format.ReadGDelta02Header(archiveFile)
 	if err != nil {
 		return fmt.Errorf("read GDELTA02 header: %w", err)
 	}
+	fileCount, chunkCount := header.FileCount, header.ChunkCount
+	refs, err := newReferenceChunks(header, opts)
+	if err != nil {
+		return fmt.Errorf("read reference chunks: %w", err)
+	}
 
 	result.FilesTotal = int(fileCount)
 
@@ -113,13 +129,13 @@ func decompressGDelta02(archiveFile *os.File, opts *Options, progressCb Progress
 	}
 
 	// Read all file metadata
-	fileMetadataList := make([]format.FileMetadata, fileCount)
+	fileMetadataList := make([]format.FileMetadata, 0, format.CapHint(fileCount))
 	for i := uint32(0); i < fileCount; i++ {
-		metadata, err := format.ReadFileMetadata(archiveFile)
+		metadata, err := format.ReadFileMetadata(archiveFile, header.Extended)
 		if err != nil {
 			return fmt.Errorf("read file metadata %d: %w", i, err)
 		}
-		fileMetadataList[i] = metadata
+		fileMetadataList = append(fileMetadataList, metadata)
 	}
 
 	// Get current position (start of chunk data section)
@@ -128,6 +144,21 @@ func decompressGDelta02(archiveFile *os.File, opts *Options, progressCb Progress
 		return fmt.Errorf("get chunk data start: %w", err)
 	}
 
+	if opts.Recover {
+		// Only reassemble files whose chunks all made it into the archive
+		var scan *format.ScanResult
+		fileMetadataList, scan = format.IntactGDelta02Files(archiveFile, chunkIndex, refs.chunks(), fileMetadataList, chunkDataStart, int64(result.CompressedSize))
+		if err := recoveryError(scan); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+	}
+
+	if sel := newPathSelector(opts.Paths); sel != nil {
+		fileMetadataList = slices.DeleteFunc(fileMetadataList, func(m format.FileMetadata) bool { return !sel.match(m.RelPath) })
+		result.FilesTotal = len(fileMetadataList)
+		result.Errors = append(result.Errors, sel.unmatched()...)
+	}
+
 	// Create output directory
 	if err := os.MkdirAll(opts.OutputPath, 0755); err != nil {
 		return fmt.Errorf("create output directory: %w", err)
@@ -146,29 +177,39 @@ func decompressGDelta02(archiveFile *os.File, opts *Options, progressCb Progress
 	var wg sync.WaitGroup
 	fileCh := make(chan format.FileMetadata, workers*4)
 
+	degrade := func(d Degradation) {
+		mu.Lock()
+		result.Degradations = append(result.Degradations, d)
+		mu.Unlock()
+	}
+
+	mapped := mapArchive(opts)
+	if mapped != nil {
+		defer mapped.Close()
+	}
+
 	for i := 0; i < workers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 
-			// Each worker reads through its own file handle (independent seeks)
-			f, err := os.Open(opts.InputPath)
+			src, closeSrc, err := openArchiveSource(opts.InputPath, mapped)
 			if err != nil {
 				mu.Lock()
 				result.Errors = append(result.Errors, fmt.Errorf("open archive: %w", err))
 				mu.Unlock()
 				return
 			}
-			defer f.Close()
+			defer closeSrc()
 
-			decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+			decoder, release, err := pool.DictDecoder(header.ChunkDictionary())
 			if err != nil {
 				mu.Lock()
 				result.Errors = append(result.Errors, fmt.Errorf("create zstd decoder: %w", err))
 				mu.Unlock()
 				return
 			}
-			defer decoder.Close()
+			defer release()
 
 			// Reusable buffers for compressed reads and decompressed scratch
 			var readBuf, scratch []byte
@@ -182,8 +223,17 @@ func decompressGDelta02(archiveFile *os.File, opts *Options, progressCb Progress
 					})
 				}
 
-				err := decompressChunkedFile(metadata, f, chunkDataStart, chunkIndex, cache, decoder, &readBuf, &scratch, opts, progressCb)
+				err := decompressChunkedFile(metadata, src, chunkDataStart, chunkIndex, refs, cache, chunkDecoder{decoder, header.Codec.Method()}, &readBuf, &scratch, opts, progressCb, degrade)
 
+				if errors.Is(err, errEntrySkipped) {
+					mu.Lock()
+					result.FilesSkipped++
+					mu.Unlock()
+					if progressCb != nil {
+						progressCb(ProgressEvent{Type: EventFileComplete, FilePath: metadata.RelPath})
+					}
+					continue
+				}
 				if err != nil {
 					mu.Lock()
 					result.Errors = append(result.Errors, fmt.Errorf("%s: %w", metadata.RelPath, err))
@@ -237,104 +287,117 @@ func decompressGDelta02(archiveFile *os.File, opts *Options, progressCb Progress
 }
 
 // decompressChunkedFile reassembles one file from its chunks. The archive
-// handle, decoder and buffers are owned by the calling worker; the chunk
+// source, decoder and buffers are owned by the calling worker; the chunk
 // cache is shared. On error the partial output file is removed.
 func decompressChunkedFile(
 	metadata format.FileMetadata,
-	archiveFile *os.File,
+	archive io.ReaderAt,
 	chunkDataStart int64,
 	chunkIndex map[[32]byte]format.ChunkInfo,
+	refs *referenceChunks,
 	cache *chunkCache,
-	decoder *zstd.Decoder,
+	decoder chunkDecoder,
 	readBuf *[]byte,
 	scratch *[]byte,
 	opts *Options,
 	progressCb ProgressCallback,
+	degrade func(Degradation),
 ) error {
 	// Build output path, rejecting entries that would escape OutputPath
-	outputPath, err := safeJoin(opts.OutputPath, metadata.RelPath)
+	name := opts.rewriter.apply(metadata.RelPath)
+	outputPath, err := safeJoin(opts.OutputPath, name)
 	if err != nil {
 		return fmt.Errorf("%s: %w", metadata.RelPath, err)
 	}
 
-	// Create parent directories
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return fmt.Errorf("create directory: %w", err)
-	}
-
-	// Check if file exists
-	if !opts.Overwrite {
-		if _, err := os.Stat(outputPath); err == nil {
-			return ErrFileExists
-		}
+	// Apply the conflict policy if the file exists
+	outputPath, err = resolveConflict(outputPath, time.Time{}, opts)
+	if err != nil {
+		return err
 	}
 
-	// Create output file
-	outFile, err := os.Create(outputPath)
+	// Create output file (and parents), renaming it if the filesystem
+	// rejects the stored name
+	outFile, degradation, err := createOutputFile(opts, name, outputPath)
 	if err != nil {
-		return fmt.Errorf("create file: %w", err)
+		return err
+	}
+	if degradation != nil {
+		degrade(*degradation)
 	}
 
 	fail := func(err error) error {
-		outFile.Close()
-		os.Remove(outputPath)
+		outFile.discard()
 		return err
 	}
 
-	reportProgress := func(bytesWritten uint64) {
-		if progressCb != nil {
-			progressCb(ProgressEvent{
-				Type:         EventFileProgress,
-				FilePath:     metadata.RelPath,
-				Current:      int64(bytesWritten),
-				Total:        int64(metadata.OrigSize),
-				CurrentBytes: bytesWritten,
-			})
-		}
+	bytesWritten, err := writeChunks(outFile, metadata, archive, chunkDataStart, chunkIndex, refs, cache, decoder, readBuf, scratch,
+		func(bytesWritten uint64) {
+			if progressCb != nil {
+				progressCb(ProgressEvent{
+					Type:         EventFileProgress,
+					FilePath:     metadata.RelPath,
+					Current:      int64(bytesWritten),
+					Total:        int64(metadata.OrigSize),
+					CurrentBytes: bytesWritten,
+				})
+			}
+		})
+	if err != nil {
+		return fail(err)
+	}
+
+	// Verify complete file was written
+	if bytesWritten != metadata.OrigSize {
+		return fail(fmt.Errorf("incomplete (wrote %d, expected %d)", bytesWritten, metadata.OrigSize))
 	}
 
+	return outFile.commit()
+}
+
+// writeChunks writes the content of one file to w chunk by chunk, taking
+// chunks from the shared cache when possible. Compressed chunks are read into
+// readBuf, or decoded straight from the mapped pages when archive is a
+// memory mapping. Chunks missing from the index are read from refs (may be
+// nil). onChunk (may be nil) receives the running byte count after each
+// chunk.
+func writeChunks(
+	w io.Writer,
+	metadata format.FileMetadata,
+	archive io.ReaderAt,
+	chunkDataStart int64,
+	chunkIndex map[[32]byte]format.ChunkInfo,
+	refs *referenceChunks,
+	cache *chunkCache,
+	decoder chunkDecoder,
+	readBuf *[]byte,
+	scratch *[]byte,
+	onChunk func(bytesWritten uint64),
+) (uint64, error) {
 	var bytesWritten uint64
 	for _, chunkHash := range metadata.ChunkHashes {
 		// Cached decompressed chunk: skip the read + decompress entirely
 		if data, ok := cache.take(chunkHash); ok {
-			n, err := outFile.Write(data)
+			n, err := w.Write(data)
 			if err != nil {
-				return fail(fmt.Errorf("write chunk: %w", err))
+				return bytesWritten, fmt.Errorf("write chunk: %w", err)
 			}
 			bytesWritten += uint64(n)
-			reportProgress(bytesWritten)
+			if onChunk != nil {
+				onChunk(bytesWritten)
+			}
 			continue
 		}
 
-		chunkInfo, exists := chunkIndex[chunkHash]
-		if !exists {
-			return fail(fmt.Errorf("chunk not found: %x", chunkHash))
-		}
-
-		// Seek to chunk data
-		if _, err := archiveFile.Seek(chunkDataStart+int64(chunkInfo.Offset), io.SeekStart); err != nil {
-			return fail(fmt.Errorf("seek chunk: %w", err))
-		}
-
-		// Read compressed chunk into the reusable buffer
-		if uint64(cap(*readBuf)) < chunkInfo.CompressedSize {
-			*readBuf = make([]byte, chunkInfo.CompressedSize)
-		}
-		compressedData := (*readBuf)[:chunkInfo.CompressedSize]
-		if _, err := io.ReadFull(archiveFile, compressedData); err != nil {
-			return fail(fmt.Errorf("read chunk: %w", err))
-		}
-
-		// Decompress chunk in one call (appends into reusable scratch)
-		decompressed, err := decoder.DecodeAll(compressedData, (*scratch)[:0])
+		decompressed, err := readChunk(chunkHash, archive, chunkDataStart, chunkIndex, refs, decoder, readBuf, scratch)
 		if err != nil {
-			return fail(fmt.Errorf("decompress chunk: %w", err))
+			return bytesWritten, err
 		}
 
-		// Write decompressed chunk to output file
-		n, err := outFile.Write(decompressed)
+		// Write decompressed chunk to output
+		n, err := w.Write(decompressed)
 		if err != nil {
-			return fail(fmt.Errorf("write chunk: %w", err))
+			return bytesWritten, fmt.Errorf("write chunk: %w", err)
 		}
 		bytesWritten += uint64(n)
 
@@ -346,19 +409,52 @@ func decompressChunkedFile(
 			*scratch = decompressed
 		}
 
-		reportProgress(bytesWritten)
+		if onChunk != nil {
+			onChunk(bytesWritten)
+		}
 	}
+	return bytesWritten, nil
+}
 
-	if err := outFile.Close(); err != nil {
-		os.Remove(outputPath)
-		return fmt.Errorf("close file: %w", err)
+// readChunk returns the decompressed data of a chunk, in scratch: from the
+// archive, or from a reference directory for a chunk missing from the index
+func readChunk(
+	chunkHash [32]byte,
+	archive io.ReaderAt,
+	chunkDataStart int64,
+	chunkIndex map[[32]byte]format.ChunkInfo,
+	refs *referenceChunks,
+	decoder chunkDecoder,
+	readBuf *[]byte,
+	scratch *[]byte,
+) ([]byte, error) {
+	chunkInfo, exists := chunkIndex[chunkHash]
+	if !exists {
+		data, ok, err := refs.read(chunkHash, (*scratch)[:0])
+		if !ok {
+			return nil, fmt.Errorf("chunk not found: %x", chunkHash)
+		}
+		return data, err
 	}
 
-	// Verify complete file was written
-	if bytesWritten != metadata.OrigSize {
-		os.Remove(outputPath)
-		return fmt.Errorf("incomplete (wrote %d, expected %d)", bytesWritten, metadata.OrigSize)
+	// Read compressed chunk (no copy from a mapping)
+	compressedData, err := mmap.Read(archive, chunkDataStart+int64(chunkInfo.Offset), int64(chunkInfo.CompressedSize), readBuf)
+	if err != nil {
+		return nil, fmt.Errorf("read chunk: %w", err)
 	}
 
-	return nil
+	// Decompress chunk in one call (appends into reusable scratch).
+	// Stored chunks are their own data, copied so the cache can keep
+	// it; without a zstd checksum, their hash tells they are intact.
+	if chunkInfo.Stored {
+		if blake3.Sum256(compressedData) != chunkHash {
+			return nil, fmt.Errorf("stored chunk %x does not match its hash", chunkHash[:8])
+		}
+		return append((*scratch)[:0], compressedData...), nil
+	}
+	decompressed, err := decoder.decode(compressedData, (*scratch)[:0])
+	if err != nil {
+		return nil, fmt.Errorf("decompress chunk: %w", err)
+	}
+	return decompressed, nil
 }
@@ -2,12 +2,15 @@
 package decompress
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"time"
 
 	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/creativeyann17/go-delta/internal/pool"
 	"github.com/klauspost/compress/zstd"
 )
 
@@ -18,13 +21,14 @@ func decompressGDelta03(archiveFile *os.File, opts *Options, progressCb Progress
 	if err != nil {
 		return fmt.Errorf("stat archive file: %w", err)
 	}
-	result.CompressedSize = uint64(archiveInfo.Size())
+	result.CompressedSize = uint64(parity.DataSize(archiveFile, archiveInfo.Size()))
 
 	// Read GDELTA03 header (magic already consumed)
-	version, dictSize, fileCount, err := format.ReadGDelta03Header(archiveFile)
+	header, err := format.ReadGDelta03Header(archiveFile)
 	if err != nil {
 		return fmt.Errorf("read GDELTA03 header: %w", err)
 	}
+	version, dictSize, fileCount := header.Version, header.DictSize, header.FileCount
 
 	if version != format.GDELTA03Version {
 		return fmt.Errorf("unsupported GDELTA03 version: %d", version)
@@ -70,16 +74,43 @@ func decompressGDelta03(archiveFile *os.File, opts *Options, progressCb Progress
 	}
 	defer decoder.Close()
 
+	if opts.Recover {
+		// Stop the sequential read below at the last intact entry
+		scan, err := scanGDelta03(archiveFile, result.CompressedSize)
+		if err != nil {
+			return err
+		}
+		if err := recoveryError(scan); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+		fileCount = uint32(scan.Intact)
+	}
+
 	// Decompress each file
 	var totalDecompSize uint64
+	sel := newPathSelector(opts.Paths)
+	selected := 0
+	var read []*format.FileEntry // entries so far, for links to find their data
+	degrade := func(d Degradation) { result.Degradations = append(result.Degradations, d) }
 
 	for i := uint32(0); i < fileCount; i++ {
 		// Read file entry
-		entry, err := format.ReadGDelta03FileEntry(archiveFile)
+		entry, err := format.ReadGDelta03FileEntry(archiveFile, header.Extended)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("read entry %d: %w", i, err))
+			break
+		}
+		dataOffset, err := archiveFile.Seek(0, io.SeekCurrent)
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Errorf("read entry %d: %w", i, err))
 			break
 		}
+		read = append(read, entry.Entry(uint64(dataOffset), read))
+		if !sel.match(entry.Path) {
+			archiveFile.Seek(int64(entry.CompressedSize), io.SeekCurrent)
+			continue
+		}
+		selected++
 
 		if progressCb != nil {
 			progressCb(ProgressEvent{
@@ -89,8 +120,47 @@ func decompressGDelta03(archiveFile *os.File, opts *Options, progressCb Progress
 			})
 		}
 
+		// Links restore a hardlink to their target's restored file, or a
+		// copy of its data
+		data := read[len(read)-1]
+		if entry.Link {
+			linked, err := opts.links.link(opts, data, degrade)
+			if err == nil && !linked && data.Target == nil {
+				err = ErrLinkTarget
+			}
+			switch {
+			case errors.Is(err, errEntrySkipped):
+				result.FilesSkipped++
+				if progressCb != nil {
+					progressCb(ProgressEvent{Type: EventFileComplete, FilePath: entry.Path})
+				}
+				continue
+			case err != nil:
+				result.Errors = append(result.Errors, fmt.Errorf("%s: %w", entry.Path, err))
+				if progressCb != nil {
+					progressCb(ProgressEvent{Type: EventError, FilePath: entry.Path})
+				}
+				continue
+			case linked:
+				result.FilesProcessed++
+				totalDecompSize += entry.OriginalSize
+				if progressCb != nil {
+					progressCb(ProgressEvent{
+						Type:             EventFileComplete,
+						FilePath:         entry.Path,
+						Current:          int64(entry.OriginalSize),
+						Total:            int64(entry.OriginalSize),
+						DecompressedSize: entry.OriginalSize,
+					})
+				}
+				continue
+			}
+		}
+		src := data.Data()
+
 		// Build output path, rejecting entries that would escape OutputPath
-		outputPath, pathErr := safeJoin(opts.OutputPath, entry.Path)
+		name := opts.rewriter.apply(entry.Path)
+		outputPath, pathErr := safeJoin(opts.OutputPath, name)
 		if pathErr != nil {
 			// Skip compressed data to maintain position
 			archiveFile.Seek(int64(entry.CompressedSize), io.SeekCurrent)
@@ -101,47 +171,51 @@ func decompressGDelta03(archiveFile *os.File, opts *Options, progressCb Progress
 			continue
 		}
 
-		// Create parent directories
-		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-			// Skip compressed data to maintain position
+		// Apply the conflict policy if the file exists
+		outputPath, err = resolveConflict(outputPath, time.Time{}, opts)
+		if err != nil {
+			// Skip compressed data
 			archiveFile.Seek(int64(entry.CompressedSize), io.SeekCurrent)
-			result.Errors = append(result.Errors, fmt.Errorf("%s: create directory: %w", entry.Path, err))
-			if progressCb != nil {
-				progressCb(ProgressEvent{Type: EventError, FilePath: entry.Path})
-			}
-			continue
-		}
-
-		// Check if file exists
-		if !opts.Overwrite {
-			if _, err := os.Stat(outputPath); err == nil {
-				// Skip compressed data
-				archiveFile.Seek(int64(entry.CompressedSize), io.SeekCurrent)
-				result.Errors = append(result.Errors, fmt.Errorf("%s: %w", entry.Path, ErrFileExists))
+			if errors.Is(err, errEntrySkipped) {
+				result.FilesSkipped++
 				if progressCb != nil {
-					progressCb(ProgressEvent{Type: EventError, FilePath: entry.Path})
+					progressCb(ProgressEvent{Type: EventFileComplete, FilePath: entry.Path})
 				}
 				continue
 			}
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", entry.Path, err))
+			if progressCb != nil {
+				progressCb(ProgressEvent{Type: EventError, FilePath: entry.Path})
+			}
+			continue
 		}
 
-		// Create output file
-		outFile, err := os.Create(outputPath)
+		// Create output file (and parents), renaming it if the filesystem
+		// rejects the stored name
+		outFile, degradation, err := createOutputFile(opts, name, outputPath)
 		if err != nil {
 			// Skip compressed data
 			archiveFile.Seek(int64(entry.CompressedSize), io.SeekCurrent)
-			result.Errors = append(result.Errors, fmt.Errorf("%s: create file: %w", entry.Path, err))
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", entry.Path, err))
 			if progressCb != nil {
 				progressCb(ProgressEvent{Type: EventError, FilePath: entry.Path})
 			}
 			continue
 		}
+		if degradation != nil {
+			result.Degradations = append(result.Degradations, *degradation)
+		}
 
-		// Read compressed data and decompress
-		compressedData := make([]byte, entry.CompressedSize)
-		if _, err := io.ReadFull(archiveFile, compressedData); err != nil {
-			outFile.Close()
-			os.Remove(outputPath)
+		// Read compressed data (a link's at its target) and decompress
+		compressedData := pool.Bytes(int(src.CompressedSize))
+		if entry.Link {
+			_, err = archiveFile.ReadAt(compressedData, int64(src.DataOffset))
+		} else {
+			_, err = io.ReadFull(archiveFile, compressedData)
+		}
+		if err != nil {
+			pool.PutBytes(compressedData)
+			outFile.discard()
 			result.Errors = append(result.Errors, fmt.Errorf("%s: read compressed data: %w", entry.Path, err))
 			if progressCb != nil {
 				progressCb(ProgressEvent{Type: EventError, FilePath: entry.Path})
@@ -150,10 +224,11 @@ func decompressGDelta03(archiveFile *os.File, opts *Options, progressCb Progress
 		}
 
 		// Decompress using the decoder
-		decompressed, err := decoder.DecodeAll(compressedData, nil)
+		decompressed, err := decodeEntry(src.Method, compressedData, decoder, pool.Scratch(int(entry.OriginalSize)))
+		pool.PutBytes(compressedData)
 		if err != nil {
-			outFile.Close()
-			os.Remove(outputPath)
+			pool.PutBytes(decompressed)
+			outFile.discard()
 			result.Errors = append(result.Errors, fmt.Errorf("%s: decompress: %w", entry.Path, err))
 			if progressCb != nil {
 				progressCb(ProgressEvent{Type: EventError, FilePath: entry.Path})
@@ -161,13 +236,18 @@ func decompressGDelta03(archiveFile *os.File, opts *Options, progressCb Progress
 			continue
 		}
 
-		// Write decompressed data
+		// Write decompressed data, then move the file into place
 		written, err := outFile.Write(decompressed)
-		outFile.Close()
+		pool.PutBytes(decompressed)
+		if err != nil {
+			outFile.discard()
+			err = fmt.Errorf("write: %w", err)
+		} else if err = outFile.commit(); err == nil {
+			opts.links.restored(data, outFile.path)
+		}
 
 		if err != nil {
-			os.Remove(outputPath)
-			result.Errors = append(result.Errors, fmt.Errorf("%s: write: %w", entry.Path, err))
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", entry.Path, err))
 			if progressCb != nil {
 				progressCb(ProgressEvent{Type: EventError, FilePath: entry.Path})
 			}
@@ -198,6 +278,10 @@ func decompressGDelta03(archiveFile *os.File, opts *Options, progressCb Progress
 	}
 
 	result.DecompressedSize = totalDecompSize
+	if sel != nil {
+		result.FilesTotal = selected
+		result.Errors = append(result.Errors, sel.unmatched()...)
+	}
 
 	if progressCb != nil {
 		progressCb(ProgressEvent{
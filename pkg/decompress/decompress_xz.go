@@ -3,12 +3,14 @@ package decompress
 
 import (
 	"archive/tar"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/creativeyann17/go-delta/internal/pool"
 	"github.com/ulikunitz/xz"
 )
 
@@ -16,37 +18,9 @@ import (
 // Supports both single archives and multi-part archives (archive_01.tar.xz, archive_02.tar.xz, ...)
 func decompressXz(opts *Options, progressCb ProgressCallback, result *Result) error {
 	// Detect if this is a multi-part archive (ends with _XX.tar.xz pattern)
-	xzPaths := []string{opts.InputPath}
-
-	baseName := filepath.Base(opts.InputPath)
-	if strings.Contains(baseName, "_") && strings.HasSuffix(baseName, ".tar.xz") {
-		// Check if this looks like archive_01.tar.xz pattern
-		nameWithoutExt := baseName[:len(baseName)-7] // remove .tar.xz
-		parts := strings.Split(nameWithoutExt, "_")
-		if len(parts) >= 2 {
-			lastPart := parts[len(parts)-1]
-			// Check if last part is a number
-			if len(lastPart) == 2 && lastPart[0] >= '0' && lastPart[0] <= '9' && lastPart[1] >= '0' && lastPart[1] <= '9' {
-				// Multi-part archive detected - find all parts
-				basePattern := strings.Join(parts[:len(parts)-1], "_")
-				dirPath := filepath.Dir(opts.InputPath)
-
-				// Find all matching parts
-				xzPaths = []string{}
-				for i := 1; i <= 99; i++ { // Support up to 99 parts
-					partPath := filepath.Join(dirPath, fmt.Sprintf("%s_%02d.tar.xz", basePattern, i))
-					if _, err := os.Stat(partPath); err == nil {
-						xzPaths = append(xzPaths, partPath)
-					} else {
-						break // No more parts
-					}
-				}
-
-				if len(xzPaths) == 0 {
-					return fmt.Errorf("no multi-part archive files found matching pattern: %s_XX.tar.xz", basePattern)
-				}
-			}
-		}
+	xzPaths, err := partPaths(opts.InputPath, ".tar.xz")
+	if err != nil {
+		return err
 	}
 
 	// Count total files across all archives (quick scan)
@@ -92,6 +66,37 @@ func decompressXz(opts *Options, progressCb ProgressCallback, result *Result) er
 	return nil
 }
 
+// partPaths returns every part of a multi-part archive named like
+// name_01<ext>, name_02<ext>, ... (up to 99), or just path for a single
+// archive
+func partPaths(path, ext string) ([]string, error) {
+	baseName := filepath.Base(path)
+	if !strings.Contains(baseName, "_") || !strings.HasSuffix(baseName, ext) {
+		return []string{path}, nil
+	}
+	parts := strings.Split(baseName[:len(baseName)-len(ext)], "_")
+	lastPart := parts[len(parts)-1]
+	if len(lastPart) != 2 || lastPart[0] < '0' || lastPart[0] > '9' || lastPart[1] < '0' || lastPart[1] > '9' {
+		return []string{path}, nil
+	}
+
+	// Multi-part archive detected - find all parts
+	basePattern := strings.Join(parts[:len(parts)-1], "_")
+	dirPath := filepath.Dir(path)
+	var paths []string
+	for i := 1; i <= 99; i++ {
+		partPath := filepath.Join(dirPath, fmt.Sprintf("%s_%02d%s", basePattern, i, ext))
+		if _, err := os.Stat(partPath); err != nil {
+			break // No more parts
+		}
+		paths = append(paths, partPath)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no multi-part archive files found matching pattern: %s_XX%s", basePattern, ext)
+	}
+	return paths, nil
+}
+
 // countTarXzFiles counts the number of files in a .tar.xz archive
 func countTarXzFiles(xzPath string) (int, error) {
 	file, err := os.Open(xzPath)
@@ -153,8 +158,12 @@ func extractTarXzFile(xzPath string, opts *Options, progressCb ProgressCallback,
 			return fmt.Errorf("read tar header: %w", err)
 		}
 
-		// Skip directories (they'll be created as needed)
+		// Directories are created as needed; links and special files can't
+		// be reproduced and are reported as degradations
 		if header.Typeflag != tar.TypeReg {
+			if d, ok := tarDegradation(header); ok {
+				result.Degradations = append(result.Degradations, d)
+			}
 			continue
 		}
 
@@ -168,7 +177,8 @@ func extractTarXzFile(xzPath string, opts *Options, progressCb ProgressCallback,
 		}
 
 		// Construct output path, rejecting entries that would escape OutputPath
-		outPath, pathErr := safeJoin(opts.OutputPath, header.Name)
+		name := opts.rewriter.apply(header.Name)
+		outPath, pathErr := safeJoin(opts.OutputPath, name)
 		if pathErr != nil {
 			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", header.Name, pathErr))
 			if progressCb != nil {
@@ -184,34 +194,22 @@ func extractTarXzFile(xzPath string, opts *Options, progressCb ProgressCallback,
 			continue
 		}
 
-		// Check if file already exists
-		if !opts.Overwrite {
-			if _, err := os.Stat(outPath); err == nil {
-				err := fmt.Errorf("%s: file exists (use --overwrite to replace)", header.Name)
-				result.Errors = append(result.Errors, err)
-
+		// Apply the conflict policy if the file already exists
+		outPath, err = resolveConflict(outPath, header.ModTime, opts)
+		if err != nil {
+			if errors.Is(err, errEntrySkipped) {
+				result.FilesSkipped++
+				if progressCb != nil {
+					progressCb(ProgressEvent{Type: EventFileComplete, FilePath: header.Name})
+				}
+			} else {
+				result.Errors = append(result.Errors, fmt.Errorf("%s: %w", header.Name, err))
 				if progressCb != nil {
 					progressCb(ProgressEvent{
 						Type:     EventError,
 						FilePath: header.Name,
 					})
 				}
-				// Skip the file data
-				if _, err := io.CopyN(io.Discard, tarReader, header.Size); err != nil && err != io.EOF {
-					return fmt.Errorf("skip file data: %w", err)
-				}
-				continue
-			}
-		}
-
-		// Create parent directories
-		if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("%s: mkdir: %w", header.Name, err))
-			if progressCb != nil {
-				progressCb(ProgressEvent{
-					Type:     EventError,
-					FilePath: header.Name,
-				})
 			}
 			// Skip the file data
 			if _, err := io.CopyN(io.Discard, tarReader, header.Size); err != nil && err != io.EOF {
@@ -220,10 +218,11 @@ func extractTarXzFile(xzPath string, opts *Options, progressCb ProgressCallback,
 			continue
 		}
 
-		// Create output file
-		outFile, err := os.Create(outPath)
+		// Create output file (and parents), renaming it if the filesystem
+		// rejects the stored name
+		outFile, degradation, err := createOutputFile(opts, name, outPath)
 		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("%s: create: %w", header.Name, err))
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", header.Name, err))
 			if progressCb != nil {
 				progressCb(ProgressEvent{
 					Type:     EventError,
@@ -237,22 +236,21 @@ func extractTarXzFile(xzPath string, opts *Options, progressCb ProgressCallback,
 			continue
 		}
 
+		if degradation != nil {
+			result.Degradations = append(result.Degradations, *degradation)
+		}
+
 		// Copy data with progress tracking
 		var written int64
-		buf := make([]byte, 32*1024) // 32KB buffer
+		var copyErr error
+		bufp := pool.Buffer()
+		buf := *bufp
 		for {
 			nr, errRead := tarReader.Read(buf)
 			if nr > 0 {
 				nw, errWrite := outFile.Write(buf[0:nr])
 				if errWrite != nil {
-					outFile.Close()
-					result.Errors = append(result.Errors, fmt.Errorf("%s: write: %w", header.Name, errWrite))
-					if progressCb != nil {
-						progressCb(ProgressEvent{
-							Type:     EventError,
-							FilePath: header.Name,
-						})
-					}
+					copyErr = fmt.Errorf("write: %w", errWrite)
 					break
 				}
 				written += int64(nw)
@@ -271,19 +269,28 @@ func extractTarXzFile(xzPath string, opts *Options, progressCb ProgressCallback,
 				break
 			}
 			if errRead != nil {
-				outFile.Close()
-				result.Errors = append(result.Errors, fmt.Errorf("%s: read: %w", header.Name, errRead))
-				if progressCb != nil {
-					progressCb(ProgressEvent{
-						Type:     EventError,
-						FilePath: header.Name,
-					})
-				}
+				copyErr = fmt.Errorf("read: %w", errRead)
 				break
 			}
 		}
+		pool.PutBuffer(bufp)
 
-		outFile.Close()
+		// Move the file into place only once it is complete
+		if copyErr != nil {
+			outFile.discard()
+		} else {
+			copyErr = outFile.commit()
+		}
+		if copyErr != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", header.Name, copyErr))
+			if progressCb != nil {
+				progressCb(ProgressEvent{
+					Type:     EventError,
+					FilePath: header.Name,
+				})
+			}
+			continue
+		}
 
 		// Track stats
 		result.FilesProcessed++
@@ -302,3 +309,23 @@ func extractTarXzFile(xzPath string, opts *Options, progressCb ProgressCallback,
 
 	return nil
 }
+
+// tarDegradation describes a non-regular tar entry that extraction drops.
+// Directories are not degradations (they are recreated implicitly).
+func tarDegradation(header *tar.Header) (Degradation, bool) {
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return Degradation{}, false
+	case tar.TypeSymlink:
+		return Degradation{Path: header.Name, Kind: DegradationSymlink,
+			Detail: "dropped (link to " + header.Linkname + ")"}, true
+	case tar.TypeLink:
+		return Degradation{Path: header.Name, Kind: DegradationHardlink,
+			Detail: "dropped (link to " + header.Linkname + ")"}, true
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		return Degradation{Path: header.Name, Kind: DegradationSpecialFile,
+			Detail: "dropped (device or FIFO)"}, true
+	default:
+		return Degradation{}, false
+	}
+}
@@ -3,11 +3,10 @@ package decompress
 
 import (
 	"archive/zip"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
-	"strings"
 	"sync"
 
 	"github.com/klauspost/compress/flate"
@@ -21,36 +20,9 @@ const progressReportStep = 1 << 20
 // Supports both single ZIP files and multi-part archives (archive_01.zip, archive_02.zip, ...)
 func decompressZip(opts *Options, progressCb ProgressCallback, result *Result) error {
 	// Detect if this is a multi-part archive (ends with _XX.zip pattern)
-	zipPaths := []string{opts.InputPath}
-
-	baseName := filepath.Base(opts.InputPath)
-	if strings.Contains(baseName, "_") && strings.HasSuffix(baseName, ".zip") {
-		// Check if this looks like archive_01.zip pattern
-		parts := strings.Split(baseName[:len(baseName)-4], "_")
-		if len(parts) >= 2 {
-			lastPart := parts[len(parts)-1]
-			// Check if last part is a number
-			if len(lastPart) == 2 && lastPart[0] >= '0' && lastPart[0] <= '9' && lastPart[1] >= '0' && lastPart[1] <= '9' {
-				// Multi-part archive detected - find all parts
-				basePattern := strings.Join(parts[:len(parts)-1], "_")
-				dirPath := filepath.Dir(opts.InputPath)
-
-				// Find all matching parts
-				zipPaths = []string{}
-				for i := 1; i <= 99; i++ { // Support up to 99 parts
-					partPath := filepath.Join(dirPath, fmt.Sprintf("%s_%02d.zip", basePattern, i))
-					if _, err := os.Stat(partPath); err == nil {
-						zipPaths = append(zipPaths, partPath)
-					} else {
-						break // No more parts
-					}
-				}
-
-				if len(zipPaths) == 0 {
-					return fmt.Errorf("no multi-part archive files found matching pattern: %s_XX.zip", basePattern)
-				}
-			}
-		}
+	zipPaths, err := partPaths(opts.InputPath, ".zip")
+	if err != nil {
+		return err
 	}
 
 	// Count total files across all ZIP parts
@@ -144,6 +116,12 @@ func extractZipFile(zipPath string, opts *Options, progressCb ProgressCallback,
 		mu.Unlock()
 	}
 
+	recordDegradation := func(d Degradation) {
+		mu.Lock()
+		result.Degradations = append(result.Degradations, d)
+		mu.Unlock()
+	}
+
 	// Reused across files in this part
 	buf := make([]byte, 256*1024)
 
@@ -159,7 +137,8 @@ func extractZipFile(zipPath string, opts *Options, progressCb ProgressCallback,
 		}
 
 		// Construct output path, rejecting entries that would escape OutputPath
-		outPath, err := safeJoin(opts.OutputPath, zipFile.Name)
+		name := opts.rewriter.apply(zipFile.Name)
+		outPath, err := safeJoin(opts.OutputPath, name)
 		if err != nil {
 			recordError(fmt.Errorf("%s: %w", zipFile.Name, err))
 			if progressCb != nil {
@@ -182,24 +161,19 @@ func extractZipFile(zipPath string, opts *Options, progressCb ProgressCallback,
 			continue
 		}
 
-		// Check if file already exists
-		if !opts.Overwrite {
-			if _, err := os.Stat(outPath); err == nil {
-				recordError(fmt.Errorf("%s: file exists (use --overwrite to replace)", zipFile.Name))
-
-				if progressCb != nil {
-					progressCb(ProgressEvent{
-						Type:     EventError,
-						FilePath: zipFile.Name,
-					})
-				}
-				continue
+		// Apply the conflict policy if the file already exists
+		outPath, err = resolveConflict(outPath, zipFile.Modified, opts)
+		if errors.Is(err, errEntrySkipped) {
+			mu.Lock()
+			result.FilesSkipped++
+			mu.Unlock()
+			if progressCb != nil {
+				progressCb(ProgressEvent{Type: EventFileComplete, FilePath: zipFile.Name})
 			}
+			continue
 		}
-
-		// Create parent directories
-		if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
-			recordError(fmt.Errorf("%s: mkdir: %w", zipFile.Name, err))
+		if err != nil {
+			recordError(fmt.Errorf("%s: %w", zipFile.Name, err))
 			if progressCb != nil {
 				progressCb(ProgressEvent{
 					Type:     EventError,
@@ -222,11 +196,12 @@ func extractZipFile(zipPath string, opts *Options, progressCb ProgressCallback,
 			continue
 		}
 
-		// Create output file
-		outFile, err := os.Create(outPath)
+		// Create output file (and parents), renaming it if the filesystem
+		// rejects the stored name
+		outFile, degradation, err := createOutputFile(opts, name, outPath)
 		if err != nil {
 			rc.Close()
-			recordError(fmt.Errorf("%s: create: %w", zipFile.Name, err))
+			recordError(fmt.Errorf("%s: %w", zipFile.Name, err))
 			if progressCb != nil {
 				progressCb(ProgressEvent{
 					Type:     EventError,
@@ -235,23 +210,28 @@ func extractZipFile(zipPath string, opts *Options, progressCb ProgressCallback,
 			}
 			continue
 		}
+		if degradation != nil {
+			recordDegradation(*degradation)
+		}
+
+		// Symlinks are restored as regular files holding the link target
+		if zipFile.Mode()&os.ModeSymlink != 0 {
+			recordDegradation(Degradation{
+				Path:   zipFile.Name,
+				Kind:   DegradationSymlink,
+				Detail: "restored as a regular file containing the link target",
+			})
+		}
 
 		// Copy data with progress tracking
 		var written, lastReported int64
+		var copyErr error
 		for {
 			nr, errRead := rc.Read(buf)
 			if nr > 0 {
 				nw, errWrite := outFile.Write(buf[0:nr])
 				if errWrite != nil {
-					outFile.Close()
-					rc.Close()
-					recordError(fmt.Errorf("%s: write: %w", zipFile.Name, errWrite))
-					if progressCb != nil {
-						progressCb(ProgressEvent{
-							Type:     EventError,
-							FilePath: zipFile.Name,
-						})
-					}
+					copyErr = fmt.Errorf("write: %w", errWrite)
 					break
 				}
 				written += int64(nw)
@@ -271,22 +251,29 @@ func extractZipFile(zipPath string, opts *Options, progressCb ProgressCallback,
 				break
 			}
 			if errRead != nil {
-				outFile.Close()
-				rc.Close()
-				recordError(fmt.Errorf("%s: read: %w", zipFile.Name, errRead))
-				if progressCb != nil {
-					progressCb(ProgressEvent{
-						Type:     EventError,
-						FilePath: zipFile.Name,
-					})
-				}
+				copyErr = fmt.Errorf("read: %w", errRead)
 				break
 			}
 		}
-
-		outFile.Close()
 		rc.Close()
 
+		// Move the file into place only once it is complete
+		if copyErr != nil {
+			outFile.discard()
+		} else {
+			copyErr = outFile.commit()
+		}
+		if copyErr != nil {
+			recordError(fmt.Errorf("%s: %w", zipFile.Name, copyErr))
+			if progressCb != nil {
+				progressCb(ProgressEvent{
+					Type:     EventError,
+					FilePath: zipFile.Name,
+				})
+			}
+			continue
+		}
+
 		// Track stats
 		mu.Lock()
 		result.FilesProcessed++
@@ -0,0 +1,127 @@
+// pkg/decompress/dedup_test.go
+package decompress_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// TestDedupFiles archives copies and a hardlink of one file as links to it,
+// in GDELTA01 (with packs) and GDELTA03, and restores them as copies, as
+// hardlinks and on their own
+func TestDedupFiles(t *testing.T) {
+	inputDir := t.TempDir()
+	shared := bytes.Repeat([]byte("the same content in several places\n"), 2048)
+	files := map[string][]byte{
+		"a/orig.txt":   shared,
+		"b/copy.txt":   shared,
+		"c/other.txt":  append(bytes.Clone(shared[:len(shared)-1]), '!'),
+		"d/small.txt":  []byte("tiny"),
+		"d/small2.txt": []byte("tiny"),
+		"empty1":       {},
+		"empty2":       {},
+	}
+	for name, data := range files {
+		path := filepath.Join(inputDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Link(filepath.Join(inputDir, "a/orig.txt"), filepath.Join(inputDir, "a/hardlink.txt")); err != nil {
+		t.Skipf("hardlinks not supported: %v", err)
+	}
+	files["a/hardlink.txt"] = shared
+
+	for name, copts := range map[string]compress.Options{
+		"gdelta01": {PackSmallFiles: 1024},
+		"gdelta03": {UseDictionary: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), "dedup.gdelta")
+			copts.InputPath, copts.OutputPath, copts.MaxThreads, copts.DedupFiles, copts.Quiet = inputDir, archive, 4, true, true
+			cresult, err := compress.Compress(&copts, nil)
+			if err != nil {
+				t.Fatalf("compress: %v", err)
+			}
+			// Two of orig/copy/hardlink, and one of the small files
+			if cresult.FilesProcessed != len(files) || cresult.LinkedFiles != 3 || cresult.LinkedBytes != 2*uint64(len(shared))+4 {
+				t.Fatalf("processed %d files, %d linked (%d bytes)", cresult.FilesProcessed, cresult.LinkedFiles, cresult.LinkedBytes)
+			}
+
+			vresult, err := verify.Verify(&verify.Options{InputPath: archive, VerifyData: true}, nil)
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if !vresult.IsValid() || vresult.FilesVerified != len(files) {
+				t.Fatalf("verify: %d of %d files verified, errors %v", vresult.FilesVerified, vresult.FileCount, vresult.Errors)
+			}
+
+			for name, dopts := range map[string]decompress.Options{
+				"copies":    {},
+				"hardlinks": {Hardlinks: true},
+				"selected":  {Paths: []string{"b/copy.txt"}, Hardlinks: true},
+			} {
+				t.Run(name, func(t *testing.T) {
+					outDir := t.TempDir()
+					dopts.InputPath, dopts.OutputPath, dopts.MaxThreads, dopts.Quiet = archive, outDir, 3, true
+					result, err := decompress.Decompress(&dopts, nil)
+					if err != nil {
+						t.Fatalf("decompress: %v", err)
+					}
+					if !result.Success() {
+						t.Fatalf("decompress errors: %v", result.Errors)
+					}
+					for rel, data := range files {
+						got, err := os.ReadFile(filepath.Join(outDir, rel))
+						if dopts.Paths != nil && rel != "b/copy.txt" {
+							if err == nil {
+								t.Errorf("%s restored without being selected", rel)
+							}
+							continue
+						}
+						if err != nil {
+							t.Fatal(err)
+						}
+						if !bytes.Equal(got, data) {
+							t.Errorf("%s: content mismatch", rel)
+						}
+					}
+					if dopts.Paths != nil {
+						return
+					}
+
+					// Links are restored as hardlinks to one of the first
+					// files of their content, and as copies otherwise
+					var linked int
+					for _, group := range [][]string{{"a/orig.txt", "a/hardlink.txt", "b/copy.txt"}, {"d/small.txt", "d/small2.txt"}} {
+						first, err := os.Stat(filepath.Join(outDir, group[0]))
+						if err != nil {
+							t.Fatal(err)
+						}
+						for _, rel := range group[1:] {
+							info, err := os.Stat(filepath.Join(outDir, rel))
+							if err != nil {
+								t.Fatal(err)
+							}
+							if os.SameFile(first, info) {
+								linked++
+							}
+						}
+					}
+					if want := map[bool]int{false: 0, true: 3}[dopts.Hardlinks]; linked != want && len(result.Degradations) == 0 {
+						t.Errorf("%d files restored as hardlinks, want %d", linked, want)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// pkg/decompress/degrade.go
+package decompress
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/zeebo/blake3"
+)
+
+// DegradationKind identifies an archive feature the target filesystem could
+// not reproduce as-is
+type DegradationKind string
+
+const (
+	// DegradationSymlink: a symbolic link entry was dropped or stored as a
+	// regular file holding the link target
+	DegradationSymlink DegradationKind = "symlink"
+
+	// DegradationHardlink: a hard link entry was dropped
+	DegradationHardlink DegradationKind = "hardlink"
+
+	// DegradationSpecialFile: a device, FIFO or other special entry was dropped
+	DegradationSpecialFile DegradationKind = "special-file"
+
+	// DegradationLongName: a path component exceeded the filesystem's name
+	// length limit and was shortened
+	DegradationLongName DegradationKind = "long-name"
+
+	// DegradationInvalidName: the name contained characters the filesystem
+	// rejects (e.g. ':' or '?' on FAT32/exFAT/NTFS) and they were replaced
+	DegradationInvalidName DegradationKind = "invalid-name"
+)
+
+// maxNameComponent is the byte limit applied to each rewritten path component
+// (255 is the common ceiling across ext4, NTFS, FAT32 LFN, exFAT and APFS)
+const maxNameComponent = 255
+
+// Degradation records one entry that was restored in a degraded form (or
+// dropped) because the destination lacks a feature. Degradations are not
+// errors: the rest of the archive still extracts, and they are reported in
+// their own section instead of as a flood of per-file errors.
+type Degradation struct {
+	Path   string          // Entry path as stored in the archive
+	Kind   DegradationKind // Feature that could not be reproduced
+	Detail string          // What was done instead (e.g. "restored as a_b.txt")
+}
+
+func (d Degradation) String() string {
+	return fmt.Sprintf("%s [%s]: %s", d.Path, d.Kind, d.Detail)
+}
+
+// createOutputFile starts the output file for an archive entry at outPath
+// (see outputFile). When the filesystem rejects the name itself (too long, or
+// characters it forbids), the entry name is rewritten into a form it accepts
+// and the file is created there instead; the returned Degradation describes
+// the change. Once the output runs low on space (Options.MinFreeSpace), no
+// entry is started anymore.
+func createOutputFile(opts *Options, entryName, outPath string) (*outputFile, *Degradation, error) {
+	if opts.space.low() {
+		return nil, nil, ErrLowSpace
+	}
+	if err := opts.canceled(); err != nil {
+		return nil, nil, err
+	}
+	f, err := createPartial(outPath)
+	if err == nil || !isNameError(err) {
+		if f != nil {
+			f.space, f.opts = opts.space, opts
+		}
+		return f, nil, err
+	}
+
+	kind := DegradationInvalidName
+	if errors.Is(err, syscall.ENAMETOOLONG) {
+		kind = DegradationLongName
+	}
+
+	altName := sanitizeEntryName(entryName)
+	altPath, joinErr := safeJoin(opts.OutputPath, altName)
+	if joinErr != nil || altPath == outPath {
+		return nil, nil, err
+	}
+	if opts.conflictPolicy() != ConflictOverwrite {
+		if _, statErr := os.Stat(altPath); statErr == nil {
+			return nil, nil, ErrFileExists
+		}
+	}
+
+	f, altErr := createPartial(altPath)
+	if altErr != nil {
+		// Report the original failure: the rewrite was only a fallback
+		return nil, nil, err
+	}
+	f.space, f.opts = opts.space, opts
+
+	return f, &Degradation{
+		Path:   entryName,
+		Kind:   kind,
+		Detail: "restored as " + filepath.ToSlash(altName),
+	}, nil
+}
+
+// isNameError reports whether err means the filesystem refused the file name
+// (as opposed to permissions, missing space, ...)
+func isNameError(err error) bool {
+	return errors.Is(err, syscall.ENAMETOOLONG) || errors.Is(err, syscall.EINVAL) || isInvalidNameErrno(err)
+}
+
+// sanitizeEntryName rewrites an entry path so every component is accepted by
+// restrictive filesystems: characters forbidden on FAT32/exFAT/NTFS and
+// control characters become '_', trailing dots and spaces are trimmed, and
+// components longer than maxNameComponent bytes are shortened while keeping
+// their extension and a short hash of the original name (so two long names
+// sharing a prefix don't collide).
+func sanitizeEntryName(entryName string) string {
+	parts := strings.Split(filepath.ToSlash(entryName), "/")
+	for i, part := range parts {
+		parts[i] = sanitizeComponent(part)
+	}
+	return filepath.FromSlash(strings.Join(parts, "/"))
+}
+
+func sanitizeComponent(name string) string {
+	if name == "" || name == "." || name == ".." {
+		return name
+	}
+
+	var sb strings.Builder
+	for _, r := range name {
+		if r < 0x20 || strings.ContainsRune(`<>:"\|?*`, r) {
+			sb.WriteByte('_')
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	clean := strings.TrimRight(sb.String(), " .")
+	if clean == "" {
+		clean = "_"
+	}
+
+	if len(clean) <= maxNameComponent {
+		return clean
+	}
+
+	sum := blake3.Sum256([]byte(name))
+	suffix := fmt.Sprintf("~%x", sum[:4])
+	ext := filepath.Ext(clean)
+	if len(ext) > 32 {
+		ext = ""
+	}
+	stem := clean[:len(clean)-len(ext)]
+	keep := maxNameComponent - len(suffix) - len(ext)
+	// Cut on a rune boundary so the result stays valid UTF-8
+	for keep > 0 && keep < len(stem) && stem[keep]&0xC0 == 0x80 {
+		keep--
+	}
+	return stem[:keep] + suffix + ext
+}
@@ -0,0 +1,120 @@
+// pkg/decompress/degrade_test.go
+package decompress
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ulikunitz/xz"
+)
+
+func TestSanitizeEntryName(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"plain/file.txt", "plain/file.txt"},
+		{"dir:1/what?.txt", "dir_1/what_.txt"},
+		{`a<b>c"d|e*f.txt`, "a_b_c_d_e_f.txt"},
+		{"trailing./name. ", "trailing/name"},
+		{"tab\there.txt", "tab_here.txt"},
+	}
+	for _, tc := range cases {
+		got := filepath.ToSlash(sanitizeEntryName(tc.in))
+		if got != tc.want {
+			t.Errorf("sanitizeEntryName(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestSanitizeComponentLongName(t *testing.T) {
+	long := strings.Repeat("é", 200) + ".txt" // 404 bytes
+	got := sanitizeComponent(long)
+
+	if len(got) > maxNameComponent {
+		t.Fatalf("component not shortened: %d bytes", len(got))
+	}
+	if !strings.HasSuffix(got, ".txt") {
+		t.Errorf("extension lost: %q", got)
+	}
+	if !utf8.ValidString(got) {
+		t.Errorf("shortened name is not valid UTF-8: %q", got)
+	}
+
+	other := sanitizeComponent(strings.Repeat("é", 201) + ".txt")
+	if got == other {
+		t.Errorf("distinct long names collided after shortening: %q", got)
+	}
+}
+
+// TestTarXzLinksReportedAsDegradations checks that link entries in a tar.xz
+// are summarized as degradations instead of errors while regular files still
+// extract.
+func TestTarXzLinksReportedAsDegradations(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "links.tar.xz")
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("create archive: %v", err)
+	}
+	xw, err := xz.NewWriter(f)
+	if err != nil {
+		t.Fatalf("xz writer: %v", err)
+	}
+	tw := tar.NewWriter(xw)
+
+	content := "regular file\n"
+	entries := []*tar.Header{
+		{Name: "data/file.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))},
+		{Name: "data/link.txt", Typeflag: tar.TypeSymlink, Linkname: "file.txt"},
+		{Name: "data/hard.txt", Typeflag: tar.TypeLink, Linkname: "data/file.txt"},
+	}
+	for _, h := range entries {
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatalf("write header %s: %v", h.Name, err)
+		}
+		if h.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(content)); err != nil {
+				t.Fatalf("write data: %v", err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := xw.Close(); err != nil {
+		t.Fatalf("close xz: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("close file: %v", err)
+	}
+
+	outDir := t.TempDir()
+	result, err := Decompress(&Options{
+		InputPath:  archivePath,
+		OutputPath: outDir,
+		Quiet:      true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+
+	if len(result.Errors) != 0 {
+		t.Errorf("expected no errors, got %v", result.Errors)
+	}
+	counts := result.DegradationCounts()
+	if counts[DegradationSymlink] != 1 || counts[DegradationHardlink] != 1 {
+		t.Errorf("unexpected degradation counts: %v", counts)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "data", "file.txt")); err != nil {
+		t.Errorf("regular file not extracted: %v", err)
+	}
+
+	summary := FormatSummary(result)
+	if !strings.Contains(summary, "Degradations (2 entries") {
+		t.Errorf("summary missing degradation section:\n%s", summary)
+	}
+}
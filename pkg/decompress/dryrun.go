@@ -0,0 +1,252 @@
+// pkg/decompress/dryrun.go
+package decompress
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Plan is what a restore would do to the output directory, worked out by a
+// DryRun without writing anything
+type Plan struct {
+	Create    int // Files that don't exist yet
+	Overwrite int // Existing files that would be replaced
+	Rename    int // Existing files the entry would be restored next to (ConflictRename)
+	Skip      int // Existing files that would be left alone (ConflictSkip, ConflictKeepNewer, not regular files)
+	Conflicts int // Existing files the restore would fail on with ErrFileExists (ConflictError)
+	Unsafe    int // Entries whose path escapes the output directory
+
+	// BytesNeeded is the size of the files that would be written
+	BytesNeeded uint64
+
+	// FreeSpace is the space available on the output filesystem, or on the
+	// filesystem of its nearest existing parent; FreeSpaceKnown is false
+	// when the platform can't tell
+	FreeSpace      uint64
+	FreeSpaceKnown bool
+}
+
+// EnoughSpace reports whether the files fit in the free space (true when it
+// is unknown). Replaced files count in full, as they are rewritten.
+func (p *Plan) EnoughSpace() bool {
+	return !p.FreeSpaceKnown || p.FreeSpace >= p.BytesNeeded
+}
+
+// planEntry is an archive entry a restore would write
+type planEntry struct {
+	path    string
+	size    uint64
+	modTime time.Time // zero for GDELTA entries
+}
+
+// planRestore lists the entries of the archive and checks each one against
+// the output directory, filling result.Plan. Entry data isn't read. Each
+// conflict is recorded in result.Errors as ErrFileExists, like the restore
+// would.
+func planRestore(archiveFile *os.File, detected format.ArchiveFormat, opts *Options, result *Result) error {
+	entries, err := planEntries(archiveFile, detected, opts, result)
+	if err != nil {
+		return err
+	}
+	if opts.CaseInsensitive || caseInsensitive(existingParent(opts.OutputPath)) {
+		if opts.collisions = findCaseCollisions(entries, opts); opts.collisions != nil {
+			result.CaseCollisions = opts.collisions.report
+		}
+	}
+
+	plan := &Plan{}
+	result.Plan = plan
+	result.FilesTotal = len(entries)
+	for _, e := range entries {
+		outPath, err := safeJoin(opts.OutputPath, opts.rewriter.apply(e.path))
+		if err != nil {
+			plan.Unsafe++
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", e.path, err))
+			continue
+		}
+		result.DecompressedSize += e.size
+
+		// An entry giving way to another one folding to the same name
+		if opts.collisions.loses(outPath) {
+			if opts.conflictPolicy() == ConflictRename {
+				plan.Rename++
+				plan.BytesNeeded += e.size
+			} else {
+				plan.Skip++
+			}
+			continue
+		}
+
+		info, err := os.Lstat(outPath)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			plan.Create++
+		case err != nil:
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", e.path, err))
+			continue
+		case opts.conflictPolicy() == ConflictError:
+			plan.Conflicts++
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", e.path, ErrFileExists))
+			continue
+		case !info.Mode().IsRegular():
+			plan.Skip++
+			continue
+		case opts.conflictPolicy() == ConflictOverwrite,
+			opts.conflictPolicy() == ConflictKeepNewer && entryNewer(info, e.modTime, opts):
+			plan.Overwrite++
+		case opts.conflictPolicy() == ConflictRename:
+			plan.Rename++
+		default:
+			plan.Skip++
+			continue
+		}
+		plan.BytesNeeded += e.size
+	}
+
+	plan.FreeSpace, plan.FreeSpaceKnown = freeSpace(existingParent(opts.OutputPath))
+	return nil
+}
+
+// planEntries lists the entries of the archive a restore would write
+func planEntries(archiveFile *os.File, detected format.ArchiveFormat, opts *Options, result *Result) ([]planEntry, error) {
+	switch detected {
+	case format.FormatZIP:
+		return zipPlanEntries(opts.InputPath)
+	case format.FormatXZ:
+		return xzPlanEntries(opts.InputPath)
+	case format.FormatGDelta01, format.FormatGDelta02, format.FormatGDelta03:
+		return gdeltaPlanEntries(archiveFile, detected, opts, result)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArchive, detected)
+	}
+}
+
+// existingParent returns path, or its nearest parent that exists
+func existingParent(path string) string {
+	path = filepath.Clean(path)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// gdeltaPlanEntries lists the entries of a GDELTA archive selected by
+// opts.Paths
+func gdeltaPlanEntries(archiveFile *os.File, detected format.ArchiveFormat, opts *Options, result *Result) ([]planEntry, error) {
+	info, err := archiveFile.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat archive file: %w", err)
+	}
+	size := parity.DataSize(archiveFile, info.Size())
+	result.CompressedSize = uint64(size)
+
+	var listed []repackEntry
+	var scan *format.ScanResult
+	var decoder *zstd.Decoder
+	switch detected {
+	case format.FormatGDelta01:
+		listed, scan, decoder, err = gdelta01RepackEntries(archiveFile, size)
+	case format.FormatGDelta02:
+		listed, scan, decoder, err = gdelta02RepackEntries(archiveFile, size, opts)
+	default:
+		listed, scan, decoder, err = gdelta03RepackEntries(archiveFile, size)
+	}
+	if err != nil {
+		return nil, err
+	}
+	decoder.Close() // only needed to extract
+	if err := recoveryError(scan); err != nil {
+		if !opts.Recover {
+			return nil, fmt.Errorf("%w (use --recover to plan the intact files)", err)
+		}
+		result.Errors = append(result.Errors, err)
+	}
+
+	if sel := newPathSelector(opts.Paths); sel != nil {
+		listed = slices.DeleteFunc(listed, func(e repackEntry) bool { return !sel.match(e.path) })
+		result.Errors = append(result.Errors, sel.unmatched()...)
+	}
+	entries := make([]planEntry, len(listed))
+	for i, e := range listed {
+		entries[i] = planEntry{path: e.path, size: e.size}
+	}
+	return entries, nil
+}
+
+// zipPlanEntries lists the files of every part of a ZIP archive
+func zipPlanEntries(path string) ([]planEntry, error) {
+	zipPaths, err := partPaths(path, ".zip")
+	if err != nil {
+		return nil, err
+	}
+	var entries []planEntry
+	for _, zipPath := range zipPaths {
+		zr, err := zip.OpenReader(zipPath)
+		if err != nil {
+			return nil, fmt.Errorf("open zip archive %s: %w", zipPath, err)
+		}
+		for _, f := range zr.File {
+			if !f.FileInfo().IsDir() {
+				entries = append(entries, planEntry{path: f.Name, size: f.UncompressedSize64, modTime: f.Modified})
+			}
+		}
+		zr.Close()
+	}
+	return entries, nil
+}
+
+// xzPlanEntries lists the regular files of every part of a tar.xz archive.
+// The stream has to be decompressed to reach the headers.
+func xzPlanEntries(path string) ([]planEntry, error) {
+	xzPaths, err := partPaths(path, ".tar.xz")
+	if err != nil {
+		return nil, err
+	}
+	var entries []planEntry
+	for _, xzPath := range xzPaths {
+		file, err := os.Open(xzPath)
+		if err != nil {
+			return nil, err
+		}
+		xzReader, err := xz.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("open %s: %w", xzPath, err)
+		}
+		tarReader := tar.NewReader(xzReader)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				file.Close()
+				return nil, fmt.Errorf("scan archive %s: %w", xzPath, err)
+			}
+			if header.Typeflag == tar.TypeReg {
+				entries = append(entries, planEntry{path: header.Name, size: uint64(header.Size), modTime: header.ModTime})
+			}
+		}
+		file.Close()
+	}
+	return entries, nil
+}
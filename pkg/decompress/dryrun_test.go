@@ -0,0 +1,118 @@
+// pkg/decompress/dryrun_test.go
+package decompress_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+)
+
+// TestDryRun plans restores of every format into a directory holding one of
+// the archive's files, and checks that nothing is written
+func TestDryRun(t *testing.T) {
+	inputDir := t.TempDir()
+	all := buildTestInput(t, inputDir)
+	var total uint64
+	for _, content := range all {
+		total += uint64(len(content))
+	}
+	existing := "sub1/file_001.txt"
+
+	formats := map[string]*compress.Options{
+		"GDELTA01": {Level: 3},
+		"GDELTA02": {Level: 3, ChunkSize: 16 * 1024},
+		"GDELTA03": {Level: 3, UseDictionary: true},
+		"ZIP":      {Level: 3, UseZipFormat: true, MaxThreads: 2},
+		"XZ":       {Level: 1, UseXzFormat: true, MaxThreads: 1},
+	}
+	for name, compressOpts := range formats {
+		t.Run(name, func(t *testing.T) {
+			archiveDir := t.TempDir()
+			archivePath := filepath.Join(archiveDir, "a.gdelta")
+			switch {
+			case compressOpts.UseZipFormat:
+				archivePath = filepath.Join(archiveDir, "a.zip")
+			case compressOpts.UseXzFormat:
+				archivePath = filepath.Join(archiveDir, "a.tar.xz")
+			}
+			compressOpts.InputPath = inputDir
+			compressOpts.OutputPath = archivePath
+			compressOpts.Quiet = true
+			if _, err := compress.Compress(compressOpts, nil); err != nil {
+				t.Fatal(err)
+			}
+			if compressOpts.UseZipFormat {
+				archivePath = filepath.Join(archiveDir, "a_01.zip")
+			}
+
+			outDir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(outDir, "sub1"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(outDir, existing), []byte("old"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			// "" is the default policy, ConflictError
+			for _, policy := range []decompress.ConflictPolicy{"", decompress.ConflictSkip, decompress.ConflictOverwrite} {
+				result, err := decompress.Decompress(&decompress.Options{
+					InputPath:      archivePath,
+					OutputPath:     outDir,
+					ConflictPolicy: policy,
+					DryRun:         true,
+					Quiet:          true,
+				}, nil)
+				if err != nil {
+					t.Fatalf("%s: %v", policy, err)
+				}
+				p := result.Plan
+				if p == nil || p.Create != len(all)-1 || result.FilesTotal != len(all) {
+					t.Fatalf("%s: plan %+v for %d files", policy, p, len(all))
+				}
+				wantBytes := total - uint64(len(all[existing]))
+				switch policy {
+				case decompress.ConflictOverwrite:
+					wantBytes = total
+					if p.Overwrite != 1 || p.Skip != 0 || p.Conflicts != 0 || len(result.Errors) > 0 {
+						t.Errorf("overwrite: plan %+v, errors %v", p, result.Errors)
+					}
+				case decompress.ConflictSkip:
+					if p.Overwrite != 0 || p.Skip != 1 || p.Conflicts != 0 || len(result.Errors) > 0 {
+						t.Errorf("skip: plan %+v, errors %v", p, result.Errors)
+					}
+				default:
+					// The restore would fail on the existing file
+					if p.Overwrite != 0 || p.Skip != 0 || p.Conflicts != 1 {
+						t.Errorf("error: plan %+v", p)
+					}
+					if len(result.Errors) != 1 || !errors.Is(result.Errors[0], decompress.ErrFileExists) {
+						t.Errorf("error: got errors %v, want ErrFileExists", result.Errors)
+					}
+				}
+				if p.BytesNeeded != wantBytes {
+					t.Errorf("%s: %d bytes needed, want %d", policy, p.BytesNeeded, wantBytes)
+				}
+			}
+
+			entries, err := os.ReadDir(outDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("dry run wrote to the output directory: %v", entries)
+			}
+			if content, _ := os.ReadFile(filepath.Join(outDir, existing)); string(content) != "old" {
+				t.Errorf("existing file changed: %q", content)
+			}
+		})
+	}
+
+	_, err := decompress.Decompress(&decompress.Options{InputPath: "x.gdelta", OutputPath: decompress.StdoutPath, DryRun: true}, nil)
+	if !errors.Is(err, decompress.ErrDryRunOutput) {
+		t.Errorf("dry run to stdout: got %v, want ErrDryRunOutput", err)
+	}
+}
@@ -1,19 +1,92 @@
 // pkg/decompress/errors.go
 package decompress
 
-import "errors"
+import "github.com/creativeyann17/go-delta/pkg/godelta"
 
+// Every error matches a godelta error category with errors.Is
 var (
 	// ErrInputRequired is returned when input path is not specified
-	ErrInputRequired = errors.New("input archive path is required")
+	ErrInputRequired = godelta.NewError(godelta.ErrUsage, "input archive path is required")
 
 	// ErrInvalidArchive is returned when archive format is invalid
-	ErrInvalidArchive = errors.New("invalid archive format")
+	ErrInvalidArchive = godelta.NewError(godelta.ErrCorrupt, "invalid archive format")
 
 	// ErrFileExists is returned when output file exists and overwrite is false
-	ErrFileExists = errors.New("file exists (use --overwrite to replace)")
+	ErrFileExists = godelta.NewError(godelta.ErrExists, "file exists (use --overwrite to replace)")
 
 	// ErrUnsafeEntryPath is returned when an archive entry's stored path
 	// would resolve outside the extraction output directory (zip-slip).
-	ErrUnsafeEntryPath = errors.New("entry path escapes output directory")
+	ErrUnsafeEntryPath = godelta.NewError(godelta.ErrCorrupt, "entry path escapes output directory")
+
+	// ErrDamagedArchive is returned by a recovery run when entries past the
+	// intact part of the archive could not be extracted
+	ErrDamagedArchive = godelta.NewError(godelta.ErrCorrupt, "archive is damaged")
+
+	// ErrRepackSource is returned when repacking or streaming an archive of
+	// an unknown format
+	ErrRepackSource = godelta.NewError(godelta.ErrUnsupported, "can't repack or stream this archive format")
+
+	// ErrRepackFormat is returned when the repack output has an unknown extension
+	ErrRepackFormat = godelta.NewError(godelta.ErrUsage, "repack output must end in .zip, .tar.xz or .txz")
+
+	// ErrStreamEntries is returned when a raw stream is given a second entry
+	ErrStreamEntries = godelta.NewError(godelta.ErrUsage, "raw stream output holds a single entry")
+
+	// ErrUnknownMethod is returned for entries compressed with a method this
+	// version can't decode
+	ErrUnknownMethod = godelta.NewError(godelta.ErrUnsupported, "unknown compression method")
+
+	// ErrWriteOnlyFormat is returned for tar.gz and 7z archives, which
+	// compress writes for other tools to read
+	ErrWriteOnlyFormat = godelta.NewError(godelta.ErrUnsupported, "tar.gz and 7z archives are written for other tools and can't be read back; extract them with tar or 7z")
+
+	// ErrPathNotFound is returned for an Options.Paths entry that selects
+	// nothing in the archive
+	ErrPathNotFound = godelta.NewError(godelta.ErrNotFound, "path not found in archive")
+
+	// ErrPathsFormat is returned when Options.Paths is used with a ZIP or
+	// XZ archive
+	ErrPathsFormat = godelta.NewError(godelta.ErrUnsupported, "selecting paths needs a GDELTA archive")
+
+	// ErrDryRunOutput is returned when DryRun is combined with RepackPath,
+	// TarOutput or a stream OutputPath
+	ErrDryRunOutput = godelta.NewError(godelta.ErrUsage, "dry run needs an output directory")
+
+	// ErrTarOutput is returned when TarOutput is combined with RepackPath
+	ErrTarOutput = godelta.NewError(godelta.ErrUsage, "tar output can't be combined with a repack")
+
+	// ErrPathRewrite is returned for an Options.PathRewrites entry without a
+	// From, or with an invalid regular expression
+	ErrPathRewrite = godelta.NewError(godelta.ErrUsage, "invalid path rewrite")
+
+	// ErrPathNormalization is returned when Options.PathNormalization is unknown
+	ErrPathNormalization = godelta.NewError(godelta.ErrUsage, "path normalization must be 'preserve', 'nfc' or 'nfd'")
+
+	// ErrCaseCollision is returned before extracting anything when entries
+	// only differ in case on a case-insensitive output and ConflictPolicy is
+	// ConflictError
+	ErrCaseCollision = godelta.NewError(godelta.ErrExists, "entries collide on a case-insensitive filesystem")
+
+	// ErrNotEnoughSpace is returned by CheckSpace when the entries don't fit
+	// in the free space of the output
+	ErrNotEnoughSpace = godelta.NewError(godelta.ErrNoSpace, "not enough free space for the restore")
+
+	// ErrLowSpace is returned when the output drops under MinFreeSpace
+	// during the restore
+	ErrLowSpace = godelta.NewError(godelta.ErrNoSpace, "free space dropped below the minimum")
+
+	// ErrLinkTarget is returned for a deduplicated file whose linked entry
+	// isn't in the archive
+	ErrLinkTarget = godelta.NewError(godelta.ErrCorrupt, "linked file not found in the archive")
+
+	// ErrReferenceDirs is returned when Options.ReferenceDirs doesn't list
+	// as many directories as the archive references
+	ErrReferenceDirs = godelta.NewError(godelta.ErrUsage, "reference directories don't match the archive's")
+
+	// ErrReferenceChunk is returned for a chunk of a reference directory
+	// that can't be read or no longer matches its hash
+	ErrReferenceChunk = godelta.NewError(godelta.ErrNotFound, "chunk missing from the reference directories")
+
+	// ErrConflictPolicy is returned for an unknown Options.ConflictPolicy
+	ErrConflictPolicy = godelta.NewError(godelta.ErrUsage, "conflict policy must be overwrite, skip, rename, keep-newer or error")
 )
@@ -0,0 +1,153 @@
+// pkg/decompress/features_test.go
+package decompress_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// TestUnsupportedFeature sets a feature bit this version doesn't know in
+// the header: decompress refuses the archive instead of misparsing it
+func TestUnsupportedFeature(t *testing.T) {
+	input := t.TempDir()
+	if err := os.WriteFile(filepath.Join(input, "a.txt"), []byte(strings.Repeat("feature ", 1000)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		offset int // Byte holding the features
+		opts   compress.Options
+	}{
+		"GDELTA02": {15, compress.Options{ChunkSize: 4 << 10}},
+		"GDELTA03": {18, compress.Options{UseDictionary: true}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+			copts := tc.opts
+			copts.InputPath = input
+			copts.OutputPath = archivePath
+			copts.Quiet = true
+			if _, err := compress.Compress(&copts, nil); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(archivePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data[tc.offset] |= 0x80
+			if err := os.WriteFile(archivePath, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: t.TempDir(), Quiet: true}, nil)
+			if err == nil || !strings.Contains(err.Error(), "archive requires feature bit 7 (upgrade godelta)") {
+				t.Fatalf("got %v, want an unsupported feature error", err)
+			}
+			if !errors.Is(godelta.Category(err), godelta.ErrUnsupported) {
+				t.Errorf("category %v, want ErrUnsupported", godelta.Category(err))
+			}
+		})
+	}
+}
+
+// TestWriteOnlyFormat hands decompress the tar.gz and 7z archives compress
+// writes for other tools: they are refused as unsupported, whatever their
+// name
+func TestWriteOnlyFormat(t *testing.T) {
+	input := t.TempDir()
+	if err := os.WriteFile(filepath.Join(input, "a.txt"), []byte("write only"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, copts := range map[string]compress.Options{
+		"tar.gz": {UseTarGzFormat: true},
+		"7z":     {Use7zFormat: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			copts.InputPath = input
+			copts.OutputPath = filepath.Join(dir, "a."+name)
+			copts.Quiet = true
+			if _, err := compress.Compress(&copts, nil); err != nil {
+				t.Fatal(err)
+			}
+			parts, err := filepath.Glob(filepath.Join(dir, "a_*"))
+			if err != nil || len(parts) != 1 {
+				t.Fatalf("parts %v, err %v", parts, err)
+			}
+			renamed := filepath.Join(dir, "renamed.gdelta")
+			if err := os.Rename(parts[0], renamed); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = decompress.Decompress(&decompress.Options{InputPath: renamed, OutputPath: t.TempDir(), Quiet: true}, nil)
+			if !errors.Is(err, decompress.ErrWriteOnlyFormat) {
+				t.Fatalf("got %v, want ErrWriteOnlyFormat", err)
+			}
+			if !errors.Is(godelta.Category(err), godelta.ErrUnsupported) {
+				t.Errorf("category %v, want ErrUnsupported", godelta.Category(err))
+			}
+		})
+	}
+}
+
+// TestOversizedLength sets the compressed size of the first entry or chunk
+// past the end of the archive: the restore fails on a malformed archive
+// instead of allocating that much
+func TestOversizedLength(t *testing.T) {
+	input := t.TempDir()
+	if err := os.WriteFile(filepath.Join(input, "a.txt"), []byte("some content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for name, copts := range map[string]compress.Options{
+		"GDELTA01": {},
+		"GDELTA02": {ChunkSize: 4 << 10},
+		"GDELTA03": {UseDictionary: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			copts.InputPath = input
+			copts.OutputPath = filepath.Join(t.TempDir(), "a.gdelta")
+			copts.Quiet = true
+			if _, err := compress.Compress(&copts, nil); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(copts.OutputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			layout, _ := format.Describe(bytes.NewReader(data), int64(len(data)), 1)
+			i := slices.IndexFunc(layout.Fields, func(f format.Field) bool { return strings.HasSuffix(f.Name, "].comp_size") })
+			if i < 0 {
+				t.Fatal("no comp_size field")
+			}
+			binary.LittleEndian.PutUint64(data[layout.Fields[i].Offset:], 1<<62)
+			// Cut the GDELTA01 entry index so the entry header is the only copy
+			if j := slices.IndexFunc(layout.Fields, func(f format.Field) bool { return f.Name == "index.entries" }); j >= 0 {
+				data = data[:layout.Fields[j].Offset]
+			}
+			if err := os.WriteFile(copts.OutputPath, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := decompress.Decompress(&decompress.Options{InputPath: copts.OutputPath, OutputPath: t.TempDir(), Quiet: true}, nil)
+			if err == nil && (result == nil || len(result.Errors) == 0) {
+				t.Fatal("malformed archive restored without errors")
+			}
+			if !strings.Contains(fmt.Sprint(err, result), "past the end of the archive") {
+				t.Errorf("got %v, want the data reported past the end", err)
+			}
+		})
+	}
+}
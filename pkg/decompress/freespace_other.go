@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package decompress
+
+// freeSpace can't tell the free space on this platform
+func freeSpace(string) (uint64, bool) {
+	return 0, false
+}
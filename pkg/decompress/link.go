@@ -0,0 +1,87 @@
+// pkg/decompress/link.go
+package decompress
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+)
+
+// linker restores the links of file-level dedup as hardlinks to the file
+// restored for their target (Options.Hardlinks). A nil linker restores
+// nothing, and links are written as copies.
+type linker struct {
+	mu    sync.Mutex
+	paths map[*format.FileEntry]string // where each entry was restored
+}
+
+func newLinker(opts *Options) *linker {
+	if !opts.Hardlinks {
+		return nil
+	}
+	return &linker{paths: make(map[*format.FileEntry]string)}
+}
+
+// restored records where entry was restored
+func (l *linker) restored(entry *format.FileEntry, path string) {
+	if l == nil || entry.Link {
+		return
+	}
+	l.mu.Lock()
+	l.paths[entry] = path
+	l.mu.Unlock()
+}
+
+// link restores link entry as a hardlink to its target's restored file. It
+// returns false, for the link to be restored as a copy, when the target
+// wasn't restored by this run or the filesystem can't link them; the
+// latter is reported through degrade.
+func (l *linker) link(opts *Options, entry *format.FileEntry, degrade func(Degradation)) (bool, error) {
+	if l == nil {
+		return false, nil
+	}
+	l.mu.Lock()
+	target, ok := l.paths[entry.Target]
+	l.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+
+	outPath, err := safeJoin(opts.OutputPath, opts.rewriter.apply(entry.Path))
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", entry.Path, err)
+	}
+	if outPath, err = resolveConflict(outPath, time.Time{}, opts); err != nil {
+		return false, err
+	}
+	if opts.space.low() {
+		return false, ErrLowSpace
+	}
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return false, fmt.Errorf("create directories: %w", err)
+	}
+	// The conflict policy already allowed replacing whatever is there
+	os.Remove(outPath)
+	if err := os.Link(target, outPath); err != nil {
+		degrade(Degradation{Path: entry.Path, Kind: DegradationHardlink, Detail: "restored as a copy of " + entry.Target.Path})
+		return false, nil
+	}
+	return true, nil
+}
+
+// splitLinks separates the link entries from the others, for the links to
+// be restored once their targets are
+func splitLinks(entries []*format.FileEntry) (files, links []*format.FileEntry) {
+	for _, e := range entries {
+		if e.Link {
+			links = append(links, e)
+		} else {
+			files = append(files, e)
+		}
+	}
+	return files, links
+}
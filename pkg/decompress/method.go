@@ -0,0 +1,56 @@
+// pkg/decompress/method.go
+package decompress
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/klauspost/compress/zstd"
+)
+
+// entryReader returns a reader over an entry's original bytes: the data
+// itself for stored entries, the worker's decoder reset onto it for zstd,
+// and a reader from the codec registry otherwise
+func entryReader(method format.Method, data io.Reader, decoder *zstd.Decoder) (io.Reader, error) {
+	switch method {
+	case format.MethodStore:
+		return data, nil
+	case format.MethodZstd:
+		if err := decoder.Reset(data); err != nil {
+			return nil, fmt.Errorf("reset zstd decoder: %w", err)
+		}
+		return decoder, nil
+	}
+	codec, ok := format.LookupCodec(method)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMethod, method)
+	}
+	return codec.NewReader(data)
+}
+
+// decodeEntry decodes an entry held in memory, appending to dst
+func decodeEntry(method format.Method, data []byte, decoder *zstd.Decoder, dst []byte) ([]byte, error) {
+	switch method {
+	case format.MethodStore:
+		return append(dst, data...), nil
+	case format.MethodZstd:
+		return decoder.DecodeAll(data, dst)
+	}
+	codec, ok := format.LookupCodec(method)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMethod, method)
+	}
+	return format.DecodeBlock(codec, dst, data)
+}
+
+// chunkDecoder decodes GDELTA02 chunks with the codec recorded in the
+// header; zstd reuses the worker's decoder
+type chunkDecoder struct {
+	zstd  *zstd.Decoder
+	codec format.Method
+}
+
+func (d chunkDecoder) decode(data, dst []byte) ([]byte, error) {
+	return decodeEntry(d.codec, data, d.zstd, dst)
+}
@@ -0,0 +1,152 @@
+// pkg/decompress/method_test.go
+package decompress_test
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// TestStoredEntries round-trips archives mixing stored and compressed
+// entries: already-compressed extensions are written as-is and restored
+// without going through the decoder
+func TestStoredEntries(t *testing.T) {
+	inputDir := t.TempDir()
+	photo := make([]byte, 200*1024)
+	rand.New(rand.NewSource(1)).Read(photo)
+	// No known extension: only entropy sampling can tell it is random
+	blob := make([]byte, 100*1024)
+	rand.New(rand.NewSource(2)).Read(blob)
+	want := map[string][]byte{
+		"photo.JPG":      photo,
+		"vault/key.enc":  blob,
+		"logs/app.log":   bytes.Repeat([]byte("GET /index.html 200\n"), 4000),
+		"blobs/data.bin": bytes.Repeat([]byte{0xAB}, 64*1024),
+		"empty.gz":       {},
+	}
+	for rel, content := range want {
+		full := filepath.Join(inputDir, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		opts   compress.Options
+		stored int
+	}{
+		"GDELTA01":           {compress.Options{StoreExtensions: []string{"bin"}}, 4},
+		"GDELTA01 in memory": {compress.Options{MaxThreadMemory: 1 << 20}, 3},
+		"GDELTA03":           {compress.Options{UseDictionary: true, StoreExtensions: []string{".BIN"}}, 4},
+		"no entropy check":   {compress.Options{NoEntropyCheck: true}, 2},
+		"compress all":       {compress.Options{CompressAll: true, StoreExtensions: []string{".bin"}}, 0},
+		"compress all dict":  {compress.Options{UseDictionary: true, CompressAll: true}, 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+			copts := tc.opts
+			copts.InputPath = inputDir
+			copts.OutputPath = archivePath
+			copts.Quiet = true
+			cresult, err := compress.Compress(&copts, nil)
+			if err != nil {
+				t.Fatalf("compress: %v", err)
+			}
+			if cresult.StoredFiles != tc.stored {
+				t.Errorf("expected %d stored files, got %d", tc.stored, cresult.StoredFiles)
+			}
+			if tc.stored > 0 && cresult.CompressedSize < uint64(len(photo)) {
+				t.Errorf("random photo should be stored as-is, archive is only %d bytes", cresult.CompressedSize)
+			}
+
+			vresult, err := verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true}, nil)
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if !vresult.IsValid() {
+				t.Fatalf("verify errors: %v", vresult.Errors)
+			}
+
+			outDir := t.TempDir()
+			result, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: outDir, Quiet: true}, nil)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			if !result.Success() {
+				t.Fatalf("decompress errors: %v", result.Errors)
+			}
+			for rel, content := range want {
+				got, err := os.ReadFile(filepath.Join(outDir, rel))
+				if err != nil {
+					t.Fatalf("read %s: %v", rel, err)
+				}
+				if !bytes.Equal(got, content) {
+					t.Errorf("%s: content mismatch", rel)
+				}
+			}
+		})
+	}
+}
+
+// TestCodecs round-trips GDELTA01 and GDELTA02 archives through every
+// selectable codec; decompress and verify pick the decoder from the archive
+func TestCodecs(t *testing.T) {
+	inputDir := t.TempDir()
+	want := buildTestInput(t, inputDir)
+
+	for _, codec := range compress.Codecs() {
+		for name, chunkSize := range map[string]uint64{"GDELTA01": 0, "GDELTA02": 16 * 1024} {
+			t.Run(codec+"/"+name, func(t *testing.T) {
+				archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+				_, err := compress.Compress(&compress.Options{
+					InputPath:  inputDir,
+					OutputPath: archivePath,
+					Codec:      codec,
+					ChunkSize:  chunkSize,
+					Quiet:      true,
+				}, nil)
+				if err != nil {
+					t.Fatalf("compress: %v", err)
+				}
+
+				vresult, err := verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true}, nil)
+				if err != nil {
+					t.Fatalf("verify: %v", err)
+				}
+				if !vresult.IsValid() {
+					t.Fatalf("verify errors: %v", vresult.Errors)
+				}
+				if chunkSize > 0 && vresult.Codec != codec {
+					t.Errorf("verify reports codec %q, want %q", vresult.Codec, codec)
+				}
+
+				outDir := t.TempDir()
+				result, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: outDir, Quiet: true}, nil)
+				if err != nil {
+					t.Fatalf("decompress: %v", err)
+				}
+				if !result.Success() {
+					t.Fatalf("decompress errors: %v", result.Errors)
+				}
+				for rel, content := range want {
+					got, err := os.ReadFile(filepath.Join(outDir, rel))
+					if err != nil {
+						t.Fatalf("read %s: %v", rel, err)
+					}
+					if !bytes.Equal(got, content) {
+						t.Errorf("%s: content mismatch", rel)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,16 @@
+// pkg/decompress/metrics.go
+package decompress
+
+import (
+	"time"
+
+	"github.com/creativeyann17/go-delta/pkg/metrics"
+)
+
+// recordRun records a finished decompression
+func recordRun(result *Result, start time.Time) {
+	metrics.OperationDuration.Observe(time.Since(start).Seconds(), metrics.OpDecompress)
+	metrics.FilesProcessed.Add(float64(result.FilesProcessed), metrics.OpDecompress)
+	metrics.FilesFailed.Add(float64(len(result.Errors)), metrics.OpDecompress)
+	metrics.BytesRestored.Add(float64(result.DecompressedSize))
+}
@@ -0,0 +1,55 @@
+// pkg/decompress/mmap.go
+package decompress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/creativeyann17/go-delta/internal/mmap"
+)
+
+// mapArchive maps the archive when opts.Mmap is set. It returns nil when
+// mapping is off or not possible, and workers then read through their own
+// handles.
+func mapArchive(opts *Options) *mmap.File {
+	if !opts.Mmap {
+		return nil
+	}
+	m, err := mmap.Open(opts.InputPath)
+	if err != nil {
+		if opts.Verbose {
+			fmt.Printf("Reading the archive without mmap: %v\n", err)
+		}
+		return nil
+	}
+	return m
+}
+
+// openArchiveSource returns what a worker reads entry data from: the shared
+// mapping, or a handle of its own (independent seeks) to close when done
+func openArchiveSource(path string, mapped *mmap.File) (io.ReaderAt, func() error, error) {
+	if mapped != nil {
+		return mapped, func() error { return nil }, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f.Close, nil
+}
+
+// sectionReader reads the n bytes at off in archive. From a mapping it is a
+// bytes.Buffer over the mapped pages, which the zstd decoder decodes in
+// place instead of copying through its input buffers.
+func sectionReader(archive io.ReaderAt, off, n int64) (io.Reader, error) {
+	if m, ok := archive.(*mmap.File); ok {
+		data, err := m.Slice(off, n)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(data), nil
+	}
+	return io.NewSectionReader(archive, off, n), nil
+}
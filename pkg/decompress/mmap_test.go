@@ -0,0 +1,58 @@
+// pkg/decompress/mmap_test.go
+package decompress_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// TestMmap extracts and verifies archives through a memory mapping
+func TestMmap(t *testing.T) {
+	inputDir := t.TempDir()
+	want := buildTestInput(t, inputDir)
+
+	formats := map[string]*compress.Options{
+		// Segments make the shared files decode from several goroutines
+		"GDELTA01": {Level: 3, SegmentSize: 32 * 1024},
+		"GDELTA02": {Level: 3, ChunkSize: 16 * 1024},
+	}
+	for name, compressOpts := range formats {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+			compressOpts.InputPath = inputDir
+			compressOpts.OutputPath = archivePath
+			compressOpts.Quiet = true
+			if _, err := compress.Compress(compressOpts, nil); err != nil {
+				t.Fatalf("compress: %v", err)
+			}
+
+			outDir := t.TempDir()
+			result, err := decompress.Decompress(&decompress.Options{
+				InputPath:  archivePath,
+				OutputPath: outDir,
+				MaxThreads: 4,
+				Mmap:       true,
+				Quiet:      true,
+			}, nil)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			if !result.Success() {
+				t.Fatalf("decompress errors: %v", result.Errors)
+			}
+			verifyOutput(t, outDir, want)
+
+			vr, err := verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true, Mmap: true}, nil)
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if !vr.IsValid() || !vr.DataVerified {
+				t.Fatalf("verify errors: %v", vr.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package decompress
+
+// isInvalidNameErrno reports platform-specific "bad file name" errors.
+// On Unix, FAT/exFAT mounts reject bad names with EINVAL (handled by the
+// caller), so there is nothing extra to check.
+func isInvalidNameErrno(err error) bool {
+	return false
+}
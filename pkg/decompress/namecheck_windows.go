@@ -0,0 +1,17 @@
+//go:build windows
+
+package decompress
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorInvalidName is ERROR_INVALID_NAME, returned for names containing
+// characters NTFS/FAT reject
+const errorInvalidName = syscall.Errno(123)
+
+// isInvalidNameErrno reports Windows-specific "bad file name" errors
+func isInvalidNameErrno(err error) bool {
+	return errors.Is(err, errorInvalidName)
+}
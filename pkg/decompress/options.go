@@ -2,8 +2,39 @@
 package decompress
 
 import (
+	"context"
+	"fmt"
 	"io"
+	"os"
 	"runtime"
+	"time"
+
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// ConflictPolicy decides what happens to an entry whose output file already
+// exists
+type ConflictPolicy string
+
+const (
+	// ConflictOverwrite replaces the existing file
+	ConflictOverwrite ConflictPolicy = "overwrite"
+
+	// ConflictSkip leaves the existing file alone and doesn't restore the entry
+	ConflictSkip ConflictPolicy = "skip"
+
+	// ConflictRename restores the entry next to the existing file, under the
+	// first free name of file.1.txt, file.2.txt, ...
+	ConflictRename ConflictPolicy = "rename"
+
+	// ConflictKeepNewer replaces the existing file only when the entry's
+	// modification time is later. Entries without a timestamp (GDELTA
+	// entries, and the ZIP and tar.xz archives godelta writes) use the
+	// archive's own modification time.
+	ConflictKeepNewer ConflictPolicy = "keep-newer"
+
+	// ConflictError reports the entry as ErrFileExists (the default)
+	ConflictError ConflictPolicy = "error"
 )
 
 // Options configures the decompression behavior
@@ -11,7 +42,9 @@ type Options struct {
 	// Input archive path
 	InputPath string
 
-	// Output directory path
+	// Output directory path. StdoutPath ("-") or a named pipe (FIFO)
+	// streams the restore instead: the content of a single-entry archive as
+	// is, or an uncompressed tar stream of every entry.
 	OutputPath string
 
 	// Maximum number of concurrent decompression threads
@@ -27,13 +60,129 @@ type Options struct {
 	// ProgressWriter receives progress updates (optional)
 	ProgressWriter io.Writer
 
+	// ProgressInterval and ProgressBytes thin out file progress events:
+	// one is delivered once this much time has passed, or this many more
+	// bytes were processed, since the last one. Other events always are.
+	// 0 and 0 = every event (one per buffer read)
+	// Default: 0
+	ProgressInterval time.Duration
+	ProgressBytes    uint64
+
 	// Quiet suppresses all output except errors
 	Quiet bool
 
-	// Overwrite existing files without prompting
+	// Overwrite existing files without prompting. Shorthand for
+	// ConflictPolicy ConflictOverwrite; ignored when ConflictPolicy is set.
 	Overwrite bool
+
+	// ReferenceDirs are the reference directories of a GDELTA02 archive
+	// made with compress.Options.ReferenceDirs, in the same order, when
+	// they are no longer where the archive recorded them. Their files must
+	// be unchanged.
+	// Default: the directories recorded in the archive
+	ReferenceDirs []string
+
+	// ConflictPolicy applies to entries whose output file already exists.
+	// Default: ConflictOverwrite with Overwrite, ConflictError otherwise
+	ConflictPolicy ConflictPolicy
+
+	// Recover extracts the intact part of a GDELTA archive whose tail or
+	// footer is missing (e.g. an interrupted compression). Entries are
+	// located by a forward scan that stops at the first damaged one.
+	Recover bool
+
+	// RepackPath converts a GDELTA archive into a ZIP (.zip) or tar.xz
+	// (.tar.xz, .txz) at this path instead of extracting to OutputPath.
+	// Entries are streamed from one archive into the other without touching
+	// the filesystem in between.
+	RepackPath string
+
+	// Paths restricts extraction to these entries: a path selects the entry
+	// stored under it, or every entry below it when it names a directory.
+	// GDELTA01 archives with an entry index are read without walking the
+	// other entries. Paths that select nothing are reported as
+	// ErrPathNotFound. GDELTA formats only.
+	Paths []string
+
+	// PathRewrites restore entries under other paths, so an archive made on
+	// one machine layout can be restored onto another. The first rewrite
+	// matching an entry's path applies; it is matched after Paths selects the
+	// entries, and the rewritten path must still stay inside OutputPath.
+	// Also applies to RepackPath and stream outputs.
+	PathRewrites []Rewrite
+
+	// PathNormalization restores entries under their paths in this Unicode
+	// form, before PathRewrites apply, e.g. godelta.NormalizeNFC to restore
+	// an archive made on macOS with the names Linux tools expect. Paths
+	// still selects entries by their stored path.
+	// Default: entries keep their stored path
+	PathNormalization godelta.PathNormalization
+
+	// Mmap reads GDELTA01 and GDELTA02 entry data from a memory mapping of
+	// the archive instead of a read per entry or chunk, and feeds the
+	// decoders straight from the mapped pages. Archives that can't be mapped
+	// (pipes, network filesystems, unsupported platforms) are read as usual.
+	Mmap bool
+
+	// DryRun walks the archive and reports in Result.Plan how many files
+	// would be created, overwritten, skipped or fail to restore (conflicts,
+	// also in Result.Errors) in OutputPath, how many bytes
+	// that takes and whether the filesystem has room, without writing
+	// anything. Entries are listed, not extracted (a tar.xz is still read
+	// through to reach its headers). Can't be combined with RepackPath or a
+	// stream OutputPath.
+	DryRun bool
+
+	// StreamTar writes a tar stream to a stream OutputPath even when the
+	// archive holds a single entry
+	StreamTar bool
+
+	// TarOutput receives an uncompressed tar stream of every entry instead
+	// of extracting to OutputPath (see ToTar). Can't be combined with
+	// RepackPath or DryRun.
+	TarOutput io.Writer
+
+	// CaseInsensitive checks entries for case-only collisions (README.md and
+	// readme.md) as if OutputPath were case-insensitive. Case-insensitive
+	// filesystems (macOS, Windows) are detected and always checked; this
+	// covers restores copied to one afterwards.
+	CaseInsensitive bool
+
+	// CheckSpace lists the entries before extracting them and fails with
+	// ErrNotEnoughSpace, writing nothing, when their total size plus
+	// MinFreeSpace doesn't fit in the free space of OutputPath
+	CheckSpace bool
+
+	// MinFreeSpace stops the restore with ErrLowSpace once OutputPath has
+	// less free space than this, checked every second: no entry is started
+	// and the ones in flight are dropped, so every restored file is
+	// complete and running again with ConflictSkip resumes it
+	// 0 = no check
+	MinFreeSpace uint64
+
+	// Hardlinks restores the files archived as links to an earlier one
+	// (compress.Options.DedupFiles) as hardlinks to it instead of copies,
+	// when both are restored and the filesystem allows it
+	Hardlinks bool
+
+	rewriter   *pathRewriter   // compiled PathRewrites, set by Validate
+	collisions *caseCollisions // set by preflight
+	space      *spaceGuard     // set by Decompress with MinFreeSpace
+	links      *linker         // set by Decompress with Hardlinks
+	ctx        context.Context // set by DecompressContext
+}
+
+// canceled returns the error of the run's context once it is canceled
+func (o *Options) canceled() error {
+	if o.ctx == nil {
+		return nil
+	}
+	return o.ctx.Err()
 }
 
+// StdoutPath as OutputPath streams the restore to standard output
+const StdoutPath = "-"
+
 // DefaultOptions returns options with sensible defaults
 func DefaultOptions() *Options {
 	return &Options{
@@ -56,8 +205,38 @@ func (o *Options) Validate() error {
 	if o.MaxThreads <= 0 {
 		o.MaxThreads = runtime.NumCPU()
 	}
-	if o.Quiet {
+	switch o.ConflictPolicy {
+	case "", ConflictOverwrite, ConflictSkip, ConflictRename, ConflictKeepNewer, ConflictError:
+	default:
+		return fmt.Errorf("%w: %q", ErrConflictPolicy, o.ConflictPolicy)
+	}
+	rewriter, err := newPathRewriter(o.PathRewrites, o.PathNormalization)
+	if err != nil {
+		return err
+	}
+	o.rewriter = rewriter
+	if o.DryRun && (o.RepackPath != "" || o.TarOutput != nil || isStreamTarget(o.OutputPath)) {
+		return ErrDryRunOutput
+	}
+	if o.TarOutput != nil && o.RepackPath != "" {
+		return ErrTarOutput
+	}
+	// Verbose lines would end up inside the stream
+	if o.Quiet || o.OutputPath == StdoutPath || o.TarOutput == io.Writer(os.Stdout) {
 		o.Verbose = false
 	}
 	return nil
 }
+
+// conflictPolicy returns ConflictPolicy, or the policy Overwrite stands for
+// when it isn't set
+func (o *Options) conflictPolicy() ConflictPolicy {
+	switch {
+	case o.ConflictPolicy != "":
+		return o.ConflictPolicy
+	case o.Overwrite:
+		return ConflictOverwrite
+	default:
+		return ConflictError
+	}
+}
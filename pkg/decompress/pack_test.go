@@ -0,0 +1,102 @@
+// pkg/decompress/pack_test.go
+package decompress_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// TestPackedSmallFiles packs a tree of tiny files into shared frames and
+// reads it back through verify, a full and a selective extraction, and a
+// recovery scan
+func TestPackedSmallFiles(t *testing.T) {
+	inputDir := t.TempDir()
+	files := map[string][]byte{
+		"big.log":        bytes.Repeat([]byte("a line longer than the threshold\n"), 4096),
+		"empty.txt":      {},
+		"pkg/photo.jpg":  []byte("stored by extension, never packed"),
+		"pkg/empty.json": {},
+	}
+	for i := range 400 {
+		name := fmt.Sprintf("pkg/mod%d/index.js", i)
+		files[name] = []byte(fmt.Sprintf("module.exports = require('./lib/%d');\n", i))
+	}
+	for name, data := range files {
+		path := filepath.Join(inputDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+	plainPath, packedPath := filepath.Join(dir, "plain.gdelta"), filepath.Join(dir, "packed.gdelta")
+	plain, err := compress.Compress(&compress.Options{InputPath: inputDir, OutputPath: plainPath, MaxThreads: 4, Quiet: true}, nil)
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	packed, err := compress.Compress(&compress.Options{InputPath: inputDir, OutputPath: packedPath, MaxThreads: 4, PackSmallFiles: 4096, Quiet: true}, nil)
+	if err != nil {
+		t.Fatalf("compress packed: %v", err)
+	}
+	// Everything but big.log and photo.jpg fits in one pack
+	if packed.FilesProcessed != len(files) || packed.PackedFiles != len(files)-2 || packed.Packs != 1 {
+		t.Errorf("processed %d files, %d packed in %d packs", packed.FilesProcessed, packed.PackedFiles, packed.Packs)
+	}
+	if packed.CompressedSize*4 > plain.CompressedSize {
+		t.Errorf("packed data is %d bytes, plain %d", packed.CompressedSize, plain.CompressedSize)
+	}
+
+	vresult, err := verify.Verify(&verify.Options{InputPath: packedPath, VerifyData: true}, nil)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !vresult.IsValid() || !vresult.EntryIndex || vresult.FileCount != len(files) || vresult.FilesVerified != len(files) {
+		t.Fatalf("verify: %d of %d files verified, index %v, errors %v", vresult.FilesVerified, vresult.FileCount, vresult.EntryIndex, vresult.Errors)
+	}
+
+	for name, opts := range map[string]decompress.Options{
+		"all":     {},
+		"recover": {Recover: true},
+		"select":  {Paths: []string{"pkg/mod7"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			outDir := t.TempDir()
+			opts.InputPath, opts.OutputPath, opts.MaxThreads, opts.Quiet = packedPath, outDir, 3, true
+			result, err := decompress.Decompress(&opts, nil)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			if !result.Success() {
+				t.Fatalf("decompress errors: %v", result.Errors)
+			}
+			var want int
+			for rel, data := range files {
+				if opts.Paths != nil && !strings.HasPrefix(rel, "pkg/mod7/") {
+					continue
+				}
+				want++
+				got, err := os.ReadFile(filepath.Join(outDir, rel))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, data) {
+					t.Errorf("%s: content mismatch", rel)
+				}
+			}
+			if result.FilesProcessed != want || result.FilesTotal != want {
+				t.Errorf("extracted %d of %d files, want %d", result.FilesProcessed, result.FilesTotal, want)
+			}
+		})
+	}
+}
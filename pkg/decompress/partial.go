@@ -0,0 +1,118 @@
+// pkg/decompress/partial.go
+package decompress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/zeebo/blake3"
+)
+
+// partialSuffix marks a file being restored: entries are written to
+// name.partial and renamed to name once complete
+const partialSuffix = ".partial"
+
+// outputFile is an entry's output being written. Data goes to a partial file
+// next to the final path and commit renames it into place, so a crash or a
+// failed entry never leaves a truncated file under the entry's name.
+type outputFile struct {
+	*os.File
+	path  string      // Final path
+	space *spaceGuard // stops the writes once the output runs low on space
+	opts  *Options    // stops the writes once the run is canceled
+}
+
+// stopped returns why writes must stop: the output ran low on space or the
+// run was canceled
+func (f *outputFile) stopped() error {
+	if f.space.low() {
+		return ErrLowSpace
+	}
+	if f.opts != nil {
+		return f.opts.canceled()
+	}
+	return nil
+}
+
+func (f *outputFile) Write(p []byte) (int, error) {
+	if err := f.stopped(); err != nil {
+		return 0, err
+	}
+	return f.File.Write(p)
+}
+
+func (f *outputFile) WriteAt(p []byte, off int64) (int, error) {
+	if err := f.stopped(); err != nil {
+		return 0, err
+	}
+	return f.File.WriteAt(p, off)
+}
+
+// ReadFrom goes through Write, where os.File's would bypass it
+func (f *outputFile) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{f}, r)
+}
+
+// createPartial creates the partial file for path, and any missing parents.
+// Whatever sits at the partial path is removed first: a stale partial left by
+// an interrupted restore, or a link that would redirect the write.
+func createPartial(path string) (*outputFile, error) {
+	partial := partialPath(path)
+	if err := os.MkdirAll(filepath.Dir(partial), 0755); err != nil {
+		return nil, fmt.Errorf("create directories: %w", err)
+	}
+	removeStalePartial(path)
+	f, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("create file: %w", err)
+	}
+	return &outputFile{File: f, path: path}, nil
+}
+
+// commit closes the partial file and renames it to the final path, replacing
+// any file there. The partial file is removed if that fails.
+func (f *outputFile) commit() error {
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("close file: %w", err)
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("rename into place: %w", err)
+	}
+	return nil
+}
+
+// discard closes and removes the partial file, leaving the final path as it
+// was
+func (f *outputFile) discard() {
+	f.File.Close()
+	os.Remove(f.Name())
+}
+
+// partialPath returns the partial file for path: name.partial, or a shortened
+// name keeping a hash of the original when the suffix would push the name
+// over maxNameComponent. Names already over the limit keep the plain suffix
+// so creating them fails the way the final name would.
+func partialPath(path string) string {
+	dir, name := filepath.Split(path)
+	if len(name)+len(partialSuffix) <= maxNameComponent || len(name) > maxNameComponent {
+		return path + partialSuffix
+	}
+	sum := blake3.Sum256([]byte(name))
+	suffix := fmt.Sprintf("~%x%s", sum[:4], partialSuffix)
+	keep := maxNameComponent - len(suffix)
+	// Cut on a rune boundary so the result stays valid UTF-8
+	for keep > 0 && name[keep]&0xC0 == 0x80 {
+		keep--
+	}
+	return dir + name[:keep] + suffix
+}
+
+// removeStalePartial removes the partial file an interrupted restore left for
+// path, for entries this restore doesn't write again
+func removeStalePartial(path string) {
+	os.Remove(partialPath(path))
+}
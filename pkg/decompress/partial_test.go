@@ -0,0 +1,131 @@
+// pkg/decompress/partial_test.go
+package decompress_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+)
+
+// TestFailedEntryLeavesNothing damages one stored ZIP entry and checks that
+// neither the entry nor a partial file is left in the output
+func TestFailedEntryLeavesNothing(t *testing.T) {
+	inputDir := t.TempDir()
+	all := buildTestInput(t, inputDir)
+	damaged := "sub2/file_006.txt"
+
+	archiveDir := t.TempDir()
+	if _, err := compress.Compress(&compress.Options{
+		InputPath:    inputDir,
+		OutputPath:   filepath.Join(archiveDir, "a.zip"),
+		Level:        1,
+		UseZipFormat: true,
+		MaxThreads:   1,
+		Quiet:        true,
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+	archivePath := filepath.Join(archiveDir, "a_01.zip")
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := bytes.Index(data, []byte("file 6 unique line"))
+	if at < 0 {
+		t.Fatal("entry data not found")
+	}
+	data[at] ^= 0xFF
+	if err := os.WriteFile(archivePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := t.TempDir()
+	result, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: outDir, Quiet: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Error(), damaged) {
+		t.Fatalf("expected an error for %s, got %v", damaged, result.Errors)
+	}
+	if result.FilesProcessed != len(all)-1 {
+		t.Errorf("%d files processed, want %d", result.FilesProcessed, len(all)-1)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, damaged)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("damaged entry was left in the output: %v", err)
+	}
+	assertNoPartials(t, outDir)
+}
+
+// TestStalePartials restores over the partial files of an interrupted
+// restore, one of them a link leading out of the output directory
+func TestStalePartials(t *testing.T) {
+	inputDir := t.TempDir()
+	all := buildTestInput(t, inputDir)
+	archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: inputDir, OutputPath: archivePath, Level: 3, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "target")
+	if err := os.MkdirAll(filepath.Join(outDir, "sub0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A partial the new restore writes again, one for a file it skips,
+	// and a user file that only looks like a partial
+	stale := map[string]string{
+		"sub0/file_000.txt.partial": "half written",
+		"sub0/file_004.txt.partial": "half written",
+		"sub0/notes.partial":        "keep me",
+	}
+	for name, content := range stale {
+		if err := os.WriteFile(filepath.Join(outDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "sub0/file_004.txt"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	linked := filepath.Join(outDir, "sub0/file_008.txt.partial")
+	if err := os.Symlink(outside, linked); err != nil {
+		t.Logf("symlinks not supported: %v", err)
+	}
+
+	result, err := decompress.Decompress(&decompress.Options{
+		InputPath:      archivePath,
+		OutputPath:     outDir,
+		ConflictPolicy: decompress.ConflictSkip,
+		Quiet:          true,
+	}, nil)
+	if err != nil || len(result.Errors) > 0 {
+		t.Fatalf("decompress: %v, %v", err, result.Errors)
+	}
+
+	delete(all, "sub0/file_004.txt")
+	verifyOutput(t, outDir, all)
+	if _, err := os.Stat(outside); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("restore wrote through a linked partial: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(outDir, "sub0/notes.partial")); string(content) != "keep me" {
+		t.Errorf("unrelated .partial file changed: %q", content)
+	}
+	os.Remove(filepath.Join(outDir, "sub0/notes.partial"))
+	assertNoPartials(t, outDir)
+}
+
+func assertNoPartials(t *testing.T, dir string) {
+	t.Helper()
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && strings.HasSuffix(path, ".partial") {
+			t.Errorf("partial file left behind: %s", path)
+		}
+		return nil
+	})
+}
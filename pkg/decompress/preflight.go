@@ -0,0 +1,39 @@
+// pkg/decompress/preflight.go
+package decompress
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+)
+
+// preflight lists the entries of the archive before extracting them when
+// a check needs them: free space (CheckSpace) and case-only collisions on
+// a case-insensitive output. Nothing is written when one fails. The
+// archive is rewound afterwards.
+func preflight(archiveFile *os.File, detected format.ArchiveFormat, opts *Options, result *Result) error {
+	foldCase := opts.CaseInsensitive || caseInsensitive(existingParent(opts.OutputPath))
+	if !opts.CheckSpace && !foldCase {
+		return nil
+	}
+	// Errors of the listing itself are reported again by the extraction
+	entries, err := planEntries(archiveFile, detected, opts, &Result{})
+	if err != nil {
+		return err
+	}
+	if _, err := archiveFile.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("seek to start: %w", err)
+	}
+
+	if opts.CheckSpace {
+		if err := checkSpace(entries, opts); err != nil {
+			return err
+		}
+	}
+	if foldCase {
+		return applyCaseCollisions(entries, opts, result)
+	}
+	return nil
+}
@@ -2,6 +2,11 @@
 package decompress
 
 import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
 	"github.com/creativeyann17/go-delta/pkg/godelta"
 	"github.com/vbauerster/mpb/v8"
 )
@@ -13,22 +18,112 @@ func ProgressBarCallback() (ProgressCallback, *mpb.Progress) {
 
 	// Wrap the generic callback to adapt decompress.ProgressEvent to godelta.ProgressEvent
 	callback := func(event ProgressEvent) {
-		genericCb(godelta.ProgressEvent{
-			Type:         godelta.EventType(event.Type),
-			FilePath:     event.FilePath,
-			Current:      event.Current,
-			Total:        event.Total,
-			CurrentBytes: event.CurrentBytes,
-			TotalBytes:   event.TotalBytes,
-		})
+		genericCb(event.generic())
 	}
 
 	return callback, progress
 }
 
+// generic converts the event to a godelta.ProgressEvent
+func (e ProgressEvent) generic() godelta.ProgressEvent {
+	return godelta.ProgressEvent{
+		Type:                  godelta.EventType(e.Type),
+		FilePath:              e.FilePath,
+		Current:               e.Current,
+		Total:                 e.Total,
+		CurrentBytes:          e.CurrentBytes,
+		TotalBytes:            e.TotalBytes,
+		BytesPerSecond:        e.BytesPerSecond,
+		InstantBytesPerSecond: e.InstantBytesPerSecond,
+		ETA:                   e.ETA,
+	}
+}
+
+// JSONProgressCallback creates a progress callback writing each event to w as
+// one line of JSON, for wrappers and UIs that track jobs programmatically
+func JSONProgressCallback(w io.Writer) ProgressCallback {
+	emit := godelta.JSONLines(w)
+	return func(event ProgressEvent) {
+		emit(event)
+	}
+}
+
+// maxDegradationExamples caps the example entries listed per kind in the summary
+const maxDegradationExamples = 3
+
 // FormatSummary formats a decompression result into a human-readable summary string
 func FormatSummary(result *Result) string {
-	return godelta.FormatSummary(result, godelta.OperationDecompress, false)
+	var sb strings.Builder
+	sb.WriteString(godelta.FormatSummary(result, godelta.OperationDecompress, false))
+	if result.FilesSkipped > 0 {
+		fmt.Fprintf(&sb, "  Files skipped:     %d (already exist)\n", result.FilesSkipped)
+	}
+	if len(result.CaseCollisions) > 0 {
+		fmt.Fprintf(&sb, "  Case collisions:   %d entries (same name on a case-insensitive filesystem)\n", len(result.CaseCollisions))
+		for i, c := range result.CaseCollisions {
+			if i == maxDegradationExamples {
+				fmt.Fprintf(&sb, "    ...\n")
+				break
+			}
+			fmt.Fprintf(&sb, "    %s (as %s)\n", c.Path, c.With)
+		}
+	}
+
+	// Degradations are grouped by kind with a few examples each, so a FAT32
+	// restore of 10k symlinks reads as one line rather than 10k errors
+	if len(result.Degradations) > 0 {
+		counts := result.DegradationCounts()
+		kinds := make([]string, 0, len(counts))
+		for kind := range counts {
+			kinds = append(kinds, string(kind))
+		}
+		sort.Strings(kinds)
+
+		fmt.Fprintf(&sb, "\nDegradations (%d entries restored differently by the target filesystem):\n", len(result.Degradations))
+		for _, kind := range kinds {
+			fmt.Fprintf(&sb, "  %-14s %d\n", kind+":", counts[DegradationKind(kind)])
+			shown := 0
+			for _, d := range result.Degradations {
+				if string(d.Kind) != kind {
+					continue
+				}
+				if shown == maxDegradationExamples {
+					fmt.Fprintf(&sb, "    ...\n")
+					break
+				}
+				fmt.Fprintf(&sb, "    %s: %s\n", d.Path, d.Detail)
+				shown++
+			}
+		}
+	}
+
+	if p := result.Plan; p != nil {
+		sb.WriteString("\nRestore plan:\n")
+		fmt.Fprintf(&sb, "  Create:       %d files\n", p.Create)
+		fmt.Fprintf(&sb, "  Overwrite:    %d files\n", p.Overwrite)
+		if p.Rename > 0 {
+			fmt.Fprintf(&sb, "  Rename:       %d files (restored next to the existing one)\n", p.Rename)
+		}
+		fmt.Fprintf(&sb, "  Skip:         %d files (already exist)\n", p.Skip)
+		if p.Conflicts > 0 {
+			fmt.Fprintf(&sb, "  Conflicts:    %d files (already exist, the restore would fail; use --overwrite or --on-conflict)\n", p.Conflicts)
+		}
+		if p.Unsafe > 0 {
+			fmt.Fprintf(&sb, "  Unsafe:       %d entries (outside the output directory)\n", p.Unsafe)
+		}
+		fmt.Fprintf(&sb, "  Bytes needed: %s\n", godelta.FormatSize(p.BytesNeeded))
+		switch {
+		case !p.FreeSpaceKnown:
+			sb.WriteString("  Free space:   unknown\n")
+		case p.EnoughSpace():
+			fmt.Fprintf(&sb, "  Free space:   %s (enough)\n", godelta.FormatSize(p.FreeSpace))
+		default:
+			fmt.Fprintf(&sb, "  Free space:   %s (NOT ENOUGH, %s short)\n", godelta.FormatSize(p.FreeSpace), godelta.FormatSize(p.BytesNeeded-p.FreeSpace))
+		}
+		sb.WriteString("\nDry run complete - no data written.\n")
+	}
+
+	return sb.String()
 }
 
 // FormatSize formats bytes into human-readable string
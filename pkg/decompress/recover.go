@@ -0,0 +1,37 @@
+// pkg/decompress/recover.go
+package decompress
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+)
+
+// recoveryError summarizes what a recovery scan had to leave behind, or
+// returns nil when every entry was intact
+func recoveryError(scan *format.ScanResult) error {
+	if scan.Reason == "" {
+		return nil
+	}
+	return fmt.Errorf("%w: recovered %d of %d files, intact data ends at offset %d (%s)",
+		ErrDamagedArchive, scan.Intact, scan.Declared, scan.LogicalEnd, scan.Reason)
+}
+
+// scanGDelta03 runs the recovery scan and leaves the archive positioned at
+// the first file entry, right after the dictionary
+func scanGDelta03(archiveFile *os.File, size uint64) (*format.ScanResult, error) {
+	entriesStart, err := archiveFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("get entries position: %w", err)
+	}
+	_, scan, err := format.ScanGDelta03(archiveFile, int64(size))
+	if err != nil {
+		return nil, fmt.Errorf("scan archive: %w", err)
+	}
+	if _, err := archiveFile.Seek(entriesStart, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek to file entries: %w", err)
+	}
+	return scan, nil
+}
@@ -0,0 +1,138 @@
+// pkg/decompress/recover_test.go
+package decompress_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// TestRecoverDamagedArchive cuts the tail off each GDELTA format and checks
+// that verify reports the intact part and --recover extracts exactly it
+func TestRecoverDamagedArchive(t *testing.T) {
+	inputDir := t.TempDir()
+	want := buildTestInput(t, inputDir)
+
+	formats := map[string]*compress.Options{
+		"GDELTA01": {Level: 3},
+		"GDELTA02": {Level: 3, ChunkSize: 16 * 1024},
+		"GDELTA03": {Level: 3, UseDictionary: true},
+	}
+
+	for name, compressOpts := range formats {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+			compressOpts.InputPath = inputDir
+			compressOpts.OutputPath = archivePath
+			compressOpts.Quiet = true
+			if _, err := compress.Compress(compressOpts, nil); err != nil {
+				t.Fatalf("compress: %v", err)
+			}
+			data, err := os.ReadFile(archivePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name == "GDELTA01" {
+				// Drop the entry index after the footer: the cuts below
+				// must reach the footer and the entries
+				data = data[:binary.LittleEndian.Uint64(data[len(data)-24:])]
+			}
+
+			t.Run("FooterOnly", func(t *testing.T) {
+				damaged := filepath.Join(t.TempDir(), "nofooter.gdelta")
+				footer := 8
+				if name == "GDELTA01" {
+					footer = 9
+				}
+				if err := os.WriteFile(damaged, data[:len(data)-footer], 0644); err != nil {
+					t.Fatal(err)
+				}
+
+				result := recoverArchive(t, damaged, want)
+				if len(result.Errors) > 0 {
+					t.Fatalf("unexpected errors: %v", result.Errors)
+				}
+				if result.FilesProcessed != len(want) {
+					t.Errorf("expected %d files, got %d", len(want), result.FilesProcessed)
+				}
+			})
+
+			t.Run("Truncated", func(t *testing.T) {
+				damaged := filepath.Join(t.TempDir(), "truncated.gdelta")
+				if err := os.WriteFile(damaged, data[:len(data)-1024], 0644); err != nil {
+					t.Fatal(err)
+				}
+
+				v, err := verify.Verify(&verify.Options{InputPath: damaged}, nil)
+				if err != nil {
+					t.Fatalf("verify: %v", err)
+				}
+				if v.IsValid() {
+					t.Fatal("truncated archive should not be valid")
+				}
+				if v.Recovery == nil || v.Recovery.Reason == "" {
+					t.Fatalf("expected a recovery report, got %+v", v.Recovery)
+				}
+
+				result := recoverArchive(t, damaged, want)
+				if len(result.Errors) != 1 || !errors.Is(result.Errors[0], decompress.ErrDamagedArchive) {
+					t.Fatalf("expected one ErrDamagedArchive, got %v", result.Errors)
+				}
+				if result.FilesProcessed == 0 || result.FilesProcessed >= len(want) {
+					t.Errorf("expected a partial recovery, got %d of %d files", result.FilesProcessed, len(want))
+				}
+				if result.FilesProcessed != v.Recovery.IntactFiles {
+					t.Errorf("verify reported %d intact files, recovered %d", v.Recovery.IntactFiles, result.FilesProcessed)
+				}
+			})
+		})
+	}
+}
+
+// recoverArchive extracts an archive with Recover set and checks every
+// extracted file matches its original
+func recoverArchive(t *testing.T, archivePath string, want map[string][]byte) *decompress.Result {
+	t.Helper()
+
+	extractDir := t.TempDir()
+	result, err := decompress.Decompress(&decompress.Options{
+		InputPath:  archivePath,
+		OutputPath: extractDir,
+		Recover:    true,
+		Quiet:      true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+
+	extracted := 0
+	err = filepath.Walk(extractDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		extracted++
+		rel, _ := filepath.Rel(extractDir, path)
+		got, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, want[filepath.ToSlash(rel)]) {
+			t.Errorf("%s: recovered content differs", rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extracted != result.FilesProcessed {
+		t.Errorf("extracted %d files, result reports %d", extracted, result.FilesProcessed)
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+// pkg/decompress/reference.go
+package decompress
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/zeebo/blake3"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+)
+
+// referenceChunks reads the chunks a GDELTA02 archive takes from reference
+// directories
+type referenceChunks struct {
+	table *format.ReferenceChunks
+	dirs  []string
+}
+
+// newReferenceChunks returns the reference chunks of header, read from
+// Options.ReferenceDirs or else the directories recorded in the archive;
+// nil when the archive has none
+func newReferenceChunks(header format.GDelta02Header, opts *Options) (*referenceChunks, error) {
+	table, err := header.ReferenceChunks()
+	if err != nil || table == nil {
+		return nil, err
+	}
+	dirs := table.Dirs
+	if len(opts.ReferenceDirs) > 0 {
+		if len(opts.ReferenceDirs) != len(table.Dirs) {
+			return nil, fmt.Errorf("%w: got %d, the archive references %d (%v)", ErrReferenceDirs, len(opts.ReferenceDirs), len(table.Dirs), table.Dirs)
+		}
+		dirs = opts.ReferenceDirs
+	}
+	return &referenceChunks{table: table, dirs: dirs}, nil
+}
+
+// chunks returns the reference chunk table, nil on a nil r
+func (r *referenceChunks) chunks() *format.ReferenceChunks {
+	if r == nil {
+		return nil
+	}
+	return r.table
+}
+
+// read reads the chunk with this hash from its reference file, appending
+// it to buf; ok is false when it isn't a reference chunk
+func (r *referenceChunks) read(hash [32]byte, buf []byte) (data []byte, ok bool, err error) {
+	if r == nil {
+		return nil, false, nil
+	}
+	c, ok := r.table.Chunks[hash]
+	if !ok {
+		return nil, false, nil
+	}
+	src := r.table.Sources[c.Source]
+	path := filepath.Join(r.dirs[src.Dir], filepath.FromSlash(src.Path))
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, true, fmt.Errorf("%w: %x: %v", ErrReferenceChunk, hash[:8], err)
+	}
+	defer f.Close()
+	data = append(buf, make([]byte, c.Size)...)
+	if _, err := f.ReadAt(data[len(buf):], int64(c.Offset)); err != nil {
+		return nil, true, fmt.Errorf("%w: %x: read %s: %v", ErrReferenceChunk, hash[:8], path, err)
+	}
+	if blake3.Sum256(data[len(buf):]) != hash {
+		return nil, true, fmt.Errorf("%w: %x: %s changed since the archive was made", ErrReferenceChunk, hash[:8], path)
+	}
+	return data, true, nil
+}
@@ -0,0 +1,651 @@
+// pkg/decompress/repack.go
+package decompress
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// repackEntry is one file copied into the repack output. write streams its
+// restored content into w.
+type repackEntry struct {
+	path  string
+	size  uint64
+	write func(w io.Writer) error
+}
+
+// openSinkFunc creates the output of a repack once the entry count is known.
+// modTime is the source archive's modification time.
+type openSinkFunc func(entries int, modTime time.Time) (*repackSink, error)
+
+// repack copies an archive entry by entry into the sink returned by
+// openSink: a ZIP or tar.xz for RepackPath, a raw or tar stream for a stream
+// target or TarOutput. Entries are decompressed one at a time and streamed
+// straight into the output writer, so nothing is extracted to disk.
+func repack(archiveFile *os.File, detected format.ArchiveFormat, opts *Options, progressCb ProgressCallback, result *Result, openSink openSinkFunc) error {
+	info, err := archiveFile.Stat()
+	if err != nil {
+		return fmt.Errorf("stat archive file: %w", err)
+	}
+	size := parity.DataSize(archiveFile, info.Size())
+	result.CompressedSize = uint64(size)
+
+	var entries []repackEntry
+	var scan *format.ScanResult
+	var decoder *zstd.Decoder
+	var closeParts func()
+	switch detected {
+	case format.FormatGDelta01:
+		entries, scan, decoder, err = gdelta01RepackEntries(archiveFile, size)
+	case format.FormatGDelta02:
+		entries, scan, decoder, err = gdelta02RepackEntries(archiveFile, size, opts)
+	case format.FormatGDelta03:
+		entries, scan, decoder, err = gdelta03RepackEntries(archiveFile, size)
+	case format.FormatZIP:
+		entries, closeParts, err = zipRepackEntries(opts.InputPath, result)
+	case format.FormatXZ:
+		entries, closeParts, err = xzRepackEntries(opts.InputPath, result)
+	default:
+		return fmt.Errorf("%w (got %s)", ErrRepackSource, detected)
+	}
+	if err != nil {
+		return err
+	}
+	if decoder != nil {
+		defer decoder.Close()
+	}
+	if closeParts != nil {
+		defer closeParts()
+	}
+
+	// Entries are only listed up to the first damaged one; without Recover a
+	// damaged archive is refused rather than silently repacked short
+	result.FilesTotal = len(entries)
+	if scan != nil {
+		if err := recoveryError(scan); err != nil {
+			if !opts.Recover {
+				return fmt.Errorf("%w (use --recover to keep the intact files)", err)
+			}
+			result.Errors = append(result.Errors, err)
+		}
+		result.FilesTotal = scan.Declared
+	}
+	if sel := newPathSelector(opts.Paths); sel != nil {
+		entries = slices.DeleteFunc(entries, func(e repackEntry) bool { return !sel.match(e.path) })
+		result.FilesTotal = len(entries)
+		result.Errors = append(result.Errors, sel.unmatched()...)
+	}
+	if progressCb != nil {
+		progressCb(ProgressEvent{
+			Type:  EventStart,
+			Total: int64(result.FilesTotal),
+		})
+	}
+
+	sink, err := openSink(len(entries), info.ModTime())
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := opts.canceled(); err != nil {
+			sink.abort()
+			return err
+		}
+
+		// The output is handed to other tools, so unsafe names are dropped
+		// here rather than left for the next extractor to catch
+		name := opts.rewriter.apply(entry.path)
+		if !filepath.IsLocal(name) {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", entry.path, ErrUnsafeEntryPath))
+			if progressCb != nil {
+				progressCb(ProgressEvent{Type: EventError, FilePath: entry.path})
+			}
+			continue
+		}
+
+		if progressCb != nil {
+			progressCb(ProgressEvent{
+				Type:     EventFileStart,
+				FilePath: entry.path,
+				Total:    int64(entry.size),
+			})
+		}
+
+		w, err := sink.create(filepath.ToSlash(filepath.Clean(name)), entry.size)
+		if err != nil {
+			sink.abort()
+			return fmt.Errorf("%s: add to output: %w", entry.path, err)
+		}
+
+		// Progress tracking writer (throttled; EventFileComplete finishes the bar)
+		var written, lastReported uint64
+		proxy := &godelta.ProgressWriter{
+			Writer: w,
+			OnWrite: func(n int) {
+				written += uint64(n)
+				if progressCb != nil && written-lastReported >= progressReportStep {
+					lastReported = written
+					progressCb(ProgressEvent{
+						Type:         EventFileProgress,
+						FilePath:     entry.path,
+						Current:      int64(written),
+						Total:        int64(entry.size),
+						CurrentBytes: written,
+					})
+				}
+			},
+		}
+
+		// A half-written entry leaves the output unusable, so any data
+		// error aborts the whole repack
+		if err := entry.write(proxy); err != nil {
+			sink.abort()
+			return fmt.Errorf("%s: %w", entry.path, err)
+		}
+		if written != entry.size {
+			sink.abort()
+			return fmt.Errorf("%s: size mismatch (expected %d, got %d)", entry.path, entry.size, written)
+		}
+
+		result.FilesProcessed++
+		result.DecompressedSize += written
+		if progressCb != nil {
+			progressCb(ProgressEvent{
+				Type:             EventFileComplete,
+				FilePath:         entry.path,
+				Current:          int64(entry.size),
+				Total:            int64(entry.size),
+				DecompressedSize: written,
+			})
+		}
+		if opts.Verbose {
+			fmt.Printf("Added: %s (%d bytes)\n", entry.path, written)
+		}
+	}
+
+	if err := sink.close(); err != nil {
+		return fmt.Errorf("finish output: %w", err)
+	}
+
+	if progressCb != nil {
+		progressCb(ProgressEvent{
+			Type:             EventComplete,
+			Current:          int64(result.FilesProcessed),
+			Total:            int64(result.FilesTotal),
+			TotalBytes:       result.CompressedSize,
+			DecompressedSize: result.DecompressedSize,
+		})
+	}
+	return nil
+}
+
+// gdelta01RepackEntries lists the intact GDELTA01 entries, from the entry
+// index when there is one; each one streams from its stored data offset,
+// or is cut from its small-file pack
+func gdelta01RepackEntries(archiveFile *os.File, size int64) ([]repackEntry, *format.ScanResult, *zstd.Decoder, error) {
+	fileEntries, scan, err := format.ListGDelta01(archiveFile, size)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("read archive: %w", err)
+	}
+	fileEntries, _ = format.SplitPacks(fileEntries)
+	packs := format.NewPackCache(fileEntries)
+
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("create zstd decoder: %w", err)
+	}
+
+	entries := make([]repackEntry, len(fileEntries))
+	for i, fe := range fileEntries {
+		entries[i] = repackEntry{
+			path: fe.Path,
+			size: fe.OriginalSize,
+			write: func(w io.Writer) error {
+				// A link writes its target's data
+				fe := fe.Data()
+				if fe == nil {
+					return ErrLinkTarget
+				}
+				if fe.Packed {
+					data, err := packs.Read(archiveFile, fe)
+					if err != nil {
+						return err
+					}
+					_, err = w.Write(data)
+					return err
+				}
+				if _, err := archiveFile.Seek(int64(fe.DataOffset), io.SeekStart); err != nil {
+					return fmt.Errorf("seek to data: %w", err)
+				}
+				src, err := entryReader(fe.Method, io.LimitReader(archiveFile, int64(fe.CompressedSize)), decoder)
+				if err != nil {
+					return err
+				}
+				if _, err := io.Copy(w, src); err != nil {
+					return fmt.Errorf("decompress: %w", err)
+				}
+				return nil
+			},
+		}
+	}
+	return entries, scan, decoder, nil
+}
+
+// gdelta02RepackEntries lists the GDELTA02 files whose chunks are all present;
+// each one is reassembled through a shared chunk cache
+func gdelta02RepackEntries(archiveFile *os.File, size int64, opts *Options) ([]repackEntry, *format.ScanResult, *zstd.Decoder, error) {
+	header, err := format.ReadGDelta02Header(archiveFile)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("read GDELTA02 header: %w", err)
+	}
+	refs, err := newReferenceChunks(header, opts)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("read reference chunks: %w", err)
+	}
+	chunkIndex, err := format.ReadChunkIndex(archiveFile, header.ChunkCount)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("read chunk index: %w", err)
+	}
+	metadata := make([]format.FileMetadata, 0, format.CapHint(header.FileCount))
+	for i := range header.FileCount {
+		m, err := format.ReadFileMetadata(archiveFile, header.Extended)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("read file metadata %d: %w", i, err)
+		}
+		metadata = append(metadata, m)
+	}
+	chunkDataStart, err := archiveFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("get chunk data start: %w", err)
+	}
+
+	metadata, scan := format.IntactGDelta02Files(archiveFile, chunkIndex, refs.chunks(), metadata, chunkDataStart, size)
+
+	decOpts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+	if dict := header.ChunkDictionary(); len(dict) > 0 {
+		decOpts = append(decOpts, zstd.WithDecoderDicts(dict))
+	}
+	decoder, err := zstd.NewReader(nil, decOpts...)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("create zstd decoder: %w", err)
+	}
+
+	cache := newChunkCache(metadata, maxChunkCacheBytes)
+	var readBuf, scratch []byte
+	entries := make([]repackEntry, len(metadata))
+	for i, m := range metadata {
+		entries[i] = repackEntry{
+			path: m.RelPath,
+			size: m.OrigSize,
+			write: func(w io.Writer) error {
+				_, err := writeChunks(w, m, archiveFile, chunkDataStart, chunkIndex, refs, cache, chunkDecoder{decoder, header.Codec.Method()}, &readBuf, &scratch, nil)
+				return err
+			},
+		}
+	}
+	return entries, scan, decoder, nil
+}
+
+// gdelta03RepackEntries lists the intact GDELTA03 entries; each one is
+// decoded with the archive's dictionary
+func gdelta03RepackEntries(archiveFile *os.File, size int64) ([]repackEntry, *format.ScanResult, *zstd.Decoder, error) {
+	header, err := format.ReadGDelta03Header(archiveFile)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("read GDELTA03 header: %w", err)
+	}
+	if header.Version != format.GDELTA03Version {
+		return nil, nil, nil, fmt.Errorf("unsupported GDELTA03 version: %d", header.Version)
+	}
+	dictionary := make([]byte, header.DictSize)
+	if _, err := io.ReadFull(archiveFile, dictionary); err != nil {
+		return nil, nil, nil, fmt.Errorf("read dictionary: %w", err)
+	}
+
+	fileEntries, scan, err := format.ScanGDelta03(archiveFile, size)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("read archive: %w", err)
+	}
+
+	var decoder *zstd.Decoder
+	if len(dictionary) > 0 {
+		decoder, err = zstd.NewReader(nil, zstd.WithDecoderDicts(dictionary))
+	} else {
+		decoder, err = zstd.NewReader(nil)
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("create zstd decoder: %w", err)
+	}
+
+	var compressed, decompressed []byte
+	entries := make([]repackEntry, len(fileEntries))
+	for i, fe := range fileEntries {
+		entries[i] = repackEntry{
+			path: fe.Path,
+			size: fe.OriginalSize,
+			write: func(w io.Writer) error {
+				// A link writes its target's data
+				fe := fe.Data()
+				if fe == nil {
+					return ErrLinkTarget
+				}
+				if _, err := archiveFile.Seek(int64(fe.DataOffset), io.SeekStart); err != nil {
+					return fmt.Errorf("seek to data: %w", err)
+				}
+				if uint64(cap(compressed)) < fe.CompressedSize {
+					compressed = make([]byte, fe.CompressedSize)
+				}
+				compressed = compressed[:fe.CompressedSize]
+				if _, err := io.ReadFull(archiveFile, compressed); err != nil {
+					return fmt.Errorf("read compressed data: %w", err)
+				}
+				var err error
+				decompressed, err = decodeEntry(fe.Method, compressed, decoder, decompressed[:0])
+				if err != nil {
+					return fmt.Errorf("decompress: %w", err)
+				}
+				_, err = w.Write(decompressed)
+				return err
+			},
+		}
+	}
+	return entries, scan, decoder, nil
+}
+
+// zipRepackEntries lists the files of every part of a ZIP archive; each one
+// streams from its part, which stays open until closeParts
+func zipRepackEntries(path string, result *Result) (entries []repackEntry, closeParts func(), err error) {
+	zipPaths, err := partPaths(path, ".zip")
+	if err != nil {
+		return nil, nil, err
+	}
+	var readers []*zip.ReadCloser
+	closeParts = func() {
+		for _, zr := range readers {
+			zr.Close()
+		}
+	}
+	result.CompressedSize = 0
+	for _, zipPath := range zipPaths {
+		zr, err := zip.OpenReader(zipPath)
+		if err != nil {
+			closeParts()
+			return nil, nil, fmt.Errorf("open zip archive %s: %w", zipPath, err)
+		}
+		readers = append(readers, zr)
+		zr.RegisterDecompressor(zip.Deflate, func(r io.Reader) io.ReadCloser {
+			return flate.NewReader(r)
+		})
+		if info, err := os.Stat(zipPath); err == nil {
+			result.CompressedSize += uint64(info.Size())
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			entries = append(entries, repackEntry{
+				path: f.Name,
+				size: f.UncompressedSize64,
+				write: func(w io.Writer) error {
+					rc, err := f.Open()
+					if err != nil {
+						return err
+					}
+					defer rc.Close()
+					if _, err := io.Copy(w, rc); err != nil {
+						return fmt.Errorf("decompress: %w", err)
+					}
+					return nil
+				},
+			})
+		}
+	}
+	return entries, closeParts, nil
+}
+
+// xzRepackEntries lists the regular files of every part of a tar.xz archive
+// (a first pass through the stream, as extraction does to count them). The
+// entries are written from a second pass, so they must be written in order;
+// entries that aren't written are skipped over.
+func xzRepackEntries(path string, result *Result) ([]repackEntry, func(), error) {
+	listed, err := xzPlanEntries(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	xzPaths, err := partPaths(path, ".tar.xz")
+	if err != nil {
+		return nil, nil, err
+	}
+	result.CompressedSize = 0
+	for _, xzPath := range xzPaths {
+		if info, err := os.Stat(xzPath); err == nil {
+			result.CompressedSize += uint64(info.Size())
+		}
+	}
+
+	cursor := &tarXzCursor{paths: xzPaths}
+	entries := make([]repackEntry, len(listed))
+	for i, e := range listed {
+		entries[i] = repackEntry{
+			path: e.path,
+			size: e.size,
+			write: func(w io.Writer) error {
+				r, err := cursor.seek(i)
+				if err != nil {
+					return err
+				}
+				if _, err := io.Copy(w, r); err != nil {
+					return fmt.Errorf("decompress: %w", err)
+				}
+				return nil
+			},
+		}
+	}
+	return entries, cursor.close, nil
+}
+
+// tarXzCursor reads the regular files of a multi-part tar.xz archive front
+// to back
+type tarXzCursor struct {
+	paths []string
+	file  *os.File
+	tr    *tar.Reader
+	next  int // Index of the regular file the reader reaches next
+}
+
+// seek advances to the index-th regular file of the archive and returns its
+// content
+func (c *tarXzCursor) seek(index int) (io.Reader, error) {
+	for {
+		if c.tr == nil {
+			if len(c.paths) == 0 {
+				return nil, fmt.Errorf("entry %d: %w", index, io.ErrUnexpectedEOF)
+			}
+			file, err := os.Open(c.paths[0])
+			if err != nil {
+				return nil, err
+			}
+			xzReader, err := xz.NewReader(file)
+			if err != nil {
+				file.Close()
+				return nil, fmt.Errorf("open %s: %w", c.paths[0], err)
+			}
+			c.file, c.tr, c.paths = file, tar.NewReader(xzReader), c.paths[1:]
+		}
+		header, err := c.tr.Next()
+		if err == io.EOF {
+			c.close()
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read tar header: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		c.next++
+		if c.next-1 == index {
+			return c.tr, nil
+		}
+	}
+}
+
+// close releases the part being read
+func (c *tarXzCursor) close() {
+	if c.file != nil {
+		c.file.Close()
+	}
+	c.file, c.tr = nil, nil
+}
+
+// repackSink writes entries into a standard archive, a tar stream, or (raw)
+// writes the content of a single entry as is
+type repackSink struct {
+	path    string   // Output removed on failure ("" for streams)
+	file    *os.File // Output closed when done (nil for stdout)
+	modTime time.Time
+
+	zw *zip.Writer
+	xw *xz.Writer
+	tw *tar.Writer
+
+	raw     io.Writer
+	rawUsed bool
+}
+
+// newRepackSink creates the output archive; its type follows the extension
+// (.zip, or .tar.xz / .txz). Entries take the source archive's modification
+// time since GDELTA archives don't store one per file.
+func newRepackSink(path string, overwrite bool, modTime time.Time) (*repackSink, error) {
+	lower := strings.ToLower(path)
+	isZip := strings.HasSuffix(lower, ".zip")
+	isTarXz := strings.HasSuffix(lower, ".tar.xz") || strings.HasSuffix(lower, ".txz")
+	if !isZip && !isTarXz {
+		return nil, fmt.Errorf("%w: %s", ErrRepackFormat, path)
+	}
+
+	if !overwrite {
+		if _, err := os.Stat(path); err == nil {
+			return nil, fmt.Errorf("%s: %w", path, ErrFileExists)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create output directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create %s: %w", path, err)
+	}
+
+	s := &repackSink{path: path, file: file, modTime: modTime}
+	if isZip {
+		s.zw = zip.NewWriter(file)
+		s.zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, flate.DefaultCompression)
+		})
+		return s, nil
+	}
+
+	s.xw, err = xz.NewWriter(file)
+	if err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("create xz writer: %w", err)
+	}
+	s.tw = tar.NewWriter(s.xw)
+	return s, nil
+}
+
+// newStreamSink writes to a stream target owned by the sink when file is
+// non-nil. A raw sink takes exactly one entry.
+func newStreamSink(w io.Writer, file *os.File, raw bool, modTime time.Time) *repackSink {
+	s := &repackSink{file: file, modTime: modTime}
+	if raw {
+		s.raw = w
+	} else {
+		s.tw = tar.NewWriter(w)
+	}
+	return s
+}
+
+// create starts a new entry and returns the writer for its content
+func (s *repackSink) create(name string, size uint64) (io.Writer, error) {
+	if s.raw != nil {
+		if s.rawUsed {
+			return nil, ErrStreamEntries
+		}
+		s.rawUsed = true
+		return s.raw, nil
+	}
+	if s.zw != nil {
+		header := &zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: s.modTime,
+		}
+		if size == 0 {
+			header.Method = zip.Store
+		}
+		header.SetMode(0644)
+		return s.zw.CreateHeader(header)
+	}
+
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(size),
+		ModTime:  s.modTime,
+	}
+	if err := s.tw.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	return s.tw, nil
+}
+
+// close finishes the archive; on failure the output is removed
+func (s *repackSink) close() error {
+	var err error
+	switch {
+	case s.zw != nil:
+		err = s.zw.Close()
+	case s.tw != nil:
+		if err = s.tw.Close(); err == nil && s.xw != nil {
+			err = s.xw.Close()
+		}
+	}
+	if s.file != nil {
+		if cerr := s.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil && s.path != "" {
+		os.Remove(s.path)
+	}
+	return err
+}
+
+// abort discards the partial output. A stream can't be taken back; its
+// reader sees it end early.
+func (s *repackSink) abort() {
+	if s.file != nil {
+		s.file.Close()
+	}
+	if s.path != "" {
+		os.Remove(s.path)
+	}
+}
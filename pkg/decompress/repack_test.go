@@ -0,0 +1,219 @@
+// pkg/decompress/repack_test.go
+package decompress_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ulikunitz/xz"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+)
+
+// TestRepack converts each GDELTA format into ZIP and tar.xz and reads the
+// result back with the standard library readers
+func TestRepack(t *testing.T) {
+	inputDir := t.TempDir()
+	want := buildTestInput(t, inputDir)
+
+	formats := map[string]*compress.Options{
+		"GDELTA01": {Level: 3},
+		"GDELTA02": {Level: 3, ChunkSize: 16 * 1024},
+		"GDELTA03": {Level: 3, UseDictionary: true},
+	}
+	readers := map[string]func(t *testing.T, path string) map[string][]byte{
+		"out.zip":    readZipEntries,
+		"out.tar.xz": readTarXzEntries,
+	}
+
+	for name, compressOpts := range formats {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+			compressOpts.InputPath = inputDir
+			compressOpts.OutputPath = archivePath
+			compressOpts.Quiet = true
+			if _, err := compress.Compress(compressOpts, nil); err != nil {
+				t.Fatalf("compress: %v", err)
+			}
+
+			for outName, read := range readers {
+				t.Run(outName, func(t *testing.T) {
+					outDir := t.TempDir()
+					repackPath := filepath.Join(outDir, outName)
+					result, err := decompress.Decompress(&decompress.Options{
+						InputPath:  archivePath,
+						OutputPath: outDir,
+						RepackPath: repackPath,
+						Quiet:      true,
+					}, nil)
+					if err != nil {
+						t.Fatalf("repack: %v", err)
+					}
+					if !result.Success() {
+						t.Fatalf("repack errors: %v", result.Errors)
+					}
+
+					got := read(t, repackPath)
+					if len(got) != len(want) {
+						t.Errorf("expected %d entries, got %d", len(want), len(got))
+					}
+					for rel, content := range want {
+						if !bytes.Equal(got[rel], content) {
+							t.Errorf("%s: content mismatch", rel)
+						}
+					}
+
+					// Nothing is extracted next to the output
+					entries, _ := os.ReadDir(outDir)
+					if len(entries) != 1 {
+						t.Errorf("expected only the repacked archive in %s, found %d entries", outDir, len(entries))
+					}
+
+					// An existing output is kept unless Overwrite is set
+					_, err = decompress.Decompress(&decompress.Options{InputPath: archivePath, RepackPath: repackPath, Quiet: true}, nil)
+					if !errors.Is(err, decompress.ErrFileExists) {
+						t.Errorf("expected ErrFileExists, got %v", err)
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestToTar converts every format, multi-part ZIP and tar.xz included, into
+// a tar stream
+func TestToTar(t *testing.T) {
+	inputDir := t.TempDir()
+	want := buildTestInput(t, inputDir)
+
+	formats := map[string]*compress.Options{
+		"GDELTA01": {Level: 3},
+		"GDELTA02": {Level: 3, ChunkSize: 16 * 1024},
+		"GDELTA03": {Level: 3, UseDictionary: true},
+		"ZIP":      {Level: 3, UseZipFormat: true, MaxThreads: 2},
+		"XZ":       {Level: 1, UseXzFormat: true, MaxThreads: 2},
+	}
+	for name, compressOpts := range formats {
+		t.Run(name, func(t *testing.T) {
+			archiveDir := t.TempDir()
+			archivePath := filepath.Join(archiveDir, "a.gdelta")
+			switch {
+			case compressOpts.UseZipFormat:
+				archivePath = filepath.Join(archiveDir, "a.zip")
+			case compressOpts.UseXzFormat:
+				archivePath = filepath.Join(archiveDir, "a.tar.xz")
+			}
+			compressOpts.InputPath = inputDir
+			compressOpts.OutputPath = archivePath
+			compressOpts.Quiet = true
+			if _, err := compress.Compress(compressOpts, nil); err != nil {
+				t.Fatal(err)
+			}
+			if compressOpts.UseZipFormat {
+				archivePath = filepath.Join(archiveDir, "a_01.zip")
+			}
+
+			var buf bytes.Buffer
+			result, err := decompress.ToTar(archivePath, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Success() || result.FilesTotal != len(want) {
+				t.Fatalf("%d/%d files, errors %v", result.FilesProcessed, result.FilesTotal, result.Errors)
+			}
+			got := readTarEntries(t, &buf)
+			if len(got) != len(want) {
+				t.Errorf("expected %d entries, got %d", len(want), len(got))
+			}
+			for rel, content := range want {
+				if !bytes.Equal(got[rel], content) {
+					t.Errorf("%s: content mismatch", rel)
+				}
+			}
+		})
+	}
+}
+
+func TestRepackRejectsUnknownExtension(t *testing.T) {
+	inputDir := t.TempDir()
+	buildTestInput(t, inputDir)
+	archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: inputDir, OutputPath: archivePath, Quiet: true}, nil); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "out.rar")
+	_, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, RepackPath: out, Quiet: true}, nil)
+	if !errors.Is(err, decompress.ErrRepackFormat) {
+		t.Fatalf("expected ErrRepackFormat, got %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("no output should be created, stat err = %v", err)
+	}
+}
+
+func readZipEntries(t *testing.T, path string) map[string][]byte {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	defer zr.Close()
+
+	entries := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		entries[f.Name] = data
+	}
+	return entries
+}
+
+func readTarXzEntries(t *testing.T, path string) map[string][]byte {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	xr, err := xz.NewReader(f)
+	if err != nil {
+		t.Fatalf("open xz: %v", err)
+	}
+	return readTarEntries(t, xr)
+}
+
+func readTarEntries(t *testing.T, r io.Reader) map[string][]byte {
+	t.Helper()
+	entries := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read tar: %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("read %s: %v", header.Name, err)
+		}
+		entries[header.Name] = data
+	}
+	return entries
+}
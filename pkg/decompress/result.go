@@ -9,6 +9,10 @@ type Result struct {
 	// Number of files successfully decompressed
 	FilesProcessed int
 
+	// Number of files left alone because they already existed
+	// (ConflictSkip, or ConflictKeepNewer with an older entry)
+	FilesSkipped int
+
 	// Total compressed size in bytes
 	CompressedSize uint64
 
@@ -17,11 +21,33 @@ type Result struct {
 
 	// List of errors encountered (non-fatal)
 	Errors []error
+
+	// Entries restored in a degraded form (or dropped) because the target
+	// filesystem lacks a feature: symlinks, long names, reserved characters.
+	// Reported separately from Errors; a degraded restore still succeeds.
+	Degradations []Degradation
+
+	// Entries that restore onto the same file as another one on the
+	// case-insensitive output, found before extraction. They are skipped
+	// or renamed by ConflictPolicy, or refused with ErrCaseCollision.
+	CaseCollisions []CaseCollision
+
+	// Plan is what the restore would do (DryRun only)
+	Plan *Plan
+}
+
+// DegradationCounts returns the number of degradations per kind
+func (r *Result) DegradationCounts() map[DegradationKind]int {
+	counts := make(map[DegradationKind]int)
+	for _, d := range r.Degradations {
+		counts[d.Kind]++
+	}
+	return counts
 }
 
 // Success returns true if all files were processed without errors
 func (r *Result) Success() bool {
-	return len(r.Errors) == 0 && r.FilesProcessed == r.FilesTotal
+	return len(r.Errors) == 0 && r.FilesProcessed+r.FilesSkipped == r.FilesTotal
 }
 
 // GetFilesTotal returns total files (interface method)
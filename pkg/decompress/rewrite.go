@@ -0,0 +1,95 @@
+// pkg/decompress/rewrite.go
+package decompress
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// Rewrite maps archived entry paths onto other paths at restore time, e.g.
+// From "var/www" To "srv/www" restores var/www/index.html as
+// srv/www/index.html
+type Rewrite struct {
+	// From is a path prefix, matched on whole components ("var/www" matches
+	// "var/www/index.html" but not "var/www2/index.html"), or a regular
+	// expression matched against the slash-separated path when Regexp is set
+	From string
+
+	// To replaces the prefix, or the regular expression's matches ($1, ${name}
+	// expand capture groups). An empty To moves the entries under From to
+	// the output root.
+	To string
+
+	// Regexp makes From a regular expression
+	Regexp bool
+}
+
+// pathRewriter applies Options.PathNormalization, then Options.PathRewrites.
+// The first rewrite that matches an entry applies; entries no rewrite
+// matches keep their path. A nil rewriter leaves every path alone.
+type pathRewriter struct {
+	normalization godelta.PathNormalization
+	rewrites      []Rewrite
+	patterns      []*regexp.Regexp // per rewrite, nil for prefixes
+}
+
+func newPathRewriter(rewrites []Rewrite, normalization godelta.PathNormalization) (*pathRewriter, error) {
+	if !normalization.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrPathNormalization, normalization)
+	}
+	if normalization == godelta.NormalizePreserve {
+		normalization = ""
+	}
+	if len(rewrites) == 0 && normalization == "" {
+		return nil, nil
+	}
+	r := &pathRewriter{normalization: normalization}
+	for _, rw := range rewrites {
+		if rw.From == "" {
+			return nil, fmt.Errorf("%w: empty From", ErrPathRewrite)
+		}
+		var re *regexp.Regexp
+		if rw.Regexp {
+			var err error
+			if re, err = regexp.Compile(rw.From); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrPathRewrite, err)
+			}
+		} else {
+			rw.From = normalizeEntryPath(normalization.Apply(rw.From))
+			if rw.To = normalizeEntryPath(normalization.Apply(rw.To)); rw.To == "." {
+				rw.To = ""
+			}
+		}
+		r.rewrites = append(r.rewrites, rw)
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// apply returns the path an entry is restored under
+func (r *pathRewriter) apply(entryPath string) string {
+	if r == nil {
+		return entryPath
+	}
+	entryPath = r.normalization.Apply(entryPath)
+	p := normalizeEntryPath(entryPath)
+	for i, rw := range r.rewrites {
+		if re := r.patterns[i]; re != nil {
+			if re.MatchString(p) {
+				return re.ReplaceAllString(p, rw.To)
+			}
+			continue
+		}
+		if rw.From == "." {
+			return path.Join(rw.To, p)
+		}
+		if p == rw.From || strings.HasPrefix(p, rw.From+"/") {
+			return strings.TrimPrefix(path.Join(rw.To, strings.TrimPrefix(p, rw.From)), "/")
+		}
+	}
+	return entryPath
+}
@@ -0,0 +1,152 @@
+// pkg/decompress/rewrite_test.go
+package decompress_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// TestPathRewrites restores every format with prefix and regular expression
+// rewrites, one of which tries to leave the output directory
+func TestPathRewrites(t *testing.T) {
+	inputDir := t.TempDir()
+	all := buildTestInput(t, inputDir)
+
+	rewrites := []decompress.Rewrite{
+		{From: "sub1/", To: "moved/one"},
+		{From: `^sub2/file_(\d+)\.txt$`, To: "two/${1}.dat", Regexp: true},
+		{From: "sub3", To: "../escaped"},
+		{From: "sub", To: "never"}, // not a whole component of sub0
+	}
+	want := make(map[string][]byte)
+	unsafe := 0
+	for rel, content := range all {
+		switch {
+		case strings.HasPrefix(rel, "sub1/"):
+			want["moved/one/"+strings.TrimPrefix(rel, "sub1/")] = content
+		case strings.HasPrefix(rel, "sub2/"):
+			want["two/"+strings.TrimSuffix(strings.TrimPrefix(rel, "sub2/file_"), ".txt")+".dat"] = content
+		case strings.HasPrefix(rel, "sub3/"):
+			unsafe++
+		default:
+			want[rel] = content
+		}
+	}
+
+	formats := map[string]*compress.Options{
+		"GDELTA01": {Level: 3},
+		"GDELTA02": {Level: 3, ChunkSize: 16 * 1024},
+		"GDELTA03": {Level: 3, UseDictionary: true},
+		"ZIP":      {Level: 3, UseZipFormat: true, MaxThreads: 2},
+		"XZ":       {Level: 1, UseXzFormat: true, MaxThreads: 1},
+	}
+	for name, compressOpts := range formats {
+		t.Run(name, func(t *testing.T) {
+			archiveDir := t.TempDir()
+			archivePath := filepath.Join(archiveDir, "a.gdelta")
+			switch {
+			case compressOpts.UseZipFormat:
+				archivePath = filepath.Join(archiveDir, "a.zip")
+			case compressOpts.UseXzFormat:
+				archivePath = filepath.Join(archiveDir, "a.tar.xz")
+			}
+			compressOpts.InputPath = inputDir
+			compressOpts.OutputPath = archivePath
+			compressOpts.Quiet = true
+			if _, err := compress.Compress(compressOpts, nil); err != nil {
+				t.Fatal(err)
+			}
+			if compressOpts.UseZipFormat {
+				archivePath = filepath.Join(archiveDir, "a_01.zip")
+			}
+
+			outDir := filepath.Join(t.TempDir(), "out")
+			result, err := decompress.Decompress(&decompress.Options{
+				InputPath:    archivePath,
+				OutputPath:   outDir,
+				PathRewrites: rewrites,
+				Quiet:        true,
+			}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Errors) != unsafe {
+				t.Fatalf("expected %d errors, got %v", unsafe, result.Errors)
+			}
+			for _, err := range result.Errors {
+				if !errors.Is(err, decompress.ErrUnsafeEntryPath) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			verifyOutput(t, outDir, want)
+			for _, dir := range []string{"sub1", "sub2", "sub3", "never", "../escaped"} {
+				if _, err := os.Stat(filepath.Join(outDir, dir)); err == nil {
+					t.Errorf("%s exists after the rewrite", dir)
+				}
+			}
+		})
+	}
+
+	_, err := decompress.Decompress(&decompress.Options{
+		InputPath:    "x.gdelta",
+		PathRewrites: []decompress.Rewrite{{From: "(", Regexp: true}},
+	}, nil)
+	if !errors.Is(err, decompress.ErrPathRewrite) {
+		t.Errorf("invalid expression: got %v, want ErrPathRewrite", err)
+	}
+}
+
+// TestPathNormalization stores a decomposed (macOS) name composed, and
+// composes it at restore time when it was stored as is
+func TestPathNormalization(t *testing.T) {
+	const nfd, nfc = "café.txt", "café.txt"
+	inputDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(inputDir, nfd), []byte("menu"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		atCompress godelta.PathNormalization
+		atRestore  godelta.PathNormalization
+	}{
+		{"at compression", godelta.NormalizeNFC, ""},
+		{"at restore", "", godelta.NormalizeNFC},
+	}
+	for _, tt := range tests {
+		archive := filepath.Join(t.TempDir(), "out.gdelta")
+		if _, err := compress.Compress(&compress.Options{
+			InputPath:         inputDir,
+			OutputPath:        archive,
+			PathNormalization: tt.atCompress,
+			Quiet:             true,
+		}, nil); err != nil {
+			t.Fatalf("%s: compress: %v", tt.name, err)
+		}
+		outDir := t.TempDir()
+		if _, err := decompress.Decompress(&decompress.Options{
+			InputPath:         archive,
+			OutputPath:        outDir,
+			PathNormalization: tt.atRestore,
+			Quiet:             true,
+		}, nil); err != nil {
+			t.Fatalf("%s: decompress: %v", tt.name, err)
+		}
+		entries, err := os.ReadDir(outDir)
+		if err != nil || len(entries) != 1 || entries[0].Name() != nfc {
+			t.Errorf("%s: restored %v (%v), want only %q", tt.name, entries, err, nfc)
+		}
+	}
+
+	_, err := decompress.Decompress(&decompress.Options{InputPath: "x.gdelta", PathNormalization: "nfkc"}, nil)
+	if !errors.Is(err, decompress.ErrPathNormalization) {
+		t.Errorf("unknown form: got %v, want ErrPathNormalization", err)
+	}
+}
@@ -2,6 +2,7 @@
 package decompress
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 )
@@ -11,13 +12,63 @@ import (
 // and this package's own dict/chunked/zstd formats) store entry paths as
 // untrusted strings — without this check, an entry like "../../etc/passwd"
 // or an absolute path lets extraction write anywhere the process can reach
-// (zip-slip). Returns ErrUnsafeEntryPath if the entry tries to escape.
+// (zip-slip). Absolute names and names with a ".." component are refused
+// outright, and so are names that reach outside outputDir through a
+// symlink already on disk (a directory link, or a link in place of the
+// file). Returns ErrUnsafeEntryPath if the entry tries to escape.
 func safeJoin(outputDir, entryName string) (string, error) {
+	name := filepath.FromSlash(entryName)
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" || strings.HasPrefix(name, string(filepath.Separator)) {
+		return "", ErrUnsafeEntryPath
+	}
+	for _, part := range strings.Split(filepath.ToSlash(name), "/") {
+		if part == ".." {
+			return "", ErrUnsafeEntryPath
+		}
+	}
+
 	cleanOutputDir := filepath.Clean(outputDir)
-	joined := filepath.Join(cleanOutputDir, entryName)
+	joined := filepath.Join(cleanOutputDir, name)
 	if joined != cleanOutputDir &&
 		!strings.HasPrefix(joined, cleanOutputDir+string(filepath.Separator)) {
 		return "", ErrUnsafeEntryPath
 	}
+	if !staysInside(cleanOutputDir, joined) {
+		return "", ErrUnsafeEntryPath
+	}
 	return joined, nil
 }
+
+// staysInside reports whether path, lexically under dir, still resolves
+// under dir once the symlinks on disk are followed. Only the deepest part of
+// path that already exists is resolved; what doesn't exist yet will be
+// created as plain directories and files.
+func staysInside(dir, path string) bool {
+	existing := path
+	for existing != dir {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	if existing == dir {
+		return true
+	}
+
+	resolvedDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		// A dangling link: it would be created wherever it points
+		return false
+	}
+	if resolvedDir, err = filepath.Abs(resolvedDir); err != nil {
+		return false
+	}
+	if resolved, err = filepath.Abs(resolved); err != nil {
+		return false
+	}
+	return resolved == resolvedDir || strings.HasPrefix(resolved, resolvedDir+string(filepath.Separator))
+}
@@ -1,7 +1,11 @@
 // pkg/decompress/safepath_test.go
 package decompress
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestSafeJoin(t *testing.T) {
 	base := "/data/extract"
@@ -17,11 +21,11 @@ func TestSafeJoin(t *testing.T) {
 		{"parent traversal", "../foo.txt", true, ""},
 		{"deep parent traversal", "../../etc/passwd", true, ""},
 		{"traversal inside path", "sub/../../foo.txt", true, ""},
-		// filepath.Join treats a later absolute-looking arg as just another
-		// segment (it doesn't reset to root), so this lands inside base —
-		// safe, not a traversal. Covered here to document that behavior.
-		{"absolute-looking path stays inside base", "/etc/passwd", false, "/data/extract/etc/passwd"},
+		// Would land back inside base, but no well-formed archive stores it
+		{"traversal staying inside", "sub/../foo.txt", true, ""},
+		{"absolute path", "/etc/passwd", true, ""},
 		{"sneaky prefix sibling", "../extract-evil/foo.txt", true, ""},
+		{"dotdot-looking name", "..foo/bar..", false, "/data/extract/..foo/bar.."},
 	}
 
 	for _, tc := range cases {
@@ -42,3 +46,42 @@ func TestSafeJoin(t *testing.T) {
 		})
 	}
 }
+
+func TestSafeJoinSymlinks(t *testing.T) {
+	base := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Mkdir(filepath.Join(base, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(base, "escape")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(base, "sub"), filepath.Join(base, "inside")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "passwd"), filepath.Join(base, "file")); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		entry   string
+		wantErr bool
+	}{
+		{"escape/foo.txt", true},
+		{"escape/new/dir/foo.txt", true},
+		{"escape", true},
+		{"file", true},
+		{"inside/foo.txt", false},
+		{"sub/foo.txt", false},
+		{"new/dir/foo.txt", false},
+	}
+	for _, tc := range cases {
+		_, err := safeJoin(base, tc.entry)
+		if tc.wantErr && err != ErrUnsafeEntryPath {
+			t.Errorf("entry %q: got %v, want ErrUnsafeEntryPath", tc.entry, err)
+		}
+		if !tc.wantErr && err != nil {
+			t.Errorf("entry %q: unexpected error %v", tc.entry, err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// pkg/decompress/segment.go
+package decompress
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/pool"
+)
+
+// decodeSegments decodes the zstd frames of a segmented entry in parallel,
+// writing each at its offset in out. sem is shared by the run's workers and
+// bounds the segments held in memory at once. onProgress receives the total
+// bytes written so far and may be called from several goroutines.
+func decodeSegments(
+	archive io.ReaderAt,
+	dataOffset int64,
+	segments []format.Segment,
+	out io.WriterAt,
+	sem chan struct{},
+	onProgress func(done uint64),
+) (uint64, error) {
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	var failed atomic.Bool
+	var done atomic.Uint64
+
+	fail := func(err error) {
+		errOnce.Do(func() { firstErr = err })
+		failed.Store(true)
+	}
+
+	var compOff, origOff int64
+	for i, seg := range segments {
+		if failed.Load() {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, seg format.Segment, compOff, origOff int64) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			comp := pool.Bytes(int(seg.CompressedSize))
+			defer pool.PutBytes(comp)
+			if _, err := archive.ReadAt(comp, dataOffset+compOff); err != nil {
+				fail(fmt.Errorf("read segment %d: %w", i, err))
+				return
+			}
+			dec, err := pool.Decoder()
+			if err != nil {
+				fail(fmt.Errorf("create zstd decoder: %w", err))
+				return
+			}
+			data, err := dec.DecodeAll(comp, pool.Scratch(int(seg.OrigSize)))
+			pool.PutDecoder(dec)
+			defer pool.PutBytes(data)
+			if err != nil {
+				fail(fmt.Errorf("decode segment %d: %w", i, err))
+				return
+			}
+			if len(data) != int(seg.OrigSize) {
+				fail(fmt.Errorf("segment %d: decoded %d bytes, seek table says %d", i, len(data), seg.OrigSize))
+				return
+			}
+			if _, err := out.WriteAt(data, origOff); err != nil {
+				fail(fmt.Errorf("write segment %d: %w", i, err))
+				return
+			}
+			onProgress(done.Add(uint64(len(data))))
+		}(i, seg, compOff, origOff)
+
+		compOff += int64(seg.CompressedSize)
+		origOff += int64(seg.OrigSize)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	return done.Load(), nil
+}
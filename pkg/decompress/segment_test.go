@@ -0,0 +1,82 @@
+// pkg/decompress/segment_test.go
+package decompress_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// TestSegmentedEntries compresses a file larger than the segment size as
+// parallel zstd frames and reads it back through decompress and verify
+func TestSegmentedEntries(t *testing.T) {
+	inputDir := t.TempDir()
+	var big bytes.Buffer
+	for i := 0; big.Len() < 5<<20+12345; i++ {
+		fmt.Fprintf(&big, "row %d: status=ok latency=%dms\n", i, i%977)
+	}
+	small := []byte("below the segment size\n")
+	if err := os.WriteFile(filepath.Join(inputDir, "big.log"), big.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(inputDir, "small.txt"), small, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		opts      compress.Options
+		segmented int
+	}{
+		"segmented":           {compress.Options{SegmentSize: 1 << 20, MaxThreads: 4}, 1},
+		"segmented in memory": {compress.Options{SegmentSize: 1 << 20, MaxThreads: 2, MaxThreadMemory: 16 << 20}, 1},
+		"disabled":            {compress.Options{SegmentSize: 1 << 20, DisableSegments: true}, 0},
+		"other codec":         {compress.Options{SegmentSize: 1 << 20, Codec: "lz4", Level: 1}, 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+			copts := tc.opts
+			copts.InputPath = inputDir
+			copts.OutputPath = archivePath
+			copts.Quiet = true
+			cresult, err := compress.Compress(&copts, nil)
+			if err != nil {
+				t.Fatalf("compress: %v", err)
+			}
+			if cresult.SegmentedFiles != tc.segmented {
+				t.Errorf("expected %d segmented files, got %d", tc.segmented, cresult.SegmentedFiles)
+			}
+
+			vresult, err := verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true}, nil)
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if !vresult.IsValid() {
+				t.Fatalf("verify errors: %v", vresult.Errors)
+			}
+
+			outDir := t.TempDir()
+			result, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: outDir, MaxThreads: 3, Quiet: true}, nil)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			if !result.Success() {
+				t.Fatalf("decompress errors: %v", result.Errors)
+			}
+			for rel, want := range map[string][]byte{"big.log": big.Bytes(), "small.txt": small} {
+				got, err := os.ReadFile(filepath.Join(outDir, rel))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("%s: content mismatch (%d bytes, want %d)", rel, len(got), len(want))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// pkg/decompress/select.go
+package decompress
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// pathSelector matches entry paths against Options.Paths. A path selects
+// the entry stored under it, or every entry below it when it names a
+// directory. A nil selector selects everything.
+type pathSelector struct {
+	paths   []string
+	matched []bool
+}
+
+func newPathSelector(paths []string) *pathSelector {
+	if len(paths) == 0 {
+		return nil
+	}
+	s := &pathSelector{matched: make([]bool, len(paths))}
+	for _, p := range paths {
+		s.paths = append(s.paths, normalizeEntryPath(p))
+	}
+	return s
+}
+
+// normalizeEntryPath puts a path in the slash-separated, cleaned form used
+// for matching
+func normalizeEntryPath(p string) string {
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(p)), "/")
+}
+
+// match reports whether the entry is selected
+func (s *pathSelector) match(entryPath string) bool {
+	if s == nil {
+		return true
+	}
+	entryPath = normalizeEntryPath(entryPath)
+	selected := false
+	for i, p := range s.paths {
+		if p == "." || entryPath == p || strings.HasPrefix(entryPath, p+"/") {
+			s.matched[i] = true
+			selected = true
+		}
+	}
+	return selected
+}
+
+// unmatched returns an ErrPathNotFound for every path that selected nothing
+func (s *pathSelector) unmatched() []error {
+	if s == nil {
+		return nil
+	}
+	var errs []error
+	for i, ok := range s.matched {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %w", s.paths[i], ErrPathNotFound))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,121 @@
+// pkg/decompress/select_test.go
+package decompress_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+)
+
+// TestSelectivePaths extracts a directory and a single file from each
+// GDELTA format and reports a path that isn't in the archive
+func TestSelectivePaths(t *testing.T) {
+	inputDir := t.TempDir()
+	all := buildTestInput(t, inputDir)
+
+	want := make(map[string][]byte)
+	for rel, content := range all {
+		if strings.HasPrefix(rel, "sub1/") || rel == "empty.txt" {
+			want[rel] = content
+		}
+	}
+
+	formats := map[string]*compress.Options{
+		"GDELTA01": {Level: 3},
+		"GDELTA02": {Level: 3, ChunkSize: 16 * 1024},
+		"GDELTA03": {Level: 3, UseDictionary: true},
+	}
+	for name, compressOpts := range formats {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+			compressOpts.InputPath = inputDir
+			compressOpts.OutputPath = archivePath
+			compressOpts.Quiet = true
+			if _, err := compress.Compress(compressOpts, nil); err != nil {
+				t.Fatalf("compress: %v", err)
+			}
+
+			outDir := t.TempDir()
+			result, err := decompress.Decompress(&decompress.Options{
+				InputPath:  archivePath,
+				OutputPath: outDir,
+				Paths:      []string{"sub1/", "./empty.txt", "missing"},
+				Quiet:      true,
+			}, nil)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			if len(result.Errors) != 1 || !errors.Is(result.Errors[0], decompress.ErrPathNotFound) {
+				t.Fatalf("expected one ErrPathNotFound, got %v", result.Errors)
+			}
+			if result.FilesTotal != len(want) || result.FilesProcessed != len(want) {
+				t.Errorf("expected %d files, got %d total / %d processed", len(want), result.FilesTotal, result.FilesProcessed)
+			}
+			verifyOutput(t, outDir, want)
+			if _, err := os.Stat(filepath.Join(outDir, "sub0")); !os.IsNotExist(err) {
+				t.Errorf("unselected directory sub0 was extracted")
+			}
+		})
+	}
+}
+
+// TestGDelta01Index checks that compress writes an entry index and that a
+// damaged index is ignored in favour of walking the entries
+func TestGDelta01Index(t *testing.T) {
+	inputDir := t.TempDir()
+	want := buildTestInput(t, inputDir)
+
+	archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: inputDir, OutputPath: archivePath, Level: 3, Quiet: true}, nil); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := format.ReadGDelta01Index(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("index has %d entries, want %d", len(entries), len(want))
+	}
+	for _, e := range entries {
+		if _, ok := want[e.Path]; !ok {
+			t.Errorf("unexpected index entry %s", e.Path)
+		}
+	}
+
+	// Without the index the archive still reads as a plain GDELTA01
+	indexOffset := binary.LittleEndian.Uint64(data[len(data)-24:])
+	if _, err := format.ReadGDelta01Index(bytes.NewReader(data[:indexOffset]), int64(indexOffset)); !errors.Is(err, format.ErrNoIndex) {
+		t.Errorf("expected ErrNoIndex without the index, got %v", err)
+	}
+
+	// A flipped byte in the index fails its checksum; extraction falls back
+	data[indexOffset+3] ^= 0xff
+	if _, err := format.ReadGDelta01Index(bytes.NewReader(data), int64(len(data))); err == nil || errors.Is(err, format.ErrNoIndex) {
+		t.Errorf("expected a checksum error, got %v", err)
+	}
+	if err := os.WriteFile(archivePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+	result, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: outDir, Quiet: true}, nil)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	if !result.Success() {
+		t.Fatalf("decompress errors: %v", result.Errors)
+	}
+	verifyOutput(t, outDir, want)
+}
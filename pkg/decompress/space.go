@@ -0,0 +1,109 @@
+// pkg/decompress/space.go
+package decompress
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// spaceCheckInterval is how often the free space of the output is checked
+// during a restore with MinFreeSpace
+const spaceCheckInterval = time.Second
+
+// spaceGuard watches the free space of the output during a restore and
+// flags it low once it drops under the threshold. A nil spaceGuard never
+// does.
+type spaceGuard struct {
+	dir      string
+	min      uint64
+	lowFlag  atomic.Bool
+	free     atomic.Uint64 // last free space seen
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// watchSpace starts watching the output of opts, or returns nil without a
+// MinFreeSpace or when the platform can't tell the free space
+func watchSpace(opts *Options) *spaceGuard {
+	if opts.MinFreeSpace == 0 {
+		return nil
+	}
+	g := &spaceGuard{dir: existingParent(opts.OutputPath), min: opts.MinFreeSpace, done: make(chan struct{})}
+	if !g.check() {
+		return nil
+	}
+	go func() {
+		ticker := time.NewTicker(spaceCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-g.done:
+				return
+			case <-ticker.C:
+				if !g.check() || g.low() {
+					return
+				}
+			}
+		}
+	}()
+	return g
+}
+
+// check reads the free space, flagging it low under the threshold, and
+// reports whether it could be read
+func (g *spaceGuard) check() bool {
+	free, ok := freeSpace(g.dir)
+	if !ok {
+		return false
+	}
+	g.free.Store(free)
+	if free < g.min {
+		g.lowFlag.Store(true)
+	}
+	return true
+}
+
+// low reports whether the output ran low on space
+func (g *spaceGuard) low() bool {
+	return g != nil && g.lowFlag.Load()
+}
+
+// stop ends the watch and returns the run's error: err, or ErrLowSpace when
+// the output ran low, replacing the errors of the entries it stopped
+func (g *spaceGuard) stop(result *Result, err error) error {
+	if g == nil {
+		return err
+	}
+	g.stopOnce.Do(func() { close(g.done) })
+	if !g.low() {
+		return err
+	}
+	result.Errors = slices.DeleteFunc(result.Errors, func(e error) bool { return errors.Is(e, ErrLowSpace) })
+	return fmt.Errorf("%w: %s free in %s, under %s, after %d of %d files (restored files are complete; run again with --on-conflict skip to resume)",
+		ErrLowSpace, godelta.FormatSize(g.free.Load()), g.dir, godelta.FormatSize(g.min), result.FilesProcessed, result.FilesTotal)
+}
+
+// checkSpace fails with ErrNotEnoughSpace when the entries don't fit in the
+// free space of the output, keeping MinFreeSpace free
+func checkSpace(entries []planEntry, opts *Options) error {
+	dir := existingParent(opts.OutputPath)
+	free, ok := freeSpace(dir)
+	if !ok {
+		return nil
+	}
+	var needed uint64
+	for _, e := range entries {
+		needed += e.size
+	}
+	if free < needed+opts.MinFreeSpace {
+		return fmt.Errorf("%w: %s needed (plus %s to keep free), %s free in %s",
+			ErrNotEnoughSpace, godelta.FormatSize(needed), godelta.FormatSize(opts.MinFreeSpace), godelta.FormatSize(free), dir)
+	}
+	return nil
+}
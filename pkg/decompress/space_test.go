@@ -0,0 +1,51 @@
+// pkg/decompress/space_test.go
+package decompress_test
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// TestLowSpace restores with more free space required than any disk has:
+// the pre-flight check refuses the restore, and the watch stops it before
+// any entry is written, as one error rather than one per file
+func TestLowSpace(t *testing.T) {
+	inputDir := t.TempDir()
+	buildTestInput(t, inputDir)
+	archive := filepath.Join(t.TempDir(), "a.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: inputDir, OutputPath: archive, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, checkSpace := range []bool{true, false} {
+		outDir := t.TempDir()
+		result, err := decompress.Decompress(&decompress.Options{
+			InputPath:    archive,
+			OutputPath:   outDir,
+			CheckSpace:   checkSpace,
+			MinFreeSpace: math.MaxUint64 / 2,
+			Quiet:        true,
+		}, nil)
+
+		want := decompress.ErrLowSpace
+		if checkSpace {
+			want = decompress.ErrNotEnoughSpace
+		}
+		if !errors.Is(err, want) || !errors.Is(err, godelta.ErrNoSpace) {
+			t.Errorf("check space %v: got %v, want %v", checkSpace, err, want)
+		}
+		if result != nil && len(result.Errors) > 0 {
+			t.Errorf("check space %v: per-file errors %v", checkSpace, result.Errors)
+		}
+		if entries, _ := os.ReadDir(outDir); len(entries) > 0 {
+			t.Errorf("check space %v: %d entries written", checkSpace, len(entries))
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// pkg/decompress/stream.go
+package decompress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// isStreamTarget reports whether the output is stdout or a named pipe
+// rather than a directory
+func isStreamTarget(path string) bool {
+	if path == StdoutPath {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeNamedPipe != 0
+}
+
+// ToTar writes every entry of the archive at archivePath (any supported
+// format) to w as an uncompressed tar stream, without extracting anything.
+// Use Decompress with Options.TarOutput for progress, Recover, Paths or
+// PathRewrites.
+func ToTar(archivePath string, w io.Writer) (*Result, error) {
+	return Decompress(&Options{InputPath: archivePath, TarOutput: w, Quiet: true}, nil)
+}
+
+// openStreamSink returns the sink opener for a stream OutputPath. A FIFO is
+// opened only once the archive has been read, since opening blocks until
+// the consumer attaches.
+func openStreamSink(opts *Options) openSinkFunc {
+	return func(entries int, modTime time.Time) (*repackSink, error) {
+		raw := entries == 1 && !opts.StreamTar
+		if opts.OutputPath == StdoutPath {
+			return newStreamSink(os.Stdout, nil, raw, modTime), nil
+		}
+		fifo, err := os.OpenFile(opts.OutputPath, os.O_WRONLY, 0)
+		if err != nil {
+			return nil, fmt.Errorf("open output pipe: %w", err)
+		}
+		return newStreamSink(fifo, fifo, raw, modTime), nil
+	}
+}
@@ -0,0 +1,121 @@
+// pkg/decompress/stream_unix_test.go
+//go:build unix
+
+package decompress_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+)
+
+// TestStreamToFIFO restores into a named pipe: a tar stream for several
+// entries, the bare content for a single one unless StreamTar is set
+func TestStreamToFIFO(t *testing.T) {
+	multiDir := t.TempDir()
+	want := buildTestInput(t, multiDir)
+
+	singleDir := t.TempDir()
+	single := bytes.Repeat([]byte("INSERT INTO t VALUES (1);\n"), 500)
+	if err := os.WriteFile(filepath.Join(singleDir, "dump.sql"), single, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		input     string
+		chunked   bool
+		streamTar bool
+		check     func(t *testing.T, got []byte)
+	}{
+		"tar": {input: multiDir, check: func(t *testing.T, got []byte) { checkTarStream(t, got, want) }},
+		"tar chunked": {input: multiDir, chunked: true, check: func(t *testing.T, got []byte) {
+			checkTarStream(t, got, want)
+		}},
+		"raw single": {input: singleDir, check: func(t *testing.T, got []byte) {
+			if !bytes.Equal(got, single) {
+				t.Errorf("raw stream mismatch: got %d bytes, want %d", len(got), len(single))
+			}
+		}},
+		"forced tar": {input: singleDir, streamTar: true, check: func(t *testing.T, got []byte) {
+			checkTarStream(t, got, map[string][]byte{"dump.sql": single})
+		}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+			copts := &compress.Options{InputPath: tc.input, OutputPath: archivePath, Quiet: true}
+			if tc.chunked {
+				copts.ChunkSize = 16 * 1024
+			}
+			if _, err := compress.Compress(copts, nil); err != nil {
+				t.Fatalf("compress: %v", err)
+			}
+
+			fifo := filepath.Join(t.TempDir(), "restore.pipe")
+			if err := syscall.Mkfifo(fifo, 0600); err != nil {
+				t.Skipf("mkfifo: %v", err)
+			}
+
+			// Consumer attached to the pipe, like a database import would be
+			received := make(chan []byte, 1)
+			go func() {
+				f, err := os.Open(fifo)
+				if err != nil {
+					received <- nil
+					return
+				}
+				defer f.Close()
+				data, _ := io.ReadAll(f)
+				received <- data
+			}()
+
+			result, err := decompress.Decompress(&decompress.Options{
+				InputPath:  archivePath,
+				OutputPath: fifo,
+				StreamTar:  tc.streamTar,
+				Quiet:      true,
+			}, nil)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			if !result.Success() {
+				t.Fatalf("decompress errors: %v", result.Errors)
+			}
+			tc.check(t, <-received)
+		})
+	}
+}
+
+func checkTarStream(t *testing.T, stream []byte, want map[string][]byte) {
+	t.Helper()
+	got := make(map[string][]byte)
+	tr := tar.NewReader(bytes.NewReader(stream))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read tar: %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[header.Name] = data
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %d entries, got %d", len(want), len(got))
+	}
+	for rel, content := range want {
+		if !bytes.Equal(got[rel], content) {
+			t.Errorf("%s: content mismatch", rel)
+		}
+	}
+}
@@ -2,11 +2,18 @@
 package decompress_test
 
 import (
+	"archive/tar"
 	"archive/zip"
+	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/ulikunitz/xz"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
 	"github.com/creativeyann17/go-delta/pkg/decompress"
 )
 
@@ -78,3 +85,155 @@ func TestDecompressZipRejectsPathTraversal(t *testing.T) {
 		t.Errorf("safe.txt content mismatch: got %q", string(data))
 	}
 }
+
+// TestDecompressRejectsPathTraversal patches the stored names of GDELTA
+// archives and crafts a tar.xz so one entry escapes the output directory,
+// and asserts every format refuses it while extracting the rest
+func TestDecompressRejectsPathTraversal(t *testing.T) {
+	inputDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(inputDir, "aa"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(inputDir, "aa", "escaped.txt"), []byte("should not land outside extract dir"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(inputDir, "safe.txt"), []byte("this one is fine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	formats := map[string]*compress.Options{
+		"GDELTA01": {Level: 3},
+		"GDELTA02": {Level: 3, ChunkSize: 4 * 1024},
+		"GDELTA03": {Level: 3, UseDictionary: true},
+	}
+	archives := map[string]string{}
+	for name, compressOpts := range formats {
+		archivePath := filepath.Join(t.TempDir(), "evil.gdelta")
+		compressOpts.InputPath = inputDir
+		compressOpts.OutputPath = archivePath
+		compressOpts.Quiet = true
+		if _, err := compress.Compress(compressOpts, nil); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		data, err := os.ReadFile(archivePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		patched := bytes.ReplaceAll(data, []byte("aa/escaped.txt"), []byte("../escaped.txt"))
+		if bytes.Equal(patched, data) {
+			t.Fatalf("%s: entry name not found in the archive", name)
+		}
+		if err := os.WriteFile(archivePath, patched, 0644); err != nil {
+			t.Fatal(err)
+		}
+		archives[name] = archivePath
+	}
+	archives["XZ"] = writeEvilTarXz(t, map[string]string{
+		"../escaped.txt": "should not land outside extract dir",
+		"safe.txt":       "this one is fine",
+	})
+
+	for name, archivePath := range archives {
+		t.Run(name, func(t *testing.T) {
+			extractDir := filepath.Join(t.TempDir(), "extracted")
+			result, err := decompress.Decompress(&decompress.Options{
+				InputPath:  archivePath,
+				OutputPath: extractDir,
+				MaxThreads: 2,
+				Overwrite:  true,
+				Quiet:      true,
+			}, nil)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			if len(result.Errors) != 1 || !errors.Is(result.Errors[0], decompress.ErrUnsafeEntryPath) {
+				t.Fatalf("expected one ErrUnsafeEntryPath, got %v", result.Errors)
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(extractDir), "escaped.txt")); err == nil {
+				t.Fatal("path traversal succeeded: file written outside output dir")
+			}
+			data, err := os.ReadFile(filepath.Join(extractDir, "safe.txt"))
+			if err != nil || string(data) != "this one is fine" {
+				t.Fatalf("safe.txt should have extracted normally: %q, %v", data, err)
+			}
+		})
+	}
+}
+
+// TestDecompressRejectsSymlinkedOutputDir restores into an output directory
+// where one of the archive's folders is already a symlink to somewhere else,
+// and asserts nothing is written through it
+func TestDecompressRejectsSymlinkedOutputDir(t *testing.T) {
+	inputDir := t.TempDir()
+	all := buildTestInput(t, inputDir)
+	archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: inputDir, OutputPath: archivePath, Level: 3, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(outDir, "sub1")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	result, err := decompress.Decompress(&decompress.Options{
+		InputPath:  archivePath,
+		OutputPath: outDir,
+		Overwrite:  true,
+		Quiet:      true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inSub1 := 0
+	for path := range all {
+		if strings.HasPrefix(path, "sub1/") {
+			inSub1++
+		}
+	}
+	if inSub1 == 0 || len(result.Errors) != inSub1 {
+		t.Fatalf("expected %d errors for sub1 entries, got %v", inSub1, result.Errors)
+	}
+	for _, err := range result.Errors {
+		if !errors.Is(err, decompress.ErrUnsafeEntryPath) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) > 0 {
+		t.Fatalf("%d files written through the symlink", len(entries))
+	}
+}
+
+// writeEvilTarXz writes a tar.xz holding files with the given names as is
+func writeEvilTarXz(t *testing.T, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "evil.tar.xz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	xw, err := xz.NewWriter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw := tar.NewWriter(xw)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := xw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
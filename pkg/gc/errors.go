@@ -0,0 +1,23 @@
+// pkg/gc/errors.go
+package gc
+
+import "github.com/creativeyann17/go-delta/pkg/godelta"
+
+// Every error matches a godelta error category with errors.Is
+var (
+	// ErrFormat is returned for archives other than GDELTA02, the only
+	// format with chunks that can be orphaned
+	ErrFormat = godelta.NewError(godelta.ErrUnsupported, "garbage collection needs a GDELTA02 archive")
+
+	// ErrTimestamped is returned for archives with a trusted timestamp,
+	// which rewriting the archive would invalidate
+	ErrTimestamped = godelta.NewError(godelta.ErrExists, "archive has a trusted timestamp that collecting would invalidate (remove its .tsr first)")
+
+	// ErrDamagedParity is returned when the parity of the archive reports
+	// damage: rewriting it would make the damage permanent
+	ErrDamagedParity = godelta.NewError(godelta.ErrCorrupt, "archive is damaged, run 'godelta repair' before collecting")
+
+	// ErrRewriteCorrupt is returned when the rewritten archive fails its
+	// verification; the original is left in place
+	ErrRewriteCorrupt = godelta.NewError(godelta.ErrCorrupt, "rewritten archive failed verification")
+)
@@ -0,0 +1,230 @@
+// pkg/gc/gc.go
+
+// Package gc drops the chunks of GDELTA02 archives that no file
+// references.
+package gc
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/creativeyann17/go-delta/pkg/timestamp"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// chunkIndexEntrySize is the size of one entry of the GDELTA02 chunk index
+const chunkIndexEntrySize = 56
+
+// Result describes a collection
+type Result struct {
+	ArchivePath    string
+	Chunks         int    // Chunks in the index before the collection
+	OrphanedChunks int    // Chunks no file references, dropped
+	OrphanedBytes  uint64 // Compressed size of the orphaned chunks
+	SizeBefore     uint64 // Archive size, parity included
+	SizeAfter      uint64 // Same after the rewrite (SizeBefore on a dry run)
+
+	// BytesReclaimed is SizeBefore - SizeAfter, or on a dry run the data
+	// and index entries of the orphaned chunks
+	BytesReclaimed uint64
+
+	DryRun bool
+}
+
+// Summary returns a human-readable summary of the collection
+func (r *Result) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Archive:   %s\n", r.ArchivePath)
+	fmt.Fprintf(&sb, "Chunks:    %d\n", r.Chunks)
+	fmt.Fprintf(&sb, "Orphaned:  %d chunks, %s\n", r.OrphanedChunks, godelta.FormatSize(r.OrphanedBytes))
+	switch {
+	case r.OrphanedChunks == 0:
+		sb.WriteString("Nothing to collect\n")
+	case r.DryRun:
+		fmt.Fprintf(&sb, "Would reclaim: %s (dry run, archive unchanged)\n", godelta.FormatSize(r.BytesReclaimed))
+	default:
+		fmt.Fprintf(&sb, "Reclaimed: %s (%s -> %s)\n", godelta.FormatSize(r.BytesReclaimed),
+			godelta.FormatSize(r.SizeBefore), godelta.FormatSize(r.SizeAfter))
+	}
+	return sb.String()
+}
+
+// Collect rewrites the GDELTA02 archive at path without the chunks no file
+// references, with the offsets of the others compacted. Files, their
+// metadata and the remaining chunks are copied as they are, so a .sha256
+// manifest stays valid; parity, if any, is computed again. The archive is
+// written next to the original, verified, then renamed over it. With
+// dryRun, the orphaned chunks are only counted.
+func Collect(path string, dryRun bool) (*Result, error) {
+	if _, err := os.Stat(timestamp.SidecarPath(path)); err == nil && !dryRun {
+		return nil, ErrTimestamped
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat archive: %w", err)
+	}
+
+	r := bufio.NewReaderSize(src, 1<<20)
+	if magic, err := r.Peek(format.MagicSize); err != nil || format.DetectFormat(magic) != format.FormatGDelta02 {
+		return nil, ErrFormat
+	}
+	header, err := format.ReadGDelta02Header(r)
+	if err != nil {
+		return nil, godelta.WithCategory(godelta.ErrCorrupt, err)
+	}
+	index, err := format.ReadChunkIndex(r, header.ChunkCount)
+	if err != nil {
+		return nil, godelta.WithCategory(godelta.ErrCorrupt, err)
+	}
+	files := make([]format.FileMetadata, 0, format.CapHint(header.FileCount))
+	referenced := make(map[[32]byte]bool, len(index))
+	for range header.FileCount {
+		metadata, err := format.ReadFileMetadata(r, header.Extended)
+		if err != nil {
+			return nil, godelta.WithCategory(godelta.ErrCorrupt, err)
+		}
+		for _, hash := range metadata.ChunkHashes {
+			referenced[hash] = true
+		}
+		files = append(files, metadata)
+	}
+	pos, err := src.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	dataStart := pos - int64(r.Buffered())
+
+	result := &Result{ArchivePath: path, Chunks: len(index), SizeBefore: uint64(info.Size()), DryRun: dryRun}
+	var kept []format.ChunkInfo
+	for _, c := range index {
+		if referenced[c.Hash] {
+			kept = append(kept, c)
+			continue
+		}
+		result.OrphanedChunks++
+		result.OrphanedBytes += c.CompressedSize
+	}
+	if result.OrphanedChunks == 0 || dryRun {
+		result.SizeAfter = result.SizeBefore
+		if dryRun {
+			result.BytesReclaimed = result.OrphanedBytes + uint64(result.OrphanedChunks)*chunkIndexEntrySize
+		}
+		return result, nil
+	}
+
+	percent := 0
+	if layout, err := parity.ReadLayout(src, info.Size()); err == nil {
+		report, err := parity.Check(src, info.Size())
+		if err != nil {
+			return nil, err
+		}
+		if report.Damaged() {
+			return nil, ErrDamagedParity
+		}
+		percent = layout.Percent()
+	}
+
+	// Chunk data keeps its order, without the gaps of the orphans
+	slices.SortFunc(kept, func(x, y format.ChunkInfo) int { return cmp.Compare(x.Offset, y.Offset) })
+	tmp := path + ".tmp"
+	size, err := rewrite(tmp, src, dataStart, header, files, kept, percent)
+	if err == nil {
+		var v *verify.Result
+		v, err = verify.Verify(&verify.Options{InputPath: tmp, Quiet: true}, nil)
+		if err == nil && !v.IsValid() {
+			err = errors.Join(v.Errors...)
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: %v", ErrRewriteCorrupt, err)
+		}
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return result, err
+	}
+	result.SizeAfter = uint64(size)
+	result.BytesReclaimed = result.SizeBefore - result.SizeAfter
+	return result, nil
+}
+
+// rewrite writes the archive to path with the chunks kept, in data order,
+// and returns its size
+func rewrite(path string, src *os.File, dataStart int64, header format.GDelta02Header,
+	files []format.FileMetadata, kept []format.ChunkInfo, parityPercent int) (int64, error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("create archive: %w", err)
+	}
+	defer out.Close()
+
+	index := make(map[[32]byte]format.ChunkInfo, len(kept))
+	var offset uint64
+	header.Features &^= format.FeatureStoredChunks
+	for _, c := range kept {
+		moved := c
+		moved.Offset = offset
+		index[c.Hash] = moved
+		offset += c.CompressedSize
+		if c.Stored {
+			header.Features |= format.FeatureStoredChunks
+		}
+	}
+	header.ChunkCount = uint32(len(kept))
+
+	w := bufio.NewWriterSize(out, 1<<20)
+	if err := format.WriteGDelta02Header(w, header); err != nil {
+		return 0, err
+	}
+	if err := format.WriteChunkIndex(w, index); err != nil {
+		return 0, err
+	}
+	for _, metadata := range files {
+		if err := format.WriteFileMetadata(w, metadata, header.Extended); err != nil {
+			return 0, err
+		}
+	}
+	for _, c := range kept {
+		data := io.NewSectionReader(src, dataStart+int64(c.Offset), int64(c.CompressedSize))
+		if _, err := io.CopyN(w, data, int64(c.CompressedSize)); err != nil {
+			return 0, fmt.Errorf("copy chunk %x: %w", c.Hash[:8], err)
+		}
+	}
+	if err := format.WriteArchiveFooter02(w); err != nil {
+		return 0, err
+	}
+	if err := w.Flush(); err != nil {
+		return 0, fmt.Errorf("write archive: %w", err)
+	}
+
+	info, err := out.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if parityPercent > 0 {
+		layout, err := parity.Write(out, size, parityPercent)
+		if err != nil {
+			return 0, fmt.Errorf("write parity: %w", err)
+		}
+		size += layout.SectionSize()
+	}
+	return size, out.Sync()
+}
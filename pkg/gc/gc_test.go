@@ -0,0 +1,159 @@
+// pkg/gc/gc_test.go
+package gc_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/gc"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// orphanArchive compresses two files, then drops the entry of the second
+// from the archive, leaving its chunks orphaned, and adds parityPercent
+func orphanArchive(t *testing.T, parityPercent int) string {
+	t.Helper()
+	input := t.TempDir()
+	if err := os.WriteFile(filepath.Join(input, "a.txt"), []byte(strings.Repeat("kept content ", 2000)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(input, "b.txt"), []byte(strings.Repeat("dropped data ", 3000)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := &compress.Options{InputPath: input, OutputPath: filepath.Join(t.TempDir(), "backup.gdelta"), ChunkSize: 4 << 10, Quiet: true}
+	if _, err := compress.Compress(opts, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(opts.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(data)
+	header, err := format.ReadGDelta02Header(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := format.ReadChunkIndex(r, header.ChunkCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kept []format.FileMetadata
+	for range header.FileCount {
+		m, err := format.ReadFileMetadata(r, header.Extended)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.RelPath != "b.txt" {
+			kept = append(kept, m)
+		}
+	}
+	rest, _ := io.ReadAll(r) // chunk data and footer
+
+	var out bytes.Buffer
+	header.FileCount = uint32(len(kept))
+	if err := format.WriteGDelta02Header(&out, header); err != nil {
+		t.Fatal(err)
+	}
+	if err := format.WriteChunkIndex(&out, index); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range kept {
+		if err := format.WriteFileMetadata(&out, m, header.Extended); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out.Write(rest)
+	if err := os.WriteFile(opts.OutputPath, out.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if parityPercent > 0 {
+		f, err := os.OpenFile(opts.OutputPath, os.O_RDWR, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := parity.Write(f, int64(out.Len()), parityPercent); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return opts.OutputPath
+}
+
+func TestCollect(t *testing.T) {
+	for name, parityPercent := range map[string]int{"plain": 0, "parity": 10} {
+		t.Run(name, func(t *testing.T) {
+			path := orphanArchive(t, parityPercent)
+			before, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dry, err := gc.Collect(path, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dry.OrphanedChunks == 0 || dry.BytesReclaimed == 0 {
+				t.Fatalf("dry run found nothing: %+v", dry)
+			}
+			if after, _ := os.ReadFile(path); !bytes.Equal(after, before) {
+				t.Fatal("dry run changed the archive")
+			}
+
+			result, err := gc.Collect(path, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.OrphanedChunks != dry.OrphanedChunks || result.SizeAfter+result.BytesReclaimed != uint64(len(before)) {
+				t.Errorf("unexpected result %+v (dry run %+v)", result, dry)
+			}
+			info, err := os.Stat(path)
+			if err != nil || uint64(info.Size()) != result.SizeAfter {
+				t.Fatalf("archive is %v bytes, result says %d (%v)", info.Size(), result.SizeAfter, err)
+			}
+
+			v, err := verify.Verify(&verify.Options{InputPath: path, VerifyData: true}, nil)
+			if err != nil || !v.IsValid() || v.OrphanedChunks != 0 || len(v.Warnings) != 0 {
+				t.Fatalf("collected archive: %v, %v, %d orphans", err, v.Errors, v.OrphanedChunks)
+			}
+			if parityPercent > 0 && v.Parity == nil {
+				t.Error("parity was dropped")
+			}
+			output := t.TempDir()
+			if _, err := decompress.Decompress(&decompress.Options{InputPath: path, OutputPath: output, Quiet: true}, nil); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := os.ReadFile(filepath.Join(output, "a.txt")); err != nil || string(got) != strings.Repeat("kept content ", 2000) {
+				t.Errorf("a.txt not restored (%v)", err)
+			}
+
+			again, err := gc.Collect(path, false)
+			if err != nil || again.OrphanedChunks != 0 || again.BytesReclaimed != 0 {
+				t.Errorf("second collection: %+v, %v", again, err)
+			}
+		})
+	}
+}
+
+func TestCollectFormat(t *testing.T) {
+	input := t.TempDir()
+	if err := os.WriteFile(filepath.Join(input, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "backup.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: input, OutputPath: output, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gc.Collect(output, false); !errors.Is(err, gc.ErrFormat) {
+		t.Errorf("got %v, want ErrFormat", err)
+	}
+}
@@ -0,0 +1,119 @@
+// pkg/godelta/errors.go
+package godelta
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+)
+
+// Error categories. The sentinel errors of the compress, decompress and
+// verify packages match one of them with errors.Is, and Category also
+// classifies the OS and context errors they pass through.
+var (
+	// ErrUsage is an invalid option, flag or combination of them
+	ErrUsage = errors.New("invalid usage")
+
+	// ErrNotFound is a missing input, archive or path
+	ErrNotFound = errors.New("not found")
+
+	// ErrExists is an output that already exists
+	ErrExists = errors.New("already exists")
+
+	// ErrPermission is a file or directory the process may not access
+	ErrPermission = errors.New("permission denied")
+
+	// ErrNoSpace is a destination without enough free space
+	ErrNoSpace = errors.New("no space left")
+
+	// ErrIO is any other failed read or write
+	ErrIO = errors.New("I/O error")
+
+	// ErrCorrupt is an archive whose data or structure is damaged
+	ErrCorrupt = errors.New("corrupt data")
+
+	// ErrUnsupported is an archive format, method or feature this version
+	// can't handle
+	ErrUnsupported = errors.New("unsupported")
+
+	// ErrCanceled is an operation stopped by its context or a signal
+	ErrCanceled = errors.New("canceled")
+
+	// ErrPartial is an operation that finished with per-file errors
+	ErrPartial = errors.New("completed with errors")
+)
+
+// categories in the order Category checks them
+var categories = []error{
+	ErrCanceled, ErrUsage, ErrCorrupt, ErrUnsupported, ErrExists, ErrNotFound,
+	ErrPermission, ErrNoSpace, ErrIO, ErrPartial,
+}
+
+// categoryError attaches a category to an error, keeping its message
+type categoryError struct {
+	err      error
+	category error
+}
+
+func (e *categoryError) Error() string   { return e.err.Error() }
+func (e *categoryError) Unwrap() []error { return []error{e.err, e.category} }
+
+// NewError returns an error with the given text that matches category
+// with errors.Is
+func NewError(category error, text string) error {
+	return WithCategory(category, errors.New(text))
+}
+
+// WithCategory returns err, also matching category with errors.Is
+func WithCategory(category, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &categoryError{err: err, category: category}
+}
+
+// Category returns the category of err: one of ErrCanceled, ErrUsage,
+// ErrCorrupt, ErrUnsupported, ErrExists, ErrNotFound, ErrPermission,
+// ErrNoSpace, ErrIO and ErrPartial, or nil when err is nil or
+// unclassified. Context errors, ENOSPC, fs.ErrExist, fs.ErrNotExist,
+// fs.ErrPermission and other path errors are classified too, as are the
+// counts and lengths past ReadLimits the archive readers refuse and the
+// archive features they don't support.
+func Category(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrCanceled
+	}
+	for _, category := range categories {
+		if errors.Is(err, category) {
+			return category
+		}
+	}
+
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	var syscallErr *os.SyscallError
+	switch {
+	case errors.Is(err, format.ErrMalformed):
+		return ErrCorrupt
+	case errors.Is(err, format.ErrUnsupportedFeature):
+		return ErrUnsupported
+	case errors.Is(err, syscall.ENOSPC):
+		return ErrNoSpace
+	case errors.Is(err, fs.ErrPermission):
+		return ErrPermission
+	case errors.Is(err, fs.ErrExist):
+		return ErrExists
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrNotFound
+	case errors.As(err, &pathErr), errors.As(err, &linkErr), errors.As(err, &syscallErr):
+		return ErrIO
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// pkg/godelta/errors_test.go
+package godelta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestCategory(t *testing.T) {
+	sentinel := NewError(ErrCorrupt, "invalid header")
+	_, statErr := os.Stat(filepath.Join(t.TempDir(), "missing"))
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"nil", nil, nil},
+		{"sentinel", sentinel, ErrCorrupt},
+		{"wrapped sentinel", fmt.Errorf("archive.gdelta: %w", sentinel), ErrCorrupt},
+		{"canceled", fmt.Errorf("compression canceled: %w", context.Canceled), ErrCanceled},
+		{"missing file", statErr, ErrNotFound},
+		{"disk full", &os.PathError{Op: "write", Path: "out", Err: syscall.ENOSPC}, ErrNoSpace},
+		{"other path error", &os.PathError{Op: "read", Path: "in", Err: syscall.EIO}, ErrIO},
+		{"unclassified", errors.New("boom"), nil},
+	}
+	for _, tt := range tests {
+		if got := Category(tt.err); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !errors.Is(sentinel, sentinel) || sentinel.Error() != "invalid header" {
+		t.Errorf("categorized sentinel lost its identity or message: %q", sentinel)
+	}
+}
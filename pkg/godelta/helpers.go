@@ -4,8 +4,10 @@ package godelta
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/vbauerster/mpb/v8"
 	"github.com/vbauerster/mpb/v8/decor"
@@ -27,6 +29,11 @@ type ProgressEvent struct {
 	Total        int64
 	CurrentBytes uint64
 	TotalBytes   uint64
+
+	// Run rates, shown by the overall bar when set
+	BytesPerSecond        float64
+	InstantBytesPerSecond float64
+	ETA                   time.Duration
 }
 
 // EventType indicates the type of progress event
@@ -71,6 +78,8 @@ func ProgressBarCallback() (func(ProgressEvent), *mpb.Progress) {
 	var mu sync.Mutex
 	byteMode := false
 	lastBytes := make(map[string]int64) // per in-flight file, bytes already added
+	var speed float64                   // latest instant throughput
+	var eta time.Duration               // latest estimate, 0 when unknown
 
 	// addOverallBytes credits the overall bar with this file's byte delta
 	addOverallBytes := func(filePath string, current int64) {
@@ -85,7 +94,33 @@ func ProgressBarCallback() (func(ProgressEvent), *mpb.Progress) {
 		}
 	}
 
+	// Speed and ETA come from the events rather than the bar's own timing,
+	// so both bar modes show the byte throughput
+	rateDecorators := []decor.Decorator{
+		decor.Any(func(decor.Statistics) string {
+			mu.Lock()
+			defer mu.Unlock()
+			if speed <= 0 {
+				return ""
+			}
+			return FormatSize(uint64(speed)) + "/s"
+		}, decor.WC{W: 12}),
+		decor.Any(func(s decor.Statistics) string {
+			mu.Lock()
+			defer mu.Unlock()
+			if eta <= 0 || s.Completed {
+				return ""
+			}
+			return "ETA " + eta.Round(time.Second).String()
+		}, decor.WC{W: 12}),
+	}
+
 	callback := func(event ProgressEvent) {
+		if event.InstantBytesPerSecond > 0 || event.ETA > 0 {
+			mu.Lock()
+			speed, eta = event.InstantBytesPerSecond, event.ETA
+			mu.Unlock()
+		}
 		switch event.Type {
 		case EventStart:
 			byteMode = event.TotalBytes > 0
@@ -97,7 +132,7 @@ func ProgressBarCallback() (func(ProgressEvent), *mpb.Progress) {
 						decor.CountersKibiByte("% .1f / % .1f", decor.WCSyncWidth),
 					),
 					mpb.AppendDecorators(
-						decor.Percentage(decor.WC{W: 5}),
+						append([]decor.Decorator{decor.Percentage(decor.WC{W: 5})}, rateDecorators...)...,
 					),
 					mpb.BarPriority(1000), // High priority = bottom
 				)
@@ -109,7 +144,7 @@ func ProgressBarCallback() (func(ProgressEvent), *mpb.Progress) {
 						decor.CountersNoUnit("%d / %d", decor.WCSyncWidth),
 					),
 					mpb.AppendDecorators(
-						decor.Percentage(decor.WC{W: 5}),
+						append([]decor.Decorator{decor.Percentage(decor.WC{W: 5})}, rateDecorators...)...,
 					),
 					mpb.BarPriority(1000),
 				)
@@ -252,6 +287,53 @@ func FormatSize(bytes uint64) string {
 	}
 }
 
+// ParseSize parses a human-readable size ("64KB", "1.5GB", "0") into bytes.
+// Units are binary (KB = 1024) and case-insensitive; a bare number is bytes.
+func ParseSize(s string) (uint64, error) {
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+
+	s = strings.ToUpper(strings.TrimSpace(s))
+
+	// Extract number and unit
+	var numStr string
+	var unit string
+
+	for i, r := range s {
+		if r >= '0' && r <= '9' || r == '.' {
+			numStr += string(r)
+		} else {
+			unit = s[i:]
+			break
+		}
+	}
+
+	if numStr == "" {
+		return 0, fmt.Errorf("no number found in size: %s", s)
+	}
+
+	num, err := strconv.ParseFloat(numStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number: %s", numStr)
+	}
+
+	switch unit {
+	case "B", "":
+		return uint64(num), nil
+	case "KB", "K":
+		return uint64(num * 1024), nil
+	case "MB", "M":
+		return uint64(num * 1024 * 1024), nil
+	case "GB", "G":
+		return uint64(num * 1024 * 1024 * 1024), nil
+	case "TB", "T":
+		return uint64(num * 1024 * 1024 * 1024 * 1024), nil
+	default:
+		return 0, fmt.Errorf("unknown unit: %s (use B, KB, MB, GB, TB)", unit)
+	}
+}
+
 // TruncateLeft truncates a path from the left to fit maxLen, preserving the filename
 func TruncateLeft(path string, maxLen int) string {
 	if len(path) <= maxLen {
@@ -1,7 +1,11 @@
 // pkg/godelta/io.go
 package godelta
 
-import "io"
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
 
 // ProgressWriter wraps an io.Writer with progress tracking
 type ProgressWriter struct {
@@ -53,23 +57,46 @@ func (dc *DiscardCounter) Write(p []byte) (int, error) {
 	return len(p), nil
 }
 
-// PathTracker tracks seen paths and detects duplicates
+// PathTracker tracks seen paths and detects duplicates. Paths equal once
+// Unicode-normalized (NFC) are duplicates too: they restore to the same
+// file on macOS and collide in archives moved between systems.
 type PathTracker struct {
-	seen map[string]bool
+	seen map[string]string // NFC form -> first path seen
 }
 
 // NewPathTracker creates a new PathTracker
 func NewPathTracker() *PathTracker {
 	return &PathTracker{
-		seen: make(map[string]bool),
+		seen: make(map[string]string),
 	}
 }
 
 // CheckDuplicate returns true if the path was already seen, otherwise marks it as seen
 func (pt *PathTracker) CheckDuplicate(path string) bool {
-	if pt.seen[path] {
-		return true
+	_, dup := pt.Duplicate(path)
+	return dup
+}
+
+// Duplicate is CheckDuplicate, also returning the path seen first, which
+// differs from path when they only match once normalized
+func (pt *PathTracker) Duplicate(path string) (string, bool) {
+	key := NormalizeNFC.Apply(path)
+	if first, ok := pt.seen[key]; ok {
+		return first, true
+	}
+	pt.seen[key] = path
+	return "", false
+}
+
+// JSONLines returns a function writing each value to w as one line of JSON
+// (newline-delimited JSON). It is safe for concurrent use. Write errors are
+// ignored so a consumer going away never fails the operation itself.
+func JSONLines(w io.Writer) func(v interface{}) {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(v interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = enc.Encode(v)
 	}
-	pt.seen[path] = true
-	return false
 }
@@ -0,0 +1,32 @@
+// pkg/godelta/limits.go
+package godelta
+
+import "github.com/creativeyann17/go-delta/internal/format"
+
+// ReadLimits are the largest counts and lengths the archive readers of
+// every package accept before allocating for them. An archive declaring
+// more fails with an error of category ErrCorrupt instead of exhausting
+// memory. 0 means no limit.
+type ReadLimits struct {
+	MaxFiles      uint32 // Files declared by an archive header
+	MaxChunks     uint32 // Entries of a GDELTA02 chunk index
+	MaxFileChunks uint32 // Chunks of one GDELTA02 file
+	MaxDictSize   uint32 // Bytes of a GDELTA03 dictionary
+}
+
+// DefaultReadLimits returns the limits readers start with, well above
+// anything this version writes
+func DefaultReadLimits() ReadLimits {
+	return ReadLimits(format.DefaultLimits)
+}
+
+// SetReadLimits replaces the limits of every archive reader of the
+// process, for services opening archives they don't trust
+func SetReadLimits(l ReadLimits) {
+	format.SetLimits(format.Limits(l))
+}
+
+// CurrentReadLimits returns the limits archive readers apply
+func CurrentReadLimits() ReadLimits {
+	return ReadLimits(format.CurrentLimits())
+}
@@ -0,0 +1,96 @@
+// pkg/godelta/metadata.go
+package godelta
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ArchiveMetadata describes an archive, so fleets of archives can be
+// catalogued without opening their content. GDELTA02 and GDELTA03 archives
+// record it in their header.
+type ArchiveMetadata struct {
+	Created time.Time         `json:"created"`           // when the archive was made
+	Host    string            `json:"host,omitempty"`    // hostname of the machine that made it
+	Version string            `json:"version,omitempty"` // godelta version that made it
+	Source  string            `json:"source,omitempty"`  // absolute input path
+	Labels  map[string]string `json:"labels,omitempty"`  // user-supplied key=value pairs
+
+	// Annotations are key=value notes that, unlike labels, can be changed
+	// after the archive is made ('godelta annotate')
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// MetadataRoom is the free space reserved after the metadata of a new
+// archive, so annotations can be added without moving the archive body
+const MetadataRoom = 4096
+
+// EncodeMetadata returns m as stored in an archive
+func EncodeMetadata(m *ArchiveMetadata) ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// EncodeMetadataPadded returns m as stored in an archive, padded with
+// spaces to exactly size bytes. Fails when m needs more.
+func EncodeMetadataPadded(m *ArchiveMetadata, size int) ([]byte, error) {
+	data, err := EncodeMetadata(m)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > size {
+		return nil, fmt.Errorf("metadata needs %d bytes, %d available", len(data), size)
+	}
+	return append(data, bytes.Repeat([]byte{' '}, size-len(data))...), nil
+}
+
+// DecodeMetadata reads metadata stored in an archive, padding included
+func DecodeMetadata(data []byte) (*ArchiveMetadata, error) {
+	m := &ArchiveMetadata{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, NewError(ErrCorrupt, fmt.Sprintf("invalid archive metadata: %v", err))
+	}
+	return m, nil
+}
+
+// ParseLabels turns key=value strings into labels. Keys can't be empty;
+// values split at the first '='.
+func ParseLabels(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, NewError(ErrUsage, fmt.Sprintf("label %q: expected key=value", pair))
+		}
+		labels[strings.TrimSpace(key)] = value
+	}
+	return labels, nil
+}
+
+// FormatMetadata renders m as indented "Key: value" lines, labels and
+// annotations sorted
+func FormatMetadata(m *ArchiveMetadata, indent string) string {
+	var sb strings.Builder
+	if !m.Created.IsZero() {
+		fmt.Fprintf(&sb, "%s%-11s %s\n", indent, "Created:", m.Created.Local().Format(time.RFC3339))
+	}
+	for _, field := range [][2]string{{"Host", m.Host}, {"Version", m.Version}, {"Source", m.Source}} {
+		if field[1] != "" {
+			fmt.Fprintf(&sb, "%s%-11s %s\n", indent, field[0]+":", field[1])
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(m.Labels)) {
+		fmt.Fprintf(&sb, "%s%-11s %s=%s\n", indent, "Label:", key, m.Labels[key])
+	}
+	for _, key := range slices.Sorted(maps.Keys(m.Annotations)) {
+		fmt.Fprintf(&sb, "%s%-11s %s=%s\n", indent, "Annotation:", key, m.Annotations[key])
+	}
+	return sb.String()
+}
@@ -0,0 +1,126 @@
+// pkg/godelta/throttle.go
+package godelta
+
+import (
+	"sync"
+	"time"
+)
+
+// instantWindow is the span InstantBytesPerSecond is measured over
+const instantWindow = time.Second
+
+// ProgressRates are the timing figures of a run so far
+type ProgressRates struct {
+	Elapsed               time.Duration
+	BytesPerSecond        float64       // Average since the start
+	InstantBytesPerSecond float64       // Over the last second, the average until one has passed
+	ETA                   time.Duration // Estimated time left, 0 when unknown
+}
+
+// ProgressThrottle thins out file progress events and tracks the run's
+// throughput. Progress events pass once Interval has elapsed or Bytes more
+// bytes were processed since the last one that passed (every event when
+// both are 0); other event types always pass. Safe for concurrent use.
+type ProgressThrottle struct {
+	interval time.Duration
+	bytes    uint64
+
+	mu        sync.Mutex
+	start     time.Time
+	last      time.Time
+	lastBytes uint64
+	done      uint64            // bytes processed across files
+	files     map[string]uint64 // bytes processed per file in progress
+
+	totalFiles int64  // from the start event
+	totalBytes uint64 // from the start event, 0 when unknown
+	filesDone  int64  // files completed or failed
+
+	window      time.Time // start of the current instant window
+	windowBytes uint64    // done at the start of the window
+	instant     float64   // rate over the last full window
+}
+
+// NewProgressThrottle returns a throttle passing a progress event at most
+// every interval or every bytes processed, whichever comes first
+func NewProgressThrottle(interval time.Duration, bytes uint64) *ProgressThrottle {
+	now := time.Now()
+	return &ProgressThrottle{
+		interval: interval,
+		bytes:    bytes,
+		start:    now,
+		last:     now,
+		window:   now,
+		files:    make(map[string]uint64),
+	}
+}
+
+// Observe records an event, where event.Current is the bytes of the file
+// processed so far (progress and complete events), and reports whether the
+// event should be delivered along with the run's rates. The ETA comes from
+// the start event's TotalBytes and the average throughput, or from its
+// file count when the bytes aren't known.
+func (t *ProgressThrottle) Observe(event ProgressEvent) (deliver bool, rates ProgressRates) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch event.Type {
+	case EventStart:
+		t.totalFiles, t.totalBytes = event.Total, event.TotalBytes
+	case EventFileProgress, EventFileComplete:
+		current := uint64(max(event.Current, 0))
+		if prev := t.files[event.FilePath]; current > prev {
+			t.done += current - prev
+			t.files[event.FilePath] = current
+		}
+		if event.Type == EventFileComplete {
+			delete(t.files, event.FilePath)
+			t.filesDone++
+		}
+	case EventError:
+		t.filesDone++
+	}
+	rates = t.rates(now, event.Type == EventComplete)
+
+	if event.Type != EventFileProgress || (t.interval == 0 && t.bytes == 0) {
+		return true, rates
+	}
+	if (t.interval > 0 && now.Sub(t.last) >= t.interval) || (t.bytes > 0 && t.done-t.lastBytes >= t.bytes) {
+		t.last, t.lastBytes = now, t.done
+		return true, rates
+	}
+	return false, rates
+}
+
+// rates computes the figures at now; t.mu must be held
+func (t *ProgressThrottle) rates(now time.Time, complete bool) ProgressRates {
+	rates := ProgressRates{Elapsed: now.Sub(t.start)}
+	elapsed := rates.Elapsed.Seconds()
+	if elapsed <= 0 {
+		return rates
+	}
+	rates.BytesPerSecond = float64(t.done) / elapsed
+
+	if span := now.Sub(t.window); span >= instantWindow {
+		t.instant = float64(t.done-t.windowBytes) / span.Seconds()
+		t.window, t.windowBytes = now, t.done
+	}
+	rates.InstantBytesPerSecond = t.instant
+	if rates.Elapsed < instantWindow {
+		rates.InstantBytesPerSecond = rates.BytesPerSecond
+	}
+
+	switch {
+	case complete:
+	case t.totalBytes > 0 && rates.BytesPerSecond > 0:
+		if t.done < t.totalBytes {
+			rates.ETA = time.Duration(float64(t.totalBytes-t.done) / rates.BytesPerSecond * float64(time.Second))
+		}
+	case t.totalFiles > 0 && t.filesDone > 0:
+		if t.filesDone < t.totalFiles {
+			rates.ETA = time.Duration(float64(rates.Elapsed) * float64(t.totalFiles-t.filesDone) / float64(t.filesDone))
+		}
+	}
+	return rates
+}
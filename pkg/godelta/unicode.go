@@ -0,0 +1,41 @@
+// pkg/godelta/unicode.go
+package godelta
+
+import "golang.org/x/text/unicode/norm"
+
+// PathNormalization is the Unicode normalization form applied to archived
+// paths. macOS stores names decomposed (NFD, "e" followed by a combining
+// accent) where Linux and Windows usually store them composed (NFC, "é"),
+// so the same name can be two different paths from one system to another.
+type PathNormalization string
+
+const (
+	// NormalizePreserve keeps paths as they are
+	NormalizePreserve PathNormalization = "preserve"
+
+	// NormalizeNFC composes paths (the common form on Linux and Windows)
+	NormalizeNFC PathNormalization = "nfc"
+
+	// NormalizeNFD decomposes paths (the form macOS HFS+ uses)
+	NormalizeNFD PathNormalization = "nfd"
+)
+
+// Valid reports whether n is a known form; empty means NormalizePreserve
+func (n PathNormalization) Valid() bool {
+	switch n {
+	case "", NormalizePreserve, NormalizeNFC, NormalizeNFD:
+		return true
+	}
+	return false
+}
+
+// Apply returns path in the form n
+func (n PathNormalization) Apply(path string) string {
+	switch n {
+	case NormalizeNFC:
+		return norm.NFC.String(path)
+	case NormalizeNFD:
+		return norm.NFD.String(path)
+	}
+	return path
+}
@@ -0,0 +1,25 @@
+// pkg/godelta/unicode_test.go
+package godelta
+
+import "testing"
+
+func TestPathTrackerNormalization(t *testing.T) {
+	const nfd, nfc = "docs/café.txt", "docs/café.txt"
+	if NormalizeNFC.Apply(nfd) != nfc || NormalizeNFD.Apply(nfc) != nfd || NormalizePreserve.Apply(nfd) != nfd {
+		t.Fatal("Apply didn't convert between the forms")
+	}
+	if PathNormalization("nfkc").Valid() || !PathNormalization("").Valid() {
+		t.Error("Valid accepts only preserve, nfc, nfd and empty")
+	}
+
+	pt := NewPathTracker()
+	if _, dup := pt.Duplicate(nfd); dup {
+		t.Fatal("first path reported as duplicate")
+	}
+	if first, dup := pt.Duplicate(nfc); !dup || first != nfd {
+		t.Errorf("NFC twin: got %q, %v, want %q, true", first, dup, nfd)
+	}
+	if !pt.CheckDuplicate(nfd) || pt.CheckDuplicate("docs/cafe.txt") {
+		t.Error("CheckDuplicate should match exact and normalized paths only")
+	}
+}
@@ -0,0 +1,105 @@
+// pkg/metrics/metrics_test.go
+package metrics_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/metrics"
+)
+
+func TestWriteText(t *testing.T) {
+	r := metrics.NewRegistry()
+	files := r.NewCounter("test_files_total", "Files seen.", "operation")
+	r.NewCounter("test_idle_total", "Never incremented.")
+	ratio := r.NewGauge("test_ratio", "A ratio.")
+	duration := r.NewHistogram("test_duration_seconds", "Durations.", []float64{1, 10}, "job")
+
+	files.Inc("compress")
+	files.Add(2, "compress")
+	files.Inc(`de"comp`)
+	files.Add(-5, "compress") // counters never go down
+	ratio.Set(0.25)
+	duration.Observe(0.5, "nightly")
+	duration.Observe(5, "nightly")
+	duration.Observe(50, "nightly")
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_files_total Files seen.
+# TYPE test_files_total counter
+test_files_total{operation="compress"} 3
+test_files_total{operation="de\"comp"} 1
+# HELP test_idle_total Never incremented.
+# TYPE test_idle_total counter
+test_idle_total 0
+# HELP test_ratio A ratio.
+# TYPE test_ratio gauge
+test_ratio 0.25
+# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{job="nightly",le="1"} 1
+test_duration_seconds_bucket{job="nightly",le="10"} 2
+test_duration_seconds_bucket{job="nightly",le="+Inf"} 3
+test_duration_seconds_sum{job="nightly"} 55.5
+test_duration_seconds_count{job="nightly"} 3
+`
+	if buf.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestDuplicateRegistrationPanics(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.NewCounter("dup_total", "x")
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic on duplicate registration")
+		}
+	}()
+	r.NewGauge("dup_total", "x")
+}
+
+// TestCompressRecordsMetrics checks compression workers feed the default
+// registry served at /metrics
+func TestCompressRecordsMetrics(t *testing.T) {
+	input := t.TempDir()
+	for i, content := range []string{"alpha", "beta", "gamma"} {
+		if err := os.WriteFile(filepath.Join(input, string(rune('a'+i))+".txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := compress.Compress(&compress.Options{
+		InputPath:  input,
+		OutputPath: filepath.Join(t.TempDir(), "out.gdelta"),
+		Quiet:      true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, line := range []string{
+		`godelta_files_processed_total{operation="compress"} 3`,
+		`godelta_compress_input_bytes_total 14`,
+		`godelta_operation_duration_seconds_count{operation="compress"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+}
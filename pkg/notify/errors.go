@@ -0,0 +1,19 @@
+// pkg/notify/errors.go
+package notify
+
+import "errors"
+
+var (
+	// ErrInvalidOn is returned for an unknown notify-on condition
+	ErrInvalidOn = errors.New("invalid notify condition (expected failure or always)")
+
+	// ErrInvalidURL is returned when the webhook URL isn't an http(s) URL
+	ErrInvalidURL = errors.New("webhook URL must be http or https")
+
+	// ErrEmailIncomplete is returned when email recipients are given without
+	// an SMTP server or sender
+	ErrEmailIncomplete = errors.New("email notifications need an SMTP address, a sender and a recipient")
+
+	// ErrWebhookStatus is returned when the webhook answers with a non-2xx status
+	ErrWebhookStatus = errors.New("webhook returned an error status")
+)
@@ -0,0 +1,217 @@
+// pkg/notify/notify.go
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// maxErrors caps the error messages carried in a Summary; ErrorCount keeps
+// the full count
+const maxErrors = 20
+
+// Summary describes one compress or verify run. It is the JSON body POSTed
+// to the webhook.
+type Summary struct {
+	Operation      string    `json:"operation"` // "compress" or "verify"
+	Success        bool      `json:"success"`
+	Input          string    `json:"input"`
+	Output         string    `json:"output,omitempty"`
+	Host           string    `json:"host"`
+	StartedAt      time.Time `json:"started_at"`
+	Duration       string    `json:"duration"`
+	FilesTotal     int       `json:"files_total"`
+	FilesProcessed int       `json:"files_processed"`
+	OriginalSize   uint64    `json:"original_size"`
+	CompressedSize uint64    `json:"compressed_size"`
+	ErrorCount     int       `json:"error_count"`
+	Errors         []string  `json:"errors,omitempty"`
+}
+
+// CompressSummary summarizes a compression run. runErr is the error returned
+// by compress.Compress (result may then be nil).
+func CompressSummary(opts *compress.Options, result *compress.Result, runErr error, start time.Time) Summary {
+	s := newSummary("compress", opts.InputPath, start)
+	s.Output = opts.OutputPath
+	var errs []error
+	if result != nil {
+		s.FilesTotal = result.FilesTotal
+		s.FilesProcessed = result.FilesProcessed
+		s.OriginalSize = result.OriginalSize
+		s.CompressedSize = result.CompressedSize
+		errs = result.Errors
+	}
+	if runErr != nil {
+		errs = append([]error{runErr}, errs...)
+	}
+	s.setErrors(errs)
+	s.Success = runErr == nil && len(errs) == 0
+	return s
+}
+
+// VerifySummary summarizes a verification run. runErr is the error returned
+// by verify.Verify (result may then be nil).
+func VerifySummary(opts *verify.Options, result *verify.Result, runErr error, start time.Time) Summary {
+	s := newSummary("verify", opts.InputPath, start)
+	var errs []error
+	if result != nil {
+		s.FilesTotal = result.FileCount
+		s.FilesProcessed = result.FileCount - result.CorruptFiles
+		s.OriginalSize = result.TotalOrigSize
+		s.CompressedSize = result.ArchiveSize
+		errs = result.Errors
+	}
+	if runErr != nil {
+		errs = append([]error{runErr}, errs...)
+	}
+	s.setErrors(errs)
+	s.Success = runErr == nil && result != nil && result.IsValid()
+	return s
+}
+
+func newSummary(operation, input string, start time.Time) Summary {
+	host, _ := os.Hostname()
+	return Summary{
+		Operation: operation,
+		Input:     input,
+		Host:      host,
+		StartedAt: start,
+		Duration:  time.Since(start).Round(time.Millisecond).String(),
+	}
+}
+
+func (s *Summary) setErrors(errs []error) {
+	s.ErrorCount = len(errs)
+	for i, err := range errs {
+		if i == maxErrors {
+			break
+		}
+		s.Errors = append(s.Errors, err.Error())
+	}
+}
+
+// Send delivers the summary to every configured destination if opts.On
+// selects this run. Each destination is tried even if another fails.
+func Send(ctx context.Context, opts *Options, s Summary) error {
+	if !opts.Enabled() || (opts.On != OnAlways && s.Success) {
+		return nil
+	}
+
+	var errs []error
+	if opts.URL != "" {
+		if err := postWebhook(ctx, opts, s); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		}
+	}
+	if len(opts.Email.To) > 0 {
+		if err := sendEmail(opts, s); err != nil {
+			errs = append(errs, fmt.Errorf("email: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// postWebhook POSTs the summary as JSON
+func postWebhook(ctx context.Context, opts *Options, s Summary) error {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "godelta")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: %s", ErrWebhookStatus, resp.Status)
+	}
+	return nil
+}
+
+// sendEmail sends a plain-text summary. net/smtp upgrades to STARTTLS when
+// the server offers it.
+func sendEmail(opts *Options, s Summary) error {
+	host, _, err := net.SplitHostPort(opts.Email.SMTPAddr)
+	if err != nil {
+		return fmt.Errorf("smtp address: %w", err)
+	}
+	var auth smtp.Auth
+	if opts.Email.Username != "" {
+		auth = smtp.PlainAuth("", opts.Email.Username, opts.Email.Password, host)
+	}
+
+	// net/smtp has no timeout of its own
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(opts.Email.SMTPAddr, auth, opts.Email.From, opts.Email.To, emailMessage(opts, s))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(opts.Timeout):
+		return fmt.Errorf("timed out after %s", opts.Timeout)
+	}
+}
+
+// emailMessage builds the RFC 5322 message for a summary
+func emailMessage(opts *Options, s Summary) []byte {
+	status := "succeeded"
+	if !s.Success {
+		status = "FAILED"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", opts.Email.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(opts.Email.To, ", "))
+	// Paths come from the run, so keep line breaks out of the headers
+	subject := fmt.Sprintf("godelta %s %s on %s: %s", s.Operation, status, s.Host, s.Input)
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+
+	fmt.Fprintf(&b, "Operation: %s\r\n", s.Operation)
+	fmt.Fprintf(&b, "Result:    %s\r\n", status)
+	fmt.Fprintf(&b, "Input:     %s\r\n", s.Input)
+	if s.Output != "" {
+		fmt.Fprintf(&b, "Output:    %s\r\n", s.Output)
+	}
+	fmt.Fprintf(&b, "Started:   %s (%s)\r\n", s.StartedAt.Format(time.RFC3339), s.Duration)
+	fmt.Fprintf(&b, "Files:     %d / %d\r\n", s.FilesProcessed, s.FilesTotal)
+	fmt.Fprintf(&b, "Sizes:     %s original, %s compressed\r\n",
+		compress.FormatSize(s.OriginalSize), compress.FormatSize(s.CompressedSize))
+	if s.ErrorCount > 0 {
+		fmt.Fprintf(&b, "\r\nErrors (%d):\r\n", s.ErrorCount)
+		for _, e := range s.Errors {
+			fmt.Fprintf(&b, "  %s\r\n", e)
+		}
+		if s.ErrorCount > len(s.Errors) {
+			fmt.Fprintf(&b, "  ... and %d more\r\n", s.ErrorCount-len(s.Errors))
+		}
+	}
+	return []byte(b.String())
+}
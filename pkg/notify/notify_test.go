@@ -0,0 +1,115 @@
+// pkg/notify/notify_test.go
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/notify"
+)
+
+func TestSendWebhook(t *testing.T) {
+	var received []notify.Summary
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("unexpected content type %q", ct)
+		}
+		var s notify.Summary
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		received = append(received, s)
+	}))
+	defer server.Close()
+
+	// A real run for the success case; the failure adds a run error to it
+	input := t.TempDir()
+	if err := os.WriteFile(filepath.Join(input, "ok.txt"), []byte("fine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := &compress.Options{InputPath: input, OutputPath: filepath.Join(t.TempDir(), "out.gdelta"), Quiet: true}
+	start := time.Now()
+	result, err := compress.Compress(opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := notify.CompressSummary(opts, result, nil, start)
+	failed := notify.CompressSummary(opts, result, errors.New("disk full"), start)
+
+	for _, tc := range []struct {
+		on      string
+		summary notify.Summary
+		sent    bool
+	}{
+		{notify.OnFailure, ok, false},
+		{notify.OnFailure, failed, true},
+		{notify.OnAlways, ok, true},
+	} {
+		received = nil
+		nopts := &notify.Options{URL: server.URL, On: tc.on}
+		if err := nopts.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if err := notify.Send(context.Background(), nopts, tc.summary); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+		if sent := len(received) == 1; sent != tc.sent {
+			t.Errorf("on=%s success=%v: sent=%v, want %v", tc.on, tc.summary.Success, sent, tc.sent)
+		}
+	}
+
+	// The last delivery was the successful run
+	got := received[0]
+	if got.Operation != "compress" || !got.Success || got.FilesProcessed != 1 || got.OriginalSize != 4 {
+		t.Errorf("unexpected summary: %+v", got)
+	}
+	if failed.Success || failed.ErrorCount != 1 || failed.Errors[0] != "disk full" {
+		t.Errorf("unexpected failure summary: %+v", failed)
+	}
+}
+
+func TestSendWebhookErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	opts := &notify.Options{URL: server.URL, On: notify.OnAlways}
+	if err := opts.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	err := notify.Send(context.Background(), opts, notify.Summary{Operation: "verify"})
+	if !errors.Is(err, notify.ErrWebhookStatus) {
+		t.Fatalf("expected ErrWebhookStatus, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		opts notify.Options
+		want error
+	}{
+		"defaults":        {notify.Options{URL: "https://example.com/hook"}, nil},
+		"bad on":          {notify.Options{On: "sometimes"}, notify.ErrInvalidOn},
+		"bad url":         {notify.Options{URL: "ftp://example.com"}, notify.ErrInvalidURL},
+		"email no server": {notify.Options{Email: notify.EmailOptions{To: []string{"ops@example.com"}}}, notify.ErrEmailIncomplete},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := tc.opts.Validate()
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+			if err == nil && tc.opts.On != notify.OnFailure {
+				t.Errorf("expected default On=%s, got %s", notify.OnFailure, tc.opts.On)
+			}
+		})
+	}
+}
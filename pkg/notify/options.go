@@ -0,0 +1,69 @@
+// pkg/notify/options.go
+package notify
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Notification conditions for Options.On
+const (
+	OnFailure = "failure" // only runs that failed or had file errors
+	OnAlways  = "always"  // every run
+)
+
+// Options configures where run summaries are sent
+type Options struct {
+	// URL receives a JSON Summary as an HTTP POST (optional)
+	URL string
+
+	// On selects which runs notify: OnFailure (default) or OnAlways
+	On string
+
+	// Email sends a plain-text summary over SMTP when To is set
+	Email EmailOptions
+
+	// Timeout bounds each delivery
+	// Default: 10s
+	Timeout time.Duration
+}
+
+// EmailOptions configures SMTP delivery
+type EmailOptions struct {
+	SMTPAddr string   // host:port of the SMTP server
+	From     string   // Sender address
+	To       []string // Recipient addresses
+
+	// Credentials for PLAIN auth; left empty for servers that relay without
+	// authentication
+	Username string
+	Password string
+}
+
+// Enabled reports whether any destination is configured
+func (o *Options) Enabled() bool {
+	return o.URL != "" || len(o.Email.To) > 0
+}
+
+// Validate checks the options and fills in defaults
+func (o *Options) Validate() error {
+	if o.On == "" {
+		o.On = OnFailure
+	}
+	if o.On != OnFailure && o.On != OnAlways {
+		return ErrInvalidOn
+	}
+	if o.URL != "" {
+		if u, err := url.Parse(o.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: %s", ErrInvalidURL, o.URL)
+		}
+	}
+	if len(o.Email.To) > 0 && (o.Email.SMTPAddr == "" || o.Email.From == "") {
+		return ErrEmailIncomplete
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+// pkg/profile/errors.go
+package profile
+
+import "errors"
+
+var (
+	// ErrNoProfiles is returned when the config file defines no profiles
+	ErrNoProfiles = errors.New("config defines no profiles")
+
+	// ErrUnknownProfile is returned when the requested profile is not defined
+	ErrUnknownProfile = errors.New("unknown profile")
+
+	// ErrProfileRequired is returned when no profile is named and there is no "default" one
+	ErrProfileRequired = errors.New("no profile selected")
+
+	// ErrConfigExists is returned by WriteSample when the file already exists
+	ErrConfigExists = errors.New("config file already exists")
+)
@@ -0,0 +1,275 @@
+// pkg/profile/profile.go
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is the config file used when --profile is given without --config
+const DefaultPath = "godelta.yaml"
+
+// DefaultProfile is used when a config is loaded without naming a profile
+const DefaultProfile = "default"
+
+// Config is a file of named compression profiles, in YAML or, for a .toml
+// file, TOML:
+//
+//	profiles:
+//	  nightly:
+//	    input: /data
+//	    output: /backups/nightly.gdelta
+//	    level: 9
+//	    chunk_size: 64KB
+//	    excludes: ["*.log", "node_modules/"]
+type Config struct {
+	Profiles map[string]Profile `yaml:"profiles" toml:"profiles"`
+}
+
+// Profile holds compress settings. Zero values are unset and leave the
+// command's own default (or flag) in place. Relative input and output paths
+// are resolved against the config file's directory. It covers the common
+// compress flags, not all of them; the others are given on the command line.
+type Profile struct {
+	Input  string `yaml:"input" toml:"input"`
+	Output string `yaml:"output" toml:"output"`
+
+	Threads        int    `yaml:"threads" toml:"threads"`
+	Parallelism    string `yaml:"parallelism" toml:"parallelism"`           // auto, folder, file
+	Order          string `yaml:"order" toml:"order"`                       // walk, type
+	ThreadMemory   string `yaml:"thread_memory" toml:"thread_memory"`       // e.g. "128MB"
+	ChunkSize      string `yaml:"chunk_size" toml:"chunk_size"`             // e.g. "64KB" (GDELTA02)
+	ChunkStoreSize string `yaml:"chunk_store_size" toml:"chunk_store_size"` // e.g. "1GB"
+	Level          int    `yaml:"level" toml:"level"`
+
+	// Chunk bounds (GDELTA02), default chunk_size/4 to chunk_size*4
+	ChunkMin           string `yaml:"chunk_min" toml:"chunk_min"`                     // e.g. "16KB"
+	ChunkMax           string `yaml:"chunk_max" toml:"chunk_max"`                     // e.g. "256KB"
+	ChunkNormalization *int   `yaml:"chunk_normalization" toml:"chunk_normalization"` // 0 (off) to 3, default 2
+	Chunking           string `yaml:"chunking" toml:"chunking"`                       // cdc, fixed
+
+	Zip        bool `yaml:"zip" toml:"zip"`
+	SingleZip  bool `yaml:"single_zip" toml:"single_zip"`
+	Xz         bool `yaml:"xz" toml:"xz"`
+	XzParts    bool `yaml:"xz_parts" toml:"xz_parts"`
+	TarGz      bool `yaml:"tar_gz" toml:"tar_gz"`
+	SevenZip   bool `yaml:"7z" toml:"7z"`
+	Dictionary bool `yaml:"dictionary" toml:"dictionary"` // GDELTA03
+	Gitignore  bool `yaml:"gitignore" toml:"gitignore"`
+
+	Parity   int  `yaml:"parity" toml:"parity"`     // percent of recovery data
+	Archival bool `yaml:"archival" toml:"archival"` // long-term archive preset
+	Verify   bool `yaml:"verify" toml:"verify"`     // read the archive back after writing it
+
+	Excludes []string `yaml:"excludes" toml:"excludes"` // gitignore-style patterns
+}
+
+// Load reads a config file, as TOML when its extension is .toml and as
+// YAML otherwise. Unknown keys are rejected so a typo doesn't silently
+// change what gets backed up.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	var cfg Config
+	if isTOML(path) {
+		md, err := toml.Decode(string(data), &cfg)
+		if err != nil {
+			return nil, fmt.Errorf("parse config %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("parse config %s: unknown key %s", path, undecoded[0])
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	}
+	if len(cfg.Profiles) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoProfiles, path)
+	}
+
+	base := filepath.Dir(path)
+	for name, p := range cfg.Profiles {
+		p.Input = resolve(base, p.Input)
+		p.Output = resolve(base, p.Output)
+		cfg.Profiles[name] = p
+	}
+	return &cfg, nil
+}
+
+// isTOML reports whether path names a TOML config file
+func isTOML(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+// resolve makes a relative path relative to base; empty stays empty
+func resolve(base, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
+
+// Get returns the named profile. An empty name selects DefaultProfile.
+func (c *Config) Get(name string) (*Profile, error) {
+	if name == "" {
+		p, ok := c.Profiles[DefaultProfile]
+		if !ok {
+			return nil, fmt.Errorf("%w: use --profile (available: %s)", ErrProfileRequired, strings.Join(c.Names(), ", "))
+		}
+		return &p, nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q (available: %s)", ErrUnknownProfile, name, strings.Join(c.Names(), ", "))
+	}
+	return &p, nil
+}
+
+// Names returns the profile names, sorted
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Sample is the commented config written by WriteSample
+const Sample = `# godelta compression profiles
+#
+#   godelta compress --config godelta.yaml --profile nightly
+#
+# Command-line flags override profile values. Relative paths are resolved
+# against this file's directory. Without --profile, "default" is used.
+profiles:
+  default:
+    input: ./data
+    output: ./backups/data.gdelta
+    level: 5
+
+  nightly:
+    input: /srv/data
+    output: /backups/nightly.gdelta
+    level: 9
+    threads: 4
+    chunk_size: 64KB          # content-defined dedup (GDELTA02)
+    # chunk_min: 16KB         # chunk bounds, default chunk_size/4 to *4
+    # chunk_max: 256KB
+    # chunk_normalization: 2  # 0 (off) to 3
+    # chunking: cdc           # cdc, fixed (blocks of chunk_size)
+    # chunk_store_size: 1GB   # dedup cache, 0/unset = auto
+    # parallelism: auto       # auto, folder, file
+    # order: type             # walk, type (similar files chunked together)
+    # thread_memory: 128MB
+    # parity: 10              # percent of recovery data
+    # verify: true            # read the archive back after writing it
+    gitignore: true
+    excludes:                 # gitignore syntax, relative to input
+      - "*.tmp"
+      - "node_modules/"
+
+  configs:
+    input: /etc
+    output: /backups/etc.gdelta
+    dictionary: true          # GDELTA03, many small similar files
+
+  longterm:
+    input: /srv/records
+    output: /archive/records.gdelta
+    archival: true            # deterministic, parity, manifest, verified
+
+  portable:
+    input: ./data
+    output: ./backups/data.zip
+    zip: true                 # or xz, tar_gz, 7z: true
+    level: 9
+`
+
+// SampleTOML is Sample in TOML, written by WriteSample for a .toml path
+const SampleTOML = `# godelta compression profiles
+#
+#   godelta compress --config godelta.toml --profile nightly
+#
+# Command-line flags override profile values. Relative paths are resolved
+# against this file's directory. Without --profile, "default" is used.
+[profiles.default]
+input = "./data"
+output = "./backups/data.gdelta"
+level = 5
+
+[profiles.nightly]
+input = "/srv/data"
+output = "/backups/nightly.gdelta"
+level = 9
+threads = 4
+chunk_size = "64KB"          # content-defined dedup (GDELTA02)
+# chunk_min = "16KB"         # chunk bounds, default chunk_size/4 to *4
+# chunk_max = "256KB"
+# chunk_normalization = 2    # 0 (off) to 3
+# chunking = "cdc"           # cdc, fixed (blocks of chunk_size)
+# chunk_store_size = "1GB"   # dedup cache, 0/unset = auto
+# parallelism = "auto"       # auto, folder, file
+# order = "type"             # walk, type (similar files chunked together)
+# thread_memory = "128MB"
+# parity = 10                # percent of recovery data
+# verify = true              # read the archive back after writing it
+gitignore = true
+excludes = ["*.tmp", "node_modules/"]  # gitignore syntax, relative to input
+
+[profiles.configs]
+input = "/etc"
+output = "/backups/etc.gdelta"
+dictionary = true            # GDELTA03, many small similar files
+
+[profiles.longterm]
+input = "/srv/records"
+output = "/archive/records.gdelta"
+archival = true              # deterministic, parity, manifest, verified
+
+[profiles.portable]
+input = "./data"
+output = "./backups/data.zip"
+zip = true                   # or xz, tar_gz, 7z = true
+level = 9
+`
+
+// WriteSample writes Sample to path, or SampleTOML for a .toml path,
+// refusing to replace an existing file unless force is set
+func WriteSample(path string, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%w: %s (use --force to overwrite)", ErrConfigExists, path)
+		}
+		return err
+	}
+	sample := Sample
+	if isTOML(path) {
+		sample = SampleTOML
+	}
+	if _, err := f.WriteString(sample); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,95 @@
+// pkg/profile/profile_test.go
+package profile_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/profile"
+)
+
+func TestSampleRoundTrip(t *testing.T) {
+	for _, name := range []string{profile.DefaultPath, "godelta.toml"} {
+		t.Run(name, func(t *testing.T) { testSampleRoundTrip(t, name) })
+	}
+}
+
+func testSampleRoundTrip(t *testing.T, name string) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+
+	if err := profile.WriteSample(path, false); err != nil {
+		t.Fatalf("write sample: %v", err)
+	}
+	if err := profile.WriteSample(path, false); !errors.Is(err, profile.ErrConfigExists) {
+		t.Errorf("second write: expected ErrConfigExists, got %v", err)
+	}
+	if err := profile.WriteSample(path, true); err != nil {
+		t.Errorf("forced write: %v", err)
+	}
+
+	cfg, err := profile.Load(path)
+	if err != nil {
+		t.Fatalf("load sample: %v", err)
+	}
+
+	nightly, err := cfg.Get("nightly")
+	if err != nil {
+		t.Fatalf("get nightly: %v", err)
+	}
+	if nightly.Level != 9 || nightly.ChunkSize != "64KB" || len(nightly.Excludes) != 2 {
+		t.Errorf("unexpected nightly profile: %+v", nightly)
+	}
+	if longterm, err := cfg.Get("longterm"); err != nil || !longterm.Archival {
+		t.Errorf("longterm profile: %+v, err %v", longterm, err)
+	}
+
+	// Relative paths resolve against the config directory
+	def, err := cfg.Get("")
+	if err != nil {
+		t.Fatalf("get default: %v", err)
+	}
+	if def.Input != filepath.Join(dir, "data") {
+		t.Errorf("default input = %q, want %q", def.Input, filepath.Join(dir, "data"))
+	}
+
+	if _, err := cfg.Get("missing"); !errors.Is(err, profile.ErrUnknownProfile) {
+		t.Errorf("expected ErrUnknownProfile, got %v", err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	if _, err := profile.Load(write("empty.yaml", "")); !errors.Is(err, profile.ErrNoProfiles) {
+		t.Errorf("empty config: expected ErrNoProfiles, got %v", err)
+	}
+
+	// A misspelled key must not be silently ignored
+	if _, err := profile.Load(write("typo.yaml", "profiles:\n  a:\n    levle: 3\n")); err == nil {
+		t.Errorf("expected an error for a misspelled key")
+	}
+	if _, err := profile.Load(write("typo.toml", "[profiles.a]\nlevle = 3\n")); err == nil {
+		t.Errorf("expected an error for a misspelled TOML key")
+	}
+	if _, err := profile.Load(write("empty.toml", "")); !errors.Is(err, profile.ErrNoProfiles) {
+		t.Errorf("empty TOML config: expected ErrNoProfiles, got %v", err)
+	}
+
+	cfg, err := profile.Load(write("nodefault.yaml", "profiles:\n  a:\n    level: 3\n"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, err := cfg.Get(""); !errors.Is(err, profile.ErrProfileRequired) {
+		t.Errorf("expected ErrProfileRequired, got %v", err)
+	}
+}
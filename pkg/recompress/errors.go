@@ -0,0 +1,30 @@
+// pkg/recompress/errors.go
+package recompress
+
+import "github.com/creativeyann17/go-delta/pkg/godelta"
+
+// Every error matches a godelta error category with errors.Is
+var (
+	// ErrFormat is returned for archives other than GDELTA02, whose chunks
+	// are the only data recompressed on their own
+	ErrFormat = godelta.NewError(godelta.ErrUnsupported, "recompressing needs a GDELTA02 archive (decompress and compress other formats again)")
+
+	// ErrOutput is returned when OutputPath is missing or names the input:
+	// the original is never touched
+	ErrOutput = godelta.NewError(godelta.ErrUsage, "recompressing needs an output path other than the archive")
+
+	// ErrUnknownCodec is returned when Codec names no registered codec
+	ErrUnknownCodec = godelta.NewError(godelta.ErrUsage, "unknown codec")
+
+	// ErrInvalidLevel is returned when Level is out of the codec's range
+	ErrInvalidLevel = godelta.NewError(godelta.ErrUsage, "compression level out of range for codec")
+
+	// ErrCorruptChunk is returned when a chunk of the archive doesn't
+	// decode to the data its hash names, or is said to lie past the end of
+	// the archive; nothing is written
+	ErrCorruptChunk = godelta.NewError(godelta.ErrCorrupt, "chunk is corrupt, run 'godelta repair' first")
+
+	// ErrRewriteCorrupt is returned when the recompressed archive fails its
+	// verification; it is removed
+	ErrRewriteCorrupt = godelta.NewError(godelta.ErrCorrupt, "recompressed archive failed verification")
+)
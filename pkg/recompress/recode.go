@@ -0,0 +1,309 @@
+// pkg/recompress/recode.go
+package recompress
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/zeebo/blake3"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/mmap"
+	"github.com/creativeyann17/go-delta/internal/parity"
+)
+
+// defaultLevel is used when the codec changes and no level is given
+const defaultLevel = 5
+
+// target is what the chunks are decoded from and encoded with
+type target struct {
+	srcMethod format.Method
+	srcDict   []byte
+
+	method format.Method
+	codec  format.Codec
+	level  int
+	dict   []byte // srcDict when the chunks stay zstd
+}
+
+// newTarget resolves the codec and level of opts against the archive's
+func newTarget(header format.GDelta02Header, opts *Options, result *Result) (*target, error) {
+	t := &target{srcMethod: header.Codec.Method(), srcDict: header.ChunkDictionary()}
+	t.method = t.srcMethod
+	if opts.Codec != "" {
+		id, _, ok := format.CodecByName(opts.Codec)
+		if !ok || id == format.MethodStore {
+			var names []string
+			for _, name := range format.CodecNames() {
+				if name != format.MethodStore.String() {
+					names = append(names, name)
+				}
+			}
+			return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownCodec, opts.Codec, strings.Join(names, ", "))
+		}
+		t.method = id
+	}
+	codec, ok := format.LookupCodec(t.method)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCodec, t.method)
+	}
+	t.codec = codec
+
+	t.level = opts.Level
+	if t.level == 0 && t.method == t.srcMethod {
+		t.level = header.Level
+	}
+	lo, hi := codec.LevelRange()
+	switch {
+	case hi == 0:
+		t.level = 0
+	case t.level == 0:
+		t.level = min(max(defaultLevel, lo), hi)
+	case t.level < lo || t.level > hi:
+		return nil, fmt.Errorf("%w: %s accepts %d-%d", ErrInvalidLevel, codec.Name(), lo, hi)
+	}
+
+	if t.method == format.MethodZstd {
+		t.dict = t.srcDict
+	} else {
+		result.DictionaryDropped = len(t.srcDict) > 0
+	}
+	result.CodecAfter, result.LevelAfter = codec.Name(), t.level
+	return t, nil
+}
+
+// coder recodes chunks for one worker
+type coder struct {
+	t   *target
+	dec *zstd.Decoder // nil unless the chunks are zstd
+	enc *zstd.Encoder // nil unless they become zstd
+	buf []byte
+	raw []byte
+}
+
+func newCoder(t *target) (*coder, error) {
+	c := &coder{t: t}
+	if t.srcMethod == format.MethodZstd {
+		decOpts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+		if len(t.srcDict) > 0 {
+			decOpts = append(decOpts, zstd.WithDecoderDicts(t.srcDict))
+		}
+		dec, err := zstd.NewReader(nil, decOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("create zstd decoder: %w", err)
+		}
+		c.dec = dec
+	}
+	if t.method == format.MethodZstd {
+		encOpts := []zstd.EOption{
+			zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(t.level)),
+			zstd.WithEncoderConcurrency(1),
+			zstd.WithZeroFrames(true),
+		}
+		if len(t.dict) > 0 {
+			encOpts = append(encOpts, zstd.WithEncoderDict(t.dict))
+		}
+		enc, err := zstd.NewWriter(nil, encOpts...)
+		if err != nil {
+			c.close()
+			return nil, fmt.Errorf("create zstd encoder: %w", err)
+		}
+		c.enc = enc
+	}
+	return c, nil
+}
+
+func (c *coder) close() {
+	if c.dec != nil {
+		c.dec.Close()
+	}
+	if c.enc != nil {
+		c.enc.Close()
+	}
+}
+
+// recode reads a chunk from the archive, checks it against its hash and
+// encodes it again. A chunk the codec doesn't shrink is stored.
+func (c *coder) recode(src io.ReaderAt, dataStart int64, info format.ChunkInfo) (data []byte, stored bool, err error) {
+	chunk, err := mmap.Read(src, dataStart+int64(info.Offset), int64(info.CompressedSize), &c.buf)
+	if err != nil {
+		return nil, false, fmt.Errorf("read chunk %x: %w", info.Hash[:8], err)
+	}
+
+	switch {
+	case info.Stored:
+		c.raw = append(c.raw[:0], chunk...)
+	case c.t.srcMethod == format.MethodZstd:
+		c.raw, err = c.dec.DecodeAll(chunk, c.raw[:0])
+	default:
+		var codec format.Codec
+		codec, _ = format.LookupCodec(c.t.srcMethod)
+		if codec == nil {
+			return nil, false, fmt.Errorf("%w: %s", ErrUnknownCodec, c.t.srcMethod)
+		}
+		c.raw, err = format.DecodeBlock(codec, c.raw[:0], chunk)
+	}
+	if err != nil || uint64(len(c.raw)) != info.OriginalSize || blake3.Sum256(c.raw) != info.Hash {
+		return nil, false, fmt.Errorf("%w: %x", ErrCorruptChunk, info.Hash[:8])
+	}
+
+	if c.enc != nil {
+		data = c.enc.EncodeAll(c.raw, nil)
+	} else if data, err = format.EncodeBlock(c.t.codec, nil, c.raw, c.t.level); err != nil {
+		return nil, false, fmt.Errorf("compress chunk %x: %w", info.Hash[:8], err)
+	}
+	if len(data) >= len(c.raw) {
+		return slices.Clone(c.raw), true, nil
+	}
+	return data, false, nil
+}
+
+// batchChunks are recoded at once per worker, then written in order
+const batchChunks = 16
+
+// recodeAll recodes the chunks on threads workers into w, in order, and
+// returns their new index entries
+func recodeAll(w io.Writer, src io.ReaderAt, dataStart int64, chunks []format.ChunkInfo, t *target, threads int) ([]format.ChunkInfo, error) {
+	coders := make(chan *coder, threads)
+	for range threads {
+		c, err := newCoder(t)
+		if err != nil {
+			close(coders)
+			for c := range coders {
+				c.close()
+			}
+			return nil, err
+		}
+		coders <- c
+	}
+	defer func() {
+		for range threads {
+			(<-coders).close()
+		}
+	}()
+
+	recoded := make([]format.ChunkInfo, len(chunks))
+	var offset uint64
+	for start := 0; start < len(chunks); start += threads * batchChunks {
+		batch := chunks[start:min(start+threads*batchChunks, len(chunks))]
+		data := make([][]byte, len(batch))
+		errs := make([]error, len(batch))
+		var wg sync.WaitGroup
+		for i, info := range batch {
+			c := <-coders
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { coders <- c }()
+				var stored bool
+				data[i], stored, errs[i] = c.recode(src, dataStart, info)
+				recoded[start+i] = format.ChunkInfo{Hash: info.Hash, OriginalSize: info.OriginalSize, Stored: stored}
+			}()
+		}
+		wg.Wait()
+		for i := range batch {
+			if errs[i] != nil {
+				return nil, errs[i]
+			}
+			if _, err := w.Write(data[i]); err != nil {
+				return nil, fmt.Errorf("write chunk data: %w", err)
+			}
+			recoded[start+i].Offset = offset
+			recoded[start+i].CompressedSize = uint64(len(data[i]))
+			offset += uint64(len(data[i]))
+		}
+	}
+	return recoded, nil
+}
+
+// rewrite writes the archive to path with the chunks recoded, staged in a
+// temporary file next to it until their index is known, and returns its
+// size
+func rewrite(path string, src *os.File, dataStart int64, header format.GDelta02Header,
+	files []format.FileMetadata, chunks []format.ChunkInfo, t *target, threads, parityPercent int, result *Result) (int64, error) {
+	staged, err := os.CreateTemp(filepath.Dir(path), ".recompress-*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("create temp file: %w", err)
+	}
+	defer func() {
+		staged.Close()
+		os.Remove(staged.Name())
+	}()
+	sw := bufio.NewWriterSize(staged, 1<<20)
+	recoded, err := recodeAll(sw, src, dataStart, chunks, t, threads)
+	if err != nil {
+		return 0, err
+	}
+	if err := sw.Flush(); err != nil {
+		return 0, fmt.Errorf("write temp file: %w", err)
+	}
+
+	index := make(map[[32]byte]format.ChunkInfo, len(recoded))
+	header.Features &^= format.FeatureStoredChunks | format.FeatureChunkDict
+	for _, c := range recoded {
+		index[c.Hash] = c
+		result.DataAfter += c.CompressedSize
+		if c.Stored {
+			result.StoredChunks++
+			header.Features |= format.FeatureStoredChunks
+		}
+	}
+	header.Codec = format.ChunkCodecFor(t.method)
+	header.Level = t.level
+	if result.DictionaryDropped {
+		header.Extensions = slices.DeleteFunc(slices.Clone(header.Extensions), func(e format.Extension) bool {
+			return e.Type == format.ExtChunkDict
+		})
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("create archive: %w", err)
+	}
+	defer out.Close()
+	w := bufio.NewWriterSize(out, 1<<20)
+	if err := format.WriteGDelta02Header(w, header); err != nil {
+		return 0, err
+	}
+	if err := format.WriteChunkIndex(w, index); err != nil {
+		return 0, err
+	}
+	for _, metadata := range files {
+		if err := format.WriteFileMetadata(w, metadata, header.Extended); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := staged.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("seek temp file: %w", err)
+	}
+	if _, err := io.Copy(w, staged); err != nil {
+		return 0, fmt.Errorf("copy chunk data: %w", err)
+	}
+	if err := format.WriteArchiveFooter02(w); err != nil {
+		return 0, err
+	}
+	if err := w.Flush(); err != nil {
+		return 0, fmt.Errorf("write archive: %w", err)
+	}
+
+	info, err := out.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if parityPercent > 0 {
+		layout, err := parity.Write(out, size, parityPercent)
+		if err != nil {
+			return 0, fmt.Errorf("write parity: %w", err)
+		}
+		size += layout.SectionSize()
+	}
+	return size, out.Sync()
+}
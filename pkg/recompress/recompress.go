@@ -0,0 +1,197 @@
+// pkg/recompress/recompress.go
+
+// Package recompress rewrites GDELTA02 archives with another compression
+// level or codec, from the archive alone.
+package recompress
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// Options configures a recompression
+type Options struct {
+	InputPath  string
+	OutputPath string // Must differ from InputPath
+
+	// Level is the new compression level (0 = keep the archive's, or the
+	// codec's default when it changes)
+	Level int
+
+	// Codec is the new chunk codec, one of compress.Codecs()
+	// "" = keep the archive's
+	Codec string
+
+	// MaxThreads chunks are recompressed at once
+	// Default: runtime.NumCPU()
+	MaxThreads int
+}
+
+// Result describes a recompression
+type Result struct {
+	InputPath, OutputPath string
+
+	CodecBefore, CodecAfter string
+	LevelBefore, LevelAfter int
+
+	Chunks       int    // Chunks recompressed
+	StoredChunks int    // Of which kept uncompressed, the codec not shrinking them
+	DataBefore   uint64 // Compressed chunk data before
+	DataAfter    uint64 // and after
+	SizeBefore   uint64 // Archive size, parity included
+	SizeAfter    uint64
+
+	// DictionaryDropped is set when the chunks were compressed with a
+	// chunk dictionary and the new codec isn't zstd
+	DictionaryDropped bool
+}
+
+// Summary returns a human-readable summary of the recompression
+func (r *Result) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Archive:   %s -> %s\n", r.InputPath, r.OutputPath)
+	fmt.Fprintf(&sb, "Codec:     %s level %d -> %s level %d\n", r.CodecBefore, r.LevelBefore, r.CodecAfter, r.LevelAfter)
+	fmt.Fprintf(&sb, "Chunks:    %d", r.Chunks)
+	if r.StoredChunks > 0 {
+		fmt.Fprintf(&sb, " (%d stored as-is)", r.StoredChunks)
+	}
+	sb.WriteString("\n")
+	fmt.Fprintf(&sb, "Data:      %s -> %s\n", godelta.FormatSize(r.DataBefore), godelta.FormatSize(r.DataAfter))
+	fmt.Fprintf(&sb, "Archive:   %s -> %s", godelta.FormatSize(r.SizeBefore), godelta.FormatSize(r.SizeAfter))
+	if r.SizeBefore > 0 {
+		fmt.Fprintf(&sb, " (%+.1f%%)", (float64(r.SizeAfter)-float64(r.SizeBefore))/float64(r.SizeBefore)*100)
+	}
+	sb.WriteString("\n")
+	if r.DictionaryDropped {
+		sb.WriteString("The chunk dictionary was dropped: only zstd uses one\n")
+	}
+	return sb.String()
+}
+
+// Recompress decodes every chunk of the GDELTA02 archive at
+// opts.InputPath and encodes it again with the new level or codec into
+// opts.OutputPath. Files, their metadata and chunk hashes, header
+// extensions and the dedup structure are kept, so a .sha256 manifest stays
+// valid; parity, if any, is computed again. Each chunk is checked against
+// its hash before it is encoded again, and the new archive is verified
+// (data included) before Recompress returns; on failure it is removed.
+func Recompress(opts *Options) (*Result, error) {
+	if opts.OutputPath == "" || sameFile(opts.InputPath, opts.OutputPath) {
+		return nil, ErrOutput
+	}
+	threads := opts.MaxThreads
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+
+	src, err := os.Open(opts.InputPath)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat archive: %w", err)
+	}
+
+	r := bufio.NewReaderSize(src, 1<<20)
+	if magic, err := r.Peek(format.MagicSize); err != nil || format.DetectFormat(magic) != format.FormatGDelta02 {
+		return nil, ErrFormat
+	}
+	header, err := format.ReadGDelta02Header(r)
+	if err != nil {
+		return nil, godelta.WithCategory(godelta.ErrCorrupt, err)
+	}
+	index, err := format.ReadChunkIndex(r, header.ChunkCount)
+	if err != nil {
+		return nil, godelta.WithCategory(godelta.ErrCorrupt, err)
+	}
+	files := make([]format.FileMetadata, 0, format.CapHint(header.FileCount))
+	for range header.FileCount {
+		metadata, err := format.ReadFileMetadata(r, header.Extended)
+		if err != nil {
+			return nil, godelta.WithCategory(godelta.ErrCorrupt, err)
+		}
+		files = append(files, metadata)
+	}
+	pos, err := src.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	dataStart := pos - int64(r.Buffered())
+
+	result := &Result{
+		InputPath:   opts.InputPath,
+		OutputPath:  opts.OutputPath,
+		CodecBefore: header.Codec.Method().String(),
+		LevelBefore: header.Level,
+		SizeBefore:  uint64(info.Size()),
+	}
+	target, err := newTarget(header, opts, result)
+	if err != nil {
+		return nil, err
+	}
+
+	percent := 0
+	if layout, err := parity.ReadLayout(src, info.Size()); err == nil {
+		percent = layout.Percent()
+	}
+
+	// Chunk data keeps its order. Chunks are read with their recorded
+	// size: refuse any said to lie past the end of the archive first.
+	end := uint64(max(parity.DataSize(src, info.Size())-dataStart, 0))
+	chunks := make([]format.ChunkInfo, 0, len(index))
+	for _, c := range index {
+		if c.Offset > end || c.CompressedSize > end-c.Offset {
+			return nil, fmt.Errorf("%w: %x of %d bytes at %d runs past the end of the archive", ErrCorruptChunk, c.Hash[:8], c.CompressedSize, c.Offset)
+		}
+		result.DataBefore += c.CompressedSize
+		chunks = append(chunks, c)
+	}
+	slices.SortFunc(chunks, func(x, y format.ChunkInfo) int { return cmp.Compare(x.Offset, y.Offset) })
+	result.Chunks = len(chunks)
+
+	size, err := rewrite(opts.OutputPath, src, dataStart, header, files, chunks, target, threads, percent, result)
+	if err == nil {
+		var v *verify.Result
+		v, err = verify.Verify(&verify.Options{InputPath: opts.OutputPath, VerifyData: true, MaxThreads: threads, Quiet: true}, nil)
+		if err == nil && !v.IsValid() {
+			err = errors.Join(v.Errors...)
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: %v", ErrRewriteCorrupt, err)
+		}
+	}
+	if err != nil {
+		os.Remove(opts.OutputPath)
+		return result, err
+	}
+	result.SizeAfter = uint64(size)
+	return result, nil
+}
+
+// sameFile reports whether a and b name the same file
+func sameFile(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	return err == nil && os.SameFile(ai, bi)
+}
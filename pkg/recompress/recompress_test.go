@@ -0,0 +1,144 @@
+// pkg/recompress/recompress_test.go
+package recompress_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/recompress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+func writeFiles(t *testing.T) (string, map[string]string) {
+	t.Helper()
+	input := t.TempDir()
+	files := map[string]string{}
+	for i := range 4 {
+		var sb strings.Builder
+		for j := range 3000 {
+			fmt.Fprintf(&sb, "line %d of file %d, shared tail\n", j%700, i%2)
+		}
+		files[fmt.Sprintf("f%d.txt", i)] = sb.String()
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(input, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return input, files
+}
+
+func TestRecompress(t *testing.T) {
+	input, files := writeFiles(t)
+	archive := filepath.Join(t.TempDir(), "backup.gdelta")
+	opts := &compress.Options{InputPath: input, OutputPath: archive, ChunkSize: 4 << 10, Level: 1, ChunkDictionary: true, Quiet: true}
+	if _, err := compress.Compress(opts, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		opts       recompress.Options
+		codec      string
+		level      int
+		dictionary bool
+	}{
+		{"level", recompress.Options{Level: 19}, "zstd", 19, true},
+		{"codec", recompress.Options{Codec: "lz4"}, "lz4", 5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.InputPath = archive
+			tt.opts.OutputPath = filepath.Join(t.TempDir(), "smaller.gdelta")
+			result, err := recompress.Recompress(&tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.CodecAfter != tt.codec || result.LevelAfter != tt.level || result.DictionaryDropped == tt.dictionary {
+				t.Errorf("unexpected result %+v", result)
+			}
+			info, err := os.Stat(tt.opts.OutputPath)
+			if err != nil || uint64(info.Size()) != result.SizeAfter {
+				t.Fatalf("archive is %v bytes, result says %d (%v)", info.Size(), result.SizeAfter, err)
+			}
+
+			v, err := verify.Verify(&verify.Options{InputPath: tt.opts.OutputPath, VerifyData: true}, nil)
+			if err != nil || !v.IsValid() {
+				t.Fatalf("recompressed archive: %v, %v", err, v.Errors)
+			}
+			if v.Codec != tt.codec || (v.DictSize > 0) != tt.dictionary {
+				t.Errorf("archive codec %s, dictionary %d bytes", v.Codec, v.DictSize)
+			}
+
+			output := t.TempDir()
+			if _, err := decompress.Decompress(&decompress.Options{InputPath: tt.opts.OutputPath, OutputPath: output, Quiet: true}, nil); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range files {
+				if got, err := os.ReadFile(filepath.Join(output, name)); err != nil || string(got) != content {
+					t.Errorf("%s not restored (%v)", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRecompressErrors(t *testing.T) {
+	input, _ := writeFiles(t)
+	archive := filepath.Join(t.TempDir(), "backup.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: input, OutputPath: archive, ChunkSize: 4 << 10, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	plain := filepath.Join(t.TempDir(), "plain.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: input, OutputPath: plain, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	// A chunk said to run past the end of the archive
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout, _ := format.Describe(bytes.NewReader(data), int64(len(data)), 1)
+	i := slices.IndexFunc(layout.Fields, func(f format.Field) bool { return f.Name == "chunk_index[0].comp_size" })
+	if i < 0 {
+		t.Fatal("no chunk_index[0].comp_size field")
+	}
+	binary.LittleEndian.PutUint64(data[layout.Fields[i].Offset:], 1<<62)
+	oversized := filepath.Join(t.TempDir(), "oversized.gdelta")
+	if err := os.WriteFile(oversized, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "out.gdelta")
+
+	tests := []struct {
+		name string
+		opts recompress.Options
+		want error
+	}{
+		{"same output", recompress.Options{InputPath: archive, OutputPath: archive}, recompress.ErrOutput},
+		{"not chunked", recompress.Options{InputPath: plain, OutputPath: out}, recompress.ErrFormat},
+		{"codec", recompress.Options{InputPath: archive, OutputPath: out, Codec: "store"}, recompress.ErrUnknownCodec},
+		{"level", recompress.Options{InputPath: archive, OutputPath: out, Level: 23}, recompress.ErrInvalidLevel},
+		{"oversized chunk", recompress.Options{InputPath: oversized, OutputPath: out}, recompress.ErrCorruptChunk},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := recompress.Recompress(&tt.opts); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+			if _, err := os.Stat(out); err == nil {
+				t.Error("output was written")
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// pkg/repair/errors.go
+package repair
+
+import "errors"
+
+var (
+	// ErrInputRequired is returned when input path is not specified
+	ErrInputRequired = errors.New("input path is required")
+
+	// ErrNoParity is returned for archives written without parity
+	ErrNoParity = errors.New("archive has no parity (compress with --parity to add it)")
+
+	// ErrBeyondRepair is returned when a stripe lost more blocks than its
+	// parity can rebuild
+	ErrBeyondRepair = errors.New("damage exceeds what the parity can rebuild")
+
+	// ErrDamagedStructure is returned by source repairs when more than the
+	// entry data is damaged (headers, footer, index)
+	ErrDamagedStructure = errors.New("archive structure is damaged, only entry data can be rebuilt from the source")
+
+	// ErrSourceIncomplete is returned when the source doesn't hold every
+	// damaged file, or holds it with another size
+	ErrSourceIncomplete = errors.New("source can't restore every damaged file")
+
+	// ErrSourceFormat is returned for source repairs of ZIP and XZ archives
+	ErrSourceFormat = errors.New("source repair supports GDELTA archives only")
+
+	// ErrSourcePacked is returned for source repairs of archives holding
+	// small-file packs
+	ErrSourcePacked = errors.New("source repair doesn't support archives with small-file packs")
+
+	// ErrSourceLinked is returned for source repairs of archives holding
+	// files deduplicated as links
+	ErrSourceLinked = errors.New("source repair doesn't support archives with deduplicated files")
+)
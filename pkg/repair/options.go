@@ -0,0 +1,47 @@
+// pkg/repair/options.go
+package repair
+
+import "runtime"
+
+// Options configures the repair operation
+type Options struct {
+	// InputPath is the archive to repair in place (required)
+	InputPath string
+
+	// SourcePath is the directory the archive was compressed from. When
+	// set, damaged entries (GDELTA01, GDELTA03) or chunks (GDELTA02) found
+	// by a data verification are compressed again from the files there and
+	// patched into the archive, instead of being rebuilt from parity.
+	// Source files must hold the content they had when archived: GDELTA02
+	// chunks are checked against their hash, entries only against their
+	// size.
+	SourcePath string
+
+	// MaxThreads is the number of files or chunks verified at once when
+	// looking for damage with SourcePath
+	// Default: runtime.NumCPU()
+	MaxThreads int
+
+	// DryRun locates the damage without rewriting anything
+	DryRun bool
+
+	// Verbose enables detailed logging
+	Verbose bool
+
+	// Quiet suppresses all output except errors
+	Quiet bool
+}
+
+// Validate checks if options are valid
+func (o *Options) Validate() error {
+	if o.InputPath == "" {
+		return ErrInputRequired
+	}
+	if o.MaxThreads <= 0 {
+		o.MaxThreads = runtime.NumCPU()
+	}
+	if o.Quiet {
+		o.Verbose = false
+	}
+	return nil
+}
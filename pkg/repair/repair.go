@@ -0,0 +1,85 @@
+// pkg/repair/repair.go
+package repair
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/creativeyann17/go-delta/internal/parity"
+)
+
+// Repair rebuilds the damaged blocks of an archive from its Reed-Solomon
+// parity (see compress.Options.ParityPercent) and writes them back in
+// place. Every block is compared against the checksums stored with the
+// parity, so damage anywhere in the archive, its parity included, is
+// found. The archive is checked again after the repair.
+//
+// With Options.SourcePath, damage found by a data verification is rebuilt
+// from the original files instead, so archives without parity can be
+// repaired too.
+func Repair(opts *Options) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.SourcePath != "" {
+		return repairFromSource(opts)
+	}
+
+	flag := os.O_RDWR
+	if opts.DryRun {
+		flag = os.O_RDONLY
+	}
+	f, err := os.OpenFile(opts.InputPath, flag, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat archive: %w", err)
+	}
+
+	result := &Result{ArchivePath: opts.InputPath, ArchiveSize: uint64(info.Size())}
+	report, err := parity.Check(f, info.Size())
+	if errors.Is(err, parity.ErrNoParity) {
+		return nil, ErrNoParity
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.BlockSize = report.Layout.BlockSize
+	result.DamagedBlocks = len(report.BadData)
+	result.DamagedParity = len(report.BadParity)
+	result.DamagedBytes = uint64(report.DamagedBytes())
+
+	if opts.Verbose {
+		for _, i := range report.BadData {
+			off := int64(i) * int64(report.Layout.BlockSize)
+			fmt.Printf("Damaged block %d (offset %d)\n", i, off)
+		}
+		if len(report.BadParity) > 0 {
+			fmt.Printf("Damaged parity blocks: %d\n", len(report.BadParity))
+		}
+	}
+
+	if !report.Repairable {
+		return result, ErrBeyondRepair
+	}
+	if !report.Damaged() || opts.DryRun {
+		return result, nil
+	}
+	if err := parity.Repair(f, report); err != nil {
+		return result, fmt.Errorf("repair: %w", err)
+	}
+
+	after, err := parity.Check(f, info.Size())
+	if err != nil {
+		return result, fmt.Errorf("check repaired archive: %w", err)
+	}
+	if after.Damaged() {
+		return result, fmt.Errorf("repair: %d blocks still damaged", len(after.BadData)+len(after.BadParity))
+	}
+	result.Repaired = true
+	return result, nil
+}
@@ -0,0 +1,197 @@
+// pkg/repair/repair_test.go
+package repair_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/repair"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// TestRepair damages archives of every GDELTA format written with parity,
+// repairs them and checks that they verify and extract again
+func TestRepair(t *testing.T) {
+	sourceDir := t.TempDir()
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		content := make([]byte, 20_000)
+		rng.Read(content[:10_000])
+		if err := os.WriteFile(filepath.Join(sourceDir, fmt.Sprintf("f%02d.bin", i)), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, opts := range map[string]compress.Options{
+		"GDELTA01": {},
+		"GDELTA02": {ChunkSize: 4096},
+		"GDELTA03": {UseDictionary: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+			opts.InputPath = sourceDir
+			opts.OutputPath = archivePath
+			opts.ParityPercent = 10
+			opts.Quiet = true
+			result, err := compress.Compress(&opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ParitySize == 0 {
+				t.Fatal("no parity written")
+			}
+
+			vr, err := verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true, Quiet: true}, nil)
+			if err != nil || !vr.IsValid() || vr.Parity == nil || !vr.Parity.Checked {
+				t.Fatalf("intact archive: %v, %v, parity %+v", err, vr.Errors, vr.Parity)
+			}
+
+			// Wipe a run of bytes in the middle of the archive
+			data, err := os.ReadFile(archivePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			copy(data[len(data)/3:], make([]byte, 3000))
+			if err := os.WriteFile(archivePath, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			vr, err = verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true, Quiet: true}, nil)
+			if err == nil && vr.IsValid() {
+				t.Fatal("damaged archive verified as valid")
+			}
+
+			rr, err := repair.Repair(&repair.Options{InputPath: archivePath, DryRun: true})
+			if err != nil || !rr.Damaged() || rr.Repaired {
+				t.Fatalf("dry run: %v, %+v", err, rr)
+			}
+			rr, err = repair.Repair(&repair.Options{InputPath: archivePath})
+			if err != nil || !rr.Repaired {
+				t.Fatalf("repair: %v, %+v", err, rr)
+			}
+
+			vr, err = verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true, Quiet: true}, nil)
+			if err != nil || !vr.IsValid() {
+				t.Fatalf("repaired archive: %v, %v", err, vr.Errors)
+			}
+
+			outDir := t.TempDir()
+			if _, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: outDir, Quiet: true}, nil); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 20; i++ {
+				name := fmt.Sprintf("f%02d.bin", i)
+				want, _ := os.ReadFile(filepath.Join(sourceDir, name))
+				got, err := os.ReadFile(filepath.Join(outDir, name))
+				if err != nil || !bytes.Equal(got, want) {
+					t.Fatalf("%s differs after repair (%v)", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRepairNoParity(t *testing.T) {
+	sourceDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: sourceDir, OutputPath: archivePath, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repair.Repair(&repair.Options{InputPath: archivePath}); !errors.Is(err, repair.ErrNoParity) {
+		t.Fatalf("got %v, want ErrNoParity", err)
+	}
+}
+
+// TestRepairFromSource damages two files of archives without parity and
+// rebuilds them from the source directory, first with one source missing
+func TestRepairFromSource(t *testing.T) {
+	sourceDir := t.TempDir()
+	partialDir := t.TempDir()
+	contents := make([][]byte, 20)
+	rng := rand.New(rand.NewSource(2))
+	for i := range contents {
+		contents[i] = make([]byte, 20_000)
+		rng.Read(contents[i][:10_000])
+		name := fmt.Sprintf("f%02d.bin", i)
+		if err := os.WriteFile(filepath.Join(sourceDir, name), contents[i], 0644); err != nil {
+			t.Fatal(err)
+		}
+		if i != 11 {
+			if err := os.WriteFile(filepath.Join(partialDir, name), contents[i], 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	for name, opts := range map[string]compress.Options{
+		"GDELTA01": {},
+		"GDELTA02": {ChunkSize: 4096},
+		"GDELTA03": {UseDictionary: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+			opts.InputPath = sourceDir
+			opts.OutputPath = archivePath
+			opts.Quiet = true
+			if _, err := compress.Compress(&opts, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			// Random bytes are stored as they are: flip one inside the
+			// data of f03 and of f11
+			data, err := os.ReadFile(archivePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, i := range []int{3, 11} {
+				at := bytes.Index(data, contents[i][5000:5064])
+				if at < 0 {
+					t.Fatalf("f%02d data not found in archive", i)
+				}
+				data[at+10] ^= 0xff
+			}
+			if err := os.WriteFile(archivePath, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			rr, err := repair.Repair(&repair.Options{InputPath: archivePath, SourcePath: sourceDir, DryRun: true})
+			if err != nil || rr.DamagedEntries != 2 || rr.Repaired {
+				t.Fatalf("dry run: %v, %+v", err, rr)
+			}
+
+			rr, err = repair.Repair(&repair.Options{InputPath: archivePath, SourcePath: partialDir})
+			if !errors.Is(err, repair.ErrSourceIncomplete) || len(rr.Missing) != 1 || rr.Missing[0] != "f11.bin" {
+				t.Fatalf("partial source: %v, %+v", err, rr)
+			}
+			rr, err = repair.Repair(&repair.Options{InputPath: archivePath, SourcePath: sourceDir})
+			if err != nil || !rr.Repaired || len(rr.Rebuilt) != 1 {
+				t.Fatalf("repair: %v, %+v", err, rr)
+			}
+
+			vr, err := verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true, Quiet: true}, nil)
+			if err != nil || !vr.IsValid() {
+				t.Fatalf("repaired archive: %v, %v", err, vr.Errors)
+			}
+			outDir := t.TempDir()
+			if _, err := decompress.Decompress(&decompress.Options{InputPath: archivePath, OutputPath: outDir, Quiet: true}, nil); err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range contents {
+				got, err := os.ReadFile(filepath.Join(outDir, fmt.Sprintf("f%02d.bin", i)))
+				if err != nil || !bytes.Equal(got, want) {
+					t.Fatalf("f%02d.bin differs after repair (%v)", i, err)
+				}
+			}
+		})
+	}
+}
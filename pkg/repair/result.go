@@ -0,0 +1,76 @@
+// pkg/repair/result.go
+package repair
+
+import (
+	"fmt"
+
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// Result describes a repair run
+type Result struct {
+	ArchivePath string
+	ArchiveSize uint64 // Size of the archive, parity included
+
+	BlockSize     int    // Size of the blocks the parity protects
+	DamagedBlocks int    // Archive blocks that didn't match their checksum
+	DamagedParity int    // Parity blocks that didn't match their checksum
+	DamagedBytes  uint64 // Archive bytes in damaged blocks
+
+	// Source repair (Options.SourcePath)
+	Source         string
+	DamagedEntries int      // Entries (GDELTA01, GDELTA03) or chunks (GDELTA02) that failed verification
+	Rebuilt        []string // Files whose damaged data was compressed again from the source
+	Missing        []string // Files with damaged data the source couldn't provide
+
+	// Repaired is true when all the damage was rebuilt and written back
+	Repaired bool
+}
+
+// Damaged reports whether any damage was found
+func (r *Result) Damaged() bool {
+	return r.DamagedBlocks > 0 || r.DamagedParity > 0 || r.DamagedEntries > 0
+}
+
+// Summary returns a human-readable summary of the repair
+func (r *Result) Summary() string {
+	s := fmt.Sprintf("Archive: %s\n", r.ArchivePath)
+	if r.Source != "" {
+		return s + r.sourceSummary()
+	}
+	s += fmt.Sprintf("Size:    %s (%s blocks)\n", godelta.FormatSize(r.ArchiveSize), godelta.FormatSize(uint64(r.BlockSize)))
+	switch {
+	case !r.Damaged():
+		s += "Status:  intact, nothing to repair\n"
+	case r.Repaired:
+		s += fmt.Sprintf("Status:  REPAIRED %d archive blocks (%s) and %d parity blocks\n",
+			r.DamagedBlocks, godelta.FormatSize(r.DamagedBytes), r.DamagedParity)
+	default:
+		s += fmt.Sprintf("Status:  DAMAGED %d archive blocks (%s) and %d parity blocks\n",
+			r.DamagedBlocks, godelta.FormatSize(r.DamagedBytes), r.DamagedParity)
+	}
+	return s
+}
+
+// sourceSummary is the Summary of a source repair
+func (r *Result) sourceSummary() string {
+	s := fmt.Sprintf("Source:  %s\n", r.Source)
+	switch {
+	case r.DamagedEntries == 0:
+		s += "Status:  intact, nothing to repair\n"
+	case r.Repaired:
+		s += fmt.Sprintf("Status:  REPAIRED %d files from the source (%d damaged)\n", len(r.Rebuilt), r.DamagedEntries)
+	case len(r.Rebuilt) == 0 && len(r.Missing) == 0:
+		s += fmt.Sprintf("Status:  DAMAGED %d entries\n", r.DamagedEntries)
+	default:
+		s += fmt.Sprintf("Status:  PARTIAL %d files rebuilt, %d missing from the source\n", len(r.Rebuilt), len(r.Missing))
+		for i, path := range r.Missing {
+			if i >= 10 {
+				s += fmt.Sprintf("  ... and %d more\n", len(r.Missing)-10)
+				break
+			}
+			s += fmt.Sprintf("  - %s\n", path)
+		}
+	}
+	return s
+}
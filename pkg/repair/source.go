@@ -0,0 +1,526 @@
+// pkg/repair/source.go
+package repair
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/creativeyann17/go-delta/internal/chunker"
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// defaultLevel is used where the archive doesn't record the level its
+// entries were compressed at; compress uses the same default
+const defaultLevel = 5
+
+// repairFromSource verifies the archive's data, then recompresses the
+// damaged entries (GDELTA01, GDELTA03) or chunks (GDELTA02) from the files
+// under opts.SourcePath and patches them into the archive
+func repairFromSource(opts *Options) (*Result, error) {
+	v, err := verify.Verify(&verify.Options{InputPath: opts.InputPath, VerifyData: true, MaxThreads: opts.MaxThreads, Quiet: true}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !v.HeaderValid || !v.FooterValid || !v.StructureValid || v.Recovery != nil {
+		return nil, ErrDamagedStructure
+	}
+
+	result := &Result{ArchivePath: opts.InputPath, ArchiveSize: v.ArchiveSize, Source: opts.SourcePath}
+	damaged := make(map[int]bool)
+	if v.Format == verify.FormatGDelta02 {
+		result.DamagedEntries = len(v.DamagedChunks)
+	} else {
+		for i, file := range v.Files {
+			if file.Error != nil {
+				damaged[i] = true
+			}
+		}
+		result.DamagedEntries = len(damaged)
+	}
+	if result.DamagedEntries == 0 || opts.DryRun {
+		return result, nil
+	}
+
+	f, err := os.OpenFile(opts.InputPath, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+
+	// Parity covers the old bytes; it's written again over the new ones
+	layout, err := parity.ReadLayout(f, int64(v.ArchiveSize))
+	hasParity := err == nil
+	size := int64(v.ArchiveSize)
+	if hasParity {
+		size = layout.DataSize
+	}
+
+	switch v.Format {
+	case verify.FormatGDelta01, verify.FormatGDelta03:
+		size, err = rewriteEntries(f, v, size, damaged, opts, result)
+	case verify.FormatGDelta02:
+		size, err = rewriteChunks(f, v.DamagedChunks, size, opts, result)
+	default:
+		return nil, fmt.Errorf("%w (got %s)", ErrSourceFormat, v.Format)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if err := f.Truncate(size); err != nil {
+		return result, fmt.Errorf("truncate archive: %w", err)
+	}
+	if hasParity {
+		if _, err := parity.Write(f, size, layout.Percent()); err != nil {
+			return result, fmt.Errorf("rewrite parity: %w", err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return result, err
+	}
+	if info, err := f.Stat(); err == nil {
+		result.ArchiveSize = uint64(info.Size())
+	}
+
+	if len(result.Missing) > 0 {
+		return result, fmt.Errorf("%w: %d files", ErrSourceIncomplete, len(result.Missing))
+	}
+	result.Repaired = true
+	return result, nil
+}
+
+// sourceFile opens the source of an archive entry, checking that it still
+// has the size recorded in the archive
+func sourceFile(opts *Options, relPath string, size uint64) (*os.File, error) {
+	src, err := os.Open(filepath.Join(opts.SourcePath, filepath.FromSlash(relPath)))
+	if err != nil {
+		return nil, err
+	}
+	info, err := src.Stat()
+	if err == nil && uint64(info.Size()) != size {
+		err = fmt.Errorf("source has %d bytes, archive entry %d", info.Size(), size)
+	}
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+	return src, nil
+}
+
+// codecLevel returns the level to compress with for a codec
+func codecLevel(c format.Codec, level int) int {
+	lo, hi := c.LevelRange()
+	if hi == 0 {
+		return 0
+	}
+	if level == 0 {
+		level = defaultLevel
+	}
+	return min(max(level, lo), hi)
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// entryArchive is a GDELTA01 or GDELTA03 archive being rewritten
+type entryArchive struct {
+	f          *os.File
+	gdelta03   bool
+	entries    []*format.FileEntry
+	firstEntry int64         // Offset of the first entry header
+	encoder    *zstd.Encoder // GDELTA03 dictionary encoder
+}
+
+// entryStart returns the offset of entry i's header: entries are contiguous
+func (a *entryArchive) entryStart(i int) int64 {
+	if i == 0 {
+		return a.firstEntry
+	}
+	prev := a.entries[i-1]
+	return int64(prev.DataOffset + prev.CompressedSize)
+}
+
+// openEntries lists the entries of a GDELTA01 or GDELTA03 archive whose data
+// ends at size
+func openEntries(f *os.File, v *verify.Result, size int64) (*entryArchive, error) {
+	a := &entryArchive{f: f, gdelta03: v.Format == verify.FormatGDelta03}
+	var scan *format.ScanResult
+	var err error
+	if a.gdelta03 {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		var header format.GDelta03Header
+		if header, err = format.ReadGDelta03Header(f); err != nil {
+			return nil, err
+		}
+		dictionary := make([]byte, header.DictSize)
+		if _, err := f.ReadAt(dictionary, header.DictOffset); err != nil {
+			return nil, fmt.Errorf("read dictionary: %w", err)
+		}
+		encOpts := []zstd.EOption{zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(defaultLevel))}
+		if len(dictionary) > 0 {
+			encOpts = append(encOpts, zstd.WithEncoderDict(dictionary))
+		}
+		if a.encoder, err = zstd.NewWriter(nil, encOpts...); err != nil {
+			return nil, err
+		}
+		a.firstEntry = header.DictOffset + int64(header.DictSize)
+		a.entries, scan, err = format.ScanGDelta03(f, size)
+	} else {
+		a.firstEntry = format.MagicSize + 4
+		a.entries, scan, err = format.ListGDelta01(f, size)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list entries: %w", err)
+	}
+	if slices.ContainsFunc(a.entries, func(e *format.FileEntry) bool { return e.Pack }) {
+		return nil, ErrSourcePacked
+	}
+	if slices.ContainsFunc(a.entries, func(e *format.FileEntry) bool { return e.Link }) {
+		return nil, ErrSourceLinked
+	}
+	if scan.Damaged() || len(a.entries) != len(v.Files) {
+		return nil, ErrDamagedStructure
+	}
+	for i, e := range a.entries {
+		if e.Path != v.Files[i].Path {
+			return nil, ErrDamagedStructure
+		}
+	}
+	return a, nil
+}
+
+// rewriteEntries rewrites the archive from its first damaged entry on:
+// damaged entries are compressed again from the source, the others copied
+// as they are. The new tail is staged in a temp file next to the archive,
+// then written over the old one. It returns the new size of the archive.
+func rewriteEntries(f *os.File, v *verify.Result, size int64, damaged map[int]bool, opts *Options, result *Result) (int64, error) {
+	a, err := openEntries(f, v, size)
+	if err != nil {
+		return 0, err
+	}
+	if a.encoder != nil {
+		defer a.encoder.Close()
+	}
+
+	first := len(a.entries)
+	for i := range damaged {
+		first = min(first, i)
+	}
+	start := a.entryStart(first)
+
+	tmp, err := os.CreateTemp(filepath.Dir(opts.InputPath), ".godelta-repair-*")
+	if err != nil {
+		return 0, fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	tail := &countingWriter{w: tmp}
+
+	index := make([]*format.FileEntry, 0, len(a.entries))
+	index = append(index, a.entries[:first]...)
+	for i := first; i < len(a.entries); i++ {
+		e := a.entries[i]
+		pos := start + tail.n
+		if damaged[i] {
+			src, err := sourceFile(opts, e.Path, e.OriginalSize)
+			if err == nil {
+				rebuilt, err := a.writeFromSource(tmp, tail, i, src, pos)
+				src.Close()
+				if err != nil {
+					return 0, fmt.Errorf("%s: %w", e.Path, err)
+				}
+				result.Rebuilt = append(result.Rebuilt, e.Path)
+				index = append(index, rebuilt)
+				if opts.Verbose {
+					fmt.Printf("Rebuilt %s\n", e.Path)
+				}
+				continue
+			}
+			// Keep the damaged entry; the rest of the archive is still repaired
+			result.Missing = append(result.Missing, e.Path)
+			if opts.Verbose {
+				fmt.Printf("Can't rebuild %s: %v\n", e.Path, err)
+			}
+		}
+		moved, err := a.copyEntry(tail, i, pos)
+		if err != nil {
+			return 0, err
+		}
+		index = append(index, moved)
+	}
+
+	if a.gdelta03 {
+		err = format.WriteArchiveFooter03(tail)
+	} else if err = format.WriteArchiveFooter(tail); err == nil {
+		err = format.WriteGDelta01Index(tail, start+tail.n, index)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := io.Copy(io.NewOffsetWriter(f, start), tmp); err != nil {
+		return 0, fmt.Errorf("write repaired entries: %w", err)
+	}
+	return start + tail.n, nil
+}
+
+// copyEntry copies entry i, header and data, to pos in the new tail
+func (a *entryArchive) copyEntry(tail *countingWriter, i int, pos int64) (*format.FileEntry, error) {
+	e := a.entries[i]
+	moved := *e
+	if a.gdelta03 {
+		// No offsets in GDELTA03 headers: copy the entry as it is
+		from := a.entryStart(i)
+		if _, err := io.Copy(tail, io.NewSectionReader(a.f, from, int64(e.DataOffset+e.CompressedSize)-from)); err != nil {
+			return nil, fmt.Errorf("copy %s: %w", e.Path, err)
+		}
+		moved.DataOffset = uint64(pos) + e.DataOffset - uint64(from)
+		return &moved, nil
+	}
+
+	moved.DataOffset = uint64(pos) + format.FileEntryHeaderSize + uint64(len(e.Path))
+	if _, err := tail.Write(format.AppendFileEntry(nil, &moved)); err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(tail, io.NewSectionReader(a.f, int64(e.DataOffset), int64(e.CompressedSize))); err != nil {
+		return nil, fmt.Errorf("copy %s: %w", e.Path, err)
+	}
+	return &moved, nil
+}
+
+// writeFromSource writes entry i at pos in the new tail, with its data
+// compressed again from src
+func (a *entryArchive) writeFromSource(tmp *os.File, tail *countingWriter, i int, src io.Reader, pos int64) (*format.FileEntry, error) {
+	e := a.entries[i]
+
+	// The header goes first with the compressed size patched in afterwards;
+	// GDELTA03 keeps the original header and its extensions
+	var header []byte
+	if a.gdelta03 {
+		from := a.entryStart(i)
+		header = make([]byte, int64(e.DataOffset)-from)
+		if _, err := a.f.ReadAt(header, from); err != nil {
+			return nil, fmt.Errorf("read entry header: %w", err)
+		}
+	} else {
+		header = format.AppendFileEntry(nil, e)
+	}
+	headerAt := tail.n
+	if _, err := tail.Write(header); err != nil {
+		return nil, err
+	}
+
+	dataStart := tail.n
+	if err := a.encode(tail, src, e.Method); err != nil {
+		return nil, err
+	}
+
+	rebuilt := *e
+	rebuilt.CompressedSize = uint64(tail.n - dataStart)
+	rebuilt.DataOffset = uint64(pos) + uint64(len(header))
+	if a.gdelta03 {
+		binary.LittleEndian.PutUint64(header[2+len(e.Path)+8:], rebuilt.CompressedSize)
+	} else {
+		header = format.AppendFileEntry(header[:0], &rebuilt)
+	}
+	if _, err := tmp.WriteAt(header, headerAt); err != nil {
+		return nil, err
+	}
+	return &rebuilt, nil
+}
+
+// encode compresses src with the entry's method: GDELTA03 zstd entries use
+// the archive dictionary
+func (a *entryArchive) encode(w io.Writer, src io.Reader, method format.Method) error {
+	if a.encoder != nil && method == format.MethodZstd {
+		a.encoder.Reset(w)
+		if _, err := io.Copy(a.encoder, src); err != nil {
+			return err
+		}
+		return a.encoder.Close()
+	}
+	codec, ok := format.LookupCodec(method)
+	if !ok {
+		return fmt.Errorf("unknown method %s", method)
+	}
+	cw, err := codec.NewWriter(w, codecLevel(codec, 0))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(cw, src); err != nil {
+		cw.Close()
+		return err
+	}
+	return cw.Close()
+}
+
+// rewriteChunks compresses the damaged chunks of a GDELTA02 archive again
+// from the source files that contain them. A chunk is only rewritten when
+// the source yields a chunk with its hash, so changed sources can't slip in.
+// Chunks that fit their old slot are written there; larger ones are
+// appended after the chunk data. Their index entries are updated in place.
+// It returns the new size of the archive.
+func rewriteChunks(f *os.File, damagedChunks [][32]byte, size int64, opts *Options, result *Result) (int64, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	header, err := format.ReadGDelta02Header(f)
+	if err != nil {
+		return 0, err
+	}
+	indexStart, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	index, err := format.ReadBounded(f, 56*int64(header.ChunkCount))
+	if err != nil {
+		return 0, fmt.Errorf("read chunk index: %w", err)
+	}
+	files := make([]format.FileMetadata, 0, format.CapHint(header.FileCount))
+	for range header.FileCount {
+		metadata, err := format.ReadFileMetadata(f, header.Extended)
+		if err != nil {
+			return 0, err
+		}
+		files = append(files, metadata)
+	}
+	chunkDataStart, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	// Index slot of every damaged chunk
+	slots := make(map[[32]byte]int, len(damagedChunks))
+	for _, hash := range damagedChunks {
+		slots[hash] = -1
+	}
+	for i := 0; i < len(index); i += 56 {
+		hash := [32]byte(index[i : i+32])
+		if _, ok := slots[hash]; ok {
+			slots[hash] = i
+		}
+	}
+
+	codec, ok := format.LookupCodec(header.Codec.Method())
+	if !ok {
+		return 0, fmt.Errorf("unknown codec %s", header.Codec)
+	}
+	level := codecLevel(codec, header.Level)
+	encode := func(data []byte) ([]byte, error) { return format.EncodeBlock(codec, nil, data, level) }
+	if dict := header.ChunkDictionary(); len(dict) > 0 {
+		// Chunks of the archive share its dictionary
+		enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)), zstd.WithEncoderDict(dict))
+		if err != nil {
+			return 0, fmt.Errorf("create zstd encoder: %w", err)
+		}
+		defer enc.Close()
+		encode = func(data []byte) ([]byte, error) { return enc.EncodeAll(data, nil), nil }
+	}
+	end := size - int64(len(format.ArchiveFooter02))
+	split := chunker.NewBounded(header.ChunkSize, header.Bounds.MinSize, header.Bounds.MaxSize, header.Bounds.Normalization)
+	if header.Bounds.Fixed {
+		split = chunker.NewFixed(header.ChunkSize)
+	}
+
+	for _, file := range files {
+		needed := false
+		for _, hash := range file.ChunkHashes {
+			if slot, ok := slots[hash]; ok && slot >= 0 {
+				needed = true
+				break
+			}
+		}
+		if !needed {
+			continue
+		}
+
+		src, err := sourceFile(opts, file.RelPath, file.OrigSize)
+		if err != nil {
+			if opts.Verbose {
+				fmt.Printf("Can't read %s: %v\n", file.RelPath, err)
+			}
+			continue
+		}
+		rebuilt := false
+		err = split.SplitWithCallback(src, func(chunk chunker.Chunk) error {
+			slot, ok := slots[chunk.Hash]
+			if !ok || slot < 0 {
+				return nil
+			}
+			data, err := encode(chunk.Data)
+			if err != nil {
+				return err
+			}
+			// Kept uncompressed when compression doesn't shrink it, as
+			// compress does
+			stored := len(data) >= len(chunk.Data)
+			if stored {
+				data = chunk.Data
+			}
+			entry := index[slot:]
+			offset := binary.LittleEndian.Uint64(entry[32:])
+			if room, _ := format.ParseChunkSizeField(binary.LittleEndian.Uint64(entry[40:])); uint64(len(data)) > room {
+				offset = uint64(end - chunkDataStart)
+				end += int64(len(data))
+			}
+			if _, err := f.WriteAt(data, chunkDataStart+int64(offset)); err != nil {
+				return fmt.Errorf("write chunk: %w", err)
+			}
+			binary.LittleEndian.PutUint64(entry[32:], offset)
+			binary.LittleEndian.PutUint64(entry[40:], format.ChunkSizeField(format.ChunkInfo{CompressedSize: uint64(len(data)), Stored: stored}))
+			if _, err := f.WriteAt(entry[32:48], indexStart+int64(slot)+32); err != nil {
+				return fmt.Errorf("update chunk index: %w", err)
+			}
+			slots[chunk.Hash] = -1
+			rebuilt = true
+			return nil
+		})
+		src.Close()
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", file.RelPath, err)
+		}
+		if rebuilt {
+			result.Rebuilt = append(result.Rebuilt, file.RelPath)
+			if opts.Verbose {
+				fmt.Printf("Rebuilt chunks of %s\n", file.RelPath)
+			}
+		}
+	}
+
+	// Files whose chunks the source didn't provide
+	for _, file := range files {
+		for _, hash := range file.ChunkHashes {
+			if slot, ok := slots[hash]; ok && slot >= 0 {
+				result.Missing = append(result.Missing, file.RelPath)
+				break
+			}
+		}
+	}
+
+	if _, err := f.WriteAt([]byte(format.ArchiveFooter02), end); err != nil {
+		return 0, fmt.Errorf("write footer: %w", err)
+	}
+	return end + int64(len(format.ArchiveFooter02)), nil
+}
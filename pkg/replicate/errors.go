@@ -0,0 +1,39 @@
+// pkg/replicate/errors.go
+package replicate
+
+import "github.com/creativeyann17/go-delta/pkg/godelta"
+
+// Every error matches a godelta error category with errors.Is
+var (
+	// ErrSourceRequired is returned when no source archive is given
+	ErrSourceRequired = godelta.NewError(godelta.ErrUsage, "source archive is required")
+
+	// ErrDestRequired is returned when no destination directory is given
+	ErrDestRequired = godelta.NewError(godelta.ErrUsage, "destination directory is required")
+
+	// ErrDestNotDir is returned when the destination isn't a directory
+	ErrDestNotDir = godelta.NewError(godelta.ErrUsage, "destination must be an existing directory")
+
+	// ErrSourceFormat is returned for sources other than GDELTA02 archives,
+	// which have no chunks to share
+	ErrSourceFormat = godelta.NewError(godelta.ErrUnsupported, "dedup-aware copies need a GDELTA02 source archive")
+
+	// ErrOutputExists is returned when the copy would replace a file
+	// without Overwrite
+	ErrOutputExists = godelta.NewError(godelta.ErrExists, "output archive already exists (use --overwrite)")
+
+	// ErrCopyCorrupt is returned when the copy fails its data verification
+	ErrCopyCorrupt = godelta.NewError(godelta.ErrCorrupt, "copy failed verification")
+
+	// ErrInvalidRemote is returned for a push destination that isn't
+	// "[user@]host:dir", or whose host starts with "-"
+	ErrInvalidRemote = godelta.NewError(godelta.ErrUsage, "remote must be [user@]host:dir")
+
+	// ErrInvalidName is returned by a receiver offered a name that isn't a
+	// plain file name
+	ErrInvalidName = godelta.NewError(godelta.ErrUsage, "archive name must be a file name")
+
+	// ErrProtocolVersion is returned when the sender and the receiver of a
+	// push don't speak the same version
+	ErrProtocolVersion = godelta.NewError(godelta.ErrUnsupported, "push protocol version mismatch, update godelta on both sides")
+)
@@ -0,0 +1,55 @@
+// pkg/replicate/options.go
+package replicate
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Options configures a copy
+type Options struct {
+	// SourcePath is the GDELTA02 archive to copy (required). Only its
+	// header, indexes and the chunks missing at the destination are read,
+	// so it can sit on a slow link (NFS, sshfs, ...).
+	SourcePath string
+
+	// DestDir is the destination repository: a directory of GDELTA02
+	// archives whose chunks are reused (required)
+	DestDir string
+
+	// OutputPath is the copy
+	// Default: DestDir/<name of SourcePath>
+	OutputPath string
+
+	// Overwrite replaces an existing OutputPath
+	Overwrite bool
+
+	// Verify checks the data of the copy before it replaces OutputPath
+	Verify bool
+
+	// Verbose enables detailed logging
+	Verbose bool
+
+	// Quiet suppresses all output except errors
+	Quiet bool
+}
+
+// Validate checks if options are valid and fills in defaults
+func (o *Options) Validate() error {
+	if o.SourcePath == "" {
+		return ErrSourceRequired
+	}
+	if o.DestDir == "" {
+		return ErrDestRequired
+	}
+	if info, err := os.Stat(o.DestDir); err != nil || !info.IsDir() {
+		return ErrDestNotDir
+	}
+	if o.OutputPath == "" {
+		o.OutputPath = filepath.Join(o.DestDir, filepath.Base(o.SourcePath))
+	}
+	if o.Quiet {
+		o.Verbose = false
+	}
+	return nil
+}
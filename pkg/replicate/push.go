@@ -0,0 +1,351 @@
+// pkg/replicate/push.go
+package replicate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/mmap"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// protocolVersion is bumped when the push messages change
+const protocolVersion = 1
+
+// A push is a conversation between the sender and a receiver started on
+// the destination over SSH ('godelta receive <dir>'), in gob messages:
+//
+//	sender   -> pushOffer:  the archive up to its chunk data, and options
+//	receiver -> pushWant:   the chunks none of its archives holds
+//	sender   -> pushChunk:  one per wanted chunk, in that order
+//	receiver -> pushDone:   the result, once the copy is in place
+type pushOffer struct {
+	Version       int
+	Name          string // file name of the copy in the destination directory
+	Structure     []byte // header, chunk index and file entries
+	ParityPercent int
+	Manifest      []byte // .sha256 manifest, nil without one
+	Overwrite     bool
+	Verify        bool
+}
+
+type pushWant struct {
+	Missing  [][32]byte // in data order
+	Err      string
+	Category string
+}
+
+type pushChunk struct {
+	Data []byte
+}
+
+type pushDone struct {
+	Result   *Result
+	Err      string
+	Category string
+}
+
+// PushOptions configures a push
+type PushOptions struct {
+	// SourcePath is the GDELTA02 archive to push (required)
+	SourcePath string
+
+	// Remote is the destination repository, "[user@]host:dir" (required)
+	Remote string
+
+	// Name is the file name of the copy in the destination directory
+	// Default: the name of SourcePath
+	Name string
+
+	// Overwrite replaces an existing copy
+	Overwrite bool
+
+	// Verify checks the data of the copy before it is put in place
+	Verify bool
+
+	// SSHCommand runs the receiver, with its arguments (e.g. "ssh -p 2222")
+	// Default: "ssh"
+	SSHCommand string
+
+	// RemoteCommand is the godelta binary on the destination
+	// Default: "godelta"
+	RemoteCommand string
+}
+
+// Validate checks if options are valid and fills in defaults
+func (o *PushOptions) Validate() error {
+	if o.SourcePath == "" {
+		return ErrSourceRequired
+	}
+	if _, _, err := splitRemote(o.Remote); err != nil {
+		return err
+	}
+	if o.Name == "" {
+		o.Name = filepath.Base(o.SourcePath)
+	}
+	if o.SSHCommand == "" {
+		o.SSHCommand = "ssh"
+	}
+	if o.RemoteCommand == "" {
+		o.RemoteCommand = "godelta"
+	}
+	return nil
+}
+
+// splitRemote splits "[user@]host:dir". A host starting with "-" is
+// refused: ssh would take it as an option.
+func splitRemote(remote string) (host, dir string, err error) {
+	host, dir, ok := strings.Cut(remote, ":")
+	if !ok || host == "" || dir == "" || strings.HasPrefix(host, "-") {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidRemote, remote)
+	}
+	return host, dir, nil
+}
+
+// Push copies a GDELTA02 archive into a directory of archives on another
+// machine, like Copy: a receiver started there over SSH reports the
+// chunks none of its archives holds, and only those cross the network.
+func Push(opts *PushOptions) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	host, dir, _ := splitRemote(opts.Remote)
+	args := strings.Fields(opts.SSHCommand)
+	args = append(args, host, opts.RemoteCommand, "receive", shellQuote(dir))
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", args[0], err)
+	}
+
+	result, err := PushTo(struct {
+		io.Reader
+		io.Writer
+	}{stdout, stdin}, opts)
+	stdin.Close()
+	if waitErr := cmd.Wait(); err == nil && waitErr != nil {
+		err = fmt.Errorf("%s: %w", args[0], waitErr)
+	}
+	if result != nil {
+		result.OutputPath = host + ":" + result.OutputPath
+	}
+	return result, err
+}
+
+// PushTo runs the sender side of a push over conn, connected to Receive
+func PushTo(conn io.ReadWriter, opts *PushOptions) (*Result, error) {
+	src, err := os.Open(opts.SourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("open source: %w", err)
+	}
+	defer src.Close()
+	source, err := readChunked(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opts.SourcePath, err)
+	}
+
+	offer := pushOffer{
+		Version:   protocolVersion,
+		Name:      opts.Name,
+		Structure: make([]byte, source.dataStart),
+		Overwrite: opts.Overwrite,
+		Verify:    opts.Verify,
+	}
+	if _, err := src.ReadAt(offer.Structure, 0); err != nil {
+		return nil, fmt.Errorf("read source: %w", err)
+	}
+	if info, err := src.Stat(); err == nil {
+		if layout, err := parity.ReadLayout(src, info.Size()); err == nil {
+			offer.ParityPercent = layout.Percent()
+		}
+	}
+	if offer.Manifest, err = os.ReadFile(opts.SourcePath + ".sha256"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	w := bufio.NewWriterSize(conn, 1<<20)
+	enc, dec := gob.NewEncoder(w), gob.NewDecoder(conn)
+	if err := enc.Encode(offer); err != nil {
+		return nil, fmt.Errorf("send offer: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return nil, fmt.Errorf("send offer: %w", err)
+	}
+	var want pushWant
+	if err := dec.Decode(&want); err != nil {
+		return nil, fmt.Errorf("read reply: %w", err)
+	}
+	if want.Err != "" {
+		return nil, remoteError(want.Err, want.Category)
+	}
+
+	var buf []byte
+	for _, hash := range want.Missing {
+		info, ok := source.index[hash]
+		if !ok {
+			return nil, fmt.Errorf("receiver wants unknown chunk %x", hash[:8])
+		}
+		data, err := mmap.Read(src, source.dataStart+int64(info.Offset), int64(info.CompressedSize), &buf)
+		if err != nil {
+			return nil, fmt.Errorf("read chunk %x: %w", hash[:8], err)
+		}
+		if err := enc.Encode(pushChunk{Data: data}); err != nil {
+			return nil, fmt.Errorf("send chunk: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return nil, fmt.Errorf("send chunks: %w", err)
+	}
+
+	var done pushDone
+	if err := dec.Decode(&done); err != nil {
+		return nil, fmt.Errorf("read result: %w", err)
+	}
+	if done.Result != nil {
+		done.Result.SourcePath = opts.SourcePath
+	}
+	if done.Err != "" {
+		return done.Result, remoteError(done.Err, done.Category)
+	}
+	return done.Result, nil
+}
+
+// Receive runs the receiving side of a push over conn, writing the copy
+// into destDir
+func Receive(conn io.ReadWriter, destDir string) error {
+	w := bufio.NewWriter(conn)
+	enc, dec := gob.NewEncoder(w), gob.NewDecoder(conn)
+	reply := func(msg any) error {
+		if err := enc.Encode(msg); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	var offer pushOffer
+	if err := dec.Decode(&offer); err != nil {
+		return fmt.Errorf("read offer: %w", err)
+	}
+	opts, source, err := acceptOffer(&offer, destDir)
+	if err != nil {
+		return errors.Join(err, reply(pushWant{Err: err.Error(), Category: category(err)}))
+	}
+
+	result := &Result{OutputPath: opts.OutputPath, Chunks: len(source.index)}
+	local, closeDonors := findLocalChunks(source, opts, result)
+	defer closeDonors()
+	var missing [][32]byte
+	for _, c := range source.chunksInOrder() {
+		if _, ok := local[c.Hash]; !ok {
+			missing = append(missing, c.Hash)
+		}
+	}
+	if err := reply(pushWant{Missing: missing}); err != nil {
+		return fmt.Errorf("send reply: %w", err)
+	}
+
+	received := 0
+	fetch := func(c format.ChunkInfo) (io.Reader, error) {
+		var chunk pushChunk
+		if err := dec.Decode(&chunk); err != nil {
+			return nil, err
+		}
+		received++
+		if uint64(len(chunk.Data)) != c.CompressedSize {
+			return nil, fmt.Errorf("got %d bytes, want %d", len(chunk.Data), c.CompressedSize)
+		}
+		return bytes.NewReader(chunk.Data), nil
+	}
+	err = assemble(opts, source, local, fetch, offer.ParityPercent, result)
+	if err == nil {
+		err = writeManifest(opts.OutputPath, offer.Manifest)
+	}
+	// The sender sends every chunk before reading the outcome
+	for ; err != nil && received < len(missing); received++ {
+		if dec.Decode(&pushChunk{}) != nil {
+			break
+		}
+	}
+
+	done := pushDone{Result: result}
+	if err != nil {
+		done.Err, done.Category = err.Error(), category(err)
+	}
+	return errors.Join(err, reply(done))
+}
+
+// acceptOffer checks an offer and returns the options of the copy and the
+// structure of the archive
+func acceptOffer(offer *pushOffer, destDir string) (*Options, *chunkedArchive, error) {
+	if offer.Version != protocolVersion {
+		return nil, nil, fmt.Errorf("%w: sender speaks version %d, receiver %d", ErrProtocolVersion, offer.Version, protocolVersion)
+	}
+	if offer.Name != filepath.Base(offer.Name) || offer.Name == "." || offer.Name == ".." || strings.ContainsAny(offer.Name, `/\`) {
+		return nil, nil, fmt.Errorf("%w: %q", ErrInvalidName, offer.Name)
+	}
+	opts := &Options{
+		SourcePath: offer.Name,
+		DestDir:    destDir,
+		OutputPath: filepath.Join(destDir, offer.Name),
+		Overwrite:  offer.Overwrite,
+		Verify:     offer.Verify,
+		Quiet:      true,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if _, err := os.Stat(opts.OutputPath); err == nil && !opts.Overwrite {
+		return nil, nil, ErrOutputExists
+	}
+	source, err := parseChunked(bufio.NewReader(bytes.NewReader(offer.Structure)))
+	if err != nil {
+		return nil, nil, err
+	}
+	return opts, source, nil
+}
+
+// category returns the name of the category of err sent to the other side
+func category(err error) string {
+	if c := godelta.Category(err); c != nil {
+		return c.Error()
+	}
+	return ""
+}
+
+// remoteError rebuilds an error reported by the other side, with its
+// category
+func remoteError(msg, name string) error {
+	err := fmt.Errorf("remote: %s", msg)
+	for _, c := range []error{
+		godelta.ErrCanceled, godelta.ErrUsage, godelta.ErrCorrupt, godelta.ErrUnsupported, godelta.ErrExists,
+		godelta.ErrNotFound, godelta.ErrPermission, godelta.ErrNoSpace, godelta.ErrIO, godelta.ErrPartial,
+	} {
+		if c.Error() == name {
+			return godelta.WithCategory(c, err)
+		}
+	}
+	return err
+}
+
+// shellQuote quotes s for the remote shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
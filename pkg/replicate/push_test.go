@@ -0,0 +1,91 @@
+// pkg/replicate/push_test.go
+package replicate_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/creativeyann17/go-delta/pkg/replicate"
+)
+
+// pipeConn is one end of an in-memory connection
+type pipeConn struct {
+	io.Reader
+	io.Writer
+}
+
+// push runs a sender and a receiver over in-memory pipes
+func push(t *testing.T, opts *replicate.PushOptions, destDir string) (*replicate.Result, error, error) {
+	t.Helper()
+	toReceiver, fromSender := io.Pipe()
+	toSender, fromReceiver := io.Pipe()
+	received := make(chan error, 1)
+	go func() {
+		err := replicate.Receive(pipeConn{toReceiver, fromReceiver}, destDir)
+		fromReceiver.Close()
+		received <- err
+	}()
+	result, err := replicate.PushTo(pipeConn{toSender, fromSender}, opts)
+	fromSender.Close()
+	return result, err, <-received
+}
+
+func TestPush(t *testing.T) {
+	src := t.TempDir()
+	shared := make([]byte, 1<<20)
+	rand.Read(shared)
+	if err := os.WriteFile(filepath.Join(src, "shared.bin"), shared, 0644); err != nil {
+		t.Fatal(err)
+	}
+	local, remote := t.TempDir(), t.TempDir()
+	compressDir(t, src, filepath.Join(remote, "mon.gdelta"), compress.Options{})
+	added := make([]byte, 200*1024)
+	rand.Read(added)
+	if err := os.WriteFile(filepath.Join(src, "added.bin"), added, 0644); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(local, "tue.gdelta")
+	compressDir(t, src, archive, compress.Options{})
+
+	opts := &replicate.PushOptions{SourcePath: archive, Remote: "backup@host:" + remote, Verify: true}
+	if err := opts.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	result, err, recvErr := push(t, opts, remote)
+	if err != nil || recvErr != nil {
+		t.Fatalf("push: %v, receive: %v", err, recvErr)
+	}
+	if result.ChunksReused == 0 || result.BytesTransferred > uint64(len(added))+64*1024 || !result.Verified {
+		t.Errorf("reused %d chunks, transferred %d bytes, verified %v", result.ChunksReused, result.BytesTransferred, result.Verified)
+	}
+
+	out := t.TempDir()
+	if _, err := decompress.Decompress(&decompress.Options{InputPath: filepath.Join(remote, "tue.gdelta"), OutputPath: out, Quiet: true}, nil); err != nil {
+		t.Fatalf("decompress pushed copy: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(out, "added.bin")); !bytes.Equal(got, added) {
+		t.Error("added.bin differs after the push")
+	}
+
+	// The receiver refuses to replace the copy, and the sender sees why
+	if _, err, _ := push(t, opts, remote); !errors.Is(err, godelta.ErrExists) {
+		t.Errorf("second push: got %v, want an exists error", err)
+	}
+}
+
+func TestPushOptionsValidate(t *testing.T) {
+	for _, remote := range []string{"", "host", ":/dir", "host:", "-oProxyCommand=touch /tmp/x:/dir", "-host:/dir"} {
+		opts := &replicate.PushOptions{SourcePath: "a.gdelta", Remote: remote}
+		if err := opts.Validate(); !errors.Is(err, replicate.ErrInvalidRemote) {
+			t.Errorf("remote %q: got %v, want ErrInvalidRemote", remote, err)
+		}
+	}
+}
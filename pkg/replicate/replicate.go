@@ -0,0 +1,359 @@
+// pkg/replicate/replicate.go
+package replicate
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// Result describes a copy
+type Result struct {
+	SourcePath string
+	OutputPath string
+	OutputSize uint64
+
+	Chunks            int    // Chunks in the archive
+	ChunksReused      int    // Taken from archives already at the destination
+	ChunksTransferred int    // Read from the source
+	BytesReused       uint64 // Compressed size of the reused chunks
+	BytesTransferred  uint64 // Chunk data read from the source
+
+	// Archives at the destination that provided chunks, and those that
+	// couldn't (another format or codec, unreadable)
+	DonorArchives   []string
+	SkippedArchives int
+
+	Verified bool // The copy passed a data verification
+}
+
+// Summary returns a human-readable summary of the copy
+func (r *Result) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Copied:      %s\n", r.SourcePath)
+	fmt.Fprintf(&sb, "To:          %s (%s)\n", r.OutputPath, godelta.FormatSize(r.OutputSize))
+	fmt.Fprintf(&sb, "Chunks:      %d\n", r.Chunks)
+	fmt.Fprintf(&sb, "Transferred: %d chunks, %s\n", r.ChunksTransferred, godelta.FormatSize(r.BytesTransferred))
+	fmt.Fprintf(&sb, "Reused:      %d chunks, %s from %d archives at the destination\n",
+		r.ChunksReused, godelta.FormatSize(r.BytesReused), len(r.DonorArchives))
+	if r.Verified {
+		sb.WriteString("Verified:    ✓\n")
+	}
+	return sb.String()
+}
+
+// chunkedArchive is the structure of a GDELTA02 archive, without its chunk
+// data
+type chunkedArchive struct {
+	header    format.GDelta02Header
+	index     map[[32]byte]format.ChunkInfo
+	files     []format.FileMetadata
+	dataStart int64 // Chunk offsets count from here
+}
+
+// localChunk is a chunk found in an archive at the destination
+type localChunk struct {
+	file   *os.File
+	offset int64
+	size   uint64
+	stored bool // kept uncompressed in that archive
+}
+
+// Copy copies a GDELTA02 archive into a destination repository, a
+// directory of GDELTA02 archives, reading from the source only the chunks
+// none of them holds. Chunks already at the destination are copied from
+// there, compressed as they are, so they must use the source's codec.
+// The copy holds the same files and chunks as the source; offsets differ
+// when a reused chunk was compressed at another level. A source's parity
+// and .sha256 manifest are carried over.
+func Copy(opts *Options) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(opts.OutputPath); err == nil && !opts.Overwrite {
+		return nil, ErrOutputExists
+	}
+
+	src, err := os.Open(opts.SourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("open source: %w", err)
+	}
+	defer src.Close()
+	source, err := readChunked(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opts.SourcePath, err)
+	}
+
+	result := &Result{SourcePath: opts.SourcePath, OutputPath: opts.OutputPath, Chunks: len(source.index)}
+	local, closeDonors := findLocalChunks(source, opts, result)
+	defer closeDonors()
+
+	percent := 0
+	if info, err := src.Stat(); err == nil {
+		if layout, err := parity.ReadLayout(src, info.Size()); err == nil {
+			percent = layout.Percent()
+		}
+	}
+	fetch := func(c format.ChunkInfo) (io.Reader, error) {
+		return io.NewSectionReader(src, source.dataStart+int64(c.Offset), int64(c.CompressedSize)), nil
+	}
+	if err := assemble(opts, source, local, fetch, percent, result); err != nil {
+		return result, err
+	}
+	manifest, err := os.ReadFile(opts.SourcePath + ".sha256")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return result, fmt.Errorf("read manifest: %w", err)
+	}
+	return result, writeManifest(opts.OutputPath, manifest)
+}
+
+// assemble writes the copy of source to opts.OutputPath, through a temp
+// file verified and renamed into place: chunks in local are copied from
+// the destination, the others read in source order with fetch
+func assemble(opts *Options, source *chunkedArchive, local map[[32]byte]localChunk,
+	fetch func(format.ChunkInfo) (io.Reader, error), parityPercent int, result *Result) error {
+	// Chunk data keeps the source order; reused chunks may change size,
+	// and be stored where the source compressed them or the reverse
+	chunks := source.chunksInOrder()
+	index := make(map[[32]byte]format.ChunkInfo, len(chunks))
+	var offset uint64
+	for _, c := range chunks {
+		if l, ok := local[c.Hash]; ok {
+			c.CompressedSize, c.Stored = l.size, l.stored
+		}
+		c.Offset = offset
+		index[c.Hash] = c
+		offset += c.CompressedSize
+	}
+
+	tmp := opts.OutputPath + ".tmp"
+	if err := writeCopy(tmp, source, index, chunks, local, fetch, parityPercent, result); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if opts.Verify {
+		v, err := verify.Verify(&verify.Options{InputPath: tmp, VerifyData: true, Quiet: true}, nil)
+		if err == nil && !v.IsValid() {
+			err = errors.Join(v.Errors...)
+		}
+		if err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("%w: %v", ErrCopyCorrupt, err)
+		}
+		result.Verified = true
+	}
+	if err := os.Rename(tmp, opts.OutputPath); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("rename copy: %w", err)
+	}
+	return nil
+}
+
+// findLocalChunks indexes the chunks of source held by the GDELTA02
+// archives of the destination with the same codec. The returned function
+// closes the archives kept open to read them.
+func findLocalChunks(source *chunkedArchive, opts *Options, result *Result) (map[[32]byte]localChunk, func()) {
+	local := make(map[[32]byte]localChunk)
+	var donors []*os.File
+	closeAll := func() {
+		for _, f := range donors {
+			f.Close()
+		}
+	}
+
+	entries, err := os.ReadDir(opts.DestDir)
+	if err != nil {
+		return local, closeAll
+	}
+	outAbs, _ := filepath.Abs(opts.OutputPath)
+	srcInfo, _ := os.Stat(opts.SourcePath)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".gdelta") {
+			continue
+		}
+		path := filepath.Join(opts.DestDir, entry.Name())
+		if abs, _ := filepath.Abs(path); abs == outAbs {
+			continue // replaced by the copy
+		}
+		if info, err := os.Stat(path); err != nil || (srcInfo != nil && os.SameFile(info, srcInfo)) {
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			result.SkippedArchives++
+			continue
+		}
+		archive, err := readChunked(f)
+		// Chunks compressed with another dictionary can't be copied over
+		if err != nil || archive.header.Codec.Method() != source.header.Codec.Method() || !bytes.Equal(archive.header.ChunkDictionary(), source.header.ChunkDictionary()) {
+			f.Close()
+			result.SkippedArchives++
+			continue
+		}
+		found := 0
+		for hash, info := range archive.index {
+			if _, wanted := source.index[hash]; !wanted {
+				continue
+			}
+			if _, ok := local[hash]; ok {
+				continue
+			}
+			local[hash] = localChunk{file: f, offset: archive.dataStart + int64(info.Offset), size: info.CompressedSize, stored: info.Stored}
+			found++
+		}
+		if found == 0 {
+			f.Close()
+			continue
+		}
+		donors = append(donors, f)
+		result.DonorArchives = append(result.DonorArchives, path)
+		if opts.Verbose {
+			fmt.Printf("  %s: %d chunks\n", path, found)
+		}
+	}
+	return local, closeAll
+}
+
+// writeCopy writes the copy to path: the source's header and file entries,
+// the new chunk index, then each chunk from the destination or fetch
+func writeCopy(path string, source *chunkedArchive, index map[[32]byte]format.ChunkInfo, chunks []format.ChunkInfo,
+	local map[[32]byte]localChunk, fetch func(format.ChunkInfo) (io.Reader, error), parityPercent int, result *Result) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create copy: %w", err)
+	}
+	defer out.Close()
+
+	w := bufio.NewWriterSize(out, 1<<20)
+	header := source.header
+	header.Features &^= format.FeatureStoredChunks
+	for _, c := range index {
+		if c.Stored {
+			header.Features |= format.FeatureStoredChunks
+			break
+		}
+	}
+	if err := format.WriteGDelta02Header(w, header); err != nil {
+		return err
+	}
+	if err := format.WriteChunkIndex(w, index); err != nil {
+		return err
+	}
+	for _, metadata := range source.files {
+		if err := format.WriteFileMetadata(w, metadata, source.header.Extended); err != nil {
+			return err
+		}
+	}
+	for _, c := range chunks {
+		var r io.Reader
+		size := c.CompressedSize
+		if l, ok := local[c.Hash]; ok {
+			r, size = io.NewSectionReader(l.file, l.offset, int64(l.size)), l.size
+			result.ChunksReused++
+			result.BytesReused += size
+		} else {
+			if r, err = fetch(c); err != nil {
+				return fmt.Errorf("fetch chunk %x: %w", c.Hash[:8], err)
+			}
+			result.ChunksTransferred++
+			result.BytesTransferred += size
+		}
+		if _, err := io.CopyN(w, r, int64(size)); err != nil {
+			return fmt.Errorf("copy chunk %x: %w", c.Hash[:8], err)
+		}
+	}
+	if err := format.WriteArchiveFooter02(w); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write copy: %w", err)
+	}
+
+	info, err := out.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	if parityPercent > 0 {
+		layout, err := parity.Write(out, size, parityPercent)
+		if err != nil {
+			return fmt.Errorf("write parity: %w", err)
+		}
+		size += layout.SectionSize()
+	}
+	result.OutputSize = uint64(size)
+	return out.Sync()
+}
+
+// readChunked reads the structure of the GDELTA02 archive f
+func readChunked(f *os.File) (*chunkedArchive, error) {
+	r := bufio.NewReaderSize(f, 1<<20)
+	archive, err := parseChunked(r)
+	if err != nil {
+		return nil, err
+	}
+	pos, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	archive.dataStart = pos - int64(r.Buffered())
+	return archive, nil
+}
+
+// parseChunked reads the structure of a GDELTA02 archive from r, up to
+// its chunk data
+func parseChunked(r *bufio.Reader) (*chunkedArchive, error) {
+	if magic, err := r.Peek(format.MagicSize); err != nil || format.DetectFormat(magic) != format.FormatGDelta02 {
+		return nil, ErrSourceFormat
+	}
+	header, err := format.ReadGDelta02Header(r)
+	if err != nil {
+		return nil, godelta.WithCategory(godelta.ErrCorrupt, err)
+	}
+	index, err := format.ReadChunkIndex(r, header.ChunkCount)
+	if err != nil {
+		return nil, godelta.WithCategory(godelta.ErrCorrupt, err)
+	}
+	archive := &chunkedArchive{header: header, index: index, files: make([]format.FileMetadata, 0, format.CapHint(header.FileCount))}
+	for range header.FileCount {
+		metadata, err := format.ReadFileMetadata(r, header.Extended)
+		if err != nil {
+			return nil, godelta.WithCategory(godelta.ErrCorrupt, err)
+		}
+		archive.files = append(archive.files, metadata)
+	}
+	return archive, nil
+}
+
+// chunksInOrder returns the chunks of the archive in data order
+func (a *chunkedArchive) chunksInOrder() []format.ChunkInfo {
+	return slices.SortedFunc(maps.Values(a.index), func(x, y format.ChunkInfo) int {
+		return cmp.Compare(x.Offset, y.Offset)
+	})
+}
+
+// writeManifest writes the .sha256 manifest of the source next to the
+// copy, if it had one: the files are the same
+func writeManifest(archivePath string, manifest []byte) error {
+	if manifest == nil {
+		return nil
+	}
+	if err := os.WriteFile(archivePath+".sha256", manifest, 0644); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
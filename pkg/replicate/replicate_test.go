@@ -0,0 +1,79 @@
+// pkg/replicate/replicate_test.go
+package replicate_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/replicate"
+)
+
+func compressDir(t *testing.T, src, archive string, opts compress.Options) {
+	t.Helper()
+	opts.InputPath = src
+	opts.OutputPath = archive
+	opts.ChunkSize = 64 * 1024
+	opts.Quiet = true
+	if _, err := compress.Compress(&opts, nil); err != nil {
+		t.Fatalf("compress %s: %v", archive, err)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	src := t.TempDir()
+	shared := make([]byte, 1<<20)
+	rand.Read(shared)
+	if err := os.WriteFile(filepath.Join(src, "shared.bin"), shared, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repoA, repoB := t.TempDir(), t.TempDir()
+	// Monday is already replicated; Tuesday adds a file
+	compressDir(t, src, filepath.Join(repoA, "mon.gdelta"), compress.Options{})
+	compressDir(t, src, filepath.Join(repoB, "mon.gdelta"), compress.Options{Level: 1})
+	added := make([]byte, 256*1024)
+	rand.Read(added)
+	if err := os.WriteFile(filepath.Join(src, "added.bin"), added, 0644); err != nil {
+		t.Fatal(err)
+	}
+	compressDir(t, src, filepath.Join(repoA, "tue.gdelta"), compress.Options{ParityPercent: 5, WriteManifest: true})
+
+	result, err := replicate.Copy(&replicate.Options{
+		SourcePath: filepath.Join(repoA, "tue.gdelta"),
+		DestDir:    repoB,
+		Verify:     true,
+		Quiet:      true,
+	})
+	if err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if result.ChunksReused == 0 || result.ChunksTransferred == 0 || !result.Verified {
+		t.Errorf("reused %d, transferred %d, verified %v", result.ChunksReused, result.ChunksTransferred, result.Verified)
+	}
+	if result.BytesTransferred > uint64(len(added))+64*1024 {
+		t.Errorf("transferred %d bytes for a %d-byte change", result.BytesTransferred, len(added))
+	}
+	if _, err := os.Stat(filepath.Join(repoB, "tue.gdelta.sha256")); err != nil {
+		t.Errorf("manifest not carried over: %v", err)
+	}
+
+	out := t.TempDir()
+	if _, err := decompress.Decompress(&decompress.Options{InputPath: result.OutputPath, OutputPath: out, Quiet: true}, nil); err != nil {
+		t.Fatalf("decompress copy: %v", err)
+	}
+	for name, want := range map[string][]byte{"shared.bin": shared, "added.bin": added} {
+		if got, _ := os.ReadFile(filepath.Join(out, name)); !bytes.Equal(got, want) {
+			t.Errorf("%s differs after the copy", name)
+		}
+	}
+
+	if _, err := replicate.Copy(&replicate.Options{SourcePath: filepath.Join(repoA, "tue.gdelta"), DestDir: repoB}); !errors.Is(err, replicate.ErrOutputExists) {
+		t.Errorf("second copy: got %v, want ErrOutputExists", err)
+	}
+}
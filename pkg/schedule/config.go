@@ -0,0 +1,149 @@
+// pkg/schedule/config.go
+package schedule
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// Config is the scheduler configuration file:
+//
+//	state_dir: /var/lib/godelta   # per-job logs and status.json
+//	jobs:
+//	  - name: nightly
+//	    schedule: "0 2 * * *"
+//	    input: /data
+//	    output: /backups/nightly
+//	    level: 9
+//	    chunk_size: 64KB
+//	    retention:
+//	      keep_last: 7
+//	      max_age: 720h
+type Config struct {
+	// StateDir holds <job>.log and status.json
+	// Default: directory of the config file
+	StateDir string `yaml:"state_dir"`
+
+	Jobs []Job `yaml:"jobs"`
+}
+
+// Job is one scheduled compression
+type Job struct {
+	Name     string `yaml:"name"`     // Unique; used in archive and log file names
+	Schedule string `yaml:"schedule"` // Cron expression, see ParseCron
+	Input    string `yaml:"input"`    // File or directory to compress
+	Output   string `yaml:"output"`   // Directory receiving <name>-<time>.gdelta
+
+	Level      int    `yaml:"level"`      // zstd level (default 5)
+	Threads    int    `yaml:"threads"`    // default: number of CPUs
+	ChunkSize  string `yaml:"chunk_size"` // e.g. "64KB" (GDELTA02); empty = disabled
+	Dictionary bool   `yaml:"dictionary"` // GDELTA03
+	Gitignore  bool   `yaml:"gitignore"`
+
+	Retention Retention `yaml:"retention"`
+
+	cron *Cron
+}
+
+// Retention bounds the archives kept per job after each successful run.
+// Zero values disable the corresponding rule.
+type Retention struct {
+	KeepLast int           `yaml:"keep_last"` // Keep the N newest archives
+	MaxAge   time.Duration `yaml:"max_age"`   // Delete archives older than this
+}
+
+// LoadConfig reads and validates a scheduler config file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if cfg.StateDir == "" {
+		cfg.StateDir = filepath.Dir(path)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks every job and parses its schedule
+func (c *Config) Validate() error {
+	if len(c.Jobs) == 0 {
+		return ErrNoJobs
+	}
+
+	seen := make(map[string]bool)
+	for i := range c.Jobs {
+		job := &c.Jobs[i]
+		if job.Name == "" || strings.ContainsAny(job.Name, `/\`) || job.Name == "." || job.Name == ".." {
+			return fmt.Errorf("%w: job %d: name must be a non-empty file name", ErrInvalidJob, i+1)
+		}
+		if seen[job.Name] {
+			return fmt.Errorf("%w: duplicate job name %q", ErrInvalidJob, job.Name)
+		}
+		seen[job.Name] = true
+
+		if job.Input == "" || job.Output == "" {
+			return fmt.Errorf("%w: %s: input and output are required", ErrInvalidJob, job.Name)
+		}
+		if job.Retention.KeepLast < 0 || job.Retention.MaxAge < 0 {
+			return fmt.Errorf("%w: %s: retention values must not be negative", ErrInvalidJob, job.Name)
+		}
+
+		cron, err := ParseCron(job.Schedule)
+		if err != nil {
+			return fmt.Errorf("%s: %w", job.Name, err)
+		}
+		job.cron = cron
+
+		// Surface option errors at startup, not at 2am
+		if _, err := job.compressOptions(time.Time{}); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidJob, job.Name, err)
+		}
+	}
+	return nil
+}
+
+// archivePath returns the archive written by a run started at t
+func (j *Job) archivePath(t time.Time) string {
+	return filepath.Join(j.Output, fmt.Sprintf("%s-%s.gdelta", j.Name, t.UTC().Format(archiveTimeFormat)))
+}
+
+// archiveTimeFormat sorts lexically in time order
+const archiveTimeFormat = "20060102T150405Z"
+
+// compressOptions builds the compress options for a run started at t
+func (j *Job) compressOptions(t time.Time) (*compress.Options, error) {
+	chunkSize, err := godelta.ParseSize(j.ChunkSize)
+	if err != nil {
+		return nil, fmt.Errorf("chunk_size: %w", err)
+	}
+	opts := &compress.Options{
+		InputPath:     j.Input,
+		OutputPath:    j.archivePath(t),
+		MaxThreads:    j.Threads,
+		Level:         j.Level,
+		ChunkSize:     chunkSize,
+		UseDictionary: j.Dictionary,
+		UseGitignore:  j.Gitignore,
+		Quiet:         true,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
@@ -0,0 +1,59 @@
+// pkg/schedule/cron_test.go
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	base := time.Date(2026, time.October, 16, 10, 30, 0, 0, time.UTC) // Friday
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 2 * * *", time.Date(2026, time.October, 17, 2, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.October, 16, 10, 45, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2026, time.October, 17, 10, 30, 0, 0, time.UTC)}, // strictly after
+		{"0 9-17/4 * * 1-5", time.Date(2026, time.October, 16, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, time.October, 16, 11, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches (the 20th, or Monday the 19th)
+		{"0 0 20 * 1", time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range cases {
+		c, err := ParseCron(tc.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", tc.expr, err)
+			continue
+		}
+		if got := c.Next(base); !got.Equal(tc.want) {
+			t.Errorf("%q: Next = %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestCronNeverMatches(t *testing.T) {
+	c, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Next(time.Now()); !got.IsZero() {
+		t.Errorf("expected zero time, got %v", got)
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		if _, err := ParseCron(expr); !errors.Is(err, ErrInvalidCron) {
+			t.Errorf("ParseCron(%q) = %v, want ErrInvalidCron", expr, err)
+		}
+	}
+}
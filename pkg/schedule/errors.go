@@ -0,0 +1,15 @@
+// pkg/schedule/errors.go
+package schedule
+
+import "errors"
+
+var (
+	// ErrNoJobs is returned when the config file defines no jobs
+	ErrNoJobs = errors.New("config defines no jobs")
+
+	// ErrInvalidCron is returned for a malformed cron expression
+	ErrInvalidCron = errors.New("invalid cron expression")
+
+	// ErrInvalidJob is returned when a job definition is incomplete or invalid
+	ErrInvalidJob = errors.New("invalid job")
+)
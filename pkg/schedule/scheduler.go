@@ -0,0 +1,368 @@
+// pkg/schedule/scheduler.go
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/metrics"
+)
+
+// Run outcomes recorded in JobStatus.LastResult
+const (
+	ResultOK       = "ok"       // archive written without errors
+	ResultPartial  = "partial"  // archive written, some files failed
+	ResultFailed   = "failed"   // no archive written
+	ResultCanceled = "canceled" // stopped by shutdown
+)
+
+// statusFile is the name of the last-run status file in StateDir
+const statusFile = "status.json"
+
+// JobStatus is the last-run state of one job, persisted across restarts
+type JobStatus struct {
+	Name           string    `json:"name"`
+	Schedule       string    `json:"schedule"`
+	NextRun        time.Time `json:"next_run"`
+	Running        bool      `json:"running"`
+	LastRun        time.Time `json:"last_run,omitempty"`
+	LastDuration   string    `json:"last_duration,omitempty"`
+	LastResult     string    `json:"last_result,omitempty"`
+	LastArchive    string    `json:"last_archive,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	Files          int       `json:"files"`
+	OriginalSize   uint64    `json:"original_size"`
+	CompressedSize uint64    `json:"compressed_size"`
+	Runs           int       `json:"runs"`
+	Failures       int       `json:"failures"`
+}
+
+// Scheduler runs the jobs of a Config on their cron schedules. A job whose
+// previous run is still going when it fires again is skipped for that tick.
+type Scheduler struct {
+	cfg  *Config
+	logf func(format string, args ...interface{})
+
+	mu     sync.Mutex
+	status map[string]*JobStatus
+
+	saveMu sync.Mutex // serializes status.json writes
+}
+
+// New creates a scheduler. Last-run status from a previous process is loaded
+// from StateDir so it survives restarts. logf receives every job log line
+// (may be nil).
+func New(cfg *Config, logf func(format string, args ...interface{})) (*Scheduler, error) {
+	if err := os.MkdirAll(cfg.StateDir, 0755); err != nil {
+		return nil, fmt.Errorf("create state directory: %w", err)
+	}
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+
+	s := &Scheduler{cfg: cfg, logf: logf, status: make(map[string]*JobStatus)}
+
+	previous, _ := ReadStatus(cfg.StateDir)
+	byName := make(map[string]JobStatus)
+	for _, st := range previous {
+		byName[st.Name] = st
+	}
+	for _, job := range cfg.Jobs {
+		st := byName[job.Name]
+		st.Name = job.Name
+		st.Schedule = job.Schedule
+		st.Running = false
+		s.status[job.Name] = &st
+	}
+	return s, nil
+}
+
+// ReadStatus loads the status file written by a scheduler using stateDir
+func ReadStatus(stateDir string) ([]JobStatus, error) {
+	data, err := os.ReadFile(filepath.Join(stateDir, statusFile))
+	if err != nil {
+		return nil, err
+	}
+	var statuses []JobStatus
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", statusFile, err)
+	}
+	return statuses, nil
+}
+
+// Status returns a snapshot of every job's state, in config order
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]JobStatus, 0, len(s.cfg.Jobs))
+	for _, job := range s.cfg.Jobs {
+		out = append(out, *s.status[job.Name])
+	}
+	return out
+}
+
+// Run fires jobs on schedule until ctx is canceled, then cancels running
+// jobs (their partial archives are removed) and waits for them.
+func (s *Scheduler) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	next := make(map[string]time.Time)
+	now := time.Now()
+	for _, job := range s.cfg.Jobs {
+		next[job.Name] = job.cron.Next(now)
+		s.setNextRun(job.Name, next[job.Name])
+		s.logf("[%s] next run %s", job.Name, next[job.Name].Format(time.RFC3339))
+	}
+	s.saveStatus()
+
+	for {
+		// Sleep until the earliest scheduled job
+		var wake time.Time
+		for _, t := range next {
+			if !t.IsZero() && (wake.IsZero() || t.Before(wake)) {
+				wake = t
+			}
+		}
+		if wake.IsZero() {
+			<-ctx.Done()
+			return nil
+		}
+
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		for i := range s.cfg.Jobs {
+			job := &s.cfg.Jobs[i]
+			if next[job.Name].IsZero() || now.Before(next[job.Name]) {
+				continue
+			}
+			next[job.Name] = job.cron.Next(now)
+			s.setNextRun(job.Name, next[job.Name])
+
+			if !s.markRunning(job.Name) {
+				s.logf("[%s] previous run still in progress, skipping", job.Name)
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.runJob(ctx, job)
+			}()
+		}
+		s.saveStatus()
+	}
+}
+
+// RunJob runs one job immediately, outside its schedule
+func (s *Scheduler) RunJob(ctx context.Context, name string) error {
+	for i := range s.cfg.Jobs {
+		job := &s.cfg.Jobs[i]
+		if job.Name != name {
+			continue
+		}
+		if !s.markRunning(name) {
+			return fmt.Errorf("job %s is already running", name)
+		}
+		s.runJob(ctx, job)
+
+		st := s.Status()[i]
+		if st.LastResult != ResultOK {
+			return fmt.Errorf("job %s: %s: %s", name, st.LastResult, st.LastError)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unknown job %q", ErrInvalidJob, name)
+}
+
+func (s *Scheduler) setNextRun(name string, t time.Time) {
+	s.mu.Lock()
+	s.status[name].NextRun = t
+	s.mu.Unlock()
+}
+
+// markRunning flags a job as running; false if it already was
+func (s *Scheduler) markRunning(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status[name].Running {
+		return false
+	}
+	s.status[name].Running = true
+	return true
+}
+
+// runJob performs one run: compress, apply retention, log, record status
+func (s *Scheduler) runJob(ctx context.Context, job *Job) {
+	start := time.Now()
+	logFile, err := os.OpenFile(filepath.Join(s.cfg.StateDir, job.Name+".log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		s.logf("[%s] open log: %v", job.Name, err)
+	}
+	log := func(format string, args ...interface{}) {
+		line := fmt.Sprintf(format, args...)
+		s.logf("[%s] %s", job.Name, line)
+		if logFile != nil {
+			fmt.Fprintf(logFile, "%s %s\n", time.Now().Format(time.RFC3339), line)
+		}
+	}
+	defer func() {
+		if logFile != nil {
+			logFile.Close()
+		}
+	}()
+
+	outcome := ResultFailed
+	var runErr error
+	var result *compress.Result
+
+	opts, err := job.compressOptions(start)
+	if err == nil {
+		err = os.MkdirAll(job.Output, 0755)
+	}
+	if err == nil {
+		log("starting: %s -> %s", job.Input, opts.OutputPath)
+		result, err = compress.CompressContext(ctx, opts, nil)
+	}
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		outcome, runErr = ResultCanceled, ctx.Err()
+		log("canceled")
+	case err != nil:
+		runErr = err
+		log("failed: %v", err)
+	default:
+		outcome = ResultOK
+		if len(result.Errors) > 0 {
+			outcome = ResultPartial
+			runErr = fmt.Errorf("%d files failed", len(result.Errors))
+			for _, e := range result.Errors {
+				log("error: %v", e)
+			}
+		}
+		log("done in %s: %d files, %s -> %s", time.Since(start).Round(time.Millisecond),
+			result.FilesProcessed, compress.FormatSize(result.OriginalSize), compress.FormatSize(result.CompressedSize))
+
+		removed, err := applyRetention(job, opts.OutputPath, start)
+		for _, path := range removed {
+			log("retention: removed %s", filepath.Base(path))
+		}
+		if err != nil {
+			log("retention: %v", err)
+		}
+	}
+
+	metrics.JobDuration.Observe(time.Since(start).Seconds(), job.Name, outcome)
+
+	s.mu.Lock()
+	st := s.status[job.Name]
+	st.Running = false
+	st.LastRun = start
+	st.LastDuration = time.Since(start).Round(time.Millisecond).String()
+	st.LastResult = outcome
+	st.LastError = ""
+	if runErr != nil {
+		st.LastError = runErr.Error()
+	}
+	st.Runs++
+	if outcome == ResultFailed {
+		st.Failures++
+	}
+	if result != nil && outcome != ResultCanceled {
+		st.LastArchive = opts.OutputPath
+		st.Files = result.FilesProcessed
+		st.OriginalSize = result.OriginalSize
+		st.CompressedSize = result.CompressedSize
+	}
+	s.mu.Unlock()
+	s.saveStatus()
+}
+
+// applyRetention deletes this job's older archives per its retention rules.
+// The archive just written (keep) is never removed.
+func applyRetention(job *Job, keep string, now time.Time) ([]string, error) {
+	if job.Retention.KeepLast == 0 && job.Retention.MaxAge == 0 {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(job.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only names that parse as <job>-<time>.gdelta belong to this job, so
+	// "db" never touches "db-full-..." archives of another job
+	type archive struct {
+		path string
+		time time.Time
+	}
+	var archives []archive
+	prefix := job.Name + "-"
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".gdelta") {
+			continue
+		}
+		t, err := time.Parse(archiveTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gdelta"))
+		if err != nil {
+			continue
+		}
+		archives = append(archives, archive{path: filepath.Join(job.Output, name), time: t})
+	}
+	sort.Slice(archives, func(i, j int) bool { return archives[i].time.After(archives[j].time) })
+
+	var removed []string
+	var errs []error
+	for i, a := range archives {
+		if a.path == keep {
+			continue
+		}
+		tooMany := job.Retention.KeepLast > 0 && i >= job.Retention.KeepLast
+		tooOld := job.Retention.MaxAge > 0 && now.Sub(a.time) > job.Retention.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.Remove(a.path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed = append(removed, a.path)
+	}
+	return removed, errors.Join(errs...)
+}
+
+// saveStatus writes status.json atomically (temp file + rename)
+func (s *Scheduler) saveStatus() {
+	data, err := json.MarshalIndent(s.Status(), "", "  ")
+	if err != nil {
+		return
+	}
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	path := filepath.Join(s.cfg.StateDir, statusFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		s.logf("save status: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		s.logf("save status: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+// pkg/schedule/scheduler_test.go
+package schedule
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, dir, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, "jobs.yaml")
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]string{
+		"no jobs":   "jobs: []\n",
+		"bad cron":  "jobs:\n  - {name: a, schedule: '61 * * * *', input: x, output: y}\n",
+		"duplicate": "jobs:\n  - {name: a, schedule: '@daily', input: x, output: y}\n  - {name: a, schedule: '@daily', input: x, output: y}\n",
+		"bad name":  "jobs:\n  - {name: a/b, schedule: '@daily', input: x, output: y}\n",
+		"bad level": "jobs:\n  - {name: a, schedule: '@daily', input: x, output: y, level: 40}\n",
+		"bad size":  "jobs:\n  - {name: a, schedule: '@daily', input: x, output: y, chunk_size: 12XB}\n",
+	}
+	for name, body := range cases {
+		if _, err := LoadConfig(writeConfig(t, dir, body)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRunJobLogsStatusAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "data")
+	output := filepath.Join(dir, "backups")
+	if err := os.MkdirAll(input, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(input, "file.txt"), []byte("scheduled backup"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Older archives of this job, plus one of a job sharing the name prefix
+	if err := os.MkdirAll(output, 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := []string{"nightly-20200101T000000Z.gdelta", "nightly-20200102T000000Z.gdelta", "nightly-20200103T000000Z.gdelta"}
+	for _, name := range append(old, "nightly-db-20200101T000000Z.gdelta") {
+		if err := os.WriteFile(filepath.Join(output, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := LoadConfig(writeConfig(t, dir, `
+jobs:
+  - name: nightly
+    schedule: "0 2 * * *"
+    input: `+input+`
+    output: `+output+`
+    retention:
+      keep_last: 2
+`))
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.StateDir != dir {
+		t.Errorf("StateDir = %q, want config directory %q", cfg.StateDir, dir)
+	}
+
+	s, err := New(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RunJob(context.Background(), "nightly"); err != nil {
+		t.Fatalf("run job: %v", err)
+	}
+
+	// keep_last 2 = the new archive + the newest old one
+	entries, _ := os.ReadDir(output)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	joined := strings.Join(names, ",")
+	if len(names) != 3 || !strings.Contains(joined, old[2]) || !strings.Contains(joined, "nightly-db-") {
+		t.Errorf("unexpected archives after retention: %v", names)
+	}
+
+	logData, err := os.ReadFile(filepath.Join(dir, "nightly.log"))
+	if err != nil || !strings.Contains(string(logData), "retention: removed") {
+		t.Errorf("job log missing retention lines: %q (%v)", logData, err)
+	}
+
+	statuses, err := ReadStatus(dir)
+	if err != nil {
+		t.Fatalf("read status: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].LastResult != ResultOK || statuses[0].Files != 1 || statuses[0].Runs != 1 {
+		t.Errorf("unexpected status: %+v", statuses)
+	}
+
+	// Status survives a restart
+	s2, err := New(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := s2.Status()[0]; st.Runs != 1 || st.LastRun.IsZero() {
+		t.Errorf("status not restored: %+v", st)
+	}
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := LoadConfig(writeConfig(t, dir, "jobs:\n  - {name: a, schedule: '@yearly', input: x, output: y}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler did not stop")
+	}
+}
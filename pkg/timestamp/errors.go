@@ -0,0 +1,22 @@
+// pkg/timestamp/errors.go
+package timestamp
+
+import "errors"
+
+var (
+	// ErrInvalidURL is returned when the TSA URL is not http(s)
+	ErrInvalidURL = errors.New("timestamp authority URL must be http or https")
+
+	// ErrRejected is returned when the TSA doesn't grant the request
+	ErrRejected = errors.New("timestamp request rejected")
+
+	// ErrMalformed is returned when a response or token can't be parsed
+	ErrMalformed = errors.New("malformed timestamp response")
+
+	// ErrDigestMismatch is returned when a token doesn't cover the archive,
+	// i.e. the archive changed after it was timestamped
+	ErrDigestMismatch = errors.New("timestamp does not match archive digest")
+
+	// ErrNonceMismatch is returned when a response answers another request
+	ErrNonceMismatch = errors.New("timestamp response nonce mismatch")
+)
@@ -0,0 +1,229 @@
+// pkg/timestamp/timestamp.go
+
+// Package timestamp obtains RFC 3161 trusted timestamps over archive digests
+// and checks archives against them, so a backup can later be shown to have
+// existed, unmodified, at a given date.
+//
+// The TSA response is stored unchanged next to the archive (<archive>.tsr):
+// the digest covers every byte of the archive, so the token can't live
+// inside it. The sidecar is the same file `openssl ts -reply` produces, so
+// the TSA signature can be checked independently:
+//
+//	openssl ts -verify -data backup.gdelta -in backup.gdelta.tsr -CAfile tsa-ca.pem
+package timestamp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a request to the timestamp authority
+const DefaultTimeout = 30 * time.Second
+
+// maxResponseSize caps the TSA reply (tokens are a few KB with certificates)
+const maxResponseSize = 1 << 20
+
+// Token describes a timestamp over an archive
+type Token struct {
+	Time   time.Time // When the TSA saw the digest
+	Serial *big.Int  // Token serial number assigned by the TSA
+	Policy string    // TSA policy OID
+	Digest []byte    // SHA-256 of the archive
+}
+
+// SidecarPath returns where the token of an archive is stored
+func SidecarPath(archivePath string) string {
+	return archivePath + ".tsr"
+}
+
+// ValidateURL checks that a TSA URL can be used
+func ValidateURL(tsaURL string) error {
+	u, err := url.Parse(tsaURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidURL, tsaURL)
+	}
+	return nil
+}
+
+// Stamp hashes the archive, asks the TSA at tsaURL for a timestamp over the
+// digest and writes the response to SidecarPath(archivePath)
+func Stamp(ctx context.Context, tsaURL, archivePath string) (*Token, error) {
+	if err := ValidateURL(tsaURL); err != nil {
+		return nil, err
+	}
+	digest, err := fileDigest(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The nonce ties the response to this request
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	resp, err := request(ctx, tsaURL, digest, nonce)
+	if err != nil {
+		return nil, err
+	}
+	token, err := parseResponse(resp, digest, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(SidecarPath(archivePath), resp, 0644); err != nil {
+		return nil, fmt.Errorf("write timestamp: %w", err)
+	}
+	return token, nil
+}
+
+// Check re-hashes the archive and compares it with its sidecar token. The
+// returned error wraps fs.ErrNotExist when the archive has no token, and
+// ErrDigestMismatch when the archive changed after it was timestamped.
+// The TSA signature itself is not checked (see the package documentation).
+func Check(archivePath string) (*Token, error) {
+	resp, err := os.ReadFile(SidecarPath(archivePath))
+	if err != nil {
+		return nil, err
+	}
+	digest, err := fileDigest(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	return parseResponse(resp, digest, nil)
+}
+
+// fileDigest returns the SHA-256 of a file
+func fileDigest(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("hash archive: %w", err)
+	}
+	return h.Sum(nil), nil
+}
+
+// request POSTs a TimeStampReq and returns the raw TimeStampResp
+func request(ctx context.Context, tsaURL string, digest []byte, nonce *big.Int) ([]byte, error) {
+	body, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: sha256Algorithm(),
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true, // embed the TSA certificate so the token verifies on its own
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tsaURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/timestamp-query")
+	req.Header.Set("User-Agent", "godelta")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request timestamp: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: TSA answered %s", ErrRejected, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read timestamp response: %w", err)
+	}
+	if len(data) > maxResponseSize {
+		return nil, fmt.Errorf("%w: response larger than %d bytes", ErrMalformed, maxResponseSize)
+	}
+	return data, nil
+}
+
+// parseResponse decodes a TimeStampResp and checks that its token covers
+// digest (and answers nonce, when given)
+func parseResponse(der, digest []byte, nonce *big.Int) (*Token, error) {
+	var resp timeStampResp
+	if rest, err := asn1.Unmarshal(der, &resp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	} else if len(rest) > 0 {
+		return nil, fmt.Errorf("%w: trailing data", ErrMalformed)
+	}
+	if s := resp.Status.Status; s != statusGranted && s != statusGrantedWithMods {
+		msg := fmt.Sprintf("status %d", s)
+		if len(resp.Status.StatusString) > 0 {
+			msg += ": " + strings.Join(resp.Status.StatusString, "; ")
+		}
+		return nil, fmt.Errorf("%w: %s", ErrRejected, msg)
+	}
+	if len(resp.TimeStampToken.FullBytes) == 0 {
+		return nil, fmt.Errorf("%w: no token", ErrMalformed)
+	}
+
+	info, err := parseToken(resp.TimeStampToken.FullBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) ||
+		!bytes.Equal(info.MessageImprint.HashedMessage, digest) {
+		return nil, ErrDigestMismatch
+	}
+	if nonce != nil && (info.Nonce == nil || info.Nonce.Cmp(nonce) != 0) {
+		return nil, ErrNonceMismatch
+	}
+
+	return &Token{
+		Time:   info.GenTime,
+		Serial: info.SerialNumber,
+		Policy: info.Policy.String(),
+		Digest: digest,
+	}, nil
+}
+
+// parseToken extracts the TSTInfo from a TimeStampToken (CMS SignedData)
+func parseToken(der []byte) (*tstInfo, error) {
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("%w: token: %v", ErrMalformed, err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("%w: token is not signed data", ErrMalformed)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("%w: signed data: %v", ErrMalformed, err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) || len(sd.EncapContentInfo.EContent) == 0 {
+		return nil, fmt.Errorf("%w: token holds no TSTInfo", ErrMalformed)
+	}
+	var info tstInfo
+	if _, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info); err != nil {
+		return nil, fmt.Errorf("%w: TSTInfo: %v", ErrMalformed, err)
+	}
+	return &info, nil
+}
+
+// sha256Algorithm is the AlgorithmIdentifier for SHA-256 (NULL parameters)
+func sha256Algorithm() pkix.AlgorithmIdentifier {
+	return pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
+}
@@ -0,0 +1,133 @@
+// pkg/timestamp/timestamp_test.go
+package timestamp
+
+import (
+	"context"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"io/fs"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeTSA answers timestamp requests with an unsigned token carrying the
+// requested imprint and nonce, or with status when it is not granted
+func fakeTSA(t *testing.T, status int, genTime time.Time) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/timestamp-query" {
+			t.Errorf("unexpected content type %q", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req timeStampReq
+		if _, err := asn1.Unmarshal(body, &req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+
+		resp := timeStampResp{Status: pkiStatusInfo{Status: status}}
+		if status == statusGranted {
+			info, err := asn1.Marshal(tstInfo{
+				Version:        1,
+				Policy:         asn1.ObjectIdentifier{1, 2, 3, 4},
+				MessageImprint: req.MessageImprint,
+				SerialNumber:   big.NewInt(42),
+				GenTime:        genTime,
+				Nonce:          req.Nonce,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sd, err := asn1.Marshal(signedData{
+				Version:          3,
+				DigestAlgorithms: asn1.RawValue{FullBytes: []byte{0x31, 0x00}},
+				EncapContentInfo: encapContentInfo{EContentType: oidTSTInfo, EContent: info},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := asn1.Marshal(struct {
+				ContentType asn1.ObjectIdentifier
+				Content     asn1.RawValue
+			}{oidSignedData, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.TimeStampToken = asn1.RawValue{FullBytes: token}
+		} else {
+			resp.Status.StatusString = []string{"policy not supported"}
+		}
+
+		der, err := asn1.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		w.Write(der)
+	}))
+}
+
+func TestStampAndCheck(t *testing.T) {
+	genTime := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := fakeTSA(t, statusGranted, genTime)
+	defer server.Close()
+
+	archive := filepath.Join(t.TempDir(), "backup.gdelta")
+	if err := os.WriteFile(archive, []byte("archive bytes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// No token yet
+	if _, err := Check(archive); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected fs.ErrNotExist before stamping, got %v", err)
+	}
+
+	token, err := Stamp(context.Background(), server.URL, archive)
+	if err != nil {
+		t.Fatalf("stamp: %v", err)
+	}
+	if !token.Time.Equal(genTime) || token.Serial.Int64() != 42 || token.Policy != "1.2.3.4" {
+		t.Errorf("unexpected token: %+v", token)
+	}
+
+	checked, err := Check(archive)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if !checked.Time.Equal(genTime) {
+		t.Errorf("check time = %s, want %s", checked.Time, genTime)
+	}
+
+	// Any change to the archive breaks the attestation
+	if err := os.WriteFile(archive, []byte("archive bytes, edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Check(archive); !errors.Is(err, ErrDigestMismatch) {
+		t.Fatalf("expected ErrDigestMismatch, got %v", err)
+	}
+}
+
+func TestStampRejected(t *testing.T) {
+	server := fakeTSA(t, 2, time.Time{}) // rejection
+	defer server.Close()
+
+	archive := filepath.Join(t.TempDir(), "backup.gdelta")
+	if err := os.WriteFile(archive, []byte("archive bytes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Stamp(context.Background(), server.URL, archive); !errors.Is(err, ErrRejected) {
+		t.Fatalf("expected ErrRejected, got %v", err)
+	}
+	if _, err := os.Stat(SidecarPath(archive)); !os.IsNotExist(err) {
+		t.Errorf("no sidecar should be written, stat err = %v", err)
+	}
+
+	if err := ValidateURL("ftp://tsa.example.com"); !errors.Is(err, ErrInvalidURL) {
+		t.Errorf("expected ErrInvalidURL, got %v", err)
+	}
+}
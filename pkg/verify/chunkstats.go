@@ -0,0 +1,216 @@
+// pkg/verify/chunkstats.go
+package verify
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/creativeyann17/go-delta/internal/chunkstore"
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// ChunkStats are the chunk reference statistics of a GDELTA02 archive: which
+// chunks its files share most, how references are distributed, and what
+// each file costs once its shared chunks are split between their users.
+type ChunkStats struct {
+	ArchivePath  string
+	Chunks       uint64 // Chunks in the index
+	References   uint64 // Chunk references summed over the files
+	Unreferenced uint64 // Chunks no file references
+	RefChunks    uint64 // Chunks taken from reference directories, not in the index
+
+	LogicalSize  uint64 // Original size summed over the references
+	PhysicalSize uint64 // Compressed size of the referenced chunks in the archive
+
+	Top          []ChunkRefs  // Most referenced chunks first
+	Distribution []RefBucket  // Chunks by reference count: 1, 2, 3-4, 5-8, ...
+	Files        []FileChunks // Files saving the most first
+}
+
+// ChunkRefs is a chunk of ChunkStats.Top
+type ChunkRefs struct {
+	Hash           [32]byte
+	Refs           uint64
+	OriginalSize   uint64
+	CompressedSize uint64
+}
+
+// RefBucket counts the chunks referenced MinRefs to MaxRefs times
+type RefBucket struct {
+	MinRefs, MaxRefs uint64
+	Chunks           uint64
+	CompressedSize   uint64 // Stored size of these chunks
+	SavedSize        uint64 // Original bytes their extra references didn't store
+}
+
+// FileChunks is the logical and effective physical size of a file
+type FileChunks struct {
+	Path         string
+	Chunks       uint64 // Chunk references of the file
+	SharedChunks uint64 // Those to chunks referenced more than once
+	LogicalSize  uint64 // Original size
+
+	// PhysicalSize is the compressed size of the file's chunks, each
+	// divided by its reference count: the file's share of the archive
+	PhysicalSize uint64
+}
+
+// SavedRatio returns how much smaller the file's share of the archive is
+// than its original size, as a percentage
+func (f FileChunks) SavedRatio() float64 {
+	if f.LogicalSize == 0 || f.PhysicalSize > f.LogicalSize {
+		return 0
+	}
+	return float64(f.LogicalSize-f.PhysicalSize) / float64(f.LogicalSize) * 100
+}
+
+// DedupRatio returns the logical size per byte of physical size
+func (s *ChunkStats) DedupRatio() float64 {
+	if s.PhysicalSize == 0 {
+		return 0
+	}
+	return float64(s.LogicalSize) / float64(s.PhysicalSize)
+}
+
+// ArchiveChunkStats reads the header, chunk index and file metadata of a
+// GDELTA02 archive and counts the references of its chunks, returning the
+// top most referenced ones. Chunk data isn't read.
+func ArchiveChunkStats(path string, top int) (*ChunkStats, error) {
+	header, index, files, err := readChunkRefs(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	external, err := header.ReferenceChunks()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", path, ErrMalformedArchive, err)
+	}
+
+	// Chunks of reference directories count for their original size only
+	refs := chunkstore.NewRefCounter()
+	var refChunks uint64
+	for _, file := range files {
+		for _, hash := range file.ChunkHashes {
+			info, ok := index[hash]
+			if !ok && external.Has(hash) {
+				info = format.ChunkInfo{Hash: hash, OriginalSize: uint64(external.Chunks[hash].Size)}
+				if refs.Refs(hash) == 0 {
+					refChunks++
+				}
+			} else if !ok {
+				return nil, fmt.Errorf("%s: %w: %s references chunk %x", path, ErrMissingChunk, file.RelPath, hash[:8])
+			}
+			refs.Add(info)
+		}
+	}
+
+	counted := refs.Stats(top)
+	stats := &ChunkStats{
+		ArchivePath:  path,
+		Chunks:       uint64(header.ChunkCount),
+		References:   counted.References,
+		Unreferenced: uint64(len(index)) - (counted.Chunks - refChunks),
+		RefChunks:    refChunks,
+		LogicalSize:  counted.LogicalSize,
+		PhysicalSize: counted.PhysicalSize,
+	}
+	for _, c := range counted.Top {
+		stats.Top = append(stats.Top, ChunkRefs{
+			Hash:           c.Hash,
+			Refs:           c.Refs,
+			OriginalSize:   c.OriginalSize,
+			CompressedSize: c.CompressedSize,
+		})
+	}
+	for _, b := range counted.Distribution {
+		stats.Distribution = append(stats.Distribution, RefBucket(b))
+	}
+
+	for _, file := range files {
+		fc := FileChunks{Path: file.RelPath, Chunks: uint64(len(file.ChunkHashes)), LogicalSize: file.OrigSize}
+		var physical float64
+		for _, hash := range file.ChunkHashes {
+			n := refs.Refs(hash)
+			if n > 1 {
+				fc.SharedChunks++
+			}
+			physical += float64(index[hash].CompressedSize) / float64(n) // 0 for a reference chunk
+		}
+		fc.PhysicalSize = uint64(math.Round(physical))
+		stats.Files = append(stats.Files, fc)
+	}
+	slices.SortStableFunc(stats.Files, func(a, b FileChunks) int {
+		return cmp.Compare(int64(b.LogicalSize)-int64(b.PhysicalSize), int64(a.LogicalSize)-int64(a.PhysicalSize))
+	})
+	return stats, nil
+}
+
+// readChunkRefs reads the header, chunk index and file metadata of a
+// GDELTA02 archive
+func readChunkRefs(path string) (format.GDelta02Header, map[[32]byte]format.ChunkInfo, []format.FileMetadata, error) {
+	f, header, index, err := openChunkIndex(path)
+	if err != nil {
+		return header, nil, nil, err
+	}
+	defer f.Close()
+
+	files := make([]format.FileMetadata, 0, format.CapHint(header.FileCount))
+	for range header.FileCount {
+		m, err := format.ReadFileMetadata(f, header.Extended)
+		if err != nil {
+			return header, nil, nil, fmt.Errorf("%w: %v", headerError(err, ErrInvalidHeader), err)
+		}
+		files = append(files, m)
+	}
+	return header, index, files, nil
+}
+
+// Summary returns a human-readable report, listing up to files files
+func (s *ChunkStats) Summary(files int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Archive: %s\n", s.ArchivePath)
+	fmt.Fprintf(&sb, "  Chunks:          %d, %d references", s.Chunks, s.References)
+	if s.Unreferenced > 0 {
+		fmt.Fprintf(&sb, ", %d unreferenced", s.Unreferenced)
+	}
+	if s.RefChunks > 0 {
+		fmt.Fprintf(&sb, ", %d in reference directories", s.RefChunks)
+	}
+	sb.WriteString("\n")
+	fmt.Fprintf(&sb, "  Logical size:    %s\n", godelta.FormatSize(s.LogicalSize))
+	fmt.Fprintf(&sb, "  Physical size:   %s (%.2fx)\n", godelta.FormatSize(s.PhysicalSize), s.DedupRatio())
+
+	if len(s.Distribution) > 0 {
+		sb.WriteString("\nReference counts:\n")
+		for _, b := range s.Distribution {
+			refs := fmt.Sprintf("%d", b.MinRefs)
+			if b.MaxRefs > b.MinRefs {
+				refs = fmt.Sprintf("%d-%d", b.MinRefs, b.MaxRefs)
+			}
+			fmt.Fprintf(&sb, "  %-9s %8d chunks, %s stored, %s saved\n",
+				refs+":", b.Chunks, godelta.FormatSize(b.CompressedSize), godelta.FormatSize(b.SavedSize))
+		}
+	}
+
+	if len(s.Top) > 0 {
+		sb.WriteString("\nMost referenced chunks:\n")
+		for _, c := range s.Top {
+			fmt.Fprintf(&sb, "  %x  %6d refs, %s (%s stored)\n",
+				c.Hash[:8], c.Refs, godelta.FormatSize(c.OriginalSize), godelta.FormatSize(c.CompressedSize))
+		}
+	}
+
+	if files > 0 && len(s.Files) > 0 {
+		sb.WriteString("\nFiles (logical -> effective physical):\n")
+		for _, f := range s.Files[:min(files, len(s.Files))] {
+			fmt.Fprintf(&sb, "  %s: %s -> %s (%.1f%% saved, %d/%d chunks shared)\n",
+				f.Path, godelta.FormatSize(f.LogicalSize), godelta.FormatSize(f.PhysicalSize),
+				f.SavedRatio(), f.SharedChunks, f.Chunks)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,454 @@
+// pkg/verify/compare.go
+package verify
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+)
+
+// Comparison is the outcome of comparing the entries of an archive with the
+// files of a directory, as a restore would write them
+type Comparison struct {
+	Dir      string // Directory the archive was compared with
+	Matching int    // Entries whose content equals the file on disk
+	Modified int    // Entries whose file on disk has other content
+	Missing  int    // Entries with no file on disk
+	Extra    int    // Files on disk with no entry in the archive
+
+	ModifiedPaths []string
+	MissingPaths  []string
+	ExtraPaths    []string
+
+	mu   sync.Mutex
+	seen map[string]bool // Archive paths, to find the extra files
+}
+
+// Differs reports whether the directory doesn't hold exactly the archive's
+// files
+func (c *Comparison) Differs() bool {
+	return c.Modified+c.Missing+c.Extra > 0
+}
+
+// compareEntry is an archive entry to compare; extract writes its content
+type compareEntry struct {
+	path    string
+	size    uint64
+	extract func(w io.Writer) error
+}
+
+// fileState is how a file on disk relates to its archive entry
+type fileState int
+
+const (
+	fileMatching fileState = iota
+	fileModified
+	fileMissing
+)
+
+// compareDir extracts every entry of the archive in memory and compares its
+// content with the file of the same path under opts.CompareDir, then looks
+// for files the archive doesn't hold. Entries that can't be extracted are
+// reported in Errors and counted in none of the states.
+func compareDir(opts *Options, progressCb ProgressCallback, result *Result) {
+	c := &Comparison{Dir: opts.CompareDir, seen: make(map[string]bool)}
+	result.Compare = c
+
+	err := forEachEntry(opts, result, EventFileCompare, progressCb, func(e compareEntry) error {
+		return c.compare(e.path, e.size, e.extract)
+	})
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("compare with %s: %w", opts.CompareDir, err))
+		return
+	}
+
+	if err := c.findExtra(opts.InputPath); err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("compare with %s: %w", opts.CompareDir, err))
+	}
+	sort.Strings(c.ModifiedPaths)
+	sort.Strings(c.MissingPaths)
+}
+
+// forEachEntry calls fn with every file entry of the archive: on
+// MaxThreads workers for GDELTA and ZIP archives, in stream order for XZ.
+// Errors returned by fn are added to result.Errors; the returned error is
+// about reading the archive.
+func forEachEntry(opts *Options, result *Result, eventType EventType, progressCb ProgressCallback, fn func(e compareEntry) error) error {
+	if result.Format == FormatXZ {
+		return eachXzEntry(opts, func(e compareEntry) {
+			if err := fn(e); err != nil {
+				result.Errors = append(result.Errors, err)
+			}
+		})
+	}
+
+	entries, closeArchive, err := compareEntries(opts, result)
+	if err != nil {
+		return err
+	}
+	defer closeArchive()
+
+	errs := verifyParallel(opts.MaxThreads, len(entries), func(i int, _ *[]byte) error {
+		return fn(entries[i])
+	}, func(i, done int) {
+		if progressCb != nil {
+			progressCb(ProgressEvent{
+				Type:     eventType,
+				FilePath: entries[i].path,
+				Current:  done,
+				Total:    len(entries),
+			})
+		}
+	})
+	for _, err := range errs {
+		if err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+	}
+	return nil
+}
+
+// compare checks the file at path under c.Dir against an archive entry of
+// the given size. A file of another size is modified without extracting
+// the entry; otherwise the SHA-256 of the entry's content is compared with
+// the file's.
+func (c *Comparison) compare(path string, size uint64, extract func(w io.Writer) error) error {
+	state, err := c.fileState(path, size, extract)
+	if err != nil {
+		return fmt.Errorf("compare %s: %w", path, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seen[path] = true
+	switch state {
+	case fileMatching:
+		c.Matching++
+	case fileModified:
+		c.Modified++
+		c.ModifiedPaths = append(c.ModifiedPaths, path)
+	case fileMissing:
+		c.Missing++
+		c.MissingPaths = append(c.MissingPaths, path)
+	}
+	return nil
+}
+
+func (c *Comparison) fileState(path string, size uint64, extract func(w io.Writer) error) (fileState, error) {
+	f, err := os.Open(filepath.Join(c.Dir, filepath.FromSlash(path)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fileMissing, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if !info.Mode().IsRegular() || uint64(info.Size()) != size {
+		return fileModified, nil
+	}
+
+	entryHash := sha256.New()
+	if err := extract(entryHash); err != nil {
+		return 0, fmt.Errorf("extract: %w", err)
+	}
+	fileHash := sha256.New()
+	if _, err := io.Copy(fileHash, f); err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(entryHash.Sum(nil), fileHash.Sum(nil)) {
+		return fileModified, nil
+	}
+	return fileMatching, nil
+}
+
+// findExtra lists the regular files under c.Dir that the archive doesn't
+// hold, leaving out the archive itself
+func (c *Comparison) findExtra(archivePath string) error {
+	archiveInfo, _ := os.Stat(archivePath)
+	return filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(c.Dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if c.seen[rel] {
+			return nil
+		}
+		if archiveInfo != nil {
+			if info, err := d.Info(); err == nil && os.SameFile(info, archiveInfo) {
+				return nil
+			}
+		}
+		c.Extra++
+		c.ExtraPaths = append(c.ExtraPaths, rel)
+		return nil
+	})
+}
+
+// compareEntries lists the entries of a GDELTA or ZIP archive with
+// extractors reading it at offsets, so they can run on several workers.
+// The returned func closes the archive.
+func compareEntries(opts *Options, result *Result) ([]compareEntry, func(), error) {
+	if result.Format == FormatZIP {
+		return zipCompareEntries(opts)
+	}
+
+	f, err := os.Open(opts.InputPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	var entries []compareEntry
+	switch result.Format {
+	case FormatGDelta01:
+		entries, err = gdelta01CompareEntries(f, result.dataSize())
+	case FormatGDelta02:
+		entries, err = gdelta02CompareEntries(f)
+	case FormatGDelta03:
+		entries, err = gdelta03CompareEntries(f, result.dataSize())
+	}
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return entries, func() { f.Close() }, nil
+}
+
+func gdelta01CompareEntries(f *os.File, size int64) ([]compareEntry, error) {
+	list, _, err := format.ListGDelta01(f, size)
+	if err != nil {
+		return nil, err
+	}
+	list, _ = format.SplitPacks(list)
+	packs := format.NewPackCache(list)
+	entries := make([]compareEntry, len(list))
+	for i, e := range list {
+		entries[i] = compareEntry{path: e.Path, size: e.OriginalSize, extract: func(w io.Writer) error {
+			// A link extracts its target's data
+			e := e.Data()
+			if e == nil {
+				return ErrMissingLinkTarget
+			}
+			if e.Packed {
+				data, err := packs.Read(f, e)
+				if err != nil {
+					return err
+				}
+				_, err = w.Write(data)
+				return err
+			}
+			return decodeTo(w, e.Method, io.NewSectionReader(f, int64(e.DataOffset), int64(e.CompressedSize)))
+		}}
+	}
+	return entries, nil
+}
+
+func gdelta03CompareEntries(f *os.File, size int64) ([]compareEntry, error) {
+	header, err := format.ReadGDelta03Header(f)
+	if err != nil {
+		return nil, err
+	}
+	dictionary := make([]byte, header.DictSize)
+	if _, err := f.ReadAt(dictionary, header.DictOffset); err != nil {
+		return nil, fmt.Errorf("read dictionary: %w", err)
+	}
+	list, _, err := format.ScanGDelta03(f, size)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]compareEntry, len(list))
+	for i, e := range list {
+		entries[i] = compareEntry{path: e.Path, size: e.OriginalSize, extract: func(w io.Writer) error {
+			e := e.Data()
+			if e == nil {
+				return ErrMissingLinkTarget
+			}
+			data := io.NewSectionReader(f, int64(e.DataOffset), int64(e.CompressedSize))
+			if e.Method != format.MethodZstd {
+				return decodeTo(w, e.Method, data)
+			}
+			decOpts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+			if len(dictionary) > 0 {
+				decOpts = append(decOpts, zstd.WithDecoderDicts(dictionary))
+			}
+			decoder, err := zstd.NewReader(data, decOpts...)
+			if err != nil {
+				return err
+			}
+			defer decoder.Close()
+			_, err = io.Copy(w, decoder)
+			return err
+		}}
+	}
+	return entries, nil
+}
+
+func gdelta02CompareEntries(f *os.File) ([]compareEntry, error) {
+	header, err := format.ReadGDelta02Header(f)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := format.ReadChunkIndex(f, header.ChunkCount)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]format.FileMetadata, 0, format.CapHint(header.FileCount))
+	for range header.FileCount {
+		metadata, err := format.ReadFileMetadata(f, header.Extended)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, metadata)
+	}
+	chunkDataStart, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	dictionary := header.ChunkDictionary()
+	entries := make([]compareEntry, len(files))
+	for i, file := range files {
+		entries[i] = compareEntry{path: file.RelPath, size: file.OrigSize, extract: func(w io.Writer) error {
+			// Chunks compressed with a dictionary share one decoder holding it
+			var decoder *zstd.Decoder
+			if len(dictionary) > 0 {
+				var err error
+				if decoder, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderDicts(dictionary)); err != nil {
+					return err
+				}
+				defer decoder.Close()
+			}
+			for _, hash := range file.ChunkHashes {
+				info, ok := chunks[hash]
+				if !ok {
+					return fmt.Errorf("missing chunk %x", hash[:8])
+				}
+				data := io.NewSectionReader(f, chunkDataStart+int64(info.Offset), int64(info.CompressedSize))
+				var err error
+				if info.Stored {
+					_, err = io.Copy(w, data)
+				} else if decoder != nil {
+					if err = decoder.Reset(data); err == nil {
+						_, err = io.Copy(w, decoder)
+					}
+				} else {
+					err = decodeTo(w, header.Codec.Method(), data)
+				}
+				if err != nil {
+					return fmt.Errorf("chunk %x: %w", hash[:8], err)
+				}
+			}
+			return nil
+		}}
+	}
+	return entries, nil
+}
+
+// zipCompareEntries lists the files of every part of a ZIP archive
+func zipCompareEntries(opts *Options) ([]compareEntry, func(), error) {
+	var readers []*zip.ReadCloser
+	closeAll := func() {
+		for _, r := range readers {
+			r.Close()
+		}
+	}
+	var entries []compareEntry
+	for _, zipPath := range partPaths(opts.InputPath, ".zip") {
+		r, err := zip.OpenReader(zipPath)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		readers = append(readers, r)
+		for _, file := range r.File {
+			if file.FileInfo().IsDir() {
+				continue
+			}
+			entries = append(entries, compareEntry{path: file.Name, size: file.UncompressedSize64, extract: func(w io.Writer) error {
+				rc, err := file.Open()
+				if err != nil {
+					return err
+				}
+				defer rc.Close()
+				_, err = io.Copy(w, rc)
+				return err
+			}})
+		}
+	}
+	return entries, closeAll, nil
+}
+
+// eachXzEntry calls fn with the files of every part of a tar.xz archive, in
+// stream order; extract is only valid during the call
+func eachXzEntry(opts *Options, fn func(e compareEntry)) error {
+	for _, xzPath := range partPaths(opts.InputPath, ".tar.xz") {
+		file, err := os.Open(xzPath)
+		if err != nil {
+			return err
+		}
+		xzReader, err := xz.NewReader(file)
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("create xz reader: %w", err)
+		}
+		tarReader := tar.NewReader(xzReader)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				file.Close()
+				return fmt.Errorf("read tar header: %w", err)
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+			fn(compareEntry{path: header.Name, size: uint64(header.Size), extract: func(w io.Writer) error {
+				_, err := io.Copy(w, tarReader)
+				return err
+			}})
+		}
+		file.Close()
+	}
+	return nil
+}
+
+// decodeTo writes the content of data, compressed with method, to w
+func decodeTo(w io.Writer, method format.Method, data io.Reader) error {
+	codec, ok := format.LookupCodec(method)
+	if !ok {
+		return fmt.Errorf("unknown compression method: %s", method)
+	}
+	r, err := codec.NewReader(data)
+	if err != nil {
+		return fmt.Errorf("create decoder: %w", err)
+	}
+	defer r.Close()
+	_, err = io.Copy(w, r)
+	return err
+}
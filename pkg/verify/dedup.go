@@ -0,0 +1,214 @@
+// pkg/verify/dedup.go
+package verify
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// DedupReport is the chunk overlap of several GDELTA02 archives: how much
+// of their chunk data they hold in common, and what storing every chunk
+// once (a shared chunk repository) would save. Sizes are compressed chunk
+// data, as stored in the archives.
+type DedupReport struct {
+	Archives []ArchiveChunks
+	Pairs    []ArchivePair // One per pair of archives, in input order
+
+	TotalChunks    uint64 // Chunks summed over the archives
+	DistinctChunks uint64 // Chunks once each
+	TotalSize      uint64 // Chunk data summed over the archives
+	DistinctSize   uint64 // Chunk data with every chunk stored once
+
+	// ChunkSizesDiffer is set when the archives were chunked with
+	// different settings; their chunks then rarely line up
+	ChunkSizesDiffer bool
+}
+
+// ArchiveChunks are the chunk figures of one archive in a DedupReport
+type ArchiveChunks struct {
+	Path         string
+	ChunkSize    uint64
+	Chunks       uint64 // Chunks in its index
+	DataSize     uint64 // Their compressed size
+	OriginalSize uint64 // Their original size
+	SharedChunks uint64 // Chunks also in another archive
+	SharedSize   uint64 // Their compressed size
+}
+
+// SharedRatio returns the share of the archive's chunk data also held by
+// another archive, as a percentage
+func (a ArchiveChunks) SharedRatio() float64 {
+	if a.DataSize == 0 {
+		return 0
+	}
+	return float64(a.SharedSize) / float64(a.DataSize) * 100
+}
+
+// ArchivePair is the overlap of two archives, indexes into
+// DedupReport.Archives
+type ArchivePair struct {
+	A, B         int
+	SharedChunks uint64
+	SharedSize   uint64
+}
+
+// SavedSize returns the chunk data a shared repository wouldn't store twice
+func (r *DedupReport) SavedSize() uint64 {
+	return r.TotalSize - r.DistinctSize
+}
+
+// SavedRatio returns SavedSize as a percentage of TotalSize
+func (r *DedupReport) SavedRatio() float64 {
+	if r.TotalSize == 0 {
+		return 0
+	}
+	return float64(r.SavedSize()) / float64(r.TotalSize) * 100
+}
+
+// chunkOwners tracks which archives hold a chunk
+type chunkOwners struct {
+	size     uint64 // compressed size in the first archive holding it
+	archives []int
+}
+
+// CrossDedup reads the chunk indexes of GDELTA02 archives and reports the
+// chunks they share. Only the headers and indexes are read.
+func CrossDedup(paths []string) (*DedupReport, error) {
+	if len(paths) < 2 {
+		return nil, ErrDedupArchives
+	}
+
+	report := &DedupReport{}
+	chunks := make(map[[32]byte]*chunkOwners)
+	var first format.GDelta02Header
+	for i, path := range paths {
+		header, index, err := readChunkIndex(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		archive := ArchiveChunks{Path: path, ChunkSize: header.ChunkSize, Chunks: uint64(len(index))}
+		for hash, info := range index {
+			archive.DataSize += info.CompressedSize
+			archive.OriginalSize += info.OriginalSize
+			owners, ok := chunks[hash]
+			if !ok {
+				owners = &chunkOwners{size: info.CompressedSize}
+				chunks[hash] = owners
+			}
+			owners.archives = append(owners.archives, i)
+		}
+		if i == 0 {
+			first = header
+		} else if header.ChunkSize != first.ChunkSize || header.Bounds != first.Bounds {
+			report.ChunkSizesDiffer = true
+		}
+		report.Archives = append(report.Archives, archive)
+		report.TotalChunks += archive.Chunks
+		report.TotalSize += archive.DataSize
+	}
+
+	pairs := make(map[[2]int]*ArchivePair)
+	for a := range paths {
+		for b := a + 1; b < len(paths); b++ {
+			pairs[[2]int{a, b}] = &ArchivePair{A: a, B: b}
+		}
+	}
+	for _, owners := range chunks {
+		report.DistinctChunks++
+		report.DistinctSize += owners.size
+		if len(owners.archives) < 2 {
+			continue
+		}
+		for j, a := range owners.archives {
+			report.Archives[a].SharedChunks++
+			report.Archives[a].SharedSize += owners.size
+			for _, b := range owners.archives[j+1:] {
+				pair := pairs[[2]int{a, b}]
+				pair.SharedChunks++
+				pair.SharedSize += owners.size
+			}
+		}
+	}
+	for a := range paths {
+		for b := a + 1; b < len(paths); b++ {
+			report.Pairs = append(report.Pairs, *pairs[[2]int{a, b}])
+		}
+	}
+	return report, nil
+}
+
+// readChunkIndex reads the header and chunk index of a GDELTA02 archive
+func readChunkIndex(path string) (format.GDelta02Header, map[[32]byte]format.ChunkInfo, error) {
+	f, header, index, err := openChunkIndex(path)
+	if err != nil {
+		return header, nil, err
+	}
+	f.Close()
+	return header, index, nil
+}
+
+// openChunkIndex opens a GDELTA02 archive and reads its header and chunk
+// index, leaving the file at the first file metadata
+func openChunkIndex(path string) (f *os.File, header format.GDelta02Header, index map[[32]byte]format.ChunkInfo, err error) {
+	f, err = os.Open(path)
+	if err != nil {
+		return nil, header, nil, err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			f = nil
+		}
+	}()
+
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return f, header, nil, ErrTruncatedArchive
+	}
+	if format.DetectFormat(magic) != format.FormatGDelta02 {
+		return f, header, nil, fmt.Errorf("%w: chunk reports need GDELTA02 archives", ErrUnsupportedFormat)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return f, header, nil, err
+	}
+	header, err = format.ReadGDelta02Header(f)
+	if err != nil {
+		return f, header, nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	index, err = format.ReadChunkIndex(f, header.ChunkCount)
+	if err != nil {
+		return f, header, nil, fmt.Errorf("%w: %v", ErrInvalidChunkIndex, err)
+	}
+	return f, header, index, nil
+}
+
+// Summary returns a human-readable report
+func (r *DedupReport) Summary() string {
+	var sb strings.Builder
+	sb.WriteString("Archives:\n")
+	for _, a := range r.Archives {
+		fmt.Fprintf(&sb, "  %s\n", a.Path)
+		fmt.Fprintf(&sb, "    %d chunks of ~%s, %s stored (%s original), %.1f%% shared with the others\n",
+			a.Chunks, godelta.FormatSize(a.ChunkSize), godelta.FormatSize(a.DataSize), godelta.FormatSize(a.OriginalSize), a.SharedRatio())
+	}
+	if r.ChunkSizesDiffer {
+		sb.WriteString("\n⚠ The archives were chunked with different settings, so few chunks can match\n")
+	}
+
+	sb.WriteString("\nShared between pairs:\n")
+	for _, p := range r.Pairs {
+		fmt.Fprintf(&sb, "  %s <-> %s: %d chunks, %s\n",
+			r.Archives[p.A].Path, r.Archives[p.B].Path, p.SharedChunks, godelta.FormatSize(p.SharedSize))
+	}
+
+	sb.WriteString("\nShared repository:\n")
+	fmt.Fprintf(&sb, "  Chunks:          %d, %d distinct\n", r.TotalChunks, r.DistinctChunks)
+	fmt.Fprintf(&sb, "  Chunk data:      %s now, %s stored once\n", godelta.FormatSize(r.TotalSize), godelta.FormatSize(r.DistinctSize))
+	fmt.Fprintf(&sb, "  Saved:           %s (%.1f%%)\n", godelta.FormatSize(r.SavedSize()), r.SavedRatio())
+	return sb.String()
+}
@@ -0,0 +1,141 @@
+// pkg/verify/dedup_test.go
+package verify_test
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+func TestCrossDedup(t *testing.T) {
+	dir := t.TempDir()
+	rng := rand.New(rand.NewSource(1))
+	shared := make([]byte, 64*1024)
+	rng.Read(shared)
+
+	// Two archives holding the same file next to one of their own
+	var archives []string
+	for _, name := range []string{"a", "b"} {
+		input := filepath.Join(dir, name)
+		if err := os.MkdirAll(input, 0755); err != nil {
+			t.Fatal(err)
+		}
+		own := make([]byte, 64*1024)
+		rng.Read(own)
+		if err := os.WriteFile(filepath.Join(input, "shared.bin"), shared, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(input, "own.bin"), own, 0644); err != nil {
+			t.Fatal(err)
+		}
+		archive := filepath.Join(dir, name+".gdelta")
+		if _, err := compress.Compress(&compress.Options{
+			InputPath:    input,
+			OutputPath:   archive,
+			ChunkSize:    16 * 1024,
+			ChunkingMode: compress.ChunkingFixed,
+			Quiet:        true,
+		}, nil); err != nil {
+			t.Fatal(err)
+		}
+		archives = append(archives, archive)
+	}
+
+	report, err := verify.CrossDedup(archives)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.TotalChunks != 16 || report.DistinctChunks != 12 {
+		t.Errorf("chunks %d, distinct %d, want 16 and 12", report.TotalChunks, report.DistinctChunks)
+	}
+	if len(report.Pairs) != 1 || report.Pairs[0].SharedChunks != 4 {
+		t.Errorf("pairs %+v, want one sharing 4 chunks", report.Pairs)
+	}
+	for _, a := range report.Archives {
+		if a.SharedChunks != 4 || a.SharedRatio() < 40 || a.SharedRatio() > 60 {
+			t.Errorf("%s: %d shared chunks, %.1f%%", a.Path, a.SharedChunks, a.SharedRatio())
+		}
+	}
+	if report.SavedSize() != report.Pairs[0].SharedSize || report.ChunkSizesDiffer {
+		t.Errorf("saved %d, shared %d, differ %v", report.SavedSize(), report.Pairs[0].SharedSize, report.ChunkSizesDiffer)
+	}
+
+	if _, err := verify.CrossDedup(archives[:1]); !errors.Is(err, verify.ErrDedupArchives) {
+		t.Errorf("one archive: got %v, want ErrDedupArchives", err)
+	}
+	notGDelta02 := filepath.Join(dir, "a", "own.bin")
+	if _, err := verify.CrossDedup([]string{archives[0], notGDelta02}); !errors.Is(err, verify.ErrUnsupportedFormat) {
+		t.Errorf("plain file: got %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestArchiveChunkStats(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in")
+	if err := os.MkdirAll(input, 0755); err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	shared, own := make([]byte, 64*1024), make([]byte, 64*1024)
+	rng.Read(shared)
+	rng.Read(own)
+	// Three copies of one file next to one of its own
+	for name, data := range map[string][]byte{"a.bin": shared, "b.bin": shared, "c.bin": shared, "own.bin": own} {
+		if err := os.WriteFile(filepath.Join(input, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive := filepath.Join(dir, "in.gdelta")
+	if _, err := compress.Compress(&compress.Options{
+		InputPath:    input,
+		OutputPath:   archive,
+		ChunkSize:    16 * 1024,
+		ChunkingMode: compress.ChunkingFixed,
+		Quiet:        true,
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := verify.ArchiveChunkStats(archive, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Chunks != 8 || stats.References != 16 || stats.Unreferenced != 0 {
+		t.Errorf("chunks %d, references %d, unreferenced %d, want 8, 16, 0", stats.Chunks, stats.References, stats.Unreferenced)
+	}
+	if stats.LogicalSize != 4*64*1024 {
+		t.Errorf("logical size %d, want %d", stats.LogicalSize, 4*64*1024)
+	}
+	if len(stats.Top) != 2 || stats.Top[0].Refs != 3 || stats.Top[1].Refs != 3 {
+		t.Errorf("top %+v, want two chunks of 3 references", stats.Top)
+	}
+	if len(stats.Distribution) != 2 ||
+		stats.Distribution[0].MinRefs != 1 || stats.Distribution[0].Chunks != 4 ||
+		stats.Distribution[1].MinRefs != 3 || stats.Distribution[1].MaxRefs != 4 || stats.Distribution[1].Chunks != 4 ||
+		stats.Distribution[1].SavedSize != 2*64*1024 {
+		t.Errorf("distribution %+v, want 4 chunks of 1 reference and 4 of 3-4 saving 128KB", stats.Distribution)
+	}
+
+	if len(stats.Files) != 4 || stats.Files[3].Path != "own.bin" {
+		t.Fatalf("files %+v, want own.bin last", stats.Files)
+	}
+	var physical uint64
+	for _, f := range stats.Files {
+		physical += f.PhysicalSize
+		if f.Path != "own.bin" && (f.SharedChunks != 4 || f.SavedRatio() < 60) {
+			t.Errorf("%s: %d shared chunks, %.1f%% saved, want 4 and about 67%%", f.Path, f.SharedChunks, f.SavedRatio())
+		}
+	}
+	if physical < stats.PhysicalSize-4 || physical > stats.PhysicalSize+4 {
+		t.Errorf("file physical sizes sum to %d, archive %d", physical, stats.PhysicalSize)
+	}
+
+	if _, err := verify.ArchiveChunkStats(filepath.Join(input, "own.bin"), 2); !errors.Is(err, verify.ErrUnsupportedFormat) {
+		t.Errorf("plain file: got %v, want ErrUnsupportedFormat", err)
+	}
+}
@@ -0,0 +1,70 @@
+// pkg/verify/describe.go
+package verify
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/parity"
+)
+
+// Field is one span of an archive in a Layout
+type Field struct {
+	Offset int64
+	Size   int64
+	Name   string // Dotted path such as "header.magic" or "entry[3].path"
+	Value  string // Decoded value, a summary for data and tables
+}
+
+// Layout is the annotated on-disk structure of an archive
+type Layout struct {
+	Format  Format
+	Size    int64   // Archive size, parity included
+	Fields  []Field // In file order, the parity section last
+	Omitted int     // Table entries past maxEntries, walked but not listed
+}
+
+// Describe walks the GDELTA archive at path field by field: header fields,
+// extension areas, entry tables, data spans, footer, index and parity. Only
+// the first maxEntries entries of each table are listed (0 = all). A field
+// that doesn't fit in the archive stops the walk: the layout so far is
+// returned with ErrMalformedArchive.
+func Describe(path string, maxEntries int) (*Layout, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat archive: %w", err)
+	}
+
+	dataSize := parity.DataSize(f, info.Size())
+	described, walkErr := format.Describe(f, dataSize, maxEntries)
+	layout := &Layout{
+		Format:  Format(described.Format.String()),
+		Size:    info.Size(),
+		Omitted: described.Omitted,
+	}
+	for _, field := range described.Fields {
+		layout.Fields = append(layout.Fields, Field(field))
+	}
+	if dataSize < info.Size() {
+		layout.Fields = append(layout.Fields, Field{
+			Offset: dataSize,
+			Size:   info.Size() - dataSize,
+			Name:   "parity",
+			Value:  "Reed-Solomon parity section",
+		})
+	}
+
+	switch {
+	case walkErr == nil:
+		return layout, nil
+	case described.Format == format.FormatUnknown, described.Format == format.FormatZIP, described.Format == format.FormatXZ:
+		return layout, fmt.Errorf("%w: %v", ErrUnsupportedFormat, walkErr)
+	}
+	return layout, fmt.Errorf("%w: %v", ErrMalformedArchive, walkErr)
+}
@@ -1,36 +1,75 @@
 // pkg/verify/errors.go
 package verify
 
-import "errors"
+import "github.com/creativeyann17/go-delta/pkg/godelta"
 
+// Every error matches a godelta error category with errors.Is
 var (
 	// ErrInputRequired is returned when input path is not specified
-	ErrInputRequired = errors.New("input path is required")
+	ErrInputRequired = godelta.NewError(godelta.ErrUsage, "input path is required")
 
 	// ErrInvalidMagic is returned when archive has invalid magic bytes
-	ErrInvalidMagic = errors.New("invalid archive magic bytes")
+	ErrInvalidMagic = godelta.NewError(godelta.ErrCorrupt, "invalid archive magic bytes")
 
 	// ErrInvalidHeader is returned when archive header is malformed
-	ErrInvalidHeader = errors.New("invalid archive header")
+	ErrInvalidHeader = godelta.NewError(godelta.ErrCorrupt, "invalid archive header")
 
 	// ErrInvalidFooter is returned when archive footer is invalid or missing
-	ErrInvalidFooter = errors.New("invalid archive footer")
+	ErrInvalidFooter = godelta.NewError(godelta.ErrCorrupt, "invalid archive footer")
 
 	// ErrInvalidChunkIndex is returned when chunk index is malformed (GDELTA02)
-	ErrInvalidChunkIndex = errors.New("invalid chunk index")
+	ErrInvalidChunkIndex = godelta.NewError(godelta.ErrCorrupt, "invalid chunk index")
+
+	// ErrInvalidEntryIndex is returned when the entry index at the end of a
+	// GDELTA01 archive is damaged or doesn't match its entries
+	ErrInvalidEntryIndex = godelta.NewError(godelta.ErrCorrupt, "invalid entry index")
 
 	// ErrMissingChunk is returned when a referenced chunk is not in the index
-	ErrMissingChunk = errors.New("referenced chunk not found in index")
+	ErrMissingChunk = godelta.NewError(godelta.ErrCorrupt, "referenced chunk not found in index")
+
+	// ErrOrphanedChunk is the warning for chunks not referenced by any file
+	ErrOrphanedChunk = godelta.NewError(godelta.ErrCorrupt, "chunk not referenced by any file")
+
+	// ErrDuplicatePath is returned for an entry whose path an earlier entry
+	// already holds
+	ErrDuplicatePath = godelta.NewError(godelta.ErrCorrupt, "duplicate path")
 
-	// ErrOrphanedChunk is returned when a chunk is not referenced by any file
-	ErrOrphanedChunk = errors.New("chunk not referenced by any file")
+	// ErrUnknownExtensions is the warning for optional fields written by a
+	// newer version, skipped by this one
+	ErrUnknownExtensions = godelta.NewError(godelta.ErrUnsupported, "unknown optional fields skipped")
+
+	// ErrMissingLinkTarget is returned for a deduplicated file whose
+	// linked entry is missing, after it, or not a file
+	ErrMissingLinkTarget = godelta.NewError(godelta.ErrCorrupt, "linked file not found in the archive")
 
 	// ErrCorruptData is returned when decompressed data fails integrity check
-	ErrCorruptData = errors.New("data corruption detected")
+	ErrCorruptData = godelta.NewError(godelta.ErrCorrupt, "data corruption detected")
 
 	// ErrTruncatedArchive is returned when archive appears truncated
-	ErrTruncatedArchive = errors.New("archive appears truncated")
+	ErrTruncatedArchive = godelta.NewError(godelta.ErrCorrupt, "archive appears truncated")
+
+	// ErrMalformedArchive is returned when a field of an archive's layout
+	// doesn't fit in it: a length or count past the end of the file or
+	// past the read limits (see godelta.SetReadLimits)
+	ErrMalformedArchive = godelta.NewError(godelta.ErrCorrupt, "malformed archive structure")
+
+	// ErrUnsupportedFeature is returned for an archive requiring a feature
+	// this version doesn't support (see Result.Features)
+	ErrUnsupportedFeature = godelta.NewError(godelta.ErrUnsupported, "archive requires an unsupported feature (upgrade godelta)")
 
 	// ErrUnsupportedFormat is returned for unknown archive formats
-	ErrUnsupportedFormat = errors.New("unsupported archive format")
+	ErrUnsupportedFormat = godelta.NewError(godelta.ErrUnsupported, "unsupported archive format")
+
+	// ErrStateRequired is returned when a scrub has no state file
+	ErrStateRequired = godelta.NewError(godelta.ErrUsage, "scrub state path is required")
+
+	// ErrInvalidScrubState is returned when the scrub state file can't be parsed
+	ErrInvalidScrubState = godelta.NewError(godelta.ErrCorrupt, "invalid scrub state")
+
+	// ErrDedupArchives is returned when a dedup report is given fewer than two archives
+	ErrDedupArchives = godelta.NewError(godelta.ErrUsage, "a dedup report needs at least two archives")
+
+	// ErrDamagedBlocks is returned when archive or parity blocks don't match
+	// the checksums of the parity section
+	ErrDamagedBlocks = godelta.NewError(godelta.ErrCorrupt, "blocks don't match their parity checksums")
 )
@@ -0,0 +1,175 @@
+// pkg/verify/extensions_test.go
+package verify_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/zeebo/blake3"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/pkg/decompress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// futureField is an extension type no version interprets yet, standing in
+// for a field added by a newer writer
+var futureField = []format.Extension{{Type: 0xfe01, Value: []byte("from the future")}}
+
+var extensionFiles = map[string][]byte{
+	"a.txt":     []byte("alpha alpha alpha"),
+	"dir/b.txt": bytes.Repeat([]byte("beta "), 100),
+}
+
+// TestUnknownExtensionsSkipped writes extended GDELTA02/03 archives carrying
+// unknown TLV fields in the header and every entry, and checks that verify
+// counts them without failing and decompress restores the files
+func TestUnknownExtensionsSkipped(t *testing.T) {
+	builders := map[string]func(t *testing.T, path string){
+		"GDELTA02": writeExtendedGDelta02,
+		"GDELTA03": writeExtendedGDelta03,
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "extended.gdelta")
+			build(t, archivePath)
+
+			result, err := verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true}, nil)
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if !result.IsValid() {
+				t.Fatalf("extended archive should be valid:\n%s", result.Summary())
+			}
+			// One in the header plus one per file
+			if want := 1 + len(extensionFiles); result.UnknownExtensions != want {
+				t.Errorf("expected %d unknown extensions, got %d", want, result.UnknownExtensions)
+			}
+
+			// The recovery scan walks entries itself and must skip the areas too
+			for _, recoverMode := range []bool{false, true} {
+				outDir := t.TempDir()
+				dres, err := decompress.Decompress(&decompress.Options{
+					InputPath:  archivePath,
+					OutputPath: outDir,
+					Recover:    recoverMode,
+					Quiet:      true,
+				}, nil)
+				if err != nil {
+					t.Fatalf("decompress (recover=%v): %v", recoverMode, err)
+				}
+				if len(dres.Errors) > 0 {
+					t.Fatalf("decompress (recover=%v) errors: %v", recoverMode, dres.Errors)
+				}
+				for rel, want := range extensionFiles {
+					got, err := os.ReadFile(filepath.Join(outDir, rel))
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !bytes.Equal(got, want) {
+						t.Errorf("%s (recover=%v): content mismatch", rel, recoverMode)
+					}
+				}
+			}
+		})
+	}
+}
+
+func encodeAll(t *testing.T, data []byte) []byte {
+	t.Helper()
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close()
+	return enc.EncodeAll(data, nil)
+}
+
+// writeExtendedGDelta02 stores each file as a single chunk
+func writeExtendedGDelta02(t *testing.T, path string) {
+	var metadata []format.FileMetadata
+	var data bytes.Buffer
+	index := make(map[[32]byte]format.ChunkInfo)
+	for rel, content := range extensionFiles {
+		hash := blake3.Sum256(content)
+		comp := encodeAll(t, content)
+		index[hash] = format.ChunkInfo{
+			Hash:           hash,
+			Offset:         uint64(data.Len()),
+			CompressedSize: uint64(len(comp)),
+			OriginalSize:   uint64(len(content)),
+		}
+		data.Write(comp)
+		metadata = append(metadata, format.FileMetadata{
+			RelPath:     rel,
+			OrigSize:    uint64(len(content)),
+			ChunkHashes: [][32]byte{hash},
+			Extensions:  futureField,
+		})
+	}
+
+	var buf bytes.Buffer
+	header := format.GDelta02Header{
+		ChunkSize:  64 * 1024,
+		Codec:      format.CodecZstd,
+		Level:      3,
+		FileCount:  uint32(len(metadata)),
+		ChunkCount: uint32(len(index)),
+		Extended:   true,
+		Extensions: futureField,
+	}
+	if err := format.WriteGDelta02Header(&buf, header); err != nil {
+		t.Fatal(err)
+	}
+	if err := format.WriteChunkIndex(&buf, index); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range metadata {
+		if err := format.WriteFileMetadata(&buf, m, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf.Write(data.Bytes())
+	if err := format.WriteArchiveFooter02(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeExtendedGDelta03 writes entries without a dictionary
+func writeExtendedGDelta03(t *testing.T, path string) {
+	var buf bytes.Buffer
+	header := format.GDelta03Header{
+		FileCount:  uint32(len(extensionFiles)),
+		Extended:   true,
+		Extensions: futureField,
+	}
+	if err := format.WriteGDelta03Header(&buf, header); err != nil {
+		t.Fatal(err)
+	}
+	for rel, content := range extensionFiles {
+		comp := encodeAll(t, content)
+		entry := format.GDelta03FileEntry{
+			Path:           rel,
+			OriginalSize:   uint64(len(content)),
+			CompressedSize: uint64(len(comp)),
+			Extensions:     futureField,
+		}
+		if err := format.WriteGDelta03FileEntry(&buf, entry, true); err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(comp)
+	}
+	if err := format.WriteArchiveFooter03(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,67 @@
+// pkg/verify/filetype_test.go
+package verify_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// TestSniffedTypes archives files whose extensions don't tell their type
+// and reads back the types sniffed from their content
+func TestSniffedTypes(t *testing.T) {
+	sourceDir := t.TempDir()
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(bytes.Repeat([]byte("compressed already "), 1024))
+	zw.Close()
+	files := map[string][]byte{
+		"notes.dat": bytes.Repeat([]byte("plain text, nothing else\n"), 100),
+		"blob.bin":  gz.Bytes(),
+		"empty":     {},
+	}
+	want := map[string]string{"notes.dat": "text/plain", "blob.bin": "application/gzip", "empty": "inode/x-empty"}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(sourceDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, opts := range map[string]*compress.Options{
+		"GDELTA02": {ChunkSize: 64 * 1024},
+		"GDELTA03": {UseDictionary: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "typed.gdelta")
+			opts.InputPath, opts.OutputPath, opts.Quiet, opts.SniffTypes = sourceDir, archivePath, true, true
+			cresult, err := compress.Compress(opts, nil)
+			if err != nil {
+				t.Fatalf("compress: %v", err)
+			}
+			if len(cresult.Types) != 3 || cresult.Types["text/plain"] != 1 || cresult.Types["application/gzip"] != 1 {
+				t.Errorf("types %v", cresult.Types)
+			}
+			if name == "GDELTA03" && cresult.StoredFiles != 1 {
+				t.Errorf("%d files stored as-is, want the gzip one", cresult.StoredFiles)
+			}
+
+			result, err := verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true}, nil)
+			if err != nil || !result.IsValid() {
+				t.Fatalf("verify: %v\n%s", err, result.Summary())
+			}
+			if result.UnknownExtensions != 0 {
+				t.Errorf("types counted as %d unknown extensions", result.UnknownExtensions)
+			}
+			for _, f := range result.Files {
+				if f.Type != want[f.Path] {
+					t.Errorf("%s: type %q, want %q", f.Path, f.Type, want[f.Path])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// pkg/verify/issue.go
+package verify
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// Severity tells the errors that make an archive invalid from the warnings
+// that don't
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is an error or warning of a Result in a machine-readable form
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`    // See ErrorCode
+	Message  string   `json:"message"` // The error's text
+}
+
+// codes are the codes of the sentinel errors, in the order ErrorCode
+// checks them
+var codes = []struct {
+	err  error
+	code string
+}{
+	{ErrInvalidMagic, "invalid_magic"},
+	{ErrInvalidHeader, "invalid_header"},
+	{ErrInvalidFooter, "invalid_footer"},
+	{ErrInvalidChunkIndex, "invalid_chunk_index"},
+	{ErrInvalidEntryIndex, "invalid_entry_index"},
+	{ErrMissingChunk, "missing_chunk"},
+	{ErrOrphanedChunk, "orphaned_chunk"},
+	{ErrMissingLinkTarget, "missing_link_target"},
+	{ErrDuplicatePath, "duplicate_path"},
+	{ErrCorruptData, "corrupt_data"},
+	{ErrTruncatedArchive, "truncated_archive"},
+	{ErrMalformedArchive, "malformed_archive"},
+	{ErrUnsupportedFeature, "unsupported_feature"},
+	{ErrUnsupportedFormat, "unsupported_format"},
+	{ErrUnknownExtensions, "unknown_extensions"},
+	{ErrDamagedBlocks, "damaged_blocks"},
+}
+
+// categoryCodes are the codes of the godelta categories, for errors
+// without a sentinel of their own
+var categoryCodes = map[error]string{
+	godelta.ErrUsage:       "usage",
+	godelta.ErrNotFound:    "not_found",
+	godelta.ErrExists:      "exists",
+	godelta.ErrPermission:  "permission",
+	godelta.ErrNoSpace:     "no_space",
+	godelta.ErrIO:          "io",
+	godelta.ErrCorrupt:     "corrupt",
+	godelta.ErrUnsupported: "unsupported",
+	godelta.ErrCanceled:    "canceled",
+	godelta.ErrPartial:     "partial",
+}
+
+// ErrorCode returns the machine-readable code of an error or warning of a
+// Result: the code of the sentinel error it wraps ("missing_chunk"), else
+// that of its godelta category ("io"), else "error". Codes are stable
+// across versions.
+func ErrorCode(err error) string {
+	for _, c := range codes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	if code, ok := categoryCodes[godelta.Category(err)]; ok {
+		return code
+	}
+	return "error"
+}
+
+// Issues returns the Errors then the Warnings of the result as issues
+func (r *Result) Issues() []Issue {
+	issues := make([]Issue, 0, len(r.Errors)+len(r.Warnings))
+	for _, err := range r.Errors {
+		issues = append(issues, newIssue(SeverityError, err))
+	}
+	for _, err := range r.Warnings {
+		issues = append(issues, newIssue(SeverityWarning, err))
+	}
+	return issues
+}
+
+func newIssue(severity Severity, err error) Issue {
+	return Issue{Severity: severity, Code: ErrorCode(err), Message: err.Error()}
+}
+
+// MarshalJSON encodes the result with its Errors and Warnings as lists of
+// Issues, file errors as their text and damaged chunks as hex hashes, so
+// automation can triage a result without matching error strings
+func (r *Result) MarshalJSON() ([]byte, error) {
+	type plainResult Result
+	type plainFileInfo FileInfo
+	type fileInfo struct {
+		*plainFileInfo
+		Error string `json:",omitempty"`
+	}
+
+	files := make([]fileInfo, len(r.Files))
+	for i := range r.Files {
+		files[i].plainFileInfo = (*plainFileInfo)(&r.Files[i])
+		if err := r.Files[i].Error; err != nil {
+			files[i].Error = err.Error()
+		}
+	}
+	damaged := make([]string, len(r.DamagedChunks))
+	for i, hash := range r.DamagedChunks {
+		damaged[i] = hex.EncodeToString(hash[:])
+	}
+	errs, warnings := make([]Issue, 0, len(r.Errors)), make([]Issue, 0, len(r.Warnings))
+	for _, issue := range r.Issues() {
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue)
+		} else {
+			warnings = append(warnings, issue)
+		}
+	}
+
+	return json.Marshal(struct {
+		*plainResult
+		Valid         bool
+		Files         []fileInfo
+		DamagedChunks []string
+		Errors        []Issue
+		Warnings      []Issue
+	}{(*plainResult)(r), r.IsValid(), files, damaged, errs, warnings})
+}
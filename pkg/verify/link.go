@@ -0,0 +1,52 @@
+// pkg/verify/link.go
+package verify
+
+import (
+	"fmt"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+)
+
+// linkCheck is a file deduplicated as a link: its data is its target's,
+// checked once
+type linkCheck struct {
+	file   int // index in result.Files
+	target *format.FileEntry
+}
+
+// checkLinkEntry reports a link entry without a target of its size,
+// returning whether it has one
+func checkLinkEntry(entry *format.FileEntry, result *Result) bool {
+	switch {
+	case entry.Target == nil:
+		result.Errors = append(result.Errors, fmt.Errorf("%s: %w", entry.Path, ErrMissingLinkTarget))
+	case entry.Target.OriginalSize != entry.OriginalSize:
+		result.Errors = append(result.Errors, fmt.Errorf("%s: size %d differs from linked file %s (%d)",
+			entry.Path, entry.OriginalSize, entry.Target.Path, entry.Target.OriginalSize))
+	default:
+		return true
+	}
+	result.MetadataValid = false
+	return false
+}
+
+// verifyLinks gives each link the data outcome of its target, the file at
+// index files[target] of result.Files
+func verifyLinks(links []linkCheck, files map[*format.FileEntry]int, result *Result) {
+	for _, l := range links {
+		i, ok := files[l.target]
+		if !ok {
+			continue
+		}
+		target, file := result.Files[i], &result.Files[l.file]
+		switch {
+		case target.DataValid:
+			file.DataValid = true
+			result.FilesVerified++
+		case target.Error != nil:
+			file.Error = fmt.Errorf("linked file %s: %w", target.Path, target.Error)
+			result.CorruptFiles++
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", file.Path, file.Error))
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// pkg/verify/manifest.go
+package verify
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/creativeyann17/go-delta/internal/manifest"
+)
+
+// ManifestCheck is the outcome of checking the archive's entries against
+// the SHA-256 manifest written by compress --write-manifest
+type ManifestCheck struct {
+	Path       string // Manifest file
+	Matching   int    // Entries whose content has the listed digest
+	Mismatched int    // Entries whose content has another digest
+	Missing    int    // Files listed in the manifest that the archive doesn't hold
+	Unlisted   int    // Entries the manifest doesn't list
+
+	MismatchedPaths []string
+	MissingPaths    []string
+	UnlistedPaths   []string
+
+	mu sync.Mutex
+}
+
+// Differs reports whether the archive doesn't hold exactly the files of
+// the manifest with their listed content
+func (m *ManifestCheck) Differs() bool {
+	return m.Mismatched+m.Missing+m.Unlisted > 0
+}
+
+// partName matches the first part of a multi-part archive, whose manifest
+// is named after the whole set
+var partName = regexp.MustCompile(`^(.*)_\d\d(\.zip|\.tar\.xz)$`)
+
+// manifestPath returns the manifest of an archive: opts.Manifest, or the
+// <archive>.sha256 sidecar (name.zip.sha256 for name_01.zip, ...) when it
+// exists; "" when there is none
+func manifestPath(opts *Options) string {
+	if opts.Manifest != "" {
+		return opts.Manifest
+	}
+	candidates := []string{opts.InputPath + ".sha256"}
+	if m := partName.FindStringSubmatch(opts.InputPath); m != nil {
+		candidates = append(candidates, m[1]+m[2]+".sha256")
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// checkManifest extracts every entry of the archive in memory and compares
+// its SHA-256 with the manifest's. Entries that can't be extracted are
+// reported in Errors and counted in none of the states.
+func checkManifest(opts *Options, path string, progressCb ProgressCallback, result *Result) {
+	f, err := os.Open(path)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("manifest %s: %w", path, err))
+		return
+	}
+	sums, err := manifest.Read(f)
+	f.Close()
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("manifest %s: %w", path, err))
+		return
+	}
+
+	m := &ManifestCheck{Path: path}
+	result.Manifest = m
+	seen := make(map[string]bool, len(sums))
+	err = forEachEntry(opts, result, EventManifestCheck, progressCb, func(e compareEntry) error {
+		want, listed := sums[e.path]
+		var got [32]byte
+		if listed {
+			h := sha256.New()
+			if err := e.extract(h); err != nil {
+				return fmt.Errorf("manifest %s: extract: %w", e.path, err)
+			}
+			h.Sum(got[:0])
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		seen[e.path] = true
+		switch {
+		case !listed:
+			m.Unlisted++
+			m.UnlistedPaths = append(m.UnlistedPaths, e.path)
+		case got != want:
+			m.Mismatched++
+			m.MismatchedPaths = append(m.MismatchedPaths, e.path)
+		default:
+			m.Matching++
+		}
+		return nil
+	})
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("manifest %s: %w", path, err))
+		return
+	}
+
+	for listedPath := range sums {
+		if !seen[listedPath] {
+			m.Missing++
+			m.MissingPaths = append(m.MissingPaths, listedPath)
+		}
+	}
+	sort.Strings(m.MismatchedPaths)
+	sort.Strings(m.MissingPaths)
+	sort.Strings(m.UnlistedPaths)
+}
@@ -0,0 +1,89 @@
+// pkg/verify/metadata.go
+package verify
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// headerMetadata decodes the metadata extension of a GDELTA02/03 header,
+// recording a malformed one as an error. Returns nil when there is none.
+func headerMetadata(exts []format.Extension, result *Result) *godelta.ArchiveMetadata {
+	data, ok := format.FindExtension(exts, format.ExtMetadata)
+	if !ok {
+		return nil
+	}
+	m, err := godelta.DecodeMetadata(data)
+	if err != nil {
+		result.Errors = append(result.Errors, err)
+		return nil
+	}
+	return m
+}
+
+// ReadMetadata reads the metadata recorded in the header of the archive at
+// path, without checking the rest of it. Returns nil for archives without
+// metadata, including every format other than GDELTA02 and GDELTA03.
+func ReadMetadata(path string) (*godelta.ArchiveMetadata, error) {
+	exts, err := readHeaderExtensions(path)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := format.FindExtension(exts, format.ExtMetadata)
+	if !ok {
+		return nil, nil
+	}
+	return godelta.DecodeMetadata(data)
+}
+
+// ReadFormatSpec reads the layout summary 'compress --archival' embeds in
+// the header of the archive at path. Returns "" for archives without one.
+func ReadFormatSpec(path string) (string, error) {
+	exts, err := readHeaderExtensions(path)
+	if err != nil {
+		return "", err
+	}
+	data, _ := format.FindExtension(exts, format.ExtFormatSpec)
+	return string(data), nil
+}
+
+// readHeaderExtensions reads the header extensions of the archive at path,
+// none for formats other than GDELTA02 and GDELTA03
+func readHeaderExtensions(path string) ([]format.Extension, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, format.MagicSize)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return nil, fmt.Errorf("%w: read magic: %v", ErrTruncatedArchive, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek to start: %w", err)
+	}
+
+	var exts []format.Extension
+	switch format.DetectFormat(magic) {
+	case format.FormatGDelta02:
+		header, err := format.ReadGDelta02Header(f)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+		}
+		exts = header.Extensions
+	case format.FormatGDelta03:
+		header, err := format.ReadGDelta03Header(f)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+		}
+		exts = header.Extensions
+	case format.FormatUnknown:
+		return nil, ErrUnsupportedFormat
+	}
+	return exts, nil
+}
@@ -0,0 +1,73 @@
+// pkg/verify/metadata_test.go
+package verify_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+func TestArchiveMetadata(t *testing.T) {
+	sourceDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("alpha alpha alpha"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	modes := map[string]*compress.Options{
+		"GDELTA02": {ChunkSize: 64 * 1024},
+		"GDELTA03": {UseDictionary: true},
+	}
+	for name, opts := range modes {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "labelled.gdelta")
+			opts.InputPath = sourceDir
+			opts.OutputPath = archivePath
+			opts.Quiet = true
+			opts.Metadata = &godelta.ArchiveMetadata{Version: "v1.2.3", Labels: map[string]string{"env": "prod", "team": "ops"}}
+			if _, err := compress.Compress(opts, nil); err != nil {
+				t.Fatalf("compress: %v", err)
+			}
+
+			result, err := verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true}, nil)
+			if err != nil || !result.IsValid() {
+				t.Fatalf("verify: %v\n%s", err, result.Summary())
+			}
+			if result.UnknownExtensions != 0 {
+				t.Errorf("metadata counted as %d unknown extensions", result.UnknownExtensions)
+			}
+
+			m, err := verify.ReadMetadata(archivePath)
+			if err != nil {
+				t.Fatalf("read metadata: %v", err)
+			}
+			for _, got := range []*godelta.ArchiveMetadata{result.Metadata, m} {
+				if got == nil {
+					t.Fatal("no metadata read back")
+				}
+				if got.Version != "v1.2.3" || got.Labels["env"] != "prod" || got.Labels["team"] != "ops" {
+					t.Errorf("metadata %+v", got)
+				}
+				if got.Created.IsZero() || got.Source != sourceDir {
+					t.Errorf("created %v, source %q: not filled in", got.Created, got.Source)
+				}
+			}
+		})
+	}
+
+	t.Run("GDELTA01", func(t *testing.T) {
+		_, err := compress.Compress(&compress.Options{
+			InputPath:  sourceDir,
+			OutputPath: filepath.Join(t.TempDir(), "plain.gdelta"),
+			Quiet:      true,
+			Metadata:   &godelta.ArchiveMetadata{},
+		}, nil)
+		if !errors.Is(err, compress.ErrMetadataFormat) {
+			t.Errorf("got %v, want ErrMetadataFormat", err)
+		}
+	})
+}
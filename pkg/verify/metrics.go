@@ -0,0 +1,15 @@
+// pkg/verify/metrics.go
+package verify
+
+import (
+	"time"
+
+	"github.com/creativeyann17/go-delta/pkg/metrics"
+)
+
+// recordRun records a finished verification
+func recordRun(result *Result, start time.Time) {
+	metrics.OperationDuration.Observe(time.Since(start).Seconds(), metrics.OpVerify)
+	metrics.FilesProcessed.Add(float64(len(result.Files)-result.CorruptFiles), metrics.OpVerify)
+	metrics.FilesFailed.Add(float64(result.CorruptFiles), metrics.OpVerify)
+}
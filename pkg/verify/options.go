@@ -1,6 +1,12 @@
 // pkg/verify/options.go
 package verify
 
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
 // Options configures the verify operation
 type Options struct {
 	// InputPath is the archive file to verify (required)
@@ -11,11 +17,54 @@ type Options struct {
 	// Default: false
 	VerifyData bool
 
+	// MaxThreads is the number of files (GDELTA01, GDELTA03) or chunks
+	// (GDELTA02) checked at once during data verification. Workers read the
+	// archive at offsets, so they share one handle.
+	// Default: runtime.NumCPU()
+	MaxThreads int
+
+	// Mmap reads GDELTA01 and GDELTA02 data from a memory mapping of the
+	// archive during data verification. Archives that can't be mapped
+	// (pipes, network filesystems, unsupported platforms) are read as usual.
+	Mmap bool
+
+	// ReadLimit caps the archive data read during data and parity
+	// verification, in bytes per second, shared by the workers; archives
+	// on slow or shared storage are then checked without starving others.
+	// Reads of CompareDir and Manifest checks aren't limited.
+	// Default: 0 (no limit)
+	ReadLimit int64
+
+	// CompareDir is a directory to compare the archive with, such as the
+	// one it was made from. Every entry is extracted in memory and its
+	// SHA-256 compared with the file of the same path there; files the
+	// archive doesn't hold are listed too (see Result.Compare). Nothing is
+	// written. Skipped when the archive's structure is invalid.
+	CompareDir string
+
+	// Manifest is a SHA-256 manifest (sha256sum format) to check the
+	// archive's entries against: every entry is extracted in memory and its
+	// digest compared with the listed one (see Result.Manifest). Skipped
+	// when the archive's structure is invalid.
+	// Default: the <archive>.sha256 written by compress --write-manifest,
+	// checked with VerifyData when present
+	Manifest string
+
 	// Verbose enables detailed logging during verification
 	Verbose bool
 
 	// Quiet suppresses all output except errors
 	Quiet bool
+
+	ctx context.Context // set by VerifyContext
+}
+
+// canceled returns the error of the run's context once it is canceled
+func (o *Options) canceled() error {
+	if o.ctx == nil {
+		return nil
+	}
+	return o.ctx.Err()
 }
 
 // Validate checks if options are valid
@@ -23,6 +72,53 @@ func (o *Options) Validate() error {
 	if o.InputPath == "" {
 		return ErrInputRequired
 	}
+	if o.MaxThreads <= 0 {
+		o.MaxThreads = runtime.NumCPU()
+	}
+	if o.Quiet {
+		o.Verbose = false
+	}
+	return nil
+}
+
+// ScrubOptions configures an incremental verification run (see Scrub)
+type ScrubOptions struct {
+	// InputPath is the GDELTA archive to scrub (required)
+	InputPath string
+
+	// StatePath is the JSON file holding the scrub's position between runs
+	// (required). It is created on the first run.
+	StatePath string
+
+	// Duration bounds this run; the scrub stops at the first batch boundary
+	// after it and continues there next time. 0 = finish the current pass.
+	Duration time.Duration
+
+	// MaxThreads is the number of files or chunks checked at once
+	// Default: runtime.NumCPU()
+	MaxThreads int
+
+	// Mmap reads the archive through a memory mapping (see Options.Mmap)
+	Mmap bool
+
+	// Verbose enables detailed logging
+	Verbose bool
+
+	// Quiet suppresses all output except errors
+	Quiet bool
+}
+
+// Validate checks if options are valid
+func (o *ScrubOptions) Validate() error {
+	if o.InputPath == "" {
+		return ErrInputRequired
+	}
+	if o.StatePath == "" {
+		return ErrStateRequired
+	}
+	if o.MaxThreads <= 0 {
+		o.MaxThreads = runtime.NumCPU()
+	}
 	if o.Quiet {
 		o.Verbose = false
 	}
@@ -0,0 +1,48 @@
+// pkg/verify/parallel.go
+package verify
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// verifyParallel runs check(i, readBuf) for every i in [0, n) on up to
+// workers goroutines, each with its own read buffer. Checks read the archive
+// with ReadAt (pread, or the memory mapping), so they share no file
+// position. onDone (may be nil) receives each finished check and how many
+// are done so far, one call at a time, so it can drive the caller's
+// progress callback.
+// The returned slice holds the error of each check, nil when it passed.
+func verifyParallel(workers, n int, check func(i int, readBuf *[]byte) error, onDone func(i, done int)) []error {
+	errs := make([]error, n)
+	workers = max(1, min(workers, n))
+
+	var next atomic.Int64
+	var mu sync.Mutex // serializes onDone
+	done := 0
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var readBuf []byte
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= n {
+					return
+				}
+				errs[i] = check(i, &readBuf)
+
+				mu.Lock()
+				done++
+				if onDone != nil {
+					onDone(i, done)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,55 @@
+// pkg/verify/parity.go
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/creativeyann17/go-delta/internal/parity"
+)
+
+// readParity records the parity section at the end of a GDELTA archive,
+// if it has one
+func readParity(archiveFile *os.File, size int64, result *Result) {
+	layout, err := parity.ReadLayout(archiveFile, size)
+	if err != nil {
+		if !errors.Is(err, parity.ErrNoParity) {
+			result.Errors = append(result.Errors, err)
+		}
+		return
+	}
+	result.Parity = &Parity{
+		Size:      uint64(size - layout.DataSize),
+		BlockSize: layout.BlockSize,
+		Overhead:  layout.Overhead(),
+	}
+}
+
+// checkParity compares every archive and parity block against the checksums
+// of the parity section
+func checkParity(archive io.ReaderAt, result *Result) {
+	report, err := parity.Check(archive, int64(result.ArchiveSize))
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("%w: %v", ErrDamagedBlocks, err))
+		return
+	}
+	p := result.Parity
+	p.Checked = true
+	p.DamagedBlocks = len(report.BadData)
+	p.DamagedParity = len(report.BadParity)
+	p.Repairable = report.Repairable
+	if report.Damaged() {
+		result.Errors = append(result.Errors, fmt.Errorf("%w: %d archive blocks, %d parity blocks",
+			ErrDamagedBlocks, p.DamagedBlocks, p.DamagedParity))
+	}
+}
+
+// dataSize returns the size of the archive without its parity section
+func (r *Result) dataSize() int64 {
+	if r.Parity == nil {
+		return int64(r.ArchiveSize)
+	}
+	return int64(r.ArchiveSize - r.Parity.Size)
+}
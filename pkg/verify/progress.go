@@ -0,0 +1,17 @@
+// pkg/verify/progress.go
+package verify
+
+import (
+	"io"
+
+	"github.com/creativeyann17/go-delta/pkg/godelta"
+)
+
+// JSONProgressCallback creates a progress callback writing each event to w as
+// one line of JSON, for wrappers and UIs that track jobs programmatically
+func JSONProgressCallback(w io.Writer) ProgressCallback {
+	emit := godelta.JSONLines(w)
+	return func(event ProgressEvent) {
+		emit(event)
+	}
+}
@@ -0,0 +1,70 @@
+// pkg/verify/ratelimit.go
+package verify
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// limitedReaderAt paces reads to a number of bytes per second, shared by
+// the workers reading through it
+type limitedReaderAt struct {
+	r     io.ReaderAt
+	limit float64 // bytes per second
+
+	mu   sync.Mutex
+	next time.Time // when the bytes read so far are paid for
+}
+
+// limitReads returns r paced to limit bytes per second, r itself when
+// limit is 0
+func limitReads(r io.ReaderAt, limit int64) io.ReaderAt {
+	if limit <= 0 {
+		return r
+	}
+	return &limitedReaderAt{r: r, limit: float64(limit), next: time.Now()}
+}
+
+// dataReader returns r as the archive data checks read it: paced to
+// ReadLimit, and failing once the run is canceled
+func (o *Options) dataReader(r io.ReaderAt) io.ReaderAt {
+	r = limitReads(r, o.ReadLimit)
+	if o.ctx == nil {
+		return r
+	}
+	return &contextReaderAt{r: r, ctx: o.ctx}
+}
+
+// contextReaderAt fails reads with the error of ctx once it is canceled, so
+// the data checks left stop without touching the archive
+type contextReaderAt struct {
+	r   io.ReaderAt
+	ctx context.Context
+}
+
+func (c *contextReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.ReadAt(p, off)
+}
+
+func (l *limitedReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := l.r.ReadAt(p, off)
+
+	now := time.Now()
+	l.mu.Lock()
+	if l.next.Before(now) {
+		l.next = now // no credit for the time spent idle
+	}
+	l.next = l.next.Add(time.Duration(float64(n) / l.limit * float64(time.Second)))
+	wait := l.next.Sub(now)
+	l.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	return n, err
+}
@@ -0,0 +1,61 @@
+// pkg/verify/recover.go
+package verify
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+)
+
+// recoverGDelta01 scans a GDELTA01 archive forward from its header to find
+// how much of it is intact
+func recoverGDelta01(archiveFile *os.File, result *Result) {
+	_, scan, err := format.ScanGDelta01(archiveFile, result.dataSize())
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("recovery scan: %w", err))
+		return
+	}
+	setRecovery(result, scan)
+}
+
+// recoverGDelta03 scans a GDELTA03 archive forward from its dictionary
+func recoverGDelta03(archiveFile *os.File, result *Result) {
+	_, scan, err := format.ScanGDelta03(archiveFile, result.dataSize())
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("recovery scan: %w", err))
+		return
+	}
+	setRecovery(result, scan)
+}
+
+// recoverGDelta02 counts the files whose chunks all lie within the archive.
+// The index and metadata sit before the chunk data, so a GDELTA02 archive
+// that lost part of its chunk data still describes every file.
+func recoverGDelta02(archiveFile *os.File, chunks map[[32]byte]format.ChunkInfo, refs *format.ReferenceChunks, files []format.FileMetadata, chunkDataStart int64, result *Result) {
+	if !result.MetadataValid {
+		// Chunk data follows the metadata, so none of it survived
+		setRecovery(result, &format.ScanResult{
+			Declared:   result.FileCount,
+			LogicalEnd: chunkDataStart,
+			Reason:     "file metadata is truncated",
+		})
+		return
+	}
+	_, scan := format.IntactGDelta02Files(archiveFile, chunks, refs, files, chunkDataStart, result.dataSize())
+	setRecovery(result, scan)
+}
+
+// setRecovery records a scan that stopped before the footer. Archives whose
+// writer skipped unreadable files end early but cleanly and aren't reported.
+func setRecovery(result *Result, scan *format.ScanResult) {
+	if !scan.Damaged() {
+		return
+	}
+	result.Recovery = &Recovery{
+		IntactFiles:   scan.Intact,
+		DeclaredFiles: scan.Declared,
+		LogicalEnd:    uint64(scan.LogicalEnd),
+		Reason:        scan.Reason,
+	}
+}
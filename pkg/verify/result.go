@@ -3,8 +3,12 @@ package verify
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/creativeyann17/go-delta/pkg/timestamp"
 )
 
 // Format represents the archive format type
@@ -40,8 +44,33 @@ type Result struct {
 	ChunkSize     uint64 // Configured average chunk size (0 for non-chunked)
 	ChunkCount    uint64 // Total unique chunks in archive
 	TotalChunkRef uint64 // Total chunk references across all files
+	Codec         string // Chunk data codec ("unknown" for archives that predate it)
+	Level         int    // Chunk compression level (0 = not recorded)
+	ChunkMinSize  uint64 // FastCDC minimum chunk size (ChunkSize/4 unless recorded)
+	ChunkMaxSize  uint64 // FastCDC maximum chunk size (ChunkSize*4 unless recorded)
+	Normalization int    // FastCDC normalization level (0 = off)
+	FixedChunks   bool   // Chunks are fixed-size blocks of ChunkSize, not FastCDC
 
-	// GDELTA03-specific dictionary information
+	// RefChunks are the chunks the archive takes from the reference
+	// directories RefDirs instead of holding them; their data isn't checked
+	RefChunks uint64
+	RefDirs   []string
+
+	// Features are the names of the capabilities the archive requires from
+	// its reader (GDELTA02/GDELTA03), "bit N" for those this version
+	// doesn't support
+	Features []string
+
+	// EntryIndex is true when a GDELTA01 archive ends with a valid entry
+	// index, letting readers seek straight to any entry
+	EntryIndex bool
+
+	// Parity is the Reed-Solomon parity section of a GDELTA archive (nil
+	// when there is none); VerifyData also checks its blocks
+	Parity *Parity
+
+	// Dictionary information: the GDELTA03 dictionary or the one GDELTA02
+	// chunks were compressed with
 	DictSize uint32 // Dictionary size in bytes (0 for non-dictionary)
 
 	// Data integrity (only populated when VerifyData=true)
@@ -51,6 +80,10 @@ type Result struct {
 	CorruptFiles   int  // Number of files that failed verification
 	CorruptChunks  int  // Number of chunks that failed verification
 
+	// DamagedChunks are the hashes of the chunks that failed data
+	// verification (GDELTA02)
+	DamagedChunks [][32]byte
+
 	// Structural integrity
 	StructureValid bool // Overall structure is valid
 	FooterValid    bool // Footer marker is valid
@@ -60,11 +93,45 @@ type Result struct {
 	MissingChunks  int  // Chunks referenced but not in index (GDELTA02)
 	DuplicatePaths int  // Files with duplicate paths
 
+	// Optional TLV fields in headers and file entries that this version
+	// doesn't interpret; they are skipped and don't affect validity
+	UnknownExtensions int
+
+	// Archive metadata recorded in the header (GDELTA02/GDELTA03; nil when
+	// there is none)
+	Metadata *godelta.ArchiveMetadata
+
+	// Trusted timestamp from the archive's .tsr sidecar (nil when there is
+	// none); a sidecar that doesn't match the archive is reported in Errors
+	Timestamp *timestamp.Token
+
+	// Comparison with Options.CompareDir (nil when not requested or the
+	// structure is invalid)
+	Compare *Comparison
+
+	// Check against the archive's SHA-256 manifest (nil when there is none,
+	// it wasn't checked or the structure is invalid)
+	Manifest *ManifestCheck
+
+	// Recovery scan (populated for GDELTA archives with a bad footer or
+	// unreadable entries)
+	Recovery *Recovery
+
+	// Parts of a multi-part ZIP or XZ archive (name_01.zip, name_02.zip,
+	// ...), in order; nil for a single archive. The totals above cover
+	// every part.
+	Parts []Part
+
 	// File details (populated during verification)
 	Files []FileInfo
 
-	// Errors encountered during verification
+	// Errors that make the archive invalid
 	Errors []error
+
+	// Warnings about an archive that is still valid: orphaned chunks,
+	// unknown optional fields. Issues and MarshalJSON give both a severity
+	// and a code.
+	Warnings []error
 }
 
 // FileInfo contains information about a single file in the archive
@@ -73,10 +140,41 @@ type FileInfo struct {
 	OriginalSize   uint64 // Original uncompressed size
 	CompressedSize uint64 // Compressed size in archive
 	ChunkCount     int    // Number of chunks (GDELTA02 only)
+	Type           string // MIME type recorded when archived (GDELTA02/03 with sniffed types)
 	DataValid      bool   // Data integrity verified (when VerifyData=true)
 	Error          error  // Error if verification failed for this file
 }
 
+// Part summarizes one part of a multi-part archive
+type Part struct {
+	Path          string // Path of the part
+	Size          uint64 // Part file size in bytes
+	FileCount     int    // Files in this part
+	TotalOrigSize uint64 // Sum of original file sizes
+	TotalCompSize uint64 // Sum of compressed data sizes (ZIP)
+	CorruptFiles  int    // Files that failed data verification
+	Errors        int    // Errors reported for this part
+}
+
+// Parity describes the parity section at the end of an archive
+type Parity struct {
+	Size          uint64  // Size of the parity section, included in ArchiveSize
+	BlockSize     int     // Size of the blocks the parity protects
+	Overhead      float64 // Size relative to the rest of the archive, in percent
+	Checked       bool    // Blocks were checked (VerifyData)
+	DamagedBlocks int     // Archive blocks that don't match their checksum
+	DamagedParity int     // Parity blocks that don't match their checksum
+	Repairable    bool    // Every damaged block can be rebuilt
+}
+
+// Recovery describes how much of a damaged archive can still be extracted
+type Recovery struct {
+	IntactFiles   int    // Files whose entry and data are complete
+	DeclaredFiles int    // File count from the header
+	LogicalEnd    uint64 // Offset just past the last intact data
+	Reason        string // Why the scan stopped early ("" if only the footer is bad)
+}
+
 // CompressionRatio returns the compression ratio as a percentage
 func (r *Result) CompressionRatio() float64 {
 	if r.TotalOrigSize == 0 {
@@ -144,6 +242,19 @@ func (r *Result) Summary() string {
 	s += fmt.Sprintf("Format:  %s\n", r.Format)
 	s += fmt.Sprintf("Size:    %s\n", godelta.FormatSize(r.ArchiveSize))
 	s += fmt.Sprintf("Files:   %d\n", r.FileCount)
+	if len(r.Parts) > 0 {
+		s += fmt.Sprintf("Parts:   %d\n", len(r.Parts))
+	}
+	if len(r.Features) > 0 {
+		s += fmt.Sprintf("Requires: %s\n", strings.Join(r.Features, ", "))
+	}
+	if r.EntryIndex {
+		s += fmt.Sprintf("Index:   %d entries (random access)\n", len(r.Files))
+	}
+	if r.Parity != nil {
+		s += fmt.Sprintf("Parity:  %s (%.1f%%, %s blocks)\n",
+			godelta.FormatSize(r.Parity.Size), r.Parity.Overhead, godelta.FormatSize(uint64(r.Parity.BlockSize)))
+	}
 
 	if r.TotalOrigSize > 0 {
 		s += fmt.Sprintf("Original:   %s\n", godelta.FormatSize(r.TotalOrigSize))
@@ -156,7 +267,23 @@ func (r *Result) Summary() string {
 	if r.Format == FormatGDelta02 {
 		s += fmt.Sprintf("\nChunk Info:\n")
 		s += fmt.Sprintf("  Chunk Size:  %s\n", godelta.FormatSize(r.ChunkSize))
+		if r.FixedChunks {
+			s += fmt.Sprintf("  Chunking:    fixed-size blocks\n")
+		} else {
+			s += fmt.Sprintf("  Bounds:      %s - %s (normalization %d)\n", godelta.FormatSize(r.ChunkMinSize), godelta.FormatSize(r.ChunkMaxSize), r.Normalization)
+		}
+		if r.Level > 0 {
+			s += fmt.Sprintf("  Codec:       %s (level %d)\n", r.Codec, r.Level)
+		} else {
+			s += fmt.Sprintf("  Codec:       %s\n", r.Codec)
+		}
+		if r.DictSize > 0 {
+			s += fmt.Sprintf("  Dictionary:  %s\n", godelta.FormatSize(uint64(r.DictSize)))
+		}
 		s += fmt.Sprintf("  Unique:      %d chunks\n", r.ChunkCount)
+		if r.RefChunks > 0 {
+			s += fmt.Sprintf("  Referenced:  %d chunks in %s\n", r.RefChunks, strings.Join(r.RefDirs, ", "))
+		}
 		s += fmt.Sprintf("  References:  %d total\n", r.TotalChunkRef)
 		if r.ChunkDeduplicationRatio() > 0 {
 			s += fmt.Sprintf("  Dedup Ratio: %.1f%%\n", r.ChunkDeduplicationRatio())
@@ -168,6 +295,18 @@ func (r *Result) Summary() string {
 		s += fmt.Sprintf("  Dict Size:  %s\n", godelta.FormatSize(uint64(r.DictSize)))
 	}
 
+	if len(r.Parts) > 0 {
+		s += fmt.Sprintf("\nParts:\n")
+		for _, p := range r.Parts {
+			status := "ok"
+			if p.Errors > 0 {
+				status = fmt.Sprintf("%d errors", p.Errors)
+			}
+			s += fmt.Sprintf("  %s: %d files, %s (%s)\n",
+				filepath.Base(p.Path), p.FileCount, godelta.FormatSize(p.Size), status)
+		}
+	}
+
 	if r.DataVerified {
 		s += fmt.Sprintf("\nData Integrity:\n")
 		s += fmt.Sprintf("  Files Verified:  %d/%d\n", r.FilesVerified, r.FileCount)
@@ -180,6 +319,64 @@ func (r *Result) Summary() string {
 				s += fmt.Sprintf("  Corrupt Chunks:  %d\n", r.CorruptChunks)
 			}
 		}
+		if p := r.Parity; p != nil && p.Checked {
+			switch {
+			case p.DamagedBlocks == 0 && p.DamagedParity == 0:
+				s += fmt.Sprintf("  Parity:          all blocks intact\n")
+			case p.Repairable:
+				s += fmt.Sprintf("  Parity:          %d archive, %d parity blocks damaged (repairable)\n", p.DamagedBlocks, p.DamagedParity)
+				s += fmt.Sprintf("  Use 'godelta repair' to rebuild them\n")
+			default:
+				s += fmt.Sprintf("  Parity:          %d archive, %d parity blocks damaged (beyond repair)\n", p.DamagedBlocks, p.DamagedParity)
+			}
+		}
+	}
+
+	if c := r.Compare; c != nil {
+		s += fmt.Sprintf("\nCompared with %s:\n", c.Dir)
+		s += fmt.Sprintf("  Matching: %d\n", c.Matching)
+		s += fmt.Sprintf("  Modified: %d\n", c.Modified)
+		s += fmt.Sprintf("  Missing:  %d\n", c.Missing)
+		s += fmt.Sprintf("  Extra:    %d\n", c.Extra)
+		s += comparePaths("modified", c.ModifiedPaths)
+		s += comparePaths("missing", c.MissingPaths)
+		s += comparePaths("extra", c.ExtraPaths)
+	}
+
+	if m := r.Manifest; m != nil {
+		s += fmt.Sprintf("\nManifest %s:\n", m.Path)
+		s += fmt.Sprintf("  Matching:   %d\n", m.Matching)
+		s += fmt.Sprintf("  Mismatched: %d\n", m.Mismatched)
+		s += fmt.Sprintf("  Missing:    %d\n", m.Missing)
+		s += fmt.Sprintf("  Unlisted:   %d\n", m.Unlisted)
+		s += comparePaths("mismatched", m.MismatchedPaths)
+		s += comparePaths("missing", m.MissingPaths)
+		s += comparePaths("unlisted", m.UnlistedPaths)
+	}
+
+	if r.Metadata != nil {
+		s += "\nMetadata:\n" + godelta.FormatMetadata(r.Metadata, "  ")
+	}
+
+	if r.UnknownExtensions > 0 {
+		s += fmt.Sprintf("\nExtensions: %d unknown optional fields skipped (written by a newer version)\n", r.UnknownExtensions)
+	}
+
+	if r.Timestamp != nil {
+		s += fmt.Sprintf("\nTimestamp: %s (serial %s, policy %s)\n",
+			r.Timestamp.Time.UTC().Format(time.RFC3339), r.Timestamp.Serial, r.Timestamp.Policy)
+	}
+
+	if r.Recovery != nil {
+		s += fmt.Sprintf("\nRecovery:\n")
+		s += fmt.Sprintf("  Intact Files: %d/%d\n", r.Recovery.IntactFiles, r.Recovery.DeclaredFiles)
+		s += fmt.Sprintf("  Data Ends At: offset %d of %d\n", r.Recovery.LogicalEnd, r.ArchiveSize)
+		if r.Recovery.Reason != "" {
+			s += fmt.Sprintf("  Stopped:      %s\n", r.Recovery.Reason)
+		}
+		if r.Recovery.IntactFiles > 0 {
+			s += fmt.Sprintf("  Use 'godelta decompress --recover' to extract the intact files\n")
+		}
 	}
 
 	if len(r.Errors) > 0 {
@@ -192,6 +389,29 @@ func (r *Result) Summary() string {
 			s += fmt.Sprintf("  - %v\n", err)
 		}
 	}
+	if len(r.Warnings) > 0 {
+		s += fmt.Sprintf("\nWarnings (%d):\n", len(r.Warnings))
+		for i, err := range r.Warnings {
+			if i >= 10 {
+				s += fmt.Sprintf("  ... and %d more warnings\n", len(r.Warnings)-10)
+				break
+			}
+			s += fmt.Sprintf("  - %v\n", err)
+		}
+	}
 
 	return s
 }
+
+// comparePaths lists the first paths of a comparison state
+func comparePaths(state string, paths []string) string {
+	s := ""
+	for i, path := range paths {
+		if i >= 10 {
+			s += fmt.Sprintf("  ... and %d more %s\n", len(paths)-10, state)
+			break
+		}
+		s += fmt.Sprintf("  %s: %s\n", state, path)
+	}
+	return s
+}
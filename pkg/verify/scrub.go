@@ -0,0 +1,387 @@
+// pkg/verify/scrub.go
+package verify
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/mmap"
+	"github.com/creativeyann17/go-delta/internal/parity"
+	"github.com/klauspost/compress/zstd"
+)
+
+// ScrubState is the bookmark a scrub keeps between runs. A pass checks
+// every unit (file or chunk) of the archive once, in archive order; each
+// run continues the pass where the previous one stopped.
+type ScrubState struct {
+	Archive         string    `json:"archive"`
+	ArchiveSize     int64     `json:"archive_size"`
+	ArchiveModTime  time.Time `json:"archive_mod_time"`
+	Units           int       `json:"units"`
+	Cursor          int       `json:"cursor"` // next unit to check
+	Pass            int       `json:"pass"`   // current pass, from 1
+	PassStarted     time.Time `json:"pass_started"`
+	LastRun         time.Time `json:"last_run"`
+	CompletedPasses int       `json:"completed_passes"`
+	LastPassEnd     time.Time `json:"last_pass_end,omitempty"`
+	LastPassCorrupt int       `json:"last_pass_corrupt"`
+	Corrupt         []string  `json:"corrupt,omitempty"` // damage found in the current pass
+}
+
+// ScrubResult reports one scrub run
+type ScrubResult struct {
+	Format       Format
+	Unit         string // "files" or "chunks"
+	Checked      int    // units checked in this run
+	Corrupt      int    // damaged units found in this run
+	PassComplete bool   // this run finished a pass
+	Restarted    bool   // the archive changed since the last run, so the pass started over
+	Errors       []error
+	State        *ScrubState
+	Duration     time.Duration
+}
+
+// IsValid returns true if the run found no damage
+func (r *ScrubResult) IsValid() bool {
+	return len(r.Errors) == 0
+}
+
+// Summary returns a human-readable summary of the run
+func (r *ScrubResult) Summary() string {
+	s := r.State
+	out := fmt.Sprintf("Scrubbed %d %s in %v (%s)\n", r.Checked, r.Unit, r.Duration.Round(time.Millisecond), r.Format)
+	if r.Restarted {
+		out += "Archive changed since the last run: pass restarted\n"
+	}
+	if r.PassComplete {
+		out += fmt.Sprintf("Pass %d complete: %d damaged %s\n", s.CompletedPasses, s.LastPassCorrupt, r.Unit)
+	} else {
+		out += fmt.Sprintf("Pass %d: %d/%d %s checked (%.1f%%)\n", s.Pass, s.Cursor, s.Units, r.Unit, percent(s.Cursor, s.Units))
+	}
+	for _, err := range r.Errors {
+		out += fmt.Sprintf("  ✗ %v\n", err)
+	}
+	if r.IsValid() {
+		out += "✓ No damage found in this run\n"
+	}
+	return out
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+// scrubBatch is how many units are handed to the workers between deadline
+// checks, per worker
+const scrubBatch = 16
+
+// Scrub checks the data of the next part of a GDELTA archive, for up to
+// opts.Duration, and records where it stopped in opts.StatePath. Run it
+// regularly (e.g. nightly) and it cycles through the whole archive. A
+// change of the archive's size or modification time starts a new pass.
+func Scrub(opts *ScrubOptions, progressCb ProgressCallback) (*ScrubResult, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	archiveFile, err := os.Open(opts.InputPath)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer archiveFile.Close()
+	info, err := archiveFile.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat archive: %w", err)
+	}
+
+	archive, err := listScrubUnits(archiveFile, parity.DataSize(archiveFile, info.Size()))
+	if err != nil {
+		return nil, err
+	}
+	if archive.decoder != nil {
+		defer archive.decoder.Close()
+	}
+	result := &ScrubResult{Format: archive.format, Unit: archive.unit}
+
+	state, err := loadScrubState(opts.StatePath)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil || state.ArchiveSize != info.Size() || !state.ArchiveModTime.Equal(info.ModTime()) || state.Units != len(archive.units) {
+		result.Restarted = state != nil
+		passes, lastEnd, lastCorrupt := 0, time.Time{}, 0
+		if state != nil {
+			passes, lastEnd, lastCorrupt = state.CompletedPasses, state.LastPassEnd, state.LastPassCorrupt
+		}
+		state = &ScrubState{
+			Archive:         opts.InputPath,
+			ArchiveSize:     info.Size(),
+			ArchiveModTime:  info.ModTime(),
+			Units:           len(archive.units),
+			Pass:            passes + 1,
+			PassStarted:     start,
+			CompletedPasses: passes,
+			LastPassEnd:     lastEnd,
+			LastPassCorrupt: lastCorrupt,
+		}
+	}
+	result.State = state
+
+	var data io.ReaderAt = archiveFile
+	if mapped := mapArchive(&Options{InputPath: opts.InputPath, Mmap: opts.Mmap, Verbose: opts.Verbose}); mapped != nil {
+		defer mapped.Close()
+		data = mapped
+	}
+
+	if progressCb != nil {
+		progressCb(ProgressEvent{
+			Type:    EventStart,
+			Current: state.Cursor,
+			Total:   state.Units,
+			Message: fmt.Sprintf("Scrubbing %s from %d/%d (pass %d)", archive.unit, state.Cursor, state.Units, state.Pass),
+		})
+	}
+
+	// Every run checks at least one batch, so a pass always moves forward
+	deadline := start.Add(opts.Duration)
+	for state.Cursor < state.Units {
+		batch := archive.units[state.Cursor:min(state.Cursor+opts.MaxThreads*scrubBatch, state.Units)]
+		errs := verifyParallel(opts.MaxThreads, len(batch), func(i int, readBuf *[]byte) error {
+			return archive.check(data, batch[i], readBuf)
+		}, func(_, done int) {
+			if progressCb != nil {
+				progressCb(ProgressEvent{
+					Type:    EventChunkVerify,
+					Current: state.Cursor + done,
+					Total:   state.Units,
+				})
+			}
+		})
+		for i, err := range errs {
+			if err != nil {
+				err = fmt.Errorf("%s: %w", batch[i].Path, err)
+				result.Errors = append(result.Errors, err)
+				state.Corrupt = append(state.Corrupt, err.Error())
+			}
+		}
+		result.Checked += len(batch)
+		state.Cursor += len(batch)
+		if opts.Duration > 0 && time.Now().After(deadline) {
+			break
+		}
+	}
+	result.Corrupt = len(result.Errors)
+
+	now := time.Now()
+	state.LastRun = now
+	if state.Cursor >= state.Units {
+		// Pass done: the next run starts the next one
+		result.PassComplete = true
+		state.CompletedPasses++
+		state.LastPassEnd = now
+		state.LastPassCorrupt = len(state.Corrupt)
+		state.Pass++
+		state.Cursor = 0
+		state.PassStarted = now
+		state.Corrupt = nil
+	}
+	if err := saveScrubState(opts.StatePath, state); err != nil {
+		return result, err
+	}
+	result.Duration = time.Since(start)
+
+	if progressCb != nil {
+		progressCb(ProgressEvent{
+			Type:    EventComplete,
+			Current: result.Checked,
+			Total:   state.Units,
+			Message: "Scrub complete",
+		})
+	}
+	return result, nil
+}
+
+// scrubArchive lists what a scrub checks: the files of a GDELTA01 or
+// GDELTA03 archive, or the chunks of a GDELTA02 one, in archive order. Each
+// unit is an entry whose DataOffset and sizes locate its compressed data.
+type scrubArchive struct {
+	format  Format
+	unit    string
+	units   []*format.FileEntry
+	decoder *zstd.Decoder // GDELTA03 and GDELTA02 with a chunk dictionary
+
+	// stored holds the hash of the GDELTA02 chunks kept uncompressed, by
+	// DataOffset: they are checked against it
+	stored map[uint64][32]byte
+}
+
+func (a *scrubArchive) check(data io.ReaderAt, unit *format.FileEntry, readBuf *[]byte) error {
+	if hash, ok := a.stored[unit.DataOffset]; ok {
+		chunk, err := mmap.Read(data, int64(unit.DataOffset), int64(unit.CompressedSize), readBuf)
+		if err != nil {
+			return fmt.Errorf("read compressed data: %w", err)
+		}
+		return checkStoredChunk(chunk, hash)
+	}
+	if a.decoder != nil {
+		return verifyGDelta03FileData(data, int64(unit.DataOffset), unit, a.decoder, readBuf)
+	}
+	return verifyGDelta01FileData(data, int64(unit.DataOffset), unit, readBuf)
+}
+
+// listScrubUnits reads the structure of the archive. Archives that are
+// damaged beyond their data are refused: verify reports what is wrong.
+func listScrubUnits(archiveFile *os.File, size int64) (*scrubArchive, error) {
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(archiveFile, magic); err != nil {
+		return nil, ErrTruncatedArchive
+	}
+	if _, err := archiveFile.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var scan *format.ScanResult
+	a := &scrubArchive{unit: "files"}
+	switch format.DetectFormat(magic) {
+	case format.FormatGDelta01:
+		a.format = FormatGDelta01
+		entries, s, err := format.ListGDelta01(archiveFile, size)
+		if err != nil {
+			return nil, fmt.Errorf("read archive: %w", err)
+		}
+		// Packed files and links have no data: their pack or linked file is
+		// checked instead
+		entries = slices.DeleteFunc(entries, func(e *format.FileEntry) bool { return e.Packed || e.Link })
+		for _, e := range entries {
+			if e.Pack {
+				e.Path = fmt.Sprintf("pack at %d", e.DataOffset)
+			}
+		}
+		a.units, scan = entries, s
+
+	case format.FormatGDelta02:
+		a.format, a.unit = FormatGDelta02, "chunks"
+		header, err := format.ReadGDelta02Header(archiveFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+		}
+		chunkIndex, err := format.ReadChunkIndex(archiveFile, header.ChunkCount)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidChunkIndex, err)
+		}
+		for i := uint32(0); i < header.FileCount; i++ {
+			if _, err := format.ReadFileMetadata(archiveFile, header.Extended); err != nil {
+				return nil, fmt.Errorf("file %d: %w", i, err)
+			}
+		}
+		dataStart, err := archiveFile.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		for hash, info := range chunkIndex {
+			if dataStart+int64(info.Offset+info.CompressedSize) > size {
+				return nil, fmt.Errorf("%w: chunk %x lies past the end", ErrTruncatedArchive, hash[:8])
+			}
+			if info.Stored {
+				if a.stored == nil {
+					a.stored = make(map[uint64][32]byte)
+				}
+				a.stored[uint64(dataStart)+info.Offset] = hash
+			}
+			a.units = append(a.units, &format.FileEntry{
+				Path:           fmt.Sprintf("chunk %x", hash[:8]),
+				OriginalSize:   info.OriginalSize,
+				CompressedSize: info.CompressedSize,
+				DataOffset:     uint64(dataStart) + info.Offset,
+				Method:         header.Codec.Method(),
+			})
+		}
+		slices.SortFunc(a.units, func(x, y *format.FileEntry) int { return cmp.Compare(x.DataOffset, y.DataOffset) })
+		// Chunks compressed with a dictionary are checked like GDELTA03
+		// entries, with a decoder holding it
+		if dictionary := header.ChunkDictionary(); len(dictionary) > 0 {
+			if a.decoder, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderDicts(dictionary)); err != nil {
+				return nil, fmt.Errorf("create zstd decoder: %w", err)
+			}
+		}
+		return a, nil
+
+	case format.FormatGDelta03:
+		a.format = FormatGDelta03
+		header, err := format.ReadGDelta03Header(archiveFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+		}
+		dictionary := make([]byte, header.DictSize)
+		if _, err := io.ReadFull(archiveFile, dictionary); err != nil {
+			return nil, fmt.Errorf("read dictionary: %w", err)
+		}
+		entries, s, err := format.ScanGDelta03(archiveFile, size)
+		if err != nil {
+			return nil, fmt.Errorf("read archive: %w", err)
+		}
+		// Links have no data: their linked file is checked instead
+		a.units, scan = slices.DeleteFunc(entries, func(e *format.FileEntry) bool { return e.Link }), s
+		zopts := []zstd.DOption{zstd.WithDecoderConcurrency(0)}
+		if len(dictionary) > 0 {
+			zopts = append(zopts, zstd.WithDecoderDicts(dictionary))
+		}
+		if a.decoder, err = zstd.NewReader(nil, zopts...); err != nil {
+			return nil, fmt.Errorf("create zstd decoder: %w", err)
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: scrub reads GDELTA archives", ErrUnsupportedFormat)
+	}
+
+	if scan.Damaged() {
+		if a.decoder != nil {
+			a.decoder.Close()
+		}
+		return nil, fmt.Errorf("%w: %d of %d files intact, run verify", ErrTruncatedArchive, scan.Intact, scan.Declared)
+	}
+	return a, nil
+}
+
+// loadScrubState reads the state file; a missing file means a first run
+func loadScrubState(path string) (*ScrubState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read scrub state: %w", err)
+	}
+	var state ScrubState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidScrubState, err)
+	}
+	return &state, nil
+}
+
+// saveScrubState writes the state file atomically (temp file + rename)
+func saveScrubState(path string, state *ScrubState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("save scrub state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("save scrub state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// pkg/verify/scrub_test.go
+package verify_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// TestScrub runs a scrub in short windows until it completes a pass, then
+// damages the archive and checks that the next pass restarts and finds it
+func TestScrub(t *testing.T) {
+	sourceDir := t.TempDir()
+	for i := 0; i < 40; i++ {
+		content := bytes.Repeat([]byte(fmt.Sprintf("file %d line\n", i)), 200)
+		if err := os.WriteFile(filepath.Join(sourceDir, fmt.Sprintf("f%02d.txt", i)), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archivePath := filepath.Join(t.TempDir(), "a.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: sourceDir, OutputPath: archivePath, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// One worker checks 16 files per batch; a 1ns window allows one batch
+	opts := &verify.ScrubOptions{
+		InputPath:  archivePath,
+		StatePath:  filepath.Join(t.TempDir(), "scrub.json"),
+		Duration:   time.Nanosecond,
+		MaxThreads: 1,
+	}
+	for run, wantCursor := range []int{16, 32, 0} {
+		result, err := verify.Scrub(opts, nil)
+		if err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		if !result.IsValid() || result.Restarted {
+			t.Fatalf("run %d: restarted=%v, errors %v", run, result.Restarted, result.Errors)
+		}
+		if result.State.Cursor != wantCursor || result.State.Units != 40 {
+			t.Fatalf("run %d: cursor %d/%d, want %d", run, result.State.Cursor, result.State.Units, wantCursor)
+		}
+		if result.PassComplete != (wantCursor == 0) {
+			t.Fatalf("run %d: PassComplete = %v", run, result.PassComplete)
+		}
+	}
+
+	// Damage one file: the changed archive starts a new pass
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := format.ReadGDelta01Index(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	damaged := entries[3]
+	for i := uint64(0); i < 8; i++ {
+		data[damaged.DataOffset+damaged.CompressedSize/2+i] ^= 0xff
+	}
+	if err := os.WriteFile(archivePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(archivePath, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	opts.Duration = 0
+	result, err := verify.Scrub(opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Restarted || !result.PassComplete || result.Checked != 40 {
+		t.Fatalf("restarted=%v complete=%v checked=%d", result.Restarted, result.PassComplete, result.Checked)
+	}
+	if result.Corrupt != 1 || result.State.LastPassCorrupt != 1 || result.State.CompletedPasses != 2 {
+		t.Fatalf("corrupt=%d, state %+v", result.Corrupt, result.State)
+	}
+}
+
+func TestScrubOptions(t *testing.T) {
+	if _, err := verify.Scrub(&verify.ScrubOptions{InputPath: "a.gdelta"}, nil); !errors.Is(err, verify.ErrStateRequired) {
+		t.Errorf("expected ErrStateRequired, got %v", err)
+	}
+}
@@ -5,16 +5,26 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"bytes"
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/creativeyann17/go-delta/internal/format"
+	"github.com/creativeyann17/go-delta/internal/mmap"
+	"github.com/creativeyann17/go-delta/internal/pool"
 	"github.com/creativeyann17/go-delta/pkg/godelta"
+	"github.com/creativeyann17/go-delta/pkg/timestamp"
 	"github.com/klauspost/compress/zstd"
 	"github.com/ulikunitz/xz"
+	"github.com/zeebo/blake3"
 )
 
 // ProgressCallback is called for progress updates during verification
@@ -22,11 +32,11 @@ type ProgressCallback func(event ProgressEvent)
 
 // ProgressEvent contains progress information
 type ProgressEvent struct {
-	Type     EventType
-	FilePath string
-	Current  int
-	Total    int
-	Message  string
+	Type     EventType `json:"type"`
+	FilePath string    `json:"file,omitempty"`
+	Current  int       `json:"current"`
+	Total    int       `json:"total"`
+	Message  string    `json:"message,omitempty"`
 }
 
 // EventType indicates the type of progress event
@@ -38,17 +48,52 @@ const (
 	EventChunkVerify
 	EventComplete
 	EventError
+	EventFileCompare
+	EventManifestCheck
 )
 
+var eventTypeNames = [...]string{
+	EventStart:         "start",
+	EventFileVerify:    "file_verify",
+	EventChunkVerify:   "chunk_verify",
+	EventComplete:      "complete",
+	EventError:         "error",
+	EventFileCompare:   "file_compare",
+	EventManifestCheck: "manifest_check",
+}
+
+// String returns the event name used in JSON progress output
+func (t EventType) String() string {
+	if t >= 0 && int(t) < len(eventTypeNames) {
+		return eventTypeNames[t]
+	}
+	return fmt.Sprintf("event(%d)", int(t))
+}
+
+// MarshalText encodes the event type by name
+func (t EventType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
 // Verify verifies an archive and returns comprehensive results
 func Verify(opts *Options, progressCb ProgressCallback) (*Result, error) {
+	return VerifyContext(context.Background(), opts, progressCb)
+}
+
+// VerifyContext is like Verify but stops when ctx is canceled. Entries
+// and chunks are checked against ctx as they are read; the checks left are
+// skipped and the error wraps ctx.Err().
+func VerifyContext(ctx context.Context, opts *Options, progressCb ProgressCallback) (_ *Result, err error) {
 	if err := opts.Validate(); err != nil {
 		return nil, err
 	}
+	opts.ctx = ctx
 
 	result := &Result{
 		ArchivePath: opts.InputPath,
 	}
+	defer recordRun(result, time.Now())
+	defer func() { err = canceledError(ctx, result, err) }()
 
 	// Open archive file
 	archiveFile, err := os.Open(opts.InputPath)
@@ -63,6 +108,8 @@ func Verify(opts *Options, progressCb ProgressCallback) (*Result, error) {
 		return nil, fmt.Errorf("stat archive: %w", err)
 	}
 	result.ArchiveSize = uint64(stat.Size())
+	readParity(archiveFile, stat.Size(), result)
+	checkTimestamp(opts.InputPath, result)
 
 	// Read magic to determine format
 	magic := make([]byte, 8)
@@ -82,31 +129,71 @@ func Verify(opts *Options, progressCb ProgressCallback) (*Result, error) {
 	switch detectedFormat {
 	case format.FormatGDelta01:
 		result.Format = FormatGDelta01
-		return result, verifyGDelta01(archiveFile, opts, progressCb, result)
+		err = verifyGDelta01(archiveFile, opts, progressCb, result)
 
 	case format.FormatGDelta02:
 		result.Format = FormatGDelta02
-		return result, verifyGDelta02(archiveFile, opts, progressCb, result)
+		err = verifyGDelta02(archiveFile, opts, progressCb, result)
 
 	case format.FormatGDelta03:
 		result.Format = FormatGDelta03
-		return result, verifyGDelta03(archiveFile, opts, progressCb, result)
+		err = verifyGDelta03(archiveFile, opts, progressCb, result)
 
 	case format.FormatZIP:
 		result.Format = FormatZIP
 		archiveFile.Close() // ZIP reader needs file path
-		return result, verifyZip(opts, progressCb, result)
+		err = verifyZip(opts, progressCb, result)
 
 	case format.FormatXZ:
 		result.Format = FormatXZ
 		archiveFile.Close() // XZ reader needs file path
-		return result, verifyXz(opts, progressCb, result)
+		err = verifyXz(opts, progressCb, result)
 
 	default:
 		result.Format = FormatUnknown
 		result.Errors = append(result.Errors, ErrInvalidMagic)
 		return result, ErrUnsupportedFormat
 	}
+
+	if opts.canceled() != nil {
+		return result, err
+	}
+	if result.UnknownExtensions > 0 {
+		result.Warnings = append(result.Warnings, fmt.Errorf("%w: %d (written by a newer version)", ErrUnknownExtensions, result.UnknownExtensions))
+	}
+	if opts.VerifyData && result.Parity != nil {
+		checkParity(opts.dataReader(archiveFile), result)
+	}
+	if opts.CompareDir != "" && result.StructureValid {
+		compareDir(opts, progressCb, result)
+	}
+	if path := manifestPath(opts); path != "" && (opts.VerifyData || opts.Manifest != "") && result.StructureValid {
+		checkManifest(opts, path, progressCb, result)
+	}
+	return result, err
+}
+
+// canceledError returns the run's error: err, or the cancellation of ctx
+// when it cut the checks short, replacing the errors it caused
+func canceledError(ctx context.Context, result *Result, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+	result.Errors = slices.DeleteFunc(result.Errors, func(e error) bool { return errors.Is(e, ctx.Err()) })
+	return fmt.Errorf("verification canceled: %w", ctx.Err())
+}
+
+// headerError returns ErrMalformedArchive when a reader refused a count
+// or length of err's archive, ErrUnsupportedFeature when it requires a
+// feature this version lacks, fallback otherwise
+func headerError(err, fallback error) error {
+	switch {
+	case errors.Is(err, format.ErrMalformed):
+		return ErrMalformedArchive
+	case errors.Is(err, format.ErrUnsupportedFeature):
+		return ErrUnsupportedFeature
+	}
+	return fallback
 }
 
 // verifyGDelta01 verifies a GDELTA01 archive
@@ -115,7 +202,7 @@ func verifyGDelta01(archiveFile *os.File, opts *Options, progressCb ProgressCall
 	reader, err := format.NewArchiveReader(archiveFile)
 	if err != nil {
 		result.Errors = append(result.Errors, fmt.Errorf("read header: %w", err))
-		return ErrInvalidHeader
+		return headerError(err, ErrInvalidHeader)
 	}
 
 	result.HeaderValid = true
@@ -133,14 +220,43 @@ func verifyGDelta01(archiveFile *os.File, opts *Options, progressCb ProgressCall
 	// Track seen paths for duplicate detection
 	pathTracker := godelta.NewPathTracker()
 
+	// Files whose data is checked once every entry is read
+	var jobs []fileDataJob
+
+	// Entries read, small-file packs included
+	var entries []*format.FileEntry
+
+	// Links, given the outcome of the file they link to
+	var links []linkCheck
+	files := make(map[*format.FileEntry]int)
+
 	// Read and verify each file entry
-	for i := 0; i < result.FileCount; i++ {
+	for i := range reader.FileCount() {
 		entry, err := reader.ReadFileEntry()
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Errorf("file %d: %w", i, err))
 			result.MetadataValid = false
 			continue
 		}
+		entries = append(entries, entry)
+
+		// A pack is no file: its frame is checked through the files in it
+		if entry.Pack {
+			result.FileCount--
+			result.TotalCompSize += entry.CompressedSize
+			if _, err := archiveFile.Seek(int64(entry.DataOffset+entry.CompressedSize), io.SeekStart); err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("skip pack data: %w", err))
+			}
+			continue
+		}
+		if entry.Packed && entry.InPack == nil {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: packed file without a pack", entry.Path))
+			result.MetadataValid = false
+		}
+		if entry.Link && checkLinkEntry(entry, result) && opts.VerifyData {
+			links = append(links, linkCheck{file: len(result.Files), target: entry.Target})
+		}
+		files[entry] = len(result.Files)
 
 		fileInfo := FileInfo{
 			Path:           entry.Path,
@@ -149,10 +265,7 @@ func verifyGDelta01(archiveFile *os.File, opts *Options, progressCb ProgressCall
 		}
 
 		// Check for duplicates
-		if pathTracker.CheckDuplicate(entry.Path) {
-			result.DuplicatePaths++
-			result.Errors = append(result.Errors, fmt.Errorf("duplicate path: %s", entry.Path))
-		}
+		checkDuplicatePath(result, pathTracker, entry.Path)
 
 		// Track stats
 		result.TotalOrigSize += entry.OriginalSize
@@ -161,50 +274,68 @@ func verifyGDelta01(archiveFile *os.File, opts *Options, progressCb ProgressCall
 			result.EmptyFiles++
 		}
 
-		if progressCb != nil {
+		if progressCb != nil && !opts.VerifyData {
 			progressCb(ProgressEvent{
 				Type:     EventFileVerify,
 				FilePath: entry.Path,
-				Current:  i + 1,
+				Current:  len(result.Files) + 1,
 				Total:    result.FileCount,
 			})
 		}
 
-		// Verify data if requested
-		if opts.VerifyData {
-			err := verifyGDelta01FileData(archiveFile, entry)
-			if err != nil {
-				fileInfo.Error = err
-				result.CorruptFiles++
-				result.Errors = append(result.Errors, fmt.Errorf("%s: %w", entry.Path, err))
-			} else {
-				fileInfo.DataValid = true
-				result.FilesVerified++
-			}
-			result.DataVerified = true
-		} else {
-			// Skip over compressed data
-			if _, err := archiveFile.Seek(int64(entry.CompressedSize), io.SeekCurrent); err != nil {
-				result.Errors = append(result.Errors, fmt.Errorf("skip data for %s: %w", entry.Path, err))
-			}
+		// The entry's data starts here
+		dataStart, err := archiveFile.Seek(0, io.SeekCurrent)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("locate data for %s: %w", entry.Path, err))
+		} else if opts.VerifyData && !entry.Link {
+			jobs = append(jobs, fileDataJob{file: len(result.Files), offset: dataStart, entry: entry})
+		}
+
+		// Skip over compressed data
+		if _, err := archiveFile.Seek(dataStart+int64(entry.CompressedSize), io.SeekStart); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("skip data for %s: %w", entry.Path, err))
 		}
 
 		result.Files = append(result.Files, fileInfo)
 	}
 
+	// Verify data if requested
+	if opts.VerifyData {
+		var data io.ReaderAt = archiveFile
+		if mapped := mapArchive(opts); mapped != nil {
+			defer mapped.Close()
+			data = mapped
+		}
+		data = opts.dataReader(data)
+		packs := format.NewPackCache(entries)
+		verifyFileData(jobs, opts, progressCb, result, func(job fileDataJob, readBuf *[]byte) error {
+			if job.entry.Packed {
+				_, err := packs.Read(data, job.entry)
+				return err
+			}
+			return verifyGDelta01FileData(data, job.offset, job.entry, readBuf)
+		})
+		verifyLinks(links, files, result)
+	}
+
 	// Verify footer
-	footer := make([]byte, 9) // "GDELTAEND"
+	footer := make([]byte, len(format.ArchiveFooter))
 	n, err := archiveFile.Read(footer)
 	if err != nil && err != io.EOF {
 		result.Errors = append(result.Errors, fmt.Errorf("read footer: %w", err))
 	}
-	if n == 9 && string(footer) == "GDELTAEND" {
+	if n == len(footer) && string(footer) == format.ArchiveFooter {
 		result.FooterValid = true
+		verifyGDelta01Index(archiveFile, entries, result)
 	} else {
 		result.FooterValid = false
 		result.Errors = append(result.Errors, ErrInvalidFooter)
 	}
 
+	if !result.FooterValid || !result.MetadataValid {
+		recoverGDelta01(archiveFile, result)
+	}
+
 	result.StructureValid = result.HeaderValid && result.MetadataValid && result.DuplicatePaths == 0
 
 	if progressCb != nil {
@@ -219,23 +350,100 @@ func verifyGDelta01(archiveFile *os.File, opts *Options, progressCb ProgressCall
 	return nil
 }
 
-// verifyGDelta01FileData verifies data integrity for a single file
-func verifyGDelta01FileData(archiveFile *os.File, entry *format.FileEntry) error {
-	// Read compressed data
-	compressedData := make([]byte, entry.CompressedSize)
-	if _, err := io.ReadFull(archiveFile, compressedData); err != nil {
-		return fmt.Errorf("read compressed data: %w", err)
+// fileDataJob is a GDELTA01 or GDELTA03 file whose data is checked once
+// every entry has been read
+type fileDataJob struct {
+	file   int   // index in result.Files
+	offset int64 // start of the compressed data
+	entry  *format.FileEntry
+}
+
+// verifyFileData checks the data of jobs on opts.MaxThreads workers and
+// records the outcome of each file in result
+func verifyFileData(jobs []fileDataJob, opts *Options, progressCb ProgressCallback, result *Result, check func(job fileDataJob, readBuf *[]byte) error) {
+	errs := verifyParallel(opts.MaxThreads, len(jobs), func(i int, readBuf *[]byte) error {
+		return check(jobs[i], readBuf)
+	}, func(i, done int) {
+		if progressCb != nil {
+			progressCb(ProgressEvent{
+				Type:     EventFileVerify,
+				FilePath: jobs[i].entry.Path,
+				Current:  done,
+				Total:    result.FileCount,
+			})
+		}
+	})
+
+	for i, err := range errs {
+		file := &result.Files[jobs[i].file]
+		if err != nil {
+			file.Error = err
+			result.CorruptFiles++
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", file.Path, err))
+		} else {
+			file.DataValid = true
+			result.FilesVerified++
+		}
+	}
+	result.DataVerified = len(jobs) > 0
+}
+
+// mapArchive maps the archive for data verification when opts.Mmap is set.
+// It returns nil when mapping is off or not possible, and data is then read
+// from the archive file.
+func mapArchive(opts *Options) *mmap.File {
+	if !opts.Mmap {
+		return nil
+	}
+	m, err := mmap.Open(opts.InputPath)
+	if err != nil {
+		if opts.Verbose {
+			fmt.Printf("Reading the archive without mmap: %v\n", err)
+		}
+		return nil
+	}
+	return m
+}
+
+// verifyGDelta01Index checks the entry index after the footer, if any,
+// against the entries read from the archive body. The archive file is
+// positioned right after the footer.
+func verifyGDelta01Index(archiveFile *os.File, read []*format.FileEntry, result *Result) {
+	pos, err := archiveFile.Seek(0, io.SeekCurrent)
+	if err != nil || pos == result.dataSize() {
+		return // archives written before the index ended at the footer
 	}
 
-	// Try to decompress
-	decoder, err := zstd.NewReader(bytes.NewReader(compressedData))
+	entries, err := format.ReadGDelta01Index(archiveFile, result.dataSize())
+	if err == nil && len(entries) != len(read) {
+		err = fmt.Errorf("%d entries, archive holds %d", len(entries), len(read))
+	}
+	for i := 0; err == nil && i < len(entries); i++ {
+		e, f := entries[i], read[i]
+		if e.Path != f.Path || e.OriginalSize != f.OriginalSize || e.CompressedSize != f.CompressedSize ||
+			e.Pack != f.Pack || e.Packed != f.Packed || e.PackOffset != f.PackOffset ||
+			e.Link != f.Link || e.LinkIndex != f.LinkIndex {
+			err = fmt.Errorf("entry %d (%s) doesn't match the archive", i, e.Path)
+		}
+	}
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("%w: %v", ErrInvalidEntryIndex, err))
+		return
+	}
+	result.EntryIndex = true
+}
+
+// verifyGDelta01FileData verifies data integrity for a single file whose
+// data starts at offset in data (the archive file or its mapping)
+func verifyGDelta01FileData(data io.ReaderAt, offset int64, entry *format.FileEntry, readBuf *[]byte) error {
+	// Read compressed data
+	compressedData, err := mmap.Read(data, offset, int64(entry.CompressedSize), readBuf)
 	if err != nil {
-		return fmt.Errorf("create decoder: %w", err)
+		return fmt.Errorf("read compressed data: %w", err)
 	}
-	defer decoder.Close()
 
 	// Decompress to /dev/null equivalent, counting bytes
-	decompressed, err := io.Copy(io.Discard, decoder)
+	decompressed, err := decodedSize(entry.Method, compressedData)
 	if err != nil {
 		return fmt.Errorf("decompress: %w", err)
 	}
@@ -248,17 +456,77 @@ func verifyGDelta01FileData(archiveFile *os.File, entry *format.FileEntry) error
 	return nil
 }
 
+// verifyGDelta03FileData verifies data integrity for a single GDELTA03 file
+// whose data starts at offset in data; zstd entries need the archive's
+// dictionary in decoder
+func verifyGDelta03FileData(data io.ReaderAt, offset int64, entry *format.FileEntry, decoder *zstd.Decoder, readBuf *[]byte) error {
+	// Read compressed data
+	compressedData, err := mmap.Read(data, offset, int64(entry.CompressedSize), readBuf)
+	if err != nil {
+		return fmt.Errorf("read compressed data: %w", err)
+	}
+
+	// Try to decompress (stored entries are their own content)
+	var size int
+	switch entry.Method {
+	case format.MethodStore:
+		size = len(compressedData)
+	case format.MethodZstd:
+		decompressed, err := decoder.DecodeAll(compressedData, pool.Scratch(int(entry.OriginalSize)))
+		size = len(decompressed)
+		pool.PutBytes(decompressed)
+		if err != nil {
+			return fmt.Errorf("decompress: %w", err)
+		}
+	default:
+		return fmt.Errorf("decompress: unknown compression method: %s", entry.Method)
+	}
+
+	if uint64(size) != entry.OriginalSize {
+		return fmt.Errorf("size mismatch: expected %d, got %d", entry.OriginalSize, size)
+	}
+	return nil
+}
+
+// checkStoredChunk checks a GDELTA02 chunk kept uncompressed, which has no
+// zstd checksum, against its hash
+func checkStoredChunk(data []byte, hash [32]byte) error {
+	if blake3.Sum256(data) != hash {
+		return errors.New("stored chunk does not match its hash")
+	}
+	return nil
+}
+
 // verifyGDelta02 verifies a GDELTA02 archive
 func verifyGDelta02(archiveFile *os.File, opts *Options, progressCb ProgressCallback, result *Result) error {
 	// Read header
-	chunkSize, fileCount, chunkCount, err := format.ReadGDelta02Header(archiveFile)
+	header, err := format.ReadGDelta02Header(archiveFile)
+	result.Features = header.Features.Names()
 	if err != nil {
 		result.Errors = append(result.Errors, fmt.Errorf("read header: %w", err))
-		return ErrInvalidHeader
+		return headerError(err, ErrInvalidHeader)
 	}
+	fileCount, chunkCount := header.FileCount, header.ChunkCount
 
 	result.HeaderValid = true
-	result.ChunkSize = chunkSize
+	result.ChunkSize = header.ChunkSize
+	result.Codec = header.Codec.String()
+	result.Level = header.Level
+	result.ChunkMinSize = header.Bounds.MinSize
+	result.ChunkMaxSize = header.Bounds.MaxSize
+	result.Normalization = header.Bounds.Normalization
+	result.FixedChunks = header.Bounds.Fixed
+	result.DictSize = uint32(len(header.ChunkDictionary()))
+	refs, err := header.ReferenceChunks()
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("read header: %w", err))
+		return headerError(err, ErrInvalidHeader)
+	}
+	if refs != nil {
+		result.RefChunks, result.RefDirs = uint64(len(refs.Chunks)), refs.Dirs
+	}
+	result.UnknownExtensions += format.CountUnknownExtensions(header.Extensions)
+	result.Metadata = headerMetadata(header.Extensions, result)
 	result.FileCount = int(fileCount)
 	result.ChunkCount = uint64(chunkCount)
 
@@ -275,7 +543,7 @@ func verifyGDelta02(archiveFile *os.File, opts *Options, progressCb ProgressCall
 	if err != nil {
 		result.Errors = append(result.Errors, fmt.Errorf("read chunk index: %w", err))
 		result.IndexValid = false
-		return ErrInvalidChunkIndex
+		return headerError(err, ErrInvalidChunkIndex)
 	}
 	result.IndexValid = true
 
@@ -287,25 +555,29 @@ func verifyGDelta02(archiveFile *os.File, opts *Options, progressCb ProgressCall
 	result.MetadataValid = true
 
 	// Read file metadata
+	var files []format.FileMetadata
 	for i := uint32(0); i < fileCount; i++ {
-		metadata, err := format.ReadFileMetadata(archiveFile)
+		metadata, err := format.ReadFileMetadata(archiveFile, header.Extended)
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Errorf("file %d: %w", i, err))
 			result.MetadataValid = false
+			if errors.Is(err, format.ErrMalformed) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+				break // the rest of the table can't be located
+			}
 			continue
 		}
+		files = append(files, metadata)
+		result.UnknownExtensions += format.CountUnknownExtensions(metadata.Extensions)
 
 		fileInfo := FileInfo{
 			Path:         metadata.RelPath,
 			OriginalSize: metadata.OrigSize,
 			ChunkCount:   len(metadata.ChunkHashes),
+			Type:         format.TypeFromExtensions(metadata.Extensions),
 		}
 
 		// Check for duplicates
-		if pathTracker.CheckDuplicate(metadata.RelPath) {
-			result.DuplicatePaths++
-			result.Errors = append(result.Errors, fmt.Errorf("duplicate path: %s", metadata.RelPath))
-		}
+		checkDuplicatePath(result, pathTracker, metadata.RelPath)
 
 		// Track stats
 		result.TotalOrigSize += metadata.OrigSize
@@ -320,9 +592,9 @@ func verifyGDelta02(archiveFile *os.File, opts *Options, progressCb ProgressCall
 			chunkRefs[hash]++
 			if info, exists := chunkIndex[hash]; exists {
 				fileCompSize += info.CompressedSize
-			} else {
+			} else if !refs.Has(hash) {
 				result.MissingChunks++
-				result.Errors = append(result.Errors, fmt.Errorf("%s: missing chunk %x", metadata.RelPath, hash[:8]))
+				result.Errors = append(result.Errors, fmt.Errorf("%s: %w: %x", metadata.RelPath, ErrMissingChunk, hash[:8]))
 			}
 		}
 		fileInfo.CompressedSize = fileCompSize
@@ -340,15 +612,19 @@ func verifyGDelta02(archiveFile *os.File, opts *Options, progressCb ProgressCall
 		result.Files = append(result.Files, fileInfo)
 	}
 
-	// Check for orphaned chunks (chunks not referenced by any file)
+	// Check for orphaned chunks (chunks not referenced by any file): they
+	// waste space but don't make the archive invalid
 	for hash := range chunkIndex {
 		if chunkRefs[hash] == 0 {
 			result.OrphanedChunks++
 			if opts.Verbose {
-				result.Errors = append(result.Errors, fmt.Errorf("orphaned chunk: %x", hash[:8]))
+				result.Warnings = append(result.Warnings, fmt.Errorf("%w: %x", ErrOrphanedChunk, hash[:8]))
 			}
 		}
 	}
+	if result.OrphanedChunks > 0 && !opts.Verbose {
+		result.Warnings = append(result.Warnings, fmt.Errorf("%w: %d chunks", ErrOrphanedChunk, result.OrphanedChunks))
+	}
 
 	// Get chunk data start position
 	chunkDataStart, err := archiveFile.Seek(0, io.SeekCurrent)
@@ -359,72 +635,96 @@ func verifyGDelta02(archiveFile *os.File, opts *Options, progressCb ProgressCall
 	// Verify chunk data if requested
 	if opts.VerifyData && chunkDataStart > 0 {
 		result.DataVerified = true
-		chunksVerified := 0
 
-		for hash, info := range chunkIndex {
-			// Seek to chunk
-			if _, err := archiveFile.Seek(chunkDataStart+int64(info.Offset), io.SeekStart); err != nil {
-				result.Errors = append(result.Errors, fmt.Errorf("seek to chunk %x: %w", hash[:8], err))
-				result.CorruptChunks++
-				continue
-			}
+		var data io.ReaderAt = archiveFile
+		if mapped := mapArchive(opts); mapped != nil {
+			defer mapped.Close()
+			data = mapped
+		}
+		data = opts.dataReader(data)
 
-			// Read compressed chunk
-			compressedData := make([]byte, info.CompressedSize)
-			if _, err := io.ReadFull(archiveFile, compressedData); err != nil {
-				result.Errors = append(result.Errors, fmt.Errorf("read chunk %x: %w", hash[:8], err))
-				result.CorruptChunks++
-				continue
-			}
+		// Check chunks in archive order so the reads move forward
+		chunks := make([]format.ChunkInfo, 0, len(chunkIndex))
+		for _, info := range chunkIndex {
+			chunks = append(chunks, info)
+		}
+		slices.SortFunc(chunks, func(a, b format.ChunkInfo) int { return cmp.Compare(a.Offset, b.Offset) })
 
-			// Try to decompress
-			decoder, err := zstd.NewReader(bytes.NewReader(compressedData))
+		decode := func(data []byte, _ uint64) (int64, error) { return decodedSize(header.Codec.Method(), data) }
+		if dictionary := header.ChunkDictionary(); len(dictionary) > 0 {
+			// Chunks need the archive's dictionary; DecodeAll is safe for
+			// concurrent use
+			decoder, err := zstd.NewReader(nil, zstd.WithDecoderDicts(dictionary), zstd.WithDecoderConcurrency(opts.MaxThreads))
 			if err != nil {
-				result.Errors = append(result.Errors, fmt.Errorf("decompress chunk %x: %w", hash[:8], err))
-				result.CorruptChunks++
-				continue
+				result.Errors = append(result.Errors, fmt.Errorf("chunk dictionary: %w", err))
+				return ErrInvalidHeader
 			}
+			defer decoder.Close()
+			decode = func(data []byte, size uint64) (int64, error) {
+				decompressed, err := decoder.DecodeAll(data, pool.Scratch(int(size)))
+				n := len(decompressed)
+				pool.PutBytes(decompressed)
+				return int64(n), err
+			}
+		}
 
-			decompressed, err := io.Copy(io.Discard, decoder)
-			decoder.Close()
-
+		errs := verifyParallel(opts.MaxThreads, len(chunks), func(i int, readBuf *[]byte) error {
+			info := chunks[i]
+			// A damaged index may point anywhere; don't read past the archive
+			if room := result.ArchiveSize - uint64(chunkDataStart); info.Offset > room || info.CompressedSize > room-info.Offset {
+				return fmt.Errorf("chunk %x: range %d+%d outside the archive", info.Hash[:8], info.Offset, info.CompressedSize)
+			}
+			compressedData, err := mmap.Read(data, chunkDataStart+int64(info.Offset), int64(info.CompressedSize), readBuf)
 			if err != nil {
-				result.Errors = append(result.Errors, fmt.Errorf("decompress chunk %x: %w", hash[:8], err))
-				result.CorruptChunks++
-				continue
+				return fmt.Errorf("read chunk %x: %w", info.Hash[:8], err)
+			}
+			// Stored chunks are their own data
+			decompressed := int64(len(compressedData))
+			if info.Stored {
+				if err := checkStoredChunk(compressedData, info.Hash); err != nil {
+					return fmt.Errorf("chunk %x: %w", info.Hash[:8], err)
+				}
+			} else if decompressed, err = decode(compressedData, info.OriginalSize); err != nil {
+				return fmt.Errorf("decompress chunk %x: %w", info.Hash[:8], err)
 			}
-
 			if uint64(decompressed) != info.OriginalSize {
-				result.Errors = append(result.Errors, fmt.Errorf("chunk %x size mismatch: expected %d, got %d",
-					hash[:8], info.OriginalSize, decompressed))
-				result.CorruptChunks++
-				continue
+				return fmt.Errorf("chunk %x size mismatch: expected %d, got %d",
+					info.Hash[:8], info.OriginalSize, decompressed)
 			}
-
-			chunksVerified++
-
-			if progressCb != nil && chunksVerified%100 == 0 {
+			return nil
+		}, func(_, done int) {
+			if progressCb != nil && done%100 == 0 {
 				progressCb(ProgressEvent{
 					Type:    EventChunkVerify,
-					Current: chunksVerified,
+					Current: done,
 					Total:   int(chunkCount),
 				})
 			}
+		})
+
+		chunksVerified := 0
+		for i, err := range errs {
+			if err != nil {
+				result.Errors = append(result.Errors, err)
+				result.CorruptChunks++
+				result.DamagedChunks = append(result.DamagedChunks, chunks[i].Hash)
+			} else {
+				chunksVerified++
+			}
 		}
 
 		result.ChunksVerified = chunksVerified
 		result.FilesVerified = result.FileCount - result.CorruptFiles
 	}
 
-	// Verify footer
-	// Seek to end - 8 bytes
-	if _, err := archiveFile.Seek(-8, io.SeekEnd); err != nil {
+	// Verify footer: the last 8 bytes in front of any parity
+	if _, err := archiveFile.Seek(result.dataSize()-8, io.SeekStart); err != nil {
 		result.Errors = append(result.Errors, fmt.Errorf("seek to footer: %w", err))
 	} else {
 		footer := make([]byte, 8)
 		if _, err := io.ReadFull(archiveFile, footer); err != nil {
 			result.Errors = append(result.Errors, fmt.Errorf("read footer: %w", err))
-		} else if string(footer) == "ENDGDLT2" {
+		} else if string(footer) == format.ArchiveFooter02 {
 			result.FooterValid = true
 		} else {
 			result.FooterValid = false
@@ -432,6 +732,10 @@ func verifyGDelta02(archiveFile *os.File, opts *Options, progressCb ProgressCall
 		}
 	}
 
+	if !result.FooterValid && result.IndexValid {
+		recoverGDelta02(archiveFile, chunkIndex, refs, files, chunkDataStart, result)
+	}
+
 	result.StructureValid = result.HeaderValid && result.IndexValid && result.MetadataValid &&
 		result.MissingChunks == 0 && result.DuplicatePaths == 0
 
@@ -450,11 +754,13 @@ func verifyGDelta02(archiveFile *os.File, opts *Options, progressCb ProgressCall
 // verifyGDelta03 verifies a GDELTA03 archive with dictionary compression
 func verifyGDelta03(archiveFile *os.File, opts *Options, progressCb ProgressCallback, result *Result) error {
 	// Read header (file position is at start, magic not consumed)
-	version, dictSize, fileCount, err := format.ReadGDelta03Header(archiveFile)
+	header, err := format.ReadGDelta03Header(archiveFile)
+	result.Features = header.Features.Names()
 	if err != nil {
 		result.Errors = append(result.Errors, fmt.Errorf("read header: %w", err))
-		return ErrInvalidHeader
+		return headerError(err, ErrInvalidHeader)
 	}
+	version, dictSize, fileCount := header.Version, header.DictSize, header.FileCount
 
 	if version != format.GDELTA03Version {
 		result.Errors = append(result.Errors, fmt.Errorf("unsupported version: %d", version))
@@ -465,6 +771,8 @@ func verifyGDelta03(archiveFile *os.File, opts *Options, progressCb ProgressCall
 	result.DictSize = dictSize
 	result.FileCount = int(fileCount)
 	result.MetadataValid = true
+	result.UnknownExtensions += format.CountUnknownExtensions(header.Extensions)
+	result.Metadata = headerMetadata(header.Extensions, result)
 
 	if progressCb != nil {
 		progressCb(ProgressEvent{
@@ -485,37 +793,42 @@ func verifyGDelta03(archiveFile *os.File, opts *Options, progressCb ProgressCall
 	// Track seen paths for duplicate detection
 	pathTracker := godelta.NewPathTracker()
 
-	// Header size: magic(8) + version(1) + dictSize(4) + fileCount(4) + reserved(4) = 21 bytes
-	const headerSize = 21
-
-	// Create decoder for data verification if needed
+	// Create decoder for data verification if needed. DecodeAll is safe for
+	// concurrent use, so the workers share it.
 	var decoder *zstd.Decoder
 	if opts.VerifyData && dictSize > 0 {
 		// Need to read the dictionary for verification
 		// Seek back to dictionary start (right after header)
-		dictStart := int64(headerSize)
-		if _, err := archiveFile.Seek(dictStart, io.SeekStart); err != nil {
+		if _, err := archiveFile.Seek(header.DictOffset, io.SeekStart); err != nil {
 			result.Errors = append(result.Errors, fmt.Errorf("seek to dictionary: %w", err))
 		} else {
 			dictionary := make([]byte, dictSize)
 			if _, err := io.ReadFull(archiveFile, dictionary); err != nil {
 				result.Errors = append(result.Errors, fmt.Errorf("read dictionary: %w", err))
 			} else {
-				decoder, _ = zstd.NewReader(nil, zstd.WithDecoderDicts(dictionary))
+				decoder, _ = zstd.NewReader(nil, zstd.WithDecoderDicts(dictionary), zstd.WithDecoderConcurrency(opts.MaxThreads))
 				if decoder != nil {
 					defer decoder.Close()
 				}
 			}
 		}
 	} else if opts.VerifyData {
-		decoder, _ = zstd.NewReader(nil)
+		decoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(opts.MaxThreads))
 		if decoder != nil {
 			defer decoder.Close()
 		}
 	}
 
+	// Files whose data is checked once every entry is read
+	var jobs []fileDataJob
+
+	// Entries read, and links given the outcome of the file they link to
+	var read []*format.FileEntry
+	var links []linkCheck
+	files := make(map[*format.FileEntry]int)
+
 	// Seek to file entries (after header and dictionary)
-	fileEntriesStart := int64(headerSize + int64(dictSize)) // header + dictionary
+	fileEntriesStart := header.DictOffset + int64(dictSize) // header + dictionary
 	if _, err := archiveFile.Seek(fileEntriesStart, io.SeekStart); err != nil {
 		result.Errors = append(result.Errors, fmt.Errorf("seek to file entries: %w", err))
 		return ErrTruncatedArchive
@@ -523,24 +836,23 @@ func verifyGDelta03(archiveFile *os.File, opts *Options, progressCb ProgressCall
 
 	// Read and verify each file entry
 	for i := 0; i < result.FileCount; i++ {
-		entry, err := format.ReadGDelta03FileEntry(archiveFile)
+		entry, err := format.ReadGDelta03FileEntry(archiveFile, header.Extended)
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Errorf("file %d: %w", i, err))
 			result.MetadataValid = false
 			break
 		}
+		result.UnknownExtensions += format.CountUnknownExtensions(entry.Extensions)
 
 		fileInfo := FileInfo{
 			Path:           entry.Path,
 			OriginalSize:   entry.OriginalSize,
 			CompressedSize: entry.CompressedSize,
+			Type:           format.TypeFromExtensions(entry.Extensions),
 		}
 
 		// Check for duplicates
-		if pathTracker.CheckDuplicate(entry.Path) {
-			result.DuplicatePaths++
-			result.Errors = append(result.Errors, fmt.Errorf("duplicate path: %s", entry.Path))
-		}
+		checkDuplicatePath(result, pathTracker, entry.Path)
 
 		// Track stats
 		result.TotalOrigSize += entry.OriginalSize
@@ -549,7 +861,7 @@ func verifyGDelta03(archiveFile *os.File, opts *Options, progressCb ProgressCall
 			result.EmptyFiles++
 		}
 
-		if progressCb != nil {
+		if progressCb != nil && !opts.VerifyData {
 			progressCb(ProgressEvent{
 				Type:     EventFileVerify,
 				FilePath: entry.Path,
@@ -558,41 +870,40 @@ func verifyGDelta03(archiveFile *os.File, opts *Options, progressCb ProgressCall
 			})
 		}
 
-		// Verify data if requested
-		if opts.VerifyData && decoder != nil {
-			// Read compressed data
-			compressedData := make([]byte, entry.CompressedSize)
-			if _, err := io.ReadFull(archiveFile, compressedData); err != nil {
-				fileInfo.Error = fmt.Errorf("read compressed data: %w", err)
-				result.CorruptFiles++
-				result.Errors = append(result.Errors, fmt.Errorf("%s: %w", entry.Path, fileInfo.Error))
-			} else {
-				// Try to decompress
-				decompressed, err := decoder.DecodeAll(compressedData, nil)
-				if err != nil {
-					fileInfo.Error = fmt.Errorf("decompress: %w", err)
-					result.CorruptFiles++
-					result.Errors = append(result.Errors, fmt.Errorf("%s: %w", entry.Path, fileInfo.Error))
-				} else if uint64(len(decompressed)) != entry.OriginalSize {
-					fileInfo.Error = fmt.Errorf("size mismatch: expected %d, got %d", entry.OriginalSize, len(decompressed))
-					result.CorruptFiles++
-					result.Errors = append(result.Errors, fmt.Errorf("%s: %w", entry.Path, fileInfo.Error))
-				} else {
-					fileInfo.DataValid = true
-					result.FilesVerified++
-				}
-			}
-			result.DataVerified = true
-		} else {
-			// Skip over compressed data
-			if _, err := archiveFile.Seek(int64(entry.CompressedSize), io.SeekCurrent); err != nil {
-				result.Errors = append(result.Errors, fmt.Errorf("skip data for %s: %w", entry.Path, err))
+		// The entry's data starts here
+		dataStart, err := archiveFile.Seek(0, io.SeekCurrent)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("locate data for %s: %w", entry.Path, err))
+		}
+		fe := entry.Entry(uint64(dataStart), read)
+		read = append(read, fe)
+		files[fe] = len(result.Files)
+		switch {
+		case fe.Link:
+			if checkLinkEntry(fe, result) && opts.VerifyData {
+				links = append(links, linkCheck{file: len(result.Files), target: fe.Target})
 			}
+		case err == nil && opts.VerifyData && decoder != nil:
+			jobs = append(jobs, fileDataJob{file: len(result.Files), offset: dataStart, entry: fe})
+		}
+
+		// Skip over compressed data
+		if _, err := archiveFile.Seek(dataStart+int64(entry.CompressedSize), io.SeekStart); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("skip data for %s: %w", entry.Path, err))
 		}
 
 		result.Files = append(result.Files, fileInfo)
 	}
 
+	// Verify data if requested
+	if len(jobs) > 0 {
+		data := opts.dataReader(archiveFile)
+		verifyFileData(jobs, opts, progressCb, result, func(job fileDataJob, readBuf *[]byte) error {
+			return verifyGDelta03FileData(data, job.offset, job.entry, decoder, readBuf)
+		})
+		verifyLinks(links, files, result)
+	}
+
 	// Verify footer
 	footer := make([]byte, 8) // "ENDGDLT3"
 	n, err := archiveFile.Read(footer)
@@ -606,6 +917,10 @@ func verifyGDelta03(archiveFile *os.File, opts *Options, progressCb ProgressCall
 		result.Errors = append(result.Errors, fmt.Errorf("invalid footer: got %q, want %q", footer[:n], format.ArchiveFooter03))
 	}
 
+	if !result.FooterValid || !result.MetadataValid {
+		recoverGDelta03(archiveFile, result)
+	}
+
 	result.StructureValid = result.HeaderValid && result.MetadataValid && result.DuplicatePaths == 0
 
 	if progressCb != nil {
@@ -622,51 +937,15 @@ func verifyGDelta03(archiveFile *os.File, opts *Options, progressCb ProgressCall
 
 // verifyXz verifies a .tar.xz archive (single or multi-part)
 func verifyXz(opts *Options, progressCb ProgressCallback, result *Result) error {
-	// Detect multi-part archives
-	xzPaths := []string{opts.InputPath}
-
-	baseName := filepath.Base(opts.InputPath)
-	if strings.Contains(baseName, "_") && strings.HasSuffix(baseName, ".tar.xz") {
-		nameWithoutExt := baseName[:len(baseName)-7]
-		parts := strings.Split(nameWithoutExt, "_")
-		if len(parts) >= 2 {
-			lastPart := parts[len(parts)-1]
-			if len(lastPart) == 2 && lastPart[0] >= '0' && lastPart[0] <= '9' && lastPart[1] >= '0' && lastPart[1] <= '9' {
-				basePattern := strings.Join(parts[:len(parts)-1], "_")
-				dirPath := filepath.Dir(opts.InputPath)
-
-				xzPaths = []string{}
-				for i := 1; i <= 99; i++ {
-					partPath := filepath.Join(dirPath, fmt.Sprintf("%s_%02d.tar.xz", basePattern, i))
-					if _, err := os.Stat(partPath); err == nil {
-						xzPaths = append(xzPaths, partPath)
-					} else {
-						break
-					}
-				}
-			}
-		}
-	}
-
 	result.HeaderValid = true
 	result.MetadataValid = true
 
 	// Track seen paths for duplicate detection
 	pathTracker := godelta.NewPathTracker()
 
-	// Verify each archive part
-	for _, xzPath := range xzPaths {
-		stat, err := os.Stat(xzPath)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("stat %s: %w", xzPath, err))
-			continue
-		}
-		result.ArchiveSize += uint64(stat.Size())
-
-		if err := verifyXzPart(xzPath, opts, progressCb, result, pathTracker); err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("verify %s: %w", xzPath, err))
-		}
-	}
+	verifyParts(opts.InputPath, ".tar.xz", result, func(xzPath string, _ int64) error {
+		return verifyXzPart(xzPath, opts, progressCb, result, pathTracker)
+	})
 
 	result.StructureValid = result.HeaderValid && result.MetadataValid && result.DuplicatePaths == 0
 	result.FooterValid = true // tar.xz doesn't have a specific footer marker
@@ -683,6 +962,93 @@ func verifyXz(opts *Options, progressCb ProgressCallback, result *Result) error
 	return nil
 }
 
+// verifyParts runs verifyPart on every part of a (possibly multi-part)
+// archive, adding their sizes to result. A multi-part archive gets a
+// summary per part in result.Parts, and a missing part is an error: an
+// archive without its first part, or with parts numbered past a gap.
+func verifyParts(path, ext string, result *Result, verifyPart func(path string, size int64) error) {
+	paths := partPaths(path, ext)
+	if len(paths) == 0 {
+		result.HeaderValid = false
+		result.Errors = append(result.Errors, fmt.Errorf("no parts found for %s", path))
+		return
+	}
+	if missing := missingPart(paths, ext); missing != "" {
+		result.MetadataValid = false
+		result.Errors = append(result.Errors, fmt.Errorf("missing part %s (later parts exist)", missing))
+	}
+
+	// The size of the given part was already counted
+	result.ArchiveSize = 0
+	for _, partPath := range paths {
+		stat, err := os.Stat(partPath)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("stat %s: %w", partPath, err))
+			continue
+		}
+		result.ArchiveSize += uint64(stat.Size())
+
+		before := *result
+		if err := verifyPart(partPath, stat.Size()); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("verify %s: %w", partPath, err))
+		}
+		if len(paths) > 1 {
+			result.Parts = append(result.Parts, Part{
+				Path:          partPath,
+				Size:          uint64(stat.Size()),
+				FileCount:     result.FileCount - before.FileCount,
+				TotalOrigSize: result.TotalOrigSize - before.TotalOrigSize,
+				TotalCompSize: result.TotalCompSize - before.TotalCompSize,
+				CorruptFiles:  result.CorruptFiles - before.CorruptFiles,
+				Errors:        len(result.Errors) - len(before.Errors),
+			})
+		}
+	}
+}
+
+// missingPart returns the name of the part missing after paths when a
+// later part exists (name_01, name_02, name_04: name_03), or ""
+func missingPart(paths []string, ext string) string {
+	last := paths[len(paths)-1]
+	if len(paths) == 1 && !strings.HasSuffix(strings.TrimSuffix(last, ext), "_01") {
+		return ""
+	}
+	base := strings.TrimSuffix(last, ext)
+	base = base[:len(base)-3]
+	matches, _ := filepath.Glob(base + "_[0-9][0-9]" + ext)
+	if len(matches) <= len(paths) {
+		return ""
+	}
+	return fmt.Sprintf("%s_%02d%s", filepath.Base(base), len(paths)+1, ext)
+}
+
+// partPaths returns the parts of a multi-part archive named like
+// name_01<ext>, name_02<ext>, ..., or just path for a single archive
+func partPaths(path, ext string) []string {
+	baseName := filepath.Base(path)
+	if !strings.Contains(baseName, "_") || !strings.HasSuffix(baseName, ext) {
+		return []string{path}
+	}
+	nameWithoutExt := baseName[:len(baseName)-len(ext)]
+	parts := strings.Split(nameWithoutExt, "_")
+	lastPart := parts[len(parts)-1]
+	if len(lastPart) != 2 || lastPart[0] < '0' || lastPart[0] > '9' || lastPart[1] < '0' || lastPart[1] > '9' {
+		return []string{path}
+	}
+	basePattern := strings.Join(parts[:len(parts)-1], "_")
+	dirPath := filepath.Dir(path)
+
+	var paths []string
+	for i := 1; i <= 99; i++ {
+		partPath := filepath.Join(dirPath, fmt.Sprintf("%s_%02d%s", basePattern, i, ext))
+		if _, err := os.Stat(partPath); err != nil {
+			break
+		}
+		paths = append(paths, partPath)
+	}
+	return paths
+}
+
 // verifyXzPart verifies a single .tar.xz archive
 func verifyXzPart(xzPath string, opts *Options, progressCb ProgressCallback, result *Result, pathTracker *godelta.PathTracker) error {
 	file, err := os.Open(xzPath)
@@ -698,7 +1064,7 @@ func verifyXzPart(xzPath string, opts *Options, progressCb ProgressCallback, res
 
 	tarReader := tar.NewReader(xzReader)
 
-	for {
+	for opts.canceled() == nil {
 		header, err := tarReader.Next()
 		if err == io.EOF {
 			break
@@ -720,10 +1086,7 @@ func verifyXzPart(xzPath string, opts *Options, progressCb ProgressCallback, res
 		}
 
 		// Check for duplicates
-		if pathTracker.CheckDuplicate(header.Name) {
-			result.DuplicatePaths++
-			result.Errors = append(result.Errors, fmt.Errorf("duplicate path: %s", header.Name))
-		}
+		checkDuplicatePath(result, pathTracker, header.Name)
 
 		// Track stats
 		result.FileCount++
@@ -773,51 +1136,15 @@ func verifyXzPart(xzPath string, opts *Options, progressCb ProgressCallback, res
 
 // verifyZip verifies a .zip archive (single or multi-part)
 func verifyZip(opts *Options, progressCb ProgressCallback, result *Result) error {
-	// Detect multi-part archives (archive_01.zip, archive_02.zip, etc.)
-	zipPaths := []string{opts.InputPath}
-
-	baseName := filepath.Base(opts.InputPath)
-	if strings.Contains(baseName, "_") && strings.HasSuffix(baseName, ".zip") {
-		nameWithoutExt := baseName[:len(baseName)-4] // remove .zip
-		parts := strings.Split(nameWithoutExt, "_")
-		if len(parts) >= 2 {
-			lastPart := parts[len(parts)-1]
-			if len(lastPart) == 2 && lastPart[0] >= '0' && lastPart[0] <= '9' && lastPart[1] >= '0' && lastPart[1] <= '9' {
-				basePattern := strings.Join(parts[:len(parts)-1], "_")
-				dirPath := filepath.Dir(opts.InputPath)
-
-				zipPaths = []string{}
-				for i := 1; i <= 99; i++ {
-					partPath := filepath.Join(dirPath, fmt.Sprintf("%s_%02d.zip", basePattern, i))
-					if _, err := os.Stat(partPath); err == nil {
-						zipPaths = append(zipPaths, partPath)
-					} else {
-						break
-					}
-				}
-			}
-		}
-	}
-
 	result.HeaderValid = true
 	result.MetadataValid = true
 
 	// Track seen paths for duplicate detection
 	pathTracker := godelta.NewPathTracker()
 
-	// Verify each archive part
-	for _, zipPath := range zipPaths {
-		stat, err := os.Stat(zipPath)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("stat %s: %w", zipPath, err))
-			continue
-		}
-		result.ArchiveSize += uint64(stat.Size())
-
-		if err := verifyZipPart(zipPath, opts, progressCb, result, pathTracker); err != nil {
-			result.Errors = append(result.Errors, fmt.Errorf("verify %s: %w", zipPath, err))
-		}
-	}
+	verifyParts(opts.InputPath, ".zip", result, func(zipPath string, size int64) error {
+		return verifyZipPart(zipPath, size, opts, progressCb, result, pathTracker)
+	})
 
 	result.StructureValid = result.HeaderValid && result.MetadataValid && result.DuplicatePaths == 0
 	result.FooterValid = true // ZIP central directory serves as footer
@@ -835,7 +1162,7 @@ func verifyZip(opts *Options, progressCb ProgressCallback, result *Result) error
 }
 
 // verifyZipPart verifies a single .zip archive
-func verifyZipPart(zipPath string, opts *Options, progressCb ProgressCallback, result *Result, pathTracker *godelta.PathTracker) error {
+func verifyZipPart(zipPath string, size int64, opts *Options, progressCb ProgressCallback, result *Result, pathTracker *godelta.PathTracker) error {
 	zipReader, err := zip.OpenReader(zipPath)
 	if err != nil {
 		result.HeaderValid = false
@@ -844,6 +1171,9 @@ func verifyZipPart(zipPath string, opts *Options, progressCb ProgressCallback, r
 	defer zipReader.Close()
 
 	for _, file := range zipReader.File {
+		if opts.canceled() != nil {
+			break
+		}
 		// Skip directories
 		if file.FileInfo().IsDir() {
 			continue
@@ -855,12 +1185,15 @@ func verifyZipPart(zipPath string, opts *Options, progressCb ProgressCallback, r
 			CompressedSize: file.CompressedSize64,
 		}
 
-		// Check for duplicates
-		if pathTracker.CheckDuplicate(file.Name) {
-			result.DuplicatePaths++
-			result.Errors = append(result.Errors, fmt.Errorf("duplicate path: %s", file.Name))
+		if err := checkZipEntry(file, size); err != nil {
+			fileInfo.Error = err
+			result.MetadataValid = false
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", file.Name, err))
 		}
 
+		// Check for duplicates
+		checkDuplicatePath(result, pathTracker, file.Name)
+
 		// Track stats
 		result.FileCount++
 		result.TotalOrigSize += file.UncompressedSize64
@@ -912,3 +1245,64 @@ func verifyZipPart(zipPath string, opts *Options, progressCb ProgressCallback, r
 
 	return nil
 }
+
+// checkZipEntry checks a central directory entry against the archive: its
+// local header must be where the entry points, and its data must end
+// inside the archive (before the central directory of a complete one)
+func checkZipEntry(file *zip.File, archiveSize int64) error {
+	offset, err := file.DataOffset()
+	if err != nil {
+		return fmt.Errorf("local header: %w", err)
+	}
+	if uint64(offset)+file.CompressedSize64 > uint64(archiveSize) {
+		return fmt.Errorf("data at offset %d (%d bytes) runs past the end of the archive (%d bytes)", offset, file.CompressedSize64, archiveSize)
+	}
+	if file.Method == zip.Store && file.CompressedSize64 != file.UncompressedSize64 {
+		return fmt.Errorf("stored entry is %d bytes but its size is %d", file.CompressedSize64, file.UncompressedSize64)
+	}
+	return nil
+}
+
+// checkTimestamp checks the archive against its trusted timestamp sidecar,
+// if it has one. A mismatch means the archive changed after it was stamped.
+func checkTimestamp(archivePath string, result *Result) {
+	token, err := timestamp.Check(archivePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("timestamp %s: %w", timestamp.SidecarPath(archivePath), err))
+		return
+	}
+	result.Timestamp = token
+}
+
+// decodedSize decodes data with the codec registered for method and returns
+// the decoded length (stored data is its own length)
+func decodedSize(method format.Method, data []byte) (int64, error) {
+	codec, ok := format.LookupCodec(method)
+	if !ok {
+		return 0, fmt.Errorf("unknown compression method: %s", method)
+	}
+	r, err := codec.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("create decoder: %w", err)
+	}
+	defer r.Close()
+	return io.Copy(io.Discard, r)
+}
+
+// checkDuplicatePath records path as an error when the archive already
+// holds it, or a path only its Unicode normalization tells apart
+func checkDuplicatePath(result *Result, pathTracker *godelta.PathTracker, path string) {
+	first, dup := pathTracker.Duplicate(path)
+	if !dup {
+		return
+	}
+	result.DuplicatePaths++
+	if first != path {
+		result.Errors = append(result.Errors, fmt.Errorf("%w: %s (same as %s once Unicode-normalized)", ErrDuplicatePath, path, first))
+		return
+	}
+	result.Errors = append(result.Errors, fmt.Errorf("%w: %s", ErrDuplicatePath, path))
+}
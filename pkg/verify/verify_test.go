@@ -3,13 +3,20 @@ package verify_test
 
 import (
 	"bytes"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/creativeyann17/go-delta/internal/format"
 	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/godelta"
 	"github.com/creativeyann17/go-delta/pkg/verify"
 )
 
@@ -73,6 +80,9 @@ func TestVerifyGDelta01(t *testing.T) {
 		if result.FileCount != 3 {
 			t.Errorf("Expected 3 files, got %d", result.FileCount)
 		}
+		if !result.EntryIndex {
+			t.Error("Entry index should be present and valid")
+		}
 		if !result.IsValid() {
 			t.Errorf("Archive should be valid, errors: %v", result.Errors)
 		}
@@ -166,6 +176,9 @@ func TestVerifyGDelta02(t *testing.T) {
 		if result.ChunkSize != 4*1024 {
 			t.Errorf("Expected chunk size 4096, got %d", result.ChunkSize)
 		}
+		if result.Codec != "zstd" || result.Level != 5 {
+			t.Errorf("Expected codec zstd level 5, got %s level %d", result.Codec, result.Level)
+		}
 		if result.ChunkCount == 0 {
 			t.Error("Expected chunks > 0")
 		}
@@ -174,6 +187,33 @@ func TestVerifyGDelta02(t *testing.T) {
 		}
 	})
 
+	// Archives written before codec/level were recorded have zero high bytes
+	// in the chunk size field
+	t.Run("LegacyHeader", func(t *testing.T) {
+		data, err := os.ReadFile(archivePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 12; i < 16; i++ {
+			data[i] = 0
+		}
+		legacyPath := filepath.Join(t.TempDir(), "legacy.gdelta")
+		if err := os.WriteFile(legacyPath, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := verify.Verify(&verify.Options{InputPath: legacyPath, VerifyData: true}, nil)
+		if err != nil {
+			t.Fatalf("Verification failed: %v", err)
+		}
+		if result.ChunkSize != 4*1024 || result.Codec != "unknown" || result.Level != 0 {
+			t.Errorf("Unexpected legacy header info: size=%d codec=%s level=%d", result.ChunkSize, result.Codec, result.Level)
+		}
+		if !result.IsValid() {
+			t.Errorf("Legacy archive should be valid, errors: %v", result.Errors)
+		}
+	})
+
 	// Verify with data check
 	t.Run("DataValidation", func(t *testing.T) {
 		opts := &verify.Options{
@@ -234,6 +274,184 @@ func TestVerifyNonExistent(t *testing.T) {
 	}
 }
 
+// TestVerifyOversizedCounts checks that headers declaring more files or
+// chunks than the read limits fail as malformed instead of allocating
+func TestVerifyOversizedCounts(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"gdelta01": format.ArchiveMagic + "\xff\xff\xff\xff",
+		"gdelta02": format.ArchiveMagic02 + "\x00\x00\x01\x00\x00\x00\x00\x00" + "\x01\x00\x00\x00" + "\xff\xff\xff\xff",
+		"gdelta03": format.ArchiveMagic03 + "\x01\x00\x00\x00\x00\xff\xff\xff\xff\x00\x00\x00\x00",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		result, err := verify.Verify(&verify.Options{InputPath: path}, nil)
+		if !errors.Is(err, verify.ErrMalformedArchive) {
+			t.Errorf("%s: got %v, want ErrMalformedArchive", name, err)
+		}
+		if result.IsValid() {
+			t.Errorf("%s: archive should not be valid", name)
+		}
+	}
+
+	// Lowered limits refuse an archive that is otherwise intact
+	input := t.TempDir()
+	for i := range 4 {
+		if err := os.WriteFile(filepath.Join(input, fmt.Sprintf("f%d.txt", i)), []byte("content"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output := filepath.Join(dir, "backup.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: input, OutputPath: output, ChunkSize: 4 << 10, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer godelta.SetReadLimits(godelta.CurrentReadLimits())
+	limits := godelta.DefaultReadLimits()
+	limits.MaxFiles = 2
+	godelta.SetReadLimits(limits)
+	_, err := verify.Verify(&verify.Options{InputPath: output}, nil)
+	if !errors.Is(err, verify.ErrMalformedArchive) || godelta.Category(err) != godelta.ErrCorrupt {
+		t.Errorf("got %v, want ErrMalformedArchive", err)
+	}
+}
+
+// TestVerifyOversizedLengths sets the compressed size of the first entry
+// or chunk past the end of the archive: verify reports the archive
+// malformed instead of allocating that much
+func TestVerifyOversizedLengths(t *testing.T) {
+	input := t.TempDir()
+	if err := os.WriteFile(filepath.Join(input, "a.txt"), []byte("some content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for name, opts := range map[string]compress.Options{
+		"gdelta01": {},
+		"gdelta02": {ChunkSize: 4 << 10},
+		"gdelta03": {UseDictionary: true},
+	} {
+		for _, compSize := range []uint64{1 << 40, 1<<63 - 1, 1<<64 - 16} {
+			t.Run(fmt.Sprintf("%s/%d", name, compSize), func(t *testing.T) {
+				opts.InputPath = input
+				opts.OutputPath = filepath.Join(t.TempDir(), "a.gdelta")
+				opts.Quiet = true
+				if _, err := compress.Compress(&opts, nil); err != nil {
+					t.Fatal(err)
+				}
+				data, err := os.ReadFile(opts.OutputPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				layout, _ := format.Describe(bytes.NewReader(data), int64(len(data)), 1)
+				i := slices.IndexFunc(layout.Fields, func(f format.Field) bool { return strings.HasSuffix(f.Name, "].comp_size") })
+				if i < 0 {
+					t.Fatal("no comp_size field")
+				}
+				if name == "gdelta02" {
+					compSize &^= 1 << 63 // the stored flag
+				}
+				binary.LittleEndian.PutUint64(data[layout.Fields[i].Offset:], compSize)
+				if err := os.WriteFile(opts.OutputPath, data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+
+				result, err := verify.Verify(&verify.Options{InputPath: opts.OutputPath, VerifyData: true, Quiet: true}, nil)
+				if result == nil || result.IsValid() {
+					t.Fatalf("archive reported valid (err %v)", err)
+				}
+				if !strings.Contains(fmt.Sprint(err, result.Errors), "past the end of the archive") {
+					t.Errorf("got %v %v, want the data reported past the end", err, result.Errors)
+				}
+			})
+		}
+	}
+}
+
+// TestVerifyFeatures checks that verify lists the features an archive
+// requires, and refuses one with a feature this version doesn't know
+func TestVerifyFeatures(t *testing.T) {
+	input := t.TempDir()
+	for i := range 20 {
+		content := strings.Repeat(fmt.Sprintf("line %d of a similar file\n", i), 300)
+		if err := os.WriteFile(filepath.Join(input, fmt.Sprintf("f%d.txt", i)), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output := filepath.Join(t.TempDir(), "backup.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: input, OutputPath: output, ChunkSize: 4 << 10, ChunkDictionary: true, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	result, err := verify.Verify(&verify.Options{InputPath: output}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Features) != 1 || result.Features[0] != "chunk-dictionary" {
+		t.Errorf("features %v, want [chunk-dictionary]", result.Features)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[15] |= 0x40 // bit 6 of the features, in the params field
+	if err := os.WriteFile(output, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = verify.Verify(&verify.Options{InputPath: output}, nil)
+	if !errors.Is(err, verify.ErrUnsupportedFeature) {
+		t.Errorf("got %v, want ErrUnsupportedFeature", err)
+	}
+	if got := strings.Join(result.Features, ", "); got != "chunk-dictionary, bit 6" {
+		t.Errorf("features %q, want chunk-dictionary and bit 6", got)
+	}
+}
+
+// TestResultIssues checks the severity and code of errors and warnings,
+// and their JSON form
+func TestResultIssues(t *testing.T) {
+	result := &verify.Result{
+		HeaderValid: true,
+		Errors: []error{
+			fmt.Errorf("a.txt: %w: 0123abcd", verify.ErrMissingChunk),
+			fmt.Errorf("read footer: %w", os.ErrPermission),
+			errors.New("something else"),
+		},
+		Warnings: []error{fmt.Errorf("%w: 2 chunks", verify.ErrOrphanedChunk)},
+		Files:    []verify.FileInfo{{Path: "a.txt", Error: verify.ErrCorruptData}},
+	}
+
+	want := []verify.Issue{
+		{Severity: verify.SeverityError, Code: "missing_chunk", Message: result.Errors[0].Error()},
+		{Severity: verify.SeverityError, Code: "permission", Message: result.Errors[1].Error()},
+		{Severity: verify.SeverityError, Code: "error", Message: "something else"},
+		{Severity: verify.SeverityWarning, Code: "orphaned_chunk", Message: result.Warnings[0].Error()},
+	}
+	issues := result.Issues()
+	if fmt.Sprint(issues) != fmt.Sprint(want) {
+		t.Errorf("issues %v, want %v", issues, want)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Valid    bool
+		Errors   []verify.Issue
+		Warnings []verify.Issue
+		Files    []struct{ Path, Error string }
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Valid || len(decoded.Errors) != 3 || len(decoded.Warnings) != 1 || decoded.Warnings[0].Code != "orphaned_chunk" {
+		t.Errorf("unexpected JSON result: %s", data)
+	}
+	if len(decoded.Files) != 1 || decoded.Files[0].Error != verify.ErrCorruptData.Error() {
+		t.Errorf("file error not encoded: %s", data)
+	}
+}
+
 // TestResultMethods tests Result helper methods
 func TestResultMethods(t *testing.T) {
 	t.Run("CompressionRatio", func(t *testing.T) {
@@ -692,20 +910,17 @@ func TestVerifyXZFormat(t *testing.T) {
 		OutputPath:  archivePath,
 		Level:       1, // Fast for tests
 		UseXzFormat: true,
-		MaxThreads:  1, // Single part for simpler testing
+		MaxThreads:  2, // Blocks compressed in parallel, still one archive
 		Quiet:       true,
 	}
 	if _, err := compress.Compress(compOpts, nil); err != nil {
 		t.Fatalf("Compression failed: %v", err)
 	}
 
-	// Find the actual archive path (multi-part naming: test_01.tar.xz)
-	actualPath := filepath.Join(filepath.Dir(archivePath), "test_01.tar.xz")
-
 	// Verify archive
 	t.Run("StructuralValidation", func(t *testing.T) {
 		opts := &verify.Options{
-			InputPath:  actualPath,
+			InputPath:  archivePath,
 			VerifyData: false,
 		}
 
@@ -734,7 +949,7 @@ func TestVerifyXZFormat(t *testing.T) {
 	// Verify with data check
 	t.Run("DataValidation", func(t *testing.T) {
 		opts := &verify.Options{
-			InputPath:  actualPath,
+			InputPath:  archivePath,
 			VerifyData: true,
 		}
 
@@ -844,6 +1059,126 @@ func TestVerifyZIPFormat(t *testing.T) {
 			t.Errorf("Expected 0 corrupt files, got %d", result.CorruptFiles)
 		}
 	})
+
+	// corrupt copies the archive with its bytes changed by fn
+	corrupt := func(t *testing.T, fn func(data []byte)) string {
+		data, err := os.ReadFile(actualPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn(data)
+		path := filepath.Join(t.TempDir(), "corrupt.zip")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// A damaged local header (the second one: the first is the format
+	// signature) doesn't match the central directory
+	t.Run("LocalHeaderMismatch", func(t *testing.T) {
+		path := corrupt(t, func(data []byte) {
+			i := bytes.Index(data[4:], []byte("PK\x03\x04"))
+			data[4+i+3] = 0
+		})
+		result, err := verify.Verify(&verify.Options{InputPath: path}, nil)
+		if err != nil {
+			t.Fatalf("Verification failed: %v", err)
+		}
+		if result.StructureValid || result.IsValid() {
+			t.Error("Structure should be invalid")
+		}
+	})
+
+	// Level 1 stores entries: a changed byte fails the CRC32
+	t.Run("CRCMismatch", func(t *testing.T) {
+		path := corrupt(t, func(data []byte) {
+			i := bytes.Index(data, []byte("hello world"))
+			data[i] ^= 0xFF
+		})
+		result, err := verify.Verify(&verify.Options{InputPath: path, VerifyData: true}, nil)
+		if err != nil {
+			t.Fatalf("Verification failed: %v", err)
+		}
+		if !result.StructureValid {
+			t.Errorf("Structure should be valid, errors: %v", result.Errors)
+		}
+		if result.CorruptFiles != 1 || result.FilesVerified != 2 {
+			t.Errorf("Expected 1 corrupt and 2 verified files, got %d and %d", result.CorruptFiles, result.FilesVerified)
+		}
+	})
+}
+
+// TestVerifyMultiPart verifies every part from any of them, with a
+// summary per part, and reports a part missing before later ones
+func TestVerifyMultiPart(t *testing.T) {
+	archiveDir := t.TempDir()
+	for i := 1; i <= 3; i++ {
+		sourceDir := t.TempDir()
+		for j := range i {
+			name := filepath.Join(sourceDir, fmt.Sprintf("part%d_file%d.txt", i, j))
+			if err := os.WriteFile(name, bytes.Repeat([]byte("part data "), 100*i), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err := compress.Compress(&compress.Options{
+			InputPath:    sourceDir,
+			OutputPath:   filepath.Join(archiveDir, fmt.Sprintf("test_%02d.zip", i)),
+			Level:        5,
+			UseZipFormat: true,
+			SingleZip:    true,
+			Quiet:        true,
+		}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := verify.Verify(&verify.Options{InputPath: filepath.Join(archiveDir, "test_02.zip"), VerifyData: true}, nil)
+	if err != nil {
+		t.Fatalf("Verification failed: %v", err)
+	}
+	if !result.IsValid() {
+		t.Fatalf("Archive should be valid, errors: %v", result.Errors)
+	}
+	if result.FileCount != 6 || result.FilesVerified != 6 {
+		t.Errorf("Expected 6 files verified, got %d of %d", result.FilesVerified, result.FileCount)
+	}
+	if len(result.Parts) != 3 {
+		t.Fatalf("Expected 3 parts, got %d", len(result.Parts))
+	}
+	var size uint64
+	for i, part := range result.Parts {
+		if filepath.Base(part.Path) != fmt.Sprintf("test_%02d.zip", i+1) || part.FileCount != i+1 {
+			t.Errorf("Part %d: %s with %d files", i+1, part.Path, part.FileCount)
+		}
+		size += part.Size
+	}
+	if size != result.ArchiveSize {
+		t.Errorf("Part sizes add up to %d, archive size %d", size, result.ArchiveSize)
+	}
+	if !contains(result.Summary(), "test_03.zip: 3 files") {
+		t.Errorf("Summary missing part line:\n%s", result.Summary())
+	}
+
+	if err := os.Remove(filepath.Join(archiveDir, "test_02.zip")); err != nil {
+		t.Fatal(err)
+	}
+	result, err = verify.Verify(&verify.Options{InputPath: filepath.Join(archiveDir, "test_01.zip")}, nil)
+	if err != nil {
+		t.Fatalf("Verification failed: %v", err)
+	}
+	if result.IsValid() || !contains(fmt.Sprint(result.Errors), "missing part test_02.zip") {
+		t.Errorf("Expected a missing part error, got %v", result.Errors)
+	}
+
+	result, err = verify.Verify(&verify.Options{InputPath: filepath.Join(archiveDir, "test_03.zip")}, nil)
+	if err != nil {
+		t.Fatalf("Verification failed: %v", err)
+	}
+	if result.IsValid() {
+		t.Error("Archive with a missing part should be invalid")
+	}
 }
 
 // Helper function
@@ -937,3 +1272,292 @@ func BenchmarkVerifyData(b *testing.B) {
 		}
 	}
 }
+
+// TestVerifyParallel checks that data verification gives the same result on
+// one and several workers, and pins a damaged file on the right entry
+func TestVerifyParallel(t *testing.T) {
+	sourceDir := t.TempDir()
+	for i := 0; i < 40; i++ {
+		content := bytes.Repeat([]byte(fmt.Sprintf("file %d line\n", i)), 200+i*50)
+		if err := os.WriteFile(filepath.Join(sourceDir, fmt.Sprintf("f%02d.txt", i)), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	formats := map[string]*compress.Options{
+		"GDELTA01": {},
+		"GDELTA02": {ChunkSize: 4 * 1024},
+		"GDELTA03": {UseDictionary: true},
+	}
+	for name, compOpts := range formats {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "test.gdelta")
+			compOpts.InputPath = sourceDir
+			compOpts.OutputPath = archivePath
+			compOpts.Quiet = true
+			if _, err := compress.Compress(compOpts, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			var results []*verify.Result
+			for _, threads := range []int{1, 8} {
+				result, err := verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true, MaxThreads: threads}, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !result.IsValid() || result.FilesVerified != 40 {
+					t.Fatalf("threads=%d: %d files verified, errors: %v", threads, result.FilesVerified, result.Errors)
+				}
+				results = append(results, result)
+			}
+			if results[0].ChunksVerified != results[1].ChunksVerified {
+				t.Errorf("chunks verified: %d on one worker, %d on eight", results[0].ChunksVerified, results[1].ChunksVerified)
+			}
+		})
+	}
+
+	// Damage the data of one GDELTA01 entry
+	archivePath := filepath.Join(t.TempDir(), "test.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: sourceDir, OutputPath: archivePath, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := format.ReadGDelta01Index(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	damaged := entries[17]
+	for i := uint64(0); i < 8; i++ {
+		data[damaged.DataOffset+damaged.CompressedSize/2+i] ^= 0xff
+	}
+	if err := os.WriteFile(archivePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true, MaxThreads: 8}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.CorruptFiles != 1 || result.FilesVerified != 39 {
+		t.Fatalf("expected 1 corrupt file, got %d (%d verified)", result.CorruptFiles, result.FilesVerified)
+	}
+	for _, f := range result.Files {
+		if (f.Error != nil) != (f.Path == damaged.Path) {
+			t.Errorf("%s: error %v", f.Path, f.Error)
+		}
+	}
+}
+
+// TestVerifyCompareDir compares archives of every format with a copy of
+// their source where one file changed, one is gone and one was added
+func TestVerifyCompareDir(t *testing.T) {
+	sourceDir := t.TempDir()
+	files := make(map[string][]byte)
+	for i := 0; i < 12; i++ {
+		name := fmt.Sprintf("dir%d/f%02d.txt", i%3, i)
+		files[name] = bytes.Repeat([]byte(fmt.Sprintf("file %d line\n", i)), 100+i*40)
+		path := filepath.Join(sourceDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, files[name], 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	formats := map[string]*compress.Options{
+		"GDELTA01": {},
+		"GDELTA02": {ChunkSize: 4 * 1024},
+		"GDELTA03": {UseDictionary: true},
+		"ZIP":      {UseZipFormat: true, MaxThreads: 1},
+		"XZ":       {UseXzFormat: true, MaxThreads: 1, Level: 1},
+	}
+	for name, compOpts := range formats {
+		t.Run(name, func(t *testing.T) {
+			outDir := t.TempDir()
+			archivePath := filepath.Join(outDir, "test.gdelta")
+			switch {
+			case compOpts.UseZipFormat:
+				archivePath = filepath.Join(outDir, "test.zip")
+			case compOpts.UseXzFormat:
+				archivePath = filepath.Join(outDir, "test.tar.xz")
+			}
+			compOpts.InputPath = sourceDir
+			compOpts.OutputPath = archivePath
+			compOpts.Quiet = true
+			if _, err := compress.Compress(compOpts, nil); err != nil {
+				t.Fatal(err)
+			}
+			if compOpts.UseZipFormat {
+				matches, _ := filepath.Glob(filepath.Join(outDir, "test_01.*"))
+				if len(matches) != 1 {
+					t.Fatalf("archive parts: %v", matches)
+				}
+				archivePath = matches[0]
+			}
+
+			compareDir := t.TempDir()
+			for name, content := range files {
+				path := filepath.Join(compareDir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, content, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			result, err := verify.Verify(&verify.Options{InputPath: archivePath, CompareDir: compareDir}, nil)
+			if err != nil || !result.IsValid() || result.Compare == nil {
+				t.Fatalf("verify: %v, %v", err, result.Errors)
+			}
+			if c := result.Compare; c.Matching != len(files) || c.Differs() {
+				t.Fatalf("identical directory: %+v", c)
+			}
+
+			// Same size, other content; a removed file; an added one
+			modified := bytes.ToUpper(files["dir0/f03.txt"])
+			if err := os.WriteFile(filepath.Join(compareDir, "dir0", "f03.txt"), modified, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Remove(filepath.Join(compareDir, "dir2", "f05.txt")); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(compareDir, "dir1", "new.txt"), []byte("new"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err = verify.Verify(&verify.Options{InputPath: archivePath, CompareDir: compareDir, MaxThreads: 4}, nil)
+			if err != nil || !result.IsValid() {
+				t.Fatalf("verify: %v, %v", err, result.Errors)
+			}
+			c := result.Compare
+			if c.Matching != len(files)-2 || c.Modified != 1 || c.Missing != 1 || c.Extra != 1 {
+				t.Fatalf("comparison: %+v", c)
+			}
+			if c.ModifiedPaths[0] != "dir0/f03.txt" || c.MissingPaths[0] != "dir2/f05.txt" || c.ExtraPaths[0] != "dir1/new.txt" {
+				t.Errorf("paths: %v %v %v", c.ModifiedPaths, c.MissingPaths, c.ExtraPaths)
+			}
+		})
+	}
+}
+
+// TestVerifyManifest checks archives against the manifest written with
+// them, then against one with a wrong digest, a missing line and an extra
+// line
+func TestVerifyManifest(t *testing.T) {
+	sourceDir := t.TempDir()
+	for i := 0; i < 8; i++ {
+		path := filepath.Join(sourceDir, fmt.Sprintf("dir%d", i%2), fmt.Sprintf("f%d.txt", i))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, bytes.Repeat([]byte(fmt.Sprintf("file %d\n", i)), 50+i*10), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	formats := map[string]*compress.Options{
+		"GDELTA02": {ChunkSize: 4 * 1024},
+		"ZIP":      {UseZipFormat: true, MaxThreads: 2},
+		"XZ":       {UseXzFormat: true, Level: 1},
+	}
+	for name, compOpts := range formats {
+		t.Run(name, func(t *testing.T) {
+			outDir := t.TempDir()
+			archivePath := filepath.Join(outDir, "test.gdelta")
+			switch {
+			case compOpts.UseZipFormat:
+				archivePath = filepath.Join(outDir, "test.zip")
+			case compOpts.UseXzFormat:
+				archivePath = filepath.Join(outDir, "test.tar.xz")
+			}
+			compOpts.InputPath = sourceDir
+			compOpts.OutputPath = archivePath
+			compOpts.WriteManifest = true
+			compOpts.Quiet = true
+			compResult, err := compress.Compress(compOpts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if compResult.ManifestPath != archivePath+".sha256" {
+				t.Fatalf("manifest written to %q", compResult.ManifestPath)
+			}
+			if compOpts.UseZipFormat {
+				archivePath = filepath.Join(outDir, "test_01.zip")
+			}
+
+			// Structural verification leaves the manifest alone
+			result, err := verify.Verify(&verify.Options{InputPath: archivePath}, nil)
+			if err != nil || result.Manifest != nil {
+				t.Fatalf("structural verify: %v, %+v", err, result.Manifest)
+			}
+
+			result, err = verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true}, nil)
+			if err != nil || !result.IsValid() || result.Manifest == nil {
+				t.Fatalf("verify: %v, %v", err, result.Errors)
+			}
+			if m := result.Manifest; m.Matching != 8 || m.Differs() {
+				t.Fatalf("manifest: %+v", m)
+			}
+
+			// Wrong digest for dir0/f0.txt, no line for dir0/f2.txt, an
+			// extra line for gone.txt
+			data, err := os.ReadFile(compResult.ManifestPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			lines[0] = strings.Repeat("0", 64) + lines[0][64:]
+			lines = append(lines[:1], lines[2:]...)
+			lines = append(lines, strings.Repeat("1", 64)+"  gone.txt")
+			tampered := filepath.Join(outDir, "tampered.sha256")
+			if err := os.WriteFile(tampered, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err = verify.Verify(&verify.Options{InputPath: archivePath, Manifest: tampered, MaxThreads: 4}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := result.Manifest
+			if m == nil || m.Matching != 6 || m.Mismatched != 1 || m.Missing != 1 || m.Unlisted != 1 {
+				t.Fatalf("tampered manifest: %+v", m)
+			}
+			if m.MismatchedPaths[0] != "dir0/f0.txt" || m.UnlistedPaths[0] != "dir0/f2.txt" || m.MissingPaths[0] != "gone.txt" {
+				t.Errorf("paths: %v %v %v", m.MismatchedPaths, m.UnlistedPaths, m.MissingPaths)
+			}
+		})
+	}
+}
+
+func TestVerifyReadLimit(t *testing.T) {
+	sourceDir := t.TempDir()
+	content := make([]byte, 256<<10)
+	for i := range content {
+		content[i] = byte(i*7919 ^ i>>5) // compresses poorly
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "data.bin"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	archivePath := filepath.Join(t.TempDir(), "test.gdelta")
+	if _, err := compress.Compress(&compress.Options{InputPath: sourceDir, OutputPath: archivePath, Level: 1, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	result, err := verify.Verify(&verify.Options{InputPath: archivePath, VerifyData: true, ReadLimit: 1 << 20, Quiet: true}, nil)
+	if err != nil || !result.IsValid() {
+		t.Fatalf("verify: %v, %v", err, result.Errors)
+	}
+	// Most of the archive is data, read at 1 MB/s
+	if want := time.Duration(info.Size()/2) * time.Second / (1 << 20); time.Since(start) < want {
+		t.Errorf("verified %d bytes in %v, want at least %v", info.Size(), time.Since(start), want)
+	}
+}
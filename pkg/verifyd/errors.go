@@ -0,0 +1,19 @@
+// pkg/verifyd/errors.go
+package verifyd
+
+import "github.com/creativeyann17/go-delta/pkg/godelta"
+
+// Every error matches a godelta error category with errors.Is
+var (
+	// ErrRootRequired is returned when no root directory is given
+	ErrRootRequired = godelta.NewError(godelta.ErrUsage, "root directory is required")
+
+	// ErrRootNotDir is returned when the root isn't a directory
+	ErrRootNotDir = godelta.NewError(godelta.ErrUsage, "root must be a directory")
+
+	// ErrStatusRequired is returned when no status file is given
+	ErrStatusRequired = godelta.NewError(godelta.ErrUsage, "status file is required")
+
+	// ErrInvalidStatus is returned when the status file can't be parsed
+	ErrInvalidStatus = godelta.NewError(godelta.ErrCorrupt, "invalid verify-daemon status file")
+)
@@ -0,0 +1,70 @@
+// pkg/verifyd/options.go
+package verifyd
+
+import (
+	"os"
+	"time"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+)
+
+// Options configures the verification daemon
+type Options struct {
+	// Root is the directory whose .gdelta archives are verified, searched
+	// recursively (required)
+	Root string
+
+	// StatusPath is the JSON file recording the last verification of each
+	// archive (required). It is read at start, so a restarted daemon
+	// carries on where it stopped.
+	StatusPath string
+
+	// Interval is how long a verification holds: an archive is verified
+	// again once its last verification is older
+	// Default: 24h
+	Interval time.Duration
+
+	// ReadLimit caps the archive data read, in bytes per second
+	// (see verify.Options.ReadLimit)
+	// Default: 0 (no limit)
+	ReadLimit int64
+
+	// MaxThreads is the number of files or chunks checked at once
+	// Default: 1
+	MaxThreads int
+
+	// CPUPriority and IOPriority lower the priority of the process when
+	// Run starts (see compress.Options.CPUPriority and IOPriority)
+	CPUPriority int
+	IOPriority  compress.IOPriority
+
+	// Logf receives one line per verification (optional)
+	Logf func(format string, args ...interface{})
+}
+
+// Validate checks if options are valid
+func (o *Options) Validate() error {
+	if o.Root == "" {
+		return ErrRootRequired
+	}
+	if o.StatusPath == "" {
+		return ErrStatusRequired
+	}
+	info, err := os.Stat(o.Root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return ErrRootNotDir
+	}
+	if o.Interval <= 0 {
+		o.Interval = 24 * time.Hour
+	}
+	if o.MaxThreads <= 0 {
+		o.MaxThreads = 1
+	}
+	if o.Logf == nil {
+		o.Logf = func(string, ...interface{}) {}
+	}
+	return nil
+}
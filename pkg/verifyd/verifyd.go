@@ -0,0 +1,373 @@
+// pkg/verifyd/verifyd.go
+
+// Package verifyd verifies the archives under a directory continuously, at
+// a low priority, so damage on cold storage is found while it can still be
+// repaired or restored from another copy.
+package verifyd
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/metrics"
+	"github.com/creativeyann17/go-delta/pkg/verify"
+)
+
+// rescanInterval is how often Run looks for archives due once none is
+const rescanInterval = time.Minute
+
+// settleTime is how long an archive must go unmodified before it is
+// verified, so one still being written isn't reported damaged
+const settleTime = time.Minute
+
+// ArchiveStatus is the last verification of an archive
+type ArchiveStatus struct {
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+	Verified time.Time `json:"verified"`
+	Duration float64   `json:"duration_seconds"`
+	Valid    bool      `json:"valid"`
+	Errors   []string  `json:"errors,omitempty"`
+	Warnings []string  `json:"warnings,omitempty"`
+}
+
+// Status is the daemon's record, kept in the status file
+type Status struct {
+	Root          string    `json:"root"`
+	Updated       time.Time `json:"updated"`
+	Current       string    `json:"current,omitempty"` // archive being verified
+	Verifications int       `json:"verifications"`     // since the status file was created
+	LastError     string    `json:"last_error,omitempty"`
+
+	// Archives are keyed by their slash-separated path below Root
+	Archives map[string]*ArchiveStatus `json:"archives"`
+}
+
+// Invalid lists the archives whose last verification failed
+func (s *Status) Invalid() []string {
+	var invalid []string
+	for path, a := range s.Archives {
+		if !a.Valid {
+			invalid = append(invalid, path)
+		}
+	}
+	slices.Sort(invalid)
+	return invalid
+}
+
+// archive is a .gdelta file found under Root
+type archive struct {
+	rel, path string
+	size      int64
+	modTime   time.Time
+}
+
+// Daemon verifies the archives under Root one at a time, each again once
+// its last verification is older than Interval
+type Daemon struct {
+	opts *Options
+
+	mu     sync.Mutex
+	status Status
+}
+
+// New creates a daemon after validating its options, carrying on from the
+// status file when there is one
+func New(opts *Options) (*Daemon, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	status, err := loadStatus(opts.StatusPath)
+	if err != nil {
+		return nil, err
+	}
+	status.Root = opts.Root
+	status.Current = ""
+	if status.Archives == nil {
+		status.Archives = make(map[string]*ArchiveStatus)
+	}
+	return &Daemon{opts: opts, status: *status}, nil
+}
+
+// Status returns a copy of the daemon's record
+func (d *Daemon) Status() Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.status
+	s.Archives = make(map[string]*ArchiveStatus, len(d.status.Archives))
+	for path, a := range d.status.Archives {
+		copied := *a
+		s.Archives[path] = &copied
+	}
+	return s
+}
+
+// Run lowers the priority of the process, then verifies the archives due
+// and looks for more every minute until ctx is canceled. A platform
+// without the priority asked for only gets a log line. A verification
+// still running at shutdown is abandoned and starts over next time; Run
+// returns nil on a clean shutdown.
+func (d *Daemon) Run(ctx context.Context) error {
+	err := compress.LowerPriority(d.opts.CPUPriority, d.opts.IOPriority)
+	if errors.Is(err, compress.ErrPriorityUnsupported) {
+		d.opts.Logf("Running at normal priority: %v", err)
+	} else if err != nil {
+		return fmt.Errorf("lower priority: %w", err)
+	}
+	d.opts.Logf("Verifying the archives under %s every %v", d.opts.Root, d.opts.Interval)
+
+	for {
+		if _, err := d.Pass(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(rescanInterval):
+		}
+	}
+}
+
+// Pass verifies every archive under Root that is due: never verified,
+// changed since its last verification, or verified more than Interval ago.
+// Those never verified or changed come first, then the least recently
+// verified. Archives modified in the last minute are left for a later
+// pass, and none is verified twice. The status file is written after each
+// archive. Pass returns the number of archives verified; Root being
+// unreadable is recorded in the status, only failing to write the status
+// file is an error.
+func (d *Daemon) Pass(ctx context.Context) (int, error) {
+	verified := 0
+	passed := make(map[string]bool)
+	for ctx.Err() == nil {
+		found, err := d.scan()
+		if err != nil {
+			d.mu.Lock()
+			d.status.LastError = err.Error()
+			d.mu.Unlock()
+			d.opts.Logf("Scan failed: %v", err)
+			return verified, d.save()
+		}
+		next, ok := d.next(found, passed, time.Now())
+		if !ok {
+			return verified, d.save()
+		}
+		if !d.verify(ctx, next) {
+			break
+		}
+		passed[next.rel] = true
+		verified++
+		if err := d.save(); err != nil {
+			return verified, err
+		}
+	}
+	return verified, nil
+}
+
+// scan lists the .gdelta files under Root, skipping unreadable
+// directories below it
+func (d *Daemon) scan() ([]archive, error) {
+	var found []archive
+	err := filepath.WalkDir(d.opts.Root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path != d.opts.Root {
+				d.opts.Logf("Scan error: %v", err)
+				return nil
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), ".gdelta") {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil // removed meanwhile
+		}
+		rel, err := filepath.Rel(d.opts.Root, path)
+		if err != nil {
+			return err
+		}
+		found = append(found, archive{rel: filepath.ToSlash(rel), path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	return found, err
+}
+
+// next forgets the archives no longer found, updates the gauges and picks
+// the archive to verify now, if any, other than those passed
+func (d *Daemon) next(found []archive, passed map[string]bool, now time.Time) (archive, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	present := make(map[string]bool, len(found))
+	var due []archive
+	var valid, invalid, pending int
+	var oldest time.Duration
+	for _, a := range found {
+		present[a.rel] = true
+		st := d.status.Archives[a.rel]
+		switch {
+		case st == nil:
+			pending++
+		case st.Valid:
+			valid++
+		default:
+			invalid++
+		}
+		if st != nil {
+			oldest = max(oldest, now.Sub(st.Verified))
+		}
+		if passed[a.rel] || now.Sub(a.modTime) < settleTime {
+			continue
+		}
+		if st == nil || st.Size != a.size || !st.ModTime.Equal(a.modTime) || now.Sub(st.Verified) >= d.opts.Interval {
+			due = append(due, a)
+		}
+	}
+	maps.DeleteFunc(d.status.Archives, func(rel string, _ *ArchiveStatus) bool { return !present[rel] })
+	metrics.DaemonArchives.Set(float64(valid), "valid")
+	metrics.DaemonArchives.Set(float64(invalid), "invalid")
+	metrics.DaemonArchives.Set(float64(pending), "pending")
+	metrics.DaemonOldestVerification.Set(oldest.Seconds())
+
+	if len(due) == 0 {
+		return archive{}, false
+	}
+	// New and changed archives first: their last verification doesn't count
+	lastVerified := func(a archive) time.Time {
+		st := d.status.Archives[a.rel]
+		if st == nil || st.Size != a.size || !st.ModTime.Equal(a.modTime) {
+			return time.Time{}
+		}
+		return st.Verified
+	}
+	return slices.MinFunc(due, func(x, y archive) int {
+		if c := lastVerified(x).Compare(lastVerified(y)); c != 0 {
+			return c
+		}
+		return cmp.Compare(x.rel, y.rel)
+	}), true
+}
+
+// verify checks a's data and records the outcome. It reports false when
+// ctx was canceled first; the verification is then abandoned.
+func (d *Daemon) verify(ctx context.Context, a archive) bool {
+	d.mu.Lock()
+	d.status.Current = a.rel
+	d.mu.Unlock()
+	if err := d.save(); err != nil {
+		d.opts.Logf("%v", err)
+	}
+
+	type outcome struct {
+		result *verify.Result
+		err    error
+	}
+	start := time.Now()
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := verify.Verify(&verify.Options{
+			InputPath:  a.path,
+			VerifyData: true,
+			MaxThreads: d.opts.MaxThreads,
+			ReadLimit:  d.opts.ReadLimit,
+			Quiet:      true,
+		}, nil)
+		done <- outcome{result, err}
+	}()
+
+	var o outcome
+	select {
+	case <-ctx.Done():
+		d.mu.Lock()
+		d.status.Current = ""
+		d.mu.Unlock()
+		return false
+	case o = <-done:
+	}
+
+	st := &ArchiveStatus{
+		Size:     a.size,
+		ModTime:  a.modTime,
+		Verified: time.Now(),
+		Duration: time.Since(start).Seconds(),
+		Valid:    o.err == nil && o.result.IsValid(),
+	}
+	if o.result != nil {
+		for _, err := range o.result.Errors {
+			st.Errors = append(st.Errors, err.Error())
+		}
+		for _, err := range o.result.Warnings {
+			st.Warnings = append(st.Warnings, err.Error())
+		}
+	}
+	if o.err != nil && len(st.Errors) == 0 {
+		st.Errors = append(st.Errors, o.err.Error())
+	}
+
+	d.mu.Lock()
+	d.status.Archives[a.rel] = st
+	d.status.Verifications++
+	d.status.Current = ""
+	d.mu.Unlock()
+
+	outcomeLabel := "valid"
+	if !st.Valid {
+		outcomeLabel = "invalid"
+	}
+	metrics.DaemonVerifications.Inc(outcomeLabel)
+	metrics.DaemonBytesVerified.Add(float64(a.size))
+	if st.Valid {
+		d.opts.Logf("OK %s (%v)", a.rel, time.Duration(st.Duration*float64(time.Second)).Round(time.Millisecond))
+	} else {
+		d.opts.Logf("INVALID %s: %s", a.rel, strings.Join(st.Errors, "; "))
+	}
+	return true
+}
+
+// save writes the status file atomically (temp file + rename)
+func (d *Daemon) save() error {
+	d.mu.Lock()
+	d.status.Updated = time.Now()
+	data, err := json.MarshalIndent(d.status, "", "  ")
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp := d.opts.StatusPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("save status: %w", err)
+	}
+	if err := os.Rename(tmp, d.opts.StatusPath); err != nil {
+		return fmt.Errorf("save status: %w", err)
+	}
+	return nil
+}
+
+// loadStatus reads the status file; a missing file means a first run
+func loadStatus(path string) (*Status, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Status{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read status: %w", err)
+	}
+	var status Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStatus, err)
+	}
+	return &status, nil
+}
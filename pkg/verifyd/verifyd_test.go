@@ -0,0 +1,147 @@
+// pkg/verifyd/verifyd_test.go
+package verifyd_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+	"github.com/creativeyann17/go-delta/pkg/verifyd"
+)
+
+// writeArchive compresses a directory with one file into path, modified
+// age ago
+func writeArchive(t *testing.T, path string, age time.Duration) {
+	t.Helper()
+	input := t.TempDir()
+	if err := os.WriteFile(filepath.Join(input, "a.txt"), []byte(strings.Repeat(path, 500)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := compress.Compress(&compress.Options{InputPath: input, OutputPath: path, Quiet: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-age)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPass(t *testing.T) {
+	root := t.TempDir()
+	writeArchive(t, filepath.Join(root, "mon.gdelta"), time.Hour)
+	writeArchive(t, filepath.Join(root, "old", "tue.gdelta"), time.Hour)
+	writeArchive(t, filepath.Join(root, "writing.gdelta"), 0) // too recent
+
+	// Damage the data of tue.gdelta, keeping its size and time
+	damaged := filepath.Join(root, "old", "tue.gdelta")
+	data, err := os.ReadFile(damaged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := len(data) * 2 / 3; i < len(data)*2/3+10; i++ {
+		data[i] ^= 0xFF
+	}
+	info, _ := os.Stat(damaged)
+	if err := os.WriteFile(damaged, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(damaged, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	statusPath := filepath.Join(t.TempDir(), "status.json")
+	opts := &verifyd.Options{Root: root, StatusPath: statusPath, Interval: time.Hour}
+	d, err := verifyd.New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := d.Pass(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("first pass verified %d archives (%v), want 2", n, err)
+	}
+	status := d.Status()
+	if got := status.Invalid(); !slices.Equal(got, []string{"old/tue.gdelta"}) {
+		t.Errorf("invalid archives %v", got)
+	}
+	if a := status.Archives["mon.gdelta"]; a == nil || !a.Valid || a.Verified.IsZero() {
+		t.Errorf("mon.gdelta: %+v", a)
+	}
+	if _, ok := status.Archives["writing.gdelta"]; ok {
+		t.Error("an archive modified a moment ago was verified")
+	}
+
+	// The status file carries the record over a restart
+	data, err = os.ReadFile(statusPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved verifyd.Status
+	if err := json.Unmarshal(data, &saved); err != nil || saved.Verifications != 2 || len(saved.Archives) != 2 {
+		t.Fatalf("status file: %v, %+v", err, saved)
+	}
+	d, err = verifyd.New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := d.Pass(context.Background()); err != nil || n != 0 {
+		t.Errorf("second pass verified %d archives (%v), want none due", n, err)
+	}
+
+	// A changed archive is due again, and a removed one forgotten
+	writeArchive(t, filepath.Join(root, "mon.gdelta"), 2*time.Hour)
+	if err := os.Remove(damaged); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := d.Pass(context.Background()); err != nil || n != 1 {
+		t.Errorf("third pass verified %d archives (%v), want 1", n, err)
+	}
+	if status := d.Status(); len(status.Archives) != 1 || len(status.Invalid()) != 0 {
+		t.Errorf("after the third pass: %+v", status.Archives)
+	}
+
+	// Once Interval has passed, every archive is verified once per pass
+	opts.Interval = time.Nanosecond
+	if n, err := d.Pass(context.Background()); err != nil || n != 1 {
+		t.Errorf("pass after the interval verified %d archives (%v), want 1", n, err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "a.gdelta")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	badStatus := filepath.Join(root, "status.json")
+	if err := os.WriteFile(badStatus, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts verifyd.Options
+		want error
+	}{
+		{"no root", verifyd.Options{StatusPath: "s.json"}, verifyd.ErrRootRequired},
+		{"no status", verifyd.Options{Root: root}, verifyd.ErrStatusRequired},
+		{"file root", verifyd.Options{Root: file, StatusPath: "s.json"}, verifyd.ErrRootNotDir},
+		{"bad status", verifyd.Options{Root: root, StatusPath: badStatus}, verifyd.ErrInvalidStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifyd.New(&tt.opts); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
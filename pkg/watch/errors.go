@@ -0,0 +1,19 @@
+// pkg/watch/errors.go
+package watch
+
+import "errors"
+
+var (
+	// ErrInputRequired is returned when input directory is not specified
+	ErrInputRequired = errors.New("input directory is required")
+
+	// ErrOutputRequired is returned when output directory is not specified
+	ErrOutputRequired = errors.New("output directory is required")
+
+	// ErrInputNotDir is returned when the input path is not a directory
+	ErrInputNotDir = errors.New("input path must be a directory")
+
+	// ErrUnsupportedFormat is returned for ZIP/XZ output (multi-part archives
+	// don't map onto one snapshot file)
+	ErrUnsupportedFormat = errors.New("watch mode only supports GDELTA archives")
+)
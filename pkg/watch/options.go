@@ -0,0 +1,65 @@
+// pkg/watch/options.go
+package watch
+
+import (
+	"os"
+	"time"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+)
+
+// Options configures watch mode
+type Options struct {
+	// InputPath is the directory to watch (required)
+	InputPath string
+
+	// OutputDir receives one snapshot archive per change batch (required)
+	OutputDir string
+
+	// Interval is the minimum time between two snapshots
+	// Default: 5m
+	Interval time.Duration
+
+	// Debounce is the quiet period required after the last change before a
+	// snapshot starts, so a burst of writes becomes one snapshot
+	// Default: 2s
+	Debounce time.Duration
+
+	// Compress is the template for each snapshot (level, threads, chunking,
+	// dictionary, gitignore...). InputPath, OutputPath, ModifiedSince,
+	// ChangedPaths and Quiet are set by the watcher.
+	Compress compress.Options
+
+	// Logf receives one line per watcher event (optional)
+	Logf func(format string, args ...interface{})
+}
+
+// Validate checks if options are valid
+func (o *Options) Validate() error {
+	if o.InputPath == "" {
+		return ErrInputRequired
+	}
+	if o.OutputDir == "" {
+		return ErrOutputRequired
+	}
+	info, err := os.Stat(o.InputPath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return ErrInputNotDir
+	}
+	if o.Compress.UseZipFormat || o.Compress.UseXzFormat || o.Compress.UseTarGzFormat || o.Compress.Use7zFormat {
+		return ErrUnsupportedFormat
+	}
+	if o.Interval <= 0 {
+		o.Interval = 5 * time.Minute
+	}
+	if o.Debounce <= 0 {
+		o.Debounce = 2 * time.Second
+	}
+	if o.Logf == nil {
+		o.Logf = func(string, ...interface{}) {}
+	}
+	return nil
+}
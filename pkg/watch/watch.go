@@ -0,0 +1,329 @@
+// pkg/watch/watch.go
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/creativeyann17/go-delta/pkg/compress"
+)
+
+// Snapshot describes one archive written by the watcher
+type Snapshot struct {
+	Path           string    `json:"path"`
+	Time           time.Time `json:"time"`
+	Full           bool      `json:"full"` // false = only files changed since the previous snapshot
+	Files          int       `json:"files"`
+	OriginalSize   uint64    `json:"original_size"`
+	CompressedSize uint64    `json:"compressed_size"`
+	Errors         int       `json:"errors"`
+}
+
+// Status is a point-in-time view of the watcher, served by StatusHandler
+type Status struct {
+	InputPath      string    `json:"input"`
+	OutputDir      string    `json:"output"`
+	PendingChanges int       `json:"pending_changes"`
+	LastChange     time.Time `json:"last_change,omitempty"`
+	Running        bool      `json:"snapshot_running"`
+	Snapshots      int       `json:"snapshots"`
+	LastSnapshot   *Snapshot `json:"last_snapshot,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+}
+
+// Watcher turns filesystem changes under InputPath into snapshot archives.
+// The first snapshot is full; each later one holds only files modified since
+// the previous successful snapshot started, and the files created or
+// written meanwhile whatever their modification time (moved in with it
+// kept). Lost events make the next snapshot full. Deletions are not recorded.
+type Watcher struct {
+	opts *Options
+
+	mu      sync.Mutex
+	status  Status
+	since   time.Time       // start time of the last successful snapshot
+	changed map[string]bool // paths created or written since the last snapshot started
+	lost    bool            // events were lost since the last snapshot started
+}
+
+// New creates a watcher after validating its options
+func New(opts *Options) (*Watcher, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Surface compression option errors now rather than at the first snapshot
+	probe := opts.Compress
+	probe.InputPath = opts.InputPath
+	if err := probe.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Watcher{
+		opts: opts,
+		status: Status{
+			InputPath: opts.InputPath,
+			OutputDir: opts.OutputDir,
+		},
+	}, nil
+}
+
+// Status returns a copy of the current watcher state
+func (w *Watcher) Status() Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := w.status
+	if s.LastSnapshot != nil {
+		last := *s.LastSnapshot
+		s.LastSnapshot = &last
+	}
+	return s
+}
+
+// StatusHandler serves Status as JSON
+func (w *Watcher) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(w.Status())
+	})
+}
+
+// Run takes a full snapshot, then watches for changes until ctx is canceled.
+// A snapshot still running at shutdown is canceled and its partial archive
+// removed; Run returns nil on a clean shutdown.
+func (w *Watcher) Run(ctx context.Context) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher: %w", err)
+	}
+	defer fw.Close()
+
+	if err := w.addRecursive(fw, w.opts.InputPath); err != nil {
+		return err
+	}
+	w.opts.Logf("Watching %s", w.opts.InputPath)
+
+	// The initial snapshot counts as a pending change
+	w.mu.Lock()
+	w.status.PendingChanges = 1
+	w.mu.Unlock()
+
+	var lastStart time.Time
+	var done chan struct{}
+
+	// Poll often enough to honor Debounce without spinning
+	tick := w.opts.Debounce / 2
+	if tick > time.Second {
+		tick = time.Second
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if done != nil {
+				w.opts.Logf("Shutting down, canceling running snapshot...")
+				<-done
+			}
+			return nil
+
+		case event, ok := <-fw.Events:
+			if !ok {
+				return nil
+			}
+			w.handleEvent(fw, event)
+
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return nil
+			}
+			// Overflow means events were lost: assume something changed,
+			// anywhere
+			w.mu.Lock()
+			w.status.LastError = err.Error()
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				w.lost = true
+				w.status.PendingChanges++
+				w.status.LastChange = time.Now()
+			}
+			w.mu.Unlock()
+			w.opts.Logf("Watch error: %v", err)
+
+		case <-done:
+			done = nil
+
+		case now := <-ticker.C:
+			if done != nil || !w.due(now, lastStart) {
+				continue
+			}
+			lastStart = now
+			done = make(chan struct{})
+			go func(done chan struct{}) {
+				defer close(done)
+				w.snapshot(ctx, now)
+			}(done)
+		}
+	}
+}
+
+// due reports whether pending changes have settled and Interval has elapsed
+func (w *Watcher) due(now, lastStart time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status.PendingChanges == 0 {
+		return false
+	}
+	if !lastStart.IsZero() && now.Sub(lastStart) < w.opts.Interval {
+		return false
+	}
+	return now.Sub(w.status.LastChange) >= w.opts.Debounce
+}
+
+// handleEvent records a change and starts watching new directories
+func (w *Watcher) handleEvent(fw *fsnotify.Watcher, event fsnotify.Event) {
+	if event.Op == fsnotify.Chmod || w.inOutputDir(event.Name) {
+		return
+	}
+
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := w.addRecursive(fw, event.Name); err != nil {
+				w.opts.Logf("Watch error: %v", err)
+			}
+		}
+	}
+
+	w.mu.Lock()
+	if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+		if w.changed == nil {
+			w.changed = make(map[string]bool)
+		}
+		w.changed[event.Name] = true
+	}
+	w.status.PendingChanges++
+	w.status.LastChange = time.Now()
+	w.mu.Unlock()
+}
+
+// addRecursive watches root and every directory below it (fsnotify watches
+// are not recursive)
+func (w *Watcher) addRecursive(fw *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable or vanished subtree: skip it, keep watching the rest
+			if path != root {
+				w.opts.Logf("Watch error: %v", err)
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if w.inOutputDir(path) {
+			return filepath.SkipDir
+		}
+		if err := fw.Add(path); err != nil {
+			return fmt.Errorf("watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// inOutputDir reports whether path is inside the snapshot directory, so
+// writing a snapshot under the watched tree doesn't trigger the next one
+func (w *Watcher) inOutputDir(path string) bool {
+	out, err1 := filepath.Abs(w.opts.OutputDir)
+	p, err2 := filepath.Abs(path)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return p == out || strings.HasPrefix(p, out+string(filepath.Separator))
+}
+
+// snapshot writes one archive of everything modified since the previous
+// successful snapshot, or created or written according to the events
+func (w *Watcher) snapshot(ctx context.Context, start time.Time) {
+	w.mu.Lock()
+	w.status.Running = true
+	pending := w.status.PendingChanges
+	w.status.PendingChanges = 0
+	changed, lost := w.changed, w.lost
+	w.changed, w.lost = nil, false
+	since := w.since
+	if lost {
+		since = time.Time{}
+	}
+	w.mu.Unlock()
+
+	full := since.IsZero()
+	kind := "incr"
+	if full {
+		kind = "full"
+	}
+	name := fmt.Sprintf("snapshot-%s-%s.gdelta", start.UTC().Format("20060102T150405.000Z"), kind)
+
+	opts := w.opts.Compress
+	opts.InputPath = w.opts.InputPath
+	opts.OutputPath = filepath.Join(w.opts.OutputDir, name)
+	opts.ModifiedSince = since
+	opts.ChangedPaths = slices.Collect(maps.Keys(changed))
+	opts.Quiet = true
+	opts.Verbose = false
+
+	result, err := compress.CompressContext(ctx, &opts, nil)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.status.Running = false
+
+	switch {
+	case errors.Is(err, compress.ErrNoFiles):
+		// Only deletions (or touched-then-restored files): nothing to store
+		w.since = start
+		w.opts.Logf("No modified files since last snapshot, skipped")
+	case err != nil:
+		// Keep the changes pending so the next tick retries them
+		w.status.PendingChanges += pending
+		w.lost = w.lost || lost
+		if w.changed == nil {
+			w.changed = changed
+		} else {
+			maps.Copy(w.changed, changed)
+		}
+		if ctx.Err() == nil {
+			w.status.LastError = err.Error()
+			w.opts.Logf("Snapshot failed: %v", err)
+		}
+	default:
+		w.since = start
+		snap := &Snapshot{
+			Path:           opts.OutputPath,
+			Time:           start,
+			Full:           full,
+			Files:          result.FilesProcessed,
+			OriginalSize:   result.OriginalSize,
+			CompressedSize: result.CompressedSize,
+			Errors:         len(result.Errors),
+		}
+		w.status.LastSnapshot = snap
+		w.status.Snapshots++
+		w.opts.Logf("Snapshot %s: %d files, %s -> %s",
+			filepath.Base(snap.Path), snap.Files,
+			compress.FormatSize(snap.OriginalSize), compress.FormatSize(snap.CompressedSize))
+	}
+}
@@ -0,0 +1,173 @@
+// pkg/watch/watch_test.go
+package watch_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeyann17/go-delta/pkg/verify"
+	"github.com/creativeyann17/go-delta/pkg/watch"
+)
+
+// waitFor polls cond until it holds or the deadline passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+// snapshotFiles returns the paths stored in a snapshot
+func snapshotFiles(t *testing.T, path string) []string {
+	t.Helper()
+	result, err := verify.Verify(&verify.Options{InputPath: path, Quiet: true}, nil)
+	if err != nil {
+		t.Fatalf("verify snapshot: %v", err)
+	}
+	var got []string
+	for _, f := range result.Files {
+		got = append(got, filepath.ToSlash(f.Path))
+	}
+	return got
+}
+
+func TestWatchIncrementalSnapshots(t *testing.T) {
+	inputDir := t.TempDir()
+	// Snapshots inside the watched tree must not trigger new snapshots
+	outputDir := filepath.Join(inputDir, ".snapshots")
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(inputDir, name), []byte("initial "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := watch.New(&watch.Options{
+		InputPath: inputDir,
+		OutputDir: outputDir,
+		Interval:  50 * time.Millisecond,
+		Debounce:  50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("new watcher: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- w.Run(ctx) }()
+
+	waitFor(t, "full snapshot", func() bool { return w.Status().Snapshots == 1 })
+	full := w.Status().LastSnapshot
+	if !full.Full || full.Files != 2 {
+		t.Fatalf("unexpected first snapshot: %+v", full)
+	}
+
+	// Let mtimes move past the snapshot start, then change one file and add
+	// one in a new directory
+	time.Sleep(20 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(inputDir, "a.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(inputDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(inputDir, "sub", "c.txt"), []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, "incremental snapshot", func() bool {
+		s := w.Status()
+		return s.Snapshots >= 2 && s.PendingChanges == 0 && !s.Running
+	})
+	incr := w.Status().LastSnapshot
+	if incr.Full {
+		t.Errorf("second snapshot should be incremental")
+	}
+
+	got := snapshotFiles(t, incr.Path)
+	if strings.Join(got, ",") != "a.txt,sub/c.txt" && strings.Join(got, ",") != "sub/c.txt,a.txt" {
+		t.Errorf("incremental snapshot files = %v, want a.txt and sub/c.txt", got)
+	}
+
+	// Status endpoint
+	rec := httptest.NewRecorder()
+	w.StatusHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/status", nil))
+	if !strings.Contains(rec.Body.String(), `"snapshots":`) {
+		t.Errorf("unexpected status body: %s", rec.Body.String())
+	}
+
+	cancel()
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Errorf("run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not shut down")
+	}
+}
+
+func TestWatchMovedInFiles(t *testing.T) {
+	inputDir := t.TempDir()
+	outputDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(inputDir, "a.txt"), []byte("initial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := watch.New(&watch.Options{
+		InputPath: inputDir,
+		OutputDir: outputDir,
+		Interval:  50 * time.Millisecond,
+		Debounce:  50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("new watcher: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+	waitFor(t, "full snapshot", func() bool { return w.Status().Snapshots == 1 })
+
+	// Files moved in keep their old modification time, like mv or rsync -a
+	old := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	outside := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(outside, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"moved.txt", "dir/inner.txt"} {
+		path := filepath.Join(outside, name)
+		if err := os.WriteFile(path, []byte("old content of "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"moved.txt", "dir"} {
+		if err := os.Rename(filepath.Join(outside, name), filepath.Join(inputDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitFor(t, "incremental snapshot", func() bool {
+		s := w.Status()
+		return s.Snapshots >= 2 && s.PendingChanges == 0 && !s.Running
+	})
+	got := snapshotFiles(t, w.Status().LastSnapshot.Path)
+	slices.Sort(got)
+	if strings.Join(got, ",") != "dir/inner.txt,moved.txt" {
+		t.Errorf("incremental snapshot files = %v, want dir/inner.txt and moved.txt", got)
+	}
+}